@@ -3,22 +3,33 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"maps"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/harperreed/health/internal/config"
 	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/timeparse"
 	"github.com/spf13/cobra"
 )
 
 var (
-	addAt    string
-	addNotes string
+	addAt       string
+	addNotes    string
+	addLocation string
+	addMeta     []string
+	addForce    bool
 )
 
 var addCmd = &cobra.Command{
-	Use:     "add <type> <value> [value2]",
+	Use:     "add <type> <value>[unit] [value2]",
 	Aliases: []string{"a"},
 	Short:   "Add a health metric",
 	Long: `Add a health metric to your personal health log.
@@ -32,11 +43,17 @@ METRIC TYPES:
     heart_rate     Resting heart rate in bpm
     hrv            Heart rate variability in ms
     temperature    Body temperature in °C
+    temp_deviation Temperature deviation from baseline in °C
+    readiness      Readiness score (0-100)
 
   Activity:
     steps          Daily step count
     sleep_hours    Hours of sleep
+    deep_sleep     Hours of deep sleep
+    rem_sleep      Hours of REM sleep
+    light_sleep    Hours of light sleep
     active_calories Calories burned through activity
+    vo2max         Estimated VO2max in ml/kg/min (see 'health stats')
 
   Nutrition:
     water          Water intake in ml
@@ -53,6 +70,11 @@ METRIC TYPES:
     focus          Focus/concentration rating
     meditation     Meditation duration in minutes
 
+  Environment:
+    aqi            Air quality index (US AQI)
+    pollen         Pollen count in grains/m³
+    ambient_temp   Outdoor temperature in °C
+
 EXAMPLES:
 
   health add weight 82.5                    # Log weight
@@ -61,40 +83,126 @@ EXAMPLES:
   health add mood 7 --notes "Great day!"    # Mood with notes
   health add steps 10432                    # Daily steps
   health add sleep_hours 7.5                # Sleep duration
+  health add weight 81.9 --location hotel   # Tag where it was measured
+  health add water +250                     # Add a glass to today's total
+  health add weight 180lb                   # Converted to kg
+  health add water +16oz                    # Converted to ml
+  health add sleep 7h30m                    # sleep_hours 7.5
+  echo 82.5 | health add weight -           # Value from stdin
+  echo "bp 120 80" | health add -           # Whole entry from stdin
+
+STDIN:
+
+  An argument of - is replaced by whatever is piped in, split on
+  whitespace, so scripts don't need to quote values. Use it for the value
+  or for the whole "<type> <value>" entry.
+
+UNITS:
+
+  Values are stored in the units listed above. A unit suffix converts
+  from another unit, with or without a space:
+    weight        lb, lbs, st, kg
+    water         oz (fluid), cup, l, ml
+    temperature   f, c (also ambient_temp)
+    temp_deviation  f, c (a difference, so 0.9f is 0.5)
+    sleep_hours   min, m, h (also deep_sleep, rem_sleep, light_sleep)
+    meditation    h, min, m
+    protein/carbs/fat  oz, g
+    calories      kj, cal, kcal (also active_calories)
+  Other metrics accept their own unit (e.g. 62bpm, 18%). Parts with
+  their own units add up, so 7h30m or "1h 15min" work for durations.
+  "sleep" is short for sleep_hours.
 
 TIMESTAMPS:
 
   Use --at to record a metric for a specific time:
     --at "2024-12-14 07:00"
     --at "2024-12-14T07:00"
-    --at "2024-12-14"`,
-	Args: cobra.MinimumNArgs(2),
+    --at "2024-12-14"
+
+RUNNING TOTALS:
+
+  water, calories, protein, carbs, and fat are cumulative: each entry adds
+  to the day's total. Prefix the value with + to log an increment and see
+  the running total for the day. Use 'health total' to check it later.
+
+LOCATIONS:
+
+  Use --location with a registered name (see 'health location') or
+  raw coordinates as "lat,lon". During a trip (see 'health travel'),
+  untagged entries get the trip's destination and --at is read in the
+  trip's timezone.
+
+DUPLICATES:
+
+  An entry within 10 minutes of another of the same type (say, logged
+  from here and by an assistant) is refused; --force adds it anyway.
+  Set windows per type with "duplicate_windows" in config.json, e.g.
+  {"weight": "1h", "mood": "0"} ("0" turns the check off). Running
+  totals and blood pressure are never checked unless listed.
+
+ALERTS:
+
+  A warning is printed when the new value crosses a threshold set with
+  'health alert add' (for cumulative metrics, when the day's total does).`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		metricType := args[0]
+		ctx := cmd.Context()
+		args, err := expandStdinArgs(args, cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		if len(args) < 2 {
+			return fmt.Errorf("add needs a metric type and a value")
+		}
+		metricType := string(models.CanonicalMetricType(args[0]))
 
 		// Handle blood pressure special case
 		if metricType == "bp" {
 			if len(args) < 3 {
 				return fmt.Errorf("blood pressure requires two values: systolic and diastolic")
 			}
-			return addBloodPressure(args[1], args[2])
+			if err := addBloodPressure(ctx, args[1], args[2]); err != nil {
+				return err
+			}
+			rememberCommand(cmd, args)
+			return nil
 		}
 
 		// Validate metric type
 		if !models.IsValidMetricType(metricType) {
-			return fmt.Errorf("unknown metric type: %s\nValid types: weight, body_fat, bp_sys, bp_dia, heart_rate, hrv, temperature, steps, sleep_hours, active_calories, water, calories, protein, carbs, fat, mood, energy, stress, anxiety, focus, meditation", metricType)
+			return fmt.Errorf("unknown metric type: %s\nValid types: weight, body_fat, bp_sys, bp_dia, heart_rate, hrv, temperature, temp_deviation, readiness, steps, sleep_hours, deep_sleep, rem_sleep, light_sleep, active_calories, vo2max, water, calories, protein, carbs, fat, mood, energy, stress, anxiety, focus, meditation, aqi, pollen, ambient_temp", metricType)
 		}
 
-		value, err := strconv.ParseFloat(args[1], 64)
+		increment := strings.HasPrefix(args[1], "+")
+		if increment && !models.IsCumulative(models.MetricType(metricType)) {
+			return fmt.Errorf("%s is not a cumulative metric; +N only applies to %s",
+				metricType, joinMetricTypes(models.CumulativeMetricTypes))
+		}
+
+		// The unit may be attached ("180lb") or a separate argument ("180 lb"),
+		// and a duration may come in parts ("7h 30m")
+		text := strings.Join(args[1:], " ")
+		for _, a := range args[2:] {
+			if _, err := strconv.ParseFloat(a, 64); err == nil {
+				return fmt.Errorf("%s takes one value, got %s", metricType, text)
+			}
+		}
+		value, err := models.ParseValue(models.MetricType(metricType), text)
 		if err != nil {
-			return fmt.Errorf("invalid value: %s", args[1])
+			return err
 		}
 
-		m := models.NewMetric(models.MetricType(metricType), value)
+		meta, err := models.ParseMetadata(addMeta)
+		if err != nil {
+			return err
+		}
+		m := models.NewMetric(models.MetricType(metricType), value).WithSource(models.SourceManual)
+		m.Metadata = meta
 
 		// Handle --at flag
 		if addAt != "" {
-			t, err := parseTime(addAt)
+			t, err := parseEntryTime(ctx, addAt)
 			if err != nil {
 				return fmt.Errorf("invalid timestamp: %s", addAt)
 			}
@@ -106,7 +214,24 @@ TIMESTAMPS:
 			m.WithNotes(addNotes)
 		}
 
-		if err := repo.CreateMetric(m); err != nil {
+		// Handle --location flag
+		if addLocation != "" {
+			tag, err := resolveLocationTag(ctx, addLocation)
+			if err != nil {
+				return err
+			}
+			m.WithLocation(tag)
+		}
+		location, err := storage.TagFromTrip(ctx, repo, m.Location, m.RecordedAt)
+		if err != nil {
+			return err
+		}
+		m.Location = location
+
+		if err := checkDuplicate(ctx, m); err != nil {
+			return err
+		}
+		if err := repo.CreateMetric(ctx, m); err != nil {
 			return fmt.Errorf("failed to create metric: %w", err)
 		}
 
@@ -115,11 +240,52 @@ TIMESTAMPS:
 			color.New(color.Faint).Sprint(m.ID.String()[:8]),
 			m.Value, m.Unit)
 
+		if increment {
+			zone, err := entryZone(ctx)
+			if err != nil {
+				return err
+			}
+			day := m.RecordedAt.In(zone)
+			total, err := storage.DailyTotal(ctx, repo, m.MetricType, day)
+			if err != nil {
+				return fmt.Errorf("failed to total %s: %w", metricType, err)
+			}
+			fmt.Printf("  %s total: %s %s\n", dayLabel(day), formatAmount(total.Sum), m.Unit)
+		}
+
+		rememberCommand(cmd, args)
+		warnAlerts(ctx, m)
+		pushOnAdd(ctx, m)
 		return nil
 	},
 }
 
-func addBloodPressure(sysStr, diaStr string) error {
+// expandStdinArgs replaces a "-" argument with the whitespace-separated
+// words read from in. Only one argument may be "-".
+func expandStdinArgs(args []string, in io.Reader) ([]string, error) {
+	for i, arg := range args {
+		if arg != "-" {
+			continue
+		}
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return nil, fmt.Errorf("read stdin: %w", err)
+		}
+		words := strings.Fields(string(data))
+		if len(words) == 0 {
+			return nil, fmt.Errorf("no value on stdin")
+		}
+		rest := args[i+1:]
+		if slices.Contains(rest, "-") {
+			return nil, fmt.Errorf("only one argument can be read from stdin")
+		}
+		expanded := append(slices.Clone(args[:i]), words...)
+		return append(expanded, rest...), nil
+	}
+	return args, nil
+}
+
+func addBloodPressure(ctx context.Context, sysStr, diaStr string) error {
 	sys, err := strconv.ParseFloat(sysStr, 64)
 	if err != nil {
 		return fmt.Errorf("invalid systolic value: %s", sysStr)
@@ -129,11 +295,11 @@ func addBloodPressure(sysStr, diaStr string) error {
 		return fmt.Errorf("invalid diastolic value: %s", diaStr)
 	}
 
-	// Use same timestamp for both
+	// Both halves share a timestamp and reading ID
 	var recordedAt time.Time
 	if addAt != "" {
 		var err error
-		recordedAt, err = parseTime(addAt)
+		recordedAt, err = parseEntryTime(ctx, addAt)
 		if err != nil {
 			return fmt.Errorf("invalid timestamp: %s", addAt)
 		}
@@ -141,20 +307,39 @@ func addBloodPressure(sysStr, diaStr string) error {
 		recordedAt = time.Now()
 	}
 
-	mSys := models.NewMetric(models.MetricBPSys, sys).WithRecordedAt(recordedAt)
-	mDia := models.NewMetric(models.MetricBPDia, dia).WithRecordedAt(recordedAt)
+	meta, err := models.ParseMetadata(addMeta)
+	if err != nil {
+		return err
+	}
+	mSys, mDia := models.NewBloodPressure(sys, dia, recordedAt)
+	mSys.WithSource(models.SourceManual)
+	mDia.WithSource(models.SourceManual)
+	mSys.Metadata, mDia.Metadata = meta, maps.Clone(meta)
 
 	if addNotes != "" {
 		mSys.WithNotes(addNotes)
 		mDia.WithNotes(addNotes)
 	}
 
-	// Create both metrics
-	if err := repo.CreateMetric(mSys); err != nil {
-		return fmt.Errorf("failed to create bp_sys: %w", err)
+	if addLocation != "" {
+		tag, err := resolveLocationTag(ctx, addLocation)
+		if err != nil {
+			return err
+		}
+		mSys.WithLocation(tag)
+		mDia.WithLocation(tag)
+	}
+	location, err := storage.TagFromTrip(ctx, repo, mSys.Location, recordedAt)
+	if err != nil {
+		return err
+	}
+	mSys.Location, mDia.Location = location, location
+
+	if err := checkDuplicate(ctx, mSys); err != nil {
+		return err
 	}
-	if err := repo.CreateMetric(mDia); err != nil {
-		return fmt.Errorf("failed to create bp_dia: %w", err)
+	if err := storage.RecordBloodPressure(ctx, repo, mSys, mDia); err != nil {
+		return fmt.Errorf("failed to add blood pressure: %w", err)
 	}
 
 	color.Green("✓ Added blood pressure")
@@ -162,26 +347,47 @@ func addBloodPressure(sysStr, diaStr string) error {
 		color.New(color.Faint).Sprint(mSys.ID.String()[:8]),
 		sys, dia)
 
+	warnAlerts(ctx, mSys, mDia)
+	pushOnAdd(ctx, mSys, mDia)
 	return nil
 }
 
-func parseTime(s string) (time.Time, error) {
-	formats := []string{
-		"2006-01-02 15:04",
-		"2006-01-02T15:04",
-		"2006-01-02",
-		time.RFC3339,
+// checkDuplicate refuses a metric that looks like one already logged (see
+// duplicate_windows in config.json), unless --force is given.
+func checkDuplicate(ctx context.Context, m *models.Metric) error {
+	if addForce {
+		return nil
 	}
-	for _, f := range formats {
-		if t, err := time.Parse(f, s); err == nil {
-			return t, nil
-		}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	windows, err := cfg.Duplicates()
+	if err != nil {
+		return err
+	}
+	existing, err := storage.FindDuplicate(ctx, repo, windows, m)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return fmt.Errorf("%s; use --force to add it anyway", models.DuplicateWarning(existing, m))
 	}
-	return time.Time{}, fmt.Errorf("unrecognized time format")
+	return nil
+}
+
+// parseTime parses a --at timestamp: YYYY-MM-DD HH:MM, RFC3339, or a
+// phrase like "2 hours ago", "this morning", or "mon 7am".
+func parseTime(s string) (time.Time, error) {
+	return timeparse.Parse(s, time.Now())
 }
 
 func init() {
-	addCmd.Flags().StringVar(&addAt, "at", "", "timestamp (YYYY-MM-DD HH:MM)")
+	addCmd.Flags().StringVar(&addAt, "at", "", "when it was measured (YYYY-MM-DD HH:MM, \"2 hours ago\", \"yesterday 7am\")")
 	addCmd.Flags().StringVar(&addNotes, "notes", "", "notes for the metric")
+	addCmd.Flags().StringVar(&addLocation, "location", "", "location name or \"lat,lon\"")
+	addCmd.Flags().StringArrayVar(&addMeta, "meta", nil, "attach metadata as key=value (repeatable)")
+	addCmd.Flags().BoolVar(&addForce, "force", false, "add even if the same metric was just logged")
+	addCmd.ValidArgsFunction = completeFirstArg(addTypeCompletions)
 	rootCmd.AddCommand(addCmd)
 }
@@ -0,0 +1,105 @@
+// ABOUTME: CLI command repeating the last add, optionally with new values.
+// ABOUTME: Add commands record themselves in history.json; 'again' replays the latest.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/harperreed/health/internal/history"
+)
+
+// historyPath is the add-command history in the data directory.
+var historyPath string
+
+var againCmd = &cobra.Command{
+	Use:   "again [value...]",
+	Short: "Repeat the last add command",
+	Long: `Repeat the last 'health add' or 'health workout add', with the same
+notes, location, and other flags. Give new values to log a different
+reading of the same metric. --at is not repeated: the new entry is
+recorded now.
+
+EXAMPLES:
+
+  health add weight 82.5 --location home
+  health again 83.1          # weight 83.1 at home
+  health add bp 120 80
+  health again 118 79        # both values for blood pressure
+  health again               # exactly the same as last time`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		last, err := history.Last(historyPath)
+		if err != nil {
+			return err
+		}
+		if last == nil {
+			return fmt.Errorf("nothing to repeat yet: 'health again' repeats the last add command")
+		}
+
+		replay := last.Args
+		if len(args) > 0 {
+			if last.Command != "add" || len(last.Args) == 0 {
+				return fmt.Errorf("new values only apply to 'health add'; the last command was 'health %s'", last.Command)
+			}
+			replay = append([]string{last.Args[0]}, args...)
+		}
+
+		target, _, err := rootCmd.Find(strings.Fields(last.Command))
+		if err != nil || target.RunE == nil {
+			return fmt.Errorf("can't repeat 'health %s'", last.Command)
+		}
+		for name, values := range last.Flags {
+			for _, v := range values {
+				if err := target.Flags().Set(name, v); err != nil {
+					return fmt.Errorf("repeat --%s: %w", name, err)
+				}
+			}
+		}
+
+		// Asking to log it again is asking for what looks like a duplicate
+		if f := target.Flags().Lookup("force"); f != nil {
+			if err := f.Value.Set("true"); err != nil {
+				return err
+			}
+		}
+
+		color.New(color.Faint).Printf("health %s %s\n", last.Command, strings.Join(replay, " "))
+		target.SetContext(cmd.Context())
+		return target.RunE(target, replay)
+	},
+}
+
+// rememberCommand records a successful add so 'health again' can repeat
+// it. --at is left out, since a repeat is logged now. History is a
+// convenience; failing to write it never fails the command.
+func rememberCommand(cmd *cobra.Command, args []string) {
+	if historyPath == "" {
+		return
+	}
+	e := history.Entry{
+		Command: strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "),
+		Args:    args,
+		Flags:   map[string][]string{},
+		At:      time.Now(),
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if f.Name == "at" {
+			return
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			e.Flags[f.Name] = sv.GetSlice()
+		} else {
+			e.Flags[f.Name] = []string{f.Value.String()}
+		}
+	})
+	_ = history.Record(historyPath, e)
+}
+
+func init() {
+	rootCmd.AddCommand(againCmd)
+}
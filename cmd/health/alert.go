@@ -0,0 +1,218 @@
+// ABOUTME: CLI commands for metric threshold alerts ("bp_sys above 140").
+// ABOUTME: Thresholds live in config; 'health add' warns when a new value crosses one.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	alertAbove float64
+	alertBelow float64
+)
+
+var alertCmd = &cobra.Command{
+	Use:     "alert",
+	Aliases: []string{"alerts"},
+	Short:   "Threshold alerts on metrics",
+	Long: `Define thresholds that warn when a value crosses them.
+
+'health add' prints a warning when a new value is above or below a threshold.
+For cumulative metrics (water, calories, protein, carbs, fat) the day's running
+total is compared instead of the single entry. 'health alerts check' shows
+which alerts the latest values currently trip.
+
+Thresholds are stored in ~/.config/health/config.json. Adding a threshold to a
+metric that already has one updates it.
+
+EXAMPLES:
+
+  health alert add bp_sys --above 140
+  health alert add sleep_hours --below 6
+  health alert add heart_rate --below 45 --above 100
+  health alert list
+  health alerts check
+  health alert delete bp_sys`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return alertListCmd.RunE(cmd, args)
+	},
+}
+
+var alertAddCmd = &cobra.Command{
+	Use:   "add <type>",
+	Short: "Add or update a threshold",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !models.IsValidMetricType(args[0]) {
+			return fmt.Errorf("unknown metric type: %s", args[0])
+		}
+		aboveSet, belowSet := cmd.Flags().Changed("above"), cmd.Flags().Changed("below")
+		if !aboveSet && !belowSet {
+			return fmt.Errorf("--above or --below is required")
+		}
+		if aboveSet && belowSet && alertBelow >= alertAbove {
+			return fmt.Errorf("--below must be less than --above")
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		idx := -1
+		for i, a := range cfg.Alerts {
+			if a.Metric == args[0] {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			cfg.Alerts = append(cfg.Alerts, config.AlertConfig{Metric: args[0]})
+			idx = len(cfg.Alerts) - 1
+		}
+		if aboveSet {
+			above := alertAbove
+			cfg.Alerts[idx].Above = &above
+		}
+		if belowSet {
+			below := alertBelow
+			cfg.Alerts[idx].Below = &below
+		}
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+
+		color.Green("✓ Alert %s", cfg.Alerts[idx].Alert())
+		return nil
+	},
+}
+
+var alertListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List thresholds",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		if len(cfg.Alerts) == 0 {
+			fmt.Println("No alerts set.")
+			return nil
+		}
+		for _, a := range cfg.ThresholdAlerts() {
+			fmt.Println(a)
+		}
+		return nil
+	},
+}
+
+var alertDeleteCmd = &cobra.Command{
+	Use:     "delete <type>",
+	Aliases: []string{"rm"},
+	Short:   "Remove the thresholds on a metric",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		for i, a := range cfg.Alerts {
+			if a.Metric != args[0] {
+				continue
+			}
+			cfg.Alerts = append(cfg.Alerts[:i], cfg.Alerts[i+1:]...)
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("save config: %w", err)
+			}
+			color.Yellow("✗ Deleted alert %s", a.Alert())
+			return nil
+		}
+
+		return fmt.Errorf("no alert on %s", args[0])
+	},
+}
+
+var alertCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Show alerts tripped by the latest values",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		alerts, err := loadAlerts()
+		if err != nil {
+			return err
+		}
+		if len(alerts) == 0 {
+			fmt.Println("No alerts set.")
+			return nil
+		}
+
+		hits, err := storage.ActiveAlerts(cmd.Context(), repo, alerts, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to check alerts: %w", err)
+		}
+		if len(hits) == 0 {
+			color.Green("✓ No active alerts")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, h := range hits {
+			fmt.Printf("%s %s\n", color.RedString("⚠ %s", h), faint.Sprint(h.At.Local().Format("2006-01-02 15:04")))
+		}
+		return nil
+	},
+}
+
+// loadAlerts returns the configured thresholds.
+func loadAlerts() ([]models.Alert, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return cfg.ThresholdAlerts(), nil
+}
+
+// warnAlerts prints a warning for each threshold the new metrics cross. The
+// metrics are already saved, so problems here are reported, not returned.
+func warnAlerts(ctx context.Context, metrics ...*models.Metric) {
+	alerts, err := loadAlerts()
+	if err != nil {
+		color.Yellow("! couldn't check alerts: %v", err)
+		return
+	}
+	for _, m := range metrics {
+		hits, err := storage.CheckAlerts(ctx, repo, alerts, m)
+		if err != nil {
+			color.Yellow("! couldn't check alerts: %v", err)
+			return
+		}
+		for _, h := range hits {
+			color.Red("⚠ %s", h)
+		}
+	}
+}
+
+func init() {
+	alertAddCmd.Flags().Float64Var(&alertAbove, "above", 0, "warn when a value is above this")
+	alertAddCmd.Flags().Float64Var(&alertBelow, "below", 0, "warn when a value is below this")
+	alertAddCmd.ValidArgsFunction = completeFirstArg(metricTypeCompletions)
+
+	alertCmd.AddCommand(alertAddCmd)
+	alertCmd.AddCommand(alertListCmd)
+	alertCmd.AddCommand(alertDeleteCmd)
+	alertCmd.AddCommand(alertCheckCmd)
+	rootCmd.AddCommand(alertCmd)
+}
@@ -0,0 +1,246 @@
+// ABOUTME: CLI commands for doctor and other health appointments.
+// ABOUTME: Schedules visits, lists upcoming ones, and links visit summaries afterwards.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	apptDuration int
+	apptLocation string
+	apptReason   string
+	apptAll      bool
+	apptLimit    int
+	apptFile     string
+)
+
+var apptCmd = &cobra.Command{
+	Use:     "appt",
+	Aliases: []string{"appointment", "appointments"},
+	Short:   "Track doctor appointments",
+	Long: `Keep track of doctor visits and other health appointments.
+
+Run 'health appt' on its own to see upcoming appointments. After a visit,
+link what was discussed with 'health appt summary'. Use 'health export ics'
+to load appointments into a calendar app.
+
+Dates and times are read in local time.
+
+EXAMPLES:
+
+  health appt add "Dr. Lee" 2025-07-02 14:00
+  health appt add "Dental cleaning" 2025-08-10 09:30 --duration 60 --location "Main St Dental"
+  health appt add "Dr. Lee" 2025-07-02 14:00 --reason "annual physical"
+  health appt summary a1b2c3d4 "BP fine. Recheck cholesterol in 6 months."
+  health appt summary a1b2c3d4 --file visit-notes.md
+  health appt list --all
+  health export ics -o appointments.ics`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return apptListCmd.RunE(cmd, args)
+	},
+}
+
+var apptAddCmd = &cobra.Command{
+	Use:   "add <provider> <date> [time]",
+	Short: "Schedule an appointment",
+	Args:  cobra.RangeArgs(2, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		clock := ""
+		if len(args) == 3 {
+			clock = args[2]
+		}
+		at, err := parseAppointmentTime(args[1], clock)
+		if err != nil {
+			return err
+		}
+
+		a := models.NewAppointment(args[0], at)
+		if apptDuration > 0 {
+			a.WithDuration(apptDuration)
+		}
+		if apptLocation != "" {
+			a.WithLocation(apptLocation)
+		}
+		if apptReason != "" {
+			a.WithReason(apptReason)
+		}
+
+		if err := repo.CreateAppointment(cmd.Context(), a); err != nil {
+			return fmt.Errorf("failed to add appointment: %w", err)
+		}
+
+		color.Green("✓ Added appointment")
+		fmt.Printf("  %s %s\n", color.New(color.Faint).Sprint(a.ID.String()[:8]), formatAppointment(a))
+		return nil
+	},
+}
+
+var apptListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List upcoming appointments",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		var appts []*models.Appointment
+		var err error
+		if apptAll {
+			appts, err = repo.ListAppointments(ctx, storage.AppointmentFilter{Limit: apptLimit})
+		} else {
+			appts, err = storage.UpcomingAppointments(ctx, repo, time.Now(), apptLimit)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list appointments: %w", err)
+		}
+
+		if len(appts) == 0 {
+			if apptAll {
+				fmt.Println("No appointments found.")
+			} else {
+				fmt.Println("No upcoming appointments.")
+			}
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, a := range appts {
+			summary := ""
+			if a.Summary != nil {
+				summary = faint.Sprint("  📝")
+			}
+			fmt.Printf("%s %s%s\n", faint.Sprint(a.ID.String()[:8]), formatAppointment(a), summary)
+		}
+		return nil
+	},
+}
+
+var apptShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show an appointment and its visit summary",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := repo.GetAppointment(cmd.Context(), args[0])
+		if err != nil {
+			return fmt.Errorf("appointment not found: %w", err)
+		}
+
+		faint := color.New(color.Faint)
+		fmt.Printf("%s %s\n", faint.Sprint(a.ID.String()[:8]), formatAppointment(a))
+		if a.DurationMinutes != nil {
+			fmt.Printf("  Duration: %d min\n", *a.DurationMinutes)
+		}
+		if a.Location != nil {
+			fmt.Printf("  Location: %s\n", *a.Location)
+		}
+		if a.Reason != nil {
+			fmt.Printf("  Reason:   %s\n", *a.Reason)
+		}
+		if a.Summary != nil {
+			fmt.Printf("\n%s\n", *a.Summary)
+		}
+		return nil
+	},
+}
+
+var apptSummaryCmd = &cobra.Command{
+	Use:   "summary <id> [text]",
+	Short: "Link a visit summary to an appointment",
+	Long: `Link notes from the visit to an appointment, replacing any earlier
+summary. Pass the text as an argument or read it from a file with --file.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var summary string
+		switch {
+		case apptFile != "" && len(args) == 2:
+			return fmt.Errorf("pass the summary as text or --file, not both")
+		case apptFile != "":
+			data, err := os.ReadFile(apptFile)
+			if err != nil {
+				return fmt.Errorf("failed to read summary: %w", err)
+			}
+			summary = string(data)
+		case len(args) == 2:
+			summary = args[1]
+		default:
+			return fmt.Errorf("summary text or --file is required")
+		}
+		summary = strings.TrimSpace(summary)
+		if summary == "" {
+			return fmt.Errorf("summary is empty")
+		}
+
+		if err := repo.SetAppointmentSummary(cmd.Context(), args[0], summary); err != nil {
+			return fmt.Errorf("failed to save summary: %w", err)
+		}
+		color.Green("✓ Linked visit summary to %s", args[0])
+		return nil
+	},
+}
+
+var apptDeleteCmd = &cobra.Command{
+	Use:     "delete <id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete an appointment",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := repo.DeleteAppointment(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("failed to delete appointment: %w", err)
+		}
+		color.Yellow("✗ Deleted appointment %s", args[0])
+		return nil
+	},
+}
+
+// parseAppointmentTime reads a date and optional HH:MM clock time in local time.
+func parseAppointmentTime(date, clock string) (time.Time, error) {
+	if clock == "" {
+		for _, f := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+			if at, err := time.ParseInLocation(f, date, time.Local); err == nil {
+				return at, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD [HH:MM])", date)
+	}
+
+	at, err := time.ParseInLocation("2006-01-02 15:04", date+" "+clock, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date or time: %s %s (use YYYY-MM-DD HH:MM)", date, clock)
+	}
+	return at, nil
+}
+
+// formatAppointment renders an appointment's time, provider, and location on one line.
+func formatAppointment(a *models.Appointment) string {
+	s := fmt.Sprintf("%s  %s", a.ScheduledAt.Local().Format("Mon 2006-01-02 15:04"), a.Provider)
+	if a.Location != nil {
+		s += color.New(color.Faint).Sprintf(" @%s", *a.Location)
+	}
+	return s
+}
+
+func init() {
+	apptAddCmd.Flags().IntVar(&apptDuration, "duration", 0, "length in minutes")
+	apptAddCmd.Flags().StringVar(&apptLocation, "location", "", "where the appointment is")
+	apptAddCmd.Flags().StringVar(&apptReason, "reason", "", "why the appointment was booked")
+	apptListCmd.Flags().BoolVar(&apptAll, "all", false, "include past appointments")
+	apptListCmd.Flags().IntVarP(&apptLimit, "limit", "n", 20, "max number of results")
+	apptSummaryCmd.Flags().StringVar(&apptFile, "file", "", "read the summary from a file")
+
+	apptCmd.AddCommand(apptAddCmd)
+	apptCmd.AddCommand(apptListCmd)
+	apptCmd.AddCommand(apptShowCmd)
+	apptCmd.AddCommand(apptSummaryCmd)
+	apptCmd.AddCommand(apptDeleteCmd)
+	rootCmd.AddCommand(apptCmd)
+}
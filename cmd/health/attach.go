@@ -0,0 +1,85 @@
+// ABOUTME: CLI command attaching photos and files to metrics.
+// ABOUTME: Attachments are copied under the data directory and referenced in metadata.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/attachment"
+	"github.com/harperreed/health/internal/config"
+)
+
+var attachRemove bool
+
+var attachCmd = &cobra.Command{
+	Use:   "attach <metric-id> <file>...",
+	Short: "Attach photos or files to a metric",
+	Long: `Attach files to a metric entry, such as a photo of a meal or of a skin
+condition, or a lab report.
+
+Each file is copied into attachments/metrics/<id>/ under the data
+directory and referenced in the metric's metadata, so exports carry the
+reference. A file with the same name replaces the earlier one.
+'health show <id>' lists a metric's attachments; deleting the metric
+deletes them.
+
+EXAMPLES:
+
+  health attach abc12345 lunch.jpg
+  health attach abc12345 rash-day1.jpg rash-day3.jpg
+  health attach abc12345 --remove lunch.jpg`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		m, err := repo.GetMetric(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("metric not found: %s", args[0])
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		dataDir := cfg.GetDataDir()
+		id := m.ID.String()
+
+		for _, file := range args[1:] {
+			if attachRemove {
+				name := path.Base(file)
+				rel, ok := m.Metadata[attachment.Key(name)]
+				if !ok {
+					return fmt.Errorf("no attachment named %s on %s", name, id[:8])
+				}
+				if err := repo.SetMetricMetadata(ctx, id, attachment.Key(name), ""); err != nil {
+					return err
+				}
+				if err := os.Remove(attachment.Path(dataDir, rel)); err != nil && !os.IsNotExist(err) {
+					return fmt.Errorf("remove attachment: %w", err)
+				}
+				color.Yellow("✗ Removed %s from %s", name, m.MetricType)
+				continue
+			}
+
+			rel, err := attachment.Save(dataDir, attachment.Metrics, id, file)
+			if err != nil {
+				return err
+			}
+			if err := repo.SetMetricMetadata(ctx, id, attachment.Key(path.Base(rel)), rel); err != nil {
+				return err
+			}
+			color.Green("✓ Attached %s to %s", path.Base(rel), m.MetricType)
+		}
+		return nil
+	},
+}
+
+func init() {
+	attachCmd.Flags().BoolVar(&attachRemove, "remove", false, "remove the named attachments instead")
+	attachCmd.ValidArgsFunction = completeFirstArg(metricIDCompletions)
+
+	rootCmd.AddCommand(attachCmd)
+}
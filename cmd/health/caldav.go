@@ -0,0 +1,159 @@
+// ABOUTME: CLI command pushing workouts and appointments to a CalDAV calendar.
+// ABOUTME: Reruns only send new or changed events and never duplicate or overwrite edits.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/caldav"
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// caldavPasswordEnv holds the CalDAV password so it stays out of shell history.
+const caldavPasswordEnv = "HEALTH_CALDAV_PASSWORD"
+
+var (
+	caldavURL        string
+	caldavUser       string
+	caldavSince      string
+	caldavForce      bool
+	caldavNoAppts    bool
+	caldavNoWorkouts bool
+)
+
+var caldavCmd = &cobra.Command{
+	Use:   "caldav",
+	Short: "Sync workouts and appointments to a CalDAV calendar",
+}
+
+var caldavPushCmd = &cobra.Command{
+	Use:   "push --url <calendar-url>",
+	Short: "Push workouts and appointments as calendar events",
+	Long: `Push workouts and appointments as events to a CalDAV calendar
+(Nextcloud, Fastmail, iCloud, Radicale, ...). --url is the calendar
+collection, e.g. https://cloud.example.com/remote.php/dav/calendars/me/training/.
+
+Each record becomes <id>.ics in the calendar, and what was pushed is kept
+in caldav.json in the data directory, so running this again (e.g. from
+cron) only sends new and changed events. Duplicates are prevented both
+ways: an event already on the server that this machine didn't push is
+left alone, and an event edited in your calendar app since the last push
+isn't overwritten. Both are reported as skipped; --force overwrites them.
+
+The password is read from $HEALTH_CALDAV_PASSWORD.
+
+EXAMPLES:
+
+  export HEALTH_CALDAV_PASSWORD=app-password
+  health caldav push --url https://cloud.example.com/remote.php/dav/calendars/me/training/ --user me
+  health caldav push --url ... --user me --since 2025-01-01 --no-appointments
+  0 * * * * health cron caldav push --url ... --user me`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if caldavURL == "" {
+			return fmt.Errorf("--url is required")
+		}
+		var since *time.Time
+		if caldavSince != "" {
+			t, err := time.ParseInLocation("2006-01-02", caldavSince, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", caldavSince)
+			}
+			since = &t
+		}
+
+		events, err := caldavEvents(cmd.Context(), repo, since, !caldavNoWorkouts, !caldavNoAppts)
+		if err != nil {
+			return err
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		statePath := caldav.StatePath(cfg.GetDataDir())
+		state, err := caldav.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		client := caldav.NewClient(caldavURL, caldavUser, os.Getenv(caldavPasswordEnv))
+		res, pushErr := client.Push(ctx, events, state.Calendar(caldavURL), caldavForce)
+		// Save whatever made it, even if the push stopped partway
+		if err := state.Save(statePath); err != nil {
+			return err
+		}
+
+		for _, s := range res.Skipped {
+			color.Yellow("⚠ Skipped %s: %v", s.Name, s.Err)
+		}
+		if pushErr != nil {
+			return pushErr
+		}
+		color.Green("✓ Pushed %d new, %d updated (%d unchanged)", res.Created, res.Updated, res.Unchanged)
+		if len(res.Skipped) > 0 {
+			fmt.Println("Use --force to overwrite skipped events.")
+		}
+		return nil
+	},
+}
+
+// caldavEvents renders the selected records as one calendar resource each.
+// DTSTAMP is the record's creation time so unchanged records render
+// identically from run to run.
+func caldavEvents(ctx context.Context, r storage.Repository, since *time.Time, workouts, appts bool) ([]caldav.Event, error) {
+	var events []caldav.Event
+
+	if workouts {
+		list, err := r.QueryWorkouts(ctx, storage.WorkoutFilter{Since: since})
+		if err != nil {
+			return nil, fmt.Errorf("list workouts: %w", err)
+		}
+		for _, w := range list {
+			full, err := r.GetWorkoutWithMetrics(ctx, w.ID.String())
+			if err != nil {
+				return nil, fmt.Errorf("load workout %s: %w", w.ID, err)
+			}
+			events = append(events, caldav.Event{
+				Name: w.ID.String() + ".ics",
+				Body: []byte(storage.WorkoutEventICS(full, w.CreatedAt)),
+			})
+		}
+	}
+
+	if appts {
+		list, err := r.ListAppointments(ctx, storage.AppointmentFilter{Since: since})
+		if err != nil {
+			return nil, fmt.Errorf("list appointments: %w", err)
+		}
+		for _, a := range list {
+			events = append(events, caldav.Event{
+				Name: a.ID.String() + ".ics",
+				Body: []byte(storage.AppointmentEventICS(a, a.CreatedAt)),
+			})
+		}
+	}
+	return events, nil
+}
+
+func init() {
+	caldavPushCmd.Flags().StringVar(&caldavURL, "url", "", "CalDAV calendar collection URL")
+	caldavPushCmd.Flags().StringVar(&caldavUser, "user", "", "CalDAV username (password from $"+caldavPasswordEnv+")")
+	caldavPushCmd.Flags().StringVar(&caldavSince, "since", "", "only push events since date (YYYY-MM-DD)")
+	caldavPushCmd.Flags().BoolVar(&caldavForce, "force", false, "overwrite events that exist or were edited on the server")
+	caldavPushCmd.Flags().BoolVar(&caldavNoAppts, "no-appointments", false, "push workouts only")
+	caldavPushCmd.Flags().BoolVar(&caldavNoWorkouts, "no-workouts", false, "push appointments only")
+	caldavCmd.AddCommand(caldavPushCmd)
+	rootCmd.AddCommand(caldavCmd)
+}
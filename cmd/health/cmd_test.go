@@ -4,14 +4,26 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/fatih/color"
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/environment"
+	"github.com/harperreed/health/internal/insights"
 	"github.com/harperreed/health/internal/models"
 	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/usage"
 	"github.com/spf13/cobra"
 )
 
@@ -46,6 +58,16 @@ func TestParseTime(t *testing.T) {
 			input:   "2025-01-31T08:30:00+05:00",
 			wantErr: false,
 		},
+		{
+			name:    "relative phrase",
+			input:   "2 hours ago",
+			wantErr: false,
+		},
+		{
+			name:    "weekday and clock",
+			input:   "mon 7am",
+			wantErr: false,
+		},
 		{
 			name:    "invalid format",
 			input:   "31-01-2025",
@@ -254,7 +276,7 @@ func TestDeleteCmdArgs(t *testing.T) {
 func TestWorkoutCmdSubcommands(t *testing.T) {
 	// Verify workout command has subcommands
 	subcommands := workoutCmd.Commands()
-	expectedSubcmds := []string{"add", "delete", "list", "metric", "show"}
+	expectedSubcmds := []string{"add", "delete", "list", "metric", "set", "show"}
 
 	cmdNames := make(map[string]bool)
 	for _, cmd := range subcommands {
@@ -477,7 +499,7 @@ func TestAddCmdWithDB(t *testing.T) {
 	}
 
 	// Verify metric was created
-	metrics, err := testDB.ListMetrics(nil, 0)
+	metrics, err := testDB.ListMetrics(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -504,7 +526,7 @@ func TestAddCmdWithNotes(t *testing.T) {
 		t.Errorf("add command with notes failed: %v", err)
 	}
 
-	metrics, err := testDB.ListMetrics(nil, 0)
+	metrics, err := testDB.ListMetrics(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -548,7 +570,7 @@ func TestAddCmdBloodPressure(t *testing.T) {
 	}
 
 	// Should create 2 metrics (bp_sys and bp_dia)
-	metrics, err := testDB.ListMetrics(nil, 0)
+	metrics, err := testDB.ListMetrics(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -635,6 +657,7 @@ func TestAddCmdInvalidTimestamp(t *testing.T) {
 }
 
 func TestListCmdWithDB(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
@@ -645,8 +668,8 @@ func TestListCmdWithDB(t *testing.T) {
 	// Create some metrics first
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
-	testDB.CreateMetric(m1)
-	testDB.CreateMetric(m2)
+	testDB.CreateMetric(ctx, m1)
+	testDB.CreateMetric(ctx, m2)
 
 	rootCmd.SetArgs([]string{"list"})
 	err := rootCmd.Execute()
@@ -657,6 +680,7 @@ func TestListCmdWithDB(t *testing.T) {
 }
 
 func TestListCmdWithTypeFilter(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
@@ -667,8 +691,8 @@ func TestListCmdWithTypeFilter(t *testing.T) {
 	// Create metrics of different types
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
-	testDB.CreateMetric(m1)
-	testDB.CreateMetric(m2)
+	testDB.CreateMetric(ctx, m1)
+	testDB.CreateMetric(ctx, m2)
 
 	rootCmd.SetArgs([]string{"list", "--type", "weight"})
 	err := rootCmd.Execute()
@@ -689,7 +713,7 @@ func TestListCmdWithLimit(t *testing.T) {
 	// Create multiple metrics
 	for i := 0; i < 10; i++ {
 		m := models.NewMetric(models.MetricWeight, float64(80+i))
-		testDB.CreateMetric(m)
+		testDB.CreateMetric(t.Context(), m)
 	}
 
 	rootCmd.SetArgs([]string{"list", "--limit", "5"})
@@ -736,12 +760,13 @@ func TestListCmdInvalidType(t *testing.T) {
 }
 
 func TestDeleteCmdWithDB(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create a metric to delete
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(ctx, m)
 
 	rootCmd.SetArgs([]string{"delete", m.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -751,7 +776,7 @@ func TestDeleteCmdWithDB(t *testing.T) {
 	}
 
 	// Verify metric was deleted
-	_, err = testDB.GetMetric(m.ID.String())
+	_, err = testDB.GetMetric(ctx, m.ID.String())
 	if err == nil {
 		t.Error("Expected metric to be deleted")
 	}
@@ -772,6 +797,94 @@ func TestDeleteCmdNotFound(t *testing.T) {
 	}
 }
 
+func TestDeleteBulkCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() {
+		deleteType, deleteSince, deleteBefore, deleteSource = "", "", "", ""
+		deleteWorkouts, deleteYes = false, false
+		rootCmd.SetIn(nil)
+		rootCmd.SetOut(nil)
+	}()
+
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 9, 0, 0, 0, time.Local) }
+	for d := 1; d <= 4; d++ {
+		testDB.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 5000).WithRecordedAt(day(d)).WithSource("fitbit"))
+	}
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 8000).WithRecordedAt(day(2)).WithSource(models.SourceManual))
+	keep := models.NewMetric(models.MetricWeight, 82).WithRecordedAt(day(1))
+	testDB.CreateMetric(ctx, keep)
+	sys, dia := models.NewBloodPressure(120, 80, day(1))
+	storage.RecordBloodPressure(ctx, testDB, sys, dia)
+	testDB.CreateWorkout(ctx, models.NewWorkout("run").WithStartedAt(day(2)).WithSource("strava"))
+
+	run := func(args ...string) (string, error) {
+		deleteType, deleteSince, deleteBefore, deleteSource = "", "", "", ""
+		deleteWorkouts, deleteYes = false, false
+		var out bytes.Buffer
+		rootCmd.SetOut(&out)
+		rootCmd.SetIn(strings.NewReader("y\n"))
+		rootCmd.SetArgs(args)
+		err := rootCmd.Execute()
+		return out.String(), err
+	}
+
+	// Without a terminal or --yes, it only counts
+	out, err := run("delete", "--type", "steps", "--source", "fitbit", "--since", "2025-03-02")
+	if err != nil {
+		t.Fatalf("delete without --yes failed: %v", err)
+	}
+	if !strings.Contains(out, "3 metrics match") || !strings.Contains(out, "--yes") {
+		t.Errorf("Expected a count of 3 and a --yes hint, got:\n%s", out)
+	}
+	steps := models.MetricSteps
+	if all, _ := testDB.ListMetrics(ctx, &steps, 0); len(all) != 5 {
+		t.Fatalf("Expected nothing deleted without --yes, got %d steps", len(all))
+	}
+
+	if _, err := run("delete", "--type", "steps", "--source", "fitbit", "--since", "2025-03-02", "--before", "2025-03-04", "--yes"); err != nil {
+		t.Fatalf("delete --yes failed: %v", err)
+	}
+	if all, _ := testDB.ListMetrics(ctx, &steps, 0); len(all) != 3 {
+		t.Errorf("Expected fitbit steps on Mar 2 and 3 deleted, leaving 3, got %d", len(all))
+	}
+
+	// Either half takes the whole blood pressure reading
+	if _, err := run("delete", "--type", "bp_dia", "--yes"); err != nil {
+		t.Fatalf("delete bp_dia failed: %v", err)
+	}
+	if _, err := testDB.GetMetric(ctx, sys.ID.String()); err == nil {
+		t.Error("Expected bp_sys deleted with its diastolic half")
+	}
+	if _, err := testDB.GetMetric(ctx, keep.ID.String()); err != nil {
+		t.Errorf("Expected weight kept: %v", err)
+	}
+
+	out, err = run("delete", "--workouts", "--source", "strava")
+	if err != nil || !strings.Contains(out, "1 workout matches") {
+		t.Errorf("Expected a count of one workout, got %v:\n%s", err, out)
+	}
+	if _, err := run("delete", "--workouts", "--source", "strava", "--yes"); err != nil {
+		t.Fatalf("delete --workouts failed: %v", err)
+	}
+	if workouts, _ := testDB.ListWorkouts(ctx, nil, 0); len(workouts) != 0 {
+		t.Errorf("Expected the strava workout deleted, got %d", len(workouts))
+	}
+
+	for _, args := range [][]string{
+		{"delete", keep.ID.String()[:8], "--type", "weight"},
+		{"delete"},
+		{"delete", "--workouts"},
+		{"delete", "--type", "nope", "--yes"},
+		{"delete", "--before", "someday", "--yes"},
+	} {
+		if _, err := run(args...); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
 func TestWorkoutAddCmdWithDB(t *testing.T) {
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
@@ -788,7 +901,7 @@ func TestWorkoutAddCmdWithDB(t *testing.T) {
 	}
 
 	// Verify workout was created
-	workouts, err := testDB.ListWorkouts(nil, 0)
+	workouts, err := testDB.ListWorkouts(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -812,7 +925,7 @@ func TestWorkoutAddCmdWithOptions(t *testing.T) {
 		t.Errorf("workout add command with options failed: %v", err)
 	}
 
-	workouts, err := testDB.ListWorkouts(nil, 0)
+	workouts, err := testDB.ListWorkouts(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -834,7 +947,7 @@ func TestWorkoutListCmdWithDB(t *testing.T) {
 
 	// Create some workouts
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "list"})
 	err := rootCmd.Execute()
@@ -861,16 +974,17 @@ func TestWorkoutListCmdEmpty(t *testing.T) {
 }
 
 func TestWorkoutShowCmdWithDB(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create a workout with metrics
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	testDB.AddWorkoutMetric(wm)
+	testDB.AddWorkoutMetric(ctx, wm)
 
 	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -896,12 +1010,13 @@ func TestWorkoutShowCmdNotFound(t *testing.T) {
 }
 
 func TestWorkoutMetricCmdWithDB(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create a workout first
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	rootCmd.SetArgs([]string{"workout", "metric", w.ID.String()[:8], "distance", "5.2", "km"})
 	err := rootCmd.Execute()
@@ -911,7 +1026,7 @@ func TestWorkoutMetricCmdWithDB(t *testing.T) {
 	}
 
 	// Verify metric was added
-	metrics, err := testDB.ListWorkoutMetrics(w.ID)
+	metrics, err := testDB.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		t.Fatalf("ListWorkoutMetrics failed: %v", err)
 	}
@@ -925,7 +1040,7 @@ func TestWorkoutMetricCmdInvalidValue(t *testing.T) {
 	defer cleanup()
 
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetOut(&bytes.Buffer{})
 	rootCmd.SetErr(&bytes.Buffer{})
@@ -954,12 +1069,13 @@ func TestWorkoutMetricCmdWorkoutNotFound(t *testing.T) {
 }
 
 func TestWorkoutDeleteCmdWithDB(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create a workout to delete
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	rootCmd.SetArgs([]string{"workout", "delete", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -969,7 +1085,7 @@ func TestWorkoutDeleteCmdWithDB(t *testing.T) {
 	}
 
 	// Verify workout was deleted
-	_, err = testDB.GetWorkout(w.ID.String())
+	_, err = testDB.GetWorkout(ctx, w.ID.String())
 	if err == nil {
 		t.Error("Expected workout to be deleted")
 	}
@@ -996,7 +1112,7 @@ func TestExportJSONCmdWithDB(t *testing.T) {
 
 	// Create some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"export", "json"})
 	err := rootCmd.Execute()
@@ -1006,13 +1122,41 @@ func TestExportJSONCmdWithDB(t *testing.T) {
 	}
 }
 
+func TestExportAnonymizeCmdWithDB(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { exportAnonymize, exportOutput = false, "" }()
+
+	m := models.NewMetric(models.MetricWeight, 82.5).WithNotes("after the party")
+	testDB.CreateMetric(t.Context(), m)
+
+	out := filepath.Join(t.TempDir(), "share.json")
+	rootCmd.SetArgs([]string{"export", "json", "--anonymize", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("export json --anonymize failed: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "82.5") || strings.Contains(string(data), "party") || strings.Contains(string(data), m.ID.String()) {
+		t.Errorf("Expected the value without notes or the original ID, got:\n%s", data)
+	}
+
+	exportOutput = ""
+	rootCmd.SetArgs([]string{"export", "yaml", "--anonymize"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected --anonymize to be refused for yaml")
+	}
+}
+
 func TestExportYAMLCmdWithDB(t *testing.T) {
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"export", "yaml"})
 	err := rootCmd.Execute()
@@ -1033,7 +1177,7 @@ func TestExportMarkdownCmdWithDB(t *testing.T) {
 
 	// Create some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"export", "markdown"})
 	err := rootCmd.Execute()
@@ -1074,7 +1218,7 @@ func TestExportToFile(t *testing.T) {
 
 	// Create some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	tmpFile := filepath.Join(t.TempDir(), "export.json")
 
@@ -1102,7 +1246,7 @@ func TestMigrateCmdToMarkdown(t *testing.T) {
 
 	// Create some test data to migrate
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	targetDir := filepath.Join(t.TempDir(), "md-export")
 
@@ -1134,6 +1278,47 @@ func TestMigrateCmdMissingToFlag(t *testing.T) {
 	}
 }
 
+func TestEphemeralFlag(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { ephemeral, migrateTo = false, "" }()
+	addAt, addNotes = "", ""
+
+	rootCmd.SetArgs([]string{"--ephemeral", "add", "weight", "82.5"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add --ephemeral failed: %v", err)
+	}
+	if _, ok := repo.(*storage.MemoryStore); !ok {
+		t.Errorf("Expected a memory store, got %T", repo)
+	}
+	if metrics, _ := testDB.ListMetrics(t.Context(), nil, 0); len(metrics) != 0 {
+		t.Errorf("Expected nothing written to the configured store, got %d metrics", len(metrics))
+	}
+	if _, err := os.Stat(historyPath); historyPath != "" || err == nil {
+		t.Errorf("Expected no add history recorded, got %q", historyPath)
+	}
+
+	rootCmd.SetArgs([]string{"migrate", "--to", "memory"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected migrating to the memory backend to fail")
+	}
+}
+
+func TestMigrateSchemaCmd(t *testing.T) {
+	_, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { migrateSchemaStatus = false }()
+
+	for _, args := range [][]string{{"migrate", "schema"}, {"migrate", "schema", "--status"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+}
+
 func TestMcpCmdExists(t *testing.T) {
 	// Verify mcp command is registered
 	found := false
@@ -1279,7 +1464,7 @@ func TestExportMarkdownWithSince(t *testing.T) {
 
 	// Create some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"export", "markdown", "--since", "2025-01-01"})
 	err := rootCmd.Execute()
@@ -1320,7 +1505,7 @@ func TestExportMarkdownWithType(t *testing.T) {
 
 	// Create some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"export", "markdown", "--type", "weight"})
 	err := rootCmd.Execute()
@@ -1408,7 +1593,7 @@ func TestListMetricsWithNotes(t *testing.T) {
 	// Create metric with notes
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("morning weight")
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"list"})
 	err := rootCmd.Execute()
@@ -1424,7 +1609,7 @@ func TestWorkoutShowWithNullableDuration(t *testing.T) {
 
 	// Create a workout without duration
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -1441,7 +1626,7 @@ func TestWorkoutShowWithNullableNotes(t *testing.T) {
 	// Create a workout without notes
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -1458,7 +1643,7 @@ func TestWorkoutShowWithNotes(t *testing.T) {
 	// Create a workout with notes
 	w := models.NewWorkout("run")
 	w.WithNotes("Morning run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -1469,15 +1654,16 @@ func TestWorkoutShowWithNotes(t *testing.T) {
 }
 
 func TestWorkoutMetricWithNullableUnit(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create a workout with metric that has no unit
 	w := models.NewWorkout("lift")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "sets", 4, "")
-	testDB.AddWorkoutMetric(wm)
+	testDB.AddWorkoutMetric(ctx, wm)
 
 	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -1488,12 +1674,13 @@ func TestWorkoutMetricWithNullableUnit(t *testing.T) {
 }
 
 func TestWorkoutMetricCmdWithUnit(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create a workout first
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	rootCmd.SetArgs([]string{"workout", "metric", w.ID.String()[:8], "distance", "5.2", "km"})
 	err := rootCmd.Execute()
@@ -1503,7 +1690,7 @@ func TestWorkoutMetricCmdWithUnit(t *testing.T) {
 	}
 
 	// Verify metric has unit
-	metrics, err := testDB.ListWorkoutMetrics(w.ID)
+	metrics, err := testDB.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		t.Fatalf("ListWorkoutMetrics failed: %v", err)
 	}
@@ -1521,7 +1708,7 @@ func TestWorkoutMetricCmdWithoutUnit(t *testing.T) {
 
 	// Create a workout first
 	w := models.NewWorkout("lift")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "metric", w.ID.String()[:8], "sets", "4"})
 	err := rootCmd.Execute()
@@ -1542,7 +1729,7 @@ func TestWorkoutListWithDuration(t *testing.T) {
 	// Create workout with duration
 	w := models.NewWorkout("run")
 	w.WithDuration(45)
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "list"})
 	err := rootCmd.Execute()
@@ -1553,6 +1740,7 @@ func TestWorkoutListWithDuration(t *testing.T) {
 }
 
 func TestWorkoutListWithTypeFilter(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
@@ -1563,8 +1751,8 @@ func TestWorkoutListWithTypeFilter(t *testing.T) {
 	// Create workouts of different types
 	w1 := models.NewWorkout("run")
 	w2 := models.NewWorkout("lift")
-	testDB.CreateWorkout(w1)
-	testDB.CreateWorkout(w2)
+	testDB.CreateWorkout(ctx, w1)
+	testDB.CreateWorkout(ctx, w2)
 
 	rootCmd.SetArgs([]string{"workout", "list", "--type", "run"})
 	err := rootCmd.Execute()
@@ -1729,6 +1917,7 @@ func TestImportCmdLongDescription(t *testing.T) {
 }
 
 func TestExportWithAllTypes(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
@@ -1741,18 +1930,18 @@ func TestExportWithAllTypes(t *testing.T) {
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
 	m3 := models.NewMetric(models.MetricSteps, 10000)
-	testDB.CreateMetric(m1)
-	testDB.CreateMetric(m2)
-	testDB.CreateMetric(m3)
+	testDB.CreateMetric(ctx, m1)
+	testDB.CreateMetric(ctx, m2)
+	testDB.CreateMetric(ctx, m3)
 
 	// Create workout with metrics
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
 	w.WithNotes("Test run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	testDB.AddWorkoutMetric(wm)
+	testDB.AddWorkoutMetric(ctx, wm)
 
 	rootCmd.SetArgs([]string{"export", "markdown"})
 	err := rootCmd.Execute()
@@ -1773,7 +1962,7 @@ func TestListWithAllNotesDisplayed(t *testing.T) {
 	// Create metrics with long notes that get truncated
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("This is a very long note that should be truncated in the display output because it exceeds the maximum length")
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"list"})
 	err := rootCmd.Execute()
@@ -1793,7 +1982,7 @@ func TestWorkoutListNoDuration(t *testing.T) {
 
 	// Create workout without duration
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(t.Context(), w)
 
 	rootCmd.SetArgs([]string{"workout", "list"})
 	err := rootCmd.Execute()
@@ -1814,7 +2003,7 @@ func TestListWithEmptyNotes(t *testing.T) {
 	// Create metric with empty notes
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("")
-	testDB.CreateMetric(m)
+	testDB.CreateMetric(t.Context(), m)
 
 	rootCmd.SetArgs([]string{"list"})
 	err := rootCmd.Execute()
@@ -1825,15 +2014,16 @@ func TestListWithEmptyNotes(t *testing.T) {
 }
 
 func TestWorkoutShowWithMetricsUnit(t *testing.T) {
+	ctx := t.Context()
 	testDB, cleanup := setupTestCLI(t)
 	defer cleanup()
 
 	// Create workout with metric that has unit
 	w := models.NewWorkout("run")
-	testDB.CreateWorkout(w)
+	testDB.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	testDB.AddWorkoutMetric(wm)
+	testDB.AddWorkoutMetric(ctx, wm)
 
 	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
 	err := rootCmd.Execute()
@@ -1865,3 +2055,2676 @@ func TestAllAddMetricTypes(t *testing.T) {
 		})
 	}
 }
+
+func TestParseSetSpec(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantSets  int
+		wantReps  int
+		wantLoad  float64
+		wantUnit  string
+		hasWeight bool
+		wantErr   bool
+	}{
+		{spec: "3x5@100kg", wantSets: 3, wantReps: 5, wantLoad: 100, wantUnit: "kg", hasWeight: true},
+		{spec: "5X3@225lb", wantSets: 5, wantReps: 3, wantLoad: 225, wantUnit: "lb", hasWeight: true},
+		{spec: "4x8@22.5", wantSets: 4, wantReps: 8, wantLoad: 22.5, hasWeight: true},
+		{spec: "3x10", wantSets: 3, wantReps: 10},
+		{spec: "12", wantSets: 1, wantReps: 12},
+		{spec: "0x5", wantErr: true},
+		{spec: "3xfive", wantErr: true},
+		{spec: "3x5@heavy", wantErr: true},
+		{spec: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			sets, reps, weight, unit, err := parseSetSpec(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSetSpec(%q) expected error", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSetSpec(%q) unexpected error: %v", tt.spec, err)
+			}
+			if sets != tt.wantSets || reps != tt.wantReps {
+				t.Errorf("parseSetSpec(%q) = %dx%d, want %dx%d", tt.spec, sets, reps, tt.wantSets, tt.wantReps)
+			}
+			if (weight != nil) != tt.hasWeight {
+				t.Fatalf("parseSetSpec(%q) weight presence = %v, want %v", tt.spec, weight != nil, tt.hasWeight)
+			}
+			if weight != nil && (*weight != tt.wantLoad || unit != tt.wantUnit) {
+				t.Errorf("parseSetSpec(%q) load = %v %q, want %v %q", tt.spec, *weight, unit, tt.wantLoad, tt.wantUnit)
+			}
+		})
+	}
+}
+
+func TestWorkoutSetCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+
+	w := models.NewWorkout("lift")
+	testDB.CreateWorkout(ctx, w)
+
+	rootCmd.SetArgs([]string{"workout", "set", w.ID.String()[:8], "bench", "3x5", "@100kg"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout set command failed: %v", err)
+	}
+
+	// A second invocation continues set numbering for the same exercise
+	rootCmd.SetArgs([]string{"workout", "set", w.ID.String()[:8], "bench", "1x3@105kg"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout set command failed: %v", err)
+	}
+
+	sets, err := testDB.ListWorkoutSets(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutSets failed: %v", err)
+	}
+	if len(sets) != 4 {
+		t.Fatalf("Expected 4 sets, got %d", len(sets))
+	}
+	last := sets[3]
+	if last.SetNumber != 4 || last.Reps != 3 {
+		t.Errorf("Expected set #4 of 3 reps, got #%d of %d", last.SetNumber, last.Reps)
+	}
+	if last.Weight == nil || *last.Weight != 105 {
+		t.Error("Expected last set weight 105")
+	}
+}
+
+func TestWorkoutSetCmdInvalidSpec(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+
+	w := models.NewWorkout("lift")
+	testDB.CreateWorkout(t.Context(), w)
+
+	rootCmd.SetOut(&bytes.Buffer{})
+	rootCmd.SetErr(&bytes.Buffer{})
+
+	rootCmd.SetArgs([]string{"workout", "set", w.ID.String()[:8], "bench", "lots"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for invalid set spec")
+	}
+}
+
+func TestRenderMarkdownPlain(t *testing.T) {
+	prev := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = prev }()
+
+	src := "# Leg day\nFelt **strong** and _fast_.\n- squats\n* lunges\n1. warmup\nSee [log](https://example.com) and `notes`."
+	want := "Leg day\nFelt strong and fast.\n• squats\n• lunges\n1. warmup\nSee log (https://example.com) and notes."
+
+	if got := renderMarkdown(src); got != want {
+		t.Errorf("renderMarkdown() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRenderMarkdownLeavesSnakeCase(t *testing.T) {
+	prev := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = prev }()
+
+	src := "use heart_rate_max and 2 * 3 * 4"
+	if got := renderMarkdown(src); got != src {
+		t.Errorf("renderMarkdown() = %q, want %q", got, src)
+	}
+}
+
+func TestFetchEnvironmentSkipsDuplicates(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	repo = testDB
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"current":{"time":1718000000,"us_aqi":30,"temperature_2m":18.5}}`))
+	}))
+	defer srv.Close()
+
+	client := environment.NewClient(srv.URL, srv.URL)
+	for i := 0; i < 2; i++ {
+		if err := fetchEnvironment(context.Background(), client, 1, 2); err != nil {
+			t.Fatalf("fetchEnvironment failed: %v", err)
+		}
+	}
+
+	aqi := models.MetricAQI
+	metrics, err := testDB.ListMetrics(ctx, &aqi, 10)
+	if err != nil {
+		t.Fatalf("ListMetrics failed: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Errorf("expected 1 aqi reading after two fetches, got %d", len(metrics))
+	}
+	temp := models.MetricAmbientTemp
+	metrics, _ = testDB.ListMetrics(ctx, &temp, 10)
+	if len(metrics) != 1 || metrics[0].Value != 18.5 {
+		t.Errorf("expected one ambient_temp of 18.5, got %+v", metrics)
+	}
+}
+
+func TestNewMetricsDedupesWithinBatch(t *testing.T) {
+	at := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	stored := models.NewMetric(models.MetricWeight, 80).WithRecordedAt(at)
+	batch := []*models.Metric{
+		models.NewMetric(models.MetricWeight, 80).WithRecordedAt(at),
+		models.NewMetric(models.MetricSteps, 900).WithRecordedAt(at),
+		models.NewMetric(models.MetricSteps, 900).WithRecordedAt(at.Add(300 * time.Millisecond)),
+		models.NewMetric(models.MetricSteps, 1200).WithRecordedAt(at.Add(time.Hour)),
+	}
+
+	fresh, skipped, err := newMetrics(batch, metricAtKey, func(m *models.Metric) (bool, error) {
+		return metricAtKey(m) == metricAtKey(stored), nil
+	})
+	if err != nil || skipped != 2 || len(fresh) != 2 || fresh[0] != batch[1] || fresh[1] != batch[3] {
+		t.Errorf("newMetrics = %v, %d, %v; want the first and last steps, 2 skipped", fresh, skipped, err)
+	}
+}
+
+func TestResolveSleepTimes(t *testing.T) {
+	day := time.Date(2024, 12, 14, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		bed      string
+		wake     string
+		wantBed  time.Time
+		wantWake time.Time
+		wantErr  bool
+	}{
+		{
+			name:     "overnight clock times",
+			bed:      "23:30",
+			wake:     "07:10",
+			wantBed:  time.Date(2024, 12, 13, 23, 30, 0, 0, time.UTC),
+			wantWake: time.Date(2024, 12, 14, 7, 10, 0, 0, time.UTC),
+		},
+		{
+			name:     "after midnight bed time",
+			bed:      "01:15",
+			wake:     "08:00",
+			wantBed:  time.Date(2024, 12, 14, 1, 15, 0, 0, time.UTC),
+			wantWake: time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "full bed timestamp with clock wake",
+			bed:      "2024-12-10 22:00",
+			wake:     "06:00",
+			wantBed:  time.Date(2024, 12, 10, 22, 0, 0, 0, time.UTC),
+			wantWake: time.Date(2024, 12, 11, 6, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "full timestamps",
+			bed:      "2024-12-10 22:00",
+			wake:     "2024-12-11 05:45",
+			wantBed:  time.Date(2024, 12, 10, 22, 0, 0, 0, time.UTC),
+			wantWake: time.Date(2024, 12, 11, 5, 45, 0, 0, time.UTC),
+		},
+		{name: "missing wake", bed: "23:00", wantErr: true},
+		{name: "wake before bed", bed: "2024-12-11 05:45", wake: "2024-12-10 22:00", wantErr: true},
+		{name: "garbage", bed: "late", wake: "07:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bed, wake, err := resolveSleepTimes(tt.bed, tt.wake, day)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bed.Equal(tt.wantBed) || !wake.Equal(tt.wantWake) {
+				t.Errorf("got %v → %v, want %v → %v", bed, wake, tt.wantBed, tt.wantWake)
+			}
+		})
+	}
+}
+
+func TestSleepAddCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { sleepBed, sleepWake, sleepDate, sleepNotes = "", "", "", "" }()
+
+	rootCmd.SetArgs([]string{"sleep", "add", "--bed", "23:30", "--wake", "07:00", "--date", "2024-12-14", "--notes", "solid"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("sleep add command failed: %v", err)
+	}
+
+	sessions, err := testDB.ListSleepSessions(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListSleepSessions failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 sleep session, got %d", len(sessions))
+	}
+	if sessions[0].Hours() != 7.5 {
+		t.Errorf("Expected 7.5 hours, got %v", sessions[0].Hours())
+	}
+
+	latest, err := testDB.GetLatestMetric(ctx, models.MetricSleepHours)
+	if err != nil {
+		t.Fatalf("Expected derived sleep_hours metric: %v", err)
+	}
+	if latest.Value != 7.5 {
+		t.Errorf("Expected sleep_hours 7.5, got %v", latest.Value)
+	}
+}
+
+func TestMedCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { medDose, medSchedule, medTakeDose = "", "daily", "" }()
+
+	rootCmd.SetArgs([]string{"med", "add", "Vitamin D", "--dose", "1000 IU", "--schedule", "daily"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("med add failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"med", "take", "vitamin-d", "--dose", "2000 IU"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("med take failed: %v", err)
+	}
+
+	m, err := testDB.GetMedication(ctx, "Vitamin D")
+	if err != nil {
+		t.Fatalf("GetMedication failed: %v", err)
+	}
+	intakes, err := testDB.ListMedicationIntakes(ctx, storage.IntakeFilter{MedicationID: &m.ID})
+	if err != nil {
+		t.Fatalf("ListMedicationIntakes failed: %v", err)
+	}
+	if len(intakes) != 1 || intakes[0].Dose == nil || *intakes[0].Dose != "2000 IU" {
+		t.Fatalf("Expected one intake with dose override, got %+v", intakes)
+	}
+
+	rootCmd.SetArgs([]string{"med", "history", "--days", "3"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("med history failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"med", "take", "nonexistent"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error taking unknown medication")
+	}
+}
+
+func TestMedAddRejectsUnknownSchedule(t *testing.T) {
+	_, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { medDose, medSchedule = "", "daily" }()
+
+	rootCmd.SetArgs([]string{"med", "add", "Melatonin", "--dose", "1 mg", "--schedule", "sometimes"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unknown schedule")
+	}
+}
+
+func TestFormatWeather(t *testing.T) {
+	w := models.NewWorkout("run")
+	metrics := []*models.WorkoutMetric{
+		models.NewWorkoutMetric(w.ID, environment.WeatherWindMetric, 12.5, "km/h"),
+		models.NewWorkoutMetric(w.ID, environment.WeatherTempMetric, 18.24, "°C"),
+		models.NewWorkoutMetric(w.ID, environment.WeatherHumidityMetric, 64, "%"),
+	}
+
+	want := "18.2 °C, 64% humidity, 12.5 km/h wind"
+	if got := formatWeather(metrics); got != want {
+		t.Errorf("formatWeather = %q, want %q", got, want)
+	}
+	if got := formatWeather(nil); got != "" {
+		t.Errorf("formatWeather(nil) = %q, want empty", got)
+	}
+}
+
+func TestLocationTaggingCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { addLocation, workoutLocation, workoutListLocation, listLocation = "", "", "", "" }()
+
+	rootCmd.SetArgs([]string{"location", "add", "gym", "--lat", "41.89", "--lon", "-87.62"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("location add failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"add", "weight", "82.5", "--location", "GYM"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add with location failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"workout", "add", "lift", "--location", "gym"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout add with location failed: %v", err)
+	}
+
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 1 || metrics[0].Location == nil || *metrics[0].Location != "gym" {
+		t.Errorf("Expected metric tagged with registered name, got %+v", metrics)
+	}
+	workouts, _ := testDB.ListWorkouts(ctx, nil, 0)
+	if len(workouts) != 1 || workouts[0].Location == nil || *workouts[0].Location != "gym" {
+		t.Errorf("Expected workout tagged gym, got %+v", workouts)
+	}
+
+	rootCmd.SetArgs([]string{"workout", "list", "--location", "gym"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout list --location failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"add", "weight", "81", "--location", "airport"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unregistered location")
+	}
+}
+
+func TestAddIncrementAndTotalCmdWithDB(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { totalDate = "" }()
+
+	for _, amount := range []string{"+250", "+500"} {
+		rootCmd.SetArgs([]string{"add", "water", amount})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("add water %s failed: %v", amount, err)
+		}
+	}
+
+	total, err := storage.DailyTotal(t.Context(), testDB, models.MetricWater, time.Now())
+	if err != nil {
+		t.Fatalf("DailyTotal failed: %v", err)
+	}
+	if total.Count != 2 || total.Sum != 750 {
+		t.Errorf("Expected 750 ml from 2 entries, got %+v", total)
+	}
+
+	rootCmd.SetArgs([]string{"total", "water"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("total water failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"add", "weight", "+1"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for increment on non-cumulative metric")
+	}
+	rootCmd.SetArgs([]string{"total", "weight"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error totalling non-cumulative metric")
+	}
+}
+
+func TestRemindCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { remindDaily, remindNotify = "", false }()
+
+	rootCmd.SetArgs([]string{"remind", "add", "Log weight", "--daily", "08:00"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remind add failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"remind", "add", "log weight", "--daily", "09:00"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for duplicate reminder")
+	}
+	rootCmd.SetArgs([]string{"remind", "add", "stretch", "--daily", "8am"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for invalid time")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if len(cfg.Reminders) != 1 || cfg.Reminders[0].Daily != "08:00" {
+		t.Fatalf("Expected one reminder saved to config, got %+v", cfg.Reminders)
+	}
+
+	// Adding starts the clock, so nothing is due until the next 08:00.
+	added, _ := testDB.GetReminderLastFired(ctx, "log-weight")
+	if added == nil {
+		t.Fatal("Expected remind add to record state")
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	later := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 8, 30, 0, 0, time.Local)
+	repo = testDB
+	if err := checkReminders(ctx, later); err != nil {
+		t.Fatalf("checkReminders failed: %v", err)
+	}
+	fired, _ := testDB.GetReminderLastFired(ctx, "log-weight")
+	if fired == nil || !fired.Equal(later.Truncate(time.Second)) {
+		t.Errorf("Expected reminder to fire at %v, got %v", later, fired)
+	}
+
+	rootCmd.SetArgs([]string{"remind", "delete", "log-weight"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remind delete failed: %v", err)
+	}
+	cfg, _ = config.Load()
+	if len(cfg.Reminders) != 0 {
+		t.Errorf("Expected reminder removed, got %+v", cfg.Reminders)
+	}
+}
+
+func TestRemindSnoozeQuietChannelsCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { remindDaily, remindNotify, remindChannels, remindQuietOff = "", false, nil, false }()
+
+	var pushed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := new(bytes.Buffer)
+		body.ReadFrom(r.Body)
+		pushed = append(pushed, body.String())
+	}))
+	defer server.Close()
+
+	rootCmd.SetArgs([]string{"remind", "add", "Log weight", "--daily", "08:00", "--channel", "sms"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for unknown channel")
+	}
+	remindChannels = nil
+	rootCmd.SetArgs([]string{"remind", "add", "Log weight", "--daily", "08:00", "--channel", "ntfy"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remind add failed: %v", err)
+	}
+	cfg, _ := config.Load()
+	cfg.Notify = &config.NotifyConfig{NtfyURL: server.URL + "/health"}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"remind", "snooze", "nothing", "2h"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error snoozing an unknown reminder")
+	}
+	rootCmd.SetArgs([]string{"remind", "snooze", "weight", "2h"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remind snooze failed: %v", err)
+	}
+	until, _ := testDB.GetReminderSnooze(ctx, "log-weight")
+	if until == nil || until.Before(time.Now().Add(119*time.Minute)) {
+		t.Fatalf("snooze = %v, want about 2h from now", until)
+	}
+
+	repo = testDB
+	if err := checkReminders(ctx, until.Add(-time.Minute)); err != nil {
+		t.Fatalf("checkReminders failed: %v", err)
+	}
+	if len(pushed) != 0 {
+		t.Errorf("Expected nothing pushed while snoozed, got %v", pushed)
+	}
+
+	// Quiet hours covering the end of the snooze hold it back too
+	cfg, _ = config.Load()
+	cfg.QuietHours = &config.QuietHoursConfig{
+		Start: until.Add(-time.Hour).Format("15:04"),
+		End:   until.Add(time.Hour).Format("15:04"),
+	}
+	cfg.Save()
+	checkReminders(ctx, *until)
+	if len(pushed) != 0 {
+		t.Errorf("Expected nothing pushed in quiet hours, got %v", pushed)
+	}
+
+	cfg, _ = config.Load()
+	cfg.QuietHours = nil
+	cfg.Save()
+	if err := checkReminders(ctx, *until); err != nil {
+		t.Fatalf("checkReminders failed: %v", err)
+	}
+	if len(pushed) != 1 || pushed[0] != "Log weight" {
+		t.Errorf("pushed = %v, want the reminder once after the snooze", pushed)
+	}
+	if left, _ := testDB.GetReminderSnooze(ctx, "log-weight"); left != nil {
+		t.Errorf("Expected firing to clear the snooze, got %v", left)
+	}
+
+	rootCmd.SetArgs([]string{"remind", "quiet", "22:00", "07:00"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remind quiet failed: %v", err)
+	}
+	if cfg, _ := config.Load(); cfg.QuietHours == nil || cfg.QuietHours.Start != "22:00" {
+		t.Errorf("quiet hours = %+v, want 22:00-07:00", cfg.QuietHours)
+	}
+	rootCmd.SetArgs([]string{"remind", "quiet", "--off"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("remind quiet --off failed: %v", err)
+	}
+	if cfg, _ := config.Load(); cfg.QuietHours != nil {
+		t.Errorf("Expected quiet hours cleared, got %+v", cfg.QuietHours)
+	}
+}
+
+func TestTravelCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { travelTZ, travelAt, travelNotes, addAt = "", "", "", "" }()
+
+	rootCmd.SetArgs([]string{"travel", "start", "Paris", "--tz", "Europe/Paris"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("travel start failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"travel", "start", "Rome"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error starting a second trip")
+	}
+	if _, err := testDB.GetLocation(ctx, "paris"); err != nil {
+		t.Error("Expected new destination to be registered as a location")
+	}
+
+	travelTZ = ""
+	rootCmd.SetArgs([]string{"add", "weight", "80.1", "--at", "2030-01-15 07:30"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add during trip failed: %v", err)
+	}
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 1 || metrics[0].Location == nil || *metrics[0].Location != "Paris" {
+		t.Fatalf("Expected metric tagged Paris, got %+v", metrics)
+	}
+	paris, _ := time.LoadLocation("Europe/Paris")
+	if want := time.Date(2030, 1, 15, 7, 30, 0, 0, paris); !metrics[0].RecordedAt.Equal(want) {
+		t.Errorf("Expected --at read in Paris time (%v), got %v", want, metrics[0].RecordedAt)
+	}
+
+	rootCmd.SetArgs([]string{"travel", "end"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("travel end failed: %v", err)
+	}
+	trips, _ := testDB.ListTrips(ctx, 0)
+	if len(trips) != 1 || trips[0].IsActive() {
+		t.Errorf("Expected one ended trip, got %+v", trips)
+	}
+	rootCmd.SetArgs([]string{"travel", "end"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error ending when not traveling")
+	}
+}
+
+func TestFastCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { fastTarget, fastAt, fastNotes = models.DefaultFastHours, "", "" }()
+
+	rootCmd.SetArgs([]string{"fast", "end"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error ending when not fasting")
+	}
+
+	rootCmd.SetArgs([]string{"fast", "start", "--target", "18", "--at", "20 hours ago"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("fast start failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"fast", "start"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error starting a second fast")
+	}
+	rootCmd.SetArgs([]string{"fast", "status"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("fast status failed: %v", err)
+	}
+
+	fastAt = ""
+	rootCmd.SetArgs([]string{"fast", "end", "--at", "1 hour ago"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("fast end failed: %v", err)
+	}
+	fasts, _ := testDB.ListFasts(ctx, 0)
+	if len(fasts) != 1 || fasts[0].IsActive() || fasts[0].TargetHours != 18 || fasts[0].Hours(time.Now()) != 19 {
+		t.Fatalf("Expected one ended 19 hour fast, got %+v", fasts)
+	}
+
+	for _, args := range [][]string{{"fast"}, {"fast", "list"}, {"fast", "delete", fasts[0].ID.String()[:8]}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+	}
+	if fasts, _ := testDB.ListFasts(ctx, 0); len(fasts) != 0 {
+		t.Errorf("Expected no fasts after delete, got %d", len(fasts))
+	}
+}
+
+func TestBloodPressureCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+
+	rootCmd.SetArgs([]string{"add", "bp", "120", "80"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add bp failed: %v", err)
+	}
+
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 2 || metrics[0].ReadingID == nil || metrics[1].ReadingID == nil ||
+		*metrics[0].ReadingID != *metrics[1].ReadingID {
+		t.Fatalf("Expected two linked bp metrics, got %+v", metrics)
+	}
+
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"delete", metrics[0].ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if remaining, _ := testDB.ListMetrics(ctx, nil, 0); len(remaining) != 0 {
+		t.Errorf("Expected deleting one half to remove the reading, %d left", len(remaining))
+	}
+}
+
+func TestDeriveCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { deriveUnit, listType = "", "" }()
+
+	rootCmd.SetArgs([]string{"derive", "add", "bmi", "weight / height^2"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for formula using an unset constant")
+	}
+
+	rootCmd.SetArgs([]string{"derive", "set", "height", "2"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("derive set failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"derive", "add", "bmi", "weight / height^2", "--unit", "kg/m²"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("derive add failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"derive", "add", "weight", "weight * 2"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for derived name shadowing a metric type")
+	}
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 80))
+
+	set, err := loadDerivedSet()
+	if err != nil || set == nil {
+		t.Fatalf("loadDerivedSet: %v", err)
+	}
+	values, err := set.FromRepo(ctx, testDB)
+	if err != nil {
+		t.Fatalf("FromRepo: %v", err)
+	}
+	if len(values) != 1 || values[0].Value != 20 || values[0].Unit != "kg/m²" {
+		t.Errorf("Expected bmi 20 kg/m², got %+v", values)
+	}
+
+	// Derived values show in list without being stored
+	rootCmd.SetArgs([]string{"list", "--type", "bmi"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("list --type bmi failed: %v", err)
+	}
+	stored, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(stored) != 1 {
+		t.Errorf("Expected only the weight stored, got %d metrics", len(stored))
+	}
+
+	rootCmd.SetArgs([]string{"derive", "delete", "bmi"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("derive delete failed: %v", err)
+	}
+	if set, _ := loadDerivedSet(); set != nil {
+		t.Errorf("Expected no derived metrics left, got %+v", set.Formulas)
+	}
+}
+
+func TestAlertCmdWithDB(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() {
+		alertAbove, alertBelow = 0, 0
+		for _, name := range []string{"above", "below"} {
+			alertAddCmd.Flags().Lookup(name).Changed = false
+		}
+	}()
+
+	rootCmd.SetArgs([]string{"alert", "add", "bp_sys"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error without --above or --below")
+	}
+	rootCmd.SetArgs([]string{"alert", "add", "bp_sys", "--above", "140"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("alert add failed: %v", err)
+	}
+	// A second add on the same metric updates its thresholds
+	rootCmd.SetArgs([]string{"alert", "add", "bp_sys", "--below", "90"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("alert add (update) failed: %v", err)
+	}
+
+	alerts, err := loadAlerts()
+	if err != nil {
+		t.Fatalf("loadAlerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].Above == nil || *alerts[0].Above != 140 ||
+		alerts[0].Below == nil || *alerts[0].Below != 90 {
+		t.Fatalf("Expected one bp_sys alert between 90 and 140, got %+v", alerts)
+	}
+
+	rootCmd.SetArgs([]string{"add", "bp", "152", "95"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add bp failed: %v", err)
+	}
+	hits, err := storage.ActiveAlerts(t.Context(), testDB, alerts, time.Now())
+	if err != nil || len(hits) != 1 || hits[0].Value != 152 {
+		t.Errorf("Expected one active alert for 152, got %+v, %v", hits, err)
+	}
+
+	rootCmd.SetArgs([]string{"alerts", "check"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("alerts check failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"alert", "delete", "bp_sys"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("alert delete failed: %v", err)
+	}
+	if alerts, _ := loadAlerts(); len(alerts) != 0 {
+		t.Errorf("Expected alert removed, got %+v", alerts)
+	}
+}
+
+func TestApptCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() {
+		apptDuration, apptLocation, apptReason, apptAll, apptFile = 0, "", "", false, ""
+		exportOutput = ""
+	}()
+
+	next := time.Now().AddDate(0, 0, 7)
+	date := next.Format("2006-01-02")
+	rootCmd.SetArgs([]string{"appt", "add", "Dr. Lee", date, "14:00", "--reason", "annual physical"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("appt add failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"appt", "add", "Dr. Lee", date, "2pm"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for invalid time")
+	}
+
+	appts, _ := testDB.ListAppointments(ctx, storage.AppointmentFilter{})
+	if len(appts) != 1 {
+		t.Fatalf("Expected 1 appointment, got %d", len(appts))
+	}
+	a := appts[0]
+	if got := a.ScheduledAt.Local(); got.Hour() != 14 || got.Format("2006-01-02") != date {
+		t.Errorf("Expected %s 14:00 local, got %v", date, got)
+	}
+
+	rootCmd.SetArgs([]string{"appt"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("appt failed: %v", err)
+	}
+
+	notes := filepath.Join(t.TempDir(), "visit.md")
+	os.WriteFile(notes, []byte("Labs look good.\n"), 0600)
+	rootCmd.SetArgs([]string{"appt", "summary", a.ID.String()[:8], "--file", notes})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("appt summary failed: %v", err)
+	}
+	got, _ := testDB.GetAppointment(ctx, a.ID.String())
+	if got.Summary == nil || *got.Summary != "Labs look good." {
+		t.Errorf("Expected linked summary, got %v", got.Summary)
+	}
+
+	out := filepath.Join(t.TempDir(), "appointments.ics")
+	rootCmd.SetArgs([]string{"export", "ics", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("export ics failed: %v", err)
+	}
+	ics, _ := os.ReadFile(out)
+	if !strings.Contains(string(ics), "SUMMARY:Dr. Lee") {
+		t.Errorf("Expected appointment in ICS export, got:\n%s", ics)
+	}
+
+	rootCmd.SetArgs([]string{"appt", "delete", a.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("appt delete failed: %v", err)
+	}
+	if appts, _ := testDB.ListAppointments(ctx, storage.AppointmentFilter{}); len(appts) != 0 {
+		t.Errorf("Expected appointment deleted, got %d", len(appts))
+	}
+}
+
+func TestProfileCmdWithDB(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { exportOutput = "" }()
+
+	out := filepath.Join(t.TempDir(), "card.txt")
+	rootCmd.SetArgs([]string{"export", "emergency-card", "-o", out})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error exporting a card without a profile")
+	}
+
+	for _, args := range [][]string{
+		{"profile", "set", "name", "Harper", "Reed"},
+		{"profile", "set", "blood-type", "o", "neg"},
+		{"profile", "set", "contact", "Jane Doe", "+1 555 0100", "spouse"},
+		{"profile", "set", "birth-date", "1980-03-15"},
+		{"profile", "set", "sex", "F"},
+		{"profile", "set", "height", "5'8\""},
+		{"profile", "add", "allergy", "penicillin"},
+		{"profile", "add", "allergy", "peanuts"},
+		{"profile", "add", "condition", "asthma"},
+		{"profile", "remove", "allergy", "Peanuts"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+	}
+	for _, args := range [][]string{
+		{"profile", "set", "blood-type", "C+"},
+		{"profile", "set", "birth-date", "March 15"},
+		{"profile", "set", "sex", "unknown"},
+		{"profile", "set", "height", "tall"},
+		{"profile", "add", "allergy", "Penicillin"},
+		{"profile", "add", "hobby", "chess"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+
+	cfg, _ := config.Load()
+	p := cfg.EmergencyProfile()
+	if p == nil || p.Name != "Harper Reed" || p.BloodType != "O-" || p.BirthDate != "1980-03-15" || p.Sex != "female" ||
+		p.HeightCM != 172.7 || len(p.Allergies) != 1 || len(p.Conditions) != 1 || p.EmergencyContact == nil {
+		t.Fatalf("Unexpected profile %+v", p)
+	}
+	if set, err := loadDerivedSet(); err != nil || set.Lookup("bmi") == nil || set.Lookup("bmr") == nil {
+		t.Errorf("derived set from profile = %+v, %v; want bmi and bmr", set, err)
+	}
+	rootCmd.SetArgs([]string{"derive", "delete", "bmi"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "profile height") {
+		t.Errorf("deleting built-in bmi: err = %v", err)
+	}
+
+	testDB.CreateMedication(t.Context(), models.NewMedication("Albuterol", "90 mcg", "as needed"))
+	rootCmd.SetArgs([]string{"export", "emergency-card", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("export emergency-card failed: %v", err)
+	}
+	card, _ := os.ReadFile(out)
+	for _, want := range []string{"Blood type: O-", "Allergies: penicillin", "Medications: Albuterol 90 mcg",
+		"Contact: Jane Doe (spouse) +1 555 0100", "█"} {
+		if !strings.Contains(string(card), want) {
+			t.Errorf("Card missing %q:\n%s", want, card)
+		}
+	}
+
+	svg := filepath.Join(t.TempDir(), "card.svg")
+	rootCmd.SetArgs([]string{"export", "emergency-card", "-o", svg})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("export emergency-card svg failed: %v", err)
+	}
+	data, _ := os.ReadFile(svg)
+	if !strings.HasPrefix(string(data), "<svg") || !strings.Contains(string(data), "<path d=\"M") {
+		t.Errorf("Expected SVG with a QR path, got:\n%s", data)
+	}
+}
+
+func TestWorkoutCommentCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { workoutCommentAuthor, workoutCommentFile = "me", "" }()
+
+	w := models.NewWorkout("run")
+	testDB.CreateWorkout(ctx, w)
+
+	rootCmd.SetArgs([]string{"workout", "comment", w.ID.String()[:8]})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error without comment text")
+	}
+
+	feedback := filepath.Join(t.TempDir(), "feedback.md")
+	os.WriteFile(feedback, []byte("Ease off the first 2 km.\n"), 0600)
+	rootCmd.SetArgs([]string{"workout", "comment", w.ID.String()[:8], "--file", feedback, "--author", "Coach Sam"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout comment failed: %v", err)
+	}
+
+	comments, err := testDB.ListWorkoutComments(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutComments failed: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Author != "Coach Sam" || comments[0].Body != "Ease off the first 2 km." {
+		t.Fatalf("Unexpected comments %+v", comments)
+	}
+
+	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout show failed: %v", err)
+	}
+}
+
+func TestAddWithUnitCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"add", "weight", "180lb"},
+		{"add", "water", "+16", "oz"},
+		{"add", "sleep", "7h", "30m"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+	}
+
+	weight, err := testDB.GetLatestMetric(ctx, models.MetricWeight)
+	if err != nil {
+		t.Fatalf("GetLatestMetric failed: %v", err)
+	}
+	if math.Abs(weight.Value-81.65) > 0.01 || weight.Unit != "kg" {
+		t.Errorf("Expected ~81.65 kg, got %v %s", weight.Value, weight.Unit)
+	}
+	water, _ := testDB.GetLatestMetric(ctx, models.MetricWater)
+	if math.Abs(water.Value-473.18) > 0.01 {
+		t.Errorf("Expected ~473.18 ml, got %v", water.Value)
+	}
+	sleep, _ := testDB.GetLatestMetric(ctx, models.MetricSleepHours)
+	if sleep.Value != 7.5 {
+		t.Errorf("Expected 7.5 hours of sleep, got %v", sleep.Value)
+	}
+
+	for _, args := range [][]string{
+		{"add", "weight", "180oz"},
+		{"add", "mood", "7", "8"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestAddDuplicateCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	defer func() { addForce = false }()
+
+	run := func(args ...string) error {
+		addForce = false
+		rootCmd.SetArgs(args)
+		return rootCmd.Execute()
+	}
+	if err := run("add", "weight", "82.5"); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if err := run("add", "weight", "82.4"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected the second weight refused with a --force hint, got %v", err)
+	}
+	if err := run("add", "weight", "82.4", "--force"); err != nil {
+		t.Errorf("add --force failed: %v", err)
+	}
+	if err := run("add", "bp", "120", "80"); err != nil {
+		t.Fatalf("add bp failed: %v", err)
+	}
+	if err := run("add", "bp", "121", "79"); err != nil {
+		t.Errorf("Expected a second blood pressure reading allowed by default, got %v", err)
+	}
+	for range 2 {
+		if err := run("add", "water", "+250"); err != nil {
+			t.Errorf("Expected running totals never refused, got %v", err)
+		}
+	}
+
+	cfgDir := filepath.Join(configHome, "health")
+	os.MkdirAll(cfgDir, 0750)
+	os.WriteFile(filepath.Join(cfgDir, "config.json"), []byte(`{"duplicate_windows": {"weight": "0", "bp_sys": "10m"}}`), 0600)
+	if err := run("add", "weight", "82.3"); err != nil {
+		t.Errorf("Expected weight allowed with its window off, got %v", err)
+	}
+	if err := run("add", "bp", "122", "78"); err == nil || !strings.Contains(err.Error(), "blood pressure reading") {
+		t.Errorf("Expected a third blood pressure reading refused with a bp_sys window, got %v", err)
+	}
+
+	weight := models.MetricWeight
+	if metrics, _ := testDB.ListMetrics(ctx, &weight, 0); len(metrics) != 3 {
+		t.Errorf("Expected 3 weights, got %d", len(metrics))
+	}
+}
+
+func TestAddFromStdinCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer rootCmd.SetIn(nil)
+	defer func() { addForce = false }()
+
+	for _, tc := range []struct {
+		stdin string
+		args  []string
+	}{
+		{"82.5\n", []string{"add", "weight", "-"}},
+		{"bp 120 80\n", []string{"add", "-"}},
+		{"180 lb", []string{"add", "weight", "-", "--force"}},
+	} {
+		rootCmd.SetIn(strings.NewReader(tc.stdin))
+		rootCmd.SetArgs(tc.args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v with stdin %q failed: %v", tc.args, tc.stdin, err)
+		}
+	}
+
+	mt := models.MetricWeight
+	weights, _ := testDB.ListMetrics(ctx, &mt, 0)
+	if len(weights) != 2 {
+		t.Fatalf("Expected 2 weights, got %d", len(weights))
+	}
+	sys, err := testDB.GetLatestMetric(ctx, models.MetricBPSys)
+	if err != nil || sys.Value != 120 {
+		t.Errorf("Expected bp_sys 120 from stdin, got %v, %v", sys, err)
+	}
+
+	rootCmd.SetIn(strings.NewReader("  \n"))
+	rootCmd.SetArgs([]string{"add", "weight", "-"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected error for empty stdin")
+	}
+}
+
+func TestStatusCmdWithDB(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+
+	testDB.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82).WithNotes("after travel"))
+
+	rootCmd.SetArgs([]string{"status"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if got := formatBytes(1536); got != "1.5 KB" {
+		t.Errorf("formatBytes(1536) = %q, want 1.5 KB", got)
+	}
+}
+
+func TestUsageCmdWithDB(t *testing.T) {
+	_, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	run := func(args ...string) {
+		t.Helper()
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+	}
+
+	logPath := filepath.Join(os.Getenv("XDG_DATA_HOME"), "health", usage.FileName)
+	run("list")
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Fatalf("usage recorded before opting in")
+	}
+
+	run("usage", "on")
+	run("list")
+	run("add", "weight", "82")
+	run("usage")
+
+	events, err := usage.Load(logPath)
+	if err != nil {
+		t.Fatalf("load usage: %v", err)
+	}
+	if len(events) != 2 || events[0].Command != "list" || events[1].Command != "add" {
+		t.Errorf("events = %+v, want list then add", events)
+	}
+
+	run("usage", "off")
+	run("list")
+	run("usage", "reset")
+	if _, err := os.Stat(logPath); !os.IsNotExist(err) {
+		t.Errorf("usage log still exists after reset")
+	}
+}
+
+func TestCronCmd(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var ran [][]string
+	fail := false
+	orig := runCronChild
+	defer func() { runCronChild = orig }()
+	runCronChild = func(args []string) ([]byte, error) {
+		ran = append(ran, args)
+		if fail {
+			return []byte("✗ boom\n"), errors.New("exit status 1")
+		}
+		return []byte("⏰ log weight\n"), nil
+	}
+
+	var stderr bytes.Buffer
+	rootCmd.SetErr(&stderr)
+	defer rootCmd.SetErr(nil)
+
+	rootCmd.SetArgs([]string{"cron", "remind", "due", "--notify"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("cron (success) failed: %v", err)
+	}
+	if len(ran) != 1 || strings.Join(ran[0], " ") != "remind due --notify" {
+		t.Errorf("wrapped command args = %v, want flags passed through", ran)
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no output on success, got %q", stderr.String())
+	}
+
+	fail = true
+	rootCmd.SetArgs([]string{"cron", "export", "json"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("expected cron to fail when the command fails")
+	}
+	if !strings.Contains(stderr.String(), "boom") {
+		t.Errorf("expected failure output on stderr, got %q", stderr.String())
+	}
+
+	log, err := os.ReadFile(filepath.Join(dataHome, "health", cronLogFile))
+	if err != nil {
+		t.Fatalf("read cron log: %v", err)
+	}
+	for _, want := range []string{"health remind due --notify: ok", "log weight", "health export json: FAILED: exit status 1", "boom"} {
+		if !strings.Contains(string(log), want) {
+			t.Errorf("cron log missing %q:\n%s", want, log)
+		}
+	}
+}
+
+func TestCaldavPushCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(caldavPasswordEnv, "secret")
+	defer func() {
+		caldavURL, caldavUser, caldavSince = "", "", ""
+		caldavForce, caldavNoAppts, caldavNoWorkouts = false, false, false
+	}()
+
+	w := models.NewWorkout("run").WithDuration(30)
+	testDB.CreateWorkout(ctx, w)
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+	a := models.NewAppointment("Dr. Lee", time.Now().Add(48*time.Hour))
+	testDB.CreateAppointment(ctx, a)
+
+	puts := map[string]string{}
+	sent := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, pass, _ := r.BasicAuth(); pass != "secret" || r.Method != http.MethodPut {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body := new(bytes.Buffer)
+		body.ReadFrom(r.Body)
+		puts[r.URL.Path] = body.String()
+		sent++
+		rw.Header().Set("ETag", `"1"`)
+		rw.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+
+	for run := 0; run < 2; run++ {
+		rootCmd.SetArgs([]string{"caldav", "push", "--url", srv.URL + "/cal/", "--user", "me"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("caldav push failed: %v", err)
+		}
+	}
+	if len(puts) != 2 || sent != 2 {
+		t.Fatalf("expected 2 events pushed once each, got %d PUTs: %v", sent, puts)
+	}
+	if body := puts["/cal/"+w.ID.String()+".ics"]; !strings.Contains(body, "SUMMARY:Workout: run") || !strings.Contains(body, "distance: 5 km") {
+		t.Errorf("unexpected workout event:\n%s", body)
+	}
+	if body := puts["/cal/"+a.ID.String()+".ics"]; !strings.Contains(body, "SUMMARY:Dr. Lee") {
+		t.Errorf("unexpected appointment event:\n%s", body)
+	}
+	if _, err := os.Stat(filepath.Join(os.Getenv("XDG_DATA_HOME"), "health", "caldav.json")); err != nil {
+		t.Errorf("expected push state saved: %v", err)
+	}
+}
+
+func TestExportChunkedAndImportCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { exportChunks, exportChunkSize = "", 16 }()
+
+	m := models.NewMetric(models.MetricWeight, 82.5)
+	testDB.CreateMetric(ctx, m)
+
+	dir := filepath.Join(t.TempDir(), "backup")
+	rootCmd.SetArgs([]string{"export", "json", "--chunks", dir})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("chunked export failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err != nil {
+		t.Fatalf("expected manifest: %v", err)
+	}
+	exportChunks = ""
+
+	testDB.DeleteMetric(ctx, m.ID.String())
+	rootCmd.SetArgs([]string{"import", dir})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("import of chunked export failed: %v", err)
+	}
+	if got, err := testDB.GetMetric(ctx, m.ID.String()); err != nil || got.Value != 82.5 {
+		t.Errorf("metric not restored from chunks: %v, %v", got, err)
+	}
+}
+
+func TestExportParquetCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { exportOutput = "" }()
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+	testDB.CreateWorkout(ctx, models.NewWorkout("run"))
+
+	rootCmd.SetArgs([]string{"export", "parquet"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("Expected parquet to stdout to be refused, got %v", err)
+	}
+
+	out := filepath.Join(t.TempDir(), "health.parquet")
+	rootCmd.SetArgs([]string{"export", "parquet", "-o", out})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("parquet export failed: %v", err)
+	}
+	for _, name := range []string{"health-metrics.parquet", "health-workouts.parquet"} {
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(out), name))
+		if err != nil || !bytes.HasPrefix(data, []byte("PAR1")) {
+			t.Errorf("expected %s to be a parquet file: %v", name, err)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "data") + string(filepath.Separator)
+	rootCmd.SetArgs([]string{"export", "parquet", "-o", dir})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("parquet export to a directory failed: %v", err)
+	}
+	for _, name := range []string{"metrics.parquet", "workouts.parquet"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || !bytes.HasPrefix(data, []byte("PAR1")) {
+			t.Errorf("expected %s in the output directory: %v", name, err)
+		}
+	}
+}
+
+func TestQueryCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { queryJSON = false }()
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+
+	rootCmd.SetArgs([]string{"query", "SELECT metric_type, avg(value) FROM metrics GROUP BY 1", "--json"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"query", "UPDATE metrics SET value = 0"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an UPDATE to be refused")
+	}
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 1 || metrics[0].Value != 82.5 {
+		t.Errorf("Expected the metric untouched, got %v", metrics)
+	}
+}
+
+func TestPromCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { promOnce = false }()
+
+	old := models.NewMetric(models.MetricWeight, 83).WithRecordedAt(time.Now().AddDate(0, 0, -3))
+	testDB.CreateMetric(ctx, old)
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWater, 500))
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWater, 250))
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"prom", "--once"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("prom --once failed: %v", err)
+	}
+
+	for _, want := range []string{
+		"# TYPE health_metric_latest gauge\n",
+		`health_metric_latest{type="weight",unit="kg"} 82.5`,
+		`health_metric_today_sum{type="water",unit="ml"} 750`,
+		`health_metric_today_count{type="water"} 2`,
+		`health_metric_today_count{type="weight"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in scrape:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPushInfluxCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(influxTokenEnv, "secret")
+	defer func() { influxURL, influxOrg, influxBucket, influxSince = "", "", "", "" }()
+
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token secret" || r.URL.Query().Get("bucket") != "health" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body := new(bytes.Buffer)
+		body.ReadFrom(r.Body)
+		bodies = append(bodies, body.String())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+	w := models.NewWorkout("run").WithDuration(30)
+	testDB.CreateWorkout(ctx, w)
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5.2, "km"))
+
+	rootCmd.SetArgs([]string{"push", "influx"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--url") {
+		t.Errorf("Expected missing --url to be refused, got %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"push", "influx", "--url", server.URL, "--bucket", "health"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("push influx failed: %v", err)
+	}
+	if len(bodies) != 1 ||
+		!strings.Contains(bodies[0], "health_metric,type=weight,unit=kg value=82.5 ") ||
+		!strings.Contains(bodies[0], "health_workout,workout_type=run distance=5.2,duration_minutes=30 ") {
+		t.Errorf("Unexpected line protocol: %q", bodies)
+	}
+
+	// push_on_add sends each new metric as it is added
+	cfg, _ := config.Load()
+	cfg.Influx = &config.InfluxConfig{URL: server.URL, Bucket: "health", PushOnAdd: true}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	rootCmd.SetArgs([]string{"add", "bp", "120", "80"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if len(bodies) != 2 || !strings.Contains(bodies[1], "type=bp_sys") || !strings.Contains(bodies[1], "type=bp_dia") {
+		t.Errorf("Expected the reading pushed on add, got %q", bodies[1:])
+	}
+}
+
+func TestMQTTPublishCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { mqttBroker = "" }()
+
+	rootCmd.SetArgs([]string{"mqtt", "publish"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--broker") {
+		t.Errorf("Expected missing --broker to be refused, got %v", err)
+	}
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+	rootCmd.SetArgs([]string{"mqtt", "publish", "--broker", addr})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "mqtt") {
+		t.Errorf("Expected an unreachable broker to fail, got %v", err)
+	}
+
+	weight := haSensorConfig(models.MetricWeight, "kg")
+	if weight["device_class"] != "weight" || weight["unit_of_measurement"] != "kg" ||
+		weight["state_topic"] != "health/weight/state" || weight["name"] != "Weight" {
+		t.Errorf("weight sensor config = %v", weight)
+	}
+	sleep := haSensorConfig(models.MetricSleepHours, "hours")
+	if sleep["device_class"] != "duration" || sleep["unit_of_measurement"] != "h" {
+		t.Errorf("sleep sensor config = %v", sleep)
+	}
+	mood := haSensorConfig(models.MetricMood, "scale")
+	if _, ok := mood["unit_of_measurement"]; ok || mood["name"] != "Mood" {
+		t.Errorf("mood sensor config = %v", mood)
+	}
+}
+
+func TestImportFitbitCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(fitbitTokenEnv, "")
+	defer func() { fitbitToken, fitbitFrom, fitbitTo = "", "", "" }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/steps/"):
+			w.Write([]byte(`{"activities-steps":[{"dateTime":"2025-03-01","value":"8234"}]}`))
+		case strings.Contains(r.URL.Path, "/heart/"):
+			w.Write([]byte(`{"activities-heart":[]}`))
+		case strings.Contains(r.URL.Path, "/weight/"):
+			w.Write([]byte(`{"weight":[{"date":"2025-03-02","time":"07:15:00","weight":82.4}]}`))
+		case strings.Contains(r.URL.Path, "/sleep/"):
+			w.Write([]byte(`{"sleep":[{"startTime":"2025-03-01T23:10:00.000","endTime":"2025-03-02T07:00:00.000"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	rootCmd.SetArgs([]string{"import", "fitbit"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--token") {
+		t.Errorf("Expected a missing token to be refused, got %v", err)
+	}
+
+	cfg, _ := config.Load()
+	cfg.Fitbit = &config.FitbitConfig{APIURL: server.URL}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	for range 2 { // the second run finds everything already stored
+		rootCmd.SetArgs([]string{"import", "fitbit", "--token", "tok", "--from", "2025-03-01", "--to", "2025-03-02"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("import fitbit failed: %v", err)
+		}
+	}
+
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 3 {
+		t.Errorf("Expected steps, weight, and sleep_hours once each, got %d metrics", len(metrics))
+	}
+	sessions, _ := testDB.ListSleepSessions(ctx, 0)
+	if len(sessions) != 1 {
+		t.Errorf("Expected 1 sleep session, got %d", len(sessions))
+	}
+	cfg, _ = config.Load()
+	if cfg.Fitbit.LastSynced != "2025-03-02" || cfg.Fitbit.APIURL != server.URL {
+		t.Errorf("Expected the cursor saved at 2025-03-02, got %+v", cfg.Fitbit)
+	}
+}
+
+func TestImportOuraCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(ouraTokenEnv, "")
+	defer func() { ouraToken, ouraSince, ouraUntil = "", "", "" }()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/usercollection/daily_readiness":
+			w.Write([]byte(`{"data":[{"day":"2025-03-02","score":74,"temperature_deviation":0.3}]}`))
+		case "/v2/usercollection/sleep":
+			w.Write([]byte(`{"data":[{"day":"2025-03-02","type":"long_sleep","bedtime_start":"2025-03-01T23:00:00Z",
+				"bedtime_end":"2025-03-02T07:00:00Z","average_hrv":48,"deep_sleep_duration":5400}]}`))
+		}
+	}))
+	defer server.Close()
+
+	rootCmd.SetArgs([]string{"import", "oura", "--since", "2025-03-01"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "--token") {
+		t.Errorf("Expected a missing token to be refused, got %v", err)
+	}
+
+	// A manual HRV reading the same day doesn't stop the import
+	wake := time.Date(2025, 3, 2, 7, 0, 0, 0, time.UTC).In(time.Local)
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricHRV, 52).WithRecordedAt(wake.Add(-time.Minute)))
+
+	cfg, _ := config.Load()
+	cfg.Oura = &config.OuraConfig{APIURL: server.URL}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	for range 2 { // the second run finds every day already imported
+		rootCmd.SetArgs([]string{"import", "oura", "--token", "tok", "--since", "2025-03-01", "--until", "2025-03-02"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("import oura failed: %v", err)
+		}
+	}
+
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	counts := map[models.MetricType]int{}
+	for _, m := range metrics {
+		counts[m.MetricType]++
+	}
+	want := map[models.MetricType]int{
+		models.MetricReadiness: 1, models.MetricTempDev: 1, models.MetricHRV: 2,
+		models.MetricDeepSleep: 1, models.MetricREMSleep: 1, models.MetricLightSleep: 1,
+		models.MetricSleepHours: 1,
+	}
+	if len(counts) != len(want) {
+		t.Errorf("Expected %v, got %v", want, counts)
+	}
+	for mt, n := range want {
+		if counts[mt] != n {
+			t.Errorf("Expected %d %s, got %d", n, mt, counts[mt])
+		}
+	}
+	sessions, _ := testDB.ListSleepSessions(ctx, 0)
+	if len(sessions) != 1 || sessions[0].Hours() != 8 {
+		t.Errorf("Expected one 8 hour sleep session, got %+v", sessions)
+	}
+}
+
+func TestImportWithingsCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(withingsSecretEnv, "shh")
+	defer func() { withingsClientID, withingsRedirectURI, withingsCode, withingsSince = "", "", "", "" }()
+
+	var lastUpdates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.Form.Get("action") {
+		case "requesttoken":
+			// The code's token is already expired, so the import refreshes it
+			if r.Form.Get("grant_type") == "authorization_code" {
+				w.Write([]byte(`{"status":0,"body":{"userid":"42","access_token":"old","refresh_token":"r1","expires_in":0}}`))
+			} else if r.Form.Get("refresh_token") == "r1" {
+				w.Write([]byte(`{"status":0,"body":{"userid":"42","access_token":"new","refresh_token":"r2","expires_in":10800}}`))
+			}
+		case "getmeas":
+			if r.Header.Get("Authorization") != "Bearer new" {
+				w.Write([]byte(`{"status":401}`))
+				return
+			}
+			lastUpdates = append(lastUpdates, r.Form.Get("lastupdate"))
+			w.Write([]byte(`{"status":0,"body":{"updatetime":1741000000,"measuregrps":[
+				{"date":1740900000,"attrib":0,"measures":[{"value":82450,"type":1,"unit":-3},{"value":185,"type":6,"unit":-1}]}]}}`))
+		}
+	}))
+	defer server.Close()
+
+	rootCmd.SetArgs([]string{"import", "withings"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "withings auth") {
+		t.Errorf("Expected to be sent to auth first, got %v", err)
+	}
+
+	cfg, _ := config.Load()
+	cfg.Withings = &config.WithingsConfig{APIURL: server.URL}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	rootCmd.SetArgs([]string{"import", "withings", "auth", "--client-id", "app"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("withings auth failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"import", "withings", "auth", "--code", "abc"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("withings auth --code failed: %v", err)
+	}
+
+	for range 2 { // the second run asks only for changes and skips what's stored
+		rootCmd.SetArgs([]string{"import", "withings"})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("import withings failed: %v", err)
+		}
+	}
+
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 2 {
+		t.Errorf("Expected weight and body_fat once each, got %d metrics", len(metrics))
+	}
+	if len(lastUpdates) != 2 || lastUpdates[0] != "" || lastUpdates[1] != "1741000000" {
+		t.Errorf("Expected the full history and then changes since the cursor, got %q", lastUpdates)
+	}
+	var token struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if ok, _ := config.LoadSecret(withingsSecretName, &token); !ok || token.RefreshToken != "r2" {
+		t.Errorf("Expected the refreshed token saved, got %+v", token)
+	}
+	cfg, _ = config.Load()
+	if cfg.Withings.ClientID != "app" || cfg.Withings.RedirectURI != withingsDefaultRedirect {
+		t.Errorf("Expected the app saved in config, got %+v", cfg.Withings)
+	}
+	data, _ := os.ReadFile(config.GetConfigPath())
+	if strings.Contains(string(data), "r2") || strings.Contains(string(data), "shh") {
+		t.Errorf("Expected no secrets in config.json, got %s", data)
+	}
+}
+
+func TestListSourceCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() {
+		listType, listLimit, listSource, listWide = "", 20, "", false
+		workoutListSource, workoutListWide = "", false
+	}()
+
+	rootCmd.SetArgs([]string{"add", "weight", "82.5"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 9000).WithSource(models.SourceFitbit))
+	rootCmd.SetArgs([]string{"workout", "add", "run"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout add failed: %v", err)
+	}
+
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	for _, m := range metrics {
+		if m.MetricType == models.MetricWeight && m.Source != models.SourceManual {
+			t.Errorf("Expected an added metric to be manual, got %q", m.Source)
+		}
+	}
+	workouts, _ := testDB.ListWorkouts(ctx, nil, 0)
+	if len(workouts) != 1 || workouts[0].Source != models.SourceManual {
+		t.Errorf("Expected an added workout to be manual, got %+v", workouts)
+	}
+
+	rootCmd.SetArgs([]string{"list", "--source", "fitbit", "--wide"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("list --source --wide failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"workout", "list", "--source", "manual", "--wide"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("workout list --source --wide failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"list", "--source", "carrier-pigeon"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "unknown source") {
+		t.Errorf("Expected an unknown source error, got %v", err)
+	}
+}
+
+func TestMetaFlagCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { addMeta, workoutMeta = nil, nil }()
+
+	rootCmd.SetArgs([]string{"add", "weight", "82.5", "--meta", "device=Body+", "--meta", "scale_id=7"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add --meta failed: %v", err)
+	}
+	metrics, _ := testDB.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 1 || metrics[0].Metadata["device"] != "Body+" || metrics[0].Metadata["scale_id"] != "7" {
+		t.Errorf("Expected the metadata stored, got %+v", metrics)
+	}
+
+	rootCmd.SetArgs([]string{"workout", "add", "ride", "--meta", "gpx=rides/0412.gpx"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout add --meta failed: %v", err)
+	}
+	workouts, _ := testDB.ListWorkouts(ctx, nil, 0)
+	if len(workouts) != 1 || workouts[0].Metadata["gpx"] != "rides/0412.gpx" {
+		t.Errorf("Expected the workout metadata stored, got %+v", workouts)
+	}
+	rootCmd.SetArgs([]string{"workout", "show", workouts[0].ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("workout show failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"add", "mood", "7", "--meta", "novalue"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "key=value") {
+		t.Errorf("Expected a metadata format error, got %v", err)
+	}
+}
+
+func TestWorkoutAttachCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { workoutShowRoute = false }()
+
+	w := models.NewWorkout("ride")
+	testDB.CreateWorkout(ctx, w)
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 2.5, "km"))
+
+	gpx := filepath.Join(t.TempDir(), "ride.gpx")
+	os.WriteFile(gpx, []byte(`<gpx><trk><trkseg>
+		<trkpt lat="41.880" lon="-87.63"><ele>180</ele><time>2025-04-12T13:00:00Z</time></trkpt>
+		<trkpt lat="41.898" lon="-87.63"><ele>192</ele><time>2025-04-12T13:06:00Z</time></trkpt>
+	</trkseg></trk></gpx>`), 0600)
+
+	for range 2 { // attaching again replaces the route and its metrics
+		rootCmd.SetArgs([]string{"workout", "attach", w.ID.String()[:8], gpx})
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("workout attach failed: %v", err)
+		}
+	}
+
+	got, _ := testDB.GetWorkoutWithMetrics(ctx, w.ID.String())
+	rel := got.Metadata["route"]
+	if rel != "attachments/workouts/"+w.ID.String()+"/ride.gpx" {
+		t.Errorf("Expected the route recorded in metadata, got %q", rel)
+	}
+	stored := filepath.Join(os.Getenv("XDG_DATA_HOME"), "health", filepath.FromSlash(rel))
+	if _, err := os.Stat(stored); err != nil {
+		t.Errorf("Expected the route copied to %s: %v", stored, err)
+	}
+	metrics := map[string]float64{}
+	for _, wm := range got.Metrics {
+		if _, dup := metrics[wm.MetricName]; dup {
+			t.Errorf("Expected one %s metric", wm.MetricName)
+		}
+		metrics[wm.MetricName] = wm.Value
+	}
+	if metrics["distance"] != 2.002 || metrics["elevation_gain"] != 12 {
+		t.Errorf("Expected the route's distance and climb, got %v", metrics)
+	}
+
+	rootCmd.SetArgs([]string{"workout", "show", w.ID.String()[:8], "--route"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("workout show --route failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"workout", "attach", w.ID.String()[:8], "ride.tcx"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	rootCmd.SetArgs([]string{"workout", "delete", w.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout delete failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(stored)); !os.IsNotExist(err) {
+		t.Errorf("Expected the attachments removed with the workout, got %v", err)
+	}
+}
+
+func TestAttachCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { attachRemove = false }()
+
+	m := models.NewMetric(models.MetricWeight, 82.5).WithMetadata("device", "Body+")
+	testDB.CreateMetric(ctx, m)
+
+	dir := t.TempDir()
+	photo := filepath.Join(dir, "lunch.jpg")
+	os.WriteFile(photo, []byte("jpeg"), 0600)
+	report := filepath.Join(dir, "report.pdf")
+	os.WriteFile(report, []byte("pdf"), 0600)
+
+	rootCmd.SetArgs([]string{"attach", m.ID.String()[:8], photo, report})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("attach failed: %v", err)
+	}
+
+	got, _ := testDB.GetMetric(ctx, m.ID.String())
+	rel := got.Metadata["attachment:lunch.jpg"]
+	if rel != "attachments/metrics/"+m.ID.String()+"/lunch.jpg" || got.Metadata["device"] != "Body+" {
+		t.Errorf("Expected the photo recorded alongside existing metadata, got %v", got.Metadata)
+	}
+	stored := filepath.Join(os.Getenv("XDG_DATA_HOME"), "health", filepath.FromSlash(rel))
+	if data, err := os.ReadFile(stored); err != nil || string(data) != "jpeg" {
+		t.Errorf("Expected the photo copied to %s, got %q, %v", stored, data, err)
+	}
+
+	rootCmd.SetArgs([]string{"show", m.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("show failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"attach", m.ID.String()[:8], "--remove", "report.pdf"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("attach --remove failed: %v", err)
+	}
+	got, _ = testDB.GetMetric(ctx, m.ID.String())
+	if _, ok := got.Metadata["attachment:report.pdf"]; ok {
+		t.Errorf("Expected the report removed, got %v", got.Metadata)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(stored), "report.pdf")); !os.IsNotExist(err) {
+		t.Errorf("Expected the report's file removed, got %v", err)
+	}
+	rootCmd.SetArgs([]string{"attach", m.ID.String()[:8], "--remove", "report.pdf"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an error removing a missing attachment")
+	}
+	attachRemove = false
+
+	rootCmd.SetArgs([]string{"delete", m.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(stored)); !os.IsNotExist(err) {
+		t.Errorf("Expected the attachments removed with the metric, got %v", err)
+	}
+}
+
+func TestShowCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() { showJSON = false }()
+
+	m := models.NewMetric(models.MetricWeight, 82.5).WithNotes("after run").WithLocation("home").
+		WithSource(models.SourceManual).WithMetadata("device", "Body+")
+	testDB.CreateMetric(ctx, m)
+
+	for _, args := range [][]string{
+		{"show", m.ID.String()[:8]},
+		{"show", m.ID.String(), "--json"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"show", "ffffffff"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found, got %v", err)
+	}
+}
+
+func TestShowDeleteAnyKindWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { showJSON = false }()
+
+	w := models.NewWorkout("run")
+	testDB.CreateWorkout(ctx, w)
+	wm := models.NewWorkoutMetric(w.ID, "distance", 5, "km")
+	testDB.AddWorkoutMetric(ctx, wm)
+	other := models.NewWorkoutMetric(w.ID, "avg_hr", 150, "bpm")
+	testDB.AddWorkoutMetric(ctx, other)
+
+	for _, args := range [][]string{
+		{"show", w.ID.String()[:8]},
+		{"show", wm.ID.String()[:8]},
+		{"show", wm.ID.String()[:8], "--json"},
+		{"show", w.ID.String()[:8], "--json"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"delete", wm.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("delete workout metric failed: %v", err)
+	}
+	got, _ := testDB.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if len(got.Metrics) != 1 || got.Metrics[0].ID != other.ID {
+		t.Errorf("Expected only the distance metric deleted, got %+v", got.Metrics)
+	}
+
+	rootCmd.SetArgs([]string{"delete", w.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("delete workout failed: %v", err)
+	}
+	if _, err := testDB.GetWorkout(ctx, w.ID.String()); err == nil {
+		t.Error("Expected the workout deleted")
+	}
+}
+
+func TestEditCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	defer func() {
+		editType, editValue, editNotes, editAt = "", "", "", ""
+		editCmd.Flags().Lookup("notes").Changed = false
+	}()
+
+	m := models.NewMetric(models.MetricSleepHours, 0.5).WithNotes("nap")
+	testDB.CreateMetric(ctx, m)
+
+	rootCmd.SetArgs([]string{"edit", m.ID.String()[:8], "--type", "meditation"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("edit --type failed: %v", err)
+	}
+	got, _ := testDB.GetMetric(ctx, m.ID.String())
+	if got.MetricType != models.MetricMeditation || got.Unit != "min" || got.Value != 30 {
+		t.Errorf("Expected 30 min of meditation, got %v %v %s", got.MetricType, got.Value, got.Unit)
+	}
+	editType = ""
+
+	rootCmd.SetArgs([]string{"edit", m.ID.String()[:8], "--value", "1h", "--notes", ""})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("edit --value failed: %v", err)
+	}
+	got, _ = testDB.GetMetric(ctx, m.ID.String())
+	if got.Value != 60 || got.Notes != nil {
+		t.Errorf("Expected 60 min without notes, got %v %v", got.Value, got.Notes)
+	}
+	editValue = ""
+	editCmd.Flags().Lookup("notes").Changed = false
+
+	sys, dia := models.NewBloodPressure(120, 80, time.Now())
+	storage.RecordBloodPressure(ctx, testDB, sys, dia)
+	rootCmd.SetArgs([]string{"edit", dia.ID.String()[:8], "--at", "2025-04-12 08:00"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("edit --at failed: %v", err)
+	}
+	gotSys, _ := testDB.GetMetric(ctx, sys.ID.String())
+	gotDia, _ := testDB.GetMetric(ctx, dia.ID.String())
+	if !gotSys.RecordedAt.Equal(gotDia.RecordedAt) || gotDia.RecordedAt.Year() != 2025 {
+		t.Errorf("Expected both halves moved, got %v and %v", gotSys.RecordedAt, gotDia.RecordedAt)
+	}
+	editAt = ""
+
+	for _, args := range [][]string{
+		{"edit", sys.ID.String()[:8], "--type", "heart_rate"},
+		{"edit", m.ID.String()[:8]},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+		editType = ""
+	}
+}
+
+func TestWorkoutIntensityCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	reset := func() { workoutRPE, workoutZone = 0, 0 }
+	defer reset()
+
+	rootCmd.SetArgs([]string{"workout", "add", "lift", "--rpe", "8", "--zone", "4", "--weather=false"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout add failed: %v", err)
+	}
+	reset()
+	workouts, _ := testDB.ListWorkouts(ctx, nil, 0)
+	if len(workouts) != 1 || workouts[0].RPE == nil || *workouts[0].RPE != 8 || *workouts[0].Zone != 4 {
+		t.Fatalf("Expected RPE 8 and zone 4 stored, got %+v", workouts)
+	}
+
+	for _, args := range [][]string{{"workout", "list"}, {"workout", "show", workouts[0].ID.String()[:8]}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"workout", "add", "run", "--rpe", "11", "--weather=false"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "rpe must be between 1 and 10") {
+		t.Errorf("Expected an RPE range error, got %v", err)
+	}
+	reset()
+	if workouts, _ := testDB.ListWorkouts(ctx, nil, 0); len(workouts) != 1 {
+		t.Errorf("Expected the invalid workout not saved, got %d workouts", len(workouts))
+	}
+}
+
+func TestWorkoutZonesCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { workoutZonesWeeks = 4 }()
+
+	rootCmd.SetArgs([]string{"workout", "zones"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "no heart rate zones set") {
+		t.Errorf("Expected an error without zones, got %v", err)
+	}
+
+	for _, args := range [][]string{{"profile", "set", "max-hr", "300"}, {"profile", "set", "hr-zones", "100,90,140,160,175"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+	rootCmd.SetArgs([]string{"profile", "set", "max-hr", "190"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("profile set max-hr failed: %v", err)
+	}
+	cfg, _ := config.Load()
+	if z, ok := cfg.HeartRateZones(); !ok || z.String() != "95,114,133,152,171" {
+		t.Errorf("Expected zones from max HR 190, got %v", z)
+	}
+
+	w := models.NewWorkout("run").WithDuration(40)
+	testDB.CreateWorkout(ctx, w)
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "avg_hr", 148, "bpm"))
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "max_hr", 171, "bpm"))
+
+	for _, args := range [][]string{{"workout", "show", w.ID.String()[:8]}, {"workout", "zones", "--weeks", "2"}, {"profile"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+}
+
+func TestTrainingLoadCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { trainingWeeks, trainingType = 4, "" }()
+
+	rootCmd.SetArgs([]string{"training", "load"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("training load with no workouts failed: %v", err)
+	}
+
+	now := time.Now()
+	for i, minutes := range []int{30, 30, 30, 120} {
+		w := models.NewWorkout("run").WithDuration(minutes)
+		w.StartedAt = now.AddDate(0, 0, -7*(3-i)).Add(-time.Hour)
+		testDB.CreateWorkout(ctx, w)
+		testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+	}
+	testDB.CreateWorkout(ctx, models.NewWorkout("lift").WithDuration(45))
+
+	for _, args := range [][]string{{"training", "load", "--weeks", "6"}, {"training", "load", "--type", "run"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"training", "load", "--weeks", "0"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected --weeks 0 to fail")
+	}
+}
+
+func TestEventCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() {
+		eventType, eventWeeklyMinutes, eventWeeklyKm, eventNotes, eventAll = "", 0, 0, "", false
+	}()
+
+	raceDay := time.Now().AddDate(0, 0, 45).Format("2006-01-02")
+	rootCmd.SetArgs([]string{"event", "add", "Chicago Marathon", raceDay, "--type", "run", "--weekly-km", "50", "--weekly-minutes", "300"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("event add failed: %v", err)
+	}
+	eventType, eventWeeklyMinutes, eventWeeklyKm = "", 0, 0
+
+	rootCmd.SetArgs([]string{"event", "add", "Old 5k", "2020-05-01"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("event add for a past event failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"event", "add", "Bad", "next tuesday"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an invalid date to fail")
+	}
+
+	events, _ := testDB.ListEvents(ctx)
+	if len(events) != 2 || events[1].Name != "Chicago Marathon" || events[1].WeeklyKm == nil || *events[1].WeeklyKm != 50 {
+		t.Fatalf("Expected both events stored with the plan, got %+v", events)
+	}
+	marathon := events[1]
+
+	w := models.NewWorkout("run").WithDuration(60)
+	testDB.CreateWorkout(ctx, w)
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 10, "km"))
+
+	for _, args := range [][]string{
+		{"event"},
+		{"event", "list", "--all"},
+		{"event", "status"},
+		{"event", "status", marathon.ID.String()[:8]},
+		{"training", "load"},
+	} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Errorf("%v failed: %v", args, err)
+		}
+	}
+
+	rootCmd.SetArgs([]string{"event", "delete", marathon.ID.String()[:8]})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("event delete failed: %v", err)
+	}
+	if events, _ := testDB.ListEvents(ctx); len(events) != 1 {
+		t.Errorf("Expected 1 event after delete, got %d", len(events))
+	}
+}
+
+func TestStatsVO2MaxCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { statsDays, statsType, statsRecord = 60, "run", false }()
+
+	rootCmd.SetArgs([]string{"stats", "vo2max"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected vo2max without a max heart rate to fail")
+	}
+
+	rootCmd.SetArgs([]string{"profile", "set", "max-hr", "190"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("profile set max-hr failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"stats", "vo2max"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected vo2max without a resting heart rate to fail")
+	}
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricHeartRate, 50))
+	rootCmd.SetArgs([]string{"stats", "vo2max"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("vo2max from the heart rate ratio failed: %v", err)
+	}
+
+	w := models.NewWorkout("run").WithDuration(50)
+	w.StartedAt = time.Now().AddDate(0, 0, -3)
+	testDB.CreateWorkout(ctx, w)
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 10, "km"))
+	testDB.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "avg_hr", 155, "bpm"))
+
+	rootCmd.SetArgs([]string{"stats", "vo2max", "--record"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("vo2max --record failed: %v", err)
+	}
+	m, err := testDB.GetLatestMetric(ctx, models.MetricVO2Max)
+	if err != nil {
+		t.Fatalf("no vo2max recorded: %v", err)
+	}
+	if m.Value != 46.8 || m.Source != models.SourceEstimate || m.Metadata["method"] != "runs" {
+		t.Errorf("recorded %v %s %v, want 46.8 from runs with source estimate", m.Value, m.Source, m.Metadata)
+	}
+
+	rootCmd.SetArgs([]string{"stats", "vo2max", "--days", "0"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected --days 0 to fail")
+	}
+}
+
+func TestStatsAverageCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { statsAvgDays = 30 }()
+
+	rootCmd.SetArgs([]string{"stats", "average", "weight"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("average with no entries failed: %v", err)
+	}
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82).WithRecordedAt(time.Now().AddDate(0, 0, -1)))
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 84).WithRecordedAt(time.Now().AddDate(0, 0, -3)))
+	rootCmd.SetArgs([]string{"stats", "avg", "weight", "--days", "7"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("stats avg failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"stats", "average", "nope"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an unknown metric type to fail")
+	}
+	rootCmd.SetArgs([]string{"stats", "average", "weight", "--days", "0"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected --days 0 to fail")
+	}
+}
+
+func TestLogFileCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() {
+		logVerbose, logFile = false, ""
+		setupLogging()
+	}()
+
+	testDB.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+
+	path := filepath.Join(t.TempDir(), "health.log")
+	rootCmd.SetArgs([]string{"list", "--log-file", path})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "msg=command path=\"health list\"") || strings.Contains(string(data), "sqlite query") {
+		t.Errorf("Expected info-level log of the command only, got:\n%s", data)
+	}
+
+	rootCmd.SetArgs([]string{"list", "--log-file", path, "--verbose"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("verbose list failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if !strings.Contains(string(data), "msg=\"sqlite query\"") || !strings.Contains(string(data), "duration=") {
+		t.Errorf("Expected query timings with --verbose, got:\n%s", data)
+	}
+}
+
+func TestMaintenanceCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { maintenanceDryRun, maintenanceQuarantine = false, false }()
+
+	m := models.NewMetric(models.MetricWeight, 82.5)
+	testDB.CreateMetric(ctx, m)
+
+	for _, args := range [][]string{{"maintenance", "--dry-run"}, {"maintenance"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		maintenanceDryRun = false
+	}
+	if _, err := testDB.GetMetric(ctx, m.ID.String()); err != nil {
+		t.Errorf("maintenance lost a metric: %v", err)
+	}
+}
+
+func TestReindexCmd(t *testing.T) {
+	_, cleanup := setupTestCLI(t)
+	defer cleanup()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	defer func() { reindexDryRun, reindexRelayout = false, false }()
+
+	rootCmd.SetArgs([]string{"reindex"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected reindex to refuse the sqlite backend")
+	}
+	if repo != nil {
+		repo.Close()
+		repo = nil
+	}
+
+	dataDir := t.TempDir()
+	os.MkdirAll(filepath.Join(configHome, "health"), 0750)
+	cfg := `{"backend": "markdown", "data_dir": "` + dataDir + `"}`
+	os.WriteFile(filepath.Join(configHome, "health", "config.json"), []byte(cfg), 0600)
+	handmade := filepath.Join(dataDir, "metrics", "2025", "01", "2025-01-05-weight.md")
+	os.MkdirAll(filepath.Dir(handmade), 0750)
+	os.WriteFile(handmade, []byte("---\nmetric_type: weight\nvalue: 81.5\nunit: kg\nrecorded_at: 2025-01-05T08:00:00Z\n---\n"), 0600)
+
+	for _, args := range [][]string{{"reindex", "--dry-run"}, {"reindex"}, {"reindex", "--relayout"}} {
+		rootCmd.SetArgs(args)
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		reindexDryRun, reindexRelayout = false, false
+	}
+	if _, err := os.Stat(handmade); !os.IsNotExist(err) {
+		t.Errorf("Expected --relayout to rename the handmade file, got %v", err)
+	}
+	if metrics, err := repo.ListMetrics(t.Context(), nil, 0); err != nil || len(metrics) != 1 {
+		t.Errorf("ListMetrics = %d, %v; want the handmade metric", len(metrics), err)
+	}
+}
+
+func TestParseWorkoutMetricFlag(t *testing.T) {
+	tests := []struct {
+		in    string
+		name  string
+		value float64
+		unit  string
+	}{
+		{"distance=5.2km", "distance", 5.2, "km"},
+		{"avg_hr=150", "avg_hr", 150, ""},
+		{"pace = 5.5 min/km", "pace", 5.5, "min/km"},
+		{"elevation=-12m", "elevation", -12, "m"},
+		{"bottles=2ea", "bottles", 2, "ea"},
+	}
+	for _, tt := range tests {
+		name, value, unit, err := parseWorkoutMetricFlag(tt.in)
+		if err != nil || name != tt.name || value != tt.value || unit != tt.unit {
+			t.Errorf("parseWorkoutMetricFlag(%q) = %q, %v, %q, %v", tt.in, name, value, unit, err)
+		}
+	}
+	for _, bad := range []string{"distance", "=5", "distance=km", "distance="} {
+		if _, _, _, err := parseWorkoutMetricFlag(bad); err == nil {
+			t.Errorf("parseWorkoutMetricFlag(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestWorkoutAddWithMetricsCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { workoutMetrics = nil }()
+
+	rootCmd.SetArgs([]string{"workout", "add", "run", "--metric", "distance=oops"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected a bad --metric to fail")
+	}
+	if ws, _ := testDB.ListWorkouts(ctx, nil, 0); len(ws) != 0 {
+		t.Fatalf("Expected no workout saved after a bad --metric, got %d", len(ws))
+	}
+	workoutMetrics = nil
+
+	rootCmd.SetArgs([]string{"workout", "add", "run", "--metric", "distance=5.2km", "-m", "avg_hr=150"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout add with metrics failed: %v", err)
+	}
+	ws, _ := testDB.ListWorkouts(ctx, nil, 0)
+	if len(ws) != 1 {
+		t.Fatalf("Expected 1 workout, got %d", len(ws))
+	}
+	wms, _ := testDB.ListWorkoutMetrics(ctx, ws[0].ID)
+	got := map[string]string{}
+	for _, m := range wms {
+		unit := ""
+		if m.Unit != nil {
+			unit = *m.Unit
+		}
+		got[m.MetricName] = strconv.FormatFloat(m.Value, 'f', -1, 64) + unit
+	}
+	if len(got) != 2 || got["distance"] != "5.2km" || got["avg_hr"] != "150" {
+		t.Errorf("workout metrics = %v", got)
+	}
+}
+
+func TestAgainCmdWithDB(t *testing.T) {
+	ctx := t.Context()
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { addNotes = ""; addForce = false; workoutMetrics = nil }()
+
+	rootCmd.SetArgs([]string{"again"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected again with no history to fail")
+	}
+
+	rootCmd.SetArgs([]string{"add", "weight", "82.5", "--notes", "morning"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	addNotes = ""
+	rootCmd.SetArgs([]string{"again", "83.1"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("again with a new value failed: %v", err)
+	}
+	addNotes = ""
+	rootCmd.SetArgs([]string{"again"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("again failed: %v", err)
+	}
+
+	weight := models.MetricWeight
+	metrics, _ := testDB.ListMetrics(ctx, &weight, 0)
+	if len(metrics) != 3 {
+		t.Fatalf("Expected 3 weights, got %d", len(metrics))
+	}
+	values := map[float64]int{}
+	for _, m := range metrics {
+		values[m.Value]++
+		if m.Notes == nil || *m.Notes != "morning" {
+			t.Errorf("weight %v lost its notes: %v", m.Value, m.Notes)
+		}
+	}
+	if values[82.5] != 1 || values[83.1] != 2 {
+		t.Errorf("weights = %v; want 82.5 once and 83.1 twice", values)
+	}
+
+	rootCmd.SetArgs([]string{"workout", "add", "run", "-m", "distance=5km"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("workout add failed: %v", err)
+	}
+	workoutMetrics = nil
+	rootCmd.SetArgs([]string{"again", "6"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected new values to be rejected for workout add")
+	}
+	rootCmd.SetArgs([]string{"again"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("again after workout add failed: %v", err)
+	}
+	ws, _ := testDB.ListWorkouts(ctx, nil, 0)
+	if len(ws) != 2 {
+		t.Fatalf("Expected 2 workouts, got %d", len(ws))
+	}
+	for _, w := range ws {
+		if wms, _ := testDB.ListWorkoutMetrics(ctx, w.ID); len(wms) != 1 {
+			t.Errorf("workout %s has %d metrics, want 1", w.ID, len(wms))
+		}
+	}
+}
+
+func TestInsightsCmdWithDB(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { insightsRules = "" }()
+
+	rootCmd.SetArgs([]string{"add", "sleep_hours", "5"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"insights"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("insights with built-in rules failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"insights", "--rules", filepath.Join(t.TempDir(), "missing.yaml")})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected a missing --rules file to fail")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	os.WriteFile(bad, []byte("rules: [{metric: sleep_hours, kind: days}]"), 0600)
+	rootCmd.SetArgs([]string{"insights", "--rules", bad})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected a rule without a threshold to fail")
+	}
+
+	good := filepath.Join(t.TempDir(), "good.yaml")
+	os.WriteFile(good, []byte("rules: [{metric: sleep_hours, kind: days, below: 6}]"), 0600)
+	rootCmd.SetArgs([]string{"insights", "--rules", good})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("insights with a rules file failed: %v", err)
+	}
+	rules, _ := insights.Load(good)
+	if obs, _ := insights.Evaluate(t.Context(), testDB, rules, time.Now()); len(obs) != 1 {
+		t.Errorf("Expected the sleep rule to fire, got %+v", obs)
+	}
+}
+
+func TestInsightsSummaryCmdWithDB(t *testing.T) {
+	_, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	defer func() { insightsDays = 30 }()
+
+	rootCmd.SetArgs([]string{"insights", "summary"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("insights summary with no data failed: %v", err)
+	}
+
+	rootCmd.SetArgs([]string{"add", "sleep_hours", "6.5"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	cfg, _ := config.Load()
+	cfg.Goals = map[string]float64{"sleep_hours": 7}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"insights", "summary", "--days", "7"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("insights summary failed: %v", err)
+	}
+
+	cfg.Goals = map[string]float64{"sleepiness": 1}
+	cfg.Save()
+	rootCmd.SetArgs([]string{"insights", "summary"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected a goal for an unknown metric to fail")
+	}
+
+	rootCmd.SetArgs([]string{"insights", "summary", "--days", "0"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected --days 0 to fail")
+	}
+}
+
+func TestTimezoneConfig(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	local := time.Local
+	defer func() { time.Local = local }()
+
+	cfg := &config.Config{Timezone: "Mars/Olympus"}
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	rootCmd.SetArgs([]string{"list"})
+	if err := rootCmd.Execute(); err == nil {
+		t.Error("Expected an unknown timezone to fail")
+	}
+
+	if err := setTimezone(); err == nil {
+		t.Error("Expected setTimezone to reject an unknown timezone")
+	}
+
+	cfg.Timezone = "Pacific/Kiritimati"
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := setTimezone(); err != nil {
+		t.Fatalf("setTimezone failed: %v", err)
+	}
+	if time.Local.String() != "Pacific/Kiritimati" {
+		t.Errorf("time.Local = %s, want the configured zone", time.Local)
+	}
+	rootCmd.SetArgs([]string{"add", "water", "500"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	total, err := storage.DailyTotal(t.Context(), testDB, models.MetricWater, time.Now().In(time.Local))
+	if err != nil || total.Sum != 500 {
+		t.Errorf("DailyTotal = %+v, %v; want 500 today in the configured zone", total, err)
+	}
+}
+
+func TestShellCompletion(t *testing.T) {
+	testDB, cleanup := setupTestCLI(t)
+	defer cleanup()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	m := models.NewMetric(models.MetricWeight, 82.5)
+	testDB.CreateMetric(t.Context(), m)
+	w := models.NewWorkout("bouldering")
+	testDB.CreateWorkout(t.Context(), w)
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	complete := func(args ...string) string {
+		t.Helper()
+		out.Reset()
+		rootCmd.SetArgs(append([]string{cobra.ShellCompRequestCmd}, args...))
+		if err := rootCmd.Execute(); err != nil {
+			t.Fatalf("__complete %v failed: %v", args, err)
+		}
+		return out.String()
+	}
+
+	if got := complete("add", "we"); !strings.Contains(got, "weight\tkg") || strings.Contains(got, "water") {
+		t.Errorf("add types = %q", got)
+	}
+	if got := complete("add", "b"); !strings.Contains(got, "bp\t") || !strings.Contains(got, "body_fat") {
+		t.Errorf("add types = %q; want bp and body_fat", got)
+	}
+	if got := complete("total", ""); !strings.Contains(got, "water") || strings.Contains(got, "weight") {
+		t.Errorf("total types = %q; want cumulative types only", got)
+	}
+	if got := complete("list", "--type", "mo"); !strings.Contains(got, "mood") {
+		t.Errorf("list --type = %q", got)
+	}
+	if got := complete("workout", "add", ""); !strings.Contains(got, "bouldering") {
+		t.Errorf("workout types = %q; want the stored type", got)
+	}
+	if got := complete("delete", m.ID.String()[:2]); !strings.Contains(got, m.ID.String()[:8]+"\tweight 82.5 kg") {
+		t.Errorf("delete IDs = %q", got)
+	}
+	if got := complete("workout", "show", ""); !strings.Contains(got, w.ID.String()[:8]+"\tbouldering") {
+		t.Errorf("workout IDs = %q", got)
+	}
+	if got := complete("delete", m.ID.String()[:8], ""); strings.Contains(got, m.ID.String()[:8]) {
+		t.Errorf("second delete arg = %q; want no IDs", got)
+	}
+}
+
+func TestMCPListToolsCmd(t *testing.T) {
+	_, cleanup := setupTestCLI(t)
+	defer cleanup()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	defer func() { mcpListTools = false }()
+	os.MkdirAll(filepath.Join(configHome, "health"), 0750)
+	writeConfig := func(cfg string) {
+		os.WriteFile(filepath.Join(configHome, "health", "config.json"), []byte(cfg), 0600)
+	}
+
+	writeConfig(`{"mcp": {"disabled_tools": ["delete_*"]}}`)
+	rootCmd.SetArgs([]string{"mcp", "--list-tools"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Errorf("mcp --list-tools failed: %v", err)
+	}
+
+	writeConfig(`{"mcp": {"enabled_tools": ["list_metric"]}}`)
+	rootCmd.SetArgs([]string{"mcp", "--list-tools"})
+	if err := rootCmd.Execute(); err == nil || !strings.Contains(err.Error(), "list_metric") {
+		t.Errorf("Expected the misspelled tool named in the error, got %v", err)
+	}
+}
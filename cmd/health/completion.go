@@ -0,0 +1,208 @@
+// ABOUTME: Dynamic shell completion for metric types, stored workout types, and ID prefixes.
+// ABOUTME: Cobra's built-in 'health completion <shell>' command generates the scripts.
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// completionLimit caps how many recent entries ID completion offers.
+const completionLimit = 50
+
+// completeFirstArg adapts a completion for the first positional argument;
+// later arguments get none.
+func completeFirstArg(complete func(cmd *cobra.Command, toComplete string) []string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return complete(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeFlag adapts a completion for a flag value.
+func completeFlag(complete func(cmd *cobra.Command, toComplete string) []string) cobra.CompletionFunc {
+	return func(cmd *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return complete(cmd, toComplete), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// sourceCompletions lists the known sources starting with toComplete.
+func sourceCompletions(_ *cobra.Command, toComplete string) []string {
+	var out []string
+	for _, s := range models.AllSources {
+		if strings.HasPrefix(s, toComplete) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// metricTypeCompletions lists metric types starting with toComplete, with
+// their units as descriptions.
+func metricTypeCompletions(_ *cobra.Command, toComplete string) []string {
+	var out []string
+	for _, mt := range models.AllMetricTypes {
+		if !strings.HasPrefix(string(mt), toComplete) {
+			continue
+		}
+		if unit := models.MetricUnits[mt]; unit != "" {
+			out = append(out, fmt.Sprintf("%s\t%s", mt, unit))
+		} else {
+			out = append(out, string(mt))
+		}
+	}
+	return out
+}
+
+// addTypeCompletions is metricTypeCompletions plus "bp", which 'health add'
+// splits into bp_sys and bp_dia.
+func addTypeCompletions(cmd *cobra.Command, toComplete string) []string {
+	out := metricTypeCompletions(cmd, toComplete)
+	if strings.HasPrefix("bp", toComplete) {
+		out = append([]string{"bp\tsystolic diastolic"}, out...)
+	}
+	return out
+}
+
+// cumulativeTypeCompletions lists the types 'health total' accepts.
+func cumulativeTypeCompletions(_ *cobra.Command, toComplete string) []string {
+	var out []string
+	for _, mt := range models.CumulativeMetricTypes {
+		if strings.HasPrefix(string(mt), toComplete) {
+			out = append(out, string(mt))
+		}
+	}
+	return out
+}
+
+// workoutTypeCompletions lists the workout types already in the store.
+func workoutTypeCompletions(cmd *cobra.Command, toComplete string) []string {
+	if repo == nil {
+		return nil
+	}
+	workouts, err := repo.QueryWorkouts(cmd.Context(), storage.WorkoutFilter{})
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, w := range workouts {
+		if strings.HasPrefix(w.WorkoutType, toComplete) && !slices.Contains(out, w.WorkoutType) {
+			out = append(out, w.WorkoutType)
+		}
+	}
+	return out
+}
+
+// metricIDCompletions offers ID prefixes of recent metrics, described by
+// type, value, and date.
+func metricIDCompletions(cmd *cobra.Command, toComplete string) []string {
+	if repo == nil {
+		return nil
+	}
+	metrics, err := repo.QueryMetrics(cmd.Context(), storage.MetricFilter{Limit: completionLimit})
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, m := range metrics {
+		id := m.ID.String()[:8]
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, fmt.Sprintf("%s\t%s %s %s %s", id, m.MetricType,
+				formatAmount(m.Value), m.Unit, m.RecordedAt.Local().Format("2006-01-02 15:04")))
+		}
+	}
+	return out
+}
+
+// workoutIDCompletions offers ID prefixes of recent workouts, described by
+// type and start time.
+func workoutIDCompletions(cmd *cobra.Command, toComplete string) []string {
+	if repo == nil {
+		return nil
+	}
+	workouts, err := repo.QueryWorkouts(cmd.Context(), storage.WorkoutFilter{Limit: completionLimit})
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, w := range workouts {
+		id := w.ID.String()[:8]
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, fmt.Sprintf("%s\t%s %s", id, w.WorkoutType, w.StartedAt.Local().Format("2006-01-02 15:04")))
+		}
+	}
+	return out
+}
+
+// entityIDCompletions offers ID prefixes of recent metrics and workouts,
+// for commands that take either.
+func entityIDCompletions(cmd *cobra.Command, toComplete string) []string {
+	return append(metricIDCompletions(cmd, toComplete), workoutIDCompletions(cmd, toComplete)...)
+}
+
+// sleepIDCompletions offers ID prefixes of recent sleep sessions.
+func sleepIDCompletions(cmd *cobra.Command, toComplete string) []string {
+	if repo == nil {
+		return nil
+	}
+	sessions, err := repo.ListSleepSessions(cmd.Context(), completionLimit)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, s := range sessions {
+		id := s.ID.String()[:8]
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, fmt.Sprintf("%s\t%s → %s", id,
+				s.BedTime.Local().Format("2006-01-02 15:04"), s.WakeTime.Local().Format("15:04")))
+		}
+	}
+	return out
+}
+
+// eventIDCompletions offers ID prefixes of events.
+func eventIDCompletions(cmd *cobra.Command, toComplete string) []string {
+	if repo == nil {
+		return nil
+	}
+	events, err := repo.ListEvents(cmd.Context())
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range events {
+		id := e.ID.String()[:8]
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, fmt.Sprintf("%s\t%s %s", id, e.Date.Local().Format("2006-01-02"), e.Name))
+		}
+	}
+	return out
+}
+
+// fastIDCompletions offers ID prefixes of recent fasts.
+func fastIDCompletions(cmd *cobra.Command, toComplete string) []string {
+	if repo == nil {
+		return nil
+	}
+	fasts, err := repo.ListFasts(cmd.Context(), completionLimit)
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, f := range fasts {
+		id := f.ID.String()[:8]
+		if strings.HasPrefix(id, toComplete) {
+			out = append(out, fmt.Sprintf("%s\t%s, %s h target", id,
+				f.StartedAt.Local().Format("2006-01-02 15:04"), formatAmount(f.TargetHours)))
+		}
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+// ABOUTME: CLI wrapper for running health commands unattended from cron.
+// ABOUTME: Logs every run to cron.log in the data dir and stays quiet unless the command fails.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+)
+
+// cronLogFile is the run log inside the data directory.
+const cronLogFile = "cron.log"
+
+var cronNotify bool
+
+// runCronChild runs health with args and returns its combined output.
+// Tests replace it to avoid spawning processes.
+var runCronChild = func(args []string) ([]byte, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("find health executable: %w", err)
+	}
+	return exec.Command(self, args...).CombinedOutput()
+}
+
+var cronCmd = &cobra.Command{
+	Use:   "cron [--notify] <command>...",
+	Short: "Run a command quietly, logging output and reporting failures",
+	Long: `Run another health command for an unattended job. Its output is
+appended to cron.log in the data directory either way, but only printed
+when the command fails. Since cron mails any output to MAILTO, you get
+mail on failures and silence otherwise.
+
+With --notify a failure also sends a desktop notification. Flags after
+the command name belong to that command.
+
+EXAMPLES:
+
+  */5 * * * * health cron remind due --notify
+  0 3 * * *   health cron export json -o ~/backups/health.json
+  0 9 * * *   health cron --notify alerts check`,
+	Args:         cobra.MinimumNArgs(1),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] == cmd.Name() {
+			return fmt.Errorf("cron cannot wrap itself")
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		start := time.Now()
+		out, runErr := runCronChild(args)
+		elapsed := time.Since(start).Round(time.Millisecond)
+
+		command := "health " + strings.Join(args, " ")
+		status := "ok"
+		if runErr != nil {
+			status = "FAILED: " + runErr.Error()
+		}
+		logPath := filepath.Join(cfg.GetDataDir(), cronLogFile)
+		logErr := appendCronLog(logPath, start, command, status, elapsed, out)
+
+		if runErr == nil {
+			return logErr
+		}
+
+		stderr := cmd.ErrOrStderr()
+		_, _ = stderr.Write(out)
+		if logErr != nil {
+			fmt.Fprintln(stderr, logErr)
+		}
+		if cronNotify {
+			if err := sendNotification("health cron failed", command); err != nil {
+				fmt.Fprintf(stderr, "could not send notification: %v\n", err)
+			}
+		}
+		return fmt.Errorf("%s failed: %w", command, runErr)
+	},
+}
+
+// appendCronLog records one run: a header line with the time, command,
+// outcome, and duration, followed by the command's output.
+func appendCronLog(path string, start time.Time, command, status string, elapsed time.Duration, out []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("create log dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open cron log: %w", err)
+	}
+	defer f.Close()
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "=== %s %s: %s (%s)\n", start.Format("2006-01-02 15:04:05"), command, status, elapsed)
+	b.Write(out)
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	if _, err := f.Write(b.Bytes()); err != nil {
+		return fmt.Errorf("write cron log: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	// Everything after the wrapped command's name is its own
+	cronCmd.Flags().SetInterspersed(false)
+	cronCmd.Flags().BoolVar(&cronNotify, "notify", false, "send a desktop notification when the command fails")
+	rootCmd.AddCommand(cronCmd)
+}
@@ -1,56 +1,297 @@
-// ABOUTME: CLI command for deleting health metrics.
-// ABOUTME: Supports deletion by full ID or ID prefix.
+// ABOUTME: CLI command for deleting metrics, workouts, and workout metrics.
+// ABOUTME: Finds the record by ID or prefix, or deletes in bulk by type, dates, location, and source.
 package main
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/attachment"
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/route"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	deleteType     string
+	deleteSince    string
+	deleteBefore   string
+	deleteLocation string
+	deleteSource   string
+	deleteWorkouts bool
+	deleteYes      bool
 )
 
 var deleteCmd = &cobra.Command{
-	Use:     "delete <id>",
+	Use:     "delete <id> | --type <type> [--since DATE] [--before DATE]",
 	Aliases: []string{"del", "rm"},
-	Short:   "Delete a health metric",
-	Long: `Delete a health metric by its ID or ID prefix.
+	Short:   "Delete a metric, workout, or workout metric, or many at once",
+	Long: `Delete a record by its ID or ID prefix: a metric, a workout, or a
+metric on a workout. Or delete every metric (or, with --workouts, every
+workout) matching --type, --since, --before, --location, and --source.
 
 You can use either the full UUID or just the first few characters (prefix).
-The ID prefix is shown in the first column of 'health list' output.
+The ID prefix is shown in the first column of 'health list' and
+'health workout list' output.
 
 EXAMPLES:
 
   health delete abc12345                    # Delete by 8-char prefix
   health delete abc12345-1234-1234-...     # Delete by full UUID
   health rm abc1                            # Short prefix (if unique)
+  health delete --type steps --source fitbit --since 2025-03-01
+  health delete --type weight --before 2020-01-01 --yes
+  health delete --workouts --source strava  # Workouts instead of metrics
+
+BULK DELETE:
+
+  Matching entries are counted by type first, then you're asked to
+  confirm; --yes skips the question, and is required when not run from a
+  terminal. --since is inclusive and --before exclusive; both take dates
+  or phrases like "yesterday". With --workouts, --type is a workout type.
 
 CAUTION:
 
-  This permanently deletes the metric. There is no undo.
-  If the prefix matches multiple metrics, an error is returned.`,
-	Args: cobra.ExactArgs(1),
+  This permanently deletes the record. There is no undo.
+  Deleting either half of a blood pressure reading deletes both.
+  Deleting a workout deletes its metrics and attached route.
+  Files attached with 'health attach' are deleted too.
+  If the prefix matches multiple records, of any kind, an error is returned.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		bulk := deleteType != "" || deleteSince != "" || deleteBefore != "" || deleteLocation != "" || deleteSource != "" || deleteWorkouts
+		if bulk && len(args) > 0 {
+			return fmt.Errorf("give an ID or filters, not both")
+		}
+		if bulk {
+			return deleteMatching(ctx, cmd.InOrStdin(), cmd.OutOrStdout())
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("delete needs an ID, or filters such as --type and --before")
+		}
 		idOrPrefix := args[0]
 
-		// First, try to get the metric to show what we're deleting
-		metric, err := repo.GetMetric(idOrPrefix)
+		// Find the record first so a bad ID gets a clear error
+		e, err := resolveEntity(ctx, idOrPrefix)
 		if err != nil {
-			return fmt.Errorf("metric not found: %s", idOrPrefix)
+			return err
+		}
+		switch e.Kind {
+		case storage.KindWorkout:
+			return deleteWorkout(ctx, e.Workout)
+		case storage.KindWorkoutMetric:
+			return deleteWorkoutMetric(ctx, e.WorkoutMetric)
 		}
 
-		if err := repo.DeleteMetric(idOrPrefix); err != nil {
+		deleted, err := storage.DeleteReading(ctx, repo, e.Metric.ID.String())
+		if err != nil {
 			return fmt.Errorf("failed to delete metric: %w", err)
 		}
 
-		color.Yellow("✗ Deleted %s", metric.MetricType)
-		fmt.Printf("  %s %.2f %s\n",
-			color.New(color.Faint).Sprint(metric.ID.String()[:8]),
-			metric.Value, metric.Unit)
+		for _, metric := range deleted {
+			if err := removeMetricAttachments(metric); err != nil {
+				return err
+			}
+			color.Yellow("✗ Deleted %s", metric.MetricType)
+			fmt.Printf("  %s %.2f %s\n",
+				color.New(color.Faint).Sprint(metric.ID.String()[:8]),
+				metric.Value, metric.Unit)
+		}
 
 		return nil
 	},
 }
 
+// removeMetricAttachments deletes the files attached to a deleted metric.
+func removeMetricAttachments(m *models.Metric) error {
+	if len(attachment.List(m.Metadata)) == 0 {
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	return attachment.RemoveMetric(cfg.GetDataDir(), m)
+}
+
+// deleteMatching deletes every metric, or with --workouts every workout,
+// matching the filter flags, after showing how many of each type match
+// and getting confirmation.
+func deleteMatching(ctx context.Context, in io.Reader, out io.Writer) error {
+	if deleteType == "" && deleteSince == "" && deleteBefore == "" && deleteLocation == "" && deleteSource == "" {
+		return fmt.Errorf("--workouts needs at least one of --type, --since, --before, --location, or --source")
+	}
+	var since, before *time.Time
+	for _, d := range []struct {
+		flag, value string
+		dst         **time.Time
+	}{{"since", deleteSince, &since}, {"before", deleteBefore, &before}} {
+		if d.value == "" {
+			continue
+		}
+		t, err := parseEntryTime(ctx, d.value)
+		if err != nil {
+			return fmt.Errorf("invalid --%s: %s", d.flag, d.value)
+		}
+		*d.dst = &t
+	}
+	var location, source *string
+	if deleteLocation != "" {
+		tag, err := resolveLocationTag(ctx, deleteLocation)
+		if err != nil {
+			return err
+		}
+		location = &tag
+	}
+	if deleteSource != "" {
+		source = &deleteSource
+	}
+
+	var ids []string
+	counts := make(map[string]int)
+	var remove func(id string) error
+	one, many := "metric", "metrics"
+	if deleteWorkouts {
+		one, many = "workout", "workouts"
+		filter := storage.WorkoutFilter{Location: location, Source: source, Since: since, Until: before}
+		if deleteType != "" {
+			filter.Type = &deleteType
+		}
+		workouts, err := repo.QueryWorkouts(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to find workouts: %w", err)
+		}
+		byID := make(map[string]*models.Workout, len(workouts))
+		for _, w := range workouts {
+			ids = append(ids, w.ID.String())
+			byID[w.ID.String()] = w
+			counts[w.WorkoutType]++
+		}
+		remove = func(id string) error {
+			w := byID[id]
+			if err := repo.DeleteWorkout(ctx, id); err != nil {
+				return err
+			}
+			if _, ok := w.Metadata[route.MetadataKey]; ok {
+				return removeAttachments(w)
+			}
+			return nil
+		}
+	} else {
+		filter := storage.MetricFilter{Location: location, Source: source, Since: since, Until: before}
+		if deleteType != "" {
+			if !models.IsValidMetricType(deleteType) {
+				return fmt.Errorf("unknown metric type: %s", deleteType)
+			}
+			mt := models.MetricType(deleteType)
+			filter.Type = &mt
+		}
+		metrics, err := storage.ReadingsMatching(ctx, repo, filter)
+		if err != nil {
+			return fmt.Errorf("failed to find metrics: %w", err)
+		}
+		byID := make(map[string]*models.Metric, len(metrics))
+		for _, m := range metrics {
+			ids = append(ids, m.ID.String())
+			byID[m.ID.String()] = m
+			counts[string(m.MetricType)]++
+		}
+		remove = func(id string) error {
+			if err := repo.DeleteMetric(ctx, id); err != nil {
+				return err
+			}
+			return removeMetricAttachments(byID[id])
+		}
+	}
+
+	if len(ids) == 0 {
+		_, _ = fmt.Fprintf(out, "No %s match.\n", many)
+		return nil
+	}
+	n := len(ids)
+	noun := plural(n, one, many)
+	types := make([]string, 0, len(counts))
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	_, _ = fmt.Fprintf(out, "%d %s %s:\n", n, noun, plural(n, "matches", "match"))
+	for _, t := range types {
+		_, _ = fmt.Fprintf(out, "  %-16s %d\n", t, counts[t])
+	}
+
+	if !deleteYes {
+		prompt := fmt.Sprintf("Delete these %d %s? [y/N] ", n, noun)
+		if n == 1 {
+			prompt = fmt.Sprintf("Delete this %s? [y/N] ", noun)
+		}
+		ok, err := confirmDelete(in, out, prompt)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	for i, id := range ids {
+		if err := remove(id); err != nil {
+			return fmt.Errorf("deleted %d of %d %s, then failed: %w", i, n, noun, err)
+		}
+	}
+	color.Yellow("✗ Deleted %d %s", n, noun)
+	return nil
+}
+
+// confirmDelete asks prompt on a terminal. Anywhere else it declines,
+// since a pipe or script should pass --yes instead.
+func confirmDelete(in io.Reader, out io.Writer, prompt string) (bool, error) {
+	inFile, isFile := in.(*os.File)
+	if !isFile || !isTerminal(int(inFile.Fd())) {
+		_, _ = fmt.Fprintln(out, "Not deleted: not run from a terminal. Use --yes to confirm.")
+		return false, nil
+	}
+	_, _ = fmt.Fprint(out, prompt)
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("failed to read response: %w", err)
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	if response != "y" && response != "yes" {
+		_, _ = fmt.Fprintln(out, "Nothing deleted.")
+		return false, nil
+	}
+	return true, nil
+}
+
+// deleteWorkoutMetric deletes one metric from a workout.
+func deleteWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	if err := repo.DeleteWorkoutMetric(ctx, wm.ID.String()); err != nil {
+		return fmt.Errorf("failed to delete workout metric: %w", err)
+	}
+	color.Yellow("✗ Deleted workout metric %s", wm.MetricName)
+	fmt.Printf("  %s %.2f\n", color.New(color.Faint).Sprint(wm.ID.String()[:8]), wm.Value)
+	return nil
+}
+
 func init() {
+	deleteCmd.Flags().StringVarP(&deleteType, "type", "t", "", "delete every entry of this type")
+	deleteCmd.Flags().StringVar(&deleteSince, "since", "", "only entries at or after this date")
+	deleteCmd.Flags().StringVar(&deleteBefore, "before", "", "only entries before this date")
+	deleteCmd.Flags().StringVar(&deleteLocation, "location", "", "only entries tagged with this location")
+	deleteCmd.Flags().StringVar(&deleteSource, "source", "", "only entries from this source (manual, mcp, fitbit, ...)")
+	deleteCmd.Flags().BoolVar(&deleteWorkouts, "workouts", false, "delete matching workouts instead of metrics")
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "delete without asking")
+	cobra.CheckErr(deleteCmd.RegisterFlagCompletionFunc("type", completeFlag(metricTypeCompletions)))
+	cobra.CheckErr(deleteCmd.RegisterFlagCompletionFunc("source", completeFlag(sourceCompletions)))
+	deleteCmd.ValidArgsFunction = completeFirstArg(entityIDCompletions)
 	rootCmd.AddCommand(deleteCmd)
 }
@@ -0,0 +1,219 @@
+// ABOUTME: CLI commands for derived metrics like BMI and net calories.
+// ABOUTME: Formulas and constants live in config; values are computed on read.
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/models"
+)
+
+var deriveUnit string
+
+var deriveCmd = &cobra.Command{
+	Use:     "derive",
+	Aliases: []string{"derived"},
+	Short:   "Computed metrics like BMI",
+	Long: `Define metrics computed from the ones you log, such as BMI or net calories.
+
+Formulas use metric types, constants, numbers, + - * / ^ and parentheses.
+Values are computed per day when you read them and are never stored: they
+show up in 'health list', the MCP summary, and exports.
+
+For each day an input was logged, cumulative inputs (calories, water, protein,
+carbs, fat) use that day's total; other inputs use their latest value so far.
+
+Formulas and constants are stored in ~/.config/health/config.json. Setting
+your height with 'health profile set height' adds bmi, body_fat_mass, and
+bmr without defining them here.
+
+EXAMPLES:
+
+  health derive set height 1.80
+  health derive add bmi "weight / height^2" --unit kg/m²
+  health derive add net_calories "calories - active_calories" --unit kcal
+  health derive list
+  health list --type bmi`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return deriveListCmd.RunE(cmd, args)
+	},
+}
+
+var deriveAddCmd = &cobra.Command{
+	Use:   "add <name> <formula>",
+	Short: "Define a derived metric",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		// Profile height and age count as constants too
+		set, err := cfg.DerivedSet()
+		if err != nil {
+			return err
+		}
+		f, err := derived.Parse(args[0], args[1], deriveUnit, set.Constants)
+		if err != nil {
+			return err
+		}
+		for _, d := range cfg.Derived {
+			if strings.EqualFold(d.Name, f.Name) {
+				return fmt.Errorf("derived metric %s already exists", f.Name)
+			}
+		}
+		cfg.Derived = append(cfg.Derived, config.DerivedConfig{Name: f.Name, Formula: args[1], Unit: deriveUnit})
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+
+		color.Green("✓ Added %s = %s", f.Name, args[1])
+		return nil
+	},
+}
+
+var deriveSetCmd = &cobra.Command{
+	Use:   "set <constant> <value>",
+	Short: "Set a constant used by formulas (e.g. height)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := strings.ToLower(args[0])
+		if models.IsValidMetricType(name) {
+			return fmt.Errorf("%s is a metric type; log it with 'health add' instead", name)
+		}
+		value, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("invalid value: %s", args[1])
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		if cfg.Constants == nil {
+			cfg.Constants = make(map[string]float64)
+		}
+		cfg.Constants[name] = value
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+
+		color.Green("✓ Set %s = %g", name, value)
+		return nil
+	},
+}
+
+var deriveListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List derived metrics with their latest values",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		set, err := cfg.DerivedSet()
+		if err != nil {
+			return err
+		}
+
+		if len(set.Formulas) == 0 {
+			fmt.Println("No derived metrics defined.")
+		} else {
+			values, err := set.FromRepo(cmd.Context(), repo)
+			if err != nil {
+				return fmt.Errorf("failed to compute derived metrics: %w", err)
+			}
+			latest := derived.Latest(values)
+
+			faint := color.New(color.Faint)
+			for _, f := range set.Formulas {
+				value := faint.Sprint("no data")
+				if m, ok := latest[f.Name]; ok {
+					value = fmt.Sprintf("%.2f %s %s", m.Value, m.Unit,
+						faint.Sprint(m.RecordedAt.Local().Format("2006-01-02")))
+				}
+				fmt.Printf("%s %s %s\n", padRight(f.Name, 16), padRight(f.Source, 32), value)
+			}
+		}
+
+		if len(set.Constants) > 0 {
+			names := make([]string, 0, len(set.Constants))
+			for name := range set.Constants {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Println()
+			for _, name := range names {
+				fmt.Printf("%s %g\n", padRight(name, 16), set.Constants[name])
+			}
+		}
+
+		return nil
+	},
+}
+
+var deriveDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a derived metric",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		for i, d := range cfg.Derived {
+			if !strings.EqualFold(d.Name, args[0]) {
+				continue
+			}
+			cfg.Derived = append(cfg.Derived[:i], cfg.Derived[i+1:]...)
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("save config: %w", err)
+			}
+			color.Yellow("✗ Deleted derived metric %s", d.Name)
+			return nil
+		}
+
+		if cfg.IsBuiltinDerived(strings.ToLower(args[0])) {
+			return fmt.Errorf("%s is computed from your profile height; it can't be deleted", strings.ToLower(args[0]))
+		}
+		return fmt.Errorf("derived metric not found: %s", args[0])
+	},
+}
+
+// loadDerivedSet returns the configured and profile-based derived metrics,
+// or nil if there are none.
+func loadDerivedSet() (*derived.Set, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	set, err := cfg.DerivedSet()
+	if err != nil || len(set.Formulas) == 0 {
+		return nil, err
+	}
+	return set, nil
+}
+
+func init() {
+	deriveAddCmd.Flags().StringVar(&deriveUnit, "unit", "", "unit shown with values (e.g. kg/m²)")
+
+	deriveCmd.AddCommand(deriveAddCmd)
+	deriveCmd.AddCommand(deriveSetCmd)
+	deriveCmd.AddCommand(deriveListCmd)
+	deriveCmd.AddCommand(deriveDeleteCmd)
+	rootCmd.AddCommand(deriveCmd)
+}
@@ -0,0 +1,113 @@
+// ABOUTME: CLI command for correcting a logged metric.
+// ABOUTME: Changes its type (remapping the unit), value, notes, or time.
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	editType  string
+	editValue string
+	editNotes string
+	editAt    string
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Correct a logged metric",
+	Long: `Correct a metric entry found by its ID or ID prefix.
+
+--type moves an entry logged under the wrong type, e.g. steps that were
+really calories. The unit becomes the new type's unit. A value in a unit
+the new type accepts is converted (sleep_hours to meditation minutes);
+otherwise the number is kept. Blood pressure readings can't be retyped.
+
+--value takes a unit suffix like 'health add' does ("180lb").
+
+EXAMPLES:
+
+  health edit abc12345 --type calories
+  health edit abc12345 --value 82.1
+  health edit abc12345 --notes "after breakfast" --at "yesterday 8am"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if editType == "" && editValue == "" && !cmd.Flags().Changed("notes") && editAt == "" {
+			return fmt.Errorf("nothing to change (use --type, --value, --notes, or --at)")
+		}
+
+		m, err := repo.GetMetric(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("metric not found: %s", args[0])
+		}
+		from := m.MetricType
+		partner, err := storage.ReadingPartner(ctx, repo, m)
+		if err != nil {
+			return err
+		}
+
+		if editType != "" && models.MetricType(editType) != m.MetricType {
+			if err := m.Retype(models.MetricType(editType)); err != nil {
+				return err
+			}
+		}
+		if editValue != "" {
+			value, err := models.ParseValue(m.MetricType, editValue)
+			if err != nil {
+				return err
+			}
+			m.Value = value
+		}
+		if cmd.Flags().Changed("notes") {
+			m.Notes = nil
+			if editNotes != "" {
+				m.WithNotes(editNotes)
+			}
+		}
+		if editAt != "" {
+			t, err := parseEntryTime(ctx, editAt)
+			if err != nil {
+				return fmt.Errorf("invalid timestamp: %s", editAt)
+			}
+			m.WithRecordedAt(t)
+		}
+
+		if err := repo.UpdateMetric(ctx, m); err != nil {
+			return fmt.Errorf("failed to update metric: %w", err)
+		}
+		// Both halves of a blood pressure reading keep the same time
+		if partner != nil && !partner.RecordedAt.Equal(m.RecordedAt) {
+			partner.WithRecordedAt(m.RecordedAt)
+			if err := repo.UpdateMetric(ctx, partner); err != nil {
+				return fmt.Errorf("failed to update %s: %w", partner.MetricType, err)
+			}
+		}
+
+		if m.MetricType != from {
+			color.Green("✓ Updated %s → %s", from, m.MetricType)
+		} else {
+			color.Green("✓ Updated %s", m.MetricType)
+		}
+		fmt.Printf("  %s %.2f %s\n",
+			color.New(color.Faint).Sprint(m.ID.String()[:8]),
+			m.Value, m.Unit)
+		return nil
+	},
+}
+
+func init() {
+	editCmd.Flags().StringVarP(&editType, "type", "t", "", "move the entry to this metric type")
+	editCmd.Flags().StringVar(&editValue, "value", "", "new value, optionally with a unit (e.g. 180lb)")
+	editCmd.Flags().StringVarP(&editNotes, "notes", "n", "", "replace the notes (empty to clear)")
+	editCmd.Flags().StringVar(&editAt, "at", "", "when it was measured (YYYY-MM-DD HH:MM, \"2 hours ago\", \"yesterday 7am\")")
+	editCmd.ValidArgsFunction = completeFirstArg(metricIDCompletions)
+	cobra.CheckErr(editCmd.RegisterFlagCompletionFunc("type", completeFlag(metricTypeCompletions)))
+	rootCmd.AddCommand(editCmd)
+}
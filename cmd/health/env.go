@@ -0,0 +1,223 @@
+// ABOUTME: CLI commands for environment readings (air quality, pollen, temperature).
+// ABOUTME: Fetches readings from a configurable provider and stores them as metrics.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/environment"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	envLat      float64
+	envLon      float64
+	envInterval time.Duration
+)
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Track environment readings (air quality, pollen, temperature)",
+	Long: `Import environmental conditions so they can be correlated with
+symptoms, sleep, and mood.
+
+Readings are stored as regular metrics: aqi, pollen, ambient_temp.
+
+CONFIGURATION (~/.config/health/config.json):
+
+  {
+    "environment": {
+      "latitude": 41.88,
+      "longitude": -87.63,
+      "air_quality_url": "https://air-quality-api.open-meteo.com/v1/air-quality",
+      "weather_url": "https://api.open-meteo.com/v1/forecast"
+    }
+  }
+
+  The URLs are optional and default to the public Open-Meteo APIs.`,
+}
+
+var envFetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "Fetch current environment readings",
+	Long: `Fetch the current AQI, pollen count, and outdoor temperature for the
+configured location and store them as metrics.
+
+Readings already stored for the same time are skipped, so it is safe to run
+this from cron. Use --interval to keep polling until interrupted.
+
+EXAMPLES:
+
+  health env fetch
+  health env fetch --lat 41.88 --lon -87.63
+  health env fetch --interval 1h`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		envCfg := config.EnvironmentConfig{}
+		if cfg.Environment != nil {
+			envCfg = *cfg.Environment
+		}
+		if cmd.Flags().Changed("lat") {
+			envCfg.Latitude = envLat
+		}
+		if cmd.Flags().Changed("lon") {
+			envCfg.Longitude = envLon
+		}
+		if cfg.Environment == nil && !(cmd.Flags().Changed("lat") && cmd.Flags().Changed("lon")) {
+			return fmt.Errorf("no location configured: set environment.latitude/longitude in config or pass --lat and --lon")
+		}
+
+		client := environment.NewClient(envCfg.AirQualityURL, envCfg.WeatherURL)
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		for {
+			if err := fetchEnvironment(ctx, client, envCfg.Latitude, envCfg.Longitude); err != nil {
+				if envInterval == 0 {
+					return err
+				}
+				color.Red("✗ %v", err)
+			}
+			if envInterval == 0 {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(envInterval):
+			}
+		}
+	},
+}
+
+// fetchEnvironment pulls one round of readings and stores any that are new.
+func fetchEnvironment(ctx context.Context, client *environment.Client, lat, lon float64) error {
+	readings, err := client.Current(ctx, lat, lon)
+	if err != nil {
+		return err
+	}
+
+	fresh, _, err := newMetrics(readings, metricAtKey, func(m *models.Metric) (bool, error) {
+		return hasMetricAt(ctx, m.MetricType, m.RecordedAt)
+	})
+	if err != nil {
+		return err
+	}
+	if err := repo.CreateMetrics(ctx, fresh); err != nil {
+		return fmt.Errorf("failed to store environment readings: %w", err)
+	}
+	for _, m := range fresh {
+		fmt.Printf("  %s %-12s %.1f %s\n",
+			color.New(color.Faint).Sprint(m.ID.String()[:8]),
+			m.MetricType, m.Value, m.Unit)
+	}
+
+	added := len(fresh)
+	if added == 0 {
+		fmt.Println("No new environment readings.")
+		return nil
+	}
+	color.Green("✓ Added %d environment reading(s)", added)
+	return nil
+}
+
+// newMetrics returns the metrics that neither repeat an earlier one's key
+// nor are already stored, as exists reports, along with how many were
+// skipped, so an importer can store the rest with one CreateMetrics call.
+func newMetrics(metrics []*models.Metric, key func(*models.Metric) string, exists func(*models.Metric) (bool, error)) ([]*models.Metric, int, error) {
+	var fresh []*models.Metric
+	skipped := 0
+	seen := make(map[string]bool)
+	for _, m := range metrics {
+		k := key(m)
+		if seen[k] {
+			skipped++
+			continue
+		}
+		seen[k] = true
+		found, err := exists(m)
+		if err != nil {
+			return nil, 0, err
+		}
+		if found {
+			skipped++
+			continue
+		}
+		fresh = append(fresh, m)
+	}
+	return fresh, skipped, nil
+}
+
+// metricAtKey identifies a metric by type and second, as hasMetricAt
+// matches them.
+func metricAtKey(m *models.Metric) string {
+	return string(m.MetricType) + " " + m.RecordedAt.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// hasMetricAt reports whether a metric of the given type is already stored at t.
+func hasMetricAt(ctx context.Context, mt models.MetricType, t time.Time) (bool, error) {
+	since := t.Truncate(time.Second)
+	until := since.Add(time.Second)
+	existing, err := repo.QueryMetrics(ctx, storage.MetricFilter{
+		Type:  &mt,
+		Since: &since,
+		Until: &until,
+		Limit: 1,
+	})
+	if err != nil {
+		return false, fmt.Errorf("check existing %s: %w", mt, err)
+	}
+	return len(existing) > 0, nil
+}
+
+func init() {
+	envFetchCmd.Flags().Float64Var(&envLat, "lat", 0, "latitude (overrides config)")
+	envFetchCmd.Flags().Float64Var(&envLon, "lon", 0, "longitude (overrides config)")
+	envFetchCmd.Flags().DurationVar(&envInterval, "interval", 0, "keep polling at this interval (e.g. 1h)")
+
+	envCmd.AddCommand(envFetchCmd)
+	rootCmd.AddCommand(envCmd)
+}
+
+// loadWorkoutEnricher builds a weather enricher from config.
+// It returns nil when no location is configured.
+func loadWorkoutEnricher() (*environment.WorkoutEnricher, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	if cfg.Environment == nil {
+		return nil, nil
+	}
+	return newWorkoutEnricher(cfg.Environment), nil
+}
+
+// newWorkoutEnricher builds a weather enricher for the given location settings.
+func newWorkoutEnricher(envCfg *config.EnvironmentConfig) *environment.WorkoutEnricher {
+	client := environment.NewClient(envCfg.AirQualityURL, envCfg.WeatherURL)
+	if envCfg.WeatherArchiveURL != "" {
+		client.ArchiveURL = envCfg.WeatherArchiveURL
+	}
+	return &environment.WorkoutEnricher{
+		Client:       client,
+		Latitude:     envCfg.Latitude,
+		Longitude:    envCfg.Longitude,
+		OutdoorTypes: envCfg.OutdoorWorkouts,
+	}
+}
@@ -0,0 +1,299 @@
+// ABOUTME: CLI commands for races and goal events to train towards.
+// ABOUTME: Counts down to each event and compares training volume with its weekly plan.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/training"
+)
+
+var (
+	eventType          string
+	eventWeeklyMinutes int
+	eventWeeklyKm      float64
+	eventNotes         string
+	eventAll           bool
+)
+
+var eventCmd = &cobra.Command{
+	Use:     "event",
+	Aliases: []string{"events", "race"},
+	Short:   "Count down to races and goal events",
+	Long: `Keep track of races and other goal dates, and how your training is
+going against a weekly plan.
+
+Run 'health event' on its own to see upcoming events. An event can carry
+a plan of weekly minutes and/or kilometers, counting only workouts of
+--type when it's set. 'health event status' compares this week and the
+average of the last four weeks with the plan. Upcoming events are also
+listed under 'health training load'.
+
+EXAMPLES:
+
+  health event add "Chicago Marathon" 2027-10-10 --type run --weekly-km 55 --weekly-minutes 300
+  health event add "Gran Fondo" 2027-06-13 --type ride --weekly-minutes 360
+  health event status
+  health event list --all
+  health event delete a1b2c3d4`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return eventListCmd.RunE(cmd, args)
+	},
+}
+
+var eventAddCmd = &cobra.Command{
+	Use:   "add <name> <date>",
+	Short: "Add a race or goal event",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		date, err := time.ParseInLocation("2006-01-02", args[1], time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", args[1])
+		}
+		if eventWeeklyMinutes < 0 || eventWeeklyKm < 0 {
+			return fmt.Errorf("weekly targets can't be negative")
+		}
+
+		e := models.NewEvent(args[0], date)
+		if eventType != "" {
+			e.WithWorkoutType(eventType)
+		}
+		if eventWeeklyMinutes > 0 {
+			e.WithWeeklyMinutes(eventWeeklyMinutes)
+		}
+		if eventWeeklyKm > 0 {
+			e.WithWeeklyKm(eventWeeklyKm)
+		}
+		if eventNotes != "" {
+			e.WithNotes(eventNotes)
+		}
+
+		if err := repo.CreateEvent(cmd.Context(), e); err != nil {
+			return fmt.Errorf("failed to add event: %w", err)
+		}
+
+		color.Green("✓ Added event")
+		fmt.Printf("  %s %s\n", color.New(color.Faint).Sprint(e.ID.String()[:8]), formatEvent(e, time.Now()))
+		return nil
+	},
+}
+
+var eventListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List upcoming events",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		events, err := listEvents(cmd.Context(), eventAll)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			if eventAll {
+				fmt.Println("No events found.")
+			} else {
+				fmt.Println("No upcoming events.")
+			}
+			return nil
+		}
+
+		now := time.Now()
+		faint := color.New(color.Faint)
+		for _, e := range events {
+			fmt.Printf("%s %s\n", faint.Sprint(e.ID.String()[:8]), formatEvent(e, now))
+		}
+		return nil
+	},
+}
+
+var eventStatusCmd = &cobra.Command{
+	Use:   "status [id]",
+	Short: "Days remaining and training volume against the plan",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		var events []*models.Event
+		if len(args) == 1 {
+			e, err := repo.GetEvent(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("event not found: %w", err)
+			}
+			events = []*models.Event{e}
+		} else {
+			var err error
+			if events, err = listEvents(ctx, false); err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				fmt.Println("No upcoming events. Add one with 'health event add'.")
+				return nil
+			}
+		}
+
+		now := time.Now()
+		for i, e := range events {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := printEventStatus(ctx, e, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+var eventDeleteCmd = &cobra.Command{
+	Use:     "delete <id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete an event",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := repo.DeleteEvent(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("failed to delete event: %w", err)
+		}
+		color.Yellow("✗ Deleted event %s", args[0])
+		return nil
+	},
+}
+
+// listEvents returns every event, or only those today or later unless all
+// is set.
+func listEvents(ctx context.Context, all bool) ([]*models.Event, error) {
+	events, err := repo.ListEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	if all {
+		return events, nil
+	}
+	now := time.Now()
+	var upcoming []*models.Event
+	for _, e := range events {
+		if e.DaysUntil(now) >= 0 {
+			upcoming = append(upcoming, e)
+		}
+	}
+	return upcoming, nil
+}
+
+// printEventStatus prints the countdown to an event and, when it has a
+// plan, this week's and the recent average training volume against it.
+func printEventStatus(ctx context.Context, e *models.Event, now time.Time) error {
+	fmt.Println(formatEvent(e, now))
+	if e.Notes != nil {
+		fmt.Printf("  %s\n", color.New(color.Faint).Sprint(*e.Notes))
+	}
+	if !e.HasPlan() || e.DaysUntil(now) < 0 {
+		return nil
+	}
+
+	since := training.WeekStart(now).AddDate(0, 0, -28)
+	workouts, err := workoutsWithMetrics(ctx, storage.WorkoutFilter{Type: e.WorkoutType, Since: &since})
+	if err != nil {
+		return err
+	}
+	thisWeek, average := training.Recent(workouts, now)
+
+	plan := ""
+	if e.WeeklyMinutes != nil {
+		plan = fmt.Sprintf("%d min", *e.WeeklyMinutes)
+	}
+	if e.WeeklyKm != nil {
+		if plan != "" {
+			plan += ", "
+		}
+		plan += fmt.Sprintf("%.1f km", *e.WeeklyKm)
+	}
+	plan += " a week"
+	if e.WorkoutType != nil {
+		plan += color.New(color.Faint).Sprintf(" (%s workouts)", *e.WorkoutType)
+	}
+	fmt.Printf("  Plan:         %s\n", plan)
+	fmt.Printf("  This week:    %s\n", formatAgainstPlan(e, thisWeek))
+	fmt.Printf("  Last 4 weeks: %s a week\n", formatAgainstPlan(e, average))
+	return nil
+}
+
+// formatAgainstPlan renders volume next to the share of the event's
+// weekly plan it covers, e.g. "120 min (40%), 18.0 km (36%)".
+func formatAgainstPlan(e *models.Event, v training.Volume) string {
+	percent := func(actual, planned float64) string {
+		p := actual / planned * 100
+		s := fmt.Sprintf("%.0f%%", p)
+		if p < 80 {
+			return color.YellowString(s)
+		}
+		return color.GreenString(s)
+	}
+	out := ""
+	if e.WeeklyMinutes != nil {
+		out = fmt.Sprintf("%.0f min (%s)", v.Minutes, percent(v.Minutes, float64(*e.WeeklyMinutes)))
+	}
+	if e.WeeklyKm != nil {
+		if out != "" {
+			out += ", "
+		}
+		out += fmt.Sprintf("%.1f km (%s)", v.DistanceKm, percent(v.DistanceKm, *e.WeeklyKm))
+	}
+	return out
+}
+
+// formatEvent renders an event's date, name, and countdown on one line.
+func formatEvent(e *models.Event, now time.Time) string {
+	days := e.DaysUntil(now)
+	var when string
+	switch {
+	case days == 0:
+		when = color.GreenString("today!")
+	case days < 0:
+		when = fmt.Sprintf("%d %s ago", -days, plural(-days, "day", "days"))
+	case days >= 14:
+		when = fmt.Sprintf("in %d days (%d weeks)", days, days/7)
+	default:
+		when = fmt.Sprintf("in %d %s", days, plural(days, "day", "days"))
+	}
+	return fmt.Sprintf("%s  %s  %s", e.Date.Local().Format("Mon 2006-01-02"), e.Name, color.New(color.Faint).Sprint(when))
+}
+
+// printUpcomingEvents lists upcoming events with their countdowns under a
+// report, or nothing when there are none.
+func printUpcomingEvents(ctx context.Context) error {
+	events, err := listEvents(ctx, false)
+	if err != nil || len(events) == 0 {
+		return err
+	}
+	now := time.Now()
+	fmt.Println("\nUpcoming events:")
+	for _, e := range events {
+		fmt.Printf("  %s\n", formatEvent(e, now))
+	}
+	return nil
+}
+
+func init() {
+	eventAddCmd.Flags().StringVarP(&eventType, "type", "t", "", "only count workouts of this type towards the plan")
+	eventAddCmd.Flags().IntVar(&eventWeeklyMinutes, "weekly-minutes", 0, "planned training minutes per week")
+	eventAddCmd.Flags().Float64Var(&eventWeeklyKm, "weekly-km", 0, "planned distance per week in km")
+	eventAddCmd.Flags().StringVarP(&eventNotes, "notes", "n", "", "notes, e.g. a goal time")
+	cobra.CheckErr(eventAddCmd.RegisterFlagCompletionFunc("type", completeFlag(workoutTypeCompletions)))
+	eventListCmd.Flags().BoolVar(&eventAll, "all", false, "include past events")
+
+	for _, c := range []*cobra.Command{eventStatusCmd, eventDeleteCmd} {
+		c.ValidArgsFunction = completeFirstArg(eventIDCompletions)
+	}
+
+	eventCmd.AddCommand(eventAddCmd)
+	eventCmd.AddCommand(eventListCmd)
+	eventCmd.AddCommand(eventStatusCmd)
+	eventCmd.AddCommand(eventDeleteCmd)
+	rootCmd.AddCommand(eventCmd)
+}
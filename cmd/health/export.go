@@ -1,22 +1,31 @@
 // ABOUTME: CLI commands for exporting and importing health data.
-// ABOUTME: Supports JSON, YAML, and Markdown export formats.
+// ABOUTME: Supports JSON, YAML, Markdown, iCalendar, Parquet, and emergency card exports.
 package main
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/harperreed/health/internal/chunked"
+	"github.com/harperreed/health/internal/config"
 	"github.com/harperreed/health/internal/models"
 	"github.com/harperreed/health/internal/storage"
 	"github.com/spf13/cobra"
 )
 
 var (
-	exportOutput string
-	exportType   string
-	exportSince  string
+	exportOutput    string
+	exportType      string
+	exportSince     string
+	exportChunks    string
+	exportChunkSize int
+	exportAnonymize bool
 )
 
 var exportCmd = &cobra.Command{
@@ -29,12 +38,42 @@ FORMATS:
   json       Full JSON export (suitable for backup/restore)
   yaml       YAML export (human-readable)
   markdown   Markdown tables (for documentation/sharing)
+  ics        Appointments as an iCalendar file (for calendar apps)
+  parquet    Metrics and workouts as two Parquet tables (for DuckDB,
+             pandas, Spark). -o health.parquet writes
+             health-metrics.parquet and health-workouts.parquet;
+             -o data/ writes data/metrics.parquet and
+             data/workouts.parquet.
+  emergency-card
+             Printable card with a QR code: blood type, allergies,
+             conditions, medications, and emergency contact from
+             'health profile'. Use -o card.svg for an SVG to print.
 
 OPTIONS:
 
   --output, -o   Write to file instead of stdout
-  --type, -t     Filter by metric type (markdown only)
+  --type, -t     Filter by metric type (markdown only; derived names work too)
   --since        Only include data since this date (YYYY-MM-DD)
+  --chunks DIR   Write checksummed chunk files and a manifest to DIR
+  --chunk-size   Chunk size in MB (default 16)
+  --anonymize    Share-safe JSON (json only): notes, metadata, places,
+                 appointments, and events removed, times cut to the day,
+                 and IDs replaced
+
+Chunked exports are for slow or unreliable destinations, such as a
+mounted S3 bucket. If a run is interrupted, running the same command
+again verifies the chunks already written and continues with the rest,
+using the snapshot taken by the first run. 'health import DIR' checks
+every chunk before importing.
+
+Derived metrics (see 'health derive') are included in every format. The
+JSON export lists them under "derived"; importing ignores them. The
+Parquet metrics table marks them with derived = true.
+
+Parquet columns are only ever added, at the end, so exports from older
+and newer versions can be queried together:
+
+  SELECT * FROM read_parquet('health-*-metrics.parquet', union_by_name = true);
 
 EXAMPLES:
 
@@ -42,20 +81,48 @@ EXAMPLES:
   health export json -o backup.json         # Save to file
   health export yaml                        # Export as YAML
   health export markdown --type weight      # Export weight as Markdown
-  health export markdown --since 2024-01-01 # Export data from 2024 onward`,
+  health export markdown --since 2024-01-01 # Export data from 2024 onward
+  health export ics -o appointments.ics     # Appointments for your calendar
+  health export parquet -o health.parquet   # Tables for DuckDB/pandas
+  health export parquet -o data/            # data/metrics.parquet, data/workouts.parquet
+  health export emergency-card -o card.svg  # Wallet card to print
+  health export json --chunks /mnt/s3/health-backup  # Resumable
+  health export json --anonymize -o share.json        # For researchers or bug reports`,
 	Args:      cobra.ExactArgs(1),
-	ValidArgs: []string{"json", "yaml", "markdown"},
+	ValidArgs: []string{"json", "yaml", "markdown", "ics", "parquet", "emergency-card"},
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		format := args[0]
+		if exportAnonymize && format != "json" {
+			return fmt.Errorf("--anonymize only applies to json exports")
+		}
 
 		var data []byte
 		var err error
 
+		// Derived metrics are computed into the export, never stored
+		set, err := loadDerivedSet()
+		if err != nil {
+			return err
+		}
+		var derive storage.DeriveFunc
+		if set != nil {
+			derive = set.Compute
+		}
+
+		if format == "parquet" {
+			return exportParquet(ctx, derive)
+		}
+
 		switch format {
 		case "json":
-			data, err = storage.ExportJSONFromRepo(repo)
+			if exportAnonymize {
+				data, err = storage.ExportJSONAnonymized(ctx, repo, derive)
+			} else {
+				data, err = storage.ExportJSONWithDerived(ctx, repo, derive)
+			}
 		case "yaml":
-			data, err = storage.ExportYAMLFromRepo(repo)
+			data, err = storage.ExportYAMLWithDerived(ctx, repo, derive)
 		case "markdown":
 			var metricType *models.MetricType
 			if exportType != "" {
@@ -70,19 +137,26 @@ EXAMPLES:
 				}
 				since = &t
 			}
-			md, err := storage.ExportMarkdownFromRepo(repo, metricType, since)
+			md, err := storage.ExportMarkdownWithDerived(ctx, repo, metricType, since, derive)
 			if err != nil {
 				return err
 			}
 			data = []byte(md)
+		case "ics":
+			data, err = storage.ExportICSFromRepo(ctx, repo)
+		case "emergency-card":
+			data, err = emergencyCard(ctx, strings.HasSuffix(strings.ToLower(exportOutput), ".svg"))
 		default:
-			return fmt.Errorf("unknown format: %s (use json, yaml, or markdown)", format)
+			return fmt.Errorf("unknown format: %s (use json, yaml, markdown, ics, parquet, or emergency-card)", format)
 		}
 
 		if err != nil {
 			return fmt.Errorf("export failed: %w", err)
 		}
 
+		if exportChunks != "" {
+			return writeChunkedExport(exportChunks, data)
+		}
 		if exportOutput != "" {
 			if err := os.WriteFile(exportOutput, data, 0600); err != nil {
 				return fmt.Errorf("failed to write file: %w", err)
@@ -96,27 +170,130 @@ EXAMPLES:
 	},
 }
 
+// exportParquet writes the metrics and workouts tables to two files named
+// after --output.
+func exportParquet(ctx context.Context, derive storage.DeriveFunc) error {
+	if exportOutput == "" {
+		return fmt.Errorf("parquet export needs --output (e.g. -o health.parquet)")
+	}
+	if exportChunks != "" {
+		return fmt.Errorf("--chunks is not supported for parquet")
+	}
+	metricsPath, workoutsPath, isDir := parquetPaths(exportOutput)
+	if isDir {
+		if err := os.MkdirAll(exportOutput, 0700); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	var metrics, workouts bytes.Buffer
+	if err := storage.ExportParquet(ctx, repo, derive, &metrics, &workouts); err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+	if err := os.WriteFile(metricsPath, metrics.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := os.WriteFile(workoutsPath, workouts.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	color.Green("Exported to %s and %s", metricsPath, workoutsPath)
+	return nil
+}
+
+// parquetPaths derives the two table files from --output: health.parquet
+// becomes health-metrics.parquet and health-workouts.parquet, while a
+// directory (an existing one, or any path ending in a separator) gets
+// metrics.parquet and workouts.parquet inside it.
+func parquetPaths(output string) (string, string, bool) {
+	info, err := os.Stat(output)
+	if strings.HasSuffix(output, "/") || strings.HasSuffix(output, string(filepath.Separator)) || (err == nil && info.IsDir()) {
+		return filepath.Join(output, "metrics.parquet"), filepath.Join(output, "workouts.parquet"), true
+	}
+	base := strings.TrimSuffix(output, filepath.Ext(output))
+	return base + "-metrics.parquet", base + "-workouts.parquet", false
+}
+
+// writeChunkedExport writes data to dir as a chunked export, resuming an
+// interrupted one. The first run stages its snapshot in the data directory
+// so a resumed run sends the same bytes even if data changed since.
+func writeChunkedExport(dir string, data []byte) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	stage := filepath.Join(cfg.GetDataDir(), "exports", chunked.Hash([]byte(abs))[:16]+".staged")
+
+	manifest, err := chunked.LoadManifest(dir)
+	if err != nil {
+		return err
+	}
+	resuming := false
+	if manifest != nil && !manifest.Complete() {
+		if staged, err := os.ReadFile(stage); err == nil && chunked.Hash(staged) == manifest.SHA256 {
+			data, resuming = staged, true
+		}
+	}
+	if !resuming {
+		manifest = chunked.Plan(data, int64(exportChunkSize)<<20, time.Now())
+		if err := os.MkdirAll(filepath.Dir(stage), 0750); err != nil {
+			return fmt.Errorf("create staging dir: %w", err)
+		}
+		if err := os.WriteFile(stage, data, 0600); err != nil {
+			return fmt.Errorf("stage export: %w", err)
+		}
+	}
+
+	res, err := chunked.Write(dir, data, manifest)
+	if err != nil {
+		return fmt.Errorf("chunked export stopped after %d new chunk(s), rerun to resume: %w", res.Written, err)
+	}
+	_ = os.Remove(stage)
+
+	if resuming {
+		color.Green("Resumed export to %s: %d chunk(s) verified, %d written", dir, res.Verified, res.Written)
+	} else {
+		color.Green("Exported to %s in %d chunk(s)", dir, res.Written)
+	}
+	return nil
+}
+
 var importCmd = &cobra.Command{
 	Use:   "import <file>",
 	Short: "Import health data from JSON",
 	Long: `Import health data from a JSON backup file.
 
-This imports metrics and workouts from a previously exported JSON file.
-Duplicate entries (same ID) will cause an error.
+This imports metrics and workouts from a previously exported JSON file,
+or from a directory written by 'health export json --chunks', whose
+chunks are verified against the manifest first. Duplicate entries (same
+ID) will cause an error.
+
+To pull data from a service instead, see 'health import fitbit --help',
+'health import oura --help', or 'health import withings --help'.
 
 EXAMPLES:
 
-  health import backup.json               # Import from file`,
+  health import backup.json               # Import from file
+  health import /mnt/s3/health-backup     # Import a chunked export`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		filename := args[0]
 
-		data, err := os.ReadFile(filename)
+		var data []byte
+		var err error
+		if info, statErr := os.Stat(filename); statErr == nil && info.IsDir() {
+			data, err = chunked.Read(filename)
+		} else {
+			data, err = os.ReadFile(filename)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to read file: %w", err)
 		}
 
-		if err := storage.ImportJSONToRepo(repo, data); err != nil {
+		if err := storage.ImportJSONToRepo(cmd.Context(), repo, data); err != nil {
 			return fmt.Errorf("import failed: %w", err)
 		}
 
@@ -129,6 +306,9 @@ func init() {
 	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "output file (default: stdout)")
 	exportCmd.Flags().StringVarP(&exportType, "type", "t", "", "filter by metric type (markdown only)")
 	exportCmd.Flags().StringVar(&exportSince, "since", "", "only include data since date (YYYY-MM-DD)")
+	exportCmd.Flags().BoolVar(&exportAnonymize, "anonymize", false, "strip personal details for sharing (json only)")
+	exportCmd.Flags().StringVar(&exportChunks, "chunks", "", "write a resumable chunked export to this directory")
+	exportCmd.Flags().IntVar(&exportChunkSize, "chunk-size", chunked.DefaultChunkSize>>20, "chunk size in MB (with --chunks)")
 
 	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(importCmd)
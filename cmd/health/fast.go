@@ -0,0 +1,262 @@
+// ABOUTME: CLI commands for intermittent fasting windows (health fast start/end/status).
+// ABOUTME: Tracks one running fast against a target and reports streaks of meeting it.
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	fastTarget float64
+	fastAt     string
+	fastNotes  string
+	fastLimit  int
+)
+
+var fastCmd = &cobra.Command{
+	Use:   "fast",
+	Short: "Track intermittent fasting windows",
+	Long: `Track fasting windows against a target length (default 16 hours).
+
+Start a fast after your last meal and end it with the first one. A fast
+that reaches its target extends your streak: the number of days in a row
+on which you finished a fast that met its target. Today doesn't count
+against the streak until it's over.
+
+Run 'health fast' on its own for the status.
+
+EXAMPLES:
+
+  health fast start                        # 16 hour target
+  health fast start --target 18 --at 20:00
+  health fast status
+  health fast end
+  health fast end --at "today 12:30"
+  health fast list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fastStatusCmd.RunE(cmd, args)
+	},
+}
+
+var fastStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start a fast",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		active, err := storage.ActiveFast(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("failed to load fasts: %w", err)
+		}
+		if active != nil {
+			return fmt.Errorf("already fasting since %s (end it with: health fast end)",
+				active.StartedAt.Local().Format("2006-01-02 15:04"))
+		}
+		if fastTarget <= 0 {
+			return fmt.Errorf("--target must be positive")
+		}
+
+		f := models.NewFast(fastTarget)
+		if fastAt != "" {
+			at, err := parseEntryTime(ctx, fastAt)
+			if err != nil {
+				return fmt.Errorf("invalid timestamp: %s", fastAt)
+			}
+			f.WithStartedAt(at)
+		}
+		if fastNotes != "" {
+			f.WithNotes(fastNotes)
+		}
+
+		if err := repo.CreateFast(ctx, f); err != nil {
+			return fmt.Errorf("failed to start fast: %w", err)
+		}
+
+		color.Green("✓ Started a %s hour fast", formatAmount(f.TargetHours))
+		fmt.Printf("  %s target reached at %s\n", color.New(color.Faint).Sprint(f.ID.String()[:8]),
+			f.TargetAt().Local().Format("Mon 15:04"))
+		return nil
+	},
+}
+
+var fastEndCmd = &cobra.Command{
+	Use:   "end",
+	Short: "End the running fast",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		f, err := storage.ActiveFast(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("failed to load fasts: %w", err)
+		}
+		if f == nil {
+			return fmt.Errorf("not fasting")
+		}
+
+		endedAt := time.Now()
+		if fastAt != "" {
+			if endedAt, err = parseEntryTime(ctx, fastAt); err != nil {
+				return fmt.Errorf("invalid timestamp: %s", fastAt)
+			}
+		}
+		if !endedAt.After(f.StartedAt) {
+			return fmt.Errorf("fast end must be after its start (%s)", f.StartedAt.Local().Format("2006-01-02 15:04"))
+		}
+
+		if err := repo.EndFast(ctx, f.ID.String(), endedAt); err != nil {
+			return fmt.Errorf("failed to end fast: %w", err)
+		}
+		f.EndedAt = &endedAt
+
+		if f.MetTarget(endedAt) {
+			color.Green("✓ Ended fast after %s, target met", formatFastHours(f.Elapsed(endedAt)))
+		} else {
+			color.Yellow("✓ Ended fast after %s, %s short of the %s hour target",
+				formatFastHours(f.Elapsed(endedAt)), formatFastHours(f.TargetAt().Sub(endedAt)), formatAmount(f.TargetHours))
+		}
+
+		fasts, err := repo.ListFasts(ctx, 0)
+		if err != nil {
+			return fmt.Errorf("failed to list fasts: %w", err)
+		}
+		if stats := models.SummarizeFasts(fasts, time.Now()); stats.CurrentStreak > 0 {
+			fmt.Printf("  Streak: %d day(s)\n", stats.CurrentStreak)
+		}
+		return nil
+	},
+}
+
+var fastStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the running fast and streaks",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fasts, err := repo.ListFasts(cmd.Context(), 0)
+		if err != nil {
+			return fmt.Errorf("failed to list fasts: %w", err)
+		}
+
+		now := time.Now()
+		faint := color.New(color.Faint)
+		var active *models.Fast
+		for _, f := range fasts {
+			if f.IsActive() {
+				active = f
+				break
+			}
+		}
+		if active == nil {
+			fmt.Println("Not fasting.")
+		} else {
+			fmt.Printf("Fasting for %s of %s hours %s\n", formatFastHours(active.Elapsed(now)),
+				formatAmount(active.TargetHours), fastProgress(active, now))
+			if active.MetTarget(now) {
+				color.Green("  Target reached at %s", active.TargetAt().Local().Format("Mon 15:04"))
+			} else {
+				fmt.Printf("  %s to go, until %s\n", formatFastHours(active.TargetAt().Sub(now)),
+					active.TargetAt().Local().Format("Mon 15:04"))
+			}
+		}
+
+		stats := models.SummarizeFasts(fasts, now)
+		if stats.Completed == 0 {
+			return nil
+		}
+		fmt.Println()
+		fmt.Printf("%s %d of %d fasts met their target\n", faint.Sprint("Completed:"), stats.MetTarget, stats.Completed)
+		fmt.Printf("%s %s h average, %s h longest\n", faint.Sprint("Length:   "),
+			formatAmount(stats.AverageHours), formatAmount(stats.LongestHours))
+		fmt.Printf("%s %d day(s), best %d\n", faint.Sprint("Streak:   "), stats.CurrentStreak, stats.LongestStreak)
+		return nil
+	},
+}
+
+var fastListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List fasts",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fasts, err := repo.ListFasts(cmd.Context(), fastLimit)
+		if err != nil {
+			return fmt.Errorf("failed to list fasts: %w", err)
+		}
+
+		if len(fasts) == 0 {
+			fmt.Println("No fasts found.")
+			return nil
+		}
+
+		now := time.Now()
+		faint := color.New(color.Faint)
+		for _, f := range fasts {
+			end := "now  "
+			if f.EndedAt != nil {
+				end = f.EndedAt.Local().Format("15:04")
+			}
+			mark := color.YellowString("✗")
+			if f.MetTarget(now) {
+				mark = color.GreenString("✓")
+			} else if f.IsActive() {
+				mark = " "
+			}
+			fmt.Printf("%s %s → %s  %s / %s h %s\n", faint.Sprint(f.ID.String()[:8]),
+				f.StartedAt.Local().Format("2006-01-02 15:04"), end,
+				padRight(formatFastHours(f.Elapsed(now)), 6), formatAmount(f.TargetHours), mark)
+		}
+		return nil
+	},
+}
+
+var fastDeleteCmd = &cobra.Command{
+	Use:     "delete <id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a fast",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := repo.DeleteFast(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("failed to delete fast: %w", err)
+		}
+		color.Yellow("✗ Deleted fast %s", args[0])
+		return nil
+	},
+}
+
+// formatFastHours renders a duration as hours and minutes, e.g. "14h05m".
+func formatFastHours(d time.Duration) string {
+	d = d.Round(time.Minute)
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// fastProgress draws a 20-cell bar of the running fast's progress.
+func fastProgress(f *models.Fast, now time.Time) string {
+	const width = 20
+	filled := int(float64(width) * f.Elapsed(now).Hours() / f.TargetHours)
+	filled = min(max(filled, 0), width)
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("·", width-filled) + "]"
+}
+
+func init() {
+	fastStartCmd.Flags().Float64Var(&fastTarget, "target", models.DefaultFastHours, "target length in hours")
+	fastStartCmd.Flags().StringVar(&fastAt, "at", "", "when the fast started (e.g. 20:00, \"yesterday 8pm\"; default now)")
+	fastStartCmd.Flags().StringVar(&fastNotes, "notes", "", "notes for the fast")
+	fastEndCmd.Flags().StringVar(&fastAt, "at", "", "when the fast ended (default now)")
+	fastListCmd.Flags().IntVarP(&fastLimit, "limit", "n", 20, "max number of results")
+	fastDeleteCmd.ValidArgsFunction = completeFirstArg(fastIDCompletions)
+
+	fastCmd.AddCommand(fastStartCmd)
+	fastCmd.AddCommand(fastEndCmd)
+	fastCmd.AddCommand(fastStatusCmd)
+	fastCmd.AddCommand(fastListCmd)
+	fastCmd.AddCommand(fastDeleteCmd)
+	rootCmd.AddCommand(fastCmd)
+}
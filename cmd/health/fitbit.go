@@ -0,0 +1,180 @@
+// ABOUTME: CLI command importing steps, heart rate, weight, and sleep from the Fitbit Web API.
+// ABOUTME: Remembers the last day synced in config so reruns only fetch what is new.
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/fitbit"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// fitbitTokenEnv holds the Fitbit access token so it stays out of shell history.
+const fitbitTokenEnv = "HEALTH_FITBIT_TOKEN"
+
+// fitbitFirstDays is how far back the first sync reaches without --from.
+const fitbitFirstDays = 30
+
+var (
+	fitbitToken string
+	fitbitFrom  string
+	fitbitTo    string
+)
+
+var importFitbitCmd = &cobra.Command{
+	Use:   "fitbit",
+	Short: "Import steps, heart rate, weight, and sleep from Fitbit",
+	Long: `Pull data from the Fitbit Web API for a range of days:
+
+  steps         daily total, recorded at 23:59 that day
+  heart_rate    daily resting heart rate, recorded at 23:59
+  weight        each weigh-in, plus body_fat when the scale measured it
+  sleep         each sleep session, with its sleep_hours metric
+
+Entries are recorded with source "fitbit", and anything already stored
+at the same time is skipped, so overlapping runs don't duplicate.
+
+The last day imported is kept in config.json ("fitbit.last_synced"); the
+next run without --from continues from the day after it. The first run
+fetches the last 30 days. Today is left out until --to includes it,
+since its totals are still growing.
+
+The token is an OAuth 2.0 access token with the activity, heartrate,
+weight, and sleep scopes (create a personal app at dev.fitbit.com).
+Pass it with --token or $HEALTH_FITBIT_TOKEN.
+
+EXAMPLES:
+
+  export HEALTH_FITBIT_TOKEN=...
+  health import fitbit                          # Everything new since the last run
+  health import fitbit --from 2024-01-01        # Backfill
+  health import fitbit --from 2025-03-01 --to 2025-03-31
+  0 6 * * * health cron import fitbit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		token := cmp.Or(fitbitToken, os.Getenv(fitbitTokenEnv))
+		if token == "" {
+			return fmt.Errorf("--token or $%s is required", fitbitTokenEnv)
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		today := time.Now()
+		today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+		to := today.AddDate(0, 0, -1)
+		if fitbitTo != "" {
+			if to, err = time.ParseInLocation("2006-01-02", fitbitTo, time.Local); err != nil {
+				return fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", fitbitTo)
+			}
+		}
+		from := today.AddDate(0, 0, -fitbitFirstDays)
+		switch {
+		case fitbitFrom != "":
+			if from, err = time.ParseInLocation("2006-01-02", fitbitFrom, time.Local); err != nil {
+				return fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", fitbitFrom)
+			}
+		case cfg.Fitbit != nil && cfg.Fitbit.LastSynced != "":
+			last, err := time.ParseInLocation("2006-01-02", cfg.Fitbit.LastSynced, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid fitbit.last_synced in config: %s", cfg.Fitbit.LastSynced)
+			}
+			from = last.AddDate(0, 0, 1)
+		}
+		if from.After(to) {
+			fmt.Println("Already up to date.")
+			return nil
+		}
+
+		if cfg.Fitbit == nil {
+			cfg.Fitbit = &config.FitbitConfig{}
+		}
+		client := fitbit.NewClient(token)
+		if cfg.Fitbit.APIURL != "" {
+			client.BaseURL = cfg.Fitbit.APIURL
+		}
+
+		var added, skipped int
+		for start := from; !start.After(to); start = start.AddDate(0, 0, fitbit.MaxDays) {
+			end := start.AddDate(0, 0, fitbit.MaxDays-1)
+			if end.After(to) {
+				end = to
+			}
+			data, err := client.Fetch(ctx, start, end)
+			if err != nil {
+				return err
+			}
+			a, s, err := storeFitbitData(ctx, data)
+			added, skipped = added+a, skipped+s
+			if err != nil {
+				return err
+			}
+
+			// Save progress after each window, so a rate limit partway
+			// through a backfill resumes where it stopped
+			if end.Before(today) && end.Format("2006-01-02") > cfg.Fitbit.LastSynced {
+				cfg.Fitbit.LastSynced = end.Format("2006-01-02")
+				if err := cfg.Save(); err != nil {
+					return fmt.Errorf("save sync cursor: %w", err)
+				}
+			}
+		}
+
+		color.Green("✓ Imported %d %s from Fitbit (%s to %s)", added, plural(added, "entry", "entries"),
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+		if skipped > 0 {
+			fmt.Printf("  %d already stored\n", skipped)
+		}
+		return nil
+	},
+}
+
+// storeFitbitData saves what one fetch returned, skipping entries already
+// stored at the same time. It returns how many were added and skipped.
+func storeFitbitData(ctx context.Context, data *fitbit.Data) (int, int, error) {
+	fresh, skipped, err := newMetrics(data.Metrics, metricAtKey, func(m *models.Metric) (bool, error) {
+		return hasMetricAt(ctx, m.MetricType, m.RecordedAt)
+	})
+	if err != nil {
+		return 0, skipped, err
+	}
+	if err := repo.CreateMetrics(ctx, fresh); err != nil {
+		return 0, skipped, fmt.Errorf("failed to store metrics: %w", err)
+	}
+	added := len(fresh)
+	for _, s := range data.Sleep {
+		// A session's sleep_hours metric is recorded at wake time
+		exists, err := hasMetricAt(ctx, models.MetricSleepHours, s.WakeTime)
+		if err != nil {
+			return added, skipped, err
+		}
+		if exists {
+			skipped++
+			continue
+		}
+		if _, err := storage.RecordSleepSession(ctx, repo, s, models.SourceFitbit); err != nil {
+			return added, skipped, fmt.Errorf("failed to add sleep session: %w", err)
+		}
+		added++
+	}
+	return added, skipped, nil
+}
+
+func init() {
+	importFitbitCmd.Flags().StringVar(&fitbitToken, "token", "", "Fitbit OAuth access token (or $"+fitbitTokenEnv+")")
+	importFitbitCmd.Flags().StringVar(&fitbitFrom, "from", "", "first day to import (YYYY-MM-DD; default: day after the last sync)")
+	importFitbitCmd.Flags().StringVar(&fitbitTo, "to", "", "last day to import (YYYY-MM-DD; default: yesterday)")
+
+	importCmd.AddCommand(importFitbitCmd)
+}
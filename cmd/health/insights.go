@@ -0,0 +1,176 @@
+// ABOUTME: CLI command printing rule-based observations about recent metrics.
+// ABOUTME: Rules come from a YAML file beside config.json, or built-in defaults.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/insights"
+	"github.com/harperreed/health/internal/models"
+)
+
+var (
+	insightsRules string
+	insightsDays  int
+)
+
+var insightsCmd = &cobra.Command{
+	Use:   "insights",
+	Short: "Observations about your recent data",
+	Long: `Check your recent metrics against a set of rules and print what they
+notice, such as "sleep_hours under 6 on 4 of the last 7 days" or
+"bp_sys trending up 3 weeks".
+
+Rules are read from ~/.config/health/insights.yaml (or insights_file in
+config.json, or --rules). Without a rules file, built-in rules check for
+short sleep, rising systolic pressure, low step counts, and resting heart
+rate up or HRV down against its baseline for three days running (possible
+illness or overtraining). The same
+observations are available to AI assistants as the health://insights
+MCP resource. 'health insights summary' gives a plain-language summary
+of the last 30 days instead.
+
+RULES FILE:
+
+  rules:
+    - name: short-sleep
+      metric: sleep_hours
+      kind: days          # at_least of the last N days cross the threshold
+      below: 6
+      at_least: 4
+      days: 7
+    - name: bp-rising
+      metric: bp_sys
+      kind: trend         # weekly averages rise (or fall) N weeks running
+      direction: up
+      weeks: 3
+    - name: low-steps
+      metric: steps
+      kind: average       # the average over the last N days crosses it
+      below: 5000
+      days: 7
+      message: "Fewer than 5000 steps a day this week"
+    - name: elevated-resting-hr
+      metric: heart_rate
+      kind: baseline      # each of the last N days is percent above the
+      direction: up       # average of the baseline_days before them
+      percent: 8
+      days: 3
+      baseline_days: 28
+      note: possible illness, stress, or overtraining
+
+  Daily values are the day's total for cumulative metrics (water, calories,
+  protein, carbs, fat) and the day's average for everything else.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules, err := loadInsightRules()
+		if err != nil {
+			return err
+		}
+
+		observations, err := insights.Evaluate(cmd.Context(), repo, rules, time.Now())
+		if err != nil {
+			return err
+		}
+		if len(observations) == 0 {
+			fmt.Println("Nothing stands out right now.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, o := range observations {
+			fmt.Printf("💡 %s %s\n", o.Message, faint.Sprintf("(%s)", o.Rule))
+		}
+		return nil
+	},
+}
+
+var insightsSummaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Plain-language summary of the last 30 days",
+	Long: `Summarize recent data in a few plain sentences: how weight and body fat
+moved, what sleep, steps, heart rate, and other metrics averaged, how many
+workouts you did, and how long it's been since the last one.
+
+Averages are compared with the daily targets under "goals" in config.json:
+
+  "goals": {"sleep_hours": 7, "steps": 8000, "weight": 80}
+
+The same summary is available to AI assistants as the
+health://insights/summary MCP resource.
+
+EXAMPLES:
+
+  health insights summary
+  health insights summary --days 7`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if insightsDays < 1 {
+			return fmt.Errorf("--days must be at least 1")
+		}
+		goals, err := loadGoals()
+		if err != nil {
+			return err
+		}
+
+		lines, err := insights.Summarize(cmd.Context(), repo, time.Now(), insightsDays, goals)
+		if err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			fmt.Printf("Nothing logged in the last %d days.\n", insightsDays)
+			return nil
+		}
+		fmt.Printf("Last %d days:\n", insightsDays)
+		for _, line := range lines {
+			fmt.Printf("  • %s\n", line)
+		}
+		return nil
+	},
+}
+
+// loadGoals reads the daily goals from config, rejecting unknown metric
+// types.
+func loadGoals() (map[string]float64, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	for name := range cfg.Goals {
+		if !models.IsValidMetricType(name) {
+			return nil, fmt.Errorf("goal for unknown metric type %q in config", name)
+		}
+	}
+	return cfg.Goals, nil
+}
+
+// loadInsightRules reads the rules file named by --rules or config, or
+// returns the built-in rules when the configured file doesn't exist.
+func loadInsightRules() ([]insights.Rule, error) {
+	if insightsRules != "" {
+		// An explicit file must exist; only the default falls back
+		path := config.ExpandPath(insightsRules)
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("insight rules: %w", err)
+		}
+		return insights.Load(path)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	return insights.Load(cfg.GetInsightsFile())
+}
+
+func init() {
+	insightsCmd.Flags().StringVar(&insightsRules, "rules", "", "rules file (default ~/.config/health/insights.yaml)")
+	insightsSummaryCmd.Flags().IntVarP(&insightsDays, "days", "d", 30, "number of days to summarize, ending today")
+	insightsCmd.AddCommand(insightsSummaryCmd)
+	rootCmd.AddCommand(insightsCmd)
+}
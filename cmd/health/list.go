@@ -1,19 +1,29 @@
 // ABOUTME: CLI command for listing health metrics.
-// ABOUTME: Supports filtering by type and limiting results.
+// ABOUTME: Supports filtering by type, location, and source, and limiting results.
 package main
 
 import (
+	"cmp"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/harperreed/health/internal/config"
 	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/reference"
+	"github.com/harperreed/health/internal/storage"
 	"github.com/spf13/cobra"
 )
 
 var (
-	listType  string
-	listLimit int
+	listType     string
+	listLimit    int
+	listLocation string
+	listSource   string
+	listWide     bool
 )
 
 var listCmd = &cobra.Command{
@@ -24,28 +34,62 @@ var listCmd = &cobra.Command{
 
 OUTPUT FORMAT:
 
-  Each line shows: ID  TIMESTAMP  TYPE  VALUE  UNIT  (NOTES)
+  Each line shows: ID  TIMESTAMP  TYPE  VALUE  UNIT  [RANGE]  @LOCATION  (NOTES)
 
   The ID is an 8-character prefix you can use with delete commands.
+  --wide adds a SOURCE column after the timestamp and shows notes in full.
+
+REFERENCE RANGES:
+
+  Blood pressure, resting heart rate, sleep, body fat, and BMI are labelled
+  with where they fall in public reference ranges ("elevated", "athlete
+  range"), with the sources cited below the list. Sleep ranges need a birth
+  date and body fat ranges need sex ('health profile set'). Set
+  "hide_reference_ranges": true in config.json to turn the labels off.
 
 FILTERING:
 
   Use --type to filter by metric type:
     weight, body_fat, bp_sys, bp_dia, heart_rate, hrv, temperature,
-    steps, sleep_hours, active_calories, water, calories, protein,
-    carbs, fat, mood, energy, stress, anxiety, focus, meditation
+    temp_deviation, readiness, steps, sleep_hours, deep_sleep,
+    rem_sleep, light_sleep, active_calories, vo2max, water, calories, protein,
+    carbs, fat, mood, energy, stress, anxiety, focus, meditation,
+    aqi, pollen, ambient_temp
+
+  Blood pressure readings are shown as one line ("bp 120/80 mmHg")
+  under the systolic ID. Filter by bp_sys or bp_dia to see one half.
+
+  Use --location to show only entries tagged with a location.
 
-  Note: Blood pressure is stored as bp_sys and bp_dia separately.
+  Use --source to show only entries from one source: manual,
+  apple_health, fitbit, oura, withings, environment, mcp, sync, or
+  estimate.
+  Entries logged before sources were recorded have none ("-").
+
+  Derived metrics (see 'health derive') are listed alongside, marked
+  "derived" in place of an ID. Use --type with a derived name (e.g.
+  --type bmi) to see its history.
 
 EXAMPLES:
 
   health list                    # Show last 20 metrics (all types)
   health list --type weight      # Show only weight entries
   health list --type mood -n 50  # Show last 50 mood entries
-  health list -t hrv             # Show HRV measurements`,
+  health list -t hrv             # Show HRV measurements
+  health list --location hotel   # Entries logged while traveling
+  health list --source fitbit -w # Imported entries, with their source`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		set, err := loadDerivedSet()
+		if err != nil {
+			return err
+		}
+
 		var metricType *models.MetricType
-		if listType != "" {
+		if set.Lookup(listType) != nil {
+			mt := models.MetricType(strings.ToLower(listType))
+			metricType = &mt
+		} else if listType != "" {
 			if !models.IsValidMetricType(listType) {
 				return fmt.Errorf("unknown metric type: %s", listType)
 			}
@@ -53,35 +97,183 @@ EXAMPLES:
 			metricType = &mt
 		}
 
-		metrics, err := repo.ListMetrics(metricType, listLimit)
-		if err != nil {
-			return fmt.Errorf("failed to list metrics: %w", err)
+		// --limit counts readings, and a blood pressure reading is two rows,
+		// so fetch enough rows for that many and cut the grouped entries down
+		filter := storage.MetricFilter{Type: metricType, Limit: listLimit * 2}
+		if listLocation != "" {
+			tag, err := resolveLocationTag(ctx, listLocation)
+			if err != nil {
+				return err
+			}
+			filter.Location = &tag
+		}
+		if listSource != "" {
+			if !models.IsValidSource(listSource) {
+				return fmt.Errorf("unknown source: %s (use %s)", listSource, strings.Join(models.AllSources, ", "))
+			}
+			filter.Source = &listSource
+		}
+
+		var metrics []*models.Metric
+		if set.Lookup(listType) == nil {
+			metrics, err = repo.QueryMetrics(ctx, filter)
+			if err != nil {
+				return fmt.Errorf("failed to list metrics: %w", err)
+			}
+		}
+
+		// Derived values have no location or source, so those filters leave them out.
+		if set != nil && filter.Location == nil && filter.Source == nil && (metricType == nil || set.Lookup(listType) != nil) {
+			values, err := set.FromRepo(ctx, repo)
+			if err != nil {
+				return fmt.Errorf("failed to compute derived metrics: %w", err)
+			}
+			metrics = mergeDerived(metrics, values, metricType, filter.Limit)
 		}
 
 		if len(metrics) == 0 {
 			fmt.Println("No metrics found.")
 			return nil
 		}
+		entries := models.GroupReadings(metrics)
+		if listLimit > 0 && len(entries) > listLimit {
+			entries = entries[:listLimit]
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		person := cfg.ReferencePerson(time.Now())
+		var notes citations
 
 		faint := color.New(color.Faint)
-		for _, m := range metrics {
-			notes := ""
+		for _, e := range entries {
+			m := e.Metric
+			metricType, value := string(m.MetricType), fmt.Sprintf("%.2f", m.Value)
+			if e.IsBloodPressure() {
+				metricType, value = "bp", e.Reading()
+			}
+			location := ""
+			if m.Location != nil {
+				location = faint.Sprintf(" @%s", *m.Location)
+			}
+			var ref *reference.Annotation
+			if person != nil {
+				if e.IsBloodPressure() {
+					ref = reference.ClassifyBP(m.Value, e.Diastolic.Value, *person)
+				} else {
+					ref = reference.Classify(string(m.MetricType), m.Value, *person)
+				}
+			}
+			label := ""
+			if ref != nil {
+				label = faint.Sprintf(" [%s%s]", ref.Label, notes.mark(ref.Source))
+			}
+			noteText := ""
 			if m.Notes != nil && *m.Notes != "" {
-				notes = faint.Sprintf(" (%s)", truncate(*m.Notes, 30))
+				if listWide {
+					noteText = faint.Sprintf(" (%s)", *m.Notes)
+				} else {
+					noteText = faint.Sprintf(" (%s)", truncate(*m.Notes, 30))
+				}
+			}
+			id := m.ID.String()[:8]
+			if set.Lookup(string(m.MetricType)) != nil {
+				id = padRight("derived", 8)
 			}
-			fmt.Printf("%s %s %s %.2f %s%s\n",
-				faint.Sprint(m.ID.String()[:8]),
-				faint.Sprint(m.RecordedAt.Format("2006-01-02 15:04")),
-				padRight(string(m.MetricType), 16),
-				m.Value,
+			when := m.RecordedAt.Local().Format("2006-01-02 15:04")
+			if listWide {
+				when += " " + padRight(cmp.Or(m.Source, "-"), 12)
+			}
+			fmt.Printf("%s %s %s %s %s%s%s%s\n",
+				faint.Sprint(id),
+				faint.Sprint(when),
+				padRight(metricType, 16),
+				value,
 				m.Unit,
-				notes)
+				label,
+				location,
+				noteText)
 		}
+		notes.print()
 
 		return nil
 	},
 }
 
+// mergeDerived interleaves derived values with stored metrics (both newest
+// first). When listing a derived type only, it takes the newest limit values;
+// otherwise it adds those inside the time span the stored metrics cover.
+func mergeDerived(metrics, values []*models.Metric, metricType *models.MetricType, limit int) []*models.Metric {
+	var oldest time.Time
+	if metricType == nil && limit > 0 && len(metrics) >= limit {
+		oldest = metrics[len(metrics)-1].RecordedAt
+	}
+
+	merged := append([]*models.Metric(nil), metrics...)
+	added := 0
+	for _, v := range values {
+		if metricType != nil && v.MetricType != *metricType {
+			continue
+		}
+		if v.RecordedAt.Before(oldest) {
+			continue
+		}
+		if metricType != nil && limit > 0 && added >= limit {
+			break
+		}
+		merged = append(merged, v)
+		added++
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].RecordedAt.After(merged[j].RecordedAt)
+	})
+	return merged
+}
+
+// citations numbers the sources behind reference labels in the order they
+// are first used, for footnotes under the output.
+type citations []reference.Source
+
+// mark returns the footnote marker for s, adding it if it's new.
+func (c *citations) mark(s reference.Source) string {
+	for i, known := range *c {
+		if known.ID == s.ID {
+			return superscript(i + 1)
+		}
+	}
+	*c = append(*c, s)
+	return superscript(len(*c))
+}
+
+// print writes the footnotes, if any.
+func (c citations) print() {
+	if len(c) == 0 {
+		return
+	}
+	faint := color.New(color.Faint)
+	fmt.Println()
+	for i, s := range c {
+		line := superscript(i+1) + " " + s.Citation
+		if s.URL != "" {
+			line += " " + s.URL
+		}
+		fmt.Println(faint.Sprint(line))
+	}
+}
+
+// superscript writes n with superscript digits.
+func superscript(n int) string {
+	digits := []rune("⁰¹²³⁴⁵⁶⁷⁸⁹")
+	var out []rune
+	for _, d := range strconv.Itoa(n) {
+		out = append(out, digits[d-'0'])
+	}
+	return string(out)
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -99,5 +291,10 @@ func padRight(s string, length int) string {
 func init() {
 	listCmd.Flags().StringVarP(&listType, "type", "t", "", "filter by metric type")
 	listCmd.Flags().IntVarP(&listLimit, "limit", "n", 20, "max number of results")
+	listCmd.Flags().StringVar(&listLocation, "location", "", "only entries tagged with this location")
+	listCmd.Flags().StringVar(&listSource, "source", "", "only entries from this source (manual, fitbit, mcp, ...)")
+	listCmd.Flags().BoolVarP(&listWide, "wide", "w", false, "show each entry's source and full notes")
+	cobra.CheckErr(listCmd.RegisterFlagCompletionFunc("type", completeFlag(metricTypeCompletions)))
+	cobra.CheckErr(listCmd.RegisterFlagCompletionFunc("source", completeFlag(sourceCompletions)))
 	rootCmd.AddCommand(listCmd)
 }
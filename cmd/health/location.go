@@ -0,0 +1,151 @@
+// ABOUTME: CLI commands for the named location registry.
+// ABOUTME: Locations tag metrics and workouts for home vs. travel analysis.
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	locationLat   float64
+	locationLon   float64
+	locationNotes string
+)
+
+var locationCmd = &cobra.Command{
+	Use:     "location",
+	Aliases: []string{"loc"},
+	Short:   "Manage named locations",
+	Long: `Register places like "home", "gym", or "office" so metrics and workouts
+can be tagged with where they happened.
+
+Any command with a --location flag accepts a registered name or raw
+coordinates as "lat,lon".
+
+EXAMPLES:
+
+  health location add gym --lat 41.89 --lon -87.62
+  health location add home
+  health add weight 82.5 --location home
+  health workout add lift --location gym
+  health workout list --location gym
+  health list --location 48.85,2.35`,
+}
+
+var locationAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Register a named location",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, _, ok, _ := models.ParseCoordinates(args[0]); ok {
+			return fmt.Errorf("location name %q looks like coordinates; pick a name", args[0])
+		}
+
+		l := models.NewLocation(args[0])
+		hasLat, hasLon := cmd.Flags().Changed("lat"), cmd.Flags().Changed("lon")
+		if hasLat != hasLon {
+			return fmt.Errorf("--lat and --lon must be given together")
+		}
+		if hasLat {
+			if _, _, _, err := models.ParseCoordinates(fmt.Sprintf("%g,%g", locationLat, locationLon)); err != nil {
+				return err
+			}
+			l.WithCoordinates(locationLat, locationLon)
+		}
+		if locationNotes != "" {
+			l.WithNotes(locationNotes)
+		}
+
+		if err := repo.CreateLocation(cmd.Context(), l); err != nil {
+			return fmt.Errorf("failed to add location: %w", err)
+		}
+
+		color.Green("✓ Added location %s", l.Name)
+		fmt.Printf("  %s %s\n", color.New(color.Faint).Sprint(l.ID.String()[:8]), formatCoordinates(l))
+
+		return nil
+	},
+}
+
+var locationListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List registered locations",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		locs, err := repo.ListLocations(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list locations: %w", err)
+		}
+
+		if len(locs) == 0 {
+			fmt.Println("No locations registered.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, l := range locs {
+			fmt.Printf("%s %s %s\n",
+				faint.Sprint(l.ID.String()[:8]),
+				padRight(l.Name, 16),
+				faint.Sprint(formatCoordinates(l)))
+		}
+
+		return nil
+	},
+}
+
+var locationDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a location from the registry",
+	Long: `Remove a location from the registry.
+
+Metrics and workouts already tagged with it keep their tag.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		l, err := repo.GetLocation(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("location not found: %s", args[0])
+		}
+
+		if err := repo.DeleteLocation(ctx, l.ID.String()); err != nil {
+			return fmt.Errorf("failed to delete location: %w", err)
+		}
+
+		color.Yellow("✗ Deleted location %s", l.Name)
+		return nil
+	},
+}
+
+// resolveLocationTag turns a --location value into the tag stored on an entry.
+func resolveLocationTag(ctx context.Context, s string) (string, error) {
+	return storage.ResolveLocationTag(ctx, repo, s)
+}
+
+// formatCoordinates renders a location's coordinates, if it has any.
+func formatCoordinates(l *models.Location) string {
+	if l.Latitude == nil || l.Longitude == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.4f, %.4f", *l.Latitude, *l.Longitude)
+}
+
+func init() {
+	locationAddCmd.Flags().Float64Var(&locationLat, "lat", 0, "latitude")
+	locationAddCmd.Flags().Float64Var(&locationLon, "lon", 0, "longitude")
+	locationAddCmd.Flags().StringVar(&locationNotes, "notes", "", "notes for the location")
+
+	locationCmd.AddCommand(locationAddCmd)
+	locationCmd.AddCommand(locationListCmd)
+	locationCmd.AddCommand(locationDeleteCmd)
+	rootCmd.AddCommand(locationCmd)
+}
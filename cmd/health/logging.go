@@ -0,0 +1,52 @@
+// ABOUTME: Sets up log/slog for every command from the root --verbose and --log-file flags.
+// ABOUTME: Logging is off unless asked for; --verbose adds storage, sync, and MCP timings.
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/harperreed/health/internal/config"
+)
+
+var (
+	logVerbose bool
+	logFile    string
+
+	// logCloser closes the --log-file opened for the current command.
+	logCloser io.Closer
+)
+
+// setupLogging points slog's default logger at --log-file, or at stderr
+// for --verbose alone. --verbose lowers the level to debug: storage query
+// timings, importer HTTP requests, CalDAV requests, and MCP calls. With neither flag nothing is logged.
+func setupLogging() error {
+	if logCloser != nil {
+		_ = logCloser.Close()
+		logCloser = nil
+	}
+
+	level := slog.LevelInfo
+	if logVerbose {
+		level = slog.LevelDebug
+	}
+
+	var w io.Writer
+	switch {
+	case logFile != "":
+		f, err := os.OpenFile(config.ExpandPath(logFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("open log file: %w", err)
+		}
+		w, logCloser = f, f
+	case logVerbose:
+		w = os.Stderr
+	default:
+		slog.SetDefault(slog.New(slog.DiscardHandler))
+		return nil
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level})))
+	return nil
+}
@@ -1,15 +1,41 @@
 // ABOUTME: Entry point for health CLI.
-// ABOUTME: Invokes the root Cobra command.
+// ABOUTME: Sets the configured timezone, then invokes the root Cobra command.
 package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"time"
+
+	"github.com/harperreed/health/internal/config"
+	_ "github.com/harperreed/health/internal/storage/postgres" // registers the "postgres" backend
 )
 
 func main() {
+	// A bad config or timezone is reported by the command that loads it
+	_ = setTimezone()
 	if err := rootCmd.Execute(); err != nil {
+		if logFile != "" {
+			slog.Error("command failed", "err", err)
+		}
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
+
+// setTimezone makes the configured timezone time.Local. Days, clock times,
+// and "today" are read in time.Local throughout, so it's set once here,
+// before any command or goroutine that reads it starts.
+func setTimezone() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	zone, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+	time.Local = zone
+	return nil
+}
@@ -0,0 +1,86 @@
+// ABOUTME: CLI command for store upkeep: orphan cleanup, VACUUM, JSONL compaction,
+// ABOUTME: and finding (optionally quarantining) markdown files that no longer parse.
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	maintenanceDryRun     bool
+	maintenanceQuarantine bool
+)
+
+var maintenanceCmd = &cobra.Command{
+	Use:   "maintenance",
+	Short: "Repair orphaned data and compact the store",
+	Long: `Tidy up the data store.
+
+  sqlite     Deletes workout metrics, sets, and comments whose workout is
+             gone and intakes whose medication is gone, unlinks sleep
+             sessions from deleted metrics, then VACUUMs the database.
+  jsonl      Rewrites health.jsonl with one line per live record.
+  markdown   Lists files whose frontmatter doesn't parse (they make listing
+             and lookups fail). --quarantine moves them to quarantine/ in
+             the data directory so you can fix them by hand.
+
+EXAMPLES:
+
+  health maintenance --dry-run        # See what would change
+  health maintenance
+  health maintenance --quarantine     # Markdown: move broken files aside`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rep, err := storage.Maintain(cmd.Context(), repo, storage.MaintenanceOptions{
+			DryRun:     maintenanceDryRun,
+			Quarantine: maintenanceQuarantine,
+		})
+		if err != nil {
+			return fmt.Errorf("maintenance failed: %w", err)
+		}
+
+		verb := "Removed"
+		if maintenanceDryRun {
+			verb = "Would remove"
+		}
+		for _, o := range rep.Orphans {
+			if o.Rows > 0 {
+				color.Yellow("%s %d orphaned %s", verb, o.Rows, o.Table)
+			}
+		}
+
+		switch {
+		case rep.Backend == "markdown":
+		case maintenanceDryRun:
+			fmt.Printf("Size: %s\n", formatBytes(rep.SizeBefore))
+		default:
+			fmt.Printf("Size: %s → %s\n", formatBytes(rep.SizeBefore), formatBytes(rep.SizeAfter))
+		}
+
+		for _, b := range rep.Broken {
+			color.Red("✗ %s: %v", b.Path, b.Err)
+		}
+		if rep.Quarantined {
+			color.Yellow("Moved %d %s to %s/", len(rep.Broken), plural(len(rep.Broken), "file", "files"), storage.QuarantineDir)
+		} else if len(rep.Broken) > 0 && !maintenanceDryRun {
+			fmt.Println("Use --quarantine to move them out of the way.")
+		}
+
+		if maintenanceDryRun {
+			return nil
+		}
+		color.Green("✓ Maintenance complete")
+		return nil
+	},
+}
+
+func init() {
+	maintenanceCmd.Flags().BoolVar(&maintenanceDryRun, "dry-run", false, "report what would change without changing anything")
+	maintenanceCmd.Flags().BoolVar(&maintenanceQuarantine, "quarantine", false, "move unparsable markdown files to quarantine/")
+	rootCmd.AddCommand(maintenanceCmd)
+}
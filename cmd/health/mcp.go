@@ -4,10 +4,14 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/fatih/color"
+	"github.com/harperreed/health/internal/config"
 	"github.com/harperreed/health/internal/mcp"
 	"github.com/spf13/cobra"
 )
@@ -39,25 +43,124 @@ CLAUDE DESKTOP CONFIGURATION:
 AVAILABLE TOOLS:
 
   add_metric          Record a health metric
+  add_metrics         Record several metrics in one call
+  add_blood_pressure  Record a blood pressure reading (120/80)
   list_metrics        List recent metrics
   delete_metric       Delete a metric by ID
+  update_metric       Correct a metric's value, type, time, or notes
   add_workout         Create a workout session
   add_workout_metric  Add a metric to a workout
   list_workouts       List recent workouts
   get_workout         Get workout with all metrics
   delete_workout      Delete a workout
   get_latest          Get most recent value for metric types
+  get_daily_total     Sum a cumulative metric over one day
+  get_average         Average a metric over recent days
+  add_sleep           Log a sleep session with bed/wake times
+  list_sleep          List recent sleep sessions
+  delete_sleep        Delete a sleep session
+  add_medication      Define a medication or supplement
+  list_medications    List medications and supplements
+  take_medication     Log a medication intake
+  medication_adherence  Doses taken vs. expected per medication
+  add_location        Register a named location
+  list_locations      List registered locations
+  export_data         Export a filtered JSON or markdown snapshot
+
+TOOL PERMISSIONS:
+
+  To give an assistant fewer tools, list them under "mcp" in config.json.
+  Entries are tool names or patterns; left-out tools aren't registered.
+
+  {
+    "mcp": {
+      "disabled_tools": ["delete_*"]
+    }
+  }
+
+  "enabled_tools" instead offers only the tools listed. Run
+  'health mcp --list-tools' to check which tools are on.
 
 AVAILABLE RESOURCES:
 
   health://metrics/recent     Recent metrics summary
   health://metrics/today      Today's metrics
-  health://workouts/recent    Recent workouts`,
+  health://workouts/recent    Recent workouts
+  health://summary            Latest of each metric, including derived ones
+                              and reference ranges, active threshold alerts,
+                              and insights
+  health://insights           Observations from 'health insights' rules
+  health://insights/summary   Plain-language summary of the last 30 days`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		server, err := mcp.NewServer(repo)
 		if err != nil {
 			return err
 		}
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		if cfg.MCP != nil {
+			if err := server.SetToolPermissions(cfg.MCP.EnabledTools, cfg.MCP.DisabledTools); err != nil {
+				return err
+			}
+		}
+		if mcpListTools {
+			for _, name := range server.ToolNames() {
+				if server.ToolPermitted(name) {
+					fmt.Printf("  %s\n", name)
+				} else {
+					fmt.Printf("  %s %s\n", name, color.New(color.Faint).Sprint("(disabled)"))
+				}
+			}
+			return nil
+		}
+
+		enricher, err := loadWorkoutEnricher()
+		if err != nil {
+			return err
+		}
+		server.SetWorkoutEnricher(enricher)
+
+		set, err := loadDerivedSet()
+		if err != nil {
+			return err
+		}
+		server.SetDerivedMetrics(set)
+
+		alerts, err := loadAlerts()
+		if err != nil {
+			return err
+		}
+		server.SetAlerts(alerts)
+
+		duplicates, err := cfg.Duplicates()
+		if err != nil {
+			return err
+		}
+		server.SetDuplicateWindows(duplicates)
+
+		rules, err := loadInsightRules()
+		if err != nil {
+			return err
+		}
+		server.SetInsightRules(rules)
+
+		goals, err := loadGoals()
+		if err != nil {
+			return err
+		}
+		server.SetGoals(goals)
+
+		server.SetReferencePerson(cfg.ReferencePerson(time.Now()))
+		zone, err := cfg.Location()
+		if err != nil {
+			return err
+		}
+		server.SetTimezone(zone)
+		if !ephemeral {
+			server.SetDataDir(cfg.GetDataDir())
+		}
 
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
@@ -74,6 +177,9 @@ AVAILABLE RESOURCES:
 	},
 }
 
+var mcpListTools bool
+
 func init() {
+	mcpCmd.Flags().BoolVar(&mcpListTools, "list-tools", false, "list the tools and which are disabled, then exit")
 	rootCmd.AddCommand(mcpCmd)
 }
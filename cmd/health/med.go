@@ -0,0 +1,270 @@
+// ABOUTME: CLI commands for medication and supplement logging.
+// ABOUTME: Defines medications, records intakes, and reports adherence history.
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	medDose     string
+	medSchedule string
+	medNotes    string
+	medAt       string
+	medTakeDose string
+	medTakeNote string
+	medDays     int
+)
+
+var medCmd = &cobra.Command{
+	Use:     "med",
+	Aliases: []string{"meds", "medication"},
+	Short:   "Manage medications and supplements",
+	Long: `Track medications and supplements and how consistently you take them.
+
+Medications can be referred to by name ("Vitamin D"), slug ("vitamin-d"),
+or ID prefix.
+
+SCHEDULES:
+
+  daily, twice daily (bid), 3x daily (tid), 4x daily (qid), weekly, as needed (prn)
+
+EXAMPLES:
+
+  health med add "Vitamin D" --dose "1000 IU" --schedule daily
+  health med take vitamin-d
+  health med take ibuprofen --dose "400 mg" --at "2024-12-14 08:00"
+  health med history --days 30
+  health med list`,
+}
+
+var medAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Define a medication or supplement",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := models.DosesPerDay(medSchedule); err != nil {
+			return err
+		}
+		if medDose == "" {
+			return fmt.Errorf("--dose is required")
+		}
+
+		m := models.NewMedication(args[0], medDose, medSchedule)
+		if medNotes != "" {
+			m.WithNotes(medNotes)
+		}
+
+		if err := repo.CreateMedication(cmd.Context(), m); err != nil {
+			return fmt.Errorf("failed to add medication: %w", err)
+		}
+
+		color.Green("✓ Added medication %s", m.Name)
+		fmt.Printf("  %s %s, %s\n",
+			color.New(color.Faint).Sprint(m.ID.String()[:8]),
+			m.Dose, m.Schedule)
+
+		return nil
+	},
+}
+
+var medListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List medications",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		meds, err := repo.ListMedications(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list medications: %w", err)
+		}
+
+		if len(meds) == 0 {
+			fmt.Println("No medications defined.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, m := range meds {
+			last := "never taken"
+			intakes, err := repo.ListMedicationIntakes(ctx, storage.IntakeFilter{MedicationID: &m.ID, Limit: 1})
+			if err != nil {
+				return fmt.Errorf("failed to list intakes: %w", err)
+			}
+			if len(intakes) > 0 {
+				last = "last " + intakes[0].TakenAt.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%s %s %s %s %s\n",
+				faint.Sprint(m.ID.String()[:8]),
+				padRight(m.Name, 16),
+				padRight(m.Dose, 10),
+				padRight(m.Schedule, 12),
+				faint.Sprint(last))
+		}
+
+		return nil
+	},
+}
+
+var medTakeCmd = &cobra.Command{
+	Use:   "take <name>",
+	Short: "Log taking a medication",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		m, err := repo.GetMedication(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("medication not found: %s", args[0])
+		}
+
+		in := models.NewMedicationIntake(m.ID)
+		if medAt != "" {
+			t, err := parseEntryTime(ctx, medAt)
+			if err != nil {
+				return fmt.Errorf("invalid timestamp: %s", medAt)
+			}
+			in.WithTakenAt(t)
+		}
+		if medTakeDose != "" {
+			in.WithDose(medTakeDose)
+		}
+		if medTakeNote != "" {
+			in.WithNotes(medTakeNote)
+		}
+
+		if err := repo.LogMedicationIntake(ctx, in); err != nil {
+			return fmt.Errorf("failed to log intake: %w", err)
+		}
+
+		dose := m.Dose
+		if in.Dose != nil {
+			dose = *in.Dose
+		}
+		color.Green("✓ Took %s", m.Name)
+		fmt.Printf("  %s %s at %s\n",
+			color.New(color.Faint).Sprint(in.ID.String()[:8]),
+			dose, in.TakenAt.Local().Format("2006-01-02 15:04"))
+
+		return nil
+	},
+}
+
+var medHistoryCmd = &cobra.Command{
+	Use:   "history [name]",
+	Short: "Show adherence and recent intakes",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if medDays <= 0 {
+			return fmt.Errorf("--days must be positive")
+		}
+
+		var meds []*models.Medication
+		if len(args) == 1 {
+			m, err := repo.GetMedication(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("medication not found: %s", args[0])
+			}
+			meds = []*models.Medication{m}
+		} else {
+			var err error
+			if meds, err = repo.ListMedications(ctx); err != nil {
+				return fmt.Errorf("failed to list medications: %w", err)
+			}
+		}
+
+		if len(meds) == 0 {
+			fmt.Println("No medications defined.")
+			return nil
+		}
+
+		until := time.Now()
+		since := until.AddDate(0, 0, -medDays)
+
+		faint := color.New(color.Faint)
+		fmt.Printf("Adherence, last %d days:\n", medDays)
+		for _, m := range meds {
+			intakes, err := repo.ListMedicationIntakes(ctx, storage.IntakeFilter{
+				MedicationID: &m.ID,
+				Since:        &since,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to list intakes: %w", err)
+			}
+
+			a := models.CalculateAdherence(m, intakes, since, until)
+			summary := fmt.Sprintf("%d taken (as needed)", a.Taken)
+			if a.Percent != nil {
+				pct := fmt.Sprintf("%3.0f%%", *a.Percent)
+				switch {
+				case *a.Percent >= 90:
+					pct = color.GreenString(pct)
+				case *a.Percent >= 70:
+					pct = color.YellowString(pct)
+				default:
+					pct = color.RedString(pct)
+				}
+				summary = fmt.Sprintf("%s  %d of %.0f doses", pct, a.Taken, a.Expected)
+			}
+			fmt.Printf("  %s %s\n", padRight(m.Name, 16), summary)
+
+			for _, in := range intakes {
+				dose := m.Dose
+				if in.Dose != nil {
+					dose = *in.Dose
+				}
+				fmt.Printf("    %s %s\n",
+					faint.Sprint(in.TakenAt.Local().Format("2006-01-02 15:04")), dose)
+			}
+		}
+
+		return nil
+	},
+}
+
+var medDeleteCmd = &cobra.Command{
+	Use:     "delete <name>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a medication and its intake history",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		m, err := repo.GetMedication(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("medication not found: %s", args[0])
+		}
+
+		if err := repo.DeleteMedication(ctx, m.ID.String()); err != nil {
+			return fmt.Errorf("failed to delete medication: %w", err)
+		}
+
+		color.Yellow("✗ Deleted medication %s", m.Name)
+		return nil
+	},
+}
+
+func init() {
+	medAddCmd.Flags().StringVar(&medDose, "dose", "", "dose per intake (e.g. \"1000 IU\")")
+	medAddCmd.Flags().StringVar(&medSchedule, "schedule", "daily", "how often it should be taken")
+	medAddCmd.Flags().StringVar(&medNotes, "notes", "", "notes for the medication")
+
+	medTakeCmd.Flags().StringVar(&medAt, "at", "", "when it was taken (YYYY-MM-DD HH:MM, \"this morning\", \"1 hour ago\")")
+	medTakeCmd.Flags().StringVar(&medTakeDose, "dose", "", "dose taken, if different from the usual")
+	medTakeCmd.Flags().StringVar(&medTakeNote, "notes", "", "notes for this intake")
+
+	medHistoryCmd.Flags().IntVarP(&medDays, "days", "d", 7, "number of days to report on")
+
+	medCmd.AddCommand(medAddCmd)
+	medCmd.AddCommand(medListCmd)
+	medCmd.AddCommand(medTakeCmd)
+	medCmd.AddCommand(medHistoryCmd)
+	medCmd.AddCommand(medDeleteCmd)
+	rootCmd.AddCommand(medCmd)
+}
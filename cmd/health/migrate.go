@@ -1,10 +1,10 @@
-// ABOUTME: CLI command for migrating health data between storage backends.
-// ABOUTME: Supports sqlite-to-markdown and markdown-to-sqlite with safety checks.
+// ABOUTME: CLI commands for migrating health data between storage backends and upgrading the SQLite schema.
+// ABOUTME: Supports any pair of registered backends (sqlite, markdown, jsonl) with safety checks.
 package main
 
 import (
 	"fmt"
-	"path/filepath"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
@@ -25,10 +25,70 @@ verify the migration was successful then update config.json manually.
 Examples:
   health migrate --to markdown
   health migrate --to sqlite --data-dir ~/health-sqlite
-  health migrate --to markdown --force`,
+  health migrate --to markdown --force
+  health migrate --to jsonl --data-dir ~/health-jsonl
+
+See 'health migrate schema' for SQLite schema versions.`,
 	RunE: runMigrate,
 }
 
+var migrateSchemaStatus bool
+
+var migrateSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Show the SQLite schema version and upgrade history",
+	Long: `The SQLite database records which schema migrations it has had.
+Opening it with a newer build applies any pending ones automatically,
+after copying the database to health.db.pre-vN-<time>.bak next to it.
+
+Without flags this reports the current version; --status lists every
+applied migration, anything pending, and the backups kept.
+
+Examples:
+  health migrate schema
+  health migrate schema --status`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, ok := repo.(*storage.DB)
+		if !ok {
+			return fmt.Errorf("schema versions only apply to the sqlite backend")
+		}
+		st, err := db.SchemaStatus(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		if up := db.Upgraded(); up != nil && up.From > 0 {
+			color.Green("✓ Upgraded schema v%d → v%d", up.From, up.To)
+		}
+		if len(st.Pending) == 0 {
+			fmt.Printf("Schema version %d (up to date)\n", st.Version)
+		} else {
+			color.Yellow("Schema version %d of %d", st.Version, st.Latest)
+		}
+		if !migrateSchemaStatus {
+			return nil
+		}
+
+		fmt.Println()
+		faint := color.New(color.Faint)
+		for _, m := range st.Applied {
+			fmt.Printf("  %04d %-20s %s\n", m.Version, m.Name, faint.Sprint("applied "+m.AppliedAt.Local().Format("2006-01-02 15:04")))
+		}
+		for _, name := range st.Pending {
+			fmt.Printf("  %s %s\n", name, color.YellowString("pending"))
+		}
+		if len(st.Backups) > 0 {
+			fmt.Println()
+			fmt.Println("Backups from before upgrades:")
+			for _, b := range st.Backups {
+				fmt.Printf("  %s\n", b)
+			}
+		}
+		return nil
+	},
+}
+
 var (
 	migrateTo      string
 	migrateDataDir string
@@ -37,7 +97,9 @@ var (
 
 func init() {
 	rootCmd.AddCommand(migrateCmd)
-	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "target backend (sqlite or markdown)")
+	migrateCmd.AddCommand(migrateSchemaCmd)
+	migrateSchemaCmd.Flags().BoolVar(&migrateSchemaStatus, "status", false, "list applied and pending migrations and backups")
+	migrateCmd.Flags().StringVar(&migrateTo, "to", "", "target backend ("+strings.Join(storage.Backends(), ", ")+")")
 	migrateCmd.Flags().StringVar(&migrateDataDir, "data-dir", "", "target data directory (defaults to current config data_dir)")
 	migrateCmd.Flags().BoolVar(&migrateForce, "force", false, "allow writing into a non-empty target directory")
 	_ = migrateCmd.MarkFlagRequired("to")
@@ -54,8 +116,11 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 	targetBackend := migrateTo
 
 	// Validate target backend
-	if targetBackend != "sqlite" && targetBackend != "markdown" {
-		return fmt.Errorf("invalid target backend %q: must be \"sqlite\" or \"markdown\"", targetBackend)
+	if !storage.IsBackend(targetBackend) {
+		return fmt.Errorf("invalid target backend %q: must be one of %s", targetBackend, strings.Join(storage.Backends(), ", "))
+	}
+	if targetBackend == "memory" {
+		return fmt.Errorf("the memory backend keeps nothing once health exits; migrate to a backend that stores data")
 	}
 	if targetBackend == sourceBackend {
 		return fmt.Errorf("target backend %q is the same as the current backend", targetBackend)
@@ -88,7 +153,7 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 	}()
 
 	// Open target storage
-	dst, err := openTargetStorage(targetBackend, targetDataDir)
+	dst, err := storage.OpenBackend(targetBackend, targetDataDir)
 	if err != nil {
 		return fmt.Errorf("open target storage (%s): %w", targetBackend, err)
 	}
@@ -97,6 +162,13 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 			_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "warning: closing target storage: %v\n", cerr)
 		}
 	}()
+	if ms, ok := dst.(*storage.MarkdownStore); ok && cfg.MarkdownLayout != "" {
+		layout, err := storage.ParseMarkdownLayout(cfg.MarkdownLayout)
+		if err != nil {
+			return err
+		}
+		ms.SetLayout(layout)
+	}
 
 	// Print plan
 	color.Yellow("Migrating health data:")
@@ -105,7 +177,7 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Run migration
-	summary, err := storage.MigrateData(src, dst)
+	summary, err := storage.MigrateData(cmd.Context(), src, dst)
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
@@ -115,6 +187,16 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("  Metrics:         %d\n", summary.Metrics)
 	fmt.Printf("  Workouts:        %d\n", summary.Workouts)
 	fmt.Printf("  Workout Metrics: %d\n", summary.WorkoutMetrics)
+	fmt.Printf("  Workout Sets:    %d\n", summary.WorkoutSets)
+	fmt.Printf("  Comments:        %d\n", summary.WorkoutComments)
+	fmt.Printf("  Sleep Sessions:  %d\n", summary.SleepSessions)
+	fmt.Printf("  Medications:     %d\n", summary.Medications)
+	fmt.Printf("  Med Intakes:     %d\n", summary.Intakes)
+	fmt.Printf("  Locations:       %d\n", summary.Locations)
+	fmt.Printf("  Trips:           %d\n", summary.Trips)
+	fmt.Printf("  Fasts:           %d\n", summary.Fasts)
+	fmt.Printf("  Appointments:    %d\n", summary.Appointments)
+	fmt.Printf("  Events:          %d\n", summary.Events)
 	fmt.Println()
 	color.Yellow("Note: config.json was NOT updated. To switch to the new backend, edit:")
 	fmt.Printf("  %s\n", config.GetConfigPath())
@@ -126,16 +208,3 @@ func runMigrate(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
-
-// openTargetStorage creates a Repository implementation for the given backend and data directory.
-func openTargetStorage(backend, dataDir string) (storage.Repository, error) {
-	switch backend {
-	case "sqlite":
-		dbPath := filepath.Join(dataDir, "health.db")
-		return storage.Open(dbPath)
-	case "markdown":
-		return storage.NewMarkdownStore(dataDir)
-	default:
-		return nil, fmt.Errorf("unknown backend: %q", backend)
-	}
-}
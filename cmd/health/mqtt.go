@@ -0,0 +1,190 @@
+// ABOUTME: CLI command publishing latest metrics to MQTT for Home Assistant.
+// ABOUTME: Sends retained discovery configs so each metric appears as an HA sensor.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/mqtt"
+)
+
+// mqttPasswordEnv holds the broker password so it stays out of shell history.
+const mqttPasswordEnv = "HEALTH_MQTT_PASSWORD"
+
+var (
+	mqttBroker          string
+	mqttUser            string
+	mqttClientID        string
+	mqttTopicPrefix     string
+	mqttDiscoveryPrefix string
+)
+
+// haDeviceClasses are the Home Assistant device classes for metric types
+// whose units HA understands. haUnits respells units the way HA expects;
+// AQI and 1-10 scales are unitless.
+var (
+	haDeviceClasses = map[models.MetricType]string{
+		models.MetricWeight:      "weight",
+		models.MetricTemperature: "temperature",
+		models.MetricAmbientTemp: "temperature",
+		models.MetricSleepHours:  "duration",
+		models.MetricDeepSleep:   "duration",
+		models.MetricREMSleep:    "duration",
+		models.MetricLightSleep:  "duration",
+		models.MetricMeditation:  "duration",
+		models.MetricAQI:         "aqi",
+	}
+	haUnits = map[string]string{"hours": "h", "AQI": "", "scale": ""}
+)
+
+var mqttCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Publish metrics to MQTT (Home Assistant)",
+}
+
+var mqttPublishCmd = &cobra.Command{
+	Use:   "publish --broker <host>",
+	Short: "Publish latest metrics as Home Assistant sensors",
+	Long: `Publish the latest value of every metric to an MQTT broker, along with
+Home Assistant discovery configs, so weight, steps, mood, and the rest
+show up as sensors on a "Health" device without any YAML.
+
+For each metric type this sends two retained messages:
+
+  homeassistant/sensor/health/<type>/config   discovery config
+  health/<type>/state                         {"value": ..., "recorded_at": ...}
+
+The recorded time is available as a sensor attribute. Derived metrics
+(see 'health derive') are published too. Run it from cron to keep the
+sensors current; unchanged values are simply sent again.
+
+The broker is host[:port] or a mqtt:// or mqtts:// (TLS) URL. The
+password is read from $HEALTH_MQTT_PASSWORD.
+
+EXAMPLES:
+
+  health mqtt publish --broker homeassistant.local --user health
+  health mqtt publish --broker mqtts://broker.example.com --user me
+  */15 * * * * health cron mqtt publish --broker homeassistant.local --user health`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if mqttBroker == "" {
+			return fmt.Errorf("--broker is required")
+		}
+		ctx := cmd.Context()
+
+		metrics, err := repo.ListMetrics(ctx, nil, 0)
+		if err != nil {
+			return fmt.Errorf("failed to list metrics: %w", err)
+		}
+		set, err := loadDerivedSet()
+		if err != nil {
+			return err
+		}
+		latest := derived.Latest(append(metrics, set.Compute(metrics)...))
+		if len(latest) == 0 {
+			fmt.Println("No metrics to publish.")
+			return nil
+		}
+
+		client, err := mqtt.Dial(ctx, mqttBroker, mqtt.Options{
+			ClientID: mqttClientID,
+			Username: mqttUser,
+			Password: os.Getenv(mqttPasswordEnv),
+		})
+		if err != nil {
+			return err
+		}
+		defer func() { _ = client.Close() }()
+
+		for _, name := range sortedKeys(latest) {
+			m := latest[name]
+			config, err := json.Marshal(haSensorConfig(m.MetricType, m.Unit))
+			if err != nil {
+				return err
+			}
+			configTopic := fmt.Sprintf("%s/sensor/health/%s/config", mqttDiscoveryPrefix, name)
+			if err := client.Publish(configTopic, config, true); err != nil {
+				return err
+			}
+			state, err := json.Marshal(map[string]any{
+				"value":       m.Value,
+				"recorded_at": m.RecordedAt.Format(time.RFC3339),
+			})
+			if err != nil {
+				return err
+			}
+			if err := client.Publish(haStateTopic(name), state, true); err != nil {
+				return err
+			}
+		}
+
+		color.Green("✓ Published %d %s to %s", len(latest), plural(len(latest), "sensor", "sensors"), mqttBroker)
+		return nil
+	},
+}
+
+// haStateTopic is where a metric's value is published.
+func haStateTopic(name string) string {
+	return mqttTopicPrefix + "/" + name + "/state"
+}
+
+// haName turns a metric name into a sensor name, e.g. "body_fat" into
+// "Body fat"; HA shows it after the device name.
+func haName(name string) string {
+	name = strings.ReplaceAll(name, "_", " ")
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// haSensorConfig is the Home Assistant MQTT discovery config for a metric.
+func haSensorConfig(mt models.MetricType, unit string) map[string]any {
+	name := string(mt)
+	config := map[string]any{
+		"name":                  haName(name),
+		"unique_id":             "health_" + name,
+		"object_id":             "health_" + name,
+		"state_topic":           haStateTopic(name),
+		"value_template":        "{{ value_json.value }}",
+		"json_attributes_topic": haStateTopic(name),
+		"state_class":           "measurement",
+		"device": map[string]any{
+			"identifiers":  []string{"health_cli"},
+			"name":         "Health",
+			"model":        "health CLI",
+			"manufacturer": "health",
+		},
+	}
+	if class, ok := haDeviceClasses[mt]; ok {
+		config["device_class"] = class
+	}
+	if u, ok := haUnits[unit]; ok {
+		unit = u
+	}
+	if unit != "" {
+		config["unit_of_measurement"] = unit
+	}
+	return config
+}
+
+func init() {
+	mqttPublishCmd.Flags().StringVar(&mqttBroker, "broker", "", "MQTT broker host[:port] or mqtt(s):// URL")
+	mqttPublishCmd.Flags().StringVar(&mqttUser, "user", "", "broker username (password from $"+mqttPasswordEnv+")")
+	mqttPublishCmd.Flags().StringVar(&mqttClientID, "client-id", "health-cli", "MQTT client ID")
+	mqttPublishCmd.Flags().StringVar(&mqttTopicPrefix, "topic-prefix", "health", "prefix of the state topics")
+	mqttPublishCmd.Flags().StringVar(&mqttDiscoveryPrefix, "discovery-prefix", "homeassistant", "Home Assistant discovery prefix")
+
+	mqttCmd.AddCommand(mqttPublishCmd)
+	rootCmd.AddCommand(mqttCmd)
+}
@@ -0,0 +1,201 @@
+// ABOUTME: CLI command importing readiness, HRV, sleep stages, and temperature from Oura.
+// ABOUTME: Imports each metric once per day, recognizing earlier imports by their source.
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/oura"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// ouraTokenEnv holds the Oura access token so it stays out of shell history.
+const ouraTokenEnv = "HEALTH_OURA_TOKEN"
+
+// ouraDefaultDays is how far back an import reaches without --since.
+const ouraDefaultDays = 30
+
+var (
+	ouraToken string
+	ouraSince string
+	ouraUntil string
+)
+
+var importOuraCmd = &cobra.Command{
+	Use:   "oura",
+	Short: "Import readiness, HRV, sleep stages, and temperature from Oura",
+	Long: `Pull data from the Oura API for a range of days:
+
+  readiness        daily readiness score
+  temp_deviation   body temperature deviation from your baseline
+  hrv              average HRV during the main sleep
+  deep_sleep       hours in each sleep stage of the main sleep
+  rem_sleep
+  light_sleep
+  sleep            the main sleep as a session, with its sleep_hours
+
+Oura files a night under the day you woke up. That day's metrics are
+recorded at wake time, or at 23:59 if there was no main sleep; naps
+are left out.
+
+Entries are attributed to the "oura" source. A metric already imported
+for a day is skipped, as is a sleep session whose sleep_hours
+is already stored at the same wake time, so reruns over the same range
+don't duplicate.
+
+The token is a personal access token or OAuth access token from
+cloud.ouraring.com. Pass it with --token or $HEALTH_OURA_TOKEN.
+
+EXAMPLES:
+
+  export HEALTH_OURA_TOKEN=...
+  health import oura                           # The last 30 days
+  health import oura --since 2024-01-01        # Backfill
+  health import oura --since 2025-03-01 --until 2025-03-31
+  0 11 * * * health cron import oura --since yesterday`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		token := cmp.Or(ouraToken, os.Getenv(ouraTokenEnv))
+		if token == "" {
+			return fmt.Errorf("--token or $%s is required", ouraTokenEnv)
+		}
+
+		today := time.Now()
+		today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.Local)
+		from, err := ouraDay(ouraSince, today, today.AddDate(0, 0, -ouraDefaultDays))
+		if err != nil {
+			return err
+		}
+		to, err := ouraDay(ouraUntil, today, today)
+		if err != nil {
+			return err
+		}
+		if from.After(to) {
+			return fmt.Errorf("--since %s is after --until %s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		client := oura.NewClient(token)
+		if cfg.Oura != nil && cfg.Oura.APIURL != "" {
+			client.BaseURL = cfg.Oura.APIURL
+		}
+
+		days, err := client.Fetch(ctx, from, to)
+		if err != nil {
+			return err
+		}
+		added, skipped, err := storeOuraDays(ctx, days)
+		if err != nil {
+			return err
+		}
+
+		color.Green("✓ Imported %d %s from Oura (%s to %s)", added, plural(added, "entry", "entries"),
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+		if skipped > 0 {
+			fmt.Printf("  %d already stored\n", skipped)
+		}
+		return nil
+	},
+}
+
+// ouraDay parses a --since/--until value: YYYY-MM-DD, "today", or
+// "yesterday". Empty means def.
+func ouraDay(s string, today, def time.Time) (time.Time, error) {
+	switch s {
+	case "":
+		return def, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", s)
+	}
+	return d, nil
+}
+
+// storeOuraDays saves what Fetch returned, skipping metrics already
+// imported for their day and sleep sessions already stored. It returns
+// how many entries were added and skipped.
+func storeOuraDays(ctx context.Context, days []*oura.Day) (int, int, error) {
+	var metrics []*models.Metric
+	for _, d := range days {
+		metrics = append(metrics, d.Metrics(time.Local)...)
+	}
+	fresh, skipped, err := newMetrics(metrics, ouraDayKey, func(m *models.Metric) (bool, error) {
+		return hasImportedOn(ctx, m.MetricType, m.RecordedAt, models.SourceOura)
+	})
+	if err != nil {
+		return 0, skipped, err
+	}
+	if err := repo.CreateMetrics(ctx, fresh); err != nil {
+		return 0, skipped, fmt.Errorf("failed to store metrics: %w", err)
+	}
+	added := len(fresh)
+
+	for _, d := range days {
+		if d.Sleep == nil {
+			continue
+		}
+		exists, err := hasMetricAt(ctx, models.MetricSleepHours, d.Sleep.WakeTime)
+		if err != nil {
+			return added, skipped, err
+		}
+		if exists {
+			skipped++
+			continue
+		}
+		s := models.NewSleepSession(d.Sleep.BedTime, d.Sleep.WakeTime)
+		if _, err := storage.RecordSleepSession(ctx, repo, s, models.SourceOura); err != nil {
+			return added, skipped, fmt.Errorf("failed to add sleep session: %w", err)
+		}
+		added++
+	}
+	return added, skipped, nil
+}
+
+// ouraDayKey identifies a metric by type and day, as hasImportedOn
+// matches them.
+func ouraDayKey(m *models.Metric) string {
+	return string(m.MetricType) + " " + m.RecordedAt.Format("2006-01-02")
+}
+
+// hasImportedOn reports whether a metric of type mt from source is stored
+// on the same local day as t.
+func hasImportedOn(ctx context.Context, mt models.MetricType, t time.Time, source string) (bool, error) {
+	since := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	until := since.AddDate(0, 0, 1)
+	n, err := repo.CountMetrics(ctx, storage.MetricFilter{
+		Type:   &mt,
+		Source: &source,
+		Since:  &since,
+		Until:  &until,
+	})
+	if err != nil {
+		return false, fmt.Errorf("check existing %s: %w", mt, err)
+	}
+	return n > 0, nil
+}
+
+func init() {
+	importOuraCmd.Flags().StringVar(&ouraToken, "token", "", "Oura access token (or $"+ouraTokenEnv+")")
+	importOuraCmd.Flags().StringVar(&ouraSince, "since", "", "first day to import (YYYY-MM-DD, today, yesterday; default: 30 days ago)")
+	importOuraCmd.Flags().StringVar(&ouraUntil, "until", "", "last day to import (YYYY-MM-DD, today, yesterday; default: today)")
+
+	importCmd.AddCommand(importOuraCmd)
+}
@@ -0,0 +1,312 @@
+// ABOUTME: CLI commands for the emergency profile and the printable emergency card.
+// ABOUTME: Profile lives in config only, so it never leaves the machine with exports or sync.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/hrzones"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/qr"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Emergency profile (blood type, allergies, contact)",
+	Long: `Keep the details a first responder needs: blood type, allergies,
+conditions, and who to call. 'health export emergency-card' combines them
+with your medications into a printable card with a QR code. Birth date and
+sex also pick the right reference ranges (sleep by age, body fat by sex)
+for values in 'health list'.
+
+Max heart rate or your own zone bounds give workouts with avg_hr and
+max_hr metrics a time-in-zone breakdown in 'health workout show' and
+'health workout zones'. Without hr-zones, zones start at 50, 60, 70, 80,
+and 90% of max-hr. Neither appears on the emergency card.
+
+Setting your height turns on derived body composition metrics: bmi and
+body_fat_mass (kg), plus bmr (kcal, Mifflin-St Jeor) once birth date and
+sex are set. They show up wherever derived metrics do: 'health list',
+'health derive list', the MCP summary, and exports.
+
+The profile is stored in ~/.config/health/config.json, not with your health
+data, so it is never included in exports, migrations, or sync.
+
+FIELDS:
+
+  name, birth-date (YYYY-MM-DD), sex (female or male),
+  height (180cm, 1.80m, or 5'11"), blood-type,
+  max-hr (bpm), hr-zones (five ascending bpm values where zones 1-5 start),
+  contact <name> <phone> [relation]
+  allergy and condition are lists; use add and remove
+
+EXAMPLES:
+
+  health profile set name "Harper Reed"
+  health profile set blood-type O+
+  health profile set birth-date 1980-03-15
+  health profile set sex female
+  health profile set height 172cm
+  health profile set max-hr 188
+  health profile set hr-zones 110,130,145,160,172
+  health profile set contact "Jane Doe" "+1 555 0100" spouse
+  health profile add allergy penicillin
+  health profile add condition asthma
+  health profile remove allergy penicillin
+  health profile                          # Show the profile`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		p := cfg.EmergencyProfile()
+		if p == nil {
+			fmt.Println("No profile set. Try 'health profile set blood-type O+'.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, f := range p.CardFields(nil) {
+			fmt.Printf("%s %s\n", faint.Sprintf("%-11s", f.Label+":"), f.Value)
+		}
+		if cfg.Profile.MaxHR > 0 {
+			fmt.Printf("%s %g bpm\n", faint.Sprintf("%-11s", "Max HR:"), cfg.Profile.MaxHR)
+		}
+		if z, ok := cfg.HeartRateZones(); ok {
+			fmt.Printf("%s %s bpm\n", faint.Sprintf("%-11s", "HR zones:"), z)
+		}
+		return nil
+	},
+}
+
+var profileSetCmd = &cobra.Command{
+	Use:   "set <field> <value>...",
+	Short: "Set name, birth-date, sex, height, blood-type, max-hr, hr-zones, or contact",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		if cfg.Profile == nil {
+			cfg.Profile = &config.ProfileConfig{}
+		}
+
+		field, values := args[0], args[1:]
+		switch field {
+		case "name":
+			cfg.Profile.Name = strings.Join(values, " ")
+		case "birth-date", "birth_date", "born":
+			if _, err := models.ParseBirthDate(values[0]); err != nil || len(values) > 1 {
+				return fmt.Errorf("invalid birth date %q (use YYYY-MM-DD)", strings.Join(values, " "))
+			}
+			cfg.Profile.BirthDate = values[0]
+		case "sex":
+			sex, err := models.NormalizeSex(strings.Join(values, ""))
+			if err != nil {
+				return err
+			}
+			cfg.Profile.Sex = sex
+		case "height":
+			height, err := models.ParseHeight(strings.Join(values, " "))
+			if err != nil {
+				return err
+			}
+			cfg.Profile.HeightCM = height
+		case "blood-type", "blood_type", "blood":
+			bt, err := models.NormalizeBloodType(strings.Join(values, ""))
+			if err != nil {
+				return err
+			}
+			cfg.Profile.BloodType = bt
+		case "max-hr", "max_hr":
+			bpm, err := strconv.ParseFloat(strings.TrimSuffix(strings.Join(values, ""), "bpm"), 64)
+			if err != nil || bpm < 100 || bpm > 250 {
+				return fmt.Errorf("invalid max heart rate %q (use bpm, e.g. 188)", strings.Join(values, " "))
+			}
+			cfg.Profile.MaxHR = bpm
+		case "hr-zones", "hr_zones":
+			z, err := hrzones.Parse(strings.Join(values, ","))
+			if err != nil {
+				return err
+			}
+			cfg.Profile.HRZones = z[:]
+		case "contact":
+			if len(values) < 2 || len(values) > 3 {
+				return fmt.Errorf("usage: health profile set contact <name> <phone> [relation]")
+			}
+			contact := &config.ContactConfig{Name: values[0], Phone: values[1]}
+			if len(values) == 3 {
+				contact.Relation = values[2]
+			}
+			cfg.Profile.EmergencyContact = contact
+		default:
+			return fmt.Errorf("unknown field: %s (use name, birth-date, sex, height, blood-type, max-hr, hr-zones, or contact)", field)
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+		color.Green("✓ Set %s", field)
+		return nil
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <allergy|condition> <value>",
+	Short: "Add an allergy or condition",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editProfileList(args[0], func(list []string) ([]string, error) {
+			for _, v := range list {
+				if strings.EqualFold(v, args[1]) {
+					return nil, fmt.Errorf("%s already listed", args[1])
+				}
+			}
+			color.Green("✓ Added %s %s", args[0], args[1])
+			return append(list, args[1]), nil
+		})
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:     "remove <allergy|condition> <value>",
+	Aliases: []string{"rm"},
+	Short:   "Remove an allergy or condition",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editProfileList(args[0], func(list []string) ([]string, error) {
+			i := slices.IndexFunc(list, func(v string) bool { return strings.EqualFold(v, args[1]) })
+			if i < 0 {
+				return nil, fmt.Errorf("%s not listed", args[1])
+			}
+			color.Yellow("✗ Removed %s %s", args[0], list[i])
+			return slices.Delete(list, i, i+1), nil
+		})
+	},
+}
+
+// editProfileList applies edit to the allergy or condition list and saves.
+func editProfileList(kind string, edit func([]string) ([]string, error)) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if cfg.Profile == nil {
+		cfg.Profile = &config.ProfileConfig{}
+	}
+
+	var list *[]string
+	switch kind {
+	case "allergy", "allergies":
+		list = &cfg.Profile.Allergies
+	case "condition", "conditions":
+		list = &cfg.Profile.Conditions
+	default:
+		return fmt.Errorf("unknown list: %s (use allergy or condition)", kind)
+	}
+
+	updated, err := edit(*list)
+	if err != nil {
+		return err
+	}
+	*list = updated
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	return nil
+}
+
+// emergencyCard builds the card from the profile and medications. With svg
+// set it returns a printable SVG, otherwise text with a terminal QR code.
+func emergencyCard(ctx context.Context, svg bool) ([]byte, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	p := cfg.EmergencyProfile()
+	if p == nil {
+		return nil, fmt.Errorf("no profile set (see 'health profile --help')")
+	}
+	meds, err := repo.ListMedications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list medications: %w", err)
+	}
+
+	fields := p.CardFields(meds)
+	text := models.CardText(fields)
+	code, err := qr.Encode([]byte(text), qr.Low)
+	if errors.Is(err, qr.ErrTooLong) {
+		// The printed details still matter more than the code
+		color.Yellow("! card is too long for a QR code; printing text only")
+		code = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if svg {
+		return emergencyCardSVG(fields, code), nil
+	}
+	if code == nil {
+		return []byte(text), nil
+	}
+	return []byte(text + "\n" + code.String()), nil
+}
+
+// emergencyCardSVG lays the card out as a wallet-sized SVG: the fields on
+// top and the QR code beneath them.
+func emergencyCardSVG(fields []models.CardField, code *qr.Code) []byte {
+	const (
+		lineHeight = 18
+		margin     = 16
+		width      = 340
+		scale      = 4
+	)
+	height := margin*2 + lineHeight*(len(fields)+1)
+	qrSize := 0
+	if code != nil {
+		qrSize = (code.Size + 8) * scale
+		height += qrSize
+	}
+
+	esc := func(s string) string {
+		var b bytes.Buffer
+		_ = xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect x="0.5" y="0.5" width="%d" height="%d" rx="8" fill="#fff" stroke="#c00"/>`+"\n", width-1, height-1)
+	y := margin + lineHeight - 4
+	fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="14" font-weight="bold" fill="#c00">EMERGENCY INFO</text>`+"\n", margin, y)
+	for _, f := range fields {
+		y += lineHeight
+		fmt.Fprintf(&b, `<text x="%d" y="%d"><tspan font-weight="bold">%s:</tspan> %s</text>`+"\n", margin, y, esc(f.Label), esc(f.Value))
+	}
+	if code != nil {
+		fmt.Fprintf(&b, `<g transform="translate(%d %d) scale(%d)"><path d="%s" fill="#000"/></g>`+"\n",
+			(width-qrSize)/2, y+lineHeight/2, scale, code.SVGPath())
+	}
+	b.WriteString("</svg>\n")
+	return []byte(b.String())
+}
+
+func init() {
+	profileCmd.AddCommand(profileSetCmd)
+	profileCmd.AddCommand(profileAddCmd)
+	profileCmd.AddCommand(profileRemoveCmd)
+	rootCmd.AddCommand(profileCmd)
+}
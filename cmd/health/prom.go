@@ -0,0 +1,194 @@
+// ABOUTME: CLI command serving metrics to Prometheus (health prom).
+// ABOUTME: Exposes latest values and today's sums and counts per metric type as gauges.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/prom"
+)
+
+var (
+	promListen string
+	promOnce   bool
+)
+
+var promCmd = &cobra.Command{
+	Use:   "prom",
+	Short: "Serve metrics to Prometheus",
+	Long: `Expose your latest metric values and today's aggregates as Prometheus
+gauges on /metrics, for graphing in Grafana next to other home metrics.
+
+Every scrape reads the store afresh, so new entries show up on the next
+scrape. The exporter listens on localhost only unless --listen says
+otherwise; there is no authentication.
+
+SERIES:
+
+  health_metric_latest{type,unit}                 newest value per type
+  health_metric_latest_timestamp_seconds{type}    when it was recorded
+  health_metric_today_sum{type,unit}              sum of today's entries
+  health_metric_today_count{type}                 number of today's entries
+
+Derived metrics (see 'health derive') are included under their names.
+"Today" is the configured timezone's calendar day. For a daily average
+divide the sum by the count.
+
+With --once the series are printed instead of served, e.g. for the
+node_exporter textfile collector.
+
+EXAMPLES:
+
+  health prom                           # http://127.0.0.1:9469/metrics
+  health prom --listen :9469            # Reachable from other hosts
+  health prom --once > /var/lib/node_exporter/health.prom
+
+PROMETHEUS CONFIG:
+
+  scrape_configs:
+    - job_name: health
+      static_configs:
+        - targets: ["127.0.0.1:9469"]`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if promOnce {
+			body, err := promScrape(cmd.Context(), time.Now())
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(body)
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			body, err := promScrape(r.Context(), time.Now())
+			if err != nil {
+				slog.Error("scrape failed", "err", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", prom.ContentType)
+			_, _ = w.Write(body)
+		})
+		srv := &http.Server{Addr: promListen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+		}()
+
+		color.Green("Serving metrics on http://%s/metrics (Ctrl-C to stop)", promListen)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("serve: %w", err)
+		}
+		return nil
+	},
+}
+
+// promScrape reads the store and renders one scrape.
+func promScrape(ctx context.Context, now time.Time) ([]byte, error) {
+	metrics, err := repo.ListMetrics(ctx, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metrics: %w", err)
+	}
+	set, err := loadDerivedSet()
+	if err != nil {
+		return nil, err
+	}
+	metrics = append(metrics, set.Compute(metrics)...)
+
+	var buf bytes.Buffer
+	if err := prom.Write(&buf, promGauges(metrics, now)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// promGauges builds the exported series from metrics in any order,
+// sorted by type so scrapes diff cleanly.
+func promGauges(metrics []*models.Metric, now time.Time) []prom.Gauge {
+	latest := derived.Latest(metrics)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	type aggregate struct {
+		sum   float64
+		count int
+		unit  string
+	}
+	today := make(map[string]*aggregate)
+	for _, m := range metrics {
+		if m.RecordedAt.Before(dayStart) || m.RecordedAt.After(now) {
+			continue
+		}
+		agg := today[string(m.MetricType)]
+		if agg == nil {
+			agg = &aggregate{unit: m.Unit}
+			today[string(m.MetricType)] = agg
+		}
+		agg.sum += m.Value
+		agg.count++
+	}
+
+	value := prom.Gauge{Name: "health_metric_latest", Help: "Most recent value of each metric type."}
+	stamp := prom.Gauge{Name: "health_metric_latest_timestamp_seconds", Help: "Unix time the most recent value was recorded."}
+	for _, name := range sortedKeys(latest) {
+		m := latest[name]
+		value.Samples = append(value.Samples, prom.Sample{
+			Labels: []prom.Label{{Name: "type", Value: name}, {Name: "unit", Value: m.Unit}},
+			Value:  m.Value,
+		})
+		stamp.Samples = append(stamp.Samples, prom.Sample{
+			Labels: []prom.Label{{Name: "type", Value: name}},
+			Value:  float64(m.RecordedAt.Unix()),
+		})
+	}
+
+	sum := prom.Gauge{Name: "health_metric_today_sum", Help: "Sum of the values recorded today."}
+	count := prom.Gauge{Name: "health_metric_today_count", Help: "Number of values recorded today."}
+	for _, name := range sortedKeys(today) {
+		agg := today[name]
+		sum.Samples = append(sum.Samples, prom.Sample{
+			Labels: []prom.Label{{Name: "type", Value: name}, {Name: "unit", Value: agg.unit}},
+			Value:  agg.sum,
+		})
+		count.Samples = append(count.Samples, prom.Sample{
+			Labels: []prom.Label{{Name: "type", Value: name}},
+			Value:  float64(agg.count),
+		})
+	}
+	return []prom.Gauge{value, stamp, sum, count}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	promCmd.Flags().StringVar(&promListen, "listen", "127.0.0.1:9469", "address to serve /metrics on")
+	promCmd.Flags().BoolVar(&promOnce, "once", false, "print the series to stdout and exit")
+	rootCmd.AddCommand(promCmd)
+}
@@ -0,0 +1,137 @@
+// ABOUTME: CLI command pushing metrics and workouts to InfluxDB as line protocol.
+// ABOUTME: Also sends each new metric from 'health add' when push_on_add is configured.
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/influx"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// influxTokenEnv holds the InfluxDB API token so it stays out of shell history.
+const influxTokenEnv = "HEALTH_INFLUX_TOKEN"
+
+var (
+	influxURL    string
+	influxOrg    string
+	influxBucket string
+	influxSince  string
+)
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push data to external time series databases",
+}
+
+var pushInfluxCmd = &cobra.Command{
+	Use:   "influx --url <server> --bucket <bucket>",
+	Short: "Write metrics and workouts to InfluxDB",
+	Long: `Write metrics and workouts to an InfluxDB bucket as line protocol, for
+long-term dashboards without running a server from health itself.
+
+Each metric becomes a health_metric point (tags type, unit, location;
+field value). Each workout becomes a health_workout point at its start
+(tags workout_type, location; fields duration_minutes and one per
+workout metric, e.g. distance or avg_hr).
+
+Points are keyed by their tags and timestamp, so pushing the same data
+again overwrites rather than duplicates it; --since only limits how much
+is sent. The token is read from $HEALTH_INFLUX_TOKEN. Works with
+InfluxDB 2.x and 3.x, and 1.8+ through its v2 compatibility API.
+
+Defaults for --url, --org, and --bucket come from "influx" in config.json.
+Set "push_on_add": true there to send each metric from 'health add' as
+soon as it is saved:
+
+  "influx": {"url": "http://localhost:8086", "org": "home",
+             "bucket": "health", "push_on_add": true}
+
+EXAMPLES:
+
+  export HEALTH_INFLUX_TOKEN=...
+  health push influx --url http://localhost:8086 --org home --bucket health
+  health push influx --since 2025-01-01
+  0 * * * * health cron push influx`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client, err := influxClient()
+		if err != nil {
+			return err
+		}
+		var since *time.Time
+		if influxSince != "" {
+			t, err := time.ParseInLocation("2006-01-02", influxSince, time.Local)
+			if err != nil {
+				return fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", influxSince)
+			}
+			since = &t
+		}
+
+		points, err := storage.InfluxPoints(cmd.Context(), repo, since)
+		if err != nil {
+			return err
+		}
+		if err := client.Write(cmd.Context(), points); err != nil {
+			return fmt.Errorf("push failed: %w", err)
+		}
+		color.Green("✓ Pushed %d %s to %s", len(points), plural(len(points), "point", "points"), client.Bucket)
+		return nil
+	},
+}
+
+// influxClient builds a client from the flags, falling back to the
+// "influx" section of the config.
+func influxClient() (*influx.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	url, org, bucket := influxURL, influxOrg, influxBucket
+	if cfg.Influx != nil {
+		url = cmp.Or(url, cfg.Influx.URL)
+		org = cmp.Or(org, cfg.Influx.Org)
+		bucket = cmp.Or(bucket, cfg.Influx.Bucket)
+	}
+	if url == "" || bucket == "" {
+		return nil, fmt.Errorf("--url and --bucket are required (or set \"influx\" in config.json)")
+	}
+	return influx.NewClient(url, org, bucket, os.Getenv(influxTokenEnv)), nil
+}
+
+// pushOnAdd sends newly added metrics to InfluxDB when push_on_add is set.
+// The metrics are already saved, so a failed push only warns; the next
+// 'health push influx' catches up.
+func pushOnAdd(ctx context.Context, metrics ...*models.Metric) {
+	cfg, err := config.Load()
+	if err != nil || cfg.Influx == nil || !cfg.Influx.PushOnAdd {
+		return
+	}
+	points := make([]influx.Point, len(metrics))
+	for i, m := range metrics {
+		points[i] = storage.MetricInfluxPoint(m)
+	}
+	client := influx.NewClient(cfg.Influx.URL, cfg.Influx.Org, cfg.Influx.Bucket, os.Getenv(influxTokenEnv))
+	if err := client.Write(ctx, points); err != nil {
+		color.Yellow("! couldn't push to InfluxDB: %v", err)
+	}
+}
+
+func init() {
+	pushInfluxCmd.Flags().StringVar(&influxURL, "url", "", "InfluxDB server URL (token from $"+influxTokenEnv+")")
+	pushInfluxCmd.Flags().StringVar(&influxOrg, "org", "", "InfluxDB organization")
+	pushInfluxCmd.Flags().StringVar(&influxBucket, "bucket", "", "InfluxDB bucket")
+	pushInfluxCmd.Flags().StringVar(&influxSince, "since", "", "only push data since date (YYYY-MM-DD)")
+
+	pushCmd.AddCommand(pushInfluxCmd)
+	rootCmd.AddCommand(pushCmd)
+}
@@ -0,0 +1,114 @@
+// ABOUTME: CLI command for read-only SQL against the SQLite backend.
+// ABOUTME: Prints the result as an aligned table, or as JSON with --json.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/storage"
+)
+
+var queryJSON bool
+
+var queryCmd = &cobra.Command{
+	Use:   "query <sql>",
+	Short: "Run a read-only SQL query (SQLite backend)",
+	Long: `Run a SELECT against the SQLite database and print the rows.
+
+This is an escape hatch for questions no report answers yet. The query
+runs read-only: SQLite refuses anything that would change the data.
+It needs the sqlite backend; the markdown and jsonl stores have no SQL.
+
+Timestamps are stored as RFC 3339 text in UTC. Main tables: metrics,
+workouts, workout_metrics, sleep_sessions, fasts, medications,
+medication_intakes.
+Run "SELECT name FROM sqlite_master WHERE type = 'table'" for the rest.
+
+EXAMPLES:
+
+  health query "SELECT metric_type, avg(value) FROM metrics GROUP BY 1"
+  health query "SELECT date(recorded_at) AS day, sum(value) FROM metrics
+                WHERE metric_type = 'water' GROUP BY day" --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, ok := repo.(*storage.DB)
+		if !ok {
+			return fmt.Errorf("health query needs the sqlite backend (see 'health migrate')")
+		}
+		res, err := db.Query(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		if queryJSON {
+			out := make([]map[string]any, 0, len(res.Rows))
+			for _, row := range res.Rows {
+				obj := make(map[string]any, len(row))
+				for i, v := range row {
+					obj[res.Columns[i]] = v
+				}
+				out = append(out, obj)
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(out)
+		}
+
+		printQueryTable(res)
+		return nil
+	},
+}
+
+// printQueryTable prints res with columns padded to their widest value.
+func printQueryTable(res *storage.QueryResult) {
+	cells := make([][]string, len(res.Rows))
+	widths := make([]int, len(res.Columns))
+	for i, c := range res.Columns {
+		widths[i] = len(c)
+	}
+	for r, row := range res.Rows {
+		cells[r] = make([]string, len(row))
+		for i, v := range row {
+			cells[r][i] = formatQueryValue(v)
+			widths[i] = max(widths[i], len(cells[r][i]))
+		}
+	}
+
+	header := make([]string, len(res.Columns))
+	for i, c := range res.Columns {
+		header[i] = padRight(c, widths[i])
+	}
+	color.New(color.Bold).Println(strings.TrimRight(strings.Join(header, "  "), " "))
+	for _, row := range cells {
+		line := make([]string, len(row))
+		for i, c := range row {
+			line[i] = padRight(c, widths[i])
+		}
+		fmt.Println(strings.TrimRight(strings.Join(line, "  "), " "))
+	}
+	color.New(color.Faint).Printf("(%d %s)\n", len(res.Rows), plural(len(res.Rows), "row", "rows"))
+}
+
+// formatQueryValue renders one cell; NULL is shown as an empty cell.
+func formatQueryValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func init() {
+	queryCmd.Flags().BoolVar(&queryJSON, "json", false, "print rows as a JSON array of objects")
+	rootCmd.AddCommand(queryCmd)
+}
@@ -0,0 +1,173 @@
+// ABOUTME: CLI command reconciling hand-edited markdown files with the store.
+// ABOUTME: Fills in missing IDs, re-keys copied files, reports parse errors, and can keep watching.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	reindexDryRun   bool
+	reindexWatch    bool
+	reindexInterval time.Duration
+	reindexRelayout bool
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Pick up hand-edited markdown files",
+	Long: `Check every markdown file in the data directory after you've edited or
+added files by hand (markdown backend only).
+
+  - Files without an id (or created_at) get one, so a new entry can be
+    written with just its fields: metric_type, value, unit, recorded_at.
+  - A file repeating another file's ID, usually a copy, gets a new ID.
+  - Files that still don't parse are listed with the reason.
+  - The ID index (.index.json) is rebuilt.
+
+--relayout also moves metric and workout files to where the configured
+markdown_layout puts them, after you change it.
+
+--watch keeps running and reindexes whenever files change, checking every
+--interval (default 2s) and waiting for edits to settle first.
+
+EXAMPLES:
+
+  health reindex --dry-run
+  health reindex
+  health reindex --relayout
+  health reindex --watch`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, ok := repo.(*storage.MarkdownStore)
+		if !ok {
+			return fmt.Errorf("reindex is only for the markdown backend")
+		}
+		if reindexRelayout {
+			if err := runRelayout(cmd.Context(), store); err != nil {
+				return err
+			}
+		}
+		if !reindexWatch {
+			return runReindex(cmd.Context(), store, true)
+		}
+		if reindexInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		if err := runReindex(ctx, store, true); err != nil {
+			return err
+		}
+		last, err := store.Fingerprint(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(color.New(color.Faint).Sprint("Watching for changes (Ctrl-C to stop)..."))
+
+		pending := false
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(reindexInterval):
+			}
+			fp, err := store.Fingerprint(ctx)
+			if err != nil {
+				color.Red("✗ %v", err)
+				continue
+			}
+			// Reindex once files have stopped changing for an interval
+			if fp != last {
+				last, pending = fp, true
+				continue
+			}
+			if !pending {
+				continue
+			}
+			pending = false
+			if err := runReindex(ctx, store, false); err != nil {
+				color.Red("✗ %v", err)
+			}
+			// Our own fixes change files too; don't count them as edits
+			if last, err = store.Fingerprint(ctx); err != nil {
+				color.Red("✗ %v", err)
+			}
+		}
+	},
+}
+
+// runReindex reindexes and prints what changed. Quiet rounds print
+// nothing unless verbose is set.
+func runReindex(ctx context.Context, store *storage.MarkdownStore, verbose bool) error {
+	rep, err := store.Reindex(ctx, reindexDryRun)
+	if err != nil {
+		return err
+	}
+
+	assigned, rekeyed := "Assigned an ID to", "Gave a new ID to copy"
+	if reindexDryRun {
+		assigned, rekeyed = "Would assign an ID to", "Would give a new ID to copy"
+	}
+	for _, p := range rep.Assigned {
+		color.Yellow("%s %s", assigned, p)
+	}
+	for _, p := range rep.Rekeyed {
+		color.Yellow("%s %s", rekeyed, p)
+	}
+	for _, b := range rep.Broken {
+		color.Red("✗ %s: %v", b.Path, b.Err)
+	}
+
+	changed := len(rep.Assigned) + len(rep.Rekeyed) + len(rep.Broken)
+	if !verbose && changed == 0 {
+		return nil
+	}
+	if len(rep.Broken) > 0 {
+		fmt.Printf("%d of %d %s don't parse; fix them by hand or move them aside with 'health maintenance --quarantine'.\n",
+			len(rep.Broken), rep.Checked, plural(rep.Checked, "file", "files"))
+		return nil
+	}
+	if !reindexDryRun {
+		color.Green("✓ Reindexed %d %s", rep.Checked, plural(rep.Checked, "file", "files"))
+	}
+	return nil
+}
+
+// runRelayout moves files to the configured layout and prints them.
+func runRelayout(ctx context.Context, store *storage.MarkdownStore) error {
+	moved, err := store.Relayout(ctx, reindexDryRun)
+	if err != nil {
+		return err
+	}
+	verb := "Moved"
+	if reindexDryRun {
+		verb = "Would move"
+	}
+	for _, p := range moved {
+		color.Yellow("%s %s", verb, p)
+	}
+	if len(moved) > 0 && !reindexDryRun {
+		color.Green("✓ Moved %d %s to the configured layout", len(moved), plural(len(moved), "file", "files"))
+	}
+	return nil
+}
+
+func init() {
+	reindexCmd.Flags().BoolVar(&reindexDryRun, "dry-run", false, "report without changing files")
+	reindexCmd.Flags().BoolVar(&reindexWatch, "watch", false, "keep reindexing when files change")
+	reindexCmd.Flags().BoolVar(&reindexRelayout, "relayout", false, "move files to the configured markdown_layout")
+	reindexCmd.Flags().DurationVar(&reindexInterval, "interval", 2*time.Second, "how often --watch checks for changes")
+	rootCmd.AddCommand(reindexCmd)
+}
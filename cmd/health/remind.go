@@ -0,0 +1,520 @@
+// ABOUTME: CLI commands for daily reminders ("log weight" at 08:00).
+// ABOUTME: Definitions, channels, and quiet hours live in config; due and snooze state in the data store.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/notify"
+)
+
+// Secrets for the ntfy and email channels, kept out of the config file.
+const (
+	ntfyTokenEnv    = "HEALTH_NTFY_TOKEN"
+	smtpPasswordEnv = "HEALTH_SMTP_PASSWORD"
+)
+
+var (
+	remindDaily    string
+	remindNotify   bool
+	remindChannels []string
+	remindInterval time.Duration
+	remindQuietOff bool
+)
+
+var remindCmd = &cobra.Command{
+	Use:   "remind",
+	Short: "Daily reminders to log things",
+	Long: `Set up daily nudges like "log weight" at 08:00.
+
+Reminders are stored in ~/.config/health/config.json. Each one fires once per
+day: 'health remind due' delivers reminders that have come due since they last
+fired, so it can run from cron, or 'health remind daemon' checks every minute.
+
+CHANNELS:
+
+  terminal  printed by 'remind due' (the default)
+  desktop   a desktop notification (same as --notify)
+  ntfy      pushed to notify.ntfy_url in config; token from $HEALTH_NTFY_TOKEN
+  email     sent via notify.email in config; password from $HEALTH_SMTP_PASSWORD
+
+EXAMPLES:
+
+  health remind add "log weight" --daily 08:00
+  health remind add "take vitamins" --daily 21:00 --notify
+  health remind add "log bp" --daily 19:00 --channel ntfy --channel email
+  health remind list
+  health remind snooze weight 2h
+  health remind quiet 22:00 07:00
+  health remind due
+  health remind daemon --notify
+
+CRON:
+
+  */5 * * * * health remind due --notify`,
+}
+
+var remindAddCmd = &cobra.Command{
+	Use:   "add <message>",
+	Short: "Add a daily reminder",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if remindDaily == "" {
+			return fmt.Errorf("--daily is required (e.g. --daily 08:00)")
+		}
+		if _, _, err := models.ParseClock(remindDaily); err != nil {
+			return err
+		}
+		for _, c := range remindChannels {
+			if !models.IsValidChannel(c) {
+				return fmt.Errorf("unknown channel %q (use %s)", c, strings.Join(models.ReminderChannels, ", "))
+			}
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		r := config.ReminderConfig{Message: args[0], Daily: remindDaily, Notify: remindNotify, Channels: remindChannels}
+		key := r.Reminder().Key()
+		for _, existing := range cfg.Reminders {
+			if existing.Reminder().Key() == key {
+				return fmt.Errorf("reminder %q already exists", existing.Message)
+			}
+		}
+		cfg.Reminders = append(cfg.Reminders, r)
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+
+		// Start counting from now so a reminder whose time already passed
+		// today doesn't fire straight away.
+		if err := repo.SetReminderLastFired(cmd.Context(), key, time.Now()); err != nil {
+			return fmt.Errorf("failed to save reminder state: %w", err)
+		}
+
+		color.Green("✓ Added reminder %q daily at %s", r.Message, r.Daily)
+		for _, c := range r.Channels {
+			if msg := channelProblem(cfg, c); msg != "" {
+				color.Yellow("⚠ %s", msg)
+			}
+		}
+		return nil
+	},
+}
+
+var remindListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List reminders",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		if len(cfg.Reminders) == 0 {
+			fmt.Println("No reminders set.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		now := time.Now()
+		for _, rc := range cfg.Reminders {
+			r := rc.Reminder()
+			last, err := repo.GetReminderLastFired(cmd.Context(), r.Key())
+			if err != nil {
+				return fmt.Errorf("failed to load reminder state: %w", err)
+			}
+			snoozed, err := repo.GetReminderSnooze(cmd.Context(), r.Key())
+			if err != nil {
+				return fmt.Errorf("failed to load reminder state: %w", err)
+			}
+
+			lastStr := "never"
+			if last != nil {
+				lastStr = last.Local().Format("Jan 2 15:04")
+			}
+			notify := ""
+			if r.Notify {
+				notify = " 🔔"
+			}
+			state := fmt.Sprintf("last fired %s", lastStr)
+			if channels := r.DeliveryChannels(); len(channels) > 1 || channels[0] != models.ChannelTerminal {
+				state += ", via " + strings.Join(channels, ", ")
+			}
+			if snoozed != nil && snoozed.After(now) {
+				state += ", snoozed until " + snoozed.Local().Format("Jan 2 15:04")
+			}
+			fmt.Printf("%s  %s%s %s\n", r.Daily, padRight(r.Message, 24), notify, faint.Sprint(state))
+		}
+		if q := cfg.Quiet(); q != nil {
+			fmt.Println(faint.Sprintf("Quiet hours %s", q))
+		}
+
+		return nil
+	},
+}
+
+var remindDeleteCmd = &cobra.Command{
+	Use:     "delete <message>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a reminder",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		key := models.Slugify(args[0])
+		for i, rc := range cfg.Reminders {
+			if rc.Reminder().Key() != key {
+				continue
+			}
+			cfg.Reminders = append(cfg.Reminders[:i], cfg.Reminders[i+1:]...)
+			if err := cfg.Save(); err != nil {
+				return fmt.Errorf("save config: %w", err)
+			}
+			color.Yellow("✗ Deleted reminder %q", rc.Message)
+			return nil
+		}
+
+		return fmt.Errorf("reminder not found: %s", args[0])
+	},
+}
+
+var remindSnoozeCmd = &cobra.Command{
+	Use:   "snooze <reminder> <duration|off>",
+	Short: "Hold a reminder back for a while",
+	Long: `Keep a reminder quiet for a duration, then fire it again even if it
+already fired today. The reminder can be named by its message or any
+unambiguous part of it. "off" cancels the snooze.
+
+EXAMPLES:
+
+  health remind snooze weight 2h
+  health remind snooze "take vitamins" 30m
+  health remind snooze weight off`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		r, err := findReminder(cfg, args[0])
+		if err != nil {
+			return err
+		}
+
+		if args[1] == "off" {
+			if err := repo.SetReminderSnooze(cmd.Context(), r.Key(), nil); err != nil {
+				return fmt.Errorf("failed to save reminder state: %w", err)
+			}
+			color.Green("✓ %q is no longer snoozed", r.Message)
+			return nil
+		}
+
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q (e.g. 30m, 2h)", args[1])
+		}
+		until := time.Now().Add(d).Truncate(time.Second)
+		if err := repo.SetReminderSnooze(cmd.Context(), r.Key(), &until); err != nil {
+			return fmt.Errorf("failed to save reminder state: %w", err)
+		}
+		color.Green("✓ Snoozed %q until %s", r.Message, until.Format("Jan 2 15:04"))
+		return nil
+	},
+}
+
+var remindQuietCmd = &cobra.Command{
+	Use:   "quiet [start end]",
+	Short: "Show or set quiet hours",
+	Long: `Set a daily window in which no reminders are delivered. Reminders that
+come due during it fire when it ends. The window may span midnight.
+
+EXAMPLES:
+
+  health remind quiet 22:00 07:00
+  health remind quiet              # show the current window
+  health remind quiet --off`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 0 && len(args) != 2 {
+			return fmt.Errorf("quiet takes a start and an end time, e.g. 22:00 07:00")
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+
+		switch {
+		case remindQuietOff:
+			cfg.QuietHours = nil
+		case len(args) == 2:
+			q := models.QuietHours{Start: args[0], End: args[1]}
+			if err := q.Validate(); err != nil {
+				return err
+			}
+			cfg.QuietHours = &config.QuietHoursConfig{Start: q.Start, End: q.End}
+		default:
+			if q := cfg.Quiet(); q != nil {
+				fmt.Printf("Quiet hours %s\n", q)
+			} else {
+				fmt.Println("No quiet hours set.")
+			}
+			return nil
+		}
+
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+		if q := cfg.Quiet(); q != nil {
+			color.Green("✓ Quiet hours %s", q)
+		} else {
+			color.Yellow("✗ Quiet hours off")
+		}
+		return nil
+	},
+}
+
+// findReminder matches name against configured reminders: by key first,
+// then by a key that contains it, as long as only one does.
+func findReminder(cfg *config.Config, name string) (models.Reminder, error) {
+	key := models.Slugify(name)
+	var matches []models.Reminder
+	for _, rc := range cfg.Reminders {
+		r := rc.Reminder()
+		if r.Key() == key {
+			return r, nil
+		}
+		if key != "" && strings.Contains(r.Key(), key) {
+			matches = append(matches, r)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return models.Reminder{}, fmt.Errorf("reminder not found: %s", name)
+	case 1:
+		return matches[0], nil
+	}
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = fmt.Sprintf("%q", m.Message)
+	}
+	return models.Reminder{}, fmt.Errorf("%q matches several reminders: %s", name, strings.Join(names, ", "))
+}
+
+var remindDueCmd = &cobra.Command{
+	Use:   "due",
+	Short: "Deliver reminders that are due",
+	Long: `Deliver reminders that have come due since they last fired, then mark
+them as fired. Prints nothing when nothing is due, so it is safe to run from
+cron. During quiet hours nothing is delivered.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return checkReminders(cmd.Context(), time.Now())
+	},
+}
+
+var remindDaemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep checking for due reminders",
+	Long: `Check for due reminders every --interval (default 1m) until interrupted.
+
+EXAMPLES:
+
+  health remind daemon
+  health remind daemon --notify --interval 5m`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if remindInterval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		for {
+			if err := checkReminders(cmd.Context(), time.Now()); err != nil {
+				color.Red("✗ %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(remindInterval):
+			}
+		}
+	},
+}
+
+// checkReminders delivers every configured reminder that is due at now to
+// its channels and records it as fired. Nothing is delivered during quiet
+// hours; due reminders stay due until they end.
+func checkReminders(ctx context.Context, now time.Time) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if q := cfg.Quiet(); q != nil {
+		quiet, err := q.Contains(now)
+		if err != nil {
+			return fmt.Errorf("quiet hours: %w", err)
+		}
+		if quiet {
+			return nil
+		}
+	}
+
+	for _, rc := range cfg.Reminders {
+		r := rc.Reminder()
+		last, err := repo.GetReminderLastFired(ctx, r.Key())
+		if err != nil {
+			return fmt.Errorf("failed to load reminder state: %w", err)
+		}
+		snoozed, err := repo.GetReminderSnooze(ctx, r.Key())
+		if err != nil {
+			return fmt.Errorf("failed to load reminder state: %w", err)
+		}
+		due, err := r.IsDue(now, last, snoozed)
+		if err != nil {
+			return fmt.Errorf("reminder %q: %w", r.Message, err)
+		}
+		if !due {
+			continue
+		}
+
+		channels := r.DeliveryChannels()
+		if remindNotify && !slices.Contains(channels, models.ChannelDesktop) {
+			channels = append(channels, models.ChannelDesktop)
+		}
+		for _, c := range channels {
+			if err := deliverReminder(ctx, cfg, c, r); err != nil {
+				color.Yellow("⚠ Could not send %s reminder: %v", c, err)
+			}
+		}
+		if err := repo.SetReminderLastFired(ctx, r.Key(), now); err != nil {
+			return fmt.Errorf("failed to save reminder state: %w", err)
+		}
+		if snoozed != nil {
+			if err := repo.SetReminderSnooze(ctx, r.Key(), nil); err != nil {
+				return fmt.Errorf("failed to save reminder state: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// deliverReminder sends r over one channel.
+func deliverReminder(ctx context.Context, cfg *config.Config, channel string, r models.Reminder) error {
+	if msg := channelProblem(cfg, channel); msg != "" {
+		return fmt.Errorf("%s", msg)
+	}
+	switch channel {
+	case models.ChannelTerminal:
+		fmt.Printf("⏰ %s %s\n", r.Message, color.New(color.Faint).Sprintf("(%s)", r.Daily))
+		return nil
+	case models.ChannelDesktop:
+		return sendNotification("health", r.Message)
+	case models.ChannelNtfy:
+		return notify.NewNtfy(cfg.Notify.NtfyURL, os.Getenv(ntfyTokenEnv)).Send(ctx, "health", r.Message)
+	case models.ChannelEmail:
+		ec := cfg.Notify.Email
+		mail := &notify.Email{
+			Host:     ec.SMTPHost,
+			Port:     ec.SMTPPort,
+			Username: ec.Username,
+			Password: os.Getenv(smtpPasswordEnv),
+			From:     ec.From,
+			To:       ec.To,
+		}
+		return mail.Send("Reminder: "+r.Message, fmt.Sprintf("%s\n\nScheduled daily at %s.\n", r.Message, r.Daily))
+	}
+	return fmt.Errorf("unknown channel %q", channel)
+}
+
+// channelProblem explains what config a channel is missing, or returns ""
+// when it is ready to use.
+func channelProblem(cfg *config.Config, channel string) string {
+	switch channel {
+	case models.ChannelNtfy:
+		if cfg.Notify == nil || cfg.Notify.NtfyURL == "" {
+			return "ntfy channel needs notify.ntfy_url in config"
+		}
+	case models.ChannelEmail:
+		if cfg.Notify == nil || cfg.Notify.Email == nil {
+			return "email channel needs notify.email in config"
+		}
+	}
+	return ""
+}
+
+// sendNotification shows a desktop notification using the platform's
+// notifier: osascript on macOS, notify-send elsewhere.
+func sendNotification(title, message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s",
+			appleScriptString(message), appleScriptString(title))
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	default:
+		cmd = exec.CommandContext(ctx, "notify-send", title, message)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+func init() {
+	remindAddCmd.Flags().StringVar(&remindDaily, "daily", "", "time of day to fire, HH:MM")
+	remindAddCmd.Flags().BoolVar(&remindNotify, "notify", false, "also send a desktop notification")
+	remindAddCmd.Flags().StringSliceVar(&remindChannels, "channel", nil, "deliver to terminal, desktop, ntfy, or email (repeatable)")
+	remindQuietCmd.Flags().BoolVar(&remindQuietOff, "off", false, "turn quiet hours off")
+	remindDueCmd.Flags().BoolVar(&remindNotify, "notify", false, "send desktop notifications for every due reminder")
+	remindDaemonCmd.Flags().BoolVar(&remindNotify, "notify", false, "send desktop notifications for every due reminder")
+	remindDaemonCmd.Flags().DurationVar(&remindInterval, "interval", time.Minute, "how often to check")
+
+	remindCmd.AddCommand(remindAddCmd)
+	remindCmd.AddCommand(remindListCmd)
+	remindCmd.AddCommand(remindDeleteCmd)
+	remindCmd.AddCommand(remindSnoozeCmd)
+	remindCmd.AddCommand(remindQuietCmd)
+	remindCmd.AddCommand(remindDueCmd)
+	remindCmd.AddCommand(remindDaemonCmd)
+	rootCmd.AddCommand(remindCmd)
+}
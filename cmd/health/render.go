@@ -0,0 +1,73 @@
+// ABOUTME: Minimal terminal renderer for markdown notes.
+// ABOUTME: Styles headings, emphasis, inline code, links, and lists using ANSI colors.
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var (
+	mdBoldRe   = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	mdItalicRe = regexp.MustCompile(`\*([^*\s][^*]*?)\*|\b_([^_\s][^_]*?)_\b`)
+	mdCodeRe   = regexp.MustCompile("`([^`]+)`")
+	mdLinkRe   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdBulletRe = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	mdNumRe    = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	mdHeadRe   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+)
+
+// renderMarkdown converts a small subset of markdown (headings, bold,
+// italics, inline code, links, bullet and numbered lists) into styled
+// terminal text. Styling is dropped automatically when color is disabled.
+func renderMarkdown(src string) string {
+	lines := strings.Split(strings.TrimRight(src, "\n"), "\n")
+	out := make([]string, 0, len(lines))
+
+	heading := color.New(color.Bold, color.Underline)
+	for _, line := range lines {
+		switch {
+		case mdHeadRe.MatchString(line):
+			m := mdHeadRe.FindStringSubmatch(line)
+			out = append(out, heading.Sprint(renderInline(m[2])))
+		case mdBulletRe.MatchString(line):
+			m := mdBulletRe.FindStringSubmatch(line)
+			out = append(out, m[1]+"• "+renderInline(m[2]))
+		case mdNumRe.MatchString(line):
+			m := mdNumRe.FindStringSubmatch(line)
+			out = append(out, m[1]+m[2]+". "+renderInline(m[3]))
+		default:
+			out = append(out, renderInline(line))
+		}
+	}
+
+	return strings.Join(out, "\n")
+}
+
+// renderInline styles inline markdown spans within a single line.
+func renderInline(s string) string {
+	bold := color.New(color.Bold)
+	italic := color.New(color.Italic)
+	code := color.New(color.FgCyan)
+	link := color.New(color.Underline, color.FgBlue)
+	faint := color.New(color.Faint)
+
+	s = mdCodeRe.ReplaceAllStringFunc(s, func(m string) string {
+		return code.Sprint(mdCodeRe.FindStringSubmatch(m)[1])
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdLinkRe.FindStringSubmatch(m)
+		return link.Sprint(parts[1]) + " " + faint.Sprintf("(%s)", parts[2])
+	})
+	s = mdBoldRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdBoldRe.FindStringSubmatch(m)
+		return bold.Sprint(parts[1] + parts[2])
+	})
+	s = mdItalicRe.ReplaceAllStringFunc(s, func(m string) string {
+		parts := mdItalicRe.FindStringSubmatch(m)
+		return italic.Sprint(parts[1] + parts[2])
+	})
+	return s
+}
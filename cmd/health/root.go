@@ -4,14 +4,28 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/history"
 	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/usage"
 	"github.com/spf13/cobra"
 )
 
 var (
 	repo storage.Repository
+
+	// usageLog is where successful commands are recorded, or empty when
+	// usage stats are off.
+	usageLog string
+
+	// ephemeral swaps the configured store for an empty in-memory one.
+	ephemeral bool
 )
 
 var rootCmd = &cobra.Command{
@@ -21,10 +35,13 @@ var rootCmd = &cobra.Command{
 
 WHAT IT TRACKS:
 
-  Biometrics     weight, body_fat, bp (blood pressure), heart_rate, hrv, temperature
-  Activity       steps, sleep_hours, active_calories
+  Biometrics     weight, body_fat, bp (blood pressure), heart_rate, hrv, temperature,
+                 temp_deviation, readiness
+  Activity       steps, sleep_hours, deep_sleep, rem_sleep, light_sleep, active_calories,
+                 vo2max
   Nutrition      water, calories, protein, carbs, fat
   Mental Health  mood, energy, stress, anxiety, focus, meditation
+  Environment    aqi, pollen, ambient_temp
 
 QUICK START:
 
@@ -33,6 +50,9 @@ QUICK START:
   $ health add mood 7 --notes "Great!"  # Log mood with notes
   $ health list                         # See recent metrics
   $ health list --type weight           # Filter by type
+  $ health add water +250               # Add to today's water
+  $ health total water                  # Today's running total
+  $ health again 82.1                   # Repeat the last add with a new value
 
 WORKOUTS:
 
@@ -40,11 +60,64 @@ WORKOUTS:
   $ health workout metric abc123 km 5.2     # Add distance to workout
   $ health workout show abc123              # View workout details
 
+TRAINING:
+
+  $ health training load                                      # Weekly volume and workload ratio
+  $ health event add "Chicago Marathon" 2027-10-10 --weekly-km 50  # Race to train towards
+  $ health event status                                       # Countdown and volume vs. plan
+  $ health stats vo2max                                       # Estimated VO2max from recent runs
+
+SLEEP:
+
+  $ health sleep add --bed 23:30 --wake 07:10   # Log last night
+  $ health sleep list                           # Recent sleep sessions
+
+MEDICATIONS:
+
+  $ health med add "Vitamin D" --dose "1000 IU"  # Define a supplement
+  $ health med take vitamin-d                    # Log taking it
+  $ health med history                           # Adherence, last 7 days
+
+LOCATIONS:
+
+  $ health location add gym --lat 41.89 --lon -87.62  # Register a place
+  $ health workout add lift --location gym            # Tag an entry
+  $ health workout list --location gym                # Filter by place
+  $ health travel start paris --tz Europe/Paris       # Tag entries while away
+
+APPOINTMENTS:
+
+  $ health appt add "Dr. Lee" 2025-07-02 14:00   # Schedule a visit
+  $ health appt summary <id> "Labs look good"     # Link notes afterwards
+  $ health export ics -o appointments.ics         # Calendar export
+
+REMINDERS:
+
+  $ health remind add "log weight" --daily 08:00  # Daily nudge
+  $ health remind due                             # Print what's due (cron-friendly)
+  $ health cron remind due --notify               # Quiet unless it fails; logs to cron.log
+
+ALERTS:
+
+  $ health alert add bp_sys --above 140  # Warn on 'health add' when crossed
+  $ health alerts check                  # Alerts tripped by latest values
+
+DERIVED METRICS:
+
+  $ health derive set height 1.80                          # Constant for formulas
+  $ health derive add bmi "weight / height^2" --unit kg/m² # Computed, never stored
+
+EMERGENCY CARD:
+
+  $ health profile set blood-type O+              # Details for responders
+  $ health export emergency-card -o card.svg      # Printable card with QR
+
 DATA EXPORT:
 
   $ health export json                  # Export to JSON
   $ health export yaml                  # Export to YAML
   $ health export markdown              # Export to Markdown
+  $ health export ics                   # Appointments as iCalendar
   $ health import backup.json           # Import from JSON
 
 MCP INTEGRATION:
@@ -63,10 +136,21 @@ DATA STORAGE:
 
   Metrics are stored locally. Default backend is SQLite at ~/.local/share/health/health.db.
   Use 'health migrate --to markdown' to switch to markdown file storage.
+  Run 'health status' to see how big the store is and how fast it grows.
+  Run 'health usage on' to keep local stats on your own logging habits.
+  Use --ephemeral to try commands against an empty in-memory store.
   Configuration is at ~/.config/health/config.json.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// Skip init for commands that don't need it
-		if cmd.Name() == "version" || cmd.Name() == "help" {
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		slog.Info("command", "path", cmd.CommandPath())
+
+		// Skip init for commands that don't need it; cron's child opens its own.
+		// Completion scripts don't need storage, but __complete (which
+		// answers the shell at tab time) does, for IDs and workout types.
+		if cmd.Name() == "version" || cmd.Name() == "help" || cmd.Name() == "cron" ||
+			(cmd.HasParent() && cmd.Parent().Name() == "completion") {
 			return nil
 		}
 
@@ -75,13 +159,38 @@ DATA STORAGE:
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		repo, err = cfg.OpenStorage()
-		if err != nil {
+		// main already made the timezone time.Local; this reports a bad one
+		if _, err := cfg.Location(); err != nil {
+			return err
+		}
+
+		if ephemeral {
+			repo = storage.NewMemoryStore()
+		} else if repo, err = cfg.OpenStorage(); err != nil {
 			return fmt.Errorf("failed to open storage: %w", err)
 		}
+		// Stderr keeps the MCP server's stdout clean
+		if db, ok := repo.(*storage.DB); ok && db.Upgraded() != nil && db.Upgraded().Backup != "" {
+			up := db.Upgraded()
+			color.New(color.FgYellow).Fprintf(os.Stderr, "Upgraded database schema v%d → v%d (backup: %s)\n", up.From, up.To, up.Backup)
+		}
+
+		historyPath = ""
+		if !ephemeral && cfg.GetBackend() != "memory" {
+			historyPath = history.Path(cfg.GetDataDir())
+		}
+		usageLog = ""
+		if cfg.UsageStats && historyPath != "" {
+			usageLog = usage.Path(cfg.GetDataDir())
+		}
 		return nil
 	},
 	PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+		command := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+		if usageLog != "" && !strings.HasPrefix(command, "usage") && !strings.HasPrefix(command, cobra.ShellCompRequestCmd) {
+			// Stats are a nicety; never fail the command over them
+			_ = usage.Record(usageLog, command, time.Now())
+		}
 		if repo != nil {
 			return repo.Close()
 		}
@@ -90,5 +199,7 @@ DATA STORAGE:
 }
 
 func init() {
-	// No persistent flags needed - database location follows XDG spec
+	rootCmd.PersistentFlags().BoolVar(&logVerbose, "verbose", false, "log debug details (storage query timings, importer HTTP requests) to stderr or --log-file")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "append logs to this file instead of stderr")
+	rootCmd.PersistentFlags().BoolVar(&ephemeral, "ephemeral", false, "keep data in memory for this run only, leaving the configured store untouched")
 }
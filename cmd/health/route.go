@@ -0,0 +1,174 @@
+// ABOUTME: CLI command attaching GPX/FIT route files to workouts.
+// ABOUTME: Copies the file into the data directory and records its distance and climb.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/attachment"
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/route"
+)
+
+var workoutShowRoute bool
+
+var workoutAttachCmd = &cobra.Command{
+	Use:   "attach <workout-id> <file>",
+	Short: "Attach a GPX or FIT route to a workout",
+	Long: `Attach the GPS route of a workout from a GPX or FIT file.
+
+The file is copied into attachments/workouts/<id>/ under the data
+directory and recorded in the workout's "route" metadata. Its distance
+(km) and elevation_gain (m) are stored as workout metrics, replacing
+any distance or elevation_gain already on the workout. Attaching
+another file replaces the route.
+
+Use 'health workout show <id> --route' for the route summary and a map
+link.
+
+Examples:
+  health workout attach abc123 morning-ride.gpx
+  health workout attach abc123 ~/Downloads/12345678.fit`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return fmt.Errorf("read route: %w", err)
+		}
+		r, err := route.Parse(args[1], data)
+		if err != nil {
+			return err
+		}
+
+		w, err := repo.GetWorkout(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("workout not found: %s", args[0])
+		}
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		dataDir := cfg.GetDataDir()
+
+		// A workout has one route; drop the previous file
+		if err := attachment.Remove(dataDir, attachment.Workouts, w.ID.String()); err != nil {
+			return err
+		}
+		rel, err := attachment.Save(dataDir, attachment.Workouts, w.ID.String(), args[1])
+		if err != nil {
+			return err
+		}
+		if err := repo.SetWorkoutMetadata(ctx, w.ID.String(), route.MetadataKey, rel); err != nil {
+			return err
+		}
+
+		summary := r.Summary()
+		if err := replaceRouteMetrics(ctx, w, summary); err != nil {
+			return err
+		}
+
+		color.Green("✓ Attached route to %s workout", w.WorkoutType)
+		fmt.Printf("  %s\n", formatRouteSummary(summary))
+		return nil
+	},
+}
+
+// replaceRouteMetrics swaps the workout's distance and elevation_gain
+// metrics for the route's, updating the ones already there in place.
+func replaceRouteMetrics(ctx context.Context, w *models.Workout, s *route.Summary) error {
+	existing, err := repo.ListWorkoutMetrics(ctx, w.ID)
+	if err != nil {
+		return fmt.Errorf("list workout metrics: %w", err)
+	}
+	stale := make(map[string][]*models.WorkoutMetric)
+	for _, wm := range existing {
+		if wm.MetricName == route.DistanceMetric || wm.MetricName == route.ElevationGainMetric {
+			stale[wm.MetricName] = append(stale[wm.MetricName], wm)
+		}
+	}
+	for _, wm := range s.WorkoutMetrics(w.ID) {
+		if old := stale[wm.MetricName]; len(old) > 0 {
+			wm.ID = old[0].ID
+			stale[wm.MetricName] = old[1:]
+			if err := repo.UpdateWorkoutMetric(ctx, wm); err != nil {
+				return fmt.Errorf("replace %s: %w", wm.MetricName, err)
+			}
+			continue
+		}
+		if err := repo.AddWorkoutMetric(ctx, wm); err != nil {
+			return fmt.Errorf("add %s: %w", wm.MetricName, err)
+		}
+	}
+	for _, old := range stale {
+		for _, wm := range old {
+			if err := repo.DeleteWorkoutMetric(ctx, wm.ID.String()); err != nil {
+				return fmt.Errorf("replace %s: %w", wm.MetricName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// formatRouteSummary renders a route summary as one line, e.g.
+// "3.00 km, +10 m / -6 m, 412 points".
+func formatRouteSummary(s *route.Summary) string {
+	line := fmt.Sprintf("%.2f km", s.DistanceKm)
+	if s.AscentM > 0 || s.DescentM > 0 {
+		line += fmt.Sprintf(", +%.0f m / -%.0f m", s.AscentM, s.DescentM)
+	}
+	return line + fmt.Sprintf(", %d %s", s.Points, plural(s.Points, "point", "points"))
+}
+
+// printRoute prints the summary of a workout's attached route and a link
+// to it on a map.
+func printRoute(w *models.Workout) error {
+	rel, ok := w.Metadata[route.MetadataKey]
+	if !ok {
+		fmt.Println("\nRoute: none attached (use 'health workout attach')")
+		return nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	path := attachment.Path(cfg.GetDataDir(), rel)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read route: %w", err)
+	}
+	r, err := route.Parse(path, data)
+	if err != nil {
+		return err
+	}
+	s := r.Summary()
+
+	fmt.Printf("\nRoute: %s\n", filepath.Base(path))
+	fmt.Printf("  %s\n", formatRouteSummary(s))
+	if !s.Start.IsZero() {
+		fmt.Printf("  %s – %s\n", s.Start.Local().Format("2006-01-02 15:04"), s.End.Local().Format("15:04"))
+	}
+	fmt.Printf("  Map: %s\n", s.MapURL())
+	return nil
+}
+
+// removeAttachments deletes the files attached to a workout.
+func removeAttachments(w *models.Workout) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	return attachment.RemoveWorkout(cfg.GetDataDir(), w)
+}
+
+func init() {
+	workoutShowCmd.Flags().BoolVar(&workoutShowRoute, "route", false, "summarize the attached route and link to a map")
+	workoutCmd.AddCommand(workoutAttachCmd)
+}
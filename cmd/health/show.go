@@ -0,0 +1,155 @@
+// ABOUTME: CLI command showing any record by ID: a metric, workout, or workout metric.
+// ABOUTME: Prints full details, or the record as JSON with --json.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/attachment"
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var showJSON bool
+
+var showCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show full details of a metric, workout, or workout metric",
+	Long: `Show everything stored for one record, found by its ID or ID prefix.
+
+For a metric: its full ID, value, timestamps, location, source, notes,
+metadata, and the full paths of its attached files. A workout is shown
+as 'health workout show' shows it, and a workout metric with the
+workout it belongs to. A prefix matching more than one record is an
+error; use a longer one.
+
+With --json, print the record in the same shape as 'health export json'.
+
+EXAMPLES:
+
+  health show abc12345
+  health show abc12345 --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		e, err := resolveEntity(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		if showJSON {
+			var v any = e.Metric
+			switch e.Kind {
+			case storage.KindWorkout:
+				if v, err = repo.GetWorkoutWithMetrics(ctx, e.Workout.ID.String()); err != nil {
+					return fmt.Errorf("failed to get workout: %w", err)
+				}
+			case storage.KindWorkoutMetric:
+				v = e.WorkoutMetric
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(v)
+		}
+
+		switch e.Kind {
+		case storage.KindWorkout:
+			return printWorkout(ctx, e.Workout.ID.String())
+		case storage.KindWorkoutMetric:
+			return printWorkoutMetric(ctx, e.WorkoutMetric)
+		}
+		return printMetric(e.Metric)
+	},
+}
+
+// resolveEntity finds the record of any kind with the given ID or prefix.
+func resolveEntity(ctx context.Context, idOrPrefix string) (*storage.Entity, error) {
+	e, err := storage.Resolve(ctx, repo, idOrPrefix)
+	if errors.Is(err, storage.ErrAmbiguous) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	return e, nil
+}
+
+// printMetric prints every stored detail of a metric.
+func printMetric(m *models.Metric) error {
+	fmt.Printf("Metric: %s\n", m.ID)
+	fmt.Printf("Type: %s\n", m.MetricType)
+	fmt.Printf("Value: %s %s\n", strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", m.Value), "0"), "."), m.Unit)
+	fmt.Printf("Recorded: %s\n", m.RecordedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Created: %s\n", m.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if m.ReadingID != nil {
+		fmt.Printf("Reading: %s\n", m.ReadingID)
+	}
+	if m.Location != nil {
+		fmt.Printf("Location: %s\n", *m.Location)
+	}
+	if m.Source != "" {
+		fmt.Printf("Source: %s\n", m.Source)
+	}
+	if m.Notes != nil {
+		fmt.Printf("Notes: %s\n", *m.Notes)
+	}
+
+	var keys []string
+	for _, k := range slices.Sorted(maps.Keys(m.Metadata)) {
+		if !strings.HasPrefix(k, attachment.KeyPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		fmt.Println("Metadata:")
+		for _, k := range keys {
+			fmt.Printf("  %s: %s\n", k, m.Metadata[k])
+		}
+	}
+
+	if files := attachment.List(m.Metadata); len(files) > 0 {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		fmt.Println("Attachments:")
+		for _, rel := range files {
+			fmt.Printf("  %s\n", attachment.Path(cfg.GetDataDir(), rel))
+		}
+	}
+	return nil
+}
+
+// printWorkoutMetric prints a workout metric and the workout it belongs to.
+func printWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	fmt.Printf("Workout metric: %s\n", wm.ID)
+	fmt.Printf("Name: %s\n", wm.MetricName)
+	unit := ""
+	if wm.Unit != nil {
+		unit = " " + *wm.Unit
+	}
+	fmt.Printf("Value: %.2f%s\n", wm.Value, unit)
+	fmt.Printf("Created: %s\n", wm.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if w, err := repo.GetWorkout(ctx, wm.WorkoutID.String()); err == nil {
+		fmt.Printf("Workout: %s (%s, %s)\n", w.ID.String()[:8], w.WorkoutType, w.StartedAt.Local().Format("2006-01-02 15:04"))
+	} else {
+		fmt.Printf("Workout: %s\n", wm.WorkoutID.String()[:8])
+	}
+	return nil
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showJSON, "json", false, "print the record as JSON")
+	showCmd.ValidArgsFunction = completeFirstArg(entityIDCompletions)
+	rootCmd.AddCommand(showCmd)
+}
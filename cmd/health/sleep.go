@@ -0,0 +1,249 @@
+// ABOUTME: CLI commands for sleep session tracking.
+// ABOUTME: Logs bed/wake times and derives the sleep_hours metric from them.
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var (
+	sleepBed        string
+	sleepWake       string
+	sleepDate       string
+	sleepAwakenings int
+	sleepQuality    int
+	sleepNotes      string
+	sleepLimit      int
+)
+
+var sleepCmd = &cobra.Command{
+	Use:   "sleep",
+	Short: "Manage sleep sessions",
+	Long: `Track sleep with bed and wake times instead of a single number.
+
+Each session also records a sleep_hours metric at wake time, so sleep keeps
+showing up in 'health list' and the MCP tools.
+
+EXAMPLES:
+
+  health sleep add --bed 23:30 --wake 07:10
+  health sleep add --bed 23:30 --wake 07:10 --quality 7 --awakenings 2
+  health sleep add --bed "2024-12-13 23:30" --wake "2024-12-14 07:10"
+  health sleep list
+  health sleep delete abc123`,
+}
+
+var sleepAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Log a sleep session",
+	Long: `Log a sleep session.
+
+--bed and --wake accept a clock time (HH:MM) or a full timestamp
+(YYYY-MM-DD HH:MM). Clock times are placed on --date (default today) for
+the wake time; a bed time later than the wake time is taken to be the
+previous evening.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		zone, err := entryZone(ctx)
+		if err != nil {
+			return err
+		}
+		date := time.Now().In(zone)
+		if sleepDate != "" {
+			d, err := time.ParseInLocation("2006-01-02", sleepDate, zone)
+			if err != nil {
+				return fmt.Errorf("invalid date: %s", sleepDate)
+			}
+			date = d
+		}
+
+		bed, wake, err := resolveSleepTimes(sleepBed, sleepWake, date)
+		if err != nil {
+			return err
+		}
+
+		s := models.NewSleepSession(bed, wake)
+		if cmd.Flags().Changed("awakenings") {
+			if sleepAwakenings < 0 {
+				return fmt.Errorf("awakenings cannot be negative")
+			}
+			s.WithAwakenings(sleepAwakenings)
+		}
+		if cmd.Flags().Changed("quality") {
+			if sleepQuality < 1 || sleepQuality > 10 {
+				return fmt.Errorf("quality must be between 1 and 10")
+			}
+			s.WithQuality(sleepQuality)
+		}
+		if sleepNotes != "" {
+			s.WithNotes(sleepNotes)
+		}
+
+		m, err := storage.RecordSleepSession(ctx, repo, s, models.SourceManual)
+		if err != nil {
+			return fmt.Errorf("failed to add sleep session: %w", err)
+		}
+
+		color.Green("✓ Added sleep session")
+		fmt.Printf("  %s %s → %s  %.2f %s\n",
+			color.New(color.Faint).Sprint(s.ID.String()[:8]),
+			s.BedTime.Local().Format("2006-01-02 15:04"),
+			s.WakeTime.Local().Format("15:04"),
+			m.Value, m.Unit)
+
+		return nil
+	},
+}
+
+var sleepListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List sleep sessions",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sessions, err := repo.ListSleepSessions(cmd.Context(), sleepLimit)
+		if err != nil {
+			return fmt.Errorf("failed to list sleep sessions: %w", err)
+		}
+
+		if len(sessions) == 0 {
+			fmt.Println("No sleep sessions found.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, s := range sessions {
+			extra := ""
+			if s.Quality != nil {
+				extra += fmt.Sprintf("  quality %d", *s.Quality)
+			}
+			if s.Awakenings != nil {
+				extra += fmt.Sprintf("  woke %dx", *s.Awakenings)
+			}
+			if s.Notes != nil && *s.Notes != "" {
+				extra += faint.Sprintf(" (%s)", truncate(*s.Notes, 30))
+			}
+			fmt.Printf("%s %s → %s %5.2f h%s\n",
+				faint.Sprint(s.ID.String()[:8]),
+				s.BedTime.Local().Format("2006-01-02 15:04"),
+				s.WakeTime.Local().Format("15:04"),
+				s.Hours(),
+				extra)
+		}
+
+		return nil
+	},
+}
+
+var sleepDeleteCmd = &cobra.Command{
+	Use:     "delete <id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a sleep session and its sleep_hours metric",
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		s, err := repo.GetSleepSession(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("sleep session not found: %s", args[0])
+		}
+
+		if err := storage.RemoveSleepSession(ctx, repo, s.ID.String()); err != nil {
+			return fmt.Errorf("failed to delete sleep session: %w", err)
+		}
+
+		color.Yellow("✗ Deleted sleep session")
+		fmt.Printf("  %s %s → %s\n",
+			color.New(color.Faint).Sprint(s.ID.String()[:8]),
+			s.BedTime.Local().Format("2006-01-02 15:04"),
+			s.WakeTime.Local().Format("15:04"))
+
+		return nil
+	},
+}
+
+// resolveSleepTimes turns --bed/--wake values into concrete timestamps.
+// Clock-only values are anchored to the wake date (or to the other value
+// when that one is a full timestamp) so overnight sleep lands correctly.
+func resolveSleepTimes(bedStr, wakeStr string, date time.Time) (time.Time, time.Time, error) {
+	if bedStr == "" || wakeStr == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("both --bed and --wake are required")
+	}
+
+	bedClock, bedIsClock := parseClock(bedStr)
+	wakeClock, wakeIsClock := parseClock(wakeStr)
+
+	var bed, wake time.Time
+	var err error
+
+	switch {
+	case bedIsClock && wakeIsClock:
+		wake = atClock(date, wakeClock)
+		bed = atClock(date, bedClock)
+		if !bed.Before(wake) {
+			bed = bed.AddDate(0, 0, -1)
+		}
+	case wakeIsClock:
+		if bed, err = parseTime(bedStr); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid bed time: %s", bedStr)
+		}
+		wake = atClock(bed, wakeClock)
+		if !wake.After(bed) {
+			wake = wake.AddDate(0, 0, 1)
+		}
+	case bedIsClock:
+		if wake, err = parseTime(wakeStr); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid wake time: %s", wakeStr)
+		}
+		bed = atClock(wake, bedClock)
+		if !bed.Before(wake) {
+			bed = bed.AddDate(0, 0, -1)
+		}
+	default:
+		if bed, err = parseTime(bedStr); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid bed time: %s", bedStr)
+		}
+		if wake, err = parseTime(wakeStr); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid wake time: %s", wakeStr)
+		}
+	}
+
+	if !wake.After(bed) {
+		return time.Time{}, time.Time{}, fmt.Errorf("wake time must be after bed time")
+	}
+	return bed, wake, nil
+}
+
+// parseClock parses an HH:MM clock time.
+func parseClock(s string) (time.Time, bool) {
+	t, err := time.Parse("15:04", s)
+	return t, err == nil
+}
+
+// atClock returns day's date at the hour and minute of clock, in day's location.
+func atClock(day, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, day.Location())
+}
+
+func init() {
+	sleepAddCmd.Flags().StringVar(&sleepBed, "bed", "", "bed time (HH:MM or YYYY-MM-DD HH:MM)")
+	sleepAddCmd.Flags().StringVar(&sleepWake, "wake", "", "wake time (HH:MM or YYYY-MM-DD HH:MM)")
+	sleepAddCmd.Flags().StringVar(&sleepDate, "date", "", "wake date for clock times (YYYY-MM-DD, default today)")
+	sleepAddCmd.Flags().IntVar(&sleepAwakenings, "awakenings", 0, "number of times you woke up")
+	sleepAddCmd.Flags().IntVar(&sleepQuality, "quality", 0, "sleep quality (1-10)")
+	sleepAddCmd.Flags().StringVar(&sleepNotes, "notes", "", "notes for the session")
+
+	sleepListCmd.Flags().IntVarP(&sleepLimit, "limit", "n", 20, "max number of results")
+	sleepDeleteCmd.ValidArgsFunction = completeFirstArg(sleepIDCompletions)
+
+	sleepCmd.AddCommand(sleepAddCmd)
+	sleepCmd.AddCommand(sleepListCmd)
+	sleepCmd.AddCommand(sleepDeleteCmd)
+	rootCmd.AddCommand(sleepCmd)
+}
@@ -0,0 +1,214 @@
+// ABOUTME: CLI commands for statistics computed on demand: VO2max and metric averages.
+// ABOUTME: VO2max comes from recent runs' pace and heart rate, or the max:resting HR ratio.
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/training"
+)
+
+var (
+	statsDays    int
+	statsType    string
+	statsRecord  bool
+	statsAvgDays int
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Fitness estimates and averages computed from your data",
+}
+
+var statsVO2MaxCmd = &cobra.Command{
+	Use:   "vo2max",
+	Short: "Estimate VO2max from recent runs and heart rate",
+	Long: `Estimate VO2max (ml/kg/min) from recent runs.
+
+Each run with a distance, a duration of at least 10 minutes, and an avg_hr
+metric gives an estimate: the oxygen cost of its average pace (Daniels)
+scaled up by the share of heart rate reserve it was run at. Runs below
+half of heart rate reserve are too easy to extrapolate from. The median
+across runs is reported, so one odd run doesn't skew it.
+
+Without any such runs, VO2max is estimated as 15.3 × max / resting heart
+rate, which is rougher.
+
+Max heart rate comes from 'health profile set max-hr', or 208 − 0.7 × age
+when only a birth date is set. Resting heart rate is your latest
+heart_rate entry.
+
+Use --record to save the estimate as a vo2max metric with source
+"estimate", so it shows up in lists, trends, and exports.
+
+EXAMPLES:
+
+  health stats vo2max
+  health stats vo2max --days 30
+  health stats vo2max --record`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if statsDays < 1 {
+			return fmt.Errorf("--days must be at least 1")
+		}
+
+		now := time.Now()
+		maxHR, fromAge, err := profileMaxHR(now)
+		if err != nil {
+			return err
+		}
+		if maxHR == 0 {
+			return fmt.Errorf("no max heart rate (use 'health profile set max-hr 188' or set a birth date)")
+		}
+		restHR, err := restingHeartRate(ctx)
+		if err != nil {
+			return err
+		}
+		if restHR == 0 {
+			return fmt.Errorf("no resting heart rate (log one with 'health add heart_rate 55')")
+		}
+
+		since := now.AddDate(0, 0, -statsDays)
+		runs, err := workoutsWithMetrics(ctx, storage.WorkoutFilter{Type: &statsType, Since: &since})
+		if err != nil {
+			return err
+		}
+		est, ok := training.EstimateVO2Max(runs, maxHR, restHR)
+		if !ok {
+			return fmt.Errorf("max heart rate %.0f must be above resting heart rate %.0f", maxHR, restHR)
+		}
+
+		fmt.Printf("VO2max ≈ %s ml/kg/min\n", color.New(color.Bold).Sprintf("%.1f", est.VO2Max))
+		faint := color.New(color.Faint)
+		maxNote := ""
+		if fromAge {
+			maxNote = " (from age)"
+		}
+		fmt.Println(faint.Sprintf("  Max HR %.0f%s, resting HR %.0f", maxHR, maxNote, restHR))
+		if est.Method == training.MethodRuns {
+			fmt.Println(faint.Sprintf("  Median of %d %s in the last %d days:", len(est.Runs), plural(len(est.Runs), "run", "runs"), statsDays))
+			for _, r := range est.Runs {
+				km, _ := training.DistanceKm(r.Workout)
+				fmt.Println(faint.Sprintf("    %s  %5.1f km in %3d min  → %.1f",
+					r.Workout.StartedAt.Local().Format("2006-01-02"), km, *r.Workout.DurationMinutes, r.VO2Max))
+			}
+		} else {
+			fmt.Println(faint.Sprint("  From the max:resting heart rate ratio. Log runs with a distance"))
+			fmt.Println(faint.Sprintf("  and avg_hr metric for a better estimate (no usable %s workouts", statsType))
+			fmt.Println(faint.Sprintf("  in the last %d days).", statsDays))
+		}
+
+		if !statsRecord {
+			return nil
+		}
+		m := models.NewMetric(models.MetricVO2Max, est.VO2Max).
+			WithSource(models.SourceEstimate).
+			WithMetadata("method", est.Method)
+		if est.Method == training.MethodRuns {
+			m.WithMetadata("runs", strconv.Itoa(len(est.Runs)))
+		}
+		if err := repo.CreateMetric(ctx, m); err != nil {
+			return fmt.Errorf("failed to record vo2max: %w", err)
+		}
+		color.Green("✓ Recorded vo2max %.1f ml/kg/min", est.VO2Max)
+		return nil
+	},
+}
+
+var statsAverageCmd = &cobra.Command{
+	Use:   "average <type>",
+	Short: "Average a metric over recent days",
+	Long: `Average every entry of a metric over the last N days (default 30),
+with the lowest and highest values and how many entries there were.
+
+The store computes the average itself, so this stays quick with years
+of entries.
+
+EXAMPLES:
+
+  health stats average weight
+  health stats average heart_rate --days 7`,
+	Aliases: []string{"avg"},
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !models.IsValidMetricType(args[0]) {
+			return fmt.Errorf("unknown metric type: %s", args[0])
+		}
+		if statsAvgDays < 1 {
+			return fmt.Errorf("--days must be at least 1")
+		}
+
+		mt := models.MetricType(args[0])
+		since := time.Now().AddDate(0, 0, -statsAvgDays)
+		avg, err := repo.AvgMetrics(cmd.Context(), storage.MetricFilter{Type: &mt, Since: &since})
+		if err != nil {
+			return fmt.Errorf("failed to average %s: %w", mt, err)
+		}
+		if avg.Count == 0 {
+			fmt.Printf("No %s logged in the last %d days.\n", mt, statsAvgDays)
+			return nil
+		}
+
+		unit := models.MetricUnits[mt]
+		fmt.Printf("%s averaged %s %s over the last %d days\n", mt,
+			color.New(color.Bold).Sprint(formatAmount(avg.Avg)), unit, statsAvgDays)
+		fmt.Println(color.New(color.Faint).Sprintf("  Range %s–%s %s, %d %s", formatAmount(avg.Min), formatAmount(avg.Max), unit,
+			avg.Count, plural(avg.Count, "entry", "entries")))
+		return nil
+	},
+}
+
+// profileMaxHR returns the profile's max heart rate, or 208 − 0.7 × age
+// (Tanaka) with fromAge set when only a birth date is known. It returns 0
+// when neither is set.
+func profileMaxHR(now time.Time) (maxHR float64, fromAge bool, err error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return 0, false, fmt.Errorf("load config: %w", err)
+	}
+	if cfg.Profile != nil && cfg.Profile.MaxHR > 0 {
+		return cfg.Profile.MaxHR, false, nil
+	}
+	if p := cfg.EmergencyProfile(); p != nil {
+		if age := p.Age(now); age > 0 {
+			return 208 - 0.7*float64(age), true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// restingHeartRate returns the latest heart_rate entry, or 0 without one.
+func restingHeartRate(ctx context.Context) (float64, error) {
+	hr := models.MetricHeartRate
+	metrics, err := repo.ListMetrics(ctx, &hr, 1)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list heart rate: %w", err)
+	}
+	if len(metrics) == 0 {
+		return 0, nil
+	}
+	return metrics[0].Value, nil
+}
+
+func init() {
+	statsVO2MaxCmd.Flags().IntVarP(&statsDays, "days", "d", 60, "use runs from this many days back")
+	statsVO2MaxCmd.Flags().StringVarP(&statsType, "type", "t", "run", "workout type to estimate from")
+	statsVO2MaxCmd.Flags().BoolVar(&statsRecord, "record", false, "save the estimate as a vo2max metric")
+	cobra.CheckErr(statsVO2MaxCmd.RegisterFlagCompletionFunc("type", completeFlag(workoutTypeCompletions)))
+	statsCmd.AddCommand(statsVO2MaxCmd)
+
+	statsAverageCmd.Flags().IntVarP(&statsAvgDays, "days", "d", 30, "average entries from this many days back")
+	statsAverageCmd.ValidArgsFunction = completeFirstArg(metricTypeCompletions)
+	statsCmd.AddCommand(statsAverageCmd)
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,83 @@
+// ABOUTME: CLI command reporting storage health: size, record counts, growth, and large notes.
+// ABOUTME: Prints soft-quota warnings when the markdown store is getting too big to scan.
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/storage"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show storage size, growth, and warnings",
+	Long: `Report on the data store: size on disk, how many records it holds, how
+fast it has grown over the last 30 days, and the largest notes.
+
+Warnings are printed when the markdown backend passes (or is on track to
+pass within 90 days) 5000 files, the point where reading every file per
+query gets slow, and for notes over 16 KB.
+
+EXAMPLES:
+
+  health status`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		st, err := storage.Status(cmd.Context(), repo, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to read storage status: %w", err)
+		}
+
+		faint := color.New(color.Faint)
+		label := func(s string) string { return faint.Sprintf("%-9s", s) }
+
+		if st.Backend != "" {
+			fmt.Printf("%s %s %s\n", label("Backend:"), st.Backend, faint.Sprint(st.Path))
+			size := formatBytes(st.SizeBytes)
+			if st.Backend == "markdown" {
+				size += fmt.Sprintf(" in %d %s", st.Files, plural(st.Files, "file", "files"))
+			}
+			fmt.Printf("%s %s\n", label("Size:"), size)
+		}
+		fmt.Printf("%s %d %s, %d %s\n", label("Records:"),
+			st.Metrics, plural(st.Metrics, "metric", "metrics"),
+			st.Workouts, plural(st.Workouts, "workout", "workouts"))
+		fmt.Printf("%s %d %s in the last 30 days (~%s/month)\n", label("Growth:"),
+			st.Recent, plural(st.Recent, "entry", "entries"), formatBytes(st.MonthlyGrowthBytes()))
+
+		if len(st.LargestNotes) > 0 {
+			fmt.Println("\nLargest notes:")
+			for _, n := range st.LargestNotes {
+				fmt.Printf("  %8s  %s %s\n", formatBytes(int64(n.Bytes)), n.Kind, faint.Sprint(n.ID.String()[:8]))
+			}
+		}
+
+		if len(st.Warnings) > 0 {
+			fmt.Println()
+			for _, w := range st.Warnings {
+				color.Yellow("⚠ %s", w)
+			}
+		}
+		return nil
+	},
+}
+
+// formatBytes renders a byte count as B, KB, or MB.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
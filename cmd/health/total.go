@@ -0,0 +1,122 @@
+// ABOUTME: CLI command for daily totals of cumulative metrics.
+// ABOUTME: Sums entries like water and calories logged over one day.
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+var totalDate string
+
+var totalCmd = &cobra.Command{
+	Use:   "total [type]",
+	Short: "Show daily totals for water, calories, and other cumulative metrics",
+	Long: `Sum the entries logged for a cumulative metric over one day.
+
+Cumulative types: water, calories, protein, carbs, fat
+
+Without a type, every cumulative metric with entries that day is shown.
+
+EXAMPLES:
+
+  health total water                   # Today's water
+  health total                         # All of today's totals
+  health total calories --date 2024-12-14`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		zone, err := entryZone(ctx)
+		if err != nil {
+			return err
+		}
+		day := time.Now().In(zone)
+		if totalDate != "" {
+			t, err := time.ParseInLocation("2006-01-02", totalDate, zone)
+			if err != nil {
+				return fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", totalDate)
+			}
+			day = t
+		}
+
+		types := models.CumulativeMetricTypes
+		if len(args) == 1 {
+			mt := models.MetricType(args[0])
+			if !models.IsCumulative(mt) {
+				return fmt.Errorf("%s is not a cumulative metric; totals apply to %s",
+					args[0], joinMetricTypes(models.CumulativeMetricTypes))
+			}
+			types = []models.MetricType{mt}
+		}
+
+		fmt.Printf("Totals for %s\n", dayLabel(day))
+		shown := 0
+		for _, mt := range types {
+			total, err := storage.DailyTotal(ctx, repo, mt, day)
+			if err != nil {
+				return fmt.Errorf("failed to total %s: %w", mt, err)
+			}
+			// Skip empty types in the overview, but always answer a direct question
+			if total.Count == 0 && len(args) == 0 {
+				continue
+			}
+			fmt.Printf("  %s %s %s %s\n",
+				padRight(string(mt), 10),
+				formatAmount(total.Sum),
+				models.MetricUnits[mt],
+				color.New(color.Faint).Sprintf("(%d %s)", total.Count, plural(total.Count, "entry", "entries")))
+			shown++
+		}
+		if shown == 0 {
+			fmt.Println("  Nothing logged.")
+		}
+
+		return nil
+	},
+}
+
+// dayLabel names a day relative to today for short status lines.
+func dayLabel(t time.Time) string {
+	now := time.Now().In(t.Location())
+	if t.Year() == now.Year() && t.YearDay() == now.YearDay() {
+		return "Today"
+	}
+	return t.Format("2006-01-02")
+}
+
+// formatAmount prints whole numbers without decimals and others with up to two.
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// plural picks the singular or plural form of a word for n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// joinMetricTypes lists metric types for help and error messages.
+func joinMetricTypes(types []models.MetricType) string {
+	names := make([]string, len(types))
+	for i, mt := range types {
+		names[i] = string(mt)
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	totalCmd.Flags().StringVar(&totalDate, "date", "", "day to total (YYYY-MM-DD, default today)")
+	totalCmd.ValidArgsFunction = completeFirstArg(cumulativeTypeCompletions)
+	rootCmd.AddCommand(totalCmd)
+}
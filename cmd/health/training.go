@@ -0,0 +1,138 @@
+// ABOUTME: CLI commands for training analytics: weekly volume and workload.
+// ABOUTME: Flags weeks where the acute:chronic workload ratio spikes above 1.5.
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/training"
+)
+
+var (
+	trainingWeeks int
+	trainingType  string
+)
+
+var trainingCmd = &cobra.Command{
+	Use:   "training",
+	Short: "Training volume and workload",
+}
+
+var trainingLoadCmd = &cobra.Command{
+	Use:   "load",
+	Short: "Weekly volume per workout type and the acute:chronic workload ratio",
+	Long: `Total each week's workouts, minutes, and distance, overall and per
+workout type, and track how this week's load compares with what you're
+used to.
+
+The acute:chronic workload ratio (ACWR) divides the minutes of the last
+7 days by the weekly average over the last 28. Around 0.8-1.3 is steady
+training; above 1.5 load is climbing faster than you've built up to and
+is flagged as a spike. Each week's ratio is taken at its end.
+Upcoming events from 'health event' are listed with their countdowns.
+
+Distance comes from the 'distance' workout metric, converted to km from
+m, mi, or yd.
+
+EXAMPLES:
+
+  health training load
+  health training load --weeks 8
+  health training load --type run`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if trainingWeeks < 1 {
+			return fmt.Errorf("--weeks must be at least 1")
+		}
+
+		now := time.Now()
+		first := training.WeekStart(now).AddDate(0, 0, -7*(trainingWeeks-1))
+		// The first week's chronic load reaches back four weeks before it ends
+		since := first.AddDate(0, 0, -21)
+		filter := storage.WorkoutFilter{Since: &since}
+		if trainingType != "" {
+			filter.Type = &trainingType
+		}
+		workouts, err := workoutsWithMetrics(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if len(workouts) == 0 {
+			fmt.Println("No workouts found.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		fmt.Println(faint.Sprintf("%-14s %8s %8s %10s %6s", "Week of", "Workouts", "Minutes", "Distance", "ACWR"))
+		var spikes []string
+		for _, week := range training.Weekly(workouts, first, trainingWeeks) {
+			end := week.Start.AddDate(0, 0, 7)
+			if end.After(now) {
+				end = now
+			}
+			load := training.Workload(workouts, end)
+			ratio := fmt.Sprintf("%6s", "-")
+			if load.Prior > 0 {
+				ratio = fmt.Sprintf("%6.2f", load.Ratio())
+			}
+			if load.Spike() {
+				ratio = color.RedString(ratio)
+				spikes = append(spikes, week.Start.Format("2006-01-02"))
+			}
+			fmt.Printf("%-14s %8d %8.0f %10s %s\n",
+				week.Start.Format("2006-01-02"), week.Total.Workouts, week.Total.Minutes,
+				formatDistance(week.Total.DistanceKm), ratio)
+
+			if trainingType != "" {
+				continue
+			}
+			types := make([]string, 0, len(week.ByType))
+			for t := range week.ByType {
+				types = append(types, t)
+			}
+			slices.Sort(types)
+			for _, t := range types {
+				v := week.ByType[t]
+				fmt.Println(faint.Sprintf("  %-12s %8d %8.0f %10s", t, v.Workouts, v.Minutes, formatDistance(v.DistanceKm)))
+			}
+		}
+
+		load := training.Workload(workouts, now)
+		fmt.Printf("\nAcute:chronic workload: ")
+		switch {
+		case load.Chronic == 0:
+			fmt.Println("- (no workouts in the last 28 days)")
+		case load.Prior == 0:
+			fmt.Println("- (no workouts before the last 7 days to compare with)")
+		default:
+			fmt.Printf("%.2f (%.0f min in the last 7 days, %.0f min/week over 28)\n", load.Ratio(), load.Acute, load.Chronic)
+		}
+		for _, s := range spikes {
+			color.Yellow("⚠ Load spike in the week of %s: ratio above %.1f", s, training.SpikeRatio)
+		}
+		return printUpcomingEvents(ctx)
+	},
+}
+
+// formatDistance renders kilometers for the volume table, or "" for none.
+func formatDistance(km float64) string {
+	if km == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f km", km)
+}
+
+func init() {
+	trainingLoadCmd.Flags().IntVarP(&trainingWeeks, "weeks", "w", 4, "number of weeks to show, ending with this one")
+	trainingLoadCmd.Flags().StringVarP(&trainingType, "type", "t", "", "only this workout type")
+	cobra.CheckErr(trainingLoadCmd.RegisterFlagCompletionFunc("type", completeFlag(workoutTypeCompletions)))
+	trainingCmd.AddCommand(trainingLoadCmd)
+	rootCmd.AddCommand(trainingCmd)
+}
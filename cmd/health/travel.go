@@ -0,0 +1,257 @@
+// ABOUTME: CLI commands for travel mode (health travel start/end).
+// ABOUTME: Active trips tag new entries with their destination and set the timezone for clock times.
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/timeparse"
+)
+
+var (
+	travelTZ    string
+	travelAt    string
+	travelNotes string
+	travelLimit int
+)
+
+var travelCmd = &cobra.Command{
+	Use:   "travel",
+	Short: "Mark trips so travel doesn't skew your data",
+	Long: `Travel mode marks a period away from home.
+
+While a trip is active:
+
+  - New metrics and workouts without --location are tagged with the
+    trip's destination, so 'health list --location' can separate them.
+  - With --tz, --at timestamps, sleep clock times, and "today" for
+    totals are read in the trip's timezone instead of the system's.
+
+Run 'health travel' on its own to see the active trip.
+
+EXAMPLES:
+
+  health travel start paris --tz Europe/Paris
+  health travel start 35.68,139.69 --tz Asia/Tokyo --notes "Conference"
+  health travel end
+  health travel list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		t, err := storage.ActiveTrip(cmd.Context(), repo)
+		if err != nil {
+			return fmt.Errorf("failed to load trips: %w", err)
+		}
+		if t == nil {
+			fmt.Println("Not traveling.")
+			return nil
+		}
+		fmt.Printf("Traveling: %s\n", formatTrip(t))
+		return nil
+	},
+}
+
+var travelStartCmd = &cobra.Command{
+	Use:   "start <destination>",
+	Short: "Start a trip",
+	Long: `Start a trip to a registered location, raw "lat,lon" coordinates, or a
+new place name (which gets registered as a location).`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		active, err := storage.ActiveTrip(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("failed to load trips: %w", err)
+		}
+		if active != nil {
+			return fmt.Errorf("already traveling to %s (end it with: health travel end)", active.Destination)
+		}
+
+		destination, err := travelDestination(ctx, args[0])
+		if err != nil {
+			return err
+		}
+
+		t := models.NewTrip(destination)
+		if travelTZ != "" {
+			if _, err := t.WithTimezone(travelTZ); err != nil {
+				return err
+			}
+		}
+		if travelAt != "" {
+			at, err := parseTime(travelAt)
+			if err != nil {
+				return fmt.Errorf("invalid timestamp: %s", travelAt)
+			}
+			t.WithStartedAt(at)
+		}
+		if travelNotes != "" {
+			t.WithNotes(travelNotes)
+		}
+
+		if err := repo.CreateTrip(ctx, t); err != nil {
+			return fmt.Errorf("failed to start trip: %w", err)
+		}
+
+		color.Green("✓ Started trip to %s", t.Destination)
+		fmt.Printf("  %s %s\n", color.New(color.Faint).Sprint(t.ID.String()[:8]), formatTrip(t))
+		return nil
+	},
+}
+
+var travelEndCmd = &cobra.Command{
+	Use:   "end",
+	Short: "End the active trip",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		t, err := storage.ActiveTrip(ctx, repo)
+		if err != nil {
+			return fmt.Errorf("failed to load trips: %w", err)
+		}
+		if t == nil {
+			return fmt.Errorf("not traveling")
+		}
+
+		endedAt := time.Now()
+		if travelAt != "" {
+			if endedAt, err = parseTime(travelAt); err != nil {
+				return fmt.Errorf("invalid timestamp: %s", travelAt)
+			}
+		}
+		if !endedAt.After(t.StartedAt) {
+			return fmt.Errorf("trip end must be after its start (%s)", t.StartedAt.Local().Format("2006-01-02 15:04"))
+		}
+
+		if err := repo.EndTrip(ctx, t.ID.String(), endedAt); err != nil {
+			return fmt.Errorf("failed to end trip: %w", err)
+		}
+		t.EndedAt = &endedAt
+
+		color.Green("✓ Ended trip to %s", t.Destination)
+		fmt.Printf("  %s %s\n", color.New(color.Faint).Sprint(t.ID.String()[:8]), formatTrip(t))
+		return nil
+	},
+}
+
+var travelListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List trips",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		trips, err := repo.ListTrips(cmd.Context(), travelLimit)
+		if err != nil {
+			return fmt.Errorf("failed to list trips: %w", err)
+		}
+
+		if len(trips) == 0 {
+			fmt.Println("No trips found.")
+			return nil
+		}
+
+		faint := color.New(color.Faint)
+		for _, t := range trips {
+			fmt.Printf("%s %s\n", faint.Sprint(t.ID.String()[:8]), formatTrip(t))
+		}
+		return nil
+	},
+}
+
+var travelDeleteCmd = &cobra.Command{
+	Use:     "delete <id>",
+	Aliases: []string{"rm"},
+	Short:   "Delete a trip",
+	Long: `Delete a trip.
+
+Entries already tagged with its destination keep their tag.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := repo.DeleteTrip(cmd.Context(), args[0]); err != nil {
+			return fmt.Errorf("failed to delete trip: %w", err)
+		}
+		color.Yellow("✗ Deleted trip %s", args[0])
+		return nil
+	},
+}
+
+// travelDestination resolves a trip destination to a location tag,
+// registering unknown place names so they can be used with --location.
+func travelDestination(ctx context.Context, s string) (string, error) {
+	if _, _, ok, _ := models.ParseCoordinates(s); ok {
+		return resolveLocationTag(ctx, s)
+	}
+	if l, err := repo.GetLocation(ctx, s); err == nil {
+		return l.Name, nil
+	}
+
+	l := models.NewLocation(s)
+	if err := repo.CreateLocation(ctx, l); err != nil {
+		return "", fmt.Errorf("failed to register location: %w", err)
+	}
+	fmt.Printf("  Registered location %s\n", l.Name)
+	return l.Name, nil
+}
+
+// formatTrip renders a trip's destination, dates, and timezone on one line.
+func formatTrip(t *models.Trip) string {
+	loc := t.Location()
+	s := fmt.Sprintf("%s  %s → ", padRight(t.Destination, 16), t.StartedAt.In(loc).Format("2006-01-02"))
+	if t.EndedAt != nil {
+		s += t.EndedAt.In(loc).Format("2006-01-02")
+	} else {
+		s += "now"
+	}
+	if t.Timezone != nil {
+		s += "  " + *t.Timezone
+	}
+	return s
+}
+
+// entryZone returns the timezone that clock times and "today" are read in:
+// the active trip's, or local time when not traveling.
+func entryZone(ctx context.Context) (*time.Location, error) {
+	t, err := storage.ActiveTrip(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trips: %w", err)
+	}
+	if t == nil {
+		return time.Local, nil
+	}
+	return t.Location(), nil
+}
+
+// parseEntryTime parses a --at timestamp. During a trip with a timezone,
+// timestamps without an offset and phrases like "this morning" are read
+// in the trip's timezone.
+func parseEntryTime(ctx context.Context, s string) (time.Time, error) {
+	t, err := storage.ActiveTrip(ctx, repo)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to load trips: %w", err)
+	}
+	if t == nil || t.Timezone == nil {
+		return parseTime(s)
+	}
+
+	return timeparse.Parse(s, time.Now().In(t.Location()))
+}
+
+func init() {
+	travelStartCmd.Flags().StringVar(&travelTZ, "tz", "", "IANA timezone at the destination (e.g. Europe/Paris)")
+	travelStartCmd.Flags().StringVar(&travelAt, "at", "", "when the trip started (YYYY-MM-DD HH:MM, default now)")
+	travelStartCmd.Flags().StringVar(&travelNotes, "notes", "", "notes for the trip")
+	travelEndCmd.Flags().StringVar(&travelAt, "at", "", "when the trip ended (YYYY-MM-DD HH:MM, default now)")
+	travelListCmd.Flags().IntVarP(&travelLimit, "limit", "n", 20, "max number of results")
+
+	travelCmd.AddCommand(travelStartCmd)
+	travelCmd.AddCommand(travelEndCmd)
+	travelCmd.AddCommand(travelListCmd)
+	travelCmd.AddCommand(travelDeleteCmd)
+	rootCmd.AddCommand(travelCmd)
+}
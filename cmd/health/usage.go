@@ -0,0 +1,145 @@
+// ABOUTME: CLI command showing local usage stats: most-run commands and metrics logged per week.
+// ABOUTME: Opt-in via 'health usage on'; the log stays in the data dir and is never sent anywhere.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/usage"
+)
+
+// usageWeeks is how many weeks of metric logging 'health usage' charts.
+const usageWeeks = 8
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Your own logging habits (local, opt-in)",
+	Long: `Show which commands you run most and how many metrics you log each
+week, so you can see your own habits.
+
+Command stats are off until you run 'health usage on'. They are appended
+to usage.jsonl in the data directory and never leave your machine; the
+weekly metric counts come straight from your data.
+
+EXAMPLES:
+
+  health usage on      # Start recording commands
+  health usage         # Show stats
+  health usage off     # Stop recording (keeps the log)
+  health usage reset   # Delete the log`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		events, err := usage.Load(usage.Path(cfg.GetDataDir()))
+		if err != nil {
+			return err
+		}
+		metrics, err := repo.ListMetrics(cmd.Context(), nil, 0)
+		if err != nil {
+			return fmt.Errorf("failed to list metrics: %w", err)
+		}
+
+		faint := color.New(color.Faint)
+		switch {
+		case len(events) > 0:
+			fmt.Printf("Most-used commands (since %s):\n", events[0].At.Local().Format("2006-01-02"))
+			for _, c := range usage.TopCommands(events, 10) {
+				fmt.Printf("  %5d  %s\n", c.Count, c.Command)
+			}
+		case cfg.UsageStats:
+			fmt.Println("No commands recorded yet.")
+		default:
+			fmt.Println("Command stats are off. Run 'health usage on' to start recording.")
+		}
+
+		times := make([]time.Time, len(metrics))
+		for i, m := range metrics {
+			times[i] = m.CreatedAt
+		}
+		weeks := usage.Weekly(times, time.Now(), usageWeeks)
+		most := 0
+		for _, w := range weeks {
+			most = max(most, w.Count)
+		}
+		fmt.Println("\nMetrics logged per week:")
+		for _, w := range weeks {
+			bar := ""
+			if most > 0 {
+				bar = strings.Repeat("█", (w.Count*30+most-1)/most)
+			}
+			fmt.Printf("  %s  %4d  %s\n", faint.Sprint(w.Start.Format("Jan 02")), w.Count, bar)
+		}
+		return nil
+	},
+}
+
+var usageOnCmd = &cobra.Command{
+	Use:   "on",
+	Short: "Start recording command usage",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setUsageStats(true)
+	},
+}
+
+var usageOffCmd = &cobra.Command{
+	Use:   "off",
+	Short: "Stop recording command usage",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setUsageStats(false)
+	},
+}
+
+var usageResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Delete the usage log",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		if err := os.Remove(usage.Path(cfg.GetDataDir())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("delete usage log: %w", err)
+		}
+		color.Yellow("✗ Usage log deleted")
+		return nil
+	},
+}
+
+// setUsageStats turns the usage log on or off in config.
+func setUsageStats(on bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	cfg.UsageStats = on
+	if err := cfg.Save(); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	if on {
+		color.Green("✓ Recording command usage to %s", usage.Path(cfg.GetDataDir()))
+	} else {
+		color.Yellow("✗ Usage stats off")
+	}
+	return nil
+}
+
+func init() {
+	usageCmd.AddCommand(usageOnCmd)
+	usageCmd.AddCommand(usageOffCmd)
+	usageCmd.AddCommand(usageResetCmd)
+	rootCmd.AddCommand(usageCmd)
+}
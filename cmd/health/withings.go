@@ -0,0 +1,233 @@
+// ABOUTME: CLI commands authorizing with Withings and importing weight and body fat.
+// ABOUTME: Keeps OAuth tokens in the secrets store, refreshing them as they expire.
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/withings"
+)
+
+// withingsSecretEnv holds the app's client secret so it stays out of
+// shell history and config.json.
+const withingsSecretEnv = "HEALTH_WITHINGS_CLIENT_SECRET"
+
+// withingsSecretName is where the OAuth token lives in the secrets store.
+const withingsSecretName = "withings"
+
+// withingsDefaultRedirect is used until another callback URL is given.
+// Nothing needs to listen there: the code is copied from the address bar.
+const withingsDefaultRedirect = "http://localhost/health-withings"
+
+var (
+	withingsClientID    string
+	withingsRedirectURI string
+	withingsCode        string
+	withingsSince       string
+)
+
+var importWithingsCmd = &cobra.Command{
+	Use:   "withings",
+	Short: "Import weight and body fat from a Withings scale",
+	Long: `Pull weigh-ins from the Withings API as weight metrics, plus body_fat
+when the scale measured it.
+
+The first run imports the whole history. Later runs ask only for
+weigh-ins added or changed since the last sync (kept in config.json as
+"withings.last_update"). --since re-reads everything from a day on.
+Entries are recorded with source "withings", and anything already stored
+at the same time is skipped.
+
+Authorize once with 'health import withings auth'. The access token is
+refreshed automatically when it expires; tokens are kept in secrets.json
+beside config.json, readable only by you.
+
+EXAMPLES:
+
+  export HEALTH_WITHINGS_CLIENT_SECRET=...
+  health import withings                       # New weigh-ins (all on the first run)
+  health import withings --since 2020-01-01    # Re-read from a date
+  0 8 * * * health cron import withings`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		if cfg.Withings == nil || cfg.Withings.ClientID == "" {
+			return fmt.Errorf("not authorized yet; run 'health import withings auth --client-id <id>'")
+		}
+		client, err := newWithingsClient(cfg)
+		if err != nil {
+			return err
+		}
+		token, err := withingsToken(ctx, client)
+		if err != nil {
+			return err
+		}
+
+		var q withings.Query
+		switch {
+		case withingsSince != "":
+			if q.Since, err = time.ParseInLocation("2006-01-02", withingsSince, time.Local); err != nil {
+				return fmt.Errorf("invalid date format: %s (use YYYY-MM-DD)", withingsSince)
+			}
+		case cfg.Withings.LastUpdate != "":
+			if q.LastUpdate, err = time.Parse(time.RFC3339, cfg.Withings.LastUpdate); err != nil {
+				return fmt.Errorf("invalid withings.last_update in config: %s", cfg.Withings.LastUpdate)
+			}
+		}
+
+		result, err := client.Measures(ctx, token.AccessToken, q, time.Local)
+		if err != nil {
+			return err
+		}
+		fresh, skipped, err := newMetrics(result.Metrics, metricAtKey, func(m *models.Metric) (bool, error) {
+			return hasMetricAt(ctx, m.MetricType, m.RecordedAt)
+		})
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateMetrics(ctx, fresh); err != nil {
+			return fmt.Errorf("failed to store metrics: %w", err)
+		}
+		added := len(fresh)
+
+		cfg.Withings.LastUpdate = result.UpdateTime.UTC().Format(time.RFC3339)
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save sync cursor: %w", err)
+		}
+
+		color.Green("✓ Imported %d %s from Withings", added, plural(added, "entry", "entries"))
+		if skipped > 0 {
+			fmt.Printf("  %d already stored\n", skipped)
+		}
+		return nil
+	},
+}
+
+var importWithingsAuthCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Authorize access to your Withings data",
+	Long: `Connect health to your Withings account. This takes two steps:
+
+  1. Create an app at developer.withings.com with a callback URL (any
+     URL works, e.g. http://localhost/health-withings) and run:
+
+       health import withings auth --client-id <id> --redirect-uri <url>
+
+     It prints a link. Open it, allow access, and copy the "code"
+     parameter from the address the browser is sent to.
+
+  2. Within 30 seconds, exchange the code for a token:
+
+       health import withings auth --code <code>
+
+The client ID and callback URL are saved in config.json. The client
+secret is read from $HEALTH_WITHINGS_CLIENT_SECRET, and the resulting
+tokens are kept in secrets.json.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+		if cfg.Withings == nil {
+			cfg.Withings = &config.WithingsConfig{}
+		}
+		cfg.Withings.ClientID = cmp.Or(withingsClientID, cfg.Withings.ClientID)
+		cfg.Withings.RedirectURI = cmp.Or(withingsRedirectURI, cfg.Withings.RedirectURI, withingsDefaultRedirect)
+		if cfg.Withings.ClientID == "" {
+			return fmt.Errorf("--client-id is required")
+		}
+		if err := cfg.Save(); err != nil {
+			return fmt.Errorf("save config: %w", err)
+		}
+		client, err := newWithingsClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		if withingsCode == "" {
+			state := make([]byte, 8)
+			if _, err := rand.Read(state); err != nil {
+				return err
+			}
+			fmt.Println("Open this link, allow access, and copy the code from the address you land on:")
+			fmt.Println()
+			fmt.Println("  " + client.AuthCodeURL(cfg.Withings.RedirectURI, hex.EncodeToString(state)))
+			fmt.Println()
+			fmt.Println("Then run: health import withings auth --code <code>")
+			return nil
+		}
+
+		token, err := client.Exchange(cmd.Context(), withingsCode, cfg.Withings.RedirectURI)
+		if err != nil {
+			return err
+		}
+		if err := config.SaveSecret(withingsSecretName, token); err != nil {
+			return fmt.Errorf("save token: %w", err)
+		}
+		color.Green("✓ Authorized Withings user %s", token.UserID)
+		return nil
+	},
+}
+
+func newWithingsClient(cfg *config.Config) (*withings.Client, error) {
+	secret := os.Getenv(withingsSecretEnv)
+	if secret == "" {
+		return nil, fmt.Errorf("$%s is required", withingsSecretEnv)
+	}
+	client := withings.NewClient(cfg.Withings.ClientID, secret)
+	if cfg.Withings.APIURL != "" {
+		client.BaseURL = cfg.Withings.APIURL
+	}
+	return client, nil
+}
+
+// withingsToken loads the stored token, refreshing and saving it again
+// when it has expired.
+func withingsToken(ctx context.Context, client *withings.Client) (*withings.Token, error) {
+	var token withings.Token
+	ok, err := config.LoadSecret(withingsSecretName, &token)
+	if err != nil {
+		return nil, fmt.Errorf("load token: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("not authorized yet; run 'health import withings auth'")
+	}
+	if !token.Expired(time.Now()) {
+		return &token, nil
+	}
+
+	fresh, err := client.Refresh(ctx, &token)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token: %w", err)
+	}
+	if err := config.SaveSecret(withingsSecretName, fresh); err != nil {
+		return nil, fmt.Errorf("save token: %w", err)
+	}
+	return fresh, nil
+}
+
+func init() {
+	importWithingsCmd.Flags().StringVar(&withingsSince, "since", "", "re-read weigh-ins from this day on (YYYY-MM-DD)")
+	importWithingsAuthCmd.Flags().StringVar(&withingsClientID, "client-id", "", "client ID of your Withings app")
+	importWithingsAuthCmd.Flags().StringVar(&withingsRedirectURI, "redirect-uri", "", "callback URL registered for the app (default "+withingsDefaultRedirect+")")
+	importWithingsAuthCmd.Flags().StringVar(&withingsCode, "code", "", "authorization code from the callback URL")
+
+	importWithingsCmd.AddCommand(importWithingsAuthCmd)
+	importCmd.AddCommand(importWithingsCmd)
+}
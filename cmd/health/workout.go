@@ -1,13 +1,22 @@
 // ABOUTME: CLI commands for managing workouts.
-// ABOUTME: Supports add, list, show, and metric subcommands.
+// ABOUTME: Supports add, list, show, metric, set, comment, and weather subcommands.
 package main
 
 import (
+	"cmp"
+	"context"
 	"fmt"
+	"maps"
+	"os"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/fatih/color"
+	"github.com/harperreed/health/internal/environment"
 	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/route"
+	"github.com/harperreed/health/internal/storage"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +25,20 @@ var (
 	workoutNotes    string
 	workoutType     string
 	workoutLimit    int
+	workoutShowRaw  bool
+	workoutWeather  bool
+	workoutLocation string
+	workoutMetrics  []string
+	workoutMeta     []string
+	workoutRPE      int
+	workoutZone     int
+
+	workoutListLocation string
+	workoutListSource   string
+	workoutListWide     bool
+
+	workoutCommentAuthor string
+	workoutCommentFile   string
 )
 
 var workoutCmd = &cobra.Command{
@@ -39,6 +62,9 @@ COMMANDS:
   list     List recent workouts
   show     View workout with all its metrics
   metric   Add a metric to an existing workout
+  set      Log strength-training sets (e.g. bench 3x5 @100kg)
+  comment  Attach feedback (e.g. from a coach) to a workout
+  weather  Attach historical weather to a workout
 
 The workout type is freeform - use whatever makes sense for you:
   run, lift, swim, cycle, yoga, hiit, walk, climb, etc.`,
@@ -49,46 +75,190 @@ var workoutAddCmd = &cobra.Command{
 	Short: "Add a new workout",
 	Long: `Add a new workout session.
 
+Each --metric name=value[unit] attaches a metric in the same step, as
+'health workout metric' would afterwards.
+
+--rpe rates how hard it felt, from 1 (very light) to 10 (max effort), and
+--zone records the intensity zone, 1 (recovery) to 5 (maximal).
+
+Outdoor workout types listed in environment.outdoor_workouts get the
+weather at the configured location attached automatically. Use --weather
+to force it for any type, or --weather=false to skip it.
+
 Examples:
   health workout add run --duration 45
-  health workout add lift --notes "Leg day"`,
+  health workout add lift --notes "Leg day"
+  health workout add hike --duration 120 --weather
+  health workout add lift --location gym
+  health workout add run --duration 30 --metric distance=5.2km --metric avg_hr=150
+  health workout add run --duration 40 --rpe 7 --zone 3`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		workoutType := args[0]
 
-		w := models.NewWorkout(workoutType)
+		meta, err := models.ParseMetadata(workoutMeta)
+		if err != nil {
+			return err
+		}
+		w := models.NewWorkout(workoutType).WithSource(models.SourceManual)
+		w.Metadata = meta
+
+		// Parse every --metric up front so a typo doesn't leave a half-logged workout
+		var metrics []*models.WorkoutMetric
+		for _, flag := range workoutMetrics {
+			name, value, unit, err := parseWorkoutMetricFlag(flag)
+			if err != nil {
+				return err
+			}
+			metrics = append(metrics, models.NewWorkoutMetric(w.ID, name, value, unit))
+		}
+
 		if workoutDuration > 0 {
 			w.WithDuration(workoutDuration)
 		}
+		if workoutRPE != 0 {
+			w.WithRPE(workoutRPE)
+		}
+		if workoutZone != 0 {
+			w.WithZone(workoutZone)
+		}
+		if err := w.ValidateIntensity(); err != nil {
+			return err
+		}
 		if workoutNotes != "" {
 			w.WithNotes(workoutNotes)
 		}
+		if workoutLocation != "" {
+			tag, err := resolveLocationTag(ctx, workoutLocation)
+			if err != nil {
+				return err
+			}
+			w.WithLocation(tag)
+		}
+		location, err := storage.TagFromTrip(ctx, repo, w.Location, w.StartedAt)
+		if err != nil {
+			return err
+		}
+		w.Location = location
 
-		if err := repo.CreateWorkout(w); err != nil {
+		if err := repo.CreateWorkout(ctx, w); err != nil {
 			return fmt.Errorf("failed to create workout: %w", err)
 		}
 
+		for _, m := range metrics {
+			if err := repo.AddWorkoutMetric(ctx, m); err != nil {
+				return fmt.Errorf("failed to add workout metric: %w", err)
+			}
+		}
+
 		color.Green("✓ Added %s workout", workoutType)
 		fmt.Printf("  ID: %s\n", w.ID.String()[:8])
 		if w.DurationMinutes != nil {
 			fmt.Printf("  Duration: %d min\n", *w.DurationMinutes)
 		}
+		if intensity := formatIntensity(w); intensity != "" {
+			fmt.Printf("  Intensity: %s\n", intensity)
+		}
+		for _, m := range metrics {
+			unit := ""
+			if m.Unit != nil {
+				unit = " " + *m.Unit
+			}
+			fmt.Printf("  %s: %s%s\n", m.MetricName, strconv.FormatFloat(m.Value, 'f', -1, 64), unit)
+		}
+		rememberCommand(cmd, args)
+
+		enricher, err := loadWorkoutEnricher()
+		if err != nil {
+			return err
+		}
+		enrich := enricher != nil && enricher.IsOutdoor(workoutType)
+		if cmd.Flags().Changed("weather") {
+			enrich = workoutWeather
+		}
+		if enrich {
+			if enricher == nil {
+				return fmt.Errorf("no location configured: set environment.latitude/longitude in config")
+			}
+			// The workout is already saved; a provider outage shouldn't undo it.
+			added, err := enricher.Enrich(ctx, repo, w)
+			if err != nil {
+				color.Yellow("! Could not fetch weather: %v", err)
+			} else if line := formatWeather(added); line != "" {
+				fmt.Printf("  Weather: %s\n", line)
+			}
+		}
 
 		return nil
 	},
 }
 
+// parseWorkoutMetricFlag splits a --metric value like "distance=5.2km" or
+// "avg_hr=150" into name, value, and (possibly empty) unit.
+func parseWorkoutMetricFlag(s string) (string, float64, string, error) {
+	name, rest, ok := strings.Cut(s, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return "", 0, "", fmt.Errorf("invalid --metric %q: use name=value[unit], e.g. distance=5.2km", s)
+	}
+	rest = strings.TrimSpace(rest)
+	end := strings.IndexFunc(rest, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.+-eE", r)
+	})
+	if end < 0 {
+		end = len(rest)
+	}
+	// Back off over a trailing e/E so units like "ea" aren't read as an exponent
+	for end > 0 {
+		if value, err := strconv.ParseFloat(rest[:end], 64); err == nil {
+			return name, value, strings.TrimSpace(rest[end:]), nil
+		}
+		end--
+	}
+	return "", 0, "", fmt.Errorf("invalid --metric %q: %q doesn't start with a number", s, rest)
+}
+
+// formatIntensity renders a workout's RPE and zone, e.g. "RPE 7/10, zone 3",
+// or "" when neither is set.
+func formatIntensity(w *models.Workout) string {
+	var parts []string
+	if w.RPE != nil {
+		parts = append(parts, fmt.Sprintf("RPE %d/%d", *w.RPE, models.MaxRPE))
+	}
+	if w.Zone != nil {
+		parts = append(parts, fmt.Sprintf("zone %d", *w.Zone))
+	}
+	return strings.Join(parts, ", ")
+}
+
 var workoutListCmd = &cobra.Command{
 	Use:     "list",
 	Aliases: []string{"ls"},
 	Short:   "List workouts",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		var wType *string
 		if workoutType != "" {
 			wType = &workoutType
 		}
 
-		workouts, err := repo.ListWorkouts(wType, workoutLimit)
+		filter := storage.WorkoutFilter{Type: wType, Limit: workoutLimit}
+		if workoutListLocation != "" {
+			tag, err := resolveLocationTag(ctx, workoutListLocation)
+			if err != nil {
+				return err
+			}
+			filter.Location = &tag
+		}
+		if workoutListSource != "" {
+			if !models.IsValidSource(workoutListSource) {
+				return fmt.Errorf("unknown source: %s (use %s)", workoutListSource, strings.Join(models.AllSources, ", "))
+			}
+			filter.Source = &workoutListSource
+		}
+
+		workouts, err := repo.QueryWorkouts(ctx, filter)
 		if err != nil {
 			return fmt.Errorf("failed to list workouts: %w", err)
 		}
@@ -104,11 +274,23 @@ var workoutListCmd = &cobra.Command{
 			if w.DurationMinutes != nil {
 				duration = fmt.Sprintf("%d min", *w.DurationMinutes)
 			}
-			fmt.Printf("%s %s %s %s\n",
+			if intensity := formatIntensity(w); intensity != "" {
+				duration = strings.TrimSpace(duration + " " + faint.Sprintf("(%s)", intensity))
+			}
+			location := ""
+			if w.Location != nil {
+				location = faint.Sprintf(" @%s", *w.Location)
+			}
+			when := w.StartedAt.Local().Format("2006-01-02 15:04")
+			if workoutListWide {
+				when += " " + padRight(cmp.Or(w.Source, "-"), 12)
+			}
+			fmt.Printf("%s %s %s %s%s\n",
 				faint.Sprint(w.ID.String()[:8]),
-				faint.Sprint(w.StartedAt.Format("2006-01-02 15:04")),
+				faint.Sprint(when),
 				padRight(w.WorkoutType, 12),
-				duration)
+				duration,
+				location)
 		}
 
 		return nil
@@ -118,36 +300,117 @@ var workoutListCmd = &cobra.Command{
 var workoutShowCmd = &cobra.Command{
 	Use:   "show <id>",
 	Short: "Show workout details",
-	Args:  cobra.ExactArgs(1),
+	Long: `Show a workout with all of its metrics and sets.
+
+Notes are rendered as markdown (bold, italics, lists, links).
+Use --raw to print them exactly as stored. --route adds a summary of the
+attached GPS route and a map link.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		w, err := repo.GetWorkoutWithMetrics(args[0])
-		if err != nil {
-			return fmt.Errorf("failed to get workout: %w", err)
-		}
+		return printWorkout(cmd.Context(), args[0])
+	},
+}
 
-		fmt.Printf("Workout: %s\n", w.ID.String()[:8])
-		fmt.Printf("Type: %s\n", w.WorkoutType)
-		fmt.Printf("Started: %s\n", w.StartedAt.Format("2006-01-02 15:04"))
-		if w.DurationMinutes != nil {
-			fmt.Printf("Duration: %d min\n", *w.DurationMinutes)
+// printWorkout prints a workout with its metrics, sets, and comments.
+func printWorkout(ctx context.Context, idOrPrefix string) error {
+	w, err := repo.GetWorkoutWithMetrics(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("failed to get workout: %w", err)
+	}
+
+	fmt.Printf("Workout: %s\n", w.ID.String()[:8])
+	fmt.Printf("Type: %s\n", w.WorkoutType)
+	fmt.Printf("Started: %s\n", w.StartedAt.Local().Format("2006-01-02 15:04"))
+	if w.DurationMinutes != nil {
+		fmt.Printf("Duration: %d min\n", *w.DurationMinutes)
+	}
+	if intensity := formatIntensity(w); intensity != "" {
+		fmt.Printf("Intensity: %s\n", intensity)
+	}
+	if w.Location != nil {
+		fmt.Printf("Location: %s\n", *w.Location)
+	}
+	if w.Source != "" {
+		fmt.Printf("Source: %s\n", w.Source)
+	}
+	if len(w.Metadata) > 0 {
+		fmt.Println("Metadata:")
+		for _, k := range slices.Sorted(maps.Keys(w.Metadata)) {
+			fmt.Printf("  %s: %s\n", k, w.Metadata[k])
 		}
-		if w.Notes != nil {
-			fmt.Printf("Notes: %s\n", *w.Notes)
+	}
+	if w.Notes != nil {
+		notes := *w.Notes
+		if !workoutShowRaw {
+			notes = renderMarkdown(notes)
 		}
-
-		if len(w.Metrics) > 0 {
-			fmt.Println("\nMetrics:")
-			for _, m := range w.Metrics {
-				unit := ""
-				if m.Unit != nil {
-					unit = *m.Unit
+		if strings.Contains(notes, "\n") {
+			fmt.Printf("Notes:\n%s\n", notes)
+		} else {
+			fmt.Printf("Notes: %s\n", notes)
+		}
+	}
+
+	var weather []*models.WorkoutMetric
+	var metrics []models.WorkoutMetric
+	for i, m := range w.Metrics {
+		if environment.IsWeatherMetric(m.MetricName) {
+			weather = append(weather, &w.Metrics[i])
+		} else {
+			metrics = append(metrics, m)
+		}
+	}
+	if line := formatWeather(weather); line != "" {
+		fmt.Printf("Weather: %s\n", line)
+	}
+
+	if len(metrics) > 0 {
+		fmt.Println("\nMetrics:")
+		for _, m := range metrics {
+			unit := ""
+			if m.Unit != nil {
+				unit = *m.Unit
+			}
+			fmt.Printf("  %s: %.2f %s\n", m.MetricName, m.Value, unit)
+		}
+	}
+	if err := printZoneBreakdown(w); err != nil {
+		return err
+	}
+
+	if len(w.Sets) > 0 {
+		fmt.Println("\nSets:")
+		for _, ws := range w.Sets {
+			load := ""
+			if ws.Weight != nil {
+				load = fmt.Sprintf(" @ %.2f", *ws.Weight)
+				if ws.WeightUnit != nil {
+					load += " " + *ws.WeightUnit
 				}
-				fmt.Printf("  %s: %.2f %s\n", m.MetricName, m.Value, unit)
 			}
+			fmt.Printf("  %s #%d: %d reps%s\n", ws.Exercise, ws.SetNumber, ws.Reps, load)
 		}
+	}
 
-		return nil
-	},
+	if len(w.Comments) > 0 {
+		fmt.Println("\nComments:")
+		faint := color.New(color.Faint)
+		for _, c := range w.Comments {
+			body := c.Body
+			if !workoutShowRaw {
+				body = renderMarkdown(body)
+			}
+			fmt.Printf("  %s %s\n", color.CyanString(c.Author), faint.Sprint(c.CreatedAt.Local().Format("2006-01-02 15:04")))
+			for _, line := range strings.Split(body, "\n") {
+				fmt.Printf("    %s\n", line)
+			}
+		}
+	}
+
+	if workoutShowRoute {
+		return printRoute(w)
+	}
+	return nil
 }
 
 var workoutMetricCmd = &cobra.Command{
@@ -161,6 +424,7 @@ Examples:
   health workout metric abc123 sets 4`,
 	Args: cobra.MinimumNArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		workoutID := args[0]
 		metricName := args[1]
 		value, err := strconv.ParseFloat(args[2], 64)
@@ -174,13 +438,13 @@ Examples:
 		}
 
 		// Verify workout exists
-		w, err := repo.GetWorkout(workoutID)
+		w, err := repo.GetWorkout(ctx, workoutID)
 		if err != nil {
 			return fmt.Errorf("workout not found: %s", workoutID)
 		}
 
 		wm := models.NewWorkoutMetric(w.ID, metricName, value, unit)
-		if err := repo.AddWorkoutMetric(wm); err != nil {
+		if err := repo.AddWorkoutMetric(ctx, wm); err != nil {
 			return fmt.Errorf("failed to add workout metric: %w", err)
 		}
 
@@ -191,11 +455,223 @@ Examples:
 	},
 }
 
+var workoutCommentCmd = &cobra.Command{
+	Use:   "comment <workout-id> [text]",
+	Short: "Attach a comment to a workout",
+	Long: `Attach feedback to a workout, such as notes from a coach. Pass the text
+as an argument or read it from a file with --file (e.g. feedback the coach
+emailed). Comments are shown by 'health workout show' and travel with
+export, import, and migrate.
+
+Examples:
+  health workout comment abc123 "Great negative split" --author "Coach Sam"
+  health workout comment abc123 --file feedback.md --author "Coach Sam"`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		var body string
+		switch {
+		case workoutCommentFile != "" && len(args) == 2:
+			return fmt.Errorf("pass the comment as text or --file, not both")
+		case workoutCommentFile != "":
+			data, err := os.ReadFile(workoutCommentFile)
+			if err != nil {
+				return fmt.Errorf("failed to read comment: %w", err)
+			}
+			body = string(data)
+		case len(args) == 2:
+			body = args[1]
+		default:
+			return fmt.Errorf("comment text or --file is required")
+		}
+		body = strings.TrimSpace(body)
+		if body == "" {
+			return fmt.Errorf("comment is empty")
+		}
+
+		w, err := repo.GetWorkout(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("workout not found: %s", args[0])
+		}
+
+		c := models.NewWorkoutComment(w.ID, workoutCommentAuthor, body)
+		if err := repo.AddWorkoutComment(ctx, c); err != nil {
+			return fmt.Errorf("failed to add comment: %w", err)
+		}
+
+		color.Green("✓ Added comment from %s to workout %s", c.Author, w.ID.String()[:8])
+		return nil
+	},
+}
+
+var workoutSetCmd = &cobra.Command{
+	Use:   "set <workout-id> <exercise> <sets>x<reps> [@weight]",
+	Short: "Log strength-training sets for a workout",
+	Long: `Log one or more sets of an exercise on an existing workout.
+
+The set spec is SETSxREPS (or just REPS for a single set), optionally
+followed by the load as @WEIGHT with a unit suffix.
+
+Examples:
+  health workout set abc123 bench 3x5 @100kg
+  health workout set abc123 squat 5x5@140kg
+  health workout set abc123 pullup 8`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		workoutID := args[0]
+		exercise := args[1]
+
+		count, reps, weight, unit, err := parseSetSpec(strings.Join(args[2:], ""))
+		if err != nil {
+			return err
+		}
+
+		// Verify workout exists
+		w, err := repo.GetWorkout(ctx, workoutID)
+		if err != nil {
+			return fmt.Errorf("workout not found: %s", workoutID)
+		}
+
+		// Continue numbering after any sets already logged for this exercise
+		existing, err := repo.ListWorkoutSets(ctx, w.ID)
+		if err != nil {
+			return fmt.Errorf("failed to list workout sets: %w", err)
+		}
+		next := 1
+		for _, ws := range existing {
+			if strings.EqualFold(ws.Exercise, exercise) && ws.SetNumber >= next {
+				next = ws.SetNumber + 1
+			}
+		}
+
+		for i := 0; i < count; i++ {
+			ws := models.NewWorkoutSet(w.ID, exercise, next+i, reps)
+			if weight != nil {
+				ws.WithWeight(*weight, unit)
+			}
+			if err := repo.AddWorkoutSet(ctx, ws); err != nil {
+				return fmt.Errorf("failed to add workout set: %w", err)
+			}
+		}
+
+		color.Green("✓ Added %d set(s) of %s to workout", count, exercise)
+		if weight != nil {
+			fmt.Printf("  %dx%d @ %.2f %s\n", count, reps, *weight, unit)
+		} else {
+			fmt.Printf("  %dx%d\n", count, reps)
+		}
+
+		return nil
+	},
+}
+
+var workoutWeatherCmd = &cobra.Command{
+	Use:   "weather <workout-id>",
+	Short: "Attach historical weather to a workout",
+	Long: `Look up the temperature, humidity, and wind at the configured location
+for the time of the workout and store them as workout metrics.
+
+Use this for workouts that were imported or added without weather.
+Running it again replaces the previous weather metrics.
+
+Examples:
+  health workout weather abc123`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		enricher, err := loadWorkoutEnricher()
+		if err != nil {
+			return err
+		}
+		if enricher == nil {
+			return fmt.Errorf("no location configured: set environment.latitude/longitude in config")
+		}
+
+		w, err := repo.GetWorkout(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("workout not found: %s", args[0])
+		}
+
+		added, err := enricher.Enrich(ctx, repo, w)
+		if err != nil {
+			return fmt.Errorf("failed to fetch weather: %w", err)
+		}
+		if len(added) == 0 {
+			fmt.Println("No weather available for this workout.")
+			return nil
+		}
+
+		color.Green("✓ Added weather to %s workout", w.WorkoutType)
+		fmt.Printf("  %s\n", formatWeather(added))
+
+		return nil
+	},
+}
+
+// formatWeather renders weather workout metrics as a single line,
+// e.g. "18.2 °C, 64 % humidity, 12.5 km/h wind".
+func formatWeather(metrics []*models.WorkoutMetric) string {
+	var temp, humidity, wind string
+	for _, wm := range metrics {
+		switch wm.MetricName {
+		case environment.WeatherTempMetric:
+			temp = fmt.Sprintf("%.1f °C", wm.Value)
+		case environment.WeatherHumidityMetric:
+			humidity = fmt.Sprintf("%.0f%% humidity", wm.Value)
+		case environment.WeatherWindMetric:
+			wind = fmt.Sprintf("%.1f km/h wind", wm.Value)
+		}
+	}
+
+	var parts []string
+	for _, p := range []string{temp, humidity, wind} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseSetSpec parses a set spec like "3x5", "5", "3x5@100kg" or "3x5@100".
+// It returns the number of sets, reps per set, and the optional load and unit.
+func parseSetSpec(spec string) (int, int, *float64, string, error) {
+	volume, load, hasLoad := strings.Cut(strings.ToLower(spec), "@")
+
+	count := 1
+	if setsStr, repsStr, ok := strings.Cut(volume, "x"); ok {
+		n, err := strconv.Atoi(setsStr)
+		if err != nil || n <= 0 {
+			return 0, 0, nil, "", fmt.Errorf("invalid set count in %q", spec)
+		}
+		count, volume = n, repsStr
+	}
+	reps, err := strconv.Atoi(volume)
+	if err != nil || reps <= 0 {
+		return 0, 0, nil, "", fmt.Errorf("invalid reps in %q (use SETSxREPS, e.g. 3x5)", spec)
+	}
+
+	if !hasLoad {
+		return count, reps, nil, "", nil
+	}
+
+	// Split the load into its numeric part and unit suffix
+	i := 0
+	for i < len(load) && (load[i] == '.' || (load[i] >= '0' && load[i] <= '9')) {
+		i++
+	}
+	weight, err := strconv.ParseFloat(load[:i], 64)
+	if err != nil {
+		return 0, 0, nil, "", fmt.Errorf("invalid weight in %q", spec)
+	}
+	return count, reps, &weight, load[i:], nil
+}
+
 var workoutDeleteCmd = &cobra.Command{
 	Use:     "delete <id>",
 	Aliases: []string{"del", "rm"},
 	Short:   "Delete a workout",
-	Long: `Delete a workout and all its metrics.
+	Long: `Delete a workout and all its metrics, along with an attached route.
 
 CAUTION: This permanently deletes the workout and all associated metrics.`,
 	Args: cobra.ExactArgs(1),
@@ -203,33 +679,83 @@ CAUTION: This permanently deletes the workout and all associated metrics.`,
 		idOrPrefix := args[0]
 
 		// Get workout to show what we're deleting
-		w, err := repo.GetWorkout(idOrPrefix)
+		w, err := repo.GetWorkout(cmd.Context(), idOrPrefix)
 		if err != nil {
 			return fmt.Errorf("workout not found: %s", idOrPrefix)
 		}
+		return deleteWorkout(cmd.Context(), w)
+	},
+}
 
-		if err := repo.DeleteWorkout(idOrPrefix); err != nil {
-			return fmt.Errorf("failed to delete workout: %w", err)
+// workoutsWithMetrics queries workouts and fills in each one's metrics.
+func workoutsWithMetrics(ctx context.Context, filter storage.WorkoutFilter) ([]*models.Workout, error) {
+	workouts, err := repo.QueryWorkouts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", err)
+	}
+	for _, w := range workouts {
+		metrics, err := repo.ListWorkoutMetrics(ctx, w.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list workout metrics: %w", err)
+		}
+		for _, m := range metrics {
+			w.Metrics = append(w.Metrics, *m)
 		}
+	}
+	return workouts, nil
+}
 
-		color.Yellow("✗ Deleted %s workout", w.WorkoutType)
-		fmt.Printf("  %s\n", color.New(color.Faint).Sprint(w.ID.String()[:8]))
+// deleteWorkout deletes a workout, its metrics, and its attached route.
+func deleteWorkout(ctx context.Context, w *models.Workout) error {
+	if err := repo.DeleteWorkout(ctx, w.ID.String()); err != nil {
+		return fmt.Errorf("failed to delete workout: %w", err)
+	}
+	if _, ok := w.Metadata[route.MetadataKey]; ok {
+		if err := removeAttachments(w); err != nil {
+			return err
+		}
+	}
 
-		return nil
-	},
+	color.Yellow("✗ Deleted %s workout", w.WorkoutType)
+	fmt.Printf("  %s\n", color.New(color.Faint).Sprint(w.ID.String()[:8]))
+	return nil
 }
 
 func init() {
 	workoutAddCmd.Flags().IntVarP(&workoutDuration, "duration", "d", 0, "duration in minutes")
 	workoutAddCmd.Flags().StringVarP(&workoutNotes, "notes", "n", "", "workout notes")
+	workoutAddCmd.Flags().IntVar(&workoutRPE, "rpe", 0, "rating of perceived exertion, 1-10")
+	workoutAddCmd.Flags().IntVar(&workoutZone, "zone", 0, "intensity zone, 1-5")
+	workoutAddCmd.Flags().StringVar(&workoutLocation, "location", "", "location name or \"lat,lon\"")
+	workoutAddCmd.Flags().StringArrayVarP(&workoutMetrics, "metric", "m", nil, "attach a metric as name=value[unit] (repeatable)")
+	workoutAddCmd.Flags().StringArrayVar(&workoutMeta, "meta", nil, "attach metadata as key=value, e.g. gpx=rides/0412.gpx (repeatable)")
+	workoutAddCmd.Flags().BoolVar(&workoutWeather, "weather", false, "attach weather at the configured location (default: outdoor types only)")
 
 	workoutListCmd.Flags().StringVarP(&workoutType, "type", "t", "", "filter by workout type")
 	workoutListCmd.Flags().IntVarP(&workoutLimit, "limit", "n", 20, "max number of results")
+	workoutListCmd.Flags().StringVar(&workoutListLocation, "location", "", "only workouts tagged with this location")
+	workoutListCmd.Flags().StringVar(&workoutListSource, "source", "", "only workouts from this source (manual, mcp, ...)")
+	workoutListCmd.Flags().BoolVarP(&workoutListWide, "wide", "w", false, "show each workout's source")
+	cobra.CheckErr(workoutListCmd.RegisterFlagCompletionFunc("type", completeFlag(workoutTypeCompletions)))
+	cobra.CheckErr(workoutListCmd.RegisterFlagCompletionFunc("source", completeFlag(sourceCompletions)))
+
+	workoutAddCmd.ValidArgsFunction = completeFirstArg(workoutTypeCompletions)
+	for _, c := range []*cobra.Command{workoutShowCmd, workoutMetricCmd, workoutCommentCmd, workoutSetCmd, workoutWeatherCmd, workoutDeleteCmd} {
+		c.ValidArgsFunction = completeFirstArg(workoutIDCompletions)
+	}
+
+	workoutShowCmd.Flags().BoolVar(&workoutShowRaw, "raw", false, "print notes without markdown rendering")
+
+	workoutCommentCmd.Flags().StringVar(&workoutCommentAuthor, "author", "me", "who the comment is from")
+	workoutCommentCmd.Flags().StringVar(&workoutCommentFile, "file", "", "read the comment from a file")
 
 	workoutCmd.AddCommand(workoutAddCmd)
 	workoutCmd.AddCommand(workoutListCmd)
 	workoutCmd.AddCommand(workoutShowCmd)
 	workoutCmd.AddCommand(workoutMetricCmd)
+	workoutCmd.AddCommand(workoutSetCmd)
+	workoutCmd.AddCommand(workoutCommentCmd)
+	workoutCmd.AddCommand(workoutWeatherCmd)
 	workoutCmd.AddCommand(workoutDeleteCmd)
 	rootCmd.AddCommand(workoutCmd)
 }
@@ -0,0 +1,140 @@
+// ABOUTME: Heart rate zone breakdowns for workouts: per workout and per week.
+// ABOUTME: Zones come from the profile's max-hr or hr-zones; minutes are estimates.
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/harperreed/health/internal/config"
+	"github.com/harperreed/health/internal/hrzones"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/training"
+)
+
+var workoutZonesWeeks int
+
+var workoutZonesCmd = &cobra.Command{
+	Use:   "zones",
+	Short: "Weekly time in heart rate zones",
+	Long: `Estimate minutes spent in each heart rate zone, week by week.
+
+Each workout's duration is spread across the zones from its avg_hr and
+max_hr metrics, assuming heart rate hovers around the average and peaks
+at the max. Workouts without a duration or avg_hr aren't counted. Set
+your zones first with 'health profile set max-hr' or 'hr-zones'.
+
+EXAMPLES:
+
+  health workout metric abc12345 avg_hr 148 bpm
+  health workout metric abc12345 max_hr 171 bpm
+  health workout zones
+  health workout zones --weeks 8`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if workoutZonesWeeks < 1 {
+			return fmt.Errorf("--weeks must be at least 1")
+		}
+		z, err := heartRateZones()
+		if err != nil {
+			return err
+		}
+		if z == nil {
+			return fmt.Errorf("no heart rate zones set (use 'health profile set max-hr 188')")
+		}
+
+		first := training.WeekStart(time.Now()).AddDate(0, 0, -7*(workoutZonesWeeks-1))
+		workouts, err := workoutsWithMetrics(ctx, storage.WorkoutFilter{Since: &first})
+		if err != nil {
+			return err
+		}
+
+		weeks := make([][hrzones.Count]float64, workoutZonesWeeks)
+		counted := make([]int, workoutZonesWeeks)
+		for _, w := range workouts {
+			minutes, ok := hrzones.ForWorkout(*z, w)
+			if !ok {
+				continue
+			}
+			i := int(math.Round(training.WeekStart(w.StartedAt).Sub(first).Hours()/24)) / 7
+			if i < 0 || i >= workoutZonesWeeks {
+				continue
+			}
+			for zone, m := range minutes {
+				weeks[i][zone] += m
+			}
+			counted[i]++
+		}
+
+		faint := color.New(color.Faint)
+		fmt.Printf("%s", faint.Sprintf("%-12s", "Week of"))
+		for zone := 1; zone <= hrzones.Count; zone++ {
+			fmt.Printf("%s", faint.Sprintf("%6s", fmt.Sprintf("Z%d", zone)))
+		}
+		fmt.Println(faint.Sprint("  (min)"))
+		for i, week := range weeks {
+			fmt.Printf("%-12s", first.AddDate(0, 0, 7*i).Format("2006-01-02"))
+			for _, m := range week {
+				fmt.Printf("%6.0f", m)
+			}
+			if counted[i] == 0 {
+				fmt.Print(faint.Sprint("  no heart rate data"))
+			} else {
+				fmt.Print(faint.Sprintf("  %d %s", counted[i], plural(counted[i], "workout", "workouts")))
+			}
+			fmt.Println()
+		}
+		fmt.Println(faint.Sprintf("\nZones from %s bpm; minutes are estimates.", z))
+		return nil
+	},
+}
+
+// heartRateZones returns the profile's heart rate zones, or nil when none
+// are set.
+func heartRateZones() (*hrzones.Zones, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	z, ok := cfg.HeartRateZones()
+	if !ok {
+		return nil, nil
+	}
+	return &z, nil
+}
+
+// printZoneBreakdown prints the workout's estimated minutes per heart rate
+// zone, when zones are set and the workout has the metrics to estimate them.
+func printZoneBreakdown(w *models.Workout) error {
+	z, err := heartRateZones()
+	if err != nil || z == nil {
+		return err
+	}
+	minutes, ok := hrzones.ForWorkout(*z, w)
+	if !ok {
+		return nil
+	}
+
+	fmt.Println("\nHeart rate zones (estimated):")
+	for i, m := range minutes {
+		bounds := fmt.Sprintf("%g+ bpm", z[i])
+		if i < hrzones.Count-1 {
+			bounds = fmt.Sprintf("%g-%g bpm", z[i], z[i+1]-1)
+		}
+		bar := strings.Repeat("█", int(math.Round(m/float64(*w.DurationMinutes)*20)))
+		fmt.Printf("  Z%d %-13s %4.0f min %s\n", i+1, bounds, m, bar)
+	}
+	return nil
+}
+
+func init() {
+	workoutZonesCmd.Flags().IntVarP(&workoutZonesWeeks, "weeks", "w", 4, "number of weeks to show, ending with this one")
+	workoutCmd.AddCommand(workoutZonesCmd)
+}
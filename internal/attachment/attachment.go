@@ -0,0 +1,122 @@
+// ABOUTME: Files attached to metrics and workouts, kept under the data directory.
+// ABOUTME: Entries reference their attachments by relative path in their metadata.
+package attachment
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// Kinds of entries that can carry attachments; each has its own
+// directory.
+const (
+	Metrics  = "metrics"
+	Workouts = "workouts"
+)
+
+// DirName is the directory under the data directory holding attachments.
+const DirName = "attachments"
+
+// KeyPrefix starts the metadata key of each attached file; the rest of
+// the key is the file name and the value its path relative to the data
+// directory, e.g. "attachment:lunch.jpg": "attachments/metrics/<id>/lunch.jpg".
+const KeyPrefix = "attachment:"
+
+// Dir returns the directory holding an entry's attachments.
+func Dir(dataDir, kind, id string) string {
+	return filepath.Join(dataDir, DirName, kind, id)
+}
+
+// Save copies the file at src into the entry's attachment directory,
+// replacing a file of the same name. It returns the copy's path relative
+// to dataDir, with forward slashes, for storing in metadata.
+func Save(dataDir, kind, id, src string) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("read attachment: %w", err)
+	}
+	defer in.Close()
+
+	dir := Dir(dataDir, kind, id)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("create attachments directory: %w", err)
+	}
+	dest := filepath.Join(dir, filepath.Base(src))
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("save attachment: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return "", fmt.Errorf("save attachment: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("save attachment: %w", err)
+	}
+
+	rel, err := filepath.Rel(dataDir, dest)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// Remove deletes all of an entry's attachments.
+func Remove(dataDir, kind, id string) error {
+	if err := os.RemoveAll(Dir(dataDir, kind, id)); err != nil {
+		return fmt.Errorf("remove attachments: %w", err)
+	}
+	return nil
+}
+
+// RemoveMetric deletes the files attached to a deleted metric, if its
+// metadata lists any. An empty dataDir, as for an in-memory store, holds
+// no attachments.
+func RemoveMetric(dataDir string, m *models.Metric) error {
+	if dataDir == "" || len(List(m.Metadata)) == 0 {
+		return nil
+	}
+	return Remove(dataDir, Metrics, m.ID.String())
+}
+
+// RemoveWorkout deletes the files attached to a deleted workout, such as
+// its route.
+func RemoveWorkout(dataDir string, w *models.Workout) error {
+	if dataDir == "" {
+		return nil
+	}
+	return Remove(dataDir, Workouts, w.ID.String())
+}
+
+// Key returns the metadata key for an attached file name.
+func Key(name string) string {
+	return KeyPrefix + name
+}
+
+// List returns the relative paths of the attachments recorded in
+// metadata, sorted by file name.
+func List(metadata map[string]string) []string {
+	var keys []string
+	for k := range metadata {
+		if strings.HasPrefix(k, KeyPrefix) {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	paths := make([]string, len(keys))
+	for i, k := range keys {
+		paths[i] = metadata[k]
+	}
+	return paths
+}
+
+// Path turns a relative path from metadata into a path on disk.
+func Path(dataDir, rel string) string {
+	return filepath.Join(dataDir, filepath.FromSlash(rel))
+}
@@ -0,0 +1,85 @@
+// ABOUTME: Tests for saving, listing, and removing attachments.
+// ABOUTME: Uses temporary data directories.
+package attachment
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func TestSaveListRemove(t *testing.T) {
+	dataDir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "lunch.jpg")
+	os.WriteFile(src, []byte("first"), 0600)
+
+	rel, err := Save(dataDir, Metrics, "abc", src)
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if rel != "attachments/metrics/abc/lunch.jpg" {
+		t.Errorf("rel = %q", rel)
+	}
+
+	// Saving a file of the same name replaces it
+	os.WriteFile(src, []byte("second"), 0600)
+	if _, err := Save(dataDir, Metrics, "abc", src); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if data, _ := os.ReadFile(Path(dataDir, rel)); string(data) != "second" {
+		t.Errorf("saved contents = %q, want the newer file", data)
+	}
+
+	md := map[string]string{Key("z.pdf"): "attachments/metrics/abc/z.pdf", Key("lunch.jpg"): rel, "device": "Body+"}
+	if got := List(md); !slices.Equal(got, []string{rel, "attachments/metrics/abc/z.pdf"}) {
+		t.Errorf("List = %v", got)
+	}
+
+	if _, err := Save(dataDir, Metrics, "abc", filepath.Join(t.TempDir(), "missing.jpg")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+
+	if err := Remove(dataDir, Metrics, "abc"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := os.Stat(Dir(dataDir, Metrics, "abc")); !os.IsNotExist(err) {
+		t.Errorf("expected the directory removed, got %v", err)
+	}
+}
+
+func TestRemoveMetricAndWorkout(t *testing.T) {
+	dataDir := t.TempDir()
+	src := filepath.Join(t.TempDir(), "lunch.jpg")
+	os.WriteFile(src, []byte("lunch"), 0600)
+
+	m := models.NewMetric(models.MetricCalories, 650)
+	rel, _ := Save(dataDir, Metrics, m.ID.String(), src)
+	if err := RemoveMetric(dataDir, m); err != nil {
+		t.Fatalf("RemoveMetric failed: %v", err)
+	}
+	if _, err := os.Stat(Path(dataDir, rel)); err != nil {
+		t.Errorf("expected attachments kept for a metric whose metadata lists none, got %v", err)
+	}
+	m.WithMetadata(Key("lunch.jpg"), rel)
+	if err := RemoveMetric(dataDir, m); err != nil {
+		t.Fatalf("RemoveMetric failed: %v", err)
+	}
+	if _, err := os.Stat(Dir(dataDir, Metrics, m.ID.String())); !os.IsNotExist(err) {
+		t.Errorf("expected the metric's attachments removed, got %v", err)
+	}
+
+	w := models.NewWorkout("run")
+	Save(dataDir, Workouts, w.ID.String(), src)
+	if err := RemoveWorkout("", w); err != nil {
+		t.Fatalf("RemoveWorkout without a data directory failed: %v", err)
+	}
+	if err := RemoveWorkout(dataDir, w); err != nil {
+		t.Fatalf("RemoveWorkout failed: %v", err)
+	}
+	if _, err := os.Stat(Dir(dataDir, Workouts, w.ID.String())); !os.IsNotExist(err) {
+		t.Errorf("expected the workout's attachments removed, got %v", err)
+	}
+}
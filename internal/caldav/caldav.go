@@ -0,0 +1,209 @@
+// ABOUTME: Minimal CalDAV client that PUTs single-event calendars into a calendar collection.
+// ABOUTME: Tracks what was pushed so reruns skip unchanged events and never duplicate or clobber them.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StateFileName is the push record inside the data directory.
+const StateFileName = "caldav.json"
+
+var (
+	// ErrExists means an event with this name is already on the server but
+	// was not pushed from here, e.g. from another machine.
+	ErrExists = errors.New("already on server")
+
+	// ErrChanged means the event was edited on the server since it was pushed.
+	ErrChanged = errors.New("changed on server")
+)
+
+// Client talks to one calendar collection.
+type Client struct {
+	URL        string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client for the calendar collection at url.
+func NewClient(url, username, password string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/") + "/",
+		Username:   username,
+		Password:   password,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Put stores body as the resource name in the collection and returns the
+// server's new ETag (empty if it sent none). With an empty etag the event
+// is only created if it doesn't exist yet; otherwise it is only replaced if
+// the server copy still has that ETag. force skips both checks.
+func (c *Client) Put(ctx context.Context, name string, body []byte, etag string, force bool) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.URL+name, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	switch {
+	case force:
+	case etag == "":
+		req.Header.Set("If-None-Match", "*")
+	default:
+		req.Header.Set("If-Match", etag)
+	}
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		slog.DebugContext(ctx, "caldav put failed", "url", req.URL.Redacted(), "duration", time.Since(start), "err", err)
+		return "", err
+	}
+	defer resp.Body.Close()
+	slog.DebugContext(ctx, "caldav put", "url", req.URL.Redacted(), "status", resp.StatusCode,
+		"if_match", req.Header.Get("If-Match"), "if_none_match", req.Header.Get("If-None-Match"), "duration", time.Since(start))
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed && etag == "":
+		return "", ErrExists
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return "", ErrChanged
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return "", fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// Pushed records one event as last sent.
+type Pushed struct {
+	Hash string `json:"hash"`
+	ETag string `json:"etag,omitempty"`
+}
+
+// State maps calendar URL to resource name to what was pushed there.
+type State map[string]map[string]Pushed
+
+// StatePath returns the state file path for a data directory.
+func StatePath(dataDir string) string {
+	return filepath.Join(dataDir, StateFileName)
+}
+
+// LoadState reads the state file. A missing file is an empty state.
+func LoadState(path string) (State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read caldav state: %w", err)
+	}
+	state := State{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse caldav state: %w", err)
+	}
+	return state, nil
+}
+
+// Save writes the state file.
+func (s State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write caldav state: %w", err)
+	}
+	return nil
+}
+
+// Calendar returns the pushed events for a calendar URL, creating the entry.
+func (s State) Calendar(url string) map[string]Pushed {
+	url = strings.TrimSuffix(url, "/") + "/"
+	if s[url] == nil {
+		s[url] = make(map[string]Pushed)
+	}
+	return s[url]
+}
+
+// Hash fingerprints an event body so unchanged events can be skipped.
+func Hash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Event is one calendar resource to push.
+type Event struct {
+	Name string // resource name in the collection, e.g. "<id>.ics"
+	Body []byte
+}
+
+// Skip is an event left alone to avoid a duplicate or overwriting an edit.
+type Skip struct {
+	Name string
+	Err  error
+}
+
+// Result counts what a push did.
+type Result struct {
+	Created   int
+	Updated   int
+	Unchanged int
+	Skipped   []Skip
+}
+
+// Push sends events that are new or changed since they were last pushed,
+// recording each success in pushed. Events already on the server from
+// elsewhere, or edited there since, are reported in Result.Skipped rather
+// than overwritten unless force is set. Any other failure stops the push;
+// events sent before it stay recorded.
+func (c *Client) Push(ctx context.Context, events []Event, pushed map[string]Pushed, force bool) (Result, error) {
+	var res Result
+	for _, ev := range events {
+		hash := Hash(ev.Body)
+		prev, seen := pushed[ev.Name]
+		if seen && prev.Hash == hash && !force {
+			res.Unchanged++
+			continue
+		}
+
+		// Without an ETag from last time there is no way to spot server
+		// edits, but the event is ours, so replace it
+		unconditional := force || (seen && prev.ETag == "")
+		etag, err := c.Put(ctx, ev.Name, ev.Body, prev.ETag, unconditional)
+		if errors.Is(err, ErrExists) || errors.Is(err, ErrChanged) {
+			res.Skipped = append(res.Skipped, Skip{Name: ev.Name, Err: err})
+			continue
+		}
+		if err != nil {
+			return res, fmt.Errorf("put %s: %w", ev.Name, err)
+		}
+		pushed[ev.Name] = Pushed{Hash: hash, ETag: etag}
+		if seen {
+			res.Updated++
+		} else {
+			res.Created++
+		}
+	}
+	slog.DebugContext(ctx, "caldav push done", "url", c.URL, "created", res.Created, "updated", res.Updated,
+		"unchanged", res.Unchanged, "skipped", len(res.Skipped))
+	return res, nil
+}
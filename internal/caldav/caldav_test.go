@@ -0,0 +1,155 @@
+// ABOUTME: Tests for the CalDAV client against an in-memory fake server.
+// ABOUTME: Covers create, skip-unchanged, update by ETag, and both duplicate guards.
+package caldav
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCalendar is a calendar collection honouring If-Match/If-None-Match.
+type fakeCalendar struct {
+	mu     sync.Mutex
+	events map[string]string
+	etags  map[string]int
+	puts   int
+}
+
+func newFakeCalendar(t *testing.T) (*fakeCalendar, *httptest.Server) {
+	t.Helper()
+	fc := &fakeCalendar{events: map[string]string{}, etags: map[string]int{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fc.mu.Lock()
+		defer fc.mu.Unlock()
+
+		if user, pass, _ := r.BasicAuth(); user != "me" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodPut || r.Header.Get("Content-Type") != "text/calendar; charset=utf-8" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/cal/")
+		_, exists := fc.events[name]
+		current := fmt.Sprintf(`"%d"`, fc.etags[name])
+		if r.Header.Get("If-None-Match") == "*" && exists {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if m := r.Header.Get("If-Match"); m != "" && (!exists || m != current) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		fc.puts++
+		fc.events[name] = string(body)
+		fc.etags[name]++
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, fc.etags[name]))
+		if exists {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return fc, srv
+}
+
+// edit simulates a change made in a calendar app.
+func (fc *fakeCalendar) edit(name string) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.events[name] += "edited"
+	fc.etags[name]++
+}
+
+func TestPush(t *testing.T) {
+	fc, srv := newFakeCalendar(t)
+	client := NewClient(srv.URL+"/cal", "me", "secret")
+	ctx := context.Background()
+	pushed := map[string]Pushed{}
+
+	events := []Event{{Name: "a.ics", Body: []byte("A1")}, {Name: "b.ics", Body: []byte("B1")}}
+	res, err := client.Push(ctx, events, pushed, false)
+	if err != nil || res.Created != 2 {
+		t.Fatalf("first push = %+v, %v; want 2 created", res, err)
+	}
+
+	// Nothing changed: nothing sent
+	res, _ = client.Push(ctx, events, pushed, false)
+	if res.Unchanged != 2 || fc.puts != 2 {
+		t.Errorf("second push = %+v after %d PUTs; want 2 unchanged and no new PUTs", res, fc.puts)
+	}
+
+	// A local change is sent as an update
+	events[0].Body = []byte("A2")
+	res, _ = client.Push(ctx, events, pushed, false)
+	if res.Updated != 1 || fc.events["a.ics"] != "A2" {
+		t.Errorf("update push = %+v, server has %q", res, fc.events["a.ics"])
+	}
+
+	// An edit made on the server is not overwritten
+	fc.edit("a.ics")
+	events[0].Body = []byte("A3")
+	res, _ = client.Push(ctx, events, pushed, false)
+	if len(res.Skipped) != 1 || !errors.Is(res.Skipped[0].Err, ErrChanged) || fc.events["a.ics"] != "A2edited" {
+		t.Errorf("push over server edit = %+v, server has %q", res, fc.events["a.ics"])
+	}
+
+	// Another machine with no state doesn't duplicate or clobber existing events
+	res, _ = client.Push(ctx, events, map[string]Pushed{}, false)
+	if len(res.Skipped) != 2 || !errors.Is(res.Skipped[1].Err, ErrExists) {
+		t.Errorf("push without state = %+v; want both skipped as existing", res)
+	}
+
+	// Unless forced
+	res, err = client.Push(ctx, events, pushed, true)
+	if err != nil || res.Updated != 2 || fc.events["a.ics"] != "A3" {
+		t.Errorf("forced push = %+v, %v, server has %q", res, err, fc.events["a.ics"])
+	}
+}
+
+func TestPushStopsOnError(t *testing.T) {
+	_, srv := newFakeCalendar(t)
+	client := NewClient(srv.URL+"/cal/", "me", "wrong")
+
+	pushed := map[string]Pushed{}
+	_, err := client.Push(context.Background(), []Event{{Name: "a.ics", Body: []byte("A")}}, pushed, false)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected unauthorized error, got %v", err)
+	}
+	if len(pushed) != 0 {
+		t.Errorf("Failed event recorded as pushed: %v", pushed)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+
+	state, err := LoadState(path)
+	if err != nil || len(state) != 0 {
+		t.Fatalf("LoadState on missing file = %v, %v", state, err)
+	}
+	state.Calendar("https://dav.example.com/cal")["a.ics"] = Pushed{Hash: "h", ETag: `"1"`}
+	if err := state.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	again, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if got := again.Calendar("https://dav.example.com/cal/")["a.ics"]; got.ETag != `"1"` {
+		t.Errorf("Reloaded state = %+v", again)
+	}
+}
@@ -0,0 +1,220 @@
+// ABOUTME: Resumable chunked export: splits data into checksummed chunk files plus a manifest.
+// ABOUTME: An interrupted write continues where it stopped, re-verifying chunks already written.
+package chunked
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestName is the manifest file inside a chunk directory.
+const ManifestName = "manifest.json"
+
+// DefaultChunkSize keeps each file small enough to re-send cheaply.
+const DefaultChunkSize = 16 << 20
+
+// Chunk is one piece of the export.
+type Chunk struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+	Done   bool   `json:"done"`
+}
+
+// Manifest describes a chunked export and how far writing it has got.
+type Manifest struct {
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	ChunkSize int64     `json:"chunk_size"`
+	Chunks    []Chunk   `json:"chunks"`
+}
+
+// Result counts what Write did.
+type Result struct {
+	Written  int // chunks written this run
+	Verified int // chunks from an earlier run that checked out
+}
+
+// Hash returns the hex SHA-256 of data.
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Plan splits data into chunks of chunkSize bytes. Nothing is written.
+func Plan(data []byte, chunkSize int64, createdAt time.Time) *Manifest {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	m := &Manifest{
+		CreatedAt: createdAt.UTC(),
+		Size:      int64(len(data)),
+		SHA256:    Hash(data),
+		ChunkSize: chunkSize,
+	}
+	// Empty data still gets one (empty) chunk
+	for off := int64(0); ; off += chunkSize {
+		end := min(off+chunkSize, m.Size)
+		part := data[off:end]
+		m.Chunks = append(m.Chunks, Chunk{
+			Name:   fmt.Sprintf("chunk-%05d", len(m.Chunks)),
+			Size:   int64(len(part)),
+			SHA256: Hash(part),
+		})
+		if end == m.Size {
+			break
+		}
+	}
+	return m
+}
+
+// Complete reports whether every chunk has been written.
+func (m *Manifest) Complete() bool {
+	for _, c := range m.Chunks {
+		if !c.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadManifest reads the manifest in dir, or returns nil if there is none.
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// Write writes data to dir as described by m, saving the manifest after
+// every chunk. Chunks marked done by an earlier run are checked against
+// their checksum and rewritten only if missing or damaged, so calling
+// Write again after an interruption finishes the job. data must be the
+// same bytes m was planned from.
+func Write(dir string, data []byte, m *Manifest) (Result, error) {
+	var res Result
+	if Hash(data) != m.SHA256 {
+		return res, fmt.Errorf("data does not match manifest")
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return res, fmt.Errorf("create export dir: %w", err)
+	}
+	if err := saveManifest(dir, m); err != nil {
+		return res, err
+	}
+
+	for i := range m.Chunks {
+		c := &m.Chunks[i]
+		path := filepath.Join(dir, c.Name)
+		if c.Done {
+			if verifyFile(path, c) == nil {
+				res.Verified++
+				continue
+			}
+			c.Done = false
+		}
+
+		off := int64(i) * m.ChunkSize
+		if err := writeFile(path, data[off:off+c.Size]); err != nil {
+			return res, fmt.Errorf("write %s: %w", c.Name, err)
+		}
+		// Read it back: a destination that accepts writes and loses them
+		// (full disk, flaky network mount) shouldn't be marked done
+		if err := verifyFile(path, c); err != nil {
+			return res, err
+		}
+		c.Done = true
+		res.Written++
+		if err := saveManifest(dir, m); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// Read reassembles a complete chunked export from dir, verifying every
+// chunk and the whole.
+func Read(dir string) ([]byte, error) {
+	m, err := LoadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("no %s in %s", ManifestName, dir)
+	}
+	if !m.Complete() {
+		return nil, fmt.Errorf("export in %s is incomplete", dir)
+	}
+
+	data := make([]byte, 0, m.Size)
+	for _, c := range m.Chunks {
+		part, err := os.ReadFile(filepath.Join(dir, c.Name))
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", c.Name, err)
+		}
+		if int64(len(part)) != c.Size || Hash(part) != c.SHA256 {
+			return nil, fmt.Errorf("%s is corrupt", c.Name)
+		}
+		data = append(data, part...)
+	}
+	if Hash(data) != m.SHA256 {
+		return nil, fmt.Errorf("reassembled export does not match manifest checksum")
+	}
+	return data, nil
+}
+
+// verifyFile checks a chunk file's size and checksum.
+func verifyFile(path string, c *Chunk) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", c.Name, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return fmt.Errorf("verify %s: %w", c.Name, err)
+	}
+	if n != c.Size || hex.EncodeToString(h.Sum(nil)) != c.SHA256 {
+		return fmt.Errorf("verify %s: checksum mismatch", c.Name)
+	}
+	return nil
+}
+
+func saveManifest(dir string, m *Manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, ManifestName), data); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	return nil
+}
+
+// writeFile replaces path via a temp file and rename, so a chunk or the
+// manifest is never left half-written under its real name.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
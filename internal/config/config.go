@@ -6,22 +6,440 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/hrzones"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/reference"
 	"github.com/harperreed/health/internal/storage"
 )
 
 // Config stores health tool configuration.
 type Config struct {
-	// Backend selects the storage backend: "sqlite" (default) or "markdown".
+	// Backend selects the storage backend: "sqlite" (default), "markdown",
+	// "jsonl" (a single health.jsonl file), "postgres" (see
+	// internal/storage/postgres), or any other name registered with
+	// storage.RegisterBackend.
 	Backend string `json:"backend,omitempty"`
 
 	// DataDir is the root directory for data storage.
 	// SQLite puts health.db here. Markdown puts metrics/ and workouts/ folders here.
+	// JSONL puts health.jsonl here.
 	// Supports ~ expansion for home directory. Defaults to ~/.local/share/health.
 	DataDir string `json:"data_dir,omitempty"`
+
+	// Timezone is the IANA zone (e.g. "America/Chicago") that days start
+	// and end in. Timestamps are stored in UTC either way. Defaults to the
+	// system's local zone.
+	Timezone string `json:"timezone,omitempty"`
+
+	// Environment configures where 'health env fetch' pulls readings from.
+	Environment *EnvironmentConfig `json:"environment,omitempty"`
+
+	// Reminders are the daily nudges checked by 'health remind due'.
+	Reminders []ReminderConfig `json:"reminders,omitempty"`
+
+	// QuietHours holds reminders back during a daily window; they fire
+	// when it ends.
+	QuietHours *QuietHoursConfig `json:"quiet_hours,omitempty"`
+
+	// Notify configures the ntfy and email reminder channels.
+	Notify *NotifyConfig `json:"notify,omitempty"`
+
+	// Alerts are thresholds that warn when a metric crosses them.
+	Alerts []AlertConfig `json:"alerts,omitempty"`
+
+	// DuplicateWindows says how close together two entries of a type count
+	// as one logged twice, as durations by metric type, e.g.
+	// {"weight": "1h", "mood": "0"}; "0" turns the check off. "default"
+	// covers unlisted types, which otherwise use 10m. Cumulative types
+	// (water, calories, ...) are only checked when listed.
+	DuplicateWindows map[string]string `json:"duplicate_windows,omitempty"`
+
+	// Derived are computed metrics (e.g. BMI) evaluated from stored metrics.
+	Derived []DerivedConfig `json:"derived,omitempty"`
+
+	// Constants are fixed values formulas can use, such as height in meters.
+	Constants map[string]float64 `json:"constants,omitempty"`
+
+	// Goals are daily targets per metric type (e.g. {"sleep_hours": 7}),
+	// compared with recent averages by 'health insights summary'.
+	Goals map[string]float64 `json:"goals,omitempty"`
+
+	// MarkdownLayout places new files with the markdown backend: "date"
+	// (metrics/YYYY/MM/, the default), "type" (metrics/<type>/YYYY/),
+	// "flat" (one metrics/YYYY-MM-DD.md per day holding all its metrics),
+	// or a pattern such as "{type}/{date}-{id}.md". See
+	// storage.ParseMarkdownLayout.
+	MarkdownLayout string `json:"markdown_layout,omitempty"`
+
+	// DailyNotes appends new entries to Obsidian-style daily notes as
+	// well, with the markdown backend.
+	DailyNotes *DailyNotesConfig `json:"daily_notes,omitempty"`
+
+	// InsightsFile is the rules file for 'health insights'. Defaults to
+	// insights.yaml next to this file. Supports ~ expansion.
+	InsightsFile string `json:"insights_file,omitempty"`
+
+	// Profile holds emergency details for 'health export emergency-card'.
+	// It lives only in this file so it never travels with exports or sync.
+	Profile *ProfileConfig `json:"profile,omitempty"`
+
+	// HideReferenceRanges turns off the public reference ranges ("elevated",
+	// "athlete range") shown next to values.
+	HideReferenceRanges bool `json:"hide_reference_ranges,omitempty"`
+
+	// UsageStats turns on the local usage log read by 'health usage'.
+	// Nothing is ever sent over the network.
+	UsageStats bool `json:"usage_stats,omitempty"`
+
+	// Influx is the default target for 'health push influx'.
+	Influx *InfluxConfig `json:"influx,omitempty"`
+
+	// Fitbit holds the sync cursor for 'health import fitbit'.
+	Fitbit *FitbitConfig `json:"fitbit,omitempty"`
+
+	// Oura overrides the API endpoint for 'health import oura'.
+	Oura *OuraConfig `json:"oura,omitempty"`
+
+	// Withings holds the app and sync cursor for 'health import withings'.
+	Withings *WithingsConfig `json:"withings,omitempty"`
+
+	// MCP narrows the tools 'health mcp' offers an assistant.
+	MCP *MCPConfig `json:"mcp,omitempty"`
+}
+
+// MCPConfig picks which MCP tools are offered. Entries are tool names or
+// patterns such as "delete_*"; a tool left out is never registered, so an
+// assistant can't call it.
+type MCPConfig struct {
+	// EnabledTools, when set, are the only tools offered.
+	EnabledTools []string `json:"enabled_tools,omitempty"`
+	// DisabledTools are never offered, e.g. ["delete_metric", "delete_workout"].
+	DisabledTools []string `json:"disabled_tools,omitempty"`
+}
+
+// ProfileConfig is the personal medical information shown on the
+// emergency card.
+type ProfileConfig struct {
+	Name             string         `json:"name,omitempty"`
+	BirthDate        string         `json:"birth_date,omitempty"`
+	Sex              string         `json:"sex,omitempty"`
+	HeightCM         float64        `json:"height_cm,omitempty"`
+	BloodType        string         `json:"blood_type,omitempty"`
+	Allergies        []string       `json:"allergies,omitempty"`
+	Conditions       []string       `json:"conditions,omitempty"`
+	EmergencyContact *ContactConfig `json:"emergency_contact,omitempty"`
+
+	// MaxHR and HRZones set the heart rate zones for workouts. Explicit
+	// zone bounds (five ascending bpm values) win over percentages of
+	// max heart rate.
+	MaxHR   float64   `json:"max_hr,omitempty"`
+	HRZones []float64 `json:"hr_zones,omitempty"`
+}
+
+// DailyNotesConfig points at the folder of daily notes, e.g. a vault's
+// "Daily" folder holding YYYY-MM-DD.md files.
+type DailyNotesConfig struct {
+	// Dir holds the notes. Supports ~ expansion.
+	Dir string `json:"dir"`
+	// Heading is the section entries go under. Defaults to "## Health".
+	Heading string `json:"heading,omitempty"`
+}
+
+// ContactConfig is a person to call in an emergency.
+type ContactConfig struct {
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// EmergencyProfile converts the profile to a models.Profile, or nil when
+// none is configured.
+func (c *Config) EmergencyProfile() *models.Profile {
+	if c.Profile == nil {
+		return nil
+	}
+	p := &models.Profile{
+		Name:       c.Profile.Name,
+		BirthDate:  c.Profile.BirthDate,
+		Sex:        c.Profile.Sex,
+		HeightCM:   c.Profile.HeightCM,
+		BloodType:  c.Profile.BloodType,
+		Allergies:  c.Profile.Allergies,
+		Conditions: c.Profile.Conditions,
+	}
+	if ec := c.Profile.EmergencyContact; ec != nil {
+		p.EmergencyContact = &models.Contact{Name: ec.Name, Phone: ec.Phone, Relation: ec.Relation}
+	}
+	return p
+}
+
+// ReferencePerson describes the user for reference ranges, using the
+// profile's sex and birth date when set. It returns nil when reference
+// ranges are turned off.
+func (c *Config) ReferencePerson(now time.Time) *reference.Person {
+	if c.HideReferenceRanges {
+		return nil
+	}
+	p := &reference.Person{}
+	if profile := c.EmergencyProfile(); profile != nil {
+		p.Sex = profile.Sex
+		p.Age = profile.Age(now)
+	}
+	return p
+}
+
+// HeartRateZones returns the configured heart rate zones, and false when
+// the profile sets neither zones nor a max heart rate.
+func (c *Config) HeartRateZones() (hrzones.Zones, bool) {
+	var z hrzones.Zones
+	if c.Profile == nil {
+		return z, false
+	}
+	if len(c.Profile.HRZones) == hrzones.Count {
+		copy(z[:], c.Profile.HRZones)
+		return z, true
+	}
+	if c.Profile.MaxHR > 0 {
+		return hrzones.FromMaxHR(c.Profile.MaxHR), true
+	}
+	return z, false
+}
+
+// AlertConfig defines a threshold on a metric type, e.g.
+// {"metric": "bp_sys", "above": 140}.
+type AlertConfig struct {
+	Metric string   `json:"metric"`
+	Above  *float64 `json:"above,omitempty"`
+	Below  *float64 `json:"below,omitempty"`
+}
+
+// Alert converts the definition to a models.Alert.
+func (a AlertConfig) Alert() models.Alert {
+	return models.Alert{MetricType: models.MetricType(a.Metric), Above: a.Above, Below: a.Below}
+}
+
+// ThresholdAlerts returns every configured alert.
+func (c *Config) ThresholdAlerts() []models.Alert {
+	alerts := make([]models.Alert, 0, len(c.Alerts))
+	for _, a := range c.Alerts {
+		alerts = append(alerts, a.Alert())
+	}
+	return alerts
+}
+
+// Duplicates returns the duplicate-entry windows: the defaults, adjusted
+// by DuplicateWindows.
+func (c *Config) Duplicates() (models.DuplicateWindows, error) {
+	w := models.DefaultDuplicateWindows()
+	for name, value := range c.DuplicateWindows {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return w, fmt.Errorf("invalid duplicate window %q for %s (use a duration like 10m or 1h, or 0 for off)", value, name)
+		}
+		switch {
+		case name == "default":
+			w.Default = d
+		case models.IsValidMetricType(name):
+			w.ByType[models.MetricType(name)] = d
+		default:
+			return w, fmt.Errorf("duplicate window for unknown metric type %q", name)
+		}
+	}
+	return w, nil
+}
+
+// DerivedConfig defines a computed metric by formula, e.g.
+// {"name": "bmi", "formula": "weight / height^2", "unit": "kg/m²"}.
+type DerivedConfig struct {
+	Name    string `json:"name"`
+	Formula string `json:"formula"`
+	Unit    string `json:"unit,omitempty"`
+}
+
+// DerivedSet parses the configured formulas against the configured
+// constants, followed by the body composition metrics the profile enables.
+func (c *Config) DerivedSet() (*derived.Set, error) {
+	formulas, constants := c.bodyComposition(time.Now())
+	set := &derived.Set{Constants: constants}
+	for _, d := range append(slices.Clone(c.Derived), formulas...) {
+		f, err := derived.Parse(d.Name, d.Formula, d.Unit, constants)
+		if err != nil {
+			return nil, err
+		}
+		set.Formulas = append(set.Formulas, f)
+	}
+	return set, nil
+}
+
+// IsBuiltinDerived reports whether name is a body composition metric
+// computed from the profile rather than defined with 'health derive add'.
+func (c *Config) IsBuiltinDerived(name string) bool {
+	formulas, _ := c.bodyComposition(time.Now())
+	return slices.ContainsFunc(formulas, func(d DerivedConfig) bool { return d.Name == name })
+}
+
+// bodyComposition returns the metrics a profile height enables (BMI and
+// body fat mass, plus Mifflin-St Jeor BMR once birth date and sex are set)
+// and the constants with the profile's height in meters and age added.
+// Formulas and constants the user defined take precedence.
+func (c *Config) bodyComposition(now time.Time) ([]DerivedConfig, map[string]float64) {
+	p := c.EmergencyProfile()
+	if p == nil || p.HeightCM == 0 {
+		return nil, c.Constants
+	}
+	constants := maps.Clone(c.Constants)
+	if constants == nil {
+		constants = make(map[string]float64)
+	}
+	if _, ok := constants["height"]; !ok {
+		constants["height"] = p.HeightCM / 100
+	}
+
+	formulas := []DerivedConfig{
+		{Name: "bmi", Formula: "weight / height^2", Unit: "kg/m²"},
+		{Name: "body_fat_mass", Formula: "weight * body_fat / 100", Unit: "kg"},
+	}
+	if age := p.Age(now); age > 0 && p.Sex != "" {
+		if _, ok := constants["age"]; !ok {
+			constants["age"] = float64(age)
+		}
+		offset := "+ 5"
+		if p.Sex == "female" {
+			offset = "- 161"
+		}
+		formulas = append(formulas, DerivedConfig{Name: "bmr", Formula: "10 * weight + 625 * height - 5 * age " + offset, Unit: "kcal"})
+	}
+	return slices.DeleteFunc(formulas, func(d DerivedConfig) bool {
+		return slices.ContainsFunc(c.Derived, func(u DerivedConfig) bool { return strings.EqualFold(u.Name, d.Name) })
+	}), constants
+}
+
+// ReminderConfig defines a daily reminder. Whether it has already fired is
+// tracked in the data store, not here.
+type ReminderConfig struct {
+	Message  string   `json:"message"`
+	Daily    string   `json:"daily"`
+	Notify   bool     `json:"notify,omitempty"`
+	Channels []string `json:"channels,omitempty"`
+}
+
+// Reminder converts the definition to a models.Reminder.
+func (r ReminderConfig) Reminder() models.Reminder {
+	return models.Reminder{Message: r.Message, Daily: r.Daily, Notify: r.Notify, Channels: r.Channels}
+}
+
+// QuietHoursConfig is a daily window such as {"start": "22:00", "end": "07:00"}.
+type QuietHoursConfig struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Quiet returns the configured quiet hours, or nil when none are set.
+func (c *Config) Quiet() *models.QuietHours {
+	if c.QuietHours == nil {
+		return nil
+	}
+	return &models.QuietHours{Start: c.QuietHours.Start, End: c.QuietHours.End}
+}
+
+// NotifyConfig holds the endpoints for the ntfy and email channels. The
+// ntfy token and SMTP password come from the environment so they stay out
+// of this file.
+type NotifyConfig struct {
+	// NtfyURL is the full topic URL, e.g. https://ntfy.sh/my-health.
+	NtfyURL string       `json:"ntfy_url,omitempty"`
+	Email   *EmailConfig `json:"email,omitempty"`
+}
+
+// EmailConfig is the SMTP server and addresses for the email channel.
+type EmailConfig struct {
+	SMTPHost string   `json:"smtp_host"`
+	SMTPPort int      `json:"smtp_port,omitempty"` // defaults to 587
+	Username string   `json:"username,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// InfluxConfig is the InfluxDB server and bucket metrics are pushed to.
+// The token comes from the environment so it stays out of this file.
+type InfluxConfig struct {
+	URL    string `json:"url"`
+	Org    string `json:"org,omitempty"`
+	Bucket string `json:"bucket"`
+	// PushOnAdd sends each metric from 'health add' right after saving it.
+	PushOnAdd bool `json:"push_on_add,omitempty"`
+}
+
+// FitbitConfig records how far 'health import fitbit' has synced. The
+// access token comes from the environment so it stays out of this file.
+type FitbitConfig struct {
+	// LastSynced is the last day (YYYY-MM-DD) imported in full.
+	LastSynced string `json:"last_synced,omitempty"`
+	// APIURL defaults to the Fitbit Web API.
+	APIURL string `json:"api_url,omitempty"`
+}
+
+// OuraConfig configures 'health import oura'. The access token comes from
+// the environment so it stays out of this file.
+type OuraConfig struct {
+	// APIURL defaults to the Oura API.
+	APIURL string `json:"api_url,omitempty"`
+}
+
+// WithingsConfig identifies the Withings app used by 'health import
+// withings' and how far it has synced. The client secret comes from the
+// environment and the OAuth tokens are kept in secrets.json.
+type WithingsConfig struct {
+	ClientID    string `json:"client_id,omitempty"`
+	RedirectURI string `json:"redirect_uri,omitempty"`
+	// LastUpdate is the server time (RFC 3339) of the last sync; the next
+	// one asks only for weigh-ins added or changed since.
+	LastUpdate string `json:"last_update,omitempty"`
+	// APIURL defaults to the Withings API.
+	APIURL string `json:"api_url,omitempty"`
+}
+
+// EnvironmentConfig holds the location and endpoints for environment readings.
+// Empty URLs fall back to the public Open-Meteo APIs.
+type EnvironmentConfig struct {
+	Latitude          float64 `json:"latitude"`
+	Longitude         float64 `json:"longitude"`
+	AirQualityURL     string  `json:"air_quality_url,omitempty"`
+	WeatherURL        string  `json:"weather_url,omitempty"`
+	WeatherArchiveURL string  `json:"weather_archive_url,omitempty"`
+
+	// OutdoorWorkouts lists workout types (e.g. "run", "ride") that get
+	// weather attached automatically when they are added.
+	OutdoorWorkouts []string `json:"outdoor_workouts,omitempty"`
+}
+
+// GetInsightsFile returns the insight rules path, defaulting to
+// insights.yaml in the config directory.
+func (c *Config) GetInsightsFile() string {
+	if c.InsightsFile == "" {
+		return filepath.Join(filepath.Dir(GetConfigPath()), "insights.yaml")
+	}
+	return ExpandPath(c.InsightsFile)
+}
+
+// Location returns the configured timezone, or time.Local when none is set.
+func (c *Config) Location() (*time.Location, error) {
+	if c.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", c.Timezone, err)
+	}
+	return loc, nil
 }
 
 // GetBackend returns the configured backend, defaulting to "sqlite".
@@ -57,20 +475,29 @@ func ExpandPath(path string) string {
 	return path
 }
 
-// OpenStorage creates a Repository implementation based on the configured backend.
+// OpenStorage opens the configured backend from the storage registry,
+// applying the file layout and daily notes for the markdown backend.
 func (c *Config) OpenStorage() (storage.Repository, error) {
-	backend := c.GetBackend()
-	dataDir := c.GetDataDir()
-
-	switch backend {
-	case "sqlite":
-		dbPath := filepath.Join(dataDir, "health.db")
-		return storage.Open(dbPath)
-	case "markdown":
-		return storage.NewMarkdownStore(dataDir)
-	default:
-		return nil, fmt.Errorf("unknown backend: %q", backend)
+	repo, err := storage.OpenBackend(c.GetBackend(), c.GetDataDir())
+	if err != nil {
+		return nil, err
+	}
+	ms, ok := repo.(*storage.MarkdownStore)
+	if !ok {
+		return repo, nil
+	}
+	if c.MarkdownLayout != "" {
+		layout, err := storage.ParseMarkdownLayout(c.MarkdownLayout)
+		if err != nil {
+			repo.Close()
+			return nil, err
+		}
+		ms.SetLayout(layout)
+	}
+	if c.DailyNotes != nil && c.DailyNotes.Dir != "" {
+		ms.SetDailyNotes(ExpandPath(c.DailyNotes.Dir), c.DailyNotes.Heading)
 	}
+	return repo, nil
 }
 
 // GetConfigPath returns the config file path.
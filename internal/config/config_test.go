@@ -4,9 +4,15 @@ package config
 
 import (
 	"encoding/json"
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
 )
 
 func TestGetBackendDefault(t *testing.T) {
@@ -40,6 +46,69 @@ func TestGetDataDirExplicit(t *testing.T) {
 	}
 }
 
+func TestGetInsightsFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if got := (&Config{}).GetInsightsFile(); got != "/tmp/xdg/health/insights.yaml" {
+		t.Errorf("GetInsightsFile() = %q, want insights.yaml beside config.json", got)
+	}
+	if got := (&Config{InsightsFile: "/etc/rules.yaml"}).GetInsightsFile(); got != "/etc/rules.yaml" {
+		t.Errorf("GetInsightsFile() = %q, want /etc/rules.yaml", got)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	if loc, err := (&Config{}).Location(); err != nil || loc != time.Local {
+		t.Errorf("Location() = %v, %v; want time.Local", loc, err)
+	}
+	if loc, err := (&Config{Timezone: "America/Chicago"}).Location(); err != nil || loc.String() != "America/Chicago" {
+		t.Errorf("Location() = %v, %v; want America/Chicago", loc, err)
+	}
+	if _, err := (&Config{Timezone: "Mars/Olympus"}).Location(); err == nil {
+		t.Error("Location() with an unknown zone succeeded; want error")
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	w, err := (&Config{DuplicateWindows: map[string]string{"weight": "1h", "mood": "0", "water": "1m", "default": "5m"}}).Duplicates()
+	if err != nil {
+		t.Fatalf("Duplicates() error: %v", err)
+	}
+	for mt, want := range map[models.MetricType]time.Duration{
+		models.MetricWeight:  time.Hour,
+		models.MetricMood:    0,
+		models.MetricWater:   time.Minute,
+		models.MetricProtein: 0,
+		models.MetricHRV:     5 * time.Minute,
+	} {
+		if got := w.Window(mt); got != want {
+			t.Errorf("Window(%s) = %v, want %v", mt, got, want)
+		}
+	}
+
+	for _, bad := range []map[string]string{{"weight": "soon"}, {"weight": "-1m"}, {"wieght": "1h"}} {
+		if _, err := (&Config{DuplicateWindows: bad}).Duplicates(); err == nil {
+			t.Errorf("Duplicates() with %v succeeded; want error", bad)
+		}
+	}
+}
+
+func TestReferencePerson(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	if p := (&Config{}).ReferencePerson(now); p == nil || p.Sex != "" || p.Age != 0 {
+		t.Errorf("ReferencePerson without a profile = %+v, want an unknown person", p)
+	}
+
+	cfg := &Config{Profile: &ProfileConfig{Sex: "male", BirthDate: "1980-03-15"}}
+	if p := cfg.ReferencePerson(now); p == nil || p.Sex != "male" || p.Age != 45 {
+		t.Errorf("ReferencePerson = %+v, want male, 45", p)
+	}
+
+	cfg.HideReferenceRanges = true
+	if p := cfg.ReferencePerson(now); p != nil {
+		t.Errorf("ReferencePerson with ranges hidden = %+v, want nil", p)
+	}
+}
+
 func TestExpandPathEmpty(t *testing.T) {
 	if got := ExpandPath(""); got != "" {
 		t.Errorf("ExpandPath(\"\") = %q, want %q", got, "")
@@ -267,6 +336,75 @@ func TestOpenStorageMarkdown(t *testing.T) {
 	}
 }
 
+func TestOpenStorageDailyNotes(t *testing.T) {
+	vault := t.TempDir()
+	cfg := &Config{
+		Backend:    "markdown",
+		DataDir:    t.TempDir(),
+		DailyNotes: &DailyNotesConfig{Dir: vault, Heading: "## Metrics"},
+	}
+
+	repo, err := cfg.OpenStorage()
+	if err != nil {
+		t.Fatalf("OpenStorage() failed: %v", err)
+	}
+	defer repo.Close()
+
+	at := time.Date(2025, 6, 10, 7, 30, 0, 0, time.Local)
+	if err := repo.CreateMetric(t.Context(), models.NewMetric(models.MetricMood, 7).WithRecordedAt(at)); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(vault, "2025-06-10.md"))
+	if err != nil || string(data) != "## Metrics\n- 07:30 mood 7/10\n" {
+		t.Errorf("daily note = %q, %v", data, err)
+	}
+}
+
+func TestOpenStorageMarkdownLayout(t *testing.T) {
+	dataDir := t.TempDir()
+	cfg := &Config{Backend: "markdown", DataDir: dataDir, MarkdownLayout: "flat"}
+
+	repo, err := cfg.OpenStorage()
+	if err != nil {
+		t.Fatalf("OpenStorage() failed: %v", err)
+	}
+	defer repo.Close()
+	m := models.NewMetric(models.MetricMood, 7).WithRecordedAt(time.Date(2025, 6, 10, 7, 30, 0, 0, time.Local))
+	if err := repo.CreateMetric(t.Context(), m); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dataDir, "metrics", "2025-06-10.md")); err != nil {
+		t.Errorf("Expected a flat day file: %v", err)
+	}
+
+	cfg.MarkdownLayout = "{date}-{type}.txt"
+	if _, err := cfg.OpenStorage(); err == nil {
+		t.Error("Expected a layout not ending in .md to be rejected")
+	}
+}
+
+func TestOpenStorageJSONL(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg := &Config{
+		Backend: "jsonl",
+		DataDir: tmpDir,
+	}
+
+	repo, err := cfg.OpenStorage()
+	if err != nil {
+		t.Fatalf("OpenStorage() for jsonl failed: %v", err)
+	}
+	defer repo.Close()
+
+	if err := repo.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82)); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "health.jsonl")); err != nil {
+		t.Errorf("Expected health.jsonl to be created: %v", err)
+	}
+}
+
 func TestOpenStorageInvalidBackend(t *testing.T) {
 	cfg := &Config{
 		Backend: "invalid",
@@ -279,6 +417,27 @@ func TestOpenStorageInvalidBackend(t *testing.T) {
 	}
 }
 
+func TestOpenStorageRegisteredBackend(t *testing.T) {
+	// A backend registered elsewhere is selectable by name with no config changes
+	var gotDir string
+	storage.RegisterBackend("config-test", func(dataDir string) (storage.Repository, error) {
+		gotDir = dataDir
+		return storage.OpenJSONL(filepath.Join(dataDir, "custom.jsonl"))
+	})
+
+	tmpDir := t.TempDir()
+	cfg := &Config{Backend: "config-test", DataDir: tmpDir}
+	repo, err := cfg.OpenStorage()
+	if err != nil {
+		t.Fatalf("OpenStorage() for registered backend failed: %v", err)
+	}
+	defer repo.Close()
+
+	if gotDir != tmpDir {
+		t.Errorf("Opener got data dir %q, want %q", gotDir, tmpDir)
+	}
+}
+
 func TestConfigJSONSerialization(t *testing.T) {
 	cfg := &Config{
 		Backend: "markdown",
@@ -339,3 +498,140 @@ func TestOpenStorageDefaultBackend(t *testing.T) {
 		t.Error("Expected non-nil repository")
 	}
 }
+
+func TestDerivedSet(t *testing.T) {
+	cfg := &Config{
+		Derived:   []DerivedConfig{{Name: "bmi", Formula: "weight / height^2", Unit: "kg/m²"}},
+		Constants: map[string]float64{"height": 1.8},
+	}
+	set, err := cfg.DerivedSet()
+	if err != nil {
+		t.Fatalf("DerivedSet() failed: %v", err)
+	}
+	if set.Lookup("bmi") == nil {
+		t.Error("expected bmi formula")
+	}
+
+	// Without the height constant the formula cannot be resolved.
+	cfg.Constants = nil
+	if _, err := cfg.DerivedSet(); err == nil {
+		t.Error("expected error for missing constant")
+	}
+}
+
+func TestDerivedSetBodyComposition(t *testing.T) {
+	cfg := &Config{Profile: &ProfileConfig{BirthDate: "1980-03-15"}}
+	if set, _ := cfg.DerivedSet(); len(set.Formulas) != 0 {
+		t.Errorf("formulas without a height = %d, want 0", len(set.Formulas))
+	}
+
+	cfg.Profile.HeightCM = 180
+	set, err := cfg.DerivedSet()
+	if err != nil {
+		t.Fatalf("DerivedSet() failed: %v", err)
+	}
+	if set.Lookup("bmi") == nil || set.Lookup("body_fat_mass") == nil || set.Lookup("bmr") != nil {
+		t.Errorf("formulas without sex = %+v; want bmi and body_fat_mass only", set.Formulas)
+	}
+
+	cfg.Profile.Sex = "male"
+	set, err = cfg.DerivedSet()
+	if err != nil {
+		t.Fatalf("DerivedSet() failed: %v", err)
+	}
+	at := time.Now()
+	values := derived.Latest(set.Compute([]*models.Metric{
+		models.NewMetric(models.MetricWeight, 81).WithRecordedAt(at),
+		models.NewMetric(models.MetricBodyFat, 20).WithRecordedAt(at),
+	}))
+	age := float64(cfg.EmergencyProfile().Age(time.Now()))
+	for name, want := range map[string]float64{"bmi": 25, "body_fat_mass": 16.2, "bmr": 810 + 1125 - 5*age + 5} {
+		if m := values[name]; m == nil || math.Abs(m.Value-want) > 1e-9 {
+			t.Errorf("%s = %+v, want %v", name, m, want)
+		}
+	}
+
+	// A user-defined formula or constant wins over the profile's
+	cfg.Derived = []DerivedConfig{{Name: "bmi", Formula: "weight / height", Unit: "kg/m"}}
+	cfg.Constants = map[string]float64{"height": 2}
+	set, err = cfg.DerivedSet()
+	if err != nil {
+		t.Fatalf("DerivedSet() failed: %v", err)
+	}
+	if f := set.Lookup("bmi"); f == nil || f.Source != "weight / height" || set.Constants["height"] != 2 {
+		t.Errorf("bmi = %+v with height %v; want the user's", f, set.Constants["height"])
+	}
+	if cfg.IsBuiltinDerived("bmi") || !cfg.IsBuiltinDerived("bmr") {
+		t.Error("IsBuiltinDerived should exclude user-defined bmi and include bmr")
+	}
+}
+
+func TestHeartRateZones(t *testing.T) {
+	cfg := &Config{}
+	if _, ok := cfg.HeartRateZones(); ok {
+		t.Error("expected no zones without a profile")
+	}
+
+	cfg.Profile = &ProfileConfig{MaxHR: 200}
+	if z, ok := cfg.HeartRateZones(); !ok || z.String() != "100,120,140,160,180" {
+		t.Errorf("HeartRateZones() from max HR = %v, %v", z, ok)
+	}
+
+	cfg.Profile.HRZones = []float64{105, 125, 145, 160, 172}
+	if z, ok := cfg.HeartRateZones(); !ok || z.String() != "105,125,145,160,172" {
+		t.Errorf("HeartRateZones() with explicit zones = %v, %v", z, ok)
+	}
+}
+
+func TestEmergencyProfile(t *testing.T) {
+	cfg := &Config{}
+	if cfg.EmergencyProfile() != nil {
+		t.Error("expected nil profile when none is configured")
+	}
+
+	cfg.Profile = &ProfileConfig{
+		BloodType:        "A+",
+		Allergies:        []string{"latex"},
+		EmergencyContact: &ContactConfig{Name: "Sam", Phone: "555-0100", Relation: "sibling"},
+	}
+	p := cfg.EmergencyProfile()
+	if p.BloodType != "A+" || len(p.Allergies) != 1 || p.EmergencyContact.Relation != "sibling" {
+		t.Errorf("EmergencyProfile() = %+v", p)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	type token struct {
+		Access  string `json:"access"`
+		Refresh string `json:"refresh"`
+	}
+
+	var got token
+	if ok, err := LoadSecret("withings", &got); ok || err != nil {
+		t.Fatalf("LoadSecret on an empty store = %v, %v", ok, err)
+	}
+	if err := SaveSecret("withings", token{"a1", "r1"}); err != nil {
+		t.Fatalf("SaveSecret failed: %v", err)
+	}
+	if err := SaveSecret("other", token{"a2", "r2"}); err != nil {
+		t.Fatalf("SaveSecret failed: %v", err)
+	}
+	if ok, err := LoadSecret("withings", &got); !ok || err != nil || got != (token{"a1", "r1"}) {
+		t.Errorf("LoadSecret = %+v, %v, %v", got, ok, err)
+	}
+	info, err := os.Stat(GetSecretsPath())
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("secrets file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+	}
+
+	if err := DeleteSecret("withings"); err != nil {
+		t.Fatalf("DeleteSecret failed: %v", err)
+	}
+	if ok, _ := LoadSecret("withings", &got); ok {
+		t.Error("expected the deleted secret to be gone")
+	}
+	if ok, _ := LoadSecret("other", &got); !ok || got.Access != "a2" {
+		t.Errorf("expected other secrets to survive, got %+v", got)
+	}
+}
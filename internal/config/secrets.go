@@ -0,0 +1,92 @@
+// ABOUTME: Credential store for OAuth tokens that integrations must keep between runs.
+// ABOUTME: Kept in secrets.json beside config.json, readable only by the owner.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GetSecretsPath returns the path of the credential store. Tokens that
+// rotate on refresh can't live in the environment, and are kept out of
+// config.json so it can be shared or committed without them.
+func GetSecretsPath() string {
+	return filepath.Join(filepath.Dir(GetConfigPath()), "secrets.json")
+}
+
+func loadSecrets() (map[string]json.RawMessage, error) {
+	secrets := map[string]json.RawMessage{}
+	data, err := os.ReadFile(GetSecretsPath())
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", GetSecretsPath(), err)
+	}
+	return secrets, nil
+}
+
+func saveSecrets(secrets map[string]json.RawMessage) error {
+	path := GetSecretsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	// WriteFile keeps the mode of an existing file
+	return os.Chmod(path, 0600)
+}
+
+// LoadSecret decodes the secret stored under name into v. It reports
+// false when nothing is stored.
+func LoadSecret(name string, v any) (bool, error) {
+	secrets, err := loadSecrets()
+	if err != nil {
+		return false, err
+	}
+	raw, ok := secrets[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return false, fmt.Errorf("parse secret %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// SaveSecret stores v under name, replacing what was there.
+func SaveSecret(name string, v any) error {
+	secrets, err := loadSecrets()
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	secrets[name] = raw
+	return saveSecrets(secrets)
+}
+
+// DeleteSecret removes the secret stored under name, if any.
+func DeleteSecret(name string) error {
+	secrets, err := loadSecrets()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return nil
+	}
+	delete(secrets, name)
+	return saveSecrets(secrets)
+}
@@ -0,0 +1,231 @@
+// ABOUTME: Derived metrics computed on the fly from stored metrics and constants.
+// ABOUTME: Formulas like weight / height^2 are evaluated per day and never stored.
+package derived
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// Formula is a named, parsed derived metric definition.
+type Formula struct {
+	Name   string
+	Source string
+	Unit   string
+	expr   node
+	inputs []models.MetricType
+	idents []string
+}
+
+// Parse validates a formula. Identifiers must be metric types or one of the
+// given constants; the name must not shadow a stored metric type.
+func Parse(name, source, unit string, constants map[string]float64) (*Formula, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return nil, fmt.Errorf("derived metric needs a name")
+	}
+	if models.IsValidMetricType(name) {
+		return nil, fmt.Errorf("%s is already a metric type", name)
+	}
+	expr, idents, err := parseExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("formula for %s: %w", name, err)
+	}
+
+	f := &Formula{Name: name, Source: source, Unit: unit, expr: expr, idents: idents}
+	seen := make(map[string]bool)
+	for _, id := range idents {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, ok := constants[id]; ok {
+			continue
+		}
+		if !models.IsValidMetricType(id) {
+			return nil, fmt.Errorf("formula for %s: %s is not a metric type or constant", name, id)
+		}
+		f.inputs = append(f.inputs, models.MetricType(id))
+	}
+	if len(f.inputs) == 0 {
+		return nil, fmt.Errorf("formula for %s uses no metrics", name)
+	}
+	return f, nil
+}
+
+// Inputs returns the metric types the formula reads.
+func (f *Formula) Inputs() []models.MetricType {
+	return f.inputs
+}
+
+// Set is a group of formulas sharing one set of constants (e.g. height).
+type Set struct {
+	Formulas  []*Formula
+	Constants map[string]float64
+}
+
+// Lookup returns the formula with the given name, or nil.
+func (s *Set) Lookup(name string) *Formula {
+	if s == nil {
+		return nil
+	}
+	for _, f := range s.Formulas {
+		if f.Name == strings.ToLower(name) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Inputs returns every metric type any formula in the set reads.
+func (s *Set) Inputs() []models.MetricType {
+	if s == nil {
+		return nil
+	}
+	var out []models.MetricType
+	seen := make(map[models.MetricType]bool)
+	for _, f := range s.Formulas {
+		for _, mt := range f.inputs {
+			if !seen[mt] {
+				seen[mt] = true
+				out = append(out, mt)
+			}
+		}
+	}
+	return out
+}
+
+// Compute evaluates every formula for each day on which one of its inputs
+// was logged. Cumulative inputs (calories, water, ...) use that day's total,
+// treating a missing total as zero; other inputs use the latest value on or
+// before the end of that day. Days where an input has no value yet are
+// skipped. The metrics slice may be in any order; results are sorted
+// newest first and carry the time of the day's last input.
+func (s *Set) Compute(metrics []*models.Metric) []*models.Metric {
+	if s == nil || len(s.Formulas) == 0 {
+		return nil
+	}
+
+	byType := make(map[models.MetricType][]*models.Metric)
+	for _, m := range metrics {
+		byType[m.MetricType] = append(byType[m.MetricType], m)
+	}
+	for _, ms := range byType {
+		sort.Slice(ms, func(i, j int) bool { return ms[i].RecordedAt.Before(ms[j].RecordedAt) })
+	}
+
+	var out []*models.Metric
+	for _, f := range s.Formulas {
+		out = append(out, s.computeFormula(f, byType)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].RecordedAt.After(out[j].RecordedAt) })
+	return out
+}
+
+// Latest picks the newest value of each derived metric from Compute's
+// output, keyed by name.
+func Latest(values []*models.Metric) map[string]*models.Metric {
+	latest := make(map[string]*models.Metric)
+	for _, m := range values {
+		if cur, ok := latest[string(m.MetricType)]; !ok || m.RecordedAt.After(cur.RecordedAt) {
+			latest[string(m.MetricType)] = m
+		}
+	}
+	return latest
+}
+
+func (s *Set) computeFormula(f *Formula, byType map[models.MetricType][]*models.Metric) []*models.Metric {
+	// Collect each day an input was logged, with the last input time that day.
+	lastInput := make(map[string]time.Time)
+	for _, mt := range f.inputs {
+		for _, m := range byType[mt] {
+			day := m.RecordedAt.Format("2006-01-02")
+			if m.RecordedAt.After(lastInput[day]) {
+				lastInput[day] = m.RecordedAt
+			}
+		}
+	}
+
+	var out []*models.Metric
+	for _, at := range lastInput {
+		dayStart := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		vars := make(map[string]float64, len(s.Constants)+len(f.inputs))
+		for k, v := range s.Constants {
+			vars[k] = v
+		}
+		complete := true
+		for _, mt := range f.inputs {
+			v, ok := inputValue(byType[mt], mt, dayStart, dayEnd)
+			if !ok {
+				complete = false
+				break
+			}
+			vars[string(mt)] = v
+		}
+		if !complete {
+			continue
+		}
+
+		value, err := f.expr.eval(vars)
+		if err != nil {
+			continue
+		}
+		// A name-based ID keeps a day's value stable across runs and exports.
+		day := dayStart.Format("2006-01-02")
+		out = append(out, &models.Metric{
+			ID:         uuid.NewSHA1(uuid.NameSpaceOID, []byte("health/derived/"+f.Name+"/"+day)),
+			MetricType: models.MetricType(f.Name),
+			Value:      value,
+			Unit:       f.Unit,
+			RecordedAt: at,
+			CreatedAt:  at,
+		})
+	}
+	return out
+}
+
+// inputValue resolves one input for the day [start, end). ms is sorted oldest first.
+func inputValue(ms []*models.Metric, mt models.MetricType, start, end time.Time) (float64, bool) {
+	if models.IsCumulative(mt) {
+		var total float64
+		for _, m := range ms {
+			if !m.RecordedAt.Before(start) && m.RecordedAt.Before(end) {
+				total += m.Value
+			}
+		}
+		return total, true
+	}
+	var (
+		value float64
+		found bool
+	)
+	for _, m := range ms {
+		if !m.RecordedAt.Before(end) {
+			break
+		}
+		value, found = m.Value, true
+	}
+	return value, found
+}
+
+// FromRepo computes derived values from every stored metric the set reads.
+func (s *Set) FromRepo(ctx context.Context, r storage.Repository) ([]*models.Metric, error) {
+	var metrics []*models.Metric
+	for _, mt := range s.Inputs() {
+		ms, err := r.ListMetrics(ctx, &mt, 0)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", mt, err)
+		}
+		metrics = append(metrics, ms...)
+	}
+	return s.Compute(metrics), nil
+}
@@ -0,0 +1,149 @@
+// ABOUTME: Tests for derived metric formulas and their per-day evaluation.
+// ABOUTME: Covers parsing, operator precedence, validation, and input alignment.
+package derived
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func TestParseExprPrecedence(t *testing.T) {
+	tests := []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", 4},
+		{"10 - 4 - 3", 3},
+		{"x / y", 2.5},
+		{"weight / height^2", 80 / (1.8 * 1.8)},
+	}
+	vars := map[string]float64{"x": 5, "y": 2, "weight": 80, "height": 1.8}
+	for _, tt := range tests {
+		n, _, err := parseExpr(tt.src)
+		if err != nil {
+			t.Fatalf("parseExpr(%q): %v", tt.src, err)
+		}
+		got, err := n.eval(vars)
+		if err != nil {
+			t.Fatalf("eval(%q): %v", tt.src, err)
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	for _, src := range []string{"", "1 +", "(1 + 2", "1 ) 2", "weight $ 2", "1..2"} {
+		if _, _, err := parseExpr(src); err == nil {
+			t.Errorf("parseExpr(%q) should fail", src)
+		}
+	}
+}
+
+func TestParseValidatesIdentifiers(t *testing.T) {
+	consts := map[string]float64{"height": 1.8}
+
+	f, err := Parse("BMI", "weight / height^2", "kg/m²", consts)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f.Name != "bmi" {
+		t.Errorf("Name = %q, want bmi", f.Name)
+	}
+	if len(f.Inputs()) != 1 || f.Inputs()[0] != models.MetricWeight {
+		t.Errorf("Inputs = %v, want [weight]", f.Inputs())
+	}
+
+	if _, err := Parse("bmi", "weight / height^2", "", nil); err == nil {
+		t.Error("expected error for unknown constant")
+	}
+	if _, err := Parse("weight", "weight * 2", "", nil); err == nil {
+		t.Error("expected error for name shadowing a metric type")
+	}
+	if _, err := Parse("two", "1 + 1", "", nil); err == nil {
+		t.Error("expected error for formula without metrics")
+	}
+}
+
+func TestComputeAlignsInputsPerDay(t *testing.T) {
+	day1 := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	consts := map[string]float64{"height": 2}
+
+	bmi, _ := Parse("bmi", "weight / height^2", "kg/m²", consts)
+	net, _ := Parse("net_calories", "calories - active_calories", "kcal", consts)
+	set := &Set{Formulas: []*Formula{bmi, net}, Constants: consts}
+
+	metrics := []*models.Metric{
+		models.NewMetric(models.MetricWeight, 80).WithRecordedAt(day1),
+		models.NewMetric(models.MetricCalories, 1200).WithRecordedAt(day1.Add(time.Hour)),
+		models.NewMetric(models.MetricCalories, 800).WithRecordedAt(day1.Add(4 * time.Hour)),
+		models.NewMetric(models.MetricActiveCalories, 500).WithRecordedAt(day1.Add(5 * time.Hour)),
+		// Day 2: only calories. They are cumulative so they total for the day;
+		// active calories are a snapshot and carry over like weight.
+		models.NewMetric(models.MetricCalories, 1500).WithRecordedAt(day2),
+	}
+
+	got := set.Compute(metrics)
+	values := make(map[string]float64)
+	for _, m := range got {
+		values[string(m.MetricType)+" "+m.RecordedAt.Format("01-02")] = m.Value
+	}
+	want := map[string]float64{
+		"bmi 12-14":          20,
+		"net_calories 12-14": 1500,
+		"net_calories 12-15": 1000,
+	}
+	if len(values) != len(want) {
+		t.Fatalf("got %v, want %v", values, want)
+	}
+	for k, v := range want {
+		if values[k] != v {
+			t.Errorf("%s = %v, want %v", k, values[k], v)
+		}
+	}
+
+	latest := Latest(got)
+	if latest["net_calories"] == nil || !latest["net_calories"].RecordedAt.Equal(day2) {
+		t.Errorf("latest net_calories = %+v", latest["net_calories"])
+	}
+	if latest["bmi"].Unit != "kg/m²" {
+		t.Errorf("bmi unit = %q", latest["bmi"].Unit)
+	}
+
+	// IDs are stable so repeated exports agree.
+	again := Latest(set.Compute(metrics))
+	if again["bmi"].ID != latest["bmi"].ID {
+		t.Error("expected derived IDs to be stable")
+	}
+}
+
+func TestComputeSkipsDaysBeforeFirstInput(t *testing.T) {
+	consts := map[string]float64{"height": 2}
+	bmi, _ := Parse("bmi", "weight / height^2", "", consts)
+	ratio, _ := Parse("ratio", "protein / weight", "", consts)
+	set := &Set{Formulas: []*Formula{bmi, ratio}, Constants: consts}
+
+	day1 := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	metrics := []*models.Metric{
+		models.NewMetric(models.MetricProtein, 120).WithRecordedAt(day1),
+		models.NewMetric(models.MetricWeight, 80).WithRecordedAt(day1.AddDate(0, 0, 1)),
+	}
+	got := set.Compute(metrics)
+	// ratio on day 1 has no weight yet; day 2 has weight and zero protein.
+	for _, m := range got {
+		if m.MetricType == "ratio" && m.RecordedAt.Day() == 14 {
+			t.Errorf("unexpected ratio before weight was logged: %+v", m)
+		}
+	}
+	if len(got) != 2 {
+		t.Errorf("got %d derived values, want 2", len(got))
+	}
+}
@@ -0,0 +1,208 @@
+// ABOUTME: Tiny arithmetic expression parser for derived metric formulas.
+// ABOUTME: Supports numbers, identifiers, + - * / ^, unary minus, and parentheses.
+package derived
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// node is a parsed expression that can be evaluated against variables.
+type node interface {
+	eval(vars map[string]float64) (float64, error)
+}
+
+type number float64
+
+func (n number) eval(map[string]float64) (float64, error) { return float64(n), nil }
+
+type ident string
+
+func (id ident) eval(vars map[string]float64) (float64, error) {
+	v, ok := vars[string(id)]
+	if !ok {
+		return 0, fmt.Errorf("no value for %s", id)
+	}
+	return v, nil
+}
+
+type unary struct{ x node }
+
+func (u unary) eval(vars map[string]float64) (float64, error) {
+	v, err := u.x.eval(vars)
+	return -v, err
+}
+
+type binary struct {
+	op   byte
+	l, r node
+}
+
+func (b binary) eval(vars map[string]float64) (float64, error) {
+	l, err := b.l.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	r, err := b.r.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	default: // '^'
+		return math.Pow(l, r), nil
+	}
+}
+
+// parser is a recursive-descent parser over a formula string:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = unary [ "^" factor ]
+//	unary  = "-" unary | atom
+//	atom   = number | identifier | "(" expr ")"
+type parser struct {
+	src    string
+	pos    int
+	idents []string
+}
+
+// parseExpr parses a formula and returns its tree and the identifiers it uses.
+func parseExpr(src string) (node, []string, error) {
+	p := &parser{src: src}
+	n, err := p.expr()
+	if err != nil {
+		return nil, nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, nil, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+	return n, p.idents, nil
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) expr() (node, error) {
+	l, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		l = binary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) term() (node, error) {
+	l, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		r, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		l = binary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *parser) factor() (node, error) {
+	base, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek() == '^' {
+		p.pos++
+		exp, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return binary{op: '^', l: base, r: exp}, nil
+	}
+	return base, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{x: x}, nil
+	}
+	return p.atom()
+}
+
+func (p *parser) atom() (node, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of formula")
+	case c == '(':
+		p.pos++
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at position %d", p.pos+1)
+		}
+		p.pos++
+		return n, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return number(v), nil
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		name := strings.ToLower(p.src[start:p.pos])
+		p.idents = append(p.idents, name)
+		return ident(name), nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+	}
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Client for fetching environmental readings (air quality, pollen, temperature, weather).
+// ABOUTME: Talks to Open-Meteo compatible endpoints and converts results into metrics.
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// Default endpoints. Both are free Open-Meteo APIs that need no API key.
+const (
+	DefaultAirQualityURL = "https://air-quality-api.open-meteo.com/v1/air-quality"
+	DefaultWeatherURL    = "https://api.open-meteo.com/v1/forecast"
+)
+
+// pollenFields are summed into a single pollen count.
+var pollenFields = []string{
+	"alder_pollen", "birch_pollen", "grass_pollen",
+	"mugwort_pollen", "olive_pollen", "ragweed_pollen",
+}
+
+// Client fetches current environmental conditions for a location.
+type Client struct {
+	AirQualityURL string
+	WeatherURL    string
+	ArchiveURL    string
+	HTTPClient    *http.Client
+}
+
+// NewClient creates a Client, falling back to the default endpoints for empty URLs.
+func NewClient(airQualityURL, weatherURL string) *Client {
+	if airQualityURL == "" {
+		airQualityURL = DefaultAirQualityURL
+	}
+	if weatherURL == "" {
+		weatherURL = DefaultWeatherURL
+	}
+	return &Client{
+		AirQualityURL: airQualityURL,
+		WeatherURL:    weatherURL,
+		ArchiveURL:    DefaultWeatherArchiveURL,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// currentResponse is the subset of an Open-Meteo response we read.
+// Values are pointers because unavailable readings come back as null.
+type currentResponse struct {
+	Current map[string]*float64 `json:"current"`
+}
+
+// Current returns aqi, pollen, and ambient_temp metrics for the given location.
+// Readings the provider doesn't report for that location are omitted.
+func (c *Client) Current(ctx context.Context, lat, lon float64) ([]*models.Metric, error) {
+	var metrics []*models.Metric
+
+	air, err := c.fetch(ctx, c.AirQualityURL, lat, lon, append([]string{"us_aqi"}, pollenFields...))
+	if err != nil {
+		return nil, fmt.Errorf("fetch air quality: %w", err)
+	}
+	airAt := readingTime(air)
+	if v := air.Current["us_aqi"]; v != nil {
+		metrics = append(metrics, newReading(models.MetricAQI, *v, airAt))
+	}
+	var pollen float64
+	var havePollen bool
+	for _, field := range pollenFields {
+		if v := air.Current[field]; v != nil {
+			pollen += *v
+			havePollen = true
+		}
+	}
+	if havePollen {
+		metrics = append(metrics, newReading(models.MetricPollen, pollen, airAt))
+	}
+
+	weather, err := c.fetch(ctx, c.WeatherURL, lat, lon, []string{"temperature_2m"})
+	if err != nil {
+		return nil, fmt.Errorf("fetch weather: %w", err)
+	}
+	if v := weather.Current["temperature_2m"]; v != nil {
+		metrics = append(metrics, newReading(models.MetricAmbientTemp, *v, readingTime(weather)))
+	}
+
+	return metrics, nil
+}
+
+func (c *Client) fetch(ctx context.Context, endpoint string, lat, lon float64, fields []string) (*currentResponse, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", endpoint, err)
+	}
+	q := u.Query()
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', -1, 64))
+	q.Set("current", strings.Join(fields, ","))
+	q.Set("timeformat", "unixtime")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var out currentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &out, nil
+}
+
+// readingTime returns the observation time reported by the provider, or now.
+func readingTime(r *currentResponse) time.Time {
+	if v := r.Current["time"]; v != nil {
+		return time.Unix(int64(*v), 0)
+	}
+	return time.Now()
+}
+
+func newReading(mt models.MetricType, value float64, at time.Time) *models.Metric {
+	return models.NewMetric(mt, value).WithRecordedAt(at).WithSource(models.SourceEnvironment)
+}
@@ -0,0 +1,84 @@
+// ABOUTME: Tests for the environment readings client.
+// ABOUTME: Uses httptest servers standing in for the Open-Meteo APIs.
+package environment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func newTestServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("latitude") != "41.88" || r.URL.Query().Get("longitude") != "-87.63" {
+			t.Errorf("unexpected query: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientCurrent(t *testing.T) {
+	air := newTestServer(t, `{"current":{"time":1718000000,"interval":3600,"us_aqi":42,"alder_pollen":null,"birch_pollen":1.5,"grass_pollen":2.5}}`)
+	weather := newTestServer(t, `{"current":{"time":1718000900,"temperature_2m":21.3}}`)
+
+	client := NewClient(air.URL, weather.URL)
+	metrics, err := client.Current(context.Background(), 41.88, -87.63)
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+
+	got := make(map[models.MetricType]*models.Metric)
+	for _, m := range metrics {
+		got[m.MetricType] = m
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 readings, got %d", len(got))
+	}
+	if got[models.MetricAQI].Value != 42 {
+		t.Errorf("aqi = %v, want 42", got[models.MetricAQI].Value)
+	}
+	if got[models.MetricPollen].Value != 4 {
+		t.Errorf("pollen = %v, want 4", got[models.MetricPollen].Value)
+	}
+	if got[models.MetricAmbientTemp].Value != 21.3 {
+		t.Errorf("ambient_temp = %v, want 21.3", got[models.MetricAmbientTemp].Value)
+	}
+	if got[models.MetricAQI].RecordedAt.Unix() != 1718000000 {
+		t.Errorf("aqi recorded_at = %v, want provider time", got[models.MetricAQI].RecordedAt)
+	}
+	if got[models.MetricAmbientTemp].Unit != "°C" {
+		t.Errorf("ambient_temp unit = %q", got[models.MetricAmbientTemp].Unit)
+	}
+}
+
+func TestClientCurrentOmitsMissingPollen(t *testing.T) {
+	air := newTestServer(t, `{"current":{"time":1718000000,"us_aqi":12,"birch_pollen":null,"grass_pollen":null}}`)
+	weather := newTestServer(t, `{"current":{"time":1718000000,"temperature_2m":null}}`)
+
+	metrics, err := NewClient(air.URL, weather.URL).Current(context.Background(), 41.88, -87.63)
+	if err != nil {
+		t.Fatalf("Current failed: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].MetricType != models.MetricAQI {
+		t.Fatalf("expected only aqi reading, got %+v", metrics)
+	}
+}
+
+func TestClientCurrentHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	if _, err := NewClient(srv.URL, srv.URL).Current(context.Background(), 0, 0); err == nil {
+		t.Fatal("expected error for non-200 response")
+	}
+}
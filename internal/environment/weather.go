@@ -0,0 +1,187 @@
+// ABOUTME: Historical weather lookup and workout enrichment.
+// ABOUTME: Fetches hourly temperature, humidity, and wind and stores them as workout metrics.
+package environment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// DefaultWeatherArchiveURL serves weather older than the forecast API's
+// lookback window.
+const DefaultWeatherArchiveURL = "https://archive-api.open-meteo.com/v1/archive"
+
+// forecastLookback is how far back the forecast API serves hourly history.
+const forecastLookback = 90 * 24 * time.Hour
+
+// Workout metric names written by weather enrichment.
+const (
+	WeatherTempMetric     = "weather_temp"
+	WeatherHumidityMetric = "weather_humidity"
+	WeatherWindMetric     = "weather_wind"
+)
+
+// IsWeatherMetric reports whether a workout metric was written by enrichment.
+func IsWeatherMetric(name string) bool {
+	return name == WeatherTempMetric || name == WeatherHumidityMetric || name == WeatherWindMetric
+}
+
+// Weather is the observed weather at one point in time.
+// Fields are nil when the provider has no value for that hour.
+type Weather struct {
+	At          time.Time
+	TempC       *float64
+	HumidityPct *float64
+	WindKmh     *float64
+}
+
+type hourlyResponse struct {
+	Hourly map[string][]*float64 `json:"hourly"`
+}
+
+// WeatherAt returns the hourly weather closest to at for a location.
+func (c *Client) WeatherAt(ctx context.Context, lat, lon float64, at time.Time) (*Weather, error) {
+	endpoint := c.WeatherURL
+	if time.Since(at) > forecastLookback {
+		endpoint = c.ArchiveURL
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", endpoint, err)
+	}
+	day := at.UTC().Format("2006-01-02")
+	q := u.Query()
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', -1, 64))
+	q.Set("hourly", strings.Join([]string{"temperature_2m", "relative_humidity_2m", "wind_speed_10m"}, ","))
+	q.Set("start_date", day)
+	q.Set("end_date", day)
+	q.Set("timeformat", "unixtime")
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch weather: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch weather: unexpected status: %s", resp.Status)
+	}
+
+	var out hourlyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode weather: %w", err)
+	}
+
+	times := out.Hourly["time"]
+	best := -1
+	bestDiff := math.MaxFloat64
+	for i, ts := range times {
+		if ts == nil {
+			continue
+		}
+		diff := math.Abs(float64(at.Unix()) - *ts)
+		if diff < bestDiff {
+			best, bestDiff = i, diff
+		}
+	}
+	if best < 0 {
+		return nil, fmt.Errorf("no hourly weather for %s", day)
+	}
+
+	valueAt := func(field string) *float64 {
+		vals := out.Hourly[field]
+		if best < len(vals) {
+			return vals[best]
+		}
+		return nil
+	}
+	return &Weather{
+		At:          time.Unix(int64(*times[best]), 0),
+		TempC:       valueAt("temperature_2m"),
+		HumidityPct: valueAt("relative_humidity_2m"),
+		WindKmh:     valueAt("wind_speed_10m"),
+	}, nil
+}
+
+// WorkoutEnricher attaches weather to workouts recorded at a fixed location.
+type WorkoutEnricher struct {
+	Client    *Client
+	Latitude  float64
+	Longitude float64
+	// OutdoorTypes lists workout types enriched automatically.
+	OutdoorTypes []string
+}
+
+// IsOutdoor reports whether workouts of this type are enriched by default.
+func (e *WorkoutEnricher) IsOutdoor(workoutType string) bool {
+	for _, t := range e.OutdoorTypes {
+		if strings.EqualFold(t, workoutType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Enrich looks up the weather at the middle of the workout and stores it as
+// workout metrics, replacing any weather metrics from an earlier run.
+func (e *WorkoutEnricher) Enrich(ctx context.Context, r storage.Repository, w *models.Workout) ([]*models.WorkoutMetric, error) {
+	at := w.StartedAt
+	if w.DurationMinutes != nil {
+		at = at.Add(time.Duration(*w.DurationMinutes) * time.Minute / 2)
+	}
+
+	weather, err := e.Client.WeatherAt(ctx, e.Latitude, e.Longitude, at)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := r.ListWorkoutMetrics(ctx, w.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list workout metrics: %w", err)
+	}
+	for _, wm := range existing {
+		if IsWeatherMetric(wm.MetricName) {
+			if err := r.DeleteWorkoutMetric(ctx, wm.ID.String()); err != nil {
+				return nil, fmt.Errorf("replace weather metric: %w", err)
+			}
+		}
+	}
+
+	var added []*models.WorkoutMetric
+	for _, v := range []struct {
+		name  string
+		value *float64
+		unit  string
+	}{
+		{WeatherTempMetric, weather.TempC, "°C"},
+		{WeatherHumidityMetric, weather.HumidityPct, "%"},
+		{WeatherWindMetric, weather.WindKmh, "km/h"},
+	} {
+		if v.value == nil {
+			continue
+		}
+		wm := models.NewWorkoutMetric(w.ID, v.name, *v.value, v.unit)
+		if err := r.AddWorkoutMetric(ctx, wm); err != nil {
+			return nil, fmt.Errorf("add weather metric: %w", err)
+		}
+		added = append(added, wm)
+	}
+	return added, nil
+}
@@ -0,0 +1,111 @@
+// ABOUTME: Tests for historical weather lookup and workout enrichment.
+// ABOUTME: Uses httptest servers and a temporary SQLite database.
+package environment
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// hourlyBody covers 10:00, 11:00 and 12:00 UTC on 2024-06-10.
+const hourlyBody = `{"hourly":{
+	"time":[1718013600,1718017200,1718020800],
+	"temperature_2m":[17.5,18.2,19.0],
+	"relative_humidity_2m":[70,64,60],
+	"wind_speed_10m":[10.1,12.5,null]}}`
+
+func TestWeatherAtPicksNearestHour(t *testing.T) {
+	archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("start_date") != "2024-06-10" || q.Get("end_date") != "2024-06-10" {
+			t.Errorf("unexpected date range: %s", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(hourlyBody))
+	}))
+	defer archive.Close()
+
+	client := NewClient("", "http://forecast.invalid")
+	client.ArchiveURL = archive.URL
+
+	// 11:20 is closest to the 11:00 reading; the date is old enough for the archive.
+	at := time.Date(2024, 6, 10, 11, 20, 0, 0, time.UTC)
+	weather, err := client.WeatherAt(context.Background(), 41.88, -87.63, at)
+	if err != nil {
+		t.Fatalf("WeatherAt failed: %v", err)
+	}
+
+	if weather.At.Unix() != 1718017200 {
+		t.Errorf("At = %v, want 11:00 UTC", weather.At.UTC())
+	}
+	if weather.TempC == nil || *weather.TempC != 18.2 {
+		t.Errorf("TempC = %v, want 18.2", weather.TempC)
+	}
+	if weather.HumidityPct == nil || *weather.HumidityPct != 64 {
+		t.Errorf("HumidityPct = %v, want 64", weather.HumidityPct)
+	}
+	if weather.WindKmh == nil || *weather.WindKmh != 12.5 {
+		t.Errorf("WindKmh = %v, want 12.5", weather.WindKmh)
+	}
+}
+
+func TestWeatherAtNoData(t *testing.T) {
+	srv := newTestServer(t, `{"hourly":{"time":[]}}`)
+	client := NewClient("", srv.URL)
+
+	if _, err := client.WeatherAt(context.Background(), 41.88, -87.63, time.Now()); err == nil {
+		t.Error("expected error when provider returns no hours")
+	}
+}
+
+func TestWorkoutEnricher(t *testing.T) {
+	ctx := t.Context()
+	archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(hourlyBody))
+	}))
+	defer archive.Close()
+
+	db := storage.NewMemoryStore()
+
+	client := NewClient("", "http://forecast.invalid")
+	client.ArchiveURL = archive.URL
+	enricher := &WorkoutEnricher{Client: client, Latitude: 41.88, Longitude: -87.63, OutdoorTypes: []string{"run"}}
+
+	if !enricher.IsOutdoor("Run") || enricher.IsOutdoor("lift") {
+		t.Error("IsOutdoor should match configured types case-insensitively")
+	}
+
+	// Starts at 11:40 and lasts 40 minutes, so the midpoint is 12:00.
+	w := models.NewWorkout("run").WithDuration(40)
+	w.StartedAt = time.Date(2024, 6, 10, 11, 40, 0, 0, time.UTC)
+	if err := db.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("create workout: %v", err)
+	}
+
+	// Enriching twice replaces rather than duplicates the weather metrics.
+	for i := 0; i < 2; i++ {
+		if _, err := enricher.Enrich(context.Background(), db, w); err != nil {
+			t.Fatalf("Enrich failed: %v", err)
+		}
+	}
+
+	metrics, err := db.ListWorkoutMetrics(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("list workout metrics: %v", err)
+	}
+	got := make(map[string]float64)
+	for _, wm := range metrics {
+		got[wm.MetricName] = wm.Value
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("expected 2 weather metrics (wind is null at noon), got %d", len(metrics))
+	}
+	if got[WeatherTempMetric] != 19.0 || got[WeatherHumidityMetric] != 60 {
+		t.Errorf("unexpected weather metrics: %v", got)
+	}
+}
@@ -0,0 +1,187 @@
+// ABOUTME: Client for the Fitbit Web API: daily steps, resting heart rate, weight, and sleep.
+// ABOUTME: Converts responses into metrics and sleep sessions in the caller's time zone.
+package fitbit
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// DefaultBaseURL is the Fitbit Web API.
+const DefaultBaseURL = "https://api.fitbit.com"
+
+// MaxDays is the longest range one Fetch covers; the weight log endpoint
+// accepts at most 31 days.
+const MaxDays = 31
+
+// Client reads one user's data with an OAuth 2.0 access token.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client for the Fitbit Web API.
+func NewClient(token string) *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Data is what one Fetch returns.
+type Data struct {
+	Metrics []*models.Metric
+	Sleep   []*models.SleepSession
+}
+
+// Fetch pulls the days from through to (inclusive, at most MaxDays).
+// Fitbit reports local times without a zone; they are read in from's
+// location. Daily values (steps, resting heart rate) are recorded at the
+// end of their day, so they count toward that day.
+func (c *Client) Fetch(ctx context.Context, from, to time.Time) (*Data, error) {
+	if days := int(to.Sub(from).Hours()/24) + 1; days > MaxDays {
+		return nil, fmt.Errorf("fitbit: range of %d days exceeds %d", days, MaxDays)
+	}
+	loc := from.Location()
+	span := from.Format("2006-01-02") + "/" + to.Format("2006-01-02")
+	data := &Data{}
+
+	var steps struct {
+		Days []struct {
+			DateTime string `json:"dateTime"`
+			Value    string `json:"value"`
+		} `json:"activities-steps"`
+	}
+	if err := c.get(ctx, "/1/user/-/activities/steps/date/"+span+".json", &steps); err != nil {
+		return nil, err
+	}
+	for _, d := range steps.Days {
+		v, err := strconv.ParseFloat(d.Value, 64)
+		if err != nil || v == 0 { // 0 means the tracker wasn't worn
+			continue
+		}
+		if at, ok := endOfDay(d.DateTime, loc); ok {
+			data.Metrics = append(data.Metrics, newMetric(models.MetricSteps, v, at))
+		}
+	}
+
+	var heart struct {
+		Days []struct {
+			DateTime string `json:"dateTime"`
+			Value    struct {
+				RestingHeartRate *float64 `json:"restingHeartRate"`
+			} `json:"value"`
+		} `json:"activities-heart"`
+	}
+	if err := c.get(ctx, "/1/user/-/activities/heart/date/"+span+".json", &heart); err != nil {
+		return nil, err
+	}
+	for _, d := range heart.Days {
+		if d.Value.RestingHeartRate == nil {
+			continue
+		}
+		if at, ok := endOfDay(d.DateTime, loc); ok {
+			m := newMetric(models.MetricHeartRate, *d.Value.RestingHeartRate, at)
+			m.WithNotes("resting")
+			data.Metrics = append(data.Metrics, m)
+		}
+	}
+
+	// Without an Accept-Language header the API answers in metric units
+	var weight struct {
+		Weight []struct {
+			Date   string   `json:"date"`
+			Time   string   `json:"time"`
+			Weight float64  `json:"weight"`
+			Fat    *float64 `json:"fat"`
+		} `json:"weight"`
+	}
+	if err := c.get(ctx, "/1/user/-/body/log/weight/date/"+span+".json", &weight); err != nil {
+		return nil, err
+	}
+	for _, w := range weight.Weight {
+		at, err := time.ParseInLocation("2006-01-02 15:04:05", w.Date+" "+w.Time, loc)
+		if err != nil {
+			continue
+		}
+		data.Metrics = append(data.Metrics, newMetric(models.MetricWeight, w.Weight, at))
+		if w.Fat != nil && *w.Fat > 0 {
+			data.Metrics = append(data.Metrics, newMetric(models.MetricBodyFat, *w.Fat, at))
+		}
+	}
+
+	var sleep struct {
+		Sleep []struct {
+			StartTime string `json:"startTime"`
+			EndTime   string `json:"endTime"`
+		} `json:"sleep"`
+	}
+	if err := c.get(ctx, "/1.2/user/-/sleep/date/"+span+".json", &sleep); err != nil {
+		return nil, err
+	}
+	for _, s := range sleep.Sleep {
+		bed, err1 := time.ParseInLocation("2006-01-02T15:04:05.000", s.StartTime, loc)
+		wake, err2 := time.ParseInLocation("2006-01-02T15:04:05.000", s.EndTime, loc)
+		if err1 != nil || err2 != nil || !wake.After(bed) {
+			continue
+		}
+		data.Sleep = append(data.Sleep, models.NewSleepSession(bed, wake))
+	}
+
+	return data, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fitbit: %w", err)
+	}
+	defer resp.Body.Close()
+	slog.DebugContext(ctx, "fitbit get", "path", path, "status", resp.StatusCode, "duration", time.Since(start))
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("fitbit: token rejected (expired or missing a scope?)")
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("fitbit: rate limited, try again in %s seconds", cmp.Or(resp.Header.Get("Retry-After"), "a few"))
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("fitbit: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("fitbit: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// endOfDay returns 23:59 on the YYYY-MM-DD date in loc.
+func endOfDay(date string, loc *time.Location) (time.Time, bool) {
+	d, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d.Add(23*time.Hour + 59*time.Minute), true
+}
+
+func newMetric(mt models.MetricType, value float64, at time.Time) *models.Metric {
+	return models.NewMetric(mt, value).WithRecordedAt(at).WithSource(models.SourceFitbit)
+}
@@ -0,0 +1,99 @@
+// ABOUTME: Tests for the Fitbit client against canned Web API responses.
+// ABOUTME: Checks the mapping to metrics and sleep sessions and error reporting.
+package fitbit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// fakeFitbit serves canned responses for two days, 2025-03-01 and 02.
+func fakeFitbit(t *testing.T) *httptest.Server {
+	t.Helper()
+	responses := map[string]string{
+		"/1/user/-/activities/steps/date/2025-03-01/2025-03-02.json": `{"activities-steps":[
+			{"dateTime":"2025-03-01","value":"8234"},{"dateTime":"2025-03-02","value":"0"}]}`,
+		"/1/user/-/activities/heart/date/2025-03-01/2025-03-02.json": `{"activities-heart":[
+			{"dateTime":"2025-03-01","value":{"restingHeartRate":58}},{"dateTime":"2025-03-02","value":{}}]}`,
+		"/1/user/-/body/log/weight/date/2025-03-01/2025-03-02.json": `{"weight":[
+			{"date":"2025-03-02","time":"07:15:00","weight":82.4,"fat":18.5,"bmi":24.1}]}`,
+		"/1.2/user/-/sleep/date/2025-03-01/2025-03-02.json": `{"sleep":[
+			{"dateOfSleep":"2025-03-02","startTime":"2025-03-01T23:10:00.000","endTime":"2025-03-02T06:55:30.000","isMainSleep":true}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	srv := fakeFitbit(t)
+	c := NewClient("tok")
+	c.BaseURL = srv.URL
+
+	loc := time.FixedZone("CST", -6*3600)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, loc)
+	data, err := c.Fetch(t.Context(), from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+
+	got := map[models.MetricType]*models.Metric{}
+	for _, m := range data.Metrics {
+		got[m.MetricType] = m
+	}
+	if len(data.Metrics) != 4 {
+		t.Errorf("got %d metrics, want steps, heart_rate, weight, body_fat", len(data.Metrics))
+	}
+	if m := got[models.MetricSteps]; m == nil || m.Value != 8234 ||
+		!m.RecordedAt.Equal(time.Date(2025, 3, 1, 23, 59, 0, 0, loc)) || m.Source != models.SourceFitbit || m.Notes != nil {
+		t.Errorf("steps = %+v", m)
+	}
+	if m := got[models.MetricHeartRate]; m == nil || m.Value != 58 || !strings.Contains(*m.Notes, "resting") {
+		t.Errorf("heart_rate = %+v", m)
+	}
+	weighed := time.Date(2025, 3, 2, 7, 15, 0, 0, loc)
+	if m := got[models.MetricWeight]; m == nil || m.Value != 82.4 || !m.RecordedAt.Equal(weighed) {
+		t.Errorf("weight = %+v", m)
+	}
+	if m := got[models.MetricBodyFat]; m == nil || m.Value != 18.5 || !m.RecordedAt.Equal(weighed) {
+		t.Errorf("body_fat = %+v", m)
+	}
+
+	if len(data.Sleep) != 1 {
+		t.Fatalf("got %d sleep sessions, want 1", len(data.Sleep))
+	}
+	s := data.Sleep[0]
+	if !s.BedTime.Equal(time.Date(2025, 3, 1, 23, 10, 0, 0, loc)) || !s.WakeTime.Equal(time.Date(2025, 3, 2, 6, 55, 30, 0, loc)) {
+		t.Errorf("sleep = %v → %v", s.BedTime, s.WakeTime)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	srv := fakeFitbit(t)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewClient("wrong")
+	c.BaseURL = srv.URL
+	if _, err := c.Fetch(t.Context(), from, from.AddDate(0, 0, 1)); err == nil || !strings.Contains(err.Error(), "token rejected") {
+		t.Errorf("expected a rejected token, got %v", err)
+	}
+	if _, err := c.Fetch(t.Context(), from, from.AddDate(0, 0, MaxDays)); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("expected an oversized range to be refused, got %v", err)
+	}
+}
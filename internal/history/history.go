@@ -0,0 +1,87 @@
+// ABOUTME: Short local history of add commands, so 'health again' can repeat the last one.
+// ABOUTME: Kept as history.json in the data dir, trimmed to the most recent entries.
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the history file inside the data directory.
+const FileName = "history.json"
+
+// MaxEntries is how many commands are kept.
+const MaxEntries = 20
+
+// Entry is one command as it ran: the subcommand path below the root, its
+// positional arguments, and the flags that were set.
+type Entry struct {
+	Command string              `json:"command"`
+	Args    []string            `json:"args"`
+	Flags   map[string][]string `json:"flags,omitempty"`
+	At      time.Time           `json:"at"`
+}
+
+// Path returns the history file path for a data directory.
+func Path(dataDir string) string {
+	return filepath.Join(dataDir, FileName)
+}
+
+// Load reads the history at path, oldest first. A missing file is an
+// empty history.
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse history: %w", err)
+	}
+	return entries, nil
+}
+
+// Last returns the most recent entry, or nil if there is none.
+func Last(path string) (*Entry, error) {
+	entries, err := Load(path)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[len(entries)-1], nil
+}
+
+// Record appends e to the history at path, dropping the oldest entries
+// past MaxEntries. A history that no longer parses is started afresh.
+func Record(path string, e Entry) error {
+	entries, err := Load(path)
+	if err != nil {
+		entries = nil
+	}
+	entries = append(entries, e)
+	if len(entries) > MaxEntries {
+		entries = entries[len(entries)-MaxEntries:]
+	}
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("create history dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write history: %w", err)
+	}
+	return nil
+}
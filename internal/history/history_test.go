@@ -0,0 +1,54 @@
+// ABOUTME: Tests for the add-command history behind 'health again'.
+// ABOUTME: Covers recording, trimming to MaxEntries, and recovery from a damaged file.
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLast(t *testing.T) {
+	path := Path(t.TempDir())
+
+	if e, err := Last(path); e != nil || err != nil {
+		t.Fatalf("Last on empty history = %v, %v; want nil, nil", e, err)
+	}
+
+	for i := 0; i < MaxEntries+5; i++ {
+		err := Record(path, Entry{Command: "add", Args: []string{"weight", strconv.Itoa(80 + i)}, At: time.Now()})
+		if err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	entries, err := Load(path)
+	if err != nil || len(entries) != MaxEntries {
+		t.Fatalf("Load = %d entries, %v; want %d", len(entries), err, MaxEntries)
+	}
+	if entries[0].Args[1] != "85" {
+		t.Errorf("oldest kept = %v, want the first five dropped", entries[0].Args)
+	}
+
+	Record(path, Entry{Command: "workout add", Args: []string{"run"}, Flags: map[string][]string{"metric": {"distance=5km", "avg_hr=150"}}})
+	last, err := Last(path)
+	if err != nil || last.Command != "workout add" || len(last.Flags["metric"]) != 2 {
+		t.Errorf("Last = %+v, %v", last, err)
+	}
+}
+
+func TestRecordReplacesDamagedHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	os.WriteFile(path, []byte("{not json"), 0600)
+
+	if _, err := Load(path); err == nil {
+		t.Error("Expected Load to report a damaged history")
+	}
+	if err := Record(path, Entry{Command: "add", Args: []string{"mood", "7"}}); err != nil {
+		t.Fatalf("Record over damaged history failed: %v", err)
+	}
+	if last, _ := Last(path); last == nil || last.Args[1] != "7" {
+		t.Errorf("Last = %+v", last)
+	}
+}
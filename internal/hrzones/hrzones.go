@@ -0,0 +1,130 @@
+// ABOUTME: Heart rate training zones and time-in-zone estimates for workouts.
+// ABOUTME: Spreads a workout's minutes across zones from its average and max heart rate.
+package hrzones
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// Workout metric names holding a workout's average and peak heart rate.
+const (
+	AvgMetric = "avg_hr"
+	MaxMetric = "max_hr"
+)
+
+// Count is the number of zones.
+const Count = 5
+
+// defaultShares are the fractions of max heart rate where zones 1-5 start,
+// the common 50/60/70/80/90% scheme.
+var defaultShares = [Count]float64{0.5, 0.6, 0.7, 0.8, 0.9}
+
+// Zones holds the heart rate in bpm where each of zones 1-5 starts.
+type Zones [Count]float64
+
+// FromMaxHR derives zones as 50, 60, 70, 80, and 90% of max heart rate.
+func FromMaxHR(maxHR float64) Zones {
+	var z Zones
+	for i, share := range defaultShares {
+		z[i] = math.Round(maxHR * share)
+	}
+	return z
+}
+
+// Parse reads five ascending lower bounds in bpm, e.g.
+// "100,120,140,160,175".
+func Parse(s string) (Zones, error) {
+	var z Zones
+	parts := strings.Split(s, ",")
+	if len(parts) != Count {
+		return z, fmt.Errorf("need %d zone bounds, got %d (e.g. 100,120,140,160,175)", Count, len(parts))
+	}
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || v <= 0 {
+			return z, fmt.Errorf("invalid zone bound: %q", p)
+		}
+		if i > 0 && v <= z[i-1] {
+			return z, fmt.Errorf("zone bounds must be ascending: %g after %g", v, z[i-1])
+		}
+		z[i] = v
+	}
+	return z, nil
+}
+
+// String formats the bounds the way Parse reads them.
+func (z Zones) String() string {
+	parts := make([]string, Count)
+	for i, v := range z {
+		parts[i] = strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
+
+// Zone returns the zone (1-5) a heart rate falls in. Rates below zone 1
+// count as zone 1.
+func (z Zones) Zone(hr float64) int {
+	for i := Count - 1; i > 0; i-- {
+		if hr >= z[i] {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// Estimate spreads minutes across the zones, in minutes per zone. Heart
+// rate is assumed to be normally distributed around avg, with the peak
+// two standard deviations above it and nothing beyond the peak. Without a
+// usable peak (zero, or not above avg), all the time goes to avg's zone.
+func Estimate(z Zones, avg, peak, minutes float64) [Count]float64 {
+	var out [Count]float64
+	if minutes <= 0 || avg <= 0 {
+		return out
+	}
+	if peak <= avg {
+		out[z.Zone(avg)-1] = minutes
+		return out
+	}
+
+	sd := (peak - avg) / 2
+	cdf := func(x float64) float64 {
+		return 0.5 * math.Erfc(-(x-avg)/(sd*math.Sqrt2))
+	}
+	total := cdf(peak)
+	lower := 0.0
+	for i := range Count {
+		upper := total
+		if i < Count-1 {
+			upper = min(cdf(z[i+1]), total)
+		}
+		if upper > lower {
+			out[i] = minutes * (upper - lower) / total
+			lower = upper
+		}
+	}
+	return out
+}
+
+// ForWorkout estimates the workout's minutes in each zone from its avg_hr
+// and max_hr metrics. It reports false when the workout has no duration
+// or no avg_hr.
+func ForWorkout(z Zones, w *models.Workout) ([Count]float64, bool) {
+	var avg, peak float64
+	for _, m := range w.Metrics {
+		switch m.MetricName {
+		case AvgMetric:
+			avg = m.Value
+		case MaxMetric:
+			peak = m.Value
+		}
+	}
+	if w.DurationMinutes == nil || *w.DurationMinutes <= 0 || avg <= 0 {
+		return [Count]float64{}, false
+	}
+	return Estimate(z, avg, peak, float64(*w.DurationMinutes)), true
+}
@@ -0,0 +1,103 @@
+// ABOUTME: Tests for heart rate zones and time-in-zone estimates.
+// ABOUTME: Covers parsing, zone lookup, and how minutes are spread across zones.
+package hrzones
+
+import (
+	"math"
+	"testing"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func TestFromMaxHR(t *testing.T) {
+	z := FromMaxHR(190)
+	want := Zones{95, 114, 133, 152, 171}
+	if z != want {
+		t.Errorf("FromMaxHR(190) = %v, want %v", z, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	z, err := Parse("100, 120,140,160,175")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if z.String() != "100,120,140,160,175" {
+		t.Errorf("String() = %q", z.String())
+	}
+
+	for _, bad := range []string{"100,120,140", "100,120,140,160,abc", "100,120,120,160,175", "0,120,140,160,175"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) should fail", bad)
+		}
+	}
+}
+
+func TestZone(t *testing.T) {
+	z := Zones{100, 120, 140, 160, 175}
+	tests := map[float64]int{80: 1, 100: 1, 119: 1, 120: 2, 150: 3, 160: 4, 175: 5, 200: 5}
+	for hr, want := range tests {
+		if got := z.Zone(hr); got != want {
+			t.Errorf("Zone(%g) = %d, want %d", hr, got, want)
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	z := Zones{100, 120, 140, 160, 175}
+
+	t.Run("spreads around the average", func(t *testing.T) {
+		got := Estimate(z, 145, 175, 60)
+		sum := 0.0
+		for _, m := range got {
+			sum += m
+		}
+		if math.Abs(sum-60) > 1e-9 {
+			t.Errorf("minutes sum to %g, want 60", sum)
+		}
+		if got[2] <= got[1] || got[3] <= got[4] {
+			t.Errorf("expected most time near zones 3-4, got %v", got)
+		}
+		if got[4] != 0 {
+			t.Errorf("nothing should land at or above the peak, got %g in zone 5", got[4])
+		}
+	})
+
+	t.Run("no peak puts everything in the average's zone", func(t *testing.T) {
+		got := Estimate(z, 150, 0, 40)
+		if got != [Count]float64{0, 0, 40, 0, 0} {
+			t.Errorf("Estimate = %v", got)
+		}
+	})
+
+	t.Run("no time", func(t *testing.T) {
+		if got := Estimate(z, 150, 170, 0); got != [Count]float64{} {
+			t.Errorf("Estimate = %v", got)
+		}
+	})
+}
+
+func TestForWorkout(t *testing.T) {
+	z := Zones{100, 120, 140, 160, 175}
+	w := models.NewWorkout("run").WithDuration(30)
+	if _, ok := ForWorkout(z, w); ok {
+		t.Error("expected no estimate without avg_hr")
+	}
+
+	w.Metrics = []models.WorkoutMetric{
+		*models.NewWorkoutMetric(w.ID, AvgMetric, 150, "bpm"),
+		*models.NewWorkoutMetric(w.ID, MaxMetric, 172, "bpm"),
+	}
+	got, ok := ForWorkout(z, w)
+	if !ok {
+		t.Fatal("expected an estimate")
+	}
+	if got != Estimate(z, 150, 172, 30) {
+		t.Errorf("ForWorkout = %v", got)
+	}
+
+	w.DurationMinutes = nil
+	if _, ok := ForWorkout(z, w); ok {
+		t.Error("expected no estimate without a duration")
+	}
+}
@@ -0,0 +1,142 @@
+// ABOUTME: Minimal InfluxDB client writing points as line protocol to the v2 write API.
+// ABOUTME: Works with InfluxDB 2.x/3.x and with 1.8+ through its /api/v2/write compatibility endpoint.
+package influx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BatchSize is how many points Write sends per request.
+const BatchSize = 5000
+
+// Point is one line of line protocol. Tags with empty values are left out,
+// since line protocol cannot express them.
+type Point struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      map[string]float64
+	Time        time.Time
+}
+
+// Line encodes p as line protocol with second precision, fields and tags
+// sorted by key.
+func (p Point) Line() string {
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(p.Measurement))
+	for _, k := range sortedKeys(p.Tags) {
+		if p.Tags[k] == "" {
+			continue
+		}
+		b.WriteByte(',')
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(keyEscaper.Replace(p.Tags[k]))
+	}
+	for i, k := range sortedKeys(p.Fields) {
+		if i == 0 {
+			b.WriteByte(' ')
+		} else {
+			b.WriteByte(',')
+		}
+		b.WriteString(keyEscaper.Replace(k))
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatFloat(p.Fields[k], 'f', -1, 64))
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(p.Time.Unix(), 10))
+	return b.String()
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\n`)
+	keyEscaper         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\n`)
+)
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Client writes to one bucket.
+type Client struct {
+	URL        string
+	Org        string
+	Bucket     string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client for the server at url (e.g.
+// http://localhost:8086).
+func NewClient(url, org, bucket, token string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/"),
+		Org:        org,
+		Bucket:     bucket,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Write sends points in batches of BatchSize. Points without fields are
+// skipped. Rewriting a point with the same measurement, tags, and time
+// replaces it, so sending the same data again is harmless.
+func (c *Client) Write(ctx context.Context, points []Point) error {
+	var lines []string
+	for _, p := range points {
+		if len(p.Fields) > 0 {
+			lines = append(lines, p.Line())
+		}
+	}
+	for start := 0; start < len(lines); start += BatchSize {
+		end := min(start+BatchSize, len(lines))
+		if err := c.write(ctx, strings.Join(lines[start:end], "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) write(ctx context.Context, body string) error {
+	q := url.Values{"bucket": {c.Bucket}, "precision": {"s"}}
+	if c.Org != "" {
+		q.Set("org", c.Org)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL+"/api/v2/write?"+q.Encode(), bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Token "+c.Token)
+	}
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("influx: %w", err)
+	}
+	defer resp.Body.Close()
+	slog.DebugContext(ctx, "influx write", "url", req.URL.Redacted(), "status", resp.StatusCode,
+		"bytes", len(body), "duration", time.Since(start))
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("influx: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
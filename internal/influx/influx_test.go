@@ -0,0 +1,80 @@
+// ABOUTME: Tests for the InfluxDB line protocol encoder and write client.
+// ABOUTME: Uses an httptest server standing in for the v2 write API.
+package influx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPointLine(t *testing.T) {
+	p := Point{
+		Measurement: "health metric",
+		Tags:        map[string]string{"type": "weight", "location": "my gym, downtown", "empty": ""},
+		Fields:      map[string]float64{"value": 82.5, "a=b": 80},
+		Time:        time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC),
+	}
+	want := `health\ metric,location=my\ gym\,\ downtown,type=weight a\=b=80,value=82.5 1741590000`
+	if got := p.Line(); got != want {
+		t.Errorf("Line() = %s\nwant     %s", got, want)
+	}
+}
+
+func TestClientWrite(t *testing.T) {
+	var requests []*http.Request
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, r)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/", "home", "health", "secret")
+	at := time.Unix(1700000000, 0)
+	points := make([]Point, BatchSize+1)
+	for i := range points {
+		points[i] = Point{Measurement: "m", Fields: map[string]float64{"value": float64(i)}, Time: at}
+	}
+	points = append(points, Point{Measurement: "no_fields", Time: at})
+	if err := c.Write(t.Context(), points); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2 batches", len(requests))
+	}
+	r := requests[0]
+	if r.URL.Path != "/api/v2/write" || r.URL.Query().Get("bucket") != "health" ||
+		r.URL.Query().Get("org") != "home" || r.URL.Query().Get("precision") != "s" {
+		t.Errorf("request URL = %s", r.URL)
+	}
+	if r.Header.Get("Authorization") != "Token secret" {
+		t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+	}
+	if n := strings.Count(bodies[0], "\n"); n != BatchSize {
+		t.Errorf("first batch has %d lines, want %d", n, BatchSize)
+	}
+	if bodies[1] != "m value=5000 1700000000\n" || strings.Contains(bodies[1], "no_fields") {
+		t.Errorf("second batch = %q", bodies[1])
+	}
+}
+
+func TestClientWriteError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":"not found","message":"bucket \"health\" not found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	err := NewClient(srv.URL, "", "health", "").Write(t.Context(), []Point{
+		{Measurement: "m", Fields: map[string]float64{"value": 1}, Time: time.Now()},
+	})
+	if err == nil || !strings.Contains(err.Error(), "404") || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("expected the server's error, got %v", err)
+	}
+}
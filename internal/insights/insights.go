@@ -0,0 +1,254 @@
+// ABOUTME: Evaluates insight rules against stored metrics and reports what they observe.
+// ABOUTME: Metrics are reduced to daily values (totals for cumulative types, means otherwise) first.
+package insights
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// Observation is a rule that fired, with the numbers behind it.
+type Observation struct {
+	Rule    string    `json:"rule"`
+	Metric  string    `json:"metric"`
+	Message string    `json:"message"`
+	Values  []float64 `json:"values"` // matching daily values, the average, weekly averages, or recent days then the baseline
+}
+
+// Evaluate runs every rule against the metrics in r as of now, in rule
+// order. Rules without enough data stay quiet.
+func Evaluate(ctx context.Context, r storage.Repository, rules []Rule, now time.Time) ([]Observation, error) {
+	var observations []Observation
+	for _, rule := range rules {
+		mt := models.MetricType(rule.Metric)
+		since := startOfDay(now).AddDate(0, 0, -(rule.Days - 1))
+		switch rule.Kind {
+		case KindTrend:
+			since = now.AddDate(0, 0, -7*(rule.Weeks+1))
+		case KindBaseline:
+			since = since.AddDate(0, 0, -rule.BaselineDays)
+		}
+		until := now.Add(time.Second)
+		metrics, err := r.QueryMetrics(ctx, storage.MetricFilter{Type: &mt, Since: &since, Until: &until})
+		if err != nil {
+			return nil, fmt.Errorf("insight %s: %w", rule.Name, err)
+		}
+
+		var obs *Observation
+		switch rule.Kind {
+		case KindDays:
+			obs = rule.days(dailyValues(metrics, mt, now.Location()))
+		case KindAverage:
+			obs = rule.average(dailyValues(metrics, mt, now.Location()))
+		case KindTrend:
+			obs = rule.trend(weeklyAverages(metrics, now, rule.Weeks+1))
+		case KindBaseline:
+			recentStart := startOfDay(now).AddDate(0, 0, -(rule.Days - 1))
+			var recent, before []*models.Metric
+			for _, m := range metrics {
+				if m.RecordedAt.Before(recentStart) {
+					before = append(before, m)
+				} else {
+					recent = append(recent, m)
+				}
+			}
+			obs = rule.baseline(dailyValues(recent, mt, now.Location()), dailyValues(before, mt, now.Location()))
+		}
+		if obs == nil {
+			continue
+		}
+		obs.Rule, obs.Metric = rule.Name, rule.Metric
+		if rule.Message != "" {
+			obs.Message = rule.Message
+		}
+		if rule.Note != "" {
+			obs.Message += " (" + rule.Note + ")"
+		}
+		observations = append(observations, *obs)
+	}
+	return observations, nil
+}
+
+func (r Rule) days(daily []float64) *Observation {
+	var hits []float64
+	for _, v := range daily {
+		if r.crosses(v) {
+			hits = append(hits, v)
+		}
+	}
+	if len(hits) == 0 || len(hits) < r.AtLeast {
+		return nil
+	}
+	return &Observation{
+		Message: fmt.Sprintf("%s %s on %d of the last %d days", r.Metric, r.threshold(), len(hits), r.Days),
+		Values:  hits,
+	}
+}
+
+func (r Rule) average(daily []float64) *Observation {
+	if len(daily) == 0 {
+		return nil
+	}
+	avg := mean(daily)
+	if !r.crosses(avg) {
+		return nil
+	}
+	return &Observation{
+		Message: fmt.Sprintf("%s averaged %s over the last %d days, %s", r.Metric, format(avg), r.Days, r.threshold()),
+		Values:  []float64{avg},
+	}
+}
+
+func (r Rule) trend(weekly []float64) *Observation {
+	if len(weekly) < r.Weeks+1 {
+		return nil
+	}
+	for i := 1; i < len(weekly); i++ {
+		rising := weekly[i] > weekly[i-1]
+		falling := weekly[i] < weekly[i-1]
+		if (r.Direction == "up" && !rising) || (r.Direction == "down" && !falling) {
+			return nil
+		}
+	}
+	parts := make([]string, len(weekly))
+	for i, v := range weekly {
+		parts[i] = format(v)
+	}
+	unit := ""
+	if u := models.MetricUnits[models.MetricType(r.Metric)]; u != "" {
+		unit = " " + u
+	}
+	return &Observation{
+		Message: fmt.Sprintf("%s trending %s %d weeks: %s%s", r.Metric, r.Direction, r.Weeks, strings.Join(parts, " → "), unit),
+		Values:  weekly,
+	}
+}
+
+// minBaselineDays is how many days of data a baseline needs to be trusted.
+const minBaselineDays = 7
+
+// baseline fires when every one of the last Days days is at least Percent
+// above (or below) the mean of the days before. Missing a recent day means
+// the shift isn't shown to be sustained, so the rule stays quiet.
+func (r Rule) baseline(recent, before []float64) *Observation {
+	if len(recent) < r.Days || len(before) < min(minBaselineDays, r.BaselineDays) {
+		return nil
+	}
+	base := mean(before)
+	if base == 0 {
+		return nil
+	}
+	for _, v := range recent {
+		change := (v - base) / math.Abs(base) * 100
+		if (r.Direction == "up" && change < r.Percent) || (r.Direction == "down" && change > -r.Percent) {
+			return nil
+		}
+	}
+
+	parts := make([]string, len(recent))
+	for i, v := range recent {
+		parts[i] = format(v)
+	}
+	unit := ""
+	if u := models.MetricUnits[models.MetricType(r.Metric)]; u != "" {
+		unit = " " + u
+	}
+	change := math.Abs(mean(recent)-base) / math.Abs(base) * 100
+	return &Observation{
+		Message: fmt.Sprintf("%s %s %.0f%% on its %d-day baseline of %s%s for %d days: %s",
+			r.Metric, r.Direction, change, r.BaselineDays, format(base), unit, r.Days, strings.Join(parts, ", ")),
+		Values: append(recent, base),
+	}
+}
+
+// crosses reports whether v is past the rule's threshold.
+func (r Rule) crosses(v float64) bool {
+	if r.Below != nil {
+		return v < *r.Below
+	}
+	return v > *r.Above
+}
+
+// threshold describes the rule's threshold, e.g. "under 6".
+func (r Rule) threshold() string {
+	if r.Below != nil {
+		return "under " + format(*r.Below)
+	}
+	return "over " + format(*r.Above)
+}
+
+// dailyValues reduces metrics to one value per calendar day: the day's
+// total for cumulative types, the mean otherwise. Days are in order.
+func dailyValues(metrics []*models.Metric, mt models.MetricType, loc *time.Location) []float64 {
+	var days []time.Time
+	byDay := map[time.Time][]float64{}
+	for i := len(metrics) - 1; i >= 0; i-- { // oldest first
+		day := startOfDay(metrics[i].RecordedAt.In(loc))
+		if _, ok := byDay[day]; !ok {
+			days = append(days, day)
+		}
+		byDay[day] = append(byDay[day], metrics[i].Value)
+	}
+
+	values := make([]float64, 0, len(days))
+	for _, day := range days {
+		if models.IsCumulative(mt) {
+			values = append(values, sum(byDay[day]))
+		} else {
+			values = append(values, mean(byDay[day]))
+		}
+	}
+	return values
+}
+
+// weeklyAverages returns the mean of each of the last n seven-day windows
+// ending at now, oldest first. It returns nil if any window is empty.
+func weeklyAverages(metrics []*models.Metric, now time.Time, n int) []float64 {
+	weeks := make([][]float64, n)
+	for _, m := range metrics {
+		age := now.Sub(m.RecordedAt)
+		if age < 0 {
+			continue
+		}
+		i := int(age / (7 * 24 * time.Hour))
+		if i < n {
+			weeks[n-1-i] = append(weeks[n-1-i], m.Value)
+		}
+	}
+	averages := make([]float64, n)
+	for i, w := range weeks {
+		if len(w) == 0 {
+			return nil
+		}
+		averages[i] = mean(w)
+	}
+	return averages
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func mean(values []float64) float64 {
+	return sum(values) / float64(len(values))
+}
+
+// format rounds to at most one decimal place.
+func format(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,222 @@
+// ABOUTME: Tests for insight rules: parsing the rules file and evaluating each kind.
+// ABOUTME: Seeds a temporary SQLite store with a few weeks of metrics.
+package insights
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// setupTestStore returns an empty in-memory store; the rules only read
+// through the Repository, so no backend's files are needed.
+func setupTestStore(t *testing.T) *storage.MemoryStore {
+	t.Helper()
+	return storage.NewMemoryStore()
+}
+
+func addMetric(t *testing.T, db storage.Repository, mt models.MetricType, value float64, at time.Time) {
+	t.Helper()
+	if err := db.CreateMetric(t.Context(), models.NewMetric(mt, value).WithRecordedAt(at)); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	rules, err := Parse([]byte(`
+rules:
+  - name: short-sleep
+    metric: sleep_hours
+    kind: days
+    below: 6
+    at_least: 4
+  - metric: weight
+    kind: trend
+    direction: down
+  - metric: hrv
+    kind: baseline
+    direction: down
+`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(rules) != 3 || rules[0].Days != 7 || rules[1].Weeks != 3 || rules[1].Name != "weight trend" ||
+		rules[2].Days != 3 || rules[2].BaselineDays != 28 || rules[2].Percent != 10 {
+		t.Errorf("rules = %+v; want defaults filled in", rules)
+	}
+
+	bad := []string{
+		"rules: [{metric: nope, kind: days, below: 1}]",
+		"rules: [{metric: weight, kind: days}]",
+		"rules: [{metric: weight, kind: days, below: 1, above: 2}]",
+		"rules: [{metric: weight, kind: days, below: 1, days: 3, at_least: 5}]",
+		"rules: [{metric: weight, kind: trend, direction: sideways}]",
+		"rules: [{metric: weight, kind: forecast}]",
+		"rules: [{metric: hrv, kind: baseline}]",
+		"rules: [{metric: hrv, kind: baseline, direction: down, percent: -5}]",
+	}
+	for _, src := range bad {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("Parse(%s) succeeded; want error", src)
+		}
+	}
+
+	if rules, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err != nil || len(rules) != len(DefaultRules) {
+		t.Errorf("Load of a missing file = %d rules, %v; want the defaults", len(rules), err)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	db := setupTestStore(t)
+	now := time.Date(2025, 3, 20, 21, 0, 0, 0, time.Local)
+
+	// Sleep under 6h on 4 of the last 7 nights
+	for i, h := range []float64{7.5, 5.5, 5, 8, 5.8, 7, 4.9} {
+		addMetric(t, db, models.MetricSleepHours, h, now.AddDate(0, 0, -i).Add(-12*time.Hour))
+	}
+	// Systolic rising every week for a month
+	for week, v := range []float64{130, 126, 122, 118} {
+		at := now.AddDate(0, 0, -7*week-2)
+		addMetric(t, db, models.MetricBPSys, v, at)
+		addMetric(t, db, models.MetricBPSys, v+2, at.Add(-24*time.Hour))
+	}
+	// Two entries in a day average out for steps
+	addMetric(t, db, models.MetricSteps, 3000, now.Add(-3*time.Hour))
+	addMetric(t, db, models.MetricSteps, 4000, now.Add(-2*time.Hour))
+	addMetric(t, db, models.MetricSteps, 5500, now.AddDate(0, 0, -1))
+	// ...but add up for cumulative water: 2500 ml today
+	addMetric(t, db, models.MetricWater, 1000, now.Add(-3*time.Hour))
+	addMetric(t, db, models.MetricWater, 1500, now.Add(-2*time.Hour))
+
+	observations, err := Evaluate(t.Context(), db, DefaultRules, now)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	byRule := map[string]Observation{}
+	for _, o := range observations {
+		byRule[o.Rule] = o
+	}
+
+	if o, ok := byRule["short-sleep"]; !ok || o.Message != "sleep_hours under 6 on 4 of the last 7 days" {
+		t.Errorf("short-sleep = %+v", o)
+	}
+	if o, ok := byRule["bp-rising"]; !ok || !strings.HasPrefix(o.Message, "bp_sys trending up 3 weeks: 119 → 123 → 127 → 131") {
+		t.Errorf("bp-rising = %+v", o)
+	}
+	if o, ok := byRule["low-steps"]; !ok || o.Values[0] != 4500 {
+		t.Errorf("low-steps = %+v; want an average of 4500 over two days", o)
+	}
+
+	// A break in the trend or a good week keeps rules quiet
+	addMetric(t, db, models.MetricBPSys, 100, now.AddDate(0, 0, -9))
+	quiet := []Rule{
+		{Name: "bp", Metric: "bp_sys", Kind: KindTrend, Direction: "up", Weeks: 3},
+		{Name: "sleep", Metric: "sleep_hours", Kind: KindDays, Below: floatPtr(6), AtLeast: 5, Days: 7},
+		{Name: "heart", Metric: "heart_rate", Kind: KindAverage, Above: floatPtr(90), Days: 7},
+		{Name: "water", Metric: "water", Kind: KindDays, Below: floatPtr(2000), Days: 1},
+	}
+	if observations, err := Evaluate(t.Context(), db, quiet, now); err != nil || len(observations) != 0 {
+		t.Errorf("Evaluate = %+v, %v; want nothing", observations, err)
+	}
+
+	custom := []Rule{{Name: "sleep", Metric: "sleep_hours", Kind: KindDays, Below: floatPtr(6), AtLeast: 2, Days: 7, Message: "Rough week for sleep"}}
+	if observations, _ := Evaluate(t.Context(), db, custom, now); len(observations) != 1 || observations[0].Message != "Rough week for sleep" {
+		t.Errorf("custom message = %+v", observations)
+	}
+}
+
+func TestEvaluateBaseline(t *testing.T) {
+	db := setupTestStore(t)
+	now := time.Date(2025, 3, 20, 21, 0, 0, 0, time.Local)
+
+	// Four weeks around 50 bpm and 60 ms, then three days of 55+ bpm and
+	// HRV down to 45 ms
+	for i := 3; i < 31; i++ {
+		at := now.AddDate(0, 0, -i).Add(-14 * time.Hour)
+		addMetric(t, db, models.MetricHeartRate, 49+float64(i%3), at)
+		addMetric(t, db, models.MetricHRV, 60, at)
+	}
+	for i, v := range []float64{56, 55, 57} {
+		at := now.AddDate(0, 0, -i).Add(-14 * time.Hour)
+		addMetric(t, db, models.MetricHeartRate, v, at)
+		addMetric(t, db, models.MetricHRV, 45, at)
+	}
+
+	observations, err := Evaluate(t.Context(), db, DefaultRules, now)
+	if err != nil {
+		t.Fatalf("Evaluate failed: %v", err)
+	}
+	byRule := map[string]Observation{}
+	for _, o := range observations {
+		byRule[o.Rule] = o
+	}
+	want := "heart_rate up 12% on its 28-day baseline of 50 bpm for 3 days: 57, 55, 56 (possible illness, stress, or overtraining)"
+	if o := byRule["elevated-resting-hr"]; o.Message != want {
+		t.Errorf("elevated-resting-hr = %q, want %q", o.Message, want)
+	}
+	if o, ok := byRule["suppressed-hrv"]; !ok || !strings.HasPrefix(o.Message, "hrv down 25% on its 28-day baseline of 60 ms") {
+		t.Errorf("suppressed-hrv = %+v", o)
+	}
+
+	// One ordinary day breaks a sustained shift
+	addMetric(t, db, models.MetricHeartRate, 40, now.AddDate(0, 0, -1).Add(-13*time.Hour))
+	quiet := []Rule{
+		{Name: "hr", Metric: "heart_rate", Kind: KindBaseline, Direction: "up", Percent: 8, Days: 3, BaselineDays: 28},
+		{Name: "hrv", Metric: "hrv", Kind: KindBaseline, Direction: "down", Percent: 30, Days: 3, BaselineDays: 28},
+		{Name: "weight", Metric: "weight", Kind: KindBaseline, Direction: "up", Percent: 5, Days: 3, BaselineDays: 28},
+	}
+	if observations, err := Evaluate(t.Context(), db, quiet, now); err != nil || len(observations) != 0 {
+		t.Errorf("Evaluate = %+v, %v; want nothing", observations, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	db := setupTestStore(t)
+	ctx := t.Context()
+	now := time.Date(2025, 3, 20, 21, 0, 0, 0, time.Local)
+
+	if lines, err := Summarize(ctx, db, now, 30, nil); err != nil || len(lines) != 0 {
+		t.Errorf("empty Summarize = %q, %v; want nothing", lines, err)
+	}
+
+	addMetric(t, db, models.MetricWeight, 84, now.AddDate(0, 0, -20))
+	addMetric(t, db, models.MetricWeight, 83.1, now.AddDate(0, 0, -10))
+	addMetric(t, db, models.MetricWeight, 82.8, now.AddDate(0, 0, -1))
+	addMetric(t, db, models.MetricWeight, 90, now.AddDate(0, 0, -40)) // before the window
+	for i, h := range []float64{6, 6.5, 6.7} {
+		addMetric(t, db, models.MetricSleepHours, h, now.AddDate(0, 0, -i).Add(-12*time.Hour))
+	}
+	addMetric(t, db, models.MetricBPSys, 120, now.AddDate(0, 0, -2))
+	addMetric(t, db, models.MetricBPDia, 80, now.AddDate(0, 0, -2))
+	addMetric(t, db, models.MetricMood, 7, now.AddDate(0, 0, -2))
+	for _, ago := range []int{8, 12, 15} {
+		w := models.NewWorkout("run").WithDuration(30)
+		w.StartedAt = now.AddDate(0, 0, -ago)
+		db.CreateWorkout(ctx, w)
+	}
+	lift := models.NewWorkout("lift").WithDuration(45)
+	lift.StartedAt = now.AddDate(0, 0, -25)
+	db.CreateWorkout(ctx, lift)
+
+	goals := map[string]float64{"sleep_hours": 7, "weight": 80, "steps": 8000}
+	lines, err := Summarize(ctx, db, now, 30, goals)
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	want := []string{
+		"weight down 1.2 kg over 30 days (84 → 82.8 kg), 2.8 kg from your 80 kg goal",
+		"blood pressure averaging 120/80 mmHg",
+		"sleep_hours averaging 6.4 hours on 3 days logged, below your 7 hours goal",
+		"no steps logged against your 8000 goal",
+		"mood averaging 7/10 on 1 day logged",
+		"4 workouts in 30 days, 135 min in all, mostly run",
+		"no workouts in 8 days",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Summarize =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,154 @@
+// ABOUTME: Declarative insight rules, loaded from a YAML rules file.
+// ABOUTME: Each rule names a metric and a check: days over a threshold, a trend, an average, or a baseline shift.
+package insights
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// Rule kinds.
+const (
+	KindDays     = "days"     // at least AtLeast of the last Days days cross the threshold
+	KindTrend    = "trend"    // weekly averages rise (or fall) Weeks weeks in a row
+	KindAverage  = "average"  // the average over the last Days days crosses the threshold
+	KindBaseline = "baseline" // each of the last Days days is Percent above (or below) the BaselineDays before
+)
+
+// Rule is one check from the rules file. Below and Above are thresholds
+// for the days and average kinds; Direction ("up" or "down") and Weeks are
+// for trend. Baseline uses Direction, Percent, Days, and BaselineDays.
+// Message replaces the generated text when the rule fires, and Note is
+// appended to it.
+type Rule struct {
+	Name         string   `yaml:"name"`
+	Metric       string   `yaml:"metric"`
+	Kind         string   `yaml:"kind"`
+	Below        *float64 `yaml:"below,omitempty"`
+	Above        *float64 `yaml:"above,omitempty"`
+	AtLeast      int      `yaml:"at_least,omitempty"`
+	Days         int      `yaml:"days,omitempty"`
+	Direction    string   `yaml:"direction,omitempty"`
+	Weeks        int      `yaml:"weeks,omitempty"`
+	Percent      float64  `yaml:"percent,omitempty"`
+	BaselineDays int      `yaml:"baseline_days,omitempty"`
+	Message      string   `yaml:"message,omitempty"`
+	Note         string   `yaml:"note,omitempty"`
+}
+
+// File is the layout of the rules file.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// DefaultRules are used when there is no rules file.
+var DefaultRules = []Rule{
+	{Name: "short-sleep", Metric: "sleep_hours", Kind: KindDays, Below: floatPtr(6), AtLeast: 4, Days: 7},
+	{Name: "bp-rising", Metric: "bp_sys", Kind: KindTrend, Direction: "up", Weeks: 3},
+	{Name: "low-steps", Metric: "steps", Kind: KindAverage, Below: floatPtr(5000), Days: 7},
+	{Name: "elevated-resting-hr", Metric: "heart_rate", Kind: KindBaseline, Direction: "up", Percent: 8, Days: 3, BaselineDays: 28,
+		Note: "possible illness, stress, or overtraining"},
+	{Name: "suppressed-hrv", Metric: "hrv", Kind: KindBaseline, Direction: "down", Percent: 15, Days: 3, BaselineDays: 28,
+		Note: "possible illness, stress, or overtraining"},
+}
+
+// Load reads and validates the rules file at path. A missing file gives
+// DefaultRules.
+func Load(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return DefaultRules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read insight rules: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a rules file.
+func Parse(data []byte) ([]Rule, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse insight rules: %w", err)
+	}
+	for i := range f.Rules {
+		if err := f.Rules[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return f.Rules, nil
+}
+
+// validate checks the rule and fills in default windows.
+func (r *Rule) validate() error {
+	name := r.Name
+	if name == "" {
+		name = r.Metric + " " + r.Kind
+	}
+	if !models.IsValidMetricType(r.Metric) {
+		return fmt.Errorf("insight rule %q: unknown metric %q", name, r.Metric)
+	}
+
+	switch r.Kind {
+	case KindDays, KindAverage:
+		if (r.Below == nil) == (r.Above == nil) {
+			return fmt.Errorf("insight rule %q: set exactly one of below or above", name)
+		}
+		if r.Days == 0 {
+			r.Days = 7
+		}
+		if r.Days < 1 {
+			return fmt.Errorf("insight rule %q: days must be positive", name)
+		}
+		if r.Kind == KindDays {
+			if r.AtLeast == 0 {
+				r.AtLeast = 1
+			}
+			if r.AtLeast < 1 || r.AtLeast > r.Days {
+				return fmt.Errorf("insight rule %q: at_least must be between 1 and days", name)
+			}
+		}
+	case KindTrend:
+		if r.Direction != "up" && r.Direction != "down" {
+			return fmt.Errorf("insight rule %q: direction must be up or down", name)
+		}
+		if r.Weeks == 0 {
+			r.Weeks = 3
+		}
+		if r.Weeks < 1 {
+			return fmt.Errorf("insight rule %q: weeks must be positive", name)
+		}
+	case KindBaseline:
+		if r.Direction != "up" && r.Direction != "down" {
+			return fmt.Errorf("insight rule %q: direction must be up or down", name)
+		}
+		if r.Percent == 0 {
+			r.Percent = 10
+		}
+		if r.Days == 0 {
+			r.Days = 3
+		}
+		if r.BaselineDays == 0 {
+			r.BaselineDays = 28
+		}
+		if r.Percent < 0 || r.Days < 1 || r.BaselineDays < 1 {
+			return fmt.Errorf("insight rule %q: percent, days, and baseline_days must be positive", name)
+		}
+	default:
+		return fmt.Errorf("insight rule %q: kind must be %s, %s, %s, or %s", name, KindDays, KindTrend, KindAverage, KindBaseline)
+	}
+
+	if r.Name == "" {
+		r.Name = name
+	}
+	return nil
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
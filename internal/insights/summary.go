@@ -0,0 +1,204 @@
+// ABOUTME: Plain-language summary of recent data: changes, averages against goals, and workouts.
+// ABOUTME: Built from fixed templates, so the same data always reads the same way.
+package insights
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// changeMetrics are summarized by how far they moved over the window.
+var changeMetrics = []models.MetricType{models.MetricWeight, models.MetricBodyFat}
+
+// averageMetrics are summarized by their daily average, in this order.
+var averageMetrics = []models.MetricType{
+	models.MetricSleepHours, models.MetricSteps, models.MetricHeartRate, models.MetricHRV,
+	models.MetricWater, models.MetricCalories, models.MetricProtein,
+	models.MetricMood, models.MetricEnergy, models.MetricStress,
+}
+
+// restDays is how long without a workout is worth mentioning.
+const restDays = 7
+
+// Summarize describes the last days days up to now as short sentences,
+// such as "weight down 1.2 kg (84 → 82.8 kg)" or "no workouts in 8 days".
+// Goals maps metric types to daily targets that averages are compared
+// with. It returns nothing when there's no data to talk about.
+func Summarize(ctx context.Context, r storage.Repository, now time.Time, days int, goals map[string]float64) ([]string, error) {
+	since := startOfDay(now).AddDate(0, 0, -(days - 1))
+	until := now.Add(time.Second)
+	metrics, err := r.QueryMetrics(ctx, storage.MetricFilter{Since: &since, Until: &until})
+	if err != nil {
+		return nil, fmt.Errorf("summary: %w", err)
+	}
+	byType := map[models.MetricType][]*models.Metric{}
+	for _, m := range metrics {
+		byType[m.MetricType] = append(byType[m.MetricType], m)
+	}
+
+	var lines []string
+	for _, mt := range changeMetrics {
+		if line := changeLine(mt, byType[mt], days, goals); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if sys, dia := byType[models.MetricBPSys], byType[models.MetricBPDia]; len(sys) > 0 && len(dia) > 0 {
+		lines = append(lines, fmt.Sprintf("blood pressure averaging %s/%s mmHg",
+			format(mean(values(sys))), format(mean(values(dia)))))
+	}
+
+	types := slices.Clone(averageMetrics)
+	var extra []models.MetricType
+	for name := range goals {
+		mt := models.MetricType(name)
+		if !slices.Contains(types, mt) && !slices.Contains(changeMetrics, mt) {
+			extra = append(extra, mt)
+		}
+	}
+	slices.Sort(extra)
+	for _, mt := range append(types, extra...) {
+		goal, hasGoal := goals[string(mt)]
+		daily := dailyValues(byType[mt], mt, now.Location())
+		if len(daily) == 0 {
+			if hasGoal {
+				lines = append(lines, fmt.Sprintf("no %s logged against your %s goal", mt, withUnit(goal, mt)))
+			}
+			continue
+		}
+		avg := mean(daily)
+		line := fmt.Sprintf("%s averaging %s on %d %s logged", mt, withUnit(avg, mt), len(daily), plural(len(daily), "day", "days"))
+		if hasGoal {
+			switch {
+			case format(avg) == format(goal):
+				line += fmt.Sprintf(", right on your %s goal", withUnit(goal, mt))
+			case avg < goal:
+				line += fmt.Sprintf(", below your %s goal", withUnit(goal, mt))
+			default:
+				line += fmt.Sprintf(", above your %s goal", withUnit(goal, mt))
+			}
+		}
+		lines = append(lines, line)
+	}
+
+	workoutLines, err := workoutSummary(ctx, r, now, since, days)
+	if err != nil {
+		return nil, err
+	}
+	return append(lines, workoutLines...), nil
+}
+
+// changeLine describes how a metric moved from its first to its last entry
+// in the window and how far the last is from its goal, or "" with fewer
+// than two entries.
+func changeLine(mt models.MetricType, metrics []*models.Metric, days int, goals map[string]float64) string {
+	if len(metrics) < 2 {
+		return ""
+	}
+	// Metrics arrive newest first
+	first, last := metrics[len(metrics)-1].Value, metrics[0].Value
+	diff := last - first
+	var line string
+	if math.Abs(diff) < 0.05 {
+		line = fmt.Sprintf("%s steady at %s over %d days", mt, withUnit(last, mt), days)
+	} else {
+		direction := "up"
+		if diff < 0 {
+			direction = "down"
+		}
+		line = fmt.Sprintf("%s %s %s over %d days (%s → %s)",
+			mt, direction, withUnit(math.Abs(diff), mt), days, format(first), withUnit(last, mt))
+	}
+
+	if goal, ok := goals[string(mt)]; ok {
+		if away := math.Abs(last - goal); away < 0.05 {
+			line += fmt.Sprintf(", at your %s goal", withUnit(goal, mt))
+		} else {
+			line += fmt.Sprintf(", %s from your %s goal", withUnit(away, mt), withUnit(goal, mt))
+		}
+	}
+	return line
+}
+
+// workoutSummary counts the window's workouts and their minutes, and
+// notes a gap of restDays or more since the last one.
+func workoutSummary(ctx context.Context, r storage.Repository, now, since time.Time, days int) ([]string, error) {
+	latest, err := r.QueryWorkouts(ctx, storage.WorkoutFilter{Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("summary: %w", err)
+	}
+	if len(latest) == 0 {
+		return nil, nil
+	}
+	workouts, err := r.QueryWorkouts(ctx, storage.WorkoutFilter{Since: &since})
+	if err != nil {
+		return nil, fmt.Errorf("summary: %w", err)
+	}
+
+	var lines []string
+	if len(workouts) > 0 {
+		minutes := 0
+		counts := map[string]int{}
+		for _, w := range workouts {
+			if w.DurationMinutes != nil {
+				minutes += *w.DurationMinutes
+			}
+			counts[w.WorkoutType]++
+		}
+		common := ""
+		for t, n := range counts {
+			if n > counts[common] || (n == counts[common] && t < common) {
+				common = t
+			}
+		}
+		line := fmt.Sprintf("%d %s in %d days", len(workouts), plural(len(workouts), "workout", "workouts"), days)
+		if minutes > 0 {
+			line += fmt.Sprintf(", %d min in all", minutes)
+		}
+		if len(counts) > 1 {
+			line += ", mostly " + common
+		}
+		lines = append(lines, line)
+	}
+
+	gap := int(math.Round(startOfDay(now).Sub(startOfDay(latest[0].StartedAt.In(now.Location()))).Hours() / 24))
+	if gap >= restDays {
+		lines = append(lines, fmt.Sprintf("no workouts in %d days", gap))
+	}
+	return lines, nil
+}
+
+// withUnit formats a value with its metric's unit, e.g. "6.4 hours" or
+// "7/10" for scales.
+func withUnit(v float64, mt models.MetricType) string {
+	switch unit := models.MetricUnits[mt]; unit {
+	case "", string(mt):
+		return format(v)
+	case "scale":
+		return format(v) + "/10"
+	case "%":
+		return format(v) + "%"
+	default:
+		return format(v) + " " + unit
+	}
+}
+
+func values(metrics []*models.Metric) []float64 {
+	out := make([]float64, len(metrics))
+	for i, m := range metrics {
+		out[i] = m.Value
+	}
+	return out
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
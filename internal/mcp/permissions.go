@@ -0,0 +1,79 @@
+// ABOUTME: Per-tool permissions for the MCP server, from config's mcp section.
+// ABOUTME: Tools are collected at construction and only the permitted ones registered.
+package mcp
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// tool is one tool the server can offer, with the call that registers it.
+type tool struct {
+	name     string
+	register func()
+}
+
+// addTool adds a tool to the server's catalog. It isn't offered to
+// clients until registerTools, and then only if permitted.
+func addTool[In, Out any](s *Server, t *mcp.Tool, h mcp.ToolHandlerFor[In, Out]) {
+	s.tools = append(s.tools, tool{
+		name:     t.Name,
+		register: func() { mcp.AddTool(s.mcpServer, t, h) },
+	})
+}
+
+// ToolNames returns every tool the server knows, in registration order,
+// whether or not it is permitted.
+func (s *Server) ToolNames() []string {
+	names := make([]string, len(s.tools))
+	for i, t := range s.tools {
+		names[i] = t.name
+	}
+	return names
+}
+
+// SetToolPermissions limits the tools offered to clients. With enabled,
+// only matching tools are offered; tools matching disabled never are.
+// Entries are tool names or path.Match patterns such as "delete_*". A
+// pattern matching no tool is an error, so a typo can't leave a tool on.
+func (s *Server) SetToolPermissions(enabled, disabled []string) error {
+	for _, p := range slices.Concat(enabled, disabled) {
+		if !slices.ContainsFunc(s.tools, func(t tool) bool { return matchTool(p, t.name) }) {
+			return fmt.Errorf("mcp tools: %q matches no tool (see 'health mcp --help')", p)
+		}
+	}
+	s.enabledTools, s.disabledTools = enabled, disabled
+	return nil
+}
+
+// ToolPermitted reports whether the tool called name is offered.
+func (s *Server) ToolPermitted(name string) bool {
+	matches := func(p string) bool { return matchTool(p, name) }
+	if len(s.enabledTools) > 0 && !slices.ContainsFunc(s.enabledTools, matches) {
+		return false
+	}
+	return !slices.ContainsFunc(s.disabledTools, matches)
+}
+
+// matchTool reports whether pattern names the tool. path.Match only fails
+// on a malformed pattern, which then matches nothing.
+func matchTool(pattern, name string) bool {
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
+
+// registerTools registers the permitted tools with the MCP server, once.
+func (s *Server) registerTools() {
+	if s.toolsRegistered {
+		return
+	}
+	s.toolsRegistered = true
+	for _, t := range s.tools {
+		if s.ToolPermitted(t.name) {
+			t.register()
+		}
+	}
+}
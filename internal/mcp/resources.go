@@ -1,5 +1,5 @@
 // ABOUTME: MCP resource implementations for health metrics.
-// ABOUTME: Provides health://recent, health://today, and health://summary resources.
+// ABOUTME: Provides health://recent, today, summary, insights, insights/summary, and version resources.
 package mcp
 
 import (
@@ -8,7 +8,10 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/insights"
 	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -25,7 +28,7 @@ func (s *Server) registerResources() {
 	s.mcpServer.AddResource(&mcp.Resource{
 		URI:         "health://today",
 		Name:        "Today's Health Data",
-		Description: "All health metrics logged today",
+		Description: "All health metrics logged today plus upcoming appointments and the running fast with fasting streaks",
 		MIMEType:    "application/json",
 	}, s.handleTodayResource)
 
@@ -36,19 +39,43 @@ func (s *Server) registerResources() {
 		Description: "Latest value for each metric type plus recent workouts",
 		MIMEType:    "application/json",
 	}, s.handleSummaryResource)
+
+	// health://insights - Rule-based observations worth raising unprompted
+	s.mcpServer.AddResource(&mcp.Resource{
+		URI:         "health://insights",
+		Name:        "Health Insights",
+		Description: "Observations from insight rules, like short sleep most nights, blood pressure trending up, or resting heart rate above its baseline; worth mentioning without being asked",
+		MIMEType:    "application/json",
+	}, s.handleInsightsResource)
+
+	// health://insights/summary - Plain-language summary of the last 30 days
+	s.mcpServer.AddResource(&mcp.Resource{
+		URI:         "health://insights/summary",
+		Name:        "Health Narrative Summary",
+		Description: "Plain-language bullets about the last 30 days, like weight down 1.2 kg or sleep averaging 6.4 hours below a 7 hour goal",
+		MIMEType:    "application/json",
+	}, s.handleNarrativeResource)
+
+	// health://version - Format versions for clients to negotiate against
+	s.mcpServer.AddResource(&mcp.Resource{
+		URI:         "health://version",
+		Name:        "Format Versions",
+		Description: "Server version, tool output version, and the export format version written and read",
+		MIMEType:    "application/json",
+	}, s.handleVersionResource)
 }
 
 // Resource handlers
 
 func (s *Server) handleRecentResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
 	// Get last 10 metrics
-	metrics, err := s.repo.ListMetrics(nil, 10)
+	metrics, err := s.repo.ListMetrics(ctx, nil, 10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list metrics: %w", err)
 	}
 
 	// Get last 5 workouts
-	workouts, err := s.repo.ListWorkouts(nil, 5)
+	workouts, err := s.repo.ListWorkouts(ctx, nil, 5)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list workouts: %w", err)
 	}
@@ -73,12 +100,12 @@ func (s *Server) handleRecentResource(ctx context.Context, req *mcp.ReadResource
 }
 
 func (s *Server) handleTodayResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-	// Get today's start time (midnight)
-	now := time.Now()
+	// Get today's start time (midnight in the configured timezone)
+	now := time.Now().In(s.zone)
 	todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
 	// Get all metrics and filter by today
-	metrics, err := s.repo.ListMetrics(nil, 1000)
+	metrics, err := s.repo.ListMetrics(ctx, nil, 1000)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list metrics: %w", err)
 	}
@@ -91,7 +118,7 @@ func (s *Server) handleTodayResource(ctx context.Context, req *mcp.ReadResourceR
 	}
 
 	// Get all workouts and filter by today
-	workouts, err := s.repo.ListWorkouts(nil, 1000)
+	workouts, err := s.repo.ListWorkouts(ctx, nil, 1000)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list workouts: %w", err)
 	}
@@ -103,15 +130,30 @@ func (s *Server) handleTodayResource(ctx context.Context, req *mcp.ReadResourceR
 		}
 	}
 
+	// Next few appointments, so a check-in can mention what's coming up
+	appointments, err := storage.UpcomingAppointments(ctx, s.repo, now, 5)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list appointments: %w", err)
+	}
+
+	fasting, err := s.fastingSummary(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
 	result := map[string]interface{}{
-		"date":     todayStart.Format("2006-01-02"),
-		"metrics":  todayMetrics,
-		"workouts": todayWorkouts,
+		"date":                  todayStart.Format("2006-01-02"),
+		"metrics":               todayMetrics,
+		"workouts":              todayWorkouts,
+		"upcoming_appointments": appointments,
 		"counts": map[string]int{
 			"metrics":  len(todayMetrics),
 			"workouts": len(todayWorkouts),
 		},
 	}
+	if fasting != nil {
+		result["fasting"] = fasting
+	}
 
 	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -127,24 +169,107 @@ func (s *Server) handleTodayResource(ctx context.Context, req *mcp.ReadResourceR
 	}, nil
 }
 
+// fastingSummary describes the running fast, if any, and streaks of
+// meeting the target, or returns nil when no fast was ever logged.
+func (s *Server) fastingSummary(ctx context.Context, now time.Time) (map[string]interface{}, error) {
+	fasts, err := s.repo.ListFasts(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fasts: %w", err)
+	}
+	if len(fasts) == 0 {
+		return nil, nil
+	}
+
+	stats := models.SummarizeFasts(fasts, now)
+	summary := map[string]interface{}{
+		"completed":      stats.Completed,
+		"met_target":     stats.MetTarget,
+		"average_hours":  stats.AverageHours,
+		"current_streak": stats.CurrentStreak,
+		"longest_streak": stats.LongestStreak,
+	}
+	for _, f := range fasts {
+		if f.IsActive() {
+			summary["active"] = map[string]interface{}{
+				"started_at":    f.StartedAt.In(s.zone).Format(time.RFC3339),
+				"target_hours":  f.TargetHours,
+				"elapsed_hours": f.Hours(now),
+				"target_at":     f.TargetAt().In(s.zone).Format(time.RFC3339),
+				"met_target":    f.MetTarget(now),
+			}
+			break
+		}
+	}
+	return summary, nil
+}
+
 func (s *Server) handleSummaryResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
 	// Get latest value for each metric type
 	latestMetrics := make(map[string]interface{})
 	for _, mt := range models.AllMetricTypes {
-		metrics, err := s.repo.ListMetrics(&mt, 1)
+		metrics, err := s.repo.ListMetrics(ctx, &mt, 1)
 		if err == nil && len(metrics) > 0 {
 			m := metrics[0]
-			latestMetrics[string(mt)] = map[string]interface{}{
+			entry := map[string]interface{}{
 				"value":       m.Value,
 				"unit":        m.Unit,
 				"recorded_at": m.RecordedAt.Format(time.RFC3339),
 				"notes":       m.Notes,
 			}
+			if ref := s.classify(string(mt), m.Value); ref != nil {
+				entry["reference"] = ref
+			}
+			latestMetrics[string(mt)] = entry
 		}
 	}
+	if bp := s.latestBloodPressure(ctx); bp != nil {
+		latestMetrics["bp"] = bp
+	}
+
+	// Latest value of each derived metric, computed from stored inputs
+	derivedMetrics := make(map[string]interface{})
+	if s.derived != nil {
+		values, err := s.derived.FromRepo(ctx, s.repo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute derived metrics: %w", err)
+		}
+		for name, m := range derived.Latest(values) {
+			entry := map[string]interface{}{
+				"value":       m.Value,
+				"unit":        m.Unit,
+				"recorded_at": m.RecordedAt.Format(time.RFC3339),
+				"formula":     s.derived.Lookup(name).Source,
+			}
+			if ref := s.classify(name, m.Value); ref != nil {
+				entry["reference"] = ref
+			}
+			derivedMetrics[name] = entry
+		}
+	}
+
+	// Thresholds tripped by the latest values
+	hits, err := storage.ActiveAlerts(ctx, s.repo, s.alerts, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check alerts: %w", err)
+	}
+	activeAlerts := make([]map[string]interface{}, 0, len(hits))
+	for _, h := range hits {
+		activeAlerts = append(activeAlerts, map[string]interface{}{
+			"metric_type": string(h.Alert.MetricType),
+			"value":       h.Value,
+			"unit":        h.Unit,
+			"recorded_at": h.At.Format(time.RFC3339),
+			"message":     h.String(),
+		})
+	}
+
+	observations, err := insights.Evaluate(ctx, s.repo, s.insights, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate insights: %w", err)
+	}
 
 	// Get recent workouts (last 10)
-	workouts, err := s.repo.ListWorkouts(nil, 10)
+	workouts, err := s.repo.ListWorkouts(ctx, nil, 10)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list workouts: %w", err)
 	}
@@ -154,14 +279,17 @@ func (s *Server) handleSummaryResource(ctx context.Context, req *mcp.ReadResourc
 	activity := make(map[string]interface{})
 	nutrition := make(map[string]interface{})
 	mental := make(map[string]interface{})
+	environment := make(map[string]interface{})
 
 	biometricTypes := []models.MetricType{
 		models.MetricWeight, models.MetricBodyFat, models.MetricBPSys,
 		models.MetricBPDia, models.MetricHeartRate, models.MetricHRV,
-		models.MetricTemperature,
+		models.MetricTemperature, models.MetricTempDev, models.MetricReadiness,
 	}
 	activityTypes := []models.MetricType{
-		models.MetricSteps, models.MetricSleepHours, models.MetricActiveCalories,
+		models.MetricSteps, models.MetricSleepHours, models.MetricDeepSleep,
+		models.MetricREMSleep, models.MetricLightSleep, models.MetricActiveCalories,
+		models.MetricVO2Max,
 	}
 	nutritionTypes := []models.MetricType{
 		models.MetricWater, models.MetricCalories, models.MetricProtein,
@@ -171,6 +299,9 @@ func (s *Server) handleSummaryResource(ctx context.Context, req *mcp.ReadResourc
 		models.MetricMood, models.MetricEnergy, models.MetricStress,
 		models.MetricAnxiety, models.MetricFocus, models.MetricMeditation,
 	}
+	environmentTypes := []models.MetricType{
+		models.MetricAQI, models.MetricPollen, models.MetricAmbientTemp,
+	}
 
 	for _, mt := range biometricTypes {
 		if val, ok := latestMetrics[string(mt)]; ok {
@@ -192,15 +323,24 @@ func (s *Server) handleSummaryResource(ctx context.Context, req *mcp.ReadResourc
 			mental[string(mt)] = val
 		}
 	}
+	for _, mt := range environmentTypes {
+		if val, ok := latestMetrics[string(mt)]; ok {
+			environment[string(mt)] = val
+		}
+	}
 
 	result := map[string]interface{}{
 		"generated_at": time.Now().Format(time.RFC3339),
 		"metrics": map[string]interface{}{
-			"biometrics": biometrics,
-			"activity":   activity,
-			"nutrition":  nutrition,
-			"mental":     mental,
+			"biometrics":  biometrics,
+			"activity":    activity,
+			"nutrition":   nutrition,
+			"mental":      mental,
+			"environment": environment,
+			"derived":     derivedMetrics,
 		},
+		"active_alerts":   activeAlerts,
+		"insights":        observationsOrEmpty(observations),
 		"recent_workouts": workouts,
 		"summary": map[string]int{
 			"total_metric_types":   len(latestMetrics),
@@ -221,3 +361,90 @@ func (s *Server) handleSummaryResource(ctx context.Context, req *mcp.ReadResourc
 		}},
 	}, nil
 }
+
+func (s *Server) handleInsightsResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	observations, err := insights.Evaluate(ctx, s.repo, s.insights, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate insights: %w", err)
+	}
+
+	result := map[string]interface{}{
+		"generated_at": time.Now().Format(time.RFC3339),
+		"insights":     observationsOrEmpty(observations),
+		"rules":        len(s.insights),
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      "health://insights",
+			MIMEType: "application/json",
+			Text:     string(data),
+		}},
+	}, nil
+}
+
+// narrativeDays is the window health://insights/summary covers.
+const narrativeDays = 30
+
+func (s *Server) handleNarrativeResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	lines, err := insights.Summarize(ctx, s.repo, time.Now().In(s.zone), narrativeDays, s.goals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize: %w", err)
+	}
+	if lines == nil {
+		lines = []string{}
+	}
+
+	result := map[string]interface{}{
+		"generated_at": time.Now().Format(time.RFC3339),
+		"days":         narrativeDays,
+		"summary":      lines,
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      "health://insights/summary",
+			MIMEType: "application/json",
+			Text:     string(data),
+		}},
+	}, nil
+}
+
+// observationsOrEmpty keeps "no insights" as [] rather than null in JSON.
+func observationsOrEmpty(observations []insights.Observation) []insights.Observation {
+	if observations == nil {
+		return []insights.Observation{}
+	}
+	return observations
+}
+
+func (s *Server) handleVersionResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	result := map[string]interface{}{
+		"server":         ServerVersion,
+		"output_version": OutputVersion,
+		"export_format":  storage.ExportFormatVersion,
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{
+			URI:      "health://version",
+			MIMEType: "application/json",
+			Text:     string(data),
+		}},
+	}, nil
+}
@@ -4,7 +4,14 @@ package mcp
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
+	"github.com/harperreed/health/internal/derived"
+	"github.com/harperreed/health/internal/environment"
+	"github.com/harperreed/health/internal/insights"
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/reference"
 	"github.com/harperreed/health/internal/storage"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -13,30 +20,140 @@ import (
 type Server struct {
 	mcpServer *mcp.Server
 	repo      storage.Repository
+	enricher  *environment.WorkoutEnricher
+	derived   *derived.Set
+	alerts    []models.Alert
+	// duplicates is off until SetDuplicateWindows
+	duplicates models.DuplicateWindows
+	insights   []insights.Rule
+	goals      map[string]float64
+	person     *reference.Person
+	zone       *time.Location
+	// dataDir holds attachments; empty until SetDataDir
+	dataDir string
+
+	tools           []tool
+	enabledTools    []string
+	disabledTools   []string
+	toolsRegistered bool
 }
 
+// ServerVersion is reported to clients during initialization.
+const ServerVersion = "1.1.0"
+
+// OutputVersion is the version of tool and resource payloads. Fields are
+// only ever added within a version; it changes when a field is removed or
+// changes meaning, so clients can check health://version before relying
+// on one.
+const OutputVersion = 1
+
 // NewServer creates a new MCP server with the given storage.
 func NewServer(repo storage.Repository) (*Server, error) {
 	mcpServer := mcp.NewServer(
 		&mcp.Implementation{
 			Name:    "health",
-			Version: "1.0.0",
+			Version: ServerVersion,
+		},
+		&mcp.ServerOptions{
+			Logger:       slog.Default(),
+			Instructions: "Read health://version for the payload and export format versions this server speaks.",
 		},
-		nil,
 	)
+	mcpServer.AddReceivingMiddleware(logRequests)
 
 	s := &Server{
 		mcpServer: mcpServer,
 		repo:      repo,
+		insights:  insights.DefaultRules,
+		zone:      time.Local,
 	}
 
-	s.registerTools()
+	s.addTools()
 	s.registerResources()
 
 	return s, nil
 }
 
-// Serve starts the MCP server using stdio transport.
+// SetWorkoutEnricher enables weather enrichment for add_workout.
+func (s *Server) SetWorkoutEnricher(e *environment.WorkoutEnricher) {
+	s.enricher = e
+}
+
+// SetDerivedMetrics adds computed metrics (e.g. BMI) to the summary resource.
+func (s *Server) SetDerivedMetrics(set *derived.Set) {
+	s.derived = set
+}
+
+// SetAlerts enables threshold warnings on added metrics and in the summary.
+func (s *Server) SetAlerts(alerts []models.Alert) {
+	s.alerts = alerts
+}
+
+// SetDuplicateWindows makes add_metric and add_blood_pressure skip, with
+// a warning, entries that look like ones already logged.
+func (s *Server) SetDuplicateWindows(w models.DuplicateWindows) {
+	s.duplicates = w
+}
+
+// SetInsightRules replaces the built-in rules behind health://insights.
+func (s *Server) SetInsightRules(rules []insights.Rule) {
+	s.insights = rules
+}
+
+// SetGoals sets the daily targets health://insights/summary compares
+// averages with.
+func (s *Server) SetGoals(goals map[string]float64) {
+	s.goals = goals
+}
+
+// SetTimezone sets the zone that "today" and bare dates are read in.
+// Defaults to time.Local.
+func (s *Server) SetTimezone(loc *time.Location) {
+	s.zone = loc
+}
+
+// SetDataDir sets the data directory, so deleting a metric or workout
+// also deletes its attached files.
+func (s *Server) SetDataDir(dir string) {
+	s.dataDir = dir
+}
+
+// SetReferencePerson labels summary values with reference ranges for p.
+// Nil, the default, leaves them unlabelled.
+func (s *Server) SetReferencePerson(p *reference.Person) {
+	s.person = p
+}
+
+// classify returns the reference range for a value, or nil.
+func (s *Server) classify(metric string, value float64) *reference.Annotation {
+	if s.person == nil {
+		return nil
+	}
+	return reference.Classify(metric, value, *s.person)
+}
+
+// logRequests logs each incoming request with its duration; tool calls
+// also log the tool name.
+func logRequests(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		start := time.Now()
+		res, err := next(ctx, method, req)
+
+		attrs := []any{"method", method, "duration", time.Since(start)}
+		if call, ok := req.(*mcp.CallToolRequest); ok {
+			attrs = append(attrs, "tool", call.Params.Name)
+		}
+		if err != nil {
+			attrs = append(attrs, "err", err)
+		}
+		slog.DebugContext(ctx, "mcp request", attrs...)
+		return res, err
+	}
+}
+
+// Serve starts the MCP server using stdio transport, offering the tools
+// SetToolPermissions allows.
 func (s *Server) Serve(ctx context.Context) error {
+	s.registerTools()
 	return s.mcpServer.Run(ctx, &mcp.StdioTransport{})
 }
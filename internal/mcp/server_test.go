@@ -4,12 +4,18 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/harperreed/health/internal/attachment"
+	"github.com/harperreed/health/internal/insights"
 	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/reference"
 	"github.com/harperreed/health/internal/storage"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -108,6 +114,16 @@ func TestHandleAddMetric(t *testing.T) {
 			wantErr:   true,
 			errSubstr: "unknown metric type",
 		},
+		{
+			name: "unit not valid for metric",
+			input: addMetricInput{
+				MetricType: "weight",
+				Value:      180,
+				Unit:       "oz",
+			},
+			wantErr:   true,
+			errSubstr: "unknown unit",
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,6 +160,34 @@ func TestHandleAddMetric(t *testing.T) {
 	}
 }
 
+func TestHandleAddMetricConvertsUnit(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+
+	_, output, err := server.handleAddMetric(context.Background(), &mcp.CallToolRequest{},
+		addMetricInput{MetricType: "water", Value: 16, Unit: "oz"})
+	if err != nil {
+		t.Fatalf("handleAddMetric failed: %v", err)
+	}
+	if math.Abs(output.Value-473.18) > 0.01 || output.Unit != "ml" {
+		t.Errorf("Expected ~473.18 ml, got %v %s", output.Value, output.Unit)
+	}
+
+	_, output, err = server.handleAddMetric(context.Background(), &mcp.CallToolRequest{},
+		addMetricInput{MetricType: "sleep", Quantity: "7h30m"})
+	if err != nil {
+		t.Fatalf("handleAddMetric with quantity failed: %v", err)
+	}
+	if output.MetricType != "sleep_hours" || output.Value != 7.5 {
+		t.Errorf("Expected sleep_hours 7.5, got %s %v", output.MetricType, output.Value)
+	}
+
+	if _, _, err := server.handleAddMetric(context.Background(), &mcp.CallToolRequest{},
+		addMetricInput{MetricType: "weight", Quantity: "181lbs", Unit: "kg"}); err == nil {
+		t.Error("Expected error for quantity with a unit")
+	}
+}
+
 func TestHandleListMetrics(t *testing.T) {
 	db := setupTestDB(t)
 	server, _ := NewServer(db)
@@ -152,8 +196,8 @@ func TestHandleListMetrics(t *testing.T) {
 	// Add some test metrics
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
-	db.CreateMetric(m1)
-	db.CreateMetric(m2)
+	db.CreateMetric(t.Context(), m1)
+	db.CreateMetric(t.Context(), m2)
 
 	tests := []struct {
 		name     string
@@ -218,9 +262,15 @@ func TestHandleDeleteMetric(t *testing.T) {
 	server, _ := NewServer(db)
 	ctx := context.Background()
 
-	// Create a metric to delete
+	dataDir := t.TempDir()
+	server.SetDataDir(dataDir)
+	src := filepath.Join(t.TempDir(), "scale.jpg")
+	os.WriteFile(src, []byte("photo"), 0600)
+
+	// Create a metric to delete, with an attached photo
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	db.CreateMetric(m)
+	rel, _ := attachment.Save(dataDir, attachment.Metrics, m.ID.String(), src)
+	db.CreateMetric(t.Context(), m.WithMetadata(attachment.Key("scale.jpg"), rel))
 
 	// Delete by prefix
 	_, output, err := server.handleDeleteMetric(ctx, &mcp.CallToolRequest{}, deleteMetricInput{
@@ -235,10 +285,13 @@ func TestHandleDeleteMetric(t *testing.T) {
 	}
 
 	// Verify deleted
-	_, err = db.GetMetric(m.ID.String())
+	_, err = db.GetMetric(t.Context(), m.ID.String())
 	if err == nil {
 		t.Error("Expected metric to be deleted")
 	}
+	if _, err := os.Stat(attachment.Dir(dataDir, attachment.Metrics, m.ID.String())); !os.IsNotExist(err) {
+		t.Errorf("Expected the metric's attachments deleted, got %v", err)
+	}
 }
 
 func TestHandleDeleteMetricNotFound(t *testing.T) {
@@ -255,6 +308,46 @@ func TestHandleDeleteMetricNotFound(t *testing.T) {
 	}
 }
 
+func TestHandleUpdateMetric(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	m := models.NewMetric(models.MetricSteps, 450).WithNotes("lunch")
+	db.CreateMetric(ctx, m)
+
+	_, output, err := server.handleUpdateMetric(ctx, &mcp.CallToolRequest{}, updateMetricInput{
+		ID:         m.ID.String()[:8],
+		MetricType: "calories",
+	})
+	if err != nil {
+		t.Fatalf("handleUpdateMetric failed: %v", err)
+	}
+	if output.MetricType != "calories" || output.Unit != "kcal" || output.Value != 450 || !strings.Contains(output.Message, "steps to calories") {
+		t.Errorf("unexpected output: %+v", output)
+	}
+
+	value, notes := 2000.0, ""
+	if _, _, err := server.handleUpdateMetric(ctx, &mcp.CallToolRequest{}, updateMetricInput{
+		ID: m.ID.String(), Value: &value, Unit: "kj", Notes: &notes,
+	}); err != nil {
+		t.Fatalf("handleUpdateMetric failed: %v", err)
+	}
+	got, _ := db.GetMetric(ctx, m.ID.String())
+	if got.MetricType != models.MetricCalories || math.Abs(got.Value-478.011) > 0.001 || got.Notes != nil {
+		t.Errorf("stored metric = %+v", got)
+	}
+
+	sys, _ := models.NewBloodPressure(120, 80, time.Now())
+	db.CreateMetric(ctx, sys)
+	if _, _, err := server.handleUpdateMetric(ctx, &mcp.CallToolRequest{}, updateMetricInput{ID: sys.ID.String(), MetricType: "heart_rate"}); err == nil {
+		t.Error("Expected an error retyping blood pressure")
+	}
+	if _, _, err := server.handleUpdateMetric(ctx, &mcp.CallToolRequest{}, updateMetricInput{ID: "nonexistent"}); err == nil {
+		t.Error("Expected an error for a nonexistent metric")
+	}
+}
+
 func TestHandleAddWorkout(t *testing.T) {
 	db := setupTestDB(t)
 	server, _ := NewServer(db)
@@ -312,6 +405,27 @@ func TestHandleAddWorkout(t *testing.T) {
 	}
 }
 
+func TestHandleAddWorkoutIntensity(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	rpe, zone := 7, 3
+	_, output, err := server.handleAddWorkout(ctx, &mcp.CallToolRequest{}, addWorkoutInput{WorkoutType: "run", RPE: &rpe, Zone: &zone})
+	if err != nil {
+		t.Fatalf("handleAddWorkout failed: %v", err)
+	}
+	w, _ := db.GetWorkout(ctx, output.ID)
+	if w.RPE == nil || *w.RPE != 7 || w.Zone == nil || *w.Zone != 3 {
+		t.Errorf("stored RPE/zone = %v/%v, want 7/3", w.RPE, w.Zone)
+	}
+
+	rpe = 11
+	if _, _, err := server.handleAddWorkout(ctx, &mcp.CallToolRequest{}, addWorkoutInput{WorkoutType: "run", RPE: &rpe}); err == nil {
+		t.Error("Expected an error for an RPE above 10")
+	}
+}
+
 func TestHandleAddWorkoutMetric(t *testing.T) {
 	db := setupTestDB(t)
 	server, _ := NewServer(db)
@@ -319,7 +433,7 @@ func TestHandleAddWorkoutMetric(t *testing.T) {
 
 	// Create a workout first
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
 
 	tests := []struct {
 		name  string
@@ -383,8 +497,8 @@ func TestHandleListWorkouts(t *testing.T) {
 	// Create test workouts
 	w1 := models.NewWorkout("run")
 	w2 := models.NewWorkout("lift")
-	db.CreateWorkout(w1)
-	db.CreateWorkout(w2)
+	db.CreateWorkout(t.Context(), w1)
+	db.CreateWorkout(t.Context(), w2)
 
 	tests := []struct {
 		name  string
@@ -440,10 +554,10 @@ func TestHandleGetWorkout(t *testing.T) {
 	// Create a workout with metrics
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	db.AddWorkoutMetric(wm)
+	db.AddWorkoutMetric(t.Context(), wm)
 
 	_, output, err := server.handleGetWorkout(ctx, &mcp.CallToolRequest{}, getWorkoutInput{
 		ID: w.ID.String()[:8],
@@ -476,9 +590,15 @@ func TestHandleDeleteWorkout(t *testing.T) {
 	server, _ := NewServer(db)
 	ctx := context.Background()
 
-	// Create a workout
+	dataDir := t.TempDir()
+	server.SetDataDir(dataDir)
+	src := filepath.Join(t.TempDir(), "route.gpx")
+	os.WriteFile(src, []byte("<gpx/>"), 0600)
+
+	// Create a workout with an attached route
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
+	attachment.Save(dataDir, attachment.Workouts, w.ID.String(), src)
 
 	_, output, err := server.handleDeleteWorkout(ctx, &mcp.CallToolRequest{}, getWorkoutInput{
 		ID: w.ID.String()[:8],
@@ -492,10 +612,13 @@ func TestHandleDeleteWorkout(t *testing.T) {
 	}
 
 	// Verify deleted
-	_, err = db.GetWorkout(w.ID.String())
+	_, err = db.GetWorkout(t.Context(), w.ID.String())
 	if err == nil {
 		t.Error("Expected workout to be deleted")
 	}
+	if _, err := os.Stat(attachment.Dir(dataDir, attachment.Workouts, w.ID.String())); !os.IsNotExist(err) {
+		t.Errorf("Expected the workout's attachments deleted, got %v", err)
+	}
 }
 
 func TestHandleDeleteWorkoutNotFound(t *testing.T) {
@@ -520,8 +643,8 @@ func TestHandleGetLatest(t *testing.T) {
 	// Add metrics
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
-	db.CreateMetric(m1)
-	db.CreateMetric(m2)
+	db.CreateMetric(t.Context(), m1)
+	db.CreateMetric(t.Context(), m2)
 
 	tests := []struct {
 		name  string
@@ -561,10 +684,10 @@ func TestHandleRecentResource(t *testing.T) {
 
 	// Add some data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	db.CreateMetric(m)
+	db.CreateMetric(t.Context(), m)
 
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
 
 	result, err := server.handleRecentResource(ctx, &mcp.ReadResourceRequest{})
 	if err != nil {
@@ -595,11 +718,11 @@ func TestHandleTodayResource(t *testing.T) {
 
 	// Add a metric for today
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	db.CreateMetric(m)
+	db.CreateMetric(t.Context(), m)
 
 	// Add a workout for today
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
 
 	result, err := server.handleTodayResource(ctx, &mcp.ReadResourceRequest{})
 	if err != nil {
@@ -625,11 +748,11 @@ func TestHandleTodayResourceFiltersOldData(t *testing.T) {
 	// Add an old metric (yesterday)
 	oldMetric := models.NewMetric(models.MetricWeight, 80.0)
 	oldMetric.RecordedAt = time.Now().Add(-48 * time.Hour)
-	db.CreateMetric(oldMetric)
+	db.CreateMetric(t.Context(), oldMetric)
 
 	// Add a today metric
 	todayMetric := models.NewMetric(models.MetricWeight, 82.5)
-	db.CreateMetric(todayMetric)
+	db.CreateMetric(t.Context(), todayMetric)
 
 	result, err := server.handleTodayResource(ctx, &mcp.ReadResourceRequest{})
 	if err != nil {
@@ -647,21 +770,87 @@ func TestHandleTodayResourceFiltersOldData(t *testing.T) {
 	}
 }
 
+func TestHandleTodayResourceUsesTimezone(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	zone := time.FixedZone("UTC+14", 14*3600)
+	server.SetTimezone(zone)
+
+	now := time.Now().In(zone)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zone)
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 80.1).WithRecordedAt(midnight.Add(-time.Minute)))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82.5).WithRecordedAt(midnight.Add(time.Minute)))
+
+	result, err := server.handleTodayResource(t.Context(), &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := result.Contents[0].Text
+	if !contains(text, "82.5") || contains(text, "80.1") {
+		t.Errorf("Expected only the metric after midnight in %s: %s", zone, text)
+	}
+	if !contains(text, midnight.Format("2006-01-02")) {
+		t.Errorf("Expected the date in %s: %s", zone, text)
+	}
+}
+
+func TestHandleTodayResourceFasting(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := t.Context()
+
+	result, err := server.handleTodayResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contains(result.Contents[0].Text, "fasting") {
+		t.Errorf("Expected no fasting section without fasts: %s", result.Contents[0].Text)
+	}
+
+	done := models.NewFast(16).WithStartedAt(time.Now().Add(-40 * time.Hour))
+	end := done.StartedAt.Add(17 * time.Hour)
+	done.EndedAt = &end
+	db.CreateFast(ctx, done)
+	db.CreateFast(ctx, models.NewFast(18).WithStartedAt(time.Now().Add(-3*time.Hour)))
+
+	result, err = server.handleTodayResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got struct {
+		Fasting struct {
+			Completed int `json:"completed"`
+			MetTarget int `json:"met_target"`
+			Active    *struct {
+				TargetHours  float64 `json:"target_hours"`
+				ElapsedHours float64 `json:"elapsed_hours"`
+			} `json:"active"`
+		} `json:"fasting"`
+	}
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Fasting.Completed != 1 || got.Fasting.MetTarget != 1 || got.Fasting.Active == nil ||
+		got.Fasting.Active.TargetHours != 18 || got.Fasting.Active.ElapsedHours < 2.9 {
+		t.Errorf("fasting = %+v", got.Fasting)
+	}
+}
+
 func TestHandleSummaryResource(t *testing.T) {
 	db := setupTestDB(t)
 	server, _ := NewServer(db)
 	ctx := context.Background()
 
 	// Add various metrics
-	db.CreateMetric(models.NewMetric(models.MetricWeight, 82.5))
-	db.CreateMetric(models.NewMetric(models.MetricMood, 7))
-	db.CreateMetric(models.NewMetric(models.MetricSteps, 10000))
-	db.CreateMetric(models.NewMetric(models.MetricCalories, 2000))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82.5))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricMood, 7))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricSteps, 10000))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricCalories, 2000))
 
 	// Add a workout
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
 
 	result, err := server.handleSummaryResource(ctx, &mcp.ReadResourceRequest{})
 	if err != nil {
@@ -694,6 +883,37 @@ func TestHandleSummaryResource(t *testing.T) {
 	}
 }
 
+func TestHandleSummaryResourceReferenceRanges(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricHeartRate, 52))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBPSys, 125))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBPDia, 85))
+
+	// Unlabelled until a person is set
+	result, err := server.handleSummaryResource(t.Context(), &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if contains(result.Contents[0].Text, "reference") {
+		t.Error("Expected no reference ranges without a person")
+	}
+
+	server.SetReferencePerson(&reference.Person{})
+	result, err = server.handleSummaryResource(t.Context(), &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text := result.Contents[0].Text
+	if !contains(text, "athlete range") {
+		t.Error("Expected heart rate labelled with the athlete range")
+	}
+	if !contains(text, "stage 1 hypertension") {
+		t.Error("Expected blood pressure labelled stage 1 hypertension")
+	}
+}
+
 func TestHandleSummaryResourceEmpty(t *testing.T) {
 	db := setupTestDB(t)
 	server, _ := NewServer(db)
@@ -750,11 +970,11 @@ func TestHandleTodayResourceFiltersOldWorkouts(t *testing.T) {
 	// Add an old workout (yesterday)
 	oldWorkout := models.NewWorkout("run")
 	oldWorkout.StartedAt = time.Now().Add(-48 * time.Hour)
-	db.CreateWorkout(oldWorkout)
+	db.CreateWorkout(t.Context(), oldWorkout)
 
 	// Add a today workout
 	todayWorkout := models.NewWorkout("lift")
-	db.CreateWorkout(todayWorkout)
+	db.CreateWorkout(t.Context(), todayWorkout)
 
 	result, err := server.handleTodayResource(ctx, &mcp.ReadResourceRequest{})
 	if err != nil {
@@ -771,24 +991,46 @@ func TestHandleTodayResourceFiltersOldWorkouts(t *testing.T) {
 	}
 }
 
+func TestHandleAddMetricWithRelativeTimestamp(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+
+	_, output, err := server.handleAddMetric(t.Context(), &mcp.CallToolRequest{}, addMetricInput{
+		MetricType: "weight",
+		Value:      82.5,
+		RecordedAt: "2 hours ago",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	m, err := db.GetMetric(t.Context(), output.ID)
+	if err != nil {
+		t.Fatalf("GetMetric failed: %v", err)
+	}
+	if age := time.Since(m.RecordedAt); age < 119*time.Minute || age > 121*time.Minute {
+		t.Errorf("RecordedAt is %v ago, want 2 hours", age)
+	}
+}
+
 func TestHandleAddMetricWithInvalidTimestamp(t *testing.T) {
 	db := setupTestDB(t)
 	server, _ := NewServer(db)
 	ctx := context.Background()
 
-	// Invalid timestamp format - should still work but use current time
-	_, output, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{
+	// An unparseable timestamp is rejected rather than replaced with now,
+	// so a backfill never lands on the wrong day
+	_, _, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{
 		MetricType: "weight",
 		Value:      82.5,
 		RecordedAt: "invalid-timestamp",
 	})
-
-	// Should succeed, but with current time (invalid timestamp is ignored)
-	if err != nil {
-		t.Errorf("Unexpected error: %v", err)
+	if err == nil || !contains(err.Error(), "recorded_at") {
+		t.Errorf("err = %v, want an invalid recorded_at error", err)
 	}
-	if output.ID == "" {
-		t.Error("Expected non-empty ID")
+
+	metrics, _ := db.ListMetrics(ctx, nil, 10)
+	if len(metrics) != 0 {
+		t.Errorf("Expected nothing stored, got %d metrics", len(metrics))
 	}
 }
 
@@ -820,33 +1062,33 @@ func TestHandleSummaryResourceWithAllCategories(t *testing.T) {
 
 	// Add metrics from all categories
 	// Biometrics
-	db.CreateMetric(models.NewMetric(models.MetricWeight, 82.5))
-	db.CreateMetric(models.NewMetric(models.MetricBodyFat, 15))
-	db.CreateMetric(models.NewMetric(models.MetricBPSys, 120))
-	db.CreateMetric(models.NewMetric(models.MetricBPDia, 80))
-	db.CreateMetric(models.NewMetric(models.MetricHeartRate, 65))
-	db.CreateMetric(models.NewMetric(models.MetricHRV, 48))
-	db.CreateMetric(models.NewMetric(models.MetricTemperature, 36.5))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82.5))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBodyFat, 15))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBPSys, 120))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBPDia, 80))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricHeartRate, 65))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricHRV, 48))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricTemperature, 36.5))
 
 	// Activity
-	db.CreateMetric(models.NewMetric(models.MetricSteps, 10000))
-	db.CreateMetric(models.NewMetric(models.MetricSleepHours, 7.5))
-	db.CreateMetric(models.NewMetric(models.MetricActiveCalories, 500))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricSteps, 10000))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricSleepHours, 7.5))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricActiveCalories, 500))
 
 	// Nutrition
-	db.CreateMetric(models.NewMetric(models.MetricWater, 2000))
-	db.CreateMetric(models.NewMetric(models.MetricCalories, 2000))
-	db.CreateMetric(models.NewMetric(models.MetricProtein, 100))
-	db.CreateMetric(models.NewMetric(models.MetricCarbs, 250))
-	db.CreateMetric(models.NewMetric(models.MetricFat, 70))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWater, 2000))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricCalories, 2000))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricProtein, 100))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricCarbs, 250))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricFat, 70))
 
 	// Mental
-	db.CreateMetric(models.NewMetric(models.MetricMood, 7))
-	db.CreateMetric(models.NewMetric(models.MetricEnergy, 6))
-	db.CreateMetric(models.NewMetric(models.MetricStress, 3))
-	db.CreateMetric(models.NewMetric(models.MetricAnxiety, 2))
-	db.CreateMetric(models.NewMetric(models.MetricFocus, 8))
-	db.CreateMetric(models.NewMetric(models.MetricMeditation, 15))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricMood, 7))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricEnergy, 6))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricStress, 3))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricAnxiety, 2))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricFocus, 8))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricMeditation, 15))
 
 	result, err := server.handleSummaryResource(ctx, &mcp.ReadResourceRequest{})
 	if err != nil {
@@ -923,9 +1165,9 @@ func TestHandleListWorkoutsWithFilter(t *testing.T) {
 	w1 := models.NewWorkout("run")
 	w2 := models.NewWorkout("lift")
 	w3 := models.NewWorkout("run")
-	db.CreateWorkout(w1)
-	db.CreateWorkout(w2)
-	db.CreateWorkout(w3)
+	db.CreateWorkout(t.Context(), w1)
+	db.CreateWorkout(t.Context(), w2)
+	db.CreateWorkout(t.Context(), w3)
 
 	// Filter by type
 	_, output, err := server.handleListWorkouts(ctx, &mcp.CallToolRequest{}, listWorkoutsInput{
@@ -937,10 +1179,11 @@ func TestHandleListWorkoutsWithFilter(t *testing.T) {
 		t.Errorf("Unexpected error: %v", err)
 	}
 
-	workouts, ok := output.([]*models.Workout)
+	result, ok := output.(listWorkoutsOutput)
 	if !ok {
-		t.Fatal("Expected workout slice output")
+		t.Fatalf("Expected listWorkoutsOutput, got %T", output)
 	}
+	workouts := result.Workouts
 	if len(workouts) != 2 {
 		t.Errorf("Expected 2 run workouts, got %d", len(workouts))
 	}
@@ -954,13 +1197,13 @@ func TestHandleRecentResourceWithData(t *testing.T) {
 	// Add multiple metrics
 	for i := 0; i < 15; i++ {
 		m := models.NewMetric(models.MetricWeight, float64(80+i))
-		db.CreateMetric(m)
+		db.CreateMetric(t.Context(), m)
 	}
 
 	// Add multiple workouts
 	for i := 0; i < 8; i++ {
 		w := models.NewWorkout("run")
-		db.CreateWorkout(w)
+		db.CreateWorkout(t.Context(), w)
 	}
 
 	result, err := server.handleRecentResource(ctx, &mcp.ReadResourceRequest{})
@@ -983,7 +1226,7 @@ func TestHandleAddWorkoutMetricWithError(t *testing.T) {
 
 	// Create a workout
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(t.Context(), w)
 
 	// Try to add metric to a nonexistent workout prefix
 	_, _, err := server.handleAddWorkoutMetric(ctx, &mcp.CallToolRequest{}, addWorkoutMetricInput{
@@ -1004,9 +1247,9 @@ func TestHandleGetLatestWithSpecificTypes(t *testing.T) {
 	ctx := context.Background()
 
 	// Add metrics
-	db.CreateMetric(models.NewMetric(models.MetricWeight, 82.5))
-	db.CreateMetric(models.NewMetric(models.MetricMood, 7))
-	db.CreateMetric(models.NewMetric(models.MetricSteps, 10000))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82.5))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricMood, 7))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricSteps, 10000))
 
 	// Get specific types only
 	_, output, err := server.handleGetLatest(ctx, &mcp.CallToolRequest{}, getLatestInput{
@@ -1063,3 +1306,814 @@ func containsImpl(s, substr string) bool {
 	}
 	return false
 }
+
+func TestHandleListMetricsPagination(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		m := models.NewMetric(models.MetricWeight, 80+float64(i))
+		m.RecordedAt = base.Add(-time.Duration(i) * time.Hour)
+		db.CreateMetric(t.Context(), m)
+	}
+
+	seen := 0
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		_, output, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{
+			Limit:  2,
+			Cursor: cursor,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		result, ok := output.(listMetricsOutput)
+		if !ok {
+			t.Fatalf("Expected listMetricsOutput, got %T", output)
+		}
+		seen += len(result.Metrics)
+		if result.TotalCount != 5 {
+			t.Errorf("TotalCount = %d on page %d, want 5", result.TotalCount, page)
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+
+	if seen != 5 {
+		t.Errorf("Expected to page through 5 metrics, got %d", seen)
+	}
+}
+
+func TestHandleListMetricsPaginationKeepsReadingsWhole(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	sys, dia := models.NewBloodPressure(120, 80, base)
+	storage.RecordBloodPressure(ctx, db, sys, dia)
+
+	_, output, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{Limit: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	result := output.(listMetricsOutput)
+	if len(result.Metrics) != 1 || result.Metrics[0].Reading != "120/80" {
+		t.Errorf("Expected the whole reading on one page, got %+v", result.Metrics)
+	}
+	if result.TotalCount != 1 || result.NextCursor != "" {
+		t.Errorf("TotalCount = %d, NextCursor = %q; want 1 and no next page", result.TotalCount, result.NextCursor)
+	}
+
+	// With a weight after it, the reading is still whole on the second page
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82).WithRecordedAt(base.Add(time.Minute)))
+	_, output, _ = server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{Limit: 1})
+	first := output.(listMetricsOutput)
+	if first.TotalCount != 2 || len(first.Metrics) != 1 || first.Metrics[0].MetricType != models.MetricWeight || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	_, output, _ = server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{Limit: 1, Cursor: first.NextCursor})
+	second := output.(listMetricsOutput)
+	if len(second.Metrics) != 1 || second.Metrics[0].Reading != "120/80" || second.NextCursor != "" {
+		t.Errorf("second page = %+v, want the whole reading and no next page", second)
+	}
+}
+
+func TestHandleListWorkoutsPagination(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+
+	for i := 0; i < 3; i++ {
+		db.CreateWorkout(t.Context(), models.NewWorkout("run").WithStartedAt(time.Now().Add(-time.Duration(i)*time.Hour)))
+	}
+	db.CreateWorkout(t.Context(), models.NewWorkout("lift"))
+
+	var seen []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		_, output, err := server.handleListWorkouts(t.Context(), &mcp.CallToolRequest{}, listWorkoutsInput{
+			WorkoutType: "run",
+			Limit:       2,
+			Cursor:      cursor,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		result := output.(listWorkoutsOutput)
+		if result.TotalCount != 3 {
+			t.Errorf("TotalCount = %d, want 3 runs", result.TotalCount)
+		}
+		for _, w := range result.Workouts {
+			seen = append(seen, w.ID.String())
+		}
+		if result.NextCursor == "" {
+			break
+		}
+		cursor = result.NextCursor
+	}
+	if len(seen) != 3 {
+		t.Errorf("Expected to page through 3 runs, got %d", len(seen))
+	}
+}
+
+func TestHandleListMetricsInvalidCursor(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	_, _, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{
+		Cursor: "not-a-cursor",
+	})
+	if err == nil {
+		t.Error("Expected error for invalid cursor")
+	}
+}
+
+func TestHandleListWorkoutsDateRange(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	old := models.NewWorkout("run").WithStartedAt(time.Now().AddDate(0, 0, -10))
+	recent := models.NewWorkout("run")
+	db.CreateWorkout(t.Context(), old)
+	db.CreateWorkout(t.Context(), recent)
+
+	since := time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	_, output, err := server.handleListWorkouts(ctx, &mcp.CallToolRequest{}, listWorkoutsInput{
+		Since: since,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, ok := output.(listWorkoutsOutput)
+	if !ok {
+		t.Fatalf("Expected listWorkoutsOutput, got %T", output)
+	}
+	workouts := result.Workouts
+	if len(workouts) != 1 || workouts[0].ID != recent.ID || result.TotalCount != 1 {
+		t.Errorf("Expected only the recent workout, got %d workouts", len(workouts))
+	}
+}
+
+func TestHandleListMetricsUntilDate(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.Local)
+	inDay := models.NewMetric(models.MetricWeight, 82).WithRecordedAt(day.Add(20 * time.Hour))
+	nextDay := models.NewMetric(models.MetricWeight, 81).WithRecordedAt(day.Add(30 * time.Hour))
+	db.CreateMetric(t.Context(), inDay)
+	db.CreateMetric(t.Context(), nextDay)
+
+	// A bare until date includes the whole day
+	_, output, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{
+		Since: "2025-03-10",
+		Until: "2025-03-10",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	result, ok := output.(listMetricsOutput)
+	if !ok {
+		t.Fatalf("Expected listMetricsOutput, got %T", output)
+	}
+	if len(result.Metrics) != 1 || result.Metrics[0].ID != inDay.ID {
+		t.Errorf("Expected only the in-day metric, got %d metrics", len(result.Metrics))
+	}
+}
+
+func TestHandleListMetricsInvalidRange(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	_, _, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{
+		Since: "last tuesday",
+	})
+	if err == nil {
+		t.Error("Expected error for invalid since")
+	}
+}
+
+func TestHandleGetWorkoutIncludesSets(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	w := models.NewWorkout("lift")
+	db.CreateWorkout(t.Context(), w)
+	db.AddWorkoutSet(t.Context(), models.NewWorkoutSet(w.ID, "deadlift", 1, 5).WithWeight(180, "kg"))
+
+	_, output, err := server.handleGetWorkout(ctx, &mcp.CallToolRequest{}, getWorkoutInput{
+		ID: w.ID.String()[:8],
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, ok := output.(*models.Workout)
+	if !ok {
+		t.Fatalf("Expected workout output, got %T", output)
+	}
+	if len(got.Sets) != 1 || got.Sets[0].Exercise != "deadlift" {
+		t.Errorf("Expected deadlift set in output, got %+v", got.Sets)
+	}
+}
+
+func TestHandleSleepTools(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	quality := 8
+	_, added, err := server.handleAddSleep(ctx, &mcp.CallToolRequest{}, addSleepInput{
+		BedTime:  "2024-12-13T23:00:00Z",
+		WakeTime: "2024-12-14 06:30",
+		Quality:  &quality,
+	})
+	if err != nil {
+		t.Fatalf("handleAddSleep failed: %v", err)
+	}
+	if added.Hours != 7.5 {
+		t.Errorf("Expected 7.5 hours, got %v", added.Hours)
+	}
+
+	latest, err := db.GetLatestMetric(t.Context(), models.MetricSleepHours)
+	if err != nil || latest.Value != 7.5 {
+		t.Errorf("Expected derived sleep_hours metric of 7.5, got %v (%v)", latest, err)
+	}
+
+	_, listed, err := server.handleListSleep(ctx, &mcp.CallToolRequest{}, listSleepInput{})
+	if err != nil {
+		t.Fatalf("handleListSleep failed: %v", err)
+	}
+	sessions, ok := listed.([]sleepSessionOutput)
+	if !ok || len(sessions) != 1 {
+		t.Fatalf("Expected one session, got %#v", listed)
+	}
+	if sessions[0].Quality == nil || *sessions[0].Quality != 8 {
+		t.Error("Expected quality in list output")
+	}
+
+	if _, _, err := server.handleDeleteSleep(ctx, &mcp.CallToolRequest{}, deleteSleepInput{ID: added.ID}); err != nil {
+		t.Fatalf("handleDeleteSleep failed: %v", err)
+	}
+	if _, err := db.GetLatestMetric(t.Context(), models.MetricSleepHours); err == nil {
+		t.Error("Expected derived metric to be removed with the session")
+	}
+}
+
+func TestHandleAddSleepValidation(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		input addSleepInput
+	}{
+		{"bad bed time", addSleepInput{BedTime: "late", WakeTime: "2024-12-14T07:00:00Z"}},
+		{"wake before bed", addSleepInput{BedTime: "2024-12-14T07:00:00Z", WakeTime: "2024-12-13T23:00:00Z"}},
+		{"quality out of range", addSleepInput{BedTime: "2024-12-13T23:00:00Z", WakeTime: "2024-12-14T07:00:00Z", Quality: new(int)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := server.handleAddSleep(ctx, &mcp.CallToolRequest{}, tt.input); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestHandleMedicationTools(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	if _, _, err := server.handleAddMedication(ctx, &mcp.CallToolRequest{}, addMedicationInput{
+		Name: "Vitamin D", Dose: "1000 IU",
+	}); err != nil {
+		t.Fatalf("handleAddMedication failed: %v", err)
+	}
+	if _, _, err := server.handleAddMedication(ctx, &mcp.CallToolRequest{}, addMedicationInput{
+		Name: "Melatonin", Dose: "1 mg", Schedule: "sometimes",
+	}); err == nil {
+		t.Error("Expected error for unknown schedule")
+	}
+
+	if _, _, err := server.handleTakeMedication(ctx, &mcp.CallToolRequest{}, takeMedicationInput{Name: "vitamin-d"}); err != nil {
+		t.Fatalf("handleTakeMedication failed: %v", err)
+	}
+	if _, _, err := server.handleTakeMedication(ctx, &mcp.CallToolRequest{}, takeMedicationInput{Name: "unknown"}); err == nil {
+		t.Error("Expected error for unknown medication")
+	}
+
+	_, out, err := server.handleMedicationAdherence(ctx, &mcp.CallToolRequest{}, medicationAdherenceInput{Days: 7})
+	if err != nil {
+		t.Fatalf("handleMedicationAdherence failed: %v", err)
+	}
+	report, ok := out.([]medicationAdherenceOutput)
+	if !ok || len(report) != 1 {
+		t.Fatalf("Expected one adherence entry, got %#v", out)
+	}
+	// Added moments ago, so almost no doses are expected yet
+	if report[0].Taken != 1 || report[0].Expected >= 1 || len(report[0].TakenAt) != 1 {
+		t.Errorf("Unexpected adherence: %+v", report[0])
+	}
+	if report[0].Percent == nil || *report[0].Percent != 100 {
+		t.Errorf("Percent = %v, want 100 for a medication taken since it was added", report[0].Percent)
+	}
+
+	_, listed, err := server.handleListMedications(ctx, &mcp.CallToolRequest{}, struct{}{})
+	if err != nil {
+		t.Fatalf("handleListMedications failed: %v", err)
+	}
+	if meds, ok := listed.([]*models.Medication); !ok || len(meds) != 1 {
+		t.Errorf("Expected one medication, got %#v", listed)
+	}
+}
+
+func TestHandleLocationTools(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	lat, lon := 41.89, -87.62
+	if _, _, err := server.handleAddLocation(ctx, &mcp.CallToolRequest{}, addLocationInput{
+		Name: "gym", Latitude: &lat, Longitude: &lon,
+	}); err != nil {
+		t.Fatalf("handleAddLocation failed: %v", err)
+	}
+	if _, _, err := server.handleAddLocation(ctx, &mcp.CallToolRequest{}, addLocationInput{
+		Name: "office", Latitude: &lat,
+	}); err == nil {
+		t.Error("Expected error for latitude without longitude")
+	}
+
+	if _, _, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{
+		MetricType: "heart_rate", Value: 62, Location: "Gym",
+	}); err != nil {
+		t.Fatalf("handleAddMetric with location failed: %v", err)
+	}
+	if _, _, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{
+		MetricType: "heart_rate", Value: 60, Location: "nowhere",
+	}); err == nil {
+		t.Error("Expected error for unregistered location")
+	}
+	server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{MetricType: "heart_rate", Value: 58})
+	server.handleAddWorkout(ctx, &mcp.CallToolRequest{}, addWorkoutInput{WorkoutType: "lift", Location: "gym"})
+	server.handleAddWorkout(ctx, &mcp.CallToolRequest{}, addWorkoutInput{WorkoutType: "run"})
+
+	_, out, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{Location: "gym"})
+	if err != nil {
+		t.Fatalf("handleListMetrics failed: %v", err)
+	}
+	if list, ok := out.(listMetricsOutput); !ok || len(list.Metrics) != 1 {
+		t.Errorf("Expected 1 metric at gym, got %#v", out)
+	}
+
+	_, out, err = server.handleListWorkouts(ctx, &mcp.CallToolRequest{}, listWorkoutsInput{Location: "gym"})
+	if err != nil {
+		t.Fatalf("handleListWorkouts failed: %v", err)
+	}
+	if list, ok := out.(listWorkoutsOutput); !ok || len(list.Workouts) != 1 {
+		t.Errorf("Expected 1 workout at gym, got %#v", out)
+	}
+
+	_, out, err = server.handleListLocations(ctx, &mcp.CallToolRequest{}, struct{}{})
+	if err != nil {
+		t.Fatalf("handleListLocations failed: %v", err)
+	}
+	if locs, ok := out.([]*models.Location); !ok || len(locs) != 1 {
+		t.Errorf("Expected 1 location, got %#v", out)
+	}
+}
+
+func TestHandleGetDailyTotal(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	today := time.Now()
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWater, 250).WithRecordedAt(today))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWater, 500).WithRecordedAt(today))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWater, 1000).WithRecordedAt(today.AddDate(0, 0, -1)))
+
+	_, out, err := server.handleGetDailyTotal(ctx, &mcp.CallToolRequest{}, dailyTotalInput{MetricType: "water"})
+	if err != nil {
+		t.Fatalf("handleGetDailyTotal failed: %v", err)
+	}
+	if out.Total != 750 || out.Entries != 2 || out.Unit != "ml" {
+		t.Errorf("Expected 750 ml from 2 entries, got %+v", out)
+	}
+
+	if _, _, err := server.handleGetDailyTotal(ctx, &mcp.CallToolRequest{}, dailyTotalInput{MetricType: "weight"}); err == nil {
+		t.Error("Expected error for non-cumulative metric")
+	}
+}
+
+func TestHandleGetAverage(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	now := time.Now()
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 82).WithRecordedAt(now.AddDate(0, 0, -1)))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 84).WithRecordedAt(now.AddDate(0, 0, -3)))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 90).WithRecordedAt(now.AddDate(0, 0, -20)))
+
+	_, out, err := server.handleGetAverage(ctx, &mcp.CallToolRequest{}, averageInput{MetricType: "weight", Days: 7})
+	if err != nil {
+		t.Fatalf("handleGetAverage failed: %v", err)
+	}
+	if out.Average != 83 || out.Min != 82 || out.Max != 84 || out.Entries != 2 || out.Unit != "kg" {
+		t.Errorf("Expected 83 kg (82-84) from 2 entries, got %+v", out)
+	}
+
+	if _, out, _ := server.handleGetAverage(ctx, &mcp.CallToolRequest{}, averageInput{MetricType: "mood"}); out.Entries != 0 || out.Average != 0 {
+		t.Errorf("Expected nothing for an unlogged metric, got %+v", out)
+	}
+	if _, _, err := server.handleGetAverage(ctx, &mcp.CallToolRequest{}, averageInput{MetricType: "nope"}); err == nil {
+		t.Error("Expected error for unknown metric type")
+	}
+}
+
+func TestHandleAddMetricDuplicate(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	server.SetDuplicateWindows(models.DefaultDuplicateWindows())
+	ctx := context.Background()
+
+	_, first, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{MetricType: "weight", Value: 82.5})
+	if err != nil || first.Warning != "" {
+		t.Fatalf("first add = %+v, %v", first, err)
+	}
+	_, again, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{MetricType: "weight", Value: 82.4})
+	if err != nil {
+		t.Fatalf("second add failed: %v", err)
+	}
+	if again.Warning == "" || again.ID != first.ID || again.Value != 82.5 {
+		t.Errorf("Expected a warning pointing at the first entry, got %+v", again)
+	}
+	if _, forced, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{MetricType: "weight", Value: 82.4, Force: true}); err != nil || forced.Warning != "" {
+		t.Errorf("forced add = %+v, %v", forced, err)
+	}
+	weight := models.MetricWeight
+	if metrics, _ := db.ListMetrics(ctx, &weight, 0); len(metrics) != 2 {
+		t.Errorf("Expected 2 weights stored, got %d", len(metrics))
+	}
+
+	_, batch, _ := server.handleAddMetrics(ctx, &mcp.CallToolRequest{}, addMetricsInput{Metrics: []addMetricInput{
+		{MetricType: "weight", Value: 82},
+		{MetricType: "water", Value: 250},
+		{MetricType: "water", Value: 250},
+	}})
+	if batch.Added != 2 || batch.Duplicates != 1 || batch.Results[0].Metric.Warning == "" {
+		t.Errorf("Expected the weight skipped and both waters added, got %+v", batch)
+	}
+
+	server.handleAddBloodPressure(ctx, &mcp.CallToolRequest{}, addBloodPressureInput{Systolic: 120, Diastolic: 80})
+	if _, bp, err := server.handleAddBloodPressure(ctx, &mcp.CallToolRequest{}, addBloodPressureInput{Systolic: 121, Diastolic: 79}); err != nil || bp.Warning != "" {
+		t.Errorf("Expected repeated blood pressure readings allowed by default, got %+v, %v", bp, err)
+	}
+	windows := models.DefaultDuplicateWindows()
+	windows.ByType[models.MetricBPSys] = 10 * time.Minute
+	server.SetDuplicateWindows(windows)
+	if _, bp, err := server.handleAddBloodPressure(ctx, &mcp.CallToolRequest{}, addBloodPressureInput{Systolic: 122, Diastolic: 78}); err != nil || !strings.Contains(bp.Warning, "blood pressure reading") {
+		t.Errorf("Expected a duplicate warning for blood pressure with a bp_sys window, got %+v, %v", bp, err)
+	}
+}
+
+func TestHandleAddMetrics(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	_, out, err := server.handleAddMetrics(ctx, &mcp.CallToolRequest{}, addMetricsInput{
+		RecordedAt: "2024-12-14 20:00",
+		Metrics: []addMetricInput{
+			{MetricType: "calories", Value: 2100},
+			{MetricType: "protein", Value: 120},
+			{MetricType: "sleep_hours", Value: 7, RecordedAt: "2024-12-14 07:00"},
+			{MetricType: "happiness", Value: 8},
+			{MetricType: "mood", Value: 8},
+		},
+	})
+	if err != nil {
+		t.Fatalf("handleAddMetrics failed: %v", err)
+	}
+	if out.Added != 4 || out.Failed != 1 || len(out.Results) != 5 {
+		t.Fatalf("Expected 4 added and 1 failed, got %+v", out)
+	}
+	if bad := out.Results[3]; bad.Index != 3 || bad.Metric != nil || !strings.Contains(bad.Error, "happiness") {
+		t.Errorf("Expected the unknown type reported for entry 3, got %+v", bad)
+	}
+	if ok := out.Results[4]; ok.Metric == nil || ok.Metric.ID == "" || ok.Error != "" {
+		t.Errorf("Expected entry 4 added, got %+v", ok)
+	}
+
+	sleep := models.MetricSleepHours
+	metrics, _ := db.ListMetrics(t.Context(), &sleep, 0)
+	if len(metrics) != 1 || metrics[0].RecordedAt.In(time.Local).Hour() != 7 {
+		t.Errorf("Expected the entry's own recorded_at kept, got %+v", metrics)
+	}
+	mood := models.MetricMood
+	metrics, _ = db.ListMetrics(t.Context(), &mood, 0)
+	if len(metrics) != 1 || metrics[0].RecordedAt.In(time.Local).Hour() != 20 {
+		t.Errorf("Expected the shared recorded_at applied, got %+v", metrics)
+	}
+
+	if _, _, err := server.handleAddMetrics(ctx, &mcp.CallToolRequest{}, addMetricsInput{}); err == nil {
+		t.Error("Expected an empty list to be refused")
+	}
+	if _, _, err := server.handleAddMetrics(ctx, &mcp.CallToolRequest{}, addMetricsInput{Metrics: make([]addMetricInput, maxAddMetrics+1)}); err == nil {
+		t.Error("Expected an oversized list to be refused")
+	}
+}
+
+func TestHandleBloodPressureReadings(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	_, added, err := server.handleAddBloodPressure(ctx, &mcp.CallToolRequest{}, addBloodPressureInput{
+		Systolic: 120, Diastolic: 80, RecordedAt: "2024-12-14 08:00",
+	})
+	if err != nil {
+		t.Fatalf("handleAddBloodPressure failed: %v", err)
+	}
+	if added.Reading != "120/80" {
+		t.Errorf("Expected reading 120/80, got %q", added.Reading)
+	}
+	// A row logged before readings were linked pairs by timestamp
+	legacy := time.Date(2024, 12, 13, 8, 0, 0, 0, time.UTC)
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBPSys, 130).WithRecordedAt(legacy))
+	db.CreateMetric(t.Context(), models.NewMetric(models.MetricBPDia, 85).WithRecordedAt(legacy))
+
+	_, result, err := server.handleListMetrics(ctx, &mcp.CallToolRequest{}, listMetricsInput{})
+	if err != nil {
+		t.Fatalf("handleListMetrics failed: %v", err)
+	}
+	out := result.(listMetricsOutput)
+	if len(out.Metrics) != 2 || out.Metrics[0].Reading != "120/80" || out.Metrics[1].Reading != "130/85" {
+		t.Fatalf("Expected two paired readings, got %+v", out.Metrics)
+	}
+
+	_, latest, _ := server.handleGetLatest(ctx, &mcp.CallToolRequest{}, getLatestInput{MetricTypes: []string{"bp"}})
+	bp, ok := latest.(map[string]interface{})["bp"].(map[string]interface{})
+	if !ok || bp["reading"] != "120/80" {
+		t.Errorf("Expected latest bp 120/80, got %v", latest)
+	}
+
+	if _, _, err := server.handleDeleteMetric(ctx, &mcp.CallToolRequest{}, deleteMetricInput{ID: added.ID}); err != nil {
+		t.Fatalf("handleDeleteMetric failed: %v", err)
+	}
+	remaining, _ := db.ListMetrics(t.Context(), nil, 0)
+	if len(remaining) != 2 {
+		t.Errorf("Expected both halves of the reading deleted, %d metrics left", len(remaining))
+	}
+
+	if _, _, err := server.handleAddBloodPressure(ctx, &mcp.CallToolRequest{}, addBloodPressureInput{Systolic: 120}); err == nil {
+		t.Error("Expected error without diastolic")
+	}
+}
+
+func TestHandleAlerts(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	above := 140.0
+	server.SetAlerts([]models.Alert{{MetricType: models.MetricBPSys, Above: &above}})
+
+	_, normal, err := server.handleAddBloodPressure(ctx, &mcp.CallToolRequest{}, addBloodPressureInput{Systolic: 120, Diastolic: 80})
+	if err != nil {
+		t.Fatalf("handleAddBloodPressure failed: %v", err)
+	}
+	if len(normal.Alerts) != 0 {
+		t.Errorf("Expected no alerts for 120/80, got %v", normal.Alerts)
+	}
+
+	_, high, err := server.handleAddMetric(ctx, &mcp.CallToolRequest{}, addMetricInput{
+		MetricType: "bp_sys", Value: 152, RecordedAt: time.Now().Add(time.Minute).Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatalf("handleAddMetric failed: %v", err)
+	}
+	if len(high.Alerts) != 1 || !contains(high.Alerts[0], "above 140") {
+		t.Errorf("Expected an above-140 alert, got %v", high.Alerts)
+	}
+
+	result, err := server.handleSummaryResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("handleSummaryResource failed: %v", err)
+	}
+	if !contains(result.Contents[0].Text, "bp_sys 152 mmHg is above 140") {
+		t.Errorf("Expected active alert in summary, got %s", result.Contents[0].Text)
+	}
+}
+
+func TestHandleTodayResourceUpcomingAppointments(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	db.CreateAppointment(t.Context(), models.NewAppointment("Dr. Lee", time.Now().AddDate(0, 0, 2)))
+	db.CreateAppointment(t.Context(), models.NewAppointment("Old Visit", time.Now().AddDate(0, -1, 0)))
+
+	result, err := server.handleTodayResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("handleTodayResource failed: %v", err)
+	}
+	text := result.Contents[0].Text
+	if !contains(text, "upcoming_appointments") || !contains(text, "Dr. Lee") {
+		t.Errorf("Expected upcoming appointment in today view, got %s", text)
+	}
+	if contains(text, "Old Visit") {
+		t.Error("Expected past appointments left out")
+	}
+}
+
+func TestHandleInsightsResource(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := t.Context()
+
+	result, err := server.handleInsightsResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Contents[0].URI != "health://insights" || !contains(result.Contents[0].Text, `"insights": []`) {
+		t.Errorf("empty insights = %s", result.Contents[0].Text)
+	}
+
+	for i := 0; i < 3; i++ {
+		db.CreateMetric(ctx, models.NewMetric(models.MetricSleepHours, 5).WithRecordedAt(time.Now().AddDate(0, 0, -i)))
+	}
+	below := 6.0
+	server.SetInsightRules([]insights.Rule{
+		{Name: "short-sleep", Metric: "sleep_hours", Kind: insights.KindDays, Below: &below, AtLeast: 3, Days: 7},
+	})
+
+	result, err = server.handleInsightsResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got struct {
+		Insights []insights.Observation `json:"insights"`
+	}
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(got.Insights) != 1 || got.Insights[0].Message != "sleep_hours under 6 on 3 of the last 7 days" {
+		t.Errorf("insights = %+v", got.Insights)
+	}
+
+	summary, _ := server.handleSummaryResource(ctx, &mcp.ReadResourceRequest{})
+	if !contains(summary.Contents[0].Text, "sleep_hours under 6") {
+		t.Error("Expected the summary to carry insights")
+	}
+}
+
+func TestHandleNarrativeResource(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := t.Context()
+
+	result, err := server.handleNarrativeResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Contents[0].URI != "health://insights/summary" || !contains(result.Contents[0].Text, `"summary": []`) {
+		t.Errorf("empty summary = %s", result.Contents[0].Text)
+	}
+
+	for i := 0; i < 3; i++ {
+		db.CreateMetric(ctx, models.NewMetric(models.MetricSleepHours, 6.5).WithRecordedAt(time.Now().AddDate(0, 0, -i)))
+	}
+	server.SetGoals(map[string]float64{"sleep_hours": 7})
+
+	result, err = server.handleNarrativeResource(ctx, &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if want := "sleep_hours averaging 6.5 hours on 3 days logged, below your 7 hours goal"; !contains(result.Contents[0].Text, want) {
+		t.Errorf("summary = %s; want %q", result.Contents[0].Text, want)
+	}
+}
+
+func TestHandleVersionResource(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+
+	result, err := server.handleVersionResource(t.Context(), &mcp.ReadResourceRequest{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var got struct {
+		Server        string `json:"server"`
+		OutputVersion int    `json:"output_version"`
+		ExportFormat  string `json:"export_format"`
+	}
+	if err := json.Unmarshal([]byte(result.Contents[0].Text), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Server != ServerVersion || got.OutputVersion != OutputVersion || got.ExportFormat != storage.ExportFormatVersion {
+		t.Errorf("version resource = %+v", got)
+	}
+}
+
+func TestToolPermissions(t *testing.T) {
+	ctx := t.Context()
+	server, _ := NewServer(storage.NewMemoryStore())
+
+	if err := server.SetToolPermissions(nil, []string{"delete_*", "update_metric"}); err != nil {
+		t.Fatalf("SetToolPermissions failed: %v", err)
+	}
+	if server.ToolPermitted("delete_workout") || !server.ToolPermitted("add_metric") {
+		t.Error("Expected delete tools off and the rest on")
+	}
+	if err := server.SetToolPermissions(nil, []string{"delete_metrc"}); err == nil {
+		t.Error("Expected a misspelled tool to be rejected")
+	}
+	if err := server.SetToolPermissions([]string{"list_*", "get_*"}, []string{"get_workout"}); err != nil {
+		t.Fatalf("SetToolPermissions failed: %v", err)
+	}
+
+	server.registerTools()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.mcpServer.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("server connect failed: %v", err)
+	}
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect failed: %v", err)
+	}
+	defer session.Close()
+
+	res, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	var names []string
+	for _, tool := range res.Tools {
+		names = append(names, tool.Name)
+		if !strings.HasPrefix(tool.Name, "list_") && !strings.HasPrefix(tool.Name, "get_") || tool.Name == "get_workout" {
+			t.Errorf("Expected %s not to be registered", tool.Name)
+		}
+	}
+	if len(names) != 8 {
+		t.Errorf("Expected 8 list_ and get_ tools, got %v", names)
+	}
+	if _, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "delete_metric", Arguments: map[string]any{"id": "abc"}}); err == nil {
+		t.Error("Expected calling an unregistered tool to fail")
+	}
+}
+
+func TestHandleExportData(t *testing.T) {
+	db := setupTestDB(t)
+	server, _ := NewServer(db)
+	ctx := context.Background()
+
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 9, 0, 0, 0, time.Local) }
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 80).WithRecordedAt(day(1)))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 81).WithRecordedAt(day(10)))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7).WithRecordedAt(day(10)))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82).WithRecordedAt(day(20)))
+	db.CreateWorkout(ctx, models.NewWorkout("run").WithStartedAt(day(12)))
+
+	_, out, err := server.handleExportData(ctx, &mcp.CallToolRequest{}, exportDataInput{Since: "2025-03-05", Until: "2025-03-15"})
+	if err != nil {
+		t.Fatalf("handleExportData failed: %v", err)
+	}
+	var data storage.ExportData
+	if err := json.Unmarshal([]byte(out.Data), &data); err != nil {
+		t.Fatalf("export isn't JSON: %v", err)
+	}
+	if out.Format != "json" || len(data.Metrics) != 2 || len(data.Workouts) != 1 {
+		t.Errorf("Expected 2 metrics and 1 workout in range, got %d and %d", len(data.Metrics), len(data.Workouts))
+	}
+
+	_, out, err = server.handleExportData(ctx, &mcp.CallToolRequest{}, exportDataInput{Format: "markdown", MetricType: "weight", Until: "2025-03-10"})
+	if err != nil {
+		t.Fatalf("handleExportData markdown failed: %v", err)
+	}
+	if !strings.Contains(out.Data, "## weight") || !strings.Contains(out.Data, "81.00") || strings.Contains(out.Data, "82.00") || strings.Contains(out.Data, "mood") {
+		t.Errorf("Expected weights through Mar 10 only, got:\n%s", out.Data)
+	}
+
+	if _, _, err := server.handleExportData(ctx, &mcp.CallToolRequest{}, exportDataInput{Format: "csv"}); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if _, _, err := server.handleExportData(ctx, &mcp.CallToolRequest{}, exportDataInput{MetricType: "nope"}); err == nil {
+		t.Error("Expected error for unknown metric type")
+	}
+}
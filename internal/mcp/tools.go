@@ -4,109 +4,288 @@ package mcp
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/harperreed/health/internal/attachment"
 	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/reference"
+	"github.com/harperreed/health/internal/storage"
+	"github.com/harperreed/health/internal/timeparse"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-func (s *Server) registerTools() {
+// addTools catalogs every tool the server has; registerTools offers the
+// permitted ones to clients.
+func (s *Server) addTools() {
 	// add_metric
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "add_metric",
-		Description: "Record a health metric (weight, hrv, mood, etc.)",
+		Description: "Record a health metric (weight, hrv, mood, etc.), optionally tagged with a location name or 'lat,lon'. Pass unit (e.g. 'lb', 'oz', 'f') to convert from a unit other than the stored one, or instead of value and unit pass quantity as typed, e.g. '181lbs', '500ml', or '7h30m'; 'sleep' is short for sleep_hours. recorded_at takes RFC3339, 'YYYY-MM-DD HH:MM', or a phrase like '2 hours ago', 'this morning', or 'yesterday 7am'. metadata holds extra string details such as a device name or external ID. If the same type was logged within a few minutes, nothing is added and warning says so; pass force=true when it really is a new reading.",
 	}, s.handleAddMetric)
 
+	// add_metrics
+	addTool(s, &mcp.Tool{
+		Name:        "add_metrics",
+		Description: "Record several metrics in one call, e.g. everything in \"2100 kcal, 120g protein, slept 7h, mood 8\". Each entry takes the same fields as add_metric; recorded_at at the top level applies to entries without their own. Entries are added independently: each result reports its own ID or error (or a warning for a likely duplicate, which is skipped), and one bad entry doesn't stop the rest.",
+	}, s.handleAddMetrics)
+
+	// add_blood_pressure
+	addTool(s, &mcp.Tool{
+		Name:        "add_blood_pressure",
+		Description: "Record a blood pressure reading (systolic/diastolic in mmHg) as one linked reading. Repeated readings are expected and not checked for duplicates unless a bp_sys window is configured; then, like add_metric, one right after another is skipped with a warning unless force=true.",
+	}, s.handleAddBloodPressure)
+
 	// list_metrics
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "list_metrics",
-		Description: "List recent health metrics, optionally filtered by type",
+		Description: "List recent health metrics, optionally filtered by type, source (manual, fitbit, oura, withings, environment, mcp, ...), and a since/until date range (YYYY-MM-DD or RFC3339). Blood pressure readings appear once, as the bp_sys entry with reading set to e.g. '120/80'. Results are paged: total_count is how many entries match across all pages; pass next_cursor back as cursor to fetch older entries.",
 	}, s.handleListMetrics)
 
 	// delete_metric
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "delete_metric",
-		Description: "Delete a metric by ID or ID prefix",
+		Description: "Delete a metric by ID or ID prefix. Deleting either half of a blood pressure reading deletes both.",
 	}, s.handleDeleteMetric)
 
+	// update_metric
+	addTool(s, &mcp.Tool{
+		Name:        "update_metric",
+		Description: "Correct a metric by ID or ID prefix. metric_type moves an entry logged under the wrong type (e.g. steps that were calories) and switches to that type's unit; a value in a unit the new type accepts is converted, otherwise kept. value (with optional unit) replaces the value, notes the notes (empty clears them), and recorded_at the time. Blood pressure readings can't be retyped.",
+	}, s.handleUpdateMetric)
+
 	// add_workout
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "add_workout",
-		Description: "Create a new workout session",
+		Description: "Create a new workout session. rpe rates perceived exertion from 1 (very light) to 10 (max effort); zone is the intensity zone, 1 (recovery) to 5 (maximal). Outdoor workout types get temperature, humidity, and wind at the configured location attached; set weather to true or false to override. metadata holds extra string details such as a device name or GPS file.",
 	}, s.handleAddWorkout)
 
 	// add_workout_metric
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "add_workout_metric",
 		Description: "Add a metric to an existing workout",
 	}, s.handleAddWorkoutMetric)
 
 	// list_workouts
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "list_workouts",
-		Description: "List recent workouts, optionally filtered by type",
+		Description: "List recent workouts, optionally filtered by type, source (manual, mcp, ...), and a since/until date range (YYYY-MM-DD or RFC3339). total_count is how many match across all pages; pass next_cursor back as cursor to fetch older entries.",
 	}, s.handleListWorkouts)
 
 	// get_workout
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "get_workout",
 		Description: "Get a workout with all its metrics",
 	}, s.handleGetWorkout)
 
 	// delete_workout
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "delete_workout",
 		Description: "Delete a workout and its metrics",
 	}, s.handleDeleteWorkout)
 
 	// get_latest
-	mcp.AddTool(s.mcpServer, &mcp.Tool{
+	addTool(s, &mcp.Tool{
 		Name:        "get_latest",
-		Description: "Get the most recent value for one or more metric types",
+		Description: "Get the most recent value for one or more metric types. Use 'bp' for the latest blood pressure reading as '120/80'.",
 	}, s.handleGetLatest)
+
+	// get_daily_total
+	addTool(s, &mcp.Tool{
+		Name:        "get_daily_total",
+		Description: "Sum a cumulative metric (water, calories, protein, carbs, fat) over one day (YYYY-MM-DD, default today)",
+	}, s.handleGetDailyTotal)
+
+	// get_average
+	addTool(s, &mcp.Tool{
+		Name:        "get_average",
+		Description: "Average a metric over the last N days (default 30), with its lowest and highest values and how many entries there were",
+	}, s.handleGetAverage)
+
+	// add_sleep
+	addTool(s, &mcp.Tool{
+		Name:        "add_sleep",
+		Description: "Log a sleep session with bed and wake times (RFC3339, 'YYYY-MM-DD HH:MM', or a phrase like 'last night' or 'today 7am'). Also records the derived sleep_hours metric.",
+	}, s.handleAddSleep)
+
+	// list_sleep
+	addTool(s, &mcp.Tool{
+		Name:        "list_sleep",
+		Description: "List recent sleep sessions with bed/wake times, hours, awakenings, and quality",
+	}, s.handleListSleep)
+
+	// delete_sleep
+	addTool(s, &mcp.Tool{
+		Name:        "delete_sleep",
+		Description: "Delete a sleep session and its derived sleep_hours metric",
+	}, s.handleDeleteSleep)
+
+	// add_medication
+	addTool(s, &mcp.Tool{
+		Name:        "add_medication",
+		Description: "Define a medication or supplement with a dose and schedule (daily, twice daily, 3x daily, 4x daily, weekly, as needed)",
+	}, s.handleAddMedication)
+
+	// list_medications
+	addTool(s, &mcp.Tool{
+		Name:        "list_medications",
+		Description: "List defined medications and supplements",
+	}, s.handleListMedications)
+
+	// take_medication
+	addTool(s, &mcp.Tool{
+		Name:        "take_medication",
+		Description: "Log taking a medication, by name or ID prefix",
+	}, s.handleTakeMedication)
+
+	// medication_adherence
+	addTool(s, &mcp.Tool{
+		Name:        "medication_adherence",
+		Description: "Report doses taken vs. expected per medication over the last N days (default 7), with intake history",
+	}, s.handleMedicationAdherence)
+
+	// add_location
+	addTool(s, &mcp.Tool{
+		Name:        "add_location",
+		Description: "Register a named location (e.g. home, gym) with optional coordinates, for tagging metrics and workouts",
+	}, s.handleAddLocation)
+
+	// list_locations
+	addTool(s, &mcp.Tool{
+		Name:        "list_locations",
+		Description: "List registered locations",
+	}, s.handleListLocations)
+
+	// export_data
+	addTool(s, &mcp.Tool{
+		Name:        "export_data",
+		Description: "Export a snapshot of the data for sharing, e.g. a summary for a doctor. format is 'json' (default) or 'markdown'. metric_type keeps just that metric; since and until (YYYY-MM-DD or RFC3339, until inclusive of its day) keep entries in the range. Derived metrics such as BMI are included.",
+	}, s.handleExportData)
 }
 
 // Tool input/output types
 
 type addMetricInput struct {
-	MetricType string  `json:"metric_type"`
-	Value      float64 `json:"value"`
-	RecordedAt string  `json:"recorded_at,omitempty"`
-	Notes      string  `json:"notes,omitempty"`
+	MetricType string            `json:"metric_type"`
+	Value      float64           `json:"value,omitempty"`
+	Unit       string            `json:"unit,omitempty"`
+	Quantity   string            `json:"quantity,omitempty"`
+	Force      bool              `json:"force,omitempty"`
+	RecordedAt string            `json:"recorded_at,omitempty"`
+	Notes      string            `json:"notes,omitempty"`
+	Location   string            `json:"location,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
 }
 
 type metricOutput struct {
-	ID         string  `json:"id"`
-	MetricType string  `json:"metric_type"`
-	Value      float64 `json:"value"`
-	Unit       string  `json:"unit"`
-	Message    string  `json:"message"`
+	ID         string   `json:"id"`
+	MetricType string   `json:"metric_type"`
+	Value      float64  `json:"value"`
+	Unit       string   `json:"unit"`
+	Message    string   `json:"message"`
+	Alerts     []string `json:"alerts,omitempty"`
+	// Warning is set, and nothing added, when the metric looks like one
+	// already logged; ID and Value are then the existing entry's.
+	Warning string `json:"warning,omitempty"`
+}
+
+type addMetricsInput struct {
+	Metrics    []addMetricInput `json:"metrics"`
+	RecordedAt string           `json:"recorded_at,omitempty"`
+}
+
+type addMetricsResult struct {
+	Index  int           `json:"index"`
+	Metric *metricOutput `json:"metric,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+type addMetricsOutput struct {
+	Added      int                `json:"added"`
+	Duplicates int                `json:"duplicates,omitempty"`
+	Failed     int                `json:"failed"`
+	Results    []addMetricsResult `json:"results"`
 }
 
 type listMetricsInput struct {
 	MetricType string `json:"metric_type,omitempty"`
+	Location   string `json:"location,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Since      string `json:"since,omitempty"`
+	Until      string `json:"until,omitempty"`
 	Limit      int    `json:"limit,omitempty"`
+	Cursor     string `json:"cursor,omitempty"`
+}
+
+type addBloodPressureInput struct {
+	Systolic   float64 `json:"systolic"`
+	Diastolic  float64 `json:"diastolic"`
+	RecordedAt string  `json:"recorded_at,omitempty"`
+	Notes      string  `json:"notes,omitempty"`
+	Location   string  `json:"location,omitempty"`
+	Force      bool    `json:"force,omitempty"`
+}
+
+type bloodPressureOutput struct {
+	ID      string   `json:"id"`
+	Reading string   `json:"reading"`
+	Message string   `json:"message"`
+	Alerts  []string `json:"alerts,omitempty"`
+	Warning string   `json:"warning,omitempty"`
+}
+
+// metricItem is a metric in a listing. Paired blood pressure is listed
+// once, as the systolic half with Reading set to "120/80".
+type metricItem struct {
+	*models.Metric
+	Reading string `json:"reading,omitempty"`
+}
+
+type listMetricsOutput struct {
+	Metrics []metricItem `json:"metrics"`
+	// TotalCount is every entry matching the filters, across all pages; a
+	// blood pressure reading counts once.
+	TotalCount int    `json:"total_count"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type deleteMetricInput struct {
 	ID string `json:"id"`
 }
 
+type updateMetricInput struct {
+	ID         string   `json:"id"`
+	MetricType string   `json:"metric_type,omitempty"`
+	Value      *float64 `json:"value,omitempty"`
+	Unit       string   `json:"unit,omitempty"`
+	Notes      *string  `json:"notes,omitempty"`
+	RecordedAt string   `json:"recorded_at,omitempty"`
+}
+
 type simpleOutput struct {
 	Message string `json:"message"`
 }
 
 type addWorkoutInput struct {
-	WorkoutType     string `json:"workout_type"`
-	DurationMinutes int    `json:"duration_minutes,omitempty"`
-	Notes           string `json:"notes,omitempty"`
+	WorkoutType     string            `json:"workout_type"`
+	DurationMinutes int               `json:"duration_minutes,omitempty"`
+	RPE             *int              `json:"rpe,omitempty"`
+	Zone            *int              `json:"zone,omitempty"`
+	Notes           string            `json:"notes,omitempty"`
+	Location        string            `json:"location,omitempty"`
+	Weather         *bool             `json:"weather,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
 }
 
 type workoutOutput struct {
 	ID          string `json:"id"`
 	WorkoutType string `json:"workout_type"`
 	Message     string `json:"message"`
+	Weather     string `json:"weather,omitempty"`
 }
 
 type addWorkoutMetricInput struct {
@@ -118,7 +297,18 @@ type addWorkoutMetricInput struct {
 
 type listWorkoutsInput struct {
 	WorkoutType string `json:"workout_type,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Since       string `json:"since,omitempty"`
+	Until       string `json:"until,omitempty"`
 	Limit       int    `json:"limit,omitempty"`
+	Cursor      string `json:"cursor,omitempty"`
+}
+
+type listWorkoutsOutput struct {
+	Workouts   []*models.Workout `json:"workouts"`
+	TotalCount int               `json:"total_count"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
 
 type getWorkoutInput struct {
@@ -129,39 +319,301 @@ type getLatestInput struct {
 	MetricTypes []string `json:"metric_types,omitempty"`
 }
 
+type dailyTotalInput struct {
+	MetricType string `json:"metric_type"`
+	Date       string `json:"date,omitempty"`
+}
+
+type dailyTotalOutput struct {
+	MetricType string  `json:"metric_type"`
+	Date       string  `json:"date"`
+	Total      float64 `json:"total"`
+	Unit       string  `json:"unit"`
+	Entries    int     `json:"entries"`
+}
+
+type averageInput struct {
+	MetricType string `json:"metric_type"`
+	Days       int    `json:"days,omitempty"`
+}
+
+type averageOutput struct {
+	MetricType string  `json:"metric_type"`
+	Since      string  `json:"since"`
+	Average    float64 `json:"average"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max"`
+	Unit       string  `json:"unit"`
+	Entries    int     `json:"entries"`
+}
+
+type exportDataInput struct {
+	Format     string `json:"format,omitempty"`
+	MetricType string `json:"metric_type,omitempty"`
+	Since      string `json:"since,omitempty"`
+	Until      string `json:"until,omitempty"`
+}
+
+type exportDataOutput struct {
+	Format string `json:"format"`
+	Data   string `json:"data"`
+}
+
+type addSleepInput struct {
+	BedTime    string `json:"bed_time"`
+	WakeTime   string `json:"wake_time"`
+	Awakenings *int   `json:"awakenings,omitempty"`
+	Quality    *int   `json:"quality,omitempty"`
+	Notes      string `json:"notes,omitempty"`
+}
+
+type sleepOutput struct {
+	ID      string  `json:"id"`
+	Hours   float64 `json:"hours"`
+	Message string  `json:"message"`
+}
+
+type listSleepInput struct {
+	Limit int `json:"limit,omitempty"`
+}
+
+type sleepSessionOutput struct {
+	ID         string  `json:"id"`
+	BedTime    string  `json:"bed_time"`
+	WakeTime   string  `json:"wake_time"`
+	Hours      float64 `json:"hours"`
+	Awakenings *int    `json:"awakenings,omitempty"`
+	Quality    *int    `json:"quality,omitempty"`
+	Notes      *string `json:"notes,omitempty"`
+}
+
+type deleteSleepInput struct {
+	ID string `json:"id"`
+}
+
+type addMedicationInput struct {
+	Name     string `json:"name"`
+	Dose     string `json:"dose"`
+	Schedule string `json:"schedule,omitempty"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+type takeMedicationInput struct {
+	Name    string `json:"name"`
+	TakenAt string `json:"taken_at,omitempty"`
+	Dose    string `json:"dose,omitempty"`
+	Notes   string `json:"notes,omitempty"`
+}
+
+type addLocationInput struct {
+	Name      string   `json:"name"`
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Notes     string   `json:"notes,omitempty"`
+}
+
+type medicationAdherenceInput struct {
+	Name string `json:"name,omitempty"`
+	Days int    `json:"days,omitempty"`
+}
+
+type medicationAdherenceOutput struct {
+	Medication string   `json:"medication"`
+	Dose       string   `json:"dose"`
+	Schedule   string   `json:"schedule"`
+	Taken      int      `json:"taken"`
+	Expected   float64  `json:"expected"`
+	Percent    *float64 `json:"percent,omitempty"`
+	TakenAt    []string `json:"taken_at"`
+}
+
 // Tool handlers
 
 func (s *Server) handleAddMetric(ctx context.Context, req *mcp.CallToolRequest, input addMetricInput) (*mcp.CallToolResult, metricOutput, error) {
+	input.MetricType = string(models.CanonicalMetricType(input.MetricType))
 	if !models.IsValidMetricType(input.MetricType) {
 		return nil, metricOutput{}, fmt.Errorf("unknown metric type: %s", input.MetricType)
 	}
 
-	m := models.NewMetric(models.MetricType(input.MetricType), input.Value)
+	var value float64
+	var err error
+	if input.Quantity != "" {
+		if input.Value != 0 || input.Unit != "" {
+			return nil, metricOutput{}, fmt.Errorf("give either quantity or value and unit, not both")
+		}
+		value, err = models.ParseValue(models.MetricType(input.MetricType), input.Quantity)
+	} else {
+		value, err = models.ConvertValue(models.MetricType(input.MetricType), input.Value, input.Unit)
+	}
+	if err != nil {
+		return nil, metricOutput{}, err
+	}
+	m := models.NewMetric(models.MetricType(input.MetricType), value).WithSource(models.SourceMCP)
 
 	if input.RecordedAt != "" {
-		t, err := time.Parse(time.RFC3339, input.RecordedAt)
+		t, err := s.parseTimestamp(input.RecordedAt)
 		if err != nil {
-			t, err = time.Parse("2006-01-02 15:04", input.RecordedAt)
-		}
-		if err == nil {
-			m.WithRecordedAt(t)
+			return nil, metricOutput{}, fmt.Errorf("invalid recorded_at: %w", err)
 		}
+		m.WithRecordedAt(t)
 	}
 
 	if input.Notes != "" {
 		m.WithNotes(input.Notes)
 	}
+	for k, v := range input.Metadata {
+		m.WithMetadata(k, v)
+	}
+
+	if input.Location != "" {
+		tag, err := storage.ResolveLocationTag(ctx, s.repo, input.Location)
+		if err != nil {
+			return nil, metricOutput{}, err
+		}
+		m.WithLocation(tag)
+	}
+	location, err := storage.TagFromTrip(ctx, s.repo, m.Location, m.RecordedAt)
+	if err != nil {
+		return nil, metricOutput{}, err
+	}
+	m.Location = location
 
-	if err := s.repo.CreateMetric(m); err != nil {
+	if !input.Force {
+		existing, err := storage.FindDuplicate(ctx, s.repo, s.duplicates, m)
+		if err != nil {
+			return nil, metricOutput{}, err
+		}
+		if existing != nil {
+			warning := models.DuplicateWarning(existing, m)
+			return nil, metricOutput{
+				ID:         existing.ID.String()[:8],
+				MetricType: input.MetricType,
+				Value:      existing.Value,
+				Unit:       existing.Unit,
+				Message:    fmt.Sprintf("Not added: %s. Pass force=true if this is a new reading.", warning),
+				Warning:    warning,
+			}, nil
+		}
+	}
+
+	if err := s.repo.CreateMetric(ctx, m); err != nil {
 		return nil, metricOutput{}, fmt.Errorf("failed to create metric: %w", err)
 	}
 
+	alerts, err := s.checkAlerts(ctx, m)
+	if err != nil {
+		return nil, metricOutput{}, err
+	}
+
 	return nil, metricOutput{
 		ID:         m.ID.String()[:8],
 		MetricType: input.MetricType,
 		Value:      m.Value,
 		Unit:       m.Unit,
 		Message:    fmt.Sprintf("Added %s: %.2f %s (ID: %s)", input.MetricType, m.Value, m.Unit, m.ID.String()[:8]),
+		Alerts:     alerts,
+	}, nil
+}
+
+// maxAddMetrics caps one add_metrics call, so a runaway list can't flood
+// the store.
+const maxAddMetrics = 50
+
+func (s *Server) handleAddMetrics(ctx context.Context, req *mcp.CallToolRequest, input addMetricsInput) (*mcp.CallToolResult, addMetricsOutput, error) {
+	if len(input.Metrics) == 0 {
+		return nil, addMetricsOutput{}, fmt.Errorf("metrics is empty")
+	}
+	if len(input.Metrics) > maxAddMetrics {
+		return nil, addMetricsOutput{}, fmt.Errorf("%d metrics in one call; the limit is %d", len(input.Metrics), maxAddMetrics)
+	}
+
+	out := addMetricsOutput{Results: make([]addMetricsResult, 0, len(input.Metrics))}
+	for i, entry := range input.Metrics {
+		if entry.RecordedAt == "" {
+			entry.RecordedAt = input.RecordedAt
+		}
+		result := addMetricsResult{Index: i}
+		_, added, err := s.handleAddMetric(ctx, req, entry)
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			out.Failed++
+		case added.Warning != "":
+			result.Metric = &added
+			out.Duplicates++
+		default:
+			result.Metric = &added
+			out.Added++
+		}
+		out.Results = append(out.Results, result)
+	}
+	return nil, out, nil
+}
+
+func (s *Server) handleAddBloodPressure(ctx context.Context, req *mcp.CallToolRequest, input addBloodPressureInput) (*mcp.CallToolResult, bloodPressureOutput, error) {
+	if input.Systolic <= 0 || input.Diastolic <= 0 {
+		return nil, bloodPressureOutput{}, fmt.Errorf("systolic and diastolic are required")
+	}
+
+	recordedAt := time.Now()
+	if input.RecordedAt != "" {
+		t, err := s.parseTimestamp(input.RecordedAt)
+		if err != nil {
+			return nil, bloodPressureOutput{}, fmt.Errorf("invalid recorded_at: %w", err)
+		}
+		recordedAt = t
+	}
+
+	sys, dia := models.NewBloodPressure(input.Systolic, input.Diastolic, recordedAt)
+	sys.WithSource(models.SourceMCP)
+	dia.WithSource(models.SourceMCP)
+	if input.Notes != "" {
+		sys.WithNotes(input.Notes)
+		dia.WithNotes(input.Notes)
+	}
+	if input.Location != "" {
+		tag, err := storage.ResolveLocationTag(ctx, s.repo, input.Location)
+		if err != nil {
+			return nil, bloodPressureOutput{}, err
+		}
+		sys.WithLocation(tag)
+	}
+	location, err := storage.TagFromTrip(ctx, s.repo, sys.Location, recordedAt)
+	if err != nil {
+		return nil, bloodPressureOutput{}, err
+	}
+	sys.Location, dia.Location = location, location
+
+	if !input.Force {
+		existing, err := storage.FindDuplicate(ctx, s.repo, s.duplicates, sys)
+		if err != nil {
+			return nil, bloodPressureOutput{}, err
+		}
+		if existing != nil {
+			warning := models.DuplicateWarning(existing, sys)
+			return nil, bloodPressureOutput{
+				ID:      existing.ID.String()[:8],
+				Message: fmt.Sprintf("Not added: %s. Pass force=true if this is a new reading.", warning),
+				Warning: warning,
+			}, nil
+		}
+	}
+
+	if err := storage.RecordBloodPressure(ctx, s.repo, sys, dia); err != nil {
+		return nil, bloodPressureOutput{}, fmt.Errorf("failed to add blood pressure: %w", err)
+	}
+
+	alerts, err := s.checkAlerts(ctx, sys, dia)
+	if err != nil {
+		return nil, bloodPressureOutput{}, err
+	}
+
+	reading := models.MetricEntry{Metric: sys, Diastolic: dia}.Reading()
+	return nil, bloodPressureOutput{
+		ID:      sys.ID.String()[:8],
+		Reading: reading,
+		Message: fmt.Sprintf("Added blood pressure: %s mmHg (ID: %s)", reading, sys.ID.String()[:8]),
+		Alerts:  alerts,
 	}, nil
 }
 
@@ -170,13 +622,50 @@ func (s *Server) handleListMetrics(ctx context.Context, req *mcp.CallToolRequest
 		input.Limit = 20
 	}
 
+	offset, err := decodeCursor(input.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	since, until, err := parseRange(input.Since, input.Until, s.zone)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var metricType *models.MetricType
 	if input.MetricType != "" {
 		mt := models.MetricType(input.MetricType)
 		metricType = &mt
 	}
 
-	metrics, err := s.repo.ListMetrics(metricType, input.Limit)
+	var location *string
+	if input.Location != "" {
+		tag, err := storage.ResolveLocationTag(ctx, s.repo, input.Location)
+		if err != nil {
+			return nil, nil, err
+		}
+		location = &tag
+	}
+
+	var source *string
+	if input.Source != "" {
+		source = &input.Source
+	}
+
+	// The cursor counts rows, and a blood pressure reading is two, so fetch
+	// enough rows for one entry more than a page to learn whether another
+	// page exists. A reading's halves share a timestamp, so they're adjacent
+	// and a page never splits them.
+	filter := storage.MetricFilter{
+		Type:     metricType,
+		Location: location,
+		Source:   source,
+		Since:    since,
+		Until:    until,
+		Limit:    2 * (input.Limit + 1),
+		Offset:   offset,
+	}
+	metrics, err := s.repo.QueryMetrics(ctx, filter)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list metrics: %w", err)
 	}
@@ -185,47 +674,242 @@ func (s *Server) handleListMetrics(ctx context.Context, req *mcp.CallToolRequest
 		return nil, map[string]interface{}{"message": "No metrics found."}, nil
 	}
 
-	return nil, metrics, nil
+	total, err := storage.CountReadings(ctx, s.repo, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count metrics: %w", err)
+	}
+	output := listMetricsOutput{TotalCount: total}
+	entries := models.GroupReadings(metrics)
+	if len(entries) > input.Limit {
+		entries = entries[:input.Limit]
+		rows := 0
+		for _, e := range entries {
+			rows++
+			if e.IsBloodPressure() {
+				rows++
+			}
+		}
+		output.NextCursor = encodeCursor(offset + rows)
+	}
+	for _, e := range entries {
+		output.Metrics = append(output.Metrics, metricItem{Metric: e.Metric, Reading: e.Reading()})
+	}
+
+	return nil, output, nil
+}
+
+// parseRange parses optional since/until parameters. Both accept a bare
+// YYYY-MM-DD date (in loc) or an RFC3339 timestamp. A bare until date
+// includes that whole day.
+func parseRange(sinceStr, untilStr string, loc *time.Location) (since, until *time.Time, err error) {
+	if sinceStr != "" {
+		t, _, err := parseDateOrTime(sinceStr, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid since: %s (use YYYY-MM-DD or RFC3339)", sinceStr)
+		}
+		since = &t
+	}
+	if untilStr != "" {
+		t, dateOnly, err := parseDateOrTime(untilStr, loc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid until: %s (use YYYY-MM-DD or RFC3339)", untilStr)
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		until = &t
+	}
+	return since, until, nil
+}
+
+// parseDateOrTime parses an RFC3339 timestamp or a bare date in loc,
+// reporting whether the input was date-only.
+func parseDateOrTime(s string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, loc)
+	return t, true, err
+}
+
+// cursorPrefix tags encoded cursors so malformed input is rejected early.
+const cursorPrefix = "offset:"
+
+// encodeCursor turns a result offset into an opaque pagination cursor.
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+// decodeCursor converts a cursor from a previous page back into an offset.
+// An empty cursor means the first page.
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+	return offset, nil
 }
 
 func (s *Server) handleDeleteMetric(ctx context.Context, req *mcp.CallToolRequest, input deleteMetricInput) (*mcp.CallToolResult, simpleOutput, error) {
-	if err := s.repo.DeleteMetric(input.ID); err != nil {
+	deleted, err := storage.DeleteReading(ctx, s.repo, input.ID)
+	if err != nil {
 		return nil, simpleOutput{}, fmt.Errorf("failed to delete metric: %w", err)
 	}
+	for _, m := range deleted {
+		if err := attachment.RemoveMetric(s.dataDir, m); err != nil {
+			return nil, simpleOutput{}, err
+		}
+	}
 
+	if len(deleted) > 1 {
+		return nil, simpleOutput{
+			Message: fmt.Sprintf("Deleted blood pressure reading: %s", input.ID),
+		}, nil
+	}
 	return nil, simpleOutput{
 		Message: fmt.Sprintf("Deleted metric: %s", input.ID),
 	}, nil
 }
 
+func (s *Server) handleUpdateMetric(ctx context.Context, req *mcp.CallToolRequest, input updateMetricInput) (*mcp.CallToolResult, metricOutput, error) {
+	m, err := s.repo.GetMetric(ctx, input.ID)
+	if err != nil {
+		return nil, metricOutput{}, fmt.Errorf("metric not found: %s", input.ID)
+	}
+	from := m.MetricType
+	partner, err := storage.ReadingPartner(ctx, s.repo, m)
+	if err != nil {
+		return nil, metricOutput{}, err
+	}
+
+	if input.MetricType != "" && models.MetricType(input.MetricType) != m.MetricType {
+		if err := m.Retype(models.MetricType(input.MetricType)); err != nil {
+			return nil, metricOutput{}, err
+		}
+	}
+	if input.Value != nil {
+		value, err := models.ConvertValue(m.MetricType, *input.Value, input.Unit)
+		if err != nil {
+			return nil, metricOutput{}, err
+		}
+		m.Value = value
+	}
+	if input.Notes != nil {
+		m.Notes = nil
+		if *input.Notes != "" {
+			m.WithNotes(*input.Notes)
+		}
+	}
+	if input.RecordedAt != "" {
+		t, err := s.parseTimestamp(input.RecordedAt)
+		if err != nil {
+			return nil, metricOutput{}, fmt.Errorf("invalid recorded_at: %w", err)
+		}
+		m.WithRecordedAt(t)
+	}
+
+	if err := s.repo.UpdateMetric(ctx, m); err != nil {
+		return nil, metricOutput{}, fmt.Errorf("failed to update metric: %w", err)
+	}
+	// Both halves of a blood pressure reading keep the same time
+	if partner != nil && !partner.RecordedAt.Equal(m.RecordedAt) {
+		partner.WithRecordedAt(m.RecordedAt)
+		if err := s.repo.UpdateMetric(ctx, partner); err != nil {
+			return nil, metricOutput{}, fmt.Errorf("failed to update %s: %w", partner.MetricType, err)
+		}
+	}
+
+	message := fmt.Sprintf("Updated %s: %.2f %s (ID: %s)", m.MetricType, m.Value, m.Unit, m.ID.String()[:8])
+	if m.MetricType != from {
+		message = fmt.Sprintf("Moved %s to %s: %.2f %s (ID: %s)", from, m.MetricType, m.Value, m.Unit, m.ID.String()[:8])
+	}
+	return nil, metricOutput{
+		ID:         m.ID.String()[:8],
+		MetricType: string(m.MetricType),
+		Value:      m.Value,
+		Unit:       m.Unit,
+		Message:    message,
+	}, nil
+}
+
 func (s *Server) handleAddWorkout(ctx context.Context, req *mcp.CallToolRequest, input addWorkoutInput) (*mcp.CallToolResult, workoutOutput, error) {
-	w := models.NewWorkout(input.WorkoutType)
+	w := models.NewWorkout(input.WorkoutType).WithSource(models.SourceMCP)
 	if input.DurationMinutes > 0 {
 		w.WithDuration(input.DurationMinutes)
 	}
+	w.RPE, w.Zone = input.RPE, input.Zone
+	if err := w.ValidateIntensity(); err != nil {
+		return nil, workoutOutput{}, err
+	}
 	if input.Notes != "" {
 		w.WithNotes(input.Notes)
 	}
+	for k, v := range input.Metadata {
+		w.WithMetadata(k, v)
+	}
+	if input.Location != "" {
+		tag, err := storage.ResolveLocationTag(ctx, s.repo, input.Location)
+		if err != nil {
+			return nil, workoutOutput{}, err
+		}
+		w.WithLocation(tag)
+	}
+	location, err := storage.TagFromTrip(ctx, s.repo, w.Location, w.StartedAt)
+	if err != nil {
+		return nil, workoutOutput{}, err
+	}
+	w.Location = location
 
-	if err := s.repo.CreateWorkout(w); err != nil {
+	if err := s.repo.CreateWorkout(ctx, w); err != nil {
 		return nil, workoutOutput{}, fmt.Errorf("failed to create workout: %w", err)
 	}
 
-	return nil, workoutOutput{
+	out := workoutOutput{
 		ID:          w.ID.String()[:8],
 		WorkoutType: input.WorkoutType,
 		Message:     fmt.Sprintf("Added %s workout (ID: %s)", input.WorkoutType, w.ID.String()[:8]),
-	}, nil
+	}
+
+	enrich := s.enricher != nil && s.enricher.IsOutdoor(input.WorkoutType)
+	if input.Weather != nil {
+		enrich = *input.Weather && s.enricher != nil
+	}
+	if enrich {
+		// The workout is already saved; report weather failures instead of failing the call.
+		added, err := s.enricher.Enrich(ctx, s.repo, w)
+		if err != nil {
+			out.Weather = fmt.Sprintf("unavailable: %v", err)
+		} else {
+			parts := make([]string, 0, len(added))
+			for _, wm := range added {
+				unit := ""
+				if wm.Unit != nil {
+					unit = *wm.Unit
+				}
+				parts = append(parts, fmt.Sprintf("%s %.1f %s", wm.MetricName, wm.Value, unit))
+			}
+			out.Weather = strings.Join(parts, ", ")
+		}
+	}
+
+	return nil, out, nil
 }
 
 func (s *Server) handleAddWorkoutMetric(ctx context.Context, req *mcp.CallToolRequest, input addWorkoutMetricInput) (*mcp.CallToolResult, simpleOutput, error) {
-	w, err := s.repo.GetWorkout(input.WorkoutID)
+	w, err := s.repo.GetWorkout(ctx, input.WorkoutID)
 	if err != nil {
 		return nil, simpleOutput{}, fmt.Errorf("workout not found: %s", input.WorkoutID)
 	}
 
 	wm := models.NewWorkoutMetric(w.ID, input.MetricName, input.Value, input.Unit)
-	if err := s.repo.AddWorkoutMetric(wm); err != nil {
+	if err := s.repo.AddWorkoutMetric(ctx, wm); err != nil {
 		return nil, simpleOutput{}, fmt.Errorf("failed to add workout metric: %w", err)
 	}
 
@@ -239,12 +923,46 @@ func (s *Server) handleListWorkouts(ctx context.Context, req *mcp.CallToolReques
 		input.Limit = 20
 	}
 
+	offset, err := decodeCursor(input.Cursor)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	since, until, err := parseRange(input.Since, input.Until, s.zone)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var workoutType *string
 	if input.WorkoutType != "" {
 		workoutType = &input.WorkoutType
 	}
 
-	workouts, err := s.repo.ListWorkouts(workoutType, input.Limit)
+	var location *string
+	if input.Location != "" {
+		tag, err := storage.ResolveLocationTag(ctx, s.repo, input.Location)
+		if err != nil {
+			return nil, nil, err
+		}
+		location = &tag
+	}
+
+	var source *string
+	if input.Source != "" {
+		source = &input.Source
+	}
+
+	// Fetch one extra row to learn whether another page exists.
+	filter := storage.WorkoutFilter{
+		Type:     workoutType,
+		Location: location,
+		Source:   source,
+		Since:    since,
+		Until:    until,
+		Limit:    input.Limit + 1,
+		Offset:   offset,
+	}
+	workouts, err := s.repo.QueryWorkouts(ctx, filter)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to list workouts: %w", err)
 	}
@@ -253,11 +971,22 @@ func (s *Server) handleListWorkouts(ctx context.Context, req *mcp.CallToolReques
 		return nil, map[string]interface{}{"message": "No workouts found."}, nil
 	}
 
-	return nil, workouts, nil
+	total, err := s.repo.CountWorkouts(ctx, filter)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to count workouts: %w", err)
+	}
+	output := listWorkoutsOutput{TotalCount: total}
+	if len(workouts) > input.Limit {
+		workouts = workouts[:input.Limit]
+		output.NextCursor = encodeCursor(offset + input.Limit)
+	}
+	output.Workouts = workouts
+
+	return nil, output, nil
 }
 
 func (s *Server) handleGetWorkout(ctx context.Context, req *mcp.CallToolRequest, input getWorkoutInput) (*mcp.CallToolResult, any, error) {
-	w, err := s.repo.GetWorkoutWithMetrics(input.ID)
+	w, err := s.repo.GetWorkoutWithMetrics(ctx, input.ID)
 	if err != nil {
 		return nil, nil, fmt.Errorf("workout not found: %s", input.ID)
 	}
@@ -266,9 +995,16 @@ func (s *Server) handleGetWorkout(ctx context.Context, req *mcp.CallToolRequest,
 }
 
 func (s *Server) handleDeleteWorkout(ctx context.Context, req *mcp.CallToolRequest, input getWorkoutInput) (*mcp.CallToolResult, simpleOutput, error) {
-	if err := s.repo.DeleteWorkout(input.ID); err != nil {
+	w, err := s.repo.GetWorkout(ctx, input.ID)
+	if err != nil {
 		return nil, simpleOutput{}, fmt.Errorf("failed to delete workout: %w", err)
 	}
+	if err := s.repo.DeleteWorkout(ctx, w.ID.String()); err != nil {
+		return nil, simpleOutput{}, fmt.Errorf("failed to delete workout: %w", err)
+	}
+	if err := attachment.RemoveWorkout(s.dataDir, w); err != nil {
+		return nil, simpleOutput{}, err
+	}
 
 	return nil, simpleOutput{
 		Message: fmt.Sprintf("Deleted workout: %s", input.ID),
@@ -286,8 +1022,11 @@ func (s *Server) handleGetLatest(ctx context.Context, req *mcp.CallToolRequest,
 
 	results := make(map[string]interface{})
 	for _, t := range types {
+		if t == "bp" {
+			continue
+		}
 		mt := models.MetricType(t)
-		metrics, err := s.repo.ListMetrics(&mt, 1)
+		metrics, err := s.repo.ListMetrics(ctx, &mt, 1)
 		if err == nil && len(metrics) > 0 {
 			results[t] = map[string]interface{}{
 				"value":       metrics[0].Value,
@@ -297,5 +1036,395 @@ func (s *Server) handleGetLatest(ctx context.Context, req *mcp.CallToolRequest,
 		}
 	}
 
+	if len(input.MetricTypes) == 0 || slices.Contains(input.MetricTypes, "bp") {
+		if bp := s.latestBloodPressure(ctx); bp != nil {
+			results["bp"] = bp
+		}
+	}
+
 	return nil, results, nil
 }
+
+func (s *Server) handleAddSleep(ctx context.Context, req *mcp.CallToolRequest, input addSleepInput) (*mcp.CallToolResult, sleepOutput, error) {
+	bed, err := s.parseTimestamp(input.BedTime)
+	if err != nil {
+		return nil, sleepOutput{}, fmt.Errorf("invalid bed_time: %s", input.BedTime)
+	}
+	wake, err := s.parseTimestamp(input.WakeTime)
+	if err != nil {
+		return nil, sleepOutput{}, fmt.Errorf("invalid wake_time: %s", input.WakeTime)
+	}
+
+	ss := models.NewSleepSession(bed, wake)
+	if input.Awakenings != nil {
+		if *input.Awakenings < 0 {
+			return nil, sleepOutput{}, fmt.Errorf("awakenings cannot be negative")
+		}
+		ss.WithAwakenings(*input.Awakenings)
+	}
+	if input.Quality != nil {
+		if *input.Quality < 1 || *input.Quality > 10 {
+			return nil, sleepOutput{}, fmt.Errorf("quality must be between 1 and 10")
+		}
+		ss.WithQuality(*input.Quality)
+	}
+	if input.Notes != "" {
+		ss.WithNotes(input.Notes)
+	}
+
+	m, err := storage.RecordSleepSession(ctx, s.repo, ss, models.SourceMCP)
+	if err != nil {
+		return nil, sleepOutput{}, fmt.Errorf("failed to add sleep session: %w", err)
+	}
+
+	return nil, sleepOutput{
+		ID:      ss.ID.String()[:8],
+		Hours:   m.Value,
+		Message: fmt.Sprintf("Added sleep session: %.2f hours (ID: %s)", m.Value, ss.ID.String()[:8]),
+	}, nil
+}
+
+func (s *Server) handleListSleep(ctx context.Context, req *mcp.CallToolRequest, input listSleepInput) (*mcp.CallToolResult, any, error) {
+	if input.Limit <= 0 {
+		input.Limit = 20
+	}
+
+	sessions, err := s.repo.ListSleepSessions(ctx, input.Limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list sleep sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		return nil, map[string]interface{}{"message": "No sleep sessions found."}, nil
+	}
+
+	out := make([]sleepSessionOutput, 0, len(sessions))
+	for _, ss := range sessions {
+		out = append(out, sleepSessionOutput{
+			ID:         ss.ID.String(),
+			BedTime:    ss.BedTime.Format(time.RFC3339),
+			WakeTime:   ss.WakeTime.Format(time.RFC3339),
+			Hours:      ss.Hours(),
+			Awakenings: ss.Awakenings,
+			Quality:    ss.Quality,
+			Notes:      ss.Notes,
+		})
+	}
+
+	return nil, out, nil
+}
+
+func (s *Server) handleDeleteSleep(ctx context.Context, req *mcp.CallToolRequest, input deleteSleepInput) (*mcp.CallToolResult, simpleOutput, error) {
+	if err := storage.RemoveSleepSession(ctx, s.repo, input.ID); err != nil {
+		return nil, simpleOutput{}, fmt.Errorf("failed to delete sleep session: %w", err)
+	}
+
+	return nil, simpleOutput{
+		Message: fmt.Sprintf("Deleted sleep session: %s", input.ID),
+	}, nil
+}
+
+// parseTimestamp reads RFC3339, 'YYYY-MM-DD HH:MM', or a phrase like
+// "2 hours ago" or "yesterday 7am" in the server's timezone.
+func (s *Server) parseTimestamp(str string) (time.Time, error) {
+	return timeparse.Parse(str, time.Now().In(s.zone))
+}
+
+func (s *Server) handleAddMedication(ctx context.Context, req *mcp.CallToolRequest, input addMedicationInput) (*mcp.CallToolResult, simpleOutput, error) {
+	if input.Schedule == "" {
+		input.Schedule = "daily"
+	}
+	if _, err := models.DosesPerDay(input.Schedule); err != nil {
+		return nil, simpleOutput{}, err
+	}
+	if input.Name == "" || input.Dose == "" {
+		return nil, simpleOutput{}, fmt.Errorf("name and dose are required")
+	}
+
+	m := models.NewMedication(input.Name, input.Dose, input.Schedule)
+	if input.Notes != "" {
+		m.WithNotes(input.Notes)
+	}
+
+	if err := s.repo.CreateMedication(ctx, m); err != nil {
+		return nil, simpleOutput{}, fmt.Errorf("failed to add medication: %w", err)
+	}
+
+	return nil, simpleOutput{
+		Message: fmt.Sprintf("Added medication %s: %s, %s (ID: %s)", m.Name, m.Dose, m.Schedule, m.ID.String()[:8]),
+	}, nil
+}
+
+func (s *Server) handleListMedications(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, any, error) {
+	meds, err := s.repo.ListMedications(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list medications: %w", err)
+	}
+
+	if len(meds) == 0 {
+		return nil, map[string]interface{}{"message": "No medications defined."}, nil
+	}
+
+	return nil, meds, nil
+}
+
+func (s *Server) handleTakeMedication(ctx context.Context, req *mcp.CallToolRequest, input takeMedicationInput) (*mcp.CallToolResult, simpleOutput, error) {
+	m, err := s.repo.GetMedication(ctx, input.Name)
+	if err != nil {
+		return nil, simpleOutput{}, fmt.Errorf("medication not found: %s", input.Name)
+	}
+
+	in := models.NewMedicationIntake(m.ID)
+	if input.TakenAt != "" {
+		t, err := s.parseTimestamp(input.TakenAt)
+		if err != nil {
+			return nil, simpleOutput{}, fmt.Errorf("invalid taken_at: %s", input.TakenAt)
+		}
+		in.WithTakenAt(t)
+	}
+	if input.Dose != "" {
+		in.WithDose(input.Dose)
+	}
+	if input.Notes != "" {
+		in.WithNotes(input.Notes)
+	}
+
+	if err := s.repo.LogMedicationIntake(ctx, in); err != nil {
+		return nil, simpleOutput{}, fmt.Errorf("failed to log intake: %w", err)
+	}
+
+	return nil, simpleOutput{
+		Message: fmt.Sprintf("Logged %s at %s", m.Name, in.TakenAt.Format(time.RFC3339)),
+	}, nil
+}
+
+func (s *Server) handleMedicationAdherence(ctx context.Context, req *mcp.CallToolRequest, input medicationAdherenceInput) (*mcp.CallToolResult, any, error) {
+	if input.Days <= 0 {
+		input.Days = 7
+	}
+
+	var meds []*models.Medication
+	if input.Name != "" {
+		m, err := s.repo.GetMedication(ctx, input.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("medication not found: %s", input.Name)
+		}
+		meds = []*models.Medication{m}
+	} else {
+		var err error
+		if meds, err = s.repo.ListMedications(ctx); err != nil {
+			return nil, nil, fmt.Errorf("failed to list medications: %w", err)
+		}
+	}
+
+	if len(meds) == 0 {
+		return nil, map[string]interface{}{"message": "No medications defined."}, nil
+	}
+
+	until := time.Now()
+	since := until.AddDate(0, 0, -input.Days)
+
+	out := make([]medicationAdherenceOutput, 0, len(meds))
+	for _, m := range meds {
+		intakes, err := s.repo.ListMedicationIntakes(ctx, storage.IntakeFilter{
+			MedicationID: &m.ID,
+			Since:        &since,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list intakes: %w", err)
+		}
+
+		a := models.CalculateAdherence(m, intakes, since, until)
+		entry := medicationAdherenceOutput{
+			Medication: m.Name,
+			Dose:       m.Dose,
+			Schedule:   m.Schedule,
+			Taken:      a.Taken,
+			Expected:   a.Expected,
+			Percent:    a.Percent,
+			TakenAt:    make([]string, 0, len(intakes)),
+		}
+		for _, in := range intakes {
+			entry.TakenAt = append(entry.TakenAt, in.TakenAt.Format(time.RFC3339))
+		}
+		out = append(out, entry)
+	}
+
+	return nil, out, nil
+}
+
+func (s *Server) handleAddLocation(ctx context.Context, req *mcp.CallToolRequest, input addLocationInput) (*mcp.CallToolResult, simpleOutput, error) {
+	if input.Name == "" {
+		return nil, simpleOutput{}, fmt.Errorf("name is required")
+	}
+	if (input.Latitude == nil) != (input.Longitude == nil) {
+		return nil, simpleOutput{}, fmt.Errorf("latitude and longitude must be given together")
+	}
+
+	l := models.NewLocation(input.Name)
+	if input.Latitude != nil {
+		if _, _, _, err := models.ParseCoordinates(fmt.Sprintf("%g,%g", *input.Latitude, *input.Longitude)); err != nil {
+			return nil, simpleOutput{}, err
+		}
+		l.WithCoordinates(*input.Latitude, *input.Longitude)
+	}
+	if input.Notes != "" {
+		l.WithNotes(input.Notes)
+	}
+
+	if err := s.repo.CreateLocation(ctx, l); err != nil {
+		return nil, simpleOutput{}, fmt.Errorf("failed to add location: %w", err)
+	}
+
+	return nil, simpleOutput{
+		Message: fmt.Sprintf("Added location %s (ID: %s)", l.Name, l.ID.String()[:8]),
+	}, nil
+}
+
+func (s *Server) handleListLocations(ctx context.Context, req *mcp.CallToolRequest, input struct{}) (*mcp.CallToolResult, any, error) {
+	locs, err := s.repo.ListLocations(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list locations: %w", err)
+	}
+
+	if len(locs) == 0 {
+		return nil, map[string]interface{}{"message": "No locations registered."}, nil
+	}
+
+	return nil, locs, nil
+}
+
+func (s *Server) handleGetDailyTotal(ctx context.Context, req *mcp.CallToolRequest, input dailyTotalInput) (*mcp.CallToolResult, dailyTotalOutput, error) {
+	mt := models.MetricType(input.MetricType)
+	if !models.IsCumulative(mt) {
+		return nil, dailyTotalOutput{}, fmt.Errorf("%s is not a cumulative metric", input.MetricType)
+	}
+
+	day := time.Now().In(s.zone)
+	if input.Date != "" {
+		t, err := time.ParseInLocation("2006-01-02", input.Date, s.zone)
+		if err != nil {
+			return nil, dailyTotalOutput{}, fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", input.Date)
+		}
+		day = t
+	}
+
+	total, err := storage.DailyTotal(ctx, s.repo, mt, day)
+	if err != nil {
+		return nil, dailyTotalOutput{}, fmt.Errorf("failed to total %s: %w", mt, err)
+	}
+
+	return nil, dailyTotalOutput{
+		MetricType: input.MetricType,
+		Date:       day.Format("2006-01-02"),
+		Total:      total.Sum,
+		Unit:       models.MetricUnits[mt],
+		Entries:    total.Count,
+	}, nil
+}
+
+func (s *Server) handleGetAverage(ctx context.Context, req *mcp.CallToolRequest, input averageInput) (*mcp.CallToolResult, averageOutput, error) {
+	if !models.IsValidMetricType(input.MetricType) {
+		return nil, averageOutput{}, fmt.Errorf("unknown metric type: %s", input.MetricType)
+	}
+	if input.Days <= 0 {
+		input.Days = 30
+	}
+
+	mt := models.MetricType(input.MetricType)
+	since := time.Now().AddDate(0, 0, -input.Days)
+	avg, err := s.repo.AvgMetrics(ctx, storage.MetricFilter{Type: &mt, Since: &since})
+	if err != nil {
+		return nil, averageOutput{}, fmt.Errorf("failed to average %s: %w", mt, err)
+	}
+
+	return nil, averageOutput{
+		MetricType: input.MetricType,
+		Since:      since.In(s.zone).Format(time.RFC3339),
+		Average:    avg.Avg,
+		Min:        avg.Min,
+		Max:        avg.Max,
+		Unit:       models.MetricUnits[mt],
+		Entries:    avg.Count,
+	}, nil
+}
+
+func (s *Server) handleExportData(ctx context.Context, req *mcp.CallToolRequest, input exportDataInput) (*mcp.CallToolResult, exportDataOutput, error) {
+	if input.Format == "" {
+		input.Format = "json"
+	}
+	since, until, err := parseRange(input.Since, input.Until, s.zone)
+	if err != nil {
+		return nil, exportDataOutput{}, err
+	}
+	filter := storage.ExportFilter{Since: since, Until: until}
+	if input.MetricType != "" {
+		mt := models.MetricType(input.MetricType)
+		if !models.IsValidMetricType(input.MetricType) && s.derived.Lookup(input.MetricType) == nil {
+			return nil, exportDataOutput{}, fmt.Errorf("unknown metric type: %s", input.MetricType)
+		}
+		filter.Type = &mt
+	}
+	var derive storage.DeriveFunc
+	if s.derived != nil {
+		derive = s.derived.Compute
+	}
+
+	var data string
+	switch input.Format {
+	case "json":
+		b, err := storage.ExportJSONFiltered(ctx, s.repo, filter, derive)
+		if err != nil {
+			return nil, exportDataOutput{}, fmt.Errorf("failed to export: %w", err)
+		}
+		data = string(b)
+	case "markdown":
+		data, err = storage.ExportMarkdownFiltered(ctx, s.repo, filter, derive)
+		if err != nil {
+			return nil, exportDataOutput{}, fmt.Errorf("failed to export: %w", err)
+		}
+	default:
+		return nil, exportDataOutput{}, fmt.Errorf("unknown format: %s (use json or markdown)", input.Format)
+	}
+
+	return nil, exportDataOutput{Format: input.Format, Data: data}, nil
+}
+
+// latestBloodPressure describes the most recent paired blood pressure
+// reading, or returns nil when there is none.
+func (s *Server) latestBloodPressure(ctx context.Context) map[string]interface{} {
+	sys, err := s.repo.GetLatestMetric(ctx, models.MetricBPSys)
+	if err != nil {
+		return nil
+	}
+	dia, err := storage.ReadingPartner(ctx, s.repo, sys)
+	if err != nil || dia == nil {
+		return nil
+	}
+	bp := map[string]interface{}{
+		"reading":     models.MetricEntry{Metric: sys, Diastolic: dia}.Reading(),
+		"unit":        sys.Unit,
+		"recorded_at": sys.RecordedAt,
+	}
+	if s.person != nil {
+		if ref := reference.ClassifyBP(sys.Value, dia.Value, *s.person); ref != nil {
+			bp["reference"] = ref
+		}
+	}
+	return bp
+}
+
+// checkAlerts describes the thresholds crossed by newly added metrics.
+func (s *Server) checkAlerts(ctx context.Context, metrics ...*models.Metric) ([]string, error) {
+	var alerts []string
+	for _, m := range metrics {
+		hits, err := storage.CheckAlerts(ctx, s.repo, s.alerts, m)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check alerts: %w", err)
+		}
+		for _, h := range hits {
+			alerts = append(alerts, h.String())
+		}
+	}
+	return alerts, nil
+}
@@ -0,0 +1,65 @@
+// ABOUTME: Alert model for metric thresholds like "bp_sys above 140".
+// ABOUTME: Decides whether a value crosses a threshold and describes the hit.
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Alert is a threshold on a metric type. A value above Above or below Below
+// crosses it; either bound may be unset.
+type Alert struct {
+	MetricType MetricType
+	Above      *float64
+	Below      *float64
+}
+
+// Crossed reports whether value is outside the alert's bounds.
+func (a Alert) Crossed(value float64) bool {
+	return (a.Above != nil && value > *a.Above) || (a.Below != nil && value < *a.Below)
+}
+
+// String describes the bounds, e.g. "bp_sys > 140" or "sleep_hours < 6, > 10".
+func (a Alert) String() string {
+	s := string(a.MetricType)
+	sep := " "
+	if a.Below != nil {
+		s += sep + "< " + formatBound(*a.Below)
+		sep = ", "
+	}
+	if a.Above != nil {
+		s += sep + "> " + formatBound(*a.Above)
+	}
+	return s
+}
+
+// AlertHit is a value that crossed an alert. For cumulative metrics the
+// value is the day's running total.
+type AlertHit struct {
+	Alert Alert
+	Value float64
+	Unit  string
+	At    time.Time
+}
+
+// String describes the hit, e.g. "bp_sys 150 mmHg is above 140".
+func (h AlertHit) String() string {
+	direction, bound := "above", h.Alert.Above
+	if h.Alert.Below != nil && h.Value < *h.Alert.Below {
+		direction, bound = "below", h.Alert.Below
+	}
+	value := formatBound(h.Value)
+	if h.Unit != "" {
+		value += " " + h.Unit
+	}
+	if IsCumulative(h.Alert.MetricType) {
+		return fmt.Sprintf("%s total %s is %s %s", h.Alert.MetricType, value, direction, formatBound(*bound))
+	}
+	return fmt.Sprintf("%s %s is %s %s", h.Alert.MetricType, value, direction, formatBound(*bound))
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
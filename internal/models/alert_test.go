@@ -0,0 +1,44 @@
+// ABOUTME: Tests for metric threshold alerts.
+// ABOUTME: Covers crossing checks and the human-readable descriptions.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestAlertCrossed(t *testing.T) {
+	a := Alert{MetricType: MetricBPSys, Above: floatPtr(140)}
+	if a.Crossed(140) || !a.Crossed(141) || a.Crossed(90) {
+		t.Error("expected only values strictly above 140 to cross")
+	}
+
+	band := Alert{MetricType: MetricSleepHours, Below: floatPtr(6), Above: floatPtr(10)}
+	for _, tt := range []struct {
+		v    float64
+		want bool
+	}{{5.5, true}, {6, false}, {8, false}, {10.5, true}} {
+		if got := band.Crossed(tt.v); got != tt.want {
+			t.Errorf("Crossed(%v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+
+	if got := band.String(); got != "sleep_hours < 6, > 10" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestAlertHitString(t *testing.T) {
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	hit := AlertHit{Alert: Alert{MetricType: MetricBPSys, Above: floatPtr(140)}, Value: 152, Unit: "mmHg", At: at}
+	if got := hit.String(); got != "bp_sys 152 mmHg is above 140" {
+		t.Errorf("String() = %q", got)
+	}
+
+	water := AlertHit{Alert: Alert{MetricType: MetricWater, Below: floatPtr(2000), Above: floatPtr(4000)}, Value: 1500, Unit: "ml", At: at}
+	if got := water.String(); got != "water total 1500 ml is below 2000" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+// ABOUTME: Appointment model for doctor visits and other health appointments.
+// ABOUTME: An appointment can carry a visit summary written up afterwards.
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultAppointmentMinutes is the assumed length of an appointment with no
+// duration, used when exporting to calendars.
+const DefaultAppointmentMinutes = 30
+
+// Appointment is a scheduled visit with a doctor, clinic, or therapist.
+// Summary holds notes from the visit itself, linked once it has happened.
+type Appointment struct {
+	ID              uuid.UUID
+	Provider        string // who the visit is with, e.g. "Dr. Lee"
+	ScheduledAt     time.Time
+	DurationMinutes *int
+	Location        *string
+	Reason          *string
+	Summary         *string
+	CreatedAt       time.Time
+}
+
+// NewAppointment creates a new Appointment with generated UUID.
+func NewAppointment(provider string, scheduledAt time.Time) *Appointment {
+	return &Appointment{
+		ID:          uuid.New(),
+		Provider:    provider,
+		ScheduledAt: scheduledAt,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// WithDuration sets the appointment length in minutes.
+func (a *Appointment) WithDuration(minutes int) *Appointment {
+	a.DurationMinutes = &minutes
+	return a
+}
+
+// WithLocation sets where the appointment takes place.
+func (a *Appointment) WithLocation(location string) *Appointment {
+	a.Location = &location
+	return a
+}
+
+// WithReason sets why the appointment was booked.
+func (a *Appointment) WithReason(reason string) *Appointment {
+	a.Reason = &reason
+	return a
+}
+
+// WithSummary links a visit summary to the appointment.
+func (a *Appointment) WithSummary(summary string) *Appointment {
+	a.Summary = &summary
+	return a
+}
+
+// EndsAt returns when the appointment finishes, assuming
+// DefaultAppointmentMinutes when no duration is set.
+func (a *Appointment) EndsAt() time.Time {
+	minutes := DefaultAppointmentMinutes
+	if a.DurationMinutes != nil {
+		minutes = *a.DurationMinutes
+	}
+	return a.ScheduledAt.Add(time.Duration(minutes) * time.Minute)
+}
+
+// IsUpcoming reports whether the appointment has not finished by now.
+func (a *Appointment) IsUpcoming(now time.Time) bool {
+	return a.EndsAt().After(now)
+}
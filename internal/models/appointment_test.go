@@ -0,0 +1,43 @@
+// ABOUTME: Tests for the Appointment model.
+// ABOUTME: Covers construction, end time defaults, and upcoming checks.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewAppointment(t *testing.T) {
+	at := time.Date(2025, 7, 2, 14, 0, 0, 0, time.UTC)
+	a := NewAppointment("Dr. Lee", at).WithLocation("Main St Clinic").WithReason("annual physical")
+
+	if a.Provider != "Dr. Lee" || !a.ScheduledAt.Equal(at) {
+		t.Errorf("got %+v", a)
+	}
+	if a.Location == nil || *a.Location != "Main St Clinic" || a.Reason == nil || *a.Reason != "annual physical" {
+		t.Errorf("expected location and reason set, got %+v", a)
+	}
+	if a.Summary != nil {
+		t.Error("expected no summary on a new appointment")
+	}
+}
+
+func TestAppointmentEndsAtAndUpcoming(t *testing.T) {
+	at := time.Date(2025, 7, 2, 14, 0, 0, 0, time.UTC)
+	a := NewAppointment("Dr. Lee", at)
+
+	if got := a.EndsAt(); !got.Equal(at.Add(30 * time.Minute)) {
+		t.Errorf("EndsAt() default = %v", got)
+	}
+	a.WithDuration(60)
+	if got := a.EndsAt(); !got.Equal(at.Add(time.Hour)) {
+		t.Errorf("EndsAt() with duration = %v", got)
+	}
+
+	if !a.IsUpcoming(at.Add(-time.Hour)) || !a.IsUpcoming(at.Add(30*time.Minute)) {
+		t.Error("expected appointment to be upcoming before it ends")
+	}
+	if a.IsUpcoming(at.Add(time.Hour)) {
+		t.Error("expected appointment not upcoming once it ended")
+	}
+}
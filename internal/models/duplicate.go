@@ -0,0 +1,61 @@
+// ABOUTME: Duplicate-entry windows: how close two entries of a metric type must be to count as one logged twice.
+// ABOUTME: Cumulative metrics and blood pressure, which is read several times in a row, are exempt by default.
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultDuplicateWindow is how close together two entries of the same
+// type are, by default, before the second is taken for the first logged
+// again (say once from the CLI and once by an assistant).
+const DefaultDuplicateWindow = 10 * time.Minute
+
+// DuplicateWindows says, per metric type, how close together two entries
+// count as duplicates. Types not in ByType use Default; a zero window
+// turns the check off.
+type DuplicateWindows struct {
+	Default time.Duration
+	ByType  map[MetricType]time.Duration
+}
+
+// DefaultDuplicateWindows checks every type but the cumulative ones and
+// blood pressure, where two or three readings a minute apart are the
+// usual way to measure it.
+func DefaultDuplicateWindows() DuplicateWindows {
+	w := DuplicateWindows{Default: DefaultDuplicateWindow, ByType: make(map[MetricType]time.Duration)}
+	for _, mt := range CumulativeMetricTypes {
+		w.ByType[mt] = 0
+	}
+	w.ByType[MetricBPSys] = 0
+	w.ByType[MetricBPDia] = 0
+	return w
+}
+
+// Window returns the duplicate window for mt.
+func (w DuplicateWindows) Window(mt MetricType) time.Duration {
+	if d, ok := w.ByType[mt]; ok {
+		return d
+	}
+	return w.Default
+}
+
+// DuplicateWarning describes a new entry that looks like existing logged
+// again, e.g. "weight was already logged 3m0s earlier (82.50 kg, 1a2b3c4d)".
+func DuplicateWarning(existing, m *Metric) string {
+	gap := m.RecordedAt.Sub(existing.RecordedAt).Round(time.Second)
+	when := "at the same time"
+	switch {
+	case gap > 0:
+		when = gap.String() + " earlier"
+	case gap < 0:
+		when = (-gap).String() + " later"
+	}
+	what := string(m.MetricType)
+	if m.IsBloodPressure() {
+		what = "blood pressure reading"
+	}
+	return fmt.Sprintf("%s was already logged %s (%.2f %s, %s)",
+		what, when, existing.Value, existing.Unit, existing.ID.String()[:8])
+}
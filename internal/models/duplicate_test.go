@@ -0,0 +1,53 @@
+// ABOUTME: Tests for duplicate-entry windows and warnings.
+// ABOUTME: Covers per-type windows, default exemptions, and the warning text.
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDuplicateWindows(t *testing.T) {
+	w := DefaultDuplicateWindows()
+	if got := w.Window(MetricWeight); got != DefaultDuplicateWindow {
+		t.Errorf("weight window = %v, want %v", got, DefaultDuplicateWindow)
+	}
+	if got := w.Window(MetricWater); got != 0 {
+		t.Errorf("water window = %v, want 0 for a running total", got)
+	}
+	if got := w.Window(MetricBPSys); got != 0 {
+		t.Errorf("bp_sys window = %v, want 0 for repeated readings", got)
+	}
+	w.ByType[MetricWeight] = time.Hour
+	if got := w.Window(MetricWeight); got != time.Hour {
+		t.Errorf("weight window = %v, want 1h once set", got)
+	}
+	if got := (DuplicateWindows{}).Window(MetricWeight); got != 0 {
+		t.Errorf("zero DuplicateWindows = %v, want off", got)
+	}
+}
+
+func TestDuplicateWarning(t *testing.T) {
+	at := time.Date(2025, 1, 5, 7, 0, 0, 0, time.UTC)
+	existing := NewMetric(MetricWeight, 82.5).WithRecordedAt(at)
+	for _, tt := range []struct {
+		at   time.Time
+		want string
+	}{
+		{at.Add(3 * time.Minute), "weight was already logged 3m0s earlier (82.50 kg, "},
+		{at.Add(-time.Minute), "1m0s later"},
+		{at, "at the same time"},
+	} {
+		got := DuplicateWarning(existing, NewMetric(MetricWeight, 83).WithRecordedAt(tt.at))
+		if !strings.Contains(got, tt.want) {
+			t.Errorf("DuplicateWarning = %q, want it to contain %q", got, tt.want)
+		}
+	}
+
+	sys, _ := NewBloodPressure(120, 80, at)
+	again, _ := NewBloodPressure(121, 79, at.Add(time.Minute))
+	if got := DuplicateWarning(sys, again); !strings.HasPrefix(got, "blood pressure reading was already logged 1m0s earlier") {
+		t.Errorf("DuplicateWarning for blood pressure = %q", got)
+	}
+}
@@ -0,0 +1,72 @@
+// ABOUTME: Event model for races and other goal dates to train towards.
+// ABOUTME: An event can carry a weekly training plan to compare actual volume against.
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a race or goal date, e.g. a marathon, with an optional weekly
+// training plan. WorkoutType limits which workouts count towards the plan;
+// nil counts all of them.
+type Event struct {
+	ID            uuid.UUID
+	Name          string
+	Date          time.Time // the day of the event, at local midnight
+	WorkoutType   *string
+	WeeklyMinutes *int
+	WeeklyKm      *float64
+	Notes         *string
+	CreatedAt     time.Time
+}
+
+// NewEvent creates a new Event with generated UUID on the day of date.
+func NewEvent(name string, date time.Time) *Event {
+	return &Event{
+		ID:        uuid.New(),
+		Name:      name,
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		CreatedAt: time.Now(),
+	}
+}
+
+// WithWorkoutType counts only workouts of this type towards the plan.
+func (e *Event) WithWorkoutType(workoutType string) *Event {
+	e.WorkoutType = &workoutType
+	return e
+}
+
+// WithWeeklyMinutes sets the planned training minutes per week.
+func (e *Event) WithWeeklyMinutes(minutes int) *Event {
+	e.WeeklyMinutes = &minutes
+	return e
+}
+
+// WithWeeklyKm sets the planned distance per week in kilometers.
+func (e *Event) WithWeeklyKm(km float64) *Event {
+	e.WeeklyKm = &km
+	return e
+}
+
+// WithNotes sets notes on the event.
+func (e *Event) WithNotes(notes string) *Event {
+	e.Notes = &notes
+	return e
+}
+
+// HasPlan reports whether the event has weekly targets to train towards.
+func (e *Event) HasPlan() bool {
+	return e.WeeklyMinutes != nil || e.WeeklyKm != nil
+}
+
+// DaysUntil returns the calendar days from now until the event, read in
+// now's location: 0 on the day itself and negative once it has passed.
+func (e *Event) DaysUntil(now time.Time) int {
+	day := e.Date.In(now.Location())
+	event := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(math.Round(event.Sub(today).Hours() / 24))
+}
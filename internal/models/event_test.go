@@ -0,0 +1,41 @@
+// ABOUTME: Tests for the Event model.
+// ABOUTME: Covers construction, plans, and the countdown in calendar days.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewEvent(t *testing.T) {
+	e := NewEvent("Chicago Marathon", time.Date(2026, 10, 11, 15, 30, 0, 0, time.UTC)).
+		WithWorkoutType("run").WithWeeklyKm(50)
+
+	if e.Name != "Chicago Marathon" || !e.Date.Equal(time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", e)
+	}
+	if e.WorkoutType == nil || *e.WorkoutType != "run" || !e.HasPlan() {
+		t.Errorf("expected a run plan, got %+v", e)
+	}
+	if NewEvent("5k", time.Now()).HasPlan() {
+		t.Error("expected no plan without weekly targets")
+	}
+}
+
+func TestEventDaysUntil(t *testing.T) {
+	e := NewEvent("Chicago Marathon", time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC))
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		{time.Date(2026, 10, 1, 23, 0, 0, 0, time.UTC), 10},
+		{time.Date(2026, 10, 10, 8, 0, 0, 0, time.UTC), 1},
+		{time.Date(2026, 10, 11, 20, 0, 0, 0, time.UTC), 0},
+		{time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC), -3},
+	}
+	for _, tt := range tests {
+		if got := e.DaysUntil(tt.now); got != tt.want {
+			t.Errorf("DaysUntil(%v) = %d, want %d", tt.now, got, tt.want)
+		}
+	}
+}
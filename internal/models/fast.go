@@ -0,0 +1,133 @@
+// ABOUTME: Fast model for intermittent fasting windows with a target length.
+// ABOUTME: Summarizes completed fasts into averages and day streaks of meeting the target.
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultFastHours is the target for a fast started without one (16:8).
+const DefaultFastHours = 16
+
+// Fast is a fasting window from StartedAt until EndedAt, or still running
+// when EndedAt is nil.
+type Fast struct {
+	ID          uuid.UUID
+	StartedAt   time.Time
+	EndedAt     *time.Time
+	TargetHours float64
+	Notes       *string
+	CreatedAt   time.Time
+}
+
+// NewFast creates a new open-ended Fast starting now.
+func NewFast(targetHours float64) *Fast {
+	now := time.Now()
+	return &Fast{
+		ID:          uuid.New(),
+		StartedAt:   now,
+		TargetHours: targetHours,
+		CreatedAt:   now,
+	}
+}
+
+// WithStartedAt sets when the fast began.
+func (f *Fast) WithStartedAt(at time.Time) *Fast {
+	f.StartedAt = at
+	return f
+}
+
+// WithNotes sets notes on the fast.
+func (f *Fast) WithNotes(notes string) *Fast {
+	f.Notes = &notes
+	return f
+}
+
+// IsActive reports whether the fast has not been ended.
+func (f *Fast) IsActive() bool {
+	return f.EndedAt == nil
+}
+
+// Elapsed returns how long the fast lasted, or has lasted so far at now.
+func (f *Fast) Elapsed(now time.Time) time.Duration {
+	if f.EndedAt != nil {
+		return f.EndedAt.Sub(f.StartedAt)
+	}
+	return now.Sub(f.StartedAt)
+}
+
+// Hours returns Elapsed in hours, rounded to two decimals.
+func (f *Fast) Hours(now time.Time) float64 {
+	return math.Round(f.Elapsed(now).Hours()*100) / 100
+}
+
+// TargetAt returns when the fast reaches its target.
+func (f *Fast) TargetAt() time.Time {
+	return f.StartedAt.Add(time.Duration(f.TargetHours * float64(time.Hour)))
+}
+
+// MetTarget reports whether the fast lasted at least its target by now.
+func (f *Fast) MetTarget(now time.Time) bool {
+	return f.Elapsed(now) >= f.TargetAt().Sub(f.StartedAt)
+}
+
+// FastStats summarizes completed fasts. A streak counts consecutive days
+// on which a fast that met its target ended; today without one yet does
+// not break the current streak.
+type FastStats struct {
+	Completed     int
+	MetTarget     int
+	AverageHours  float64
+	LongestHours  float64
+	CurrentStreak int
+	LongestStreak int
+}
+
+// SummarizeFasts computes FastStats over fasts, in any order, with days
+// read in now's location. Active fasts are left out.
+func SummarizeFasts(fasts []*Fast, now time.Time) FastStats {
+	var stats FastStats
+	var total float64
+	metDays := make(map[string]bool)
+	for _, f := range fasts {
+		if f.IsActive() {
+			continue
+		}
+		hours := f.Hours(now)
+		stats.Completed++
+		total += hours
+		stats.LongestHours = max(stats.LongestHours, hours)
+		if f.MetTarget(now) {
+			stats.MetTarget++
+			metDays[f.EndedAt.In(now.Location()).Format("2006-01-02")] = true
+		}
+	}
+	if stats.Completed > 0 {
+		stats.AverageHours = math.Round(total/float64(stats.Completed)*100) / 100
+	}
+
+	for day := range metDays {
+		d, _ := time.ParseInLocation("2006-01-02", day, now.Location())
+		if metDays[d.AddDate(0, 0, -1).Format("2006-01-02")] {
+			continue // not the first day of a run
+		}
+		run := 0
+		for metDays[d.AddDate(0, 0, run).Format("2006-01-02")] {
+			run++
+		}
+		stats.LongestStreak = max(stats.LongestStreak, run)
+	}
+
+	day := now
+	if !metDays[day.Format("2006-01-02")] {
+		day = day.AddDate(0, 0, -1)
+	}
+	for metDays[day.Format("2006-01-02")] {
+		stats.CurrentStreak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return stats
+}
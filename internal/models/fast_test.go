@@ -0,0 +1,56 @@
+// ABOUTME: Tests for the Fast model.
+// ABOUTME: Validates elapsed time, target checks, and streak statistics.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFastElapsed(t *testing.T) {
+	start := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+	f := NewFast(16).WithStartedAt(start)
+
+	now := start.Add(15 * time.Hour)
+	if !f.IsActive() || f.Hours(now) != 15 || f.MetTarget(now) {
+		t.Errorf("15h into a 16h fast: active %v, hours %v, met %v", f.IsActive(), f.Hours(now), f.MetTarget(now))
+	}
+	if want := start.Add(16 * time.Hour); !f.TargetAt().Equal(want) {
+		t.Errorf("TargetAt = %v, want %v", f.TargetAt(), want)
+	}
+
+	end := start.Add(16*time.Hour + 30*time.Minute)
+	f.EndedAt = &end
+	if f.Hours(now.AddDate(0, 0, 1)) != 16.5 || !f.MetTarget(now) {
+		t.Errorf("ended fast: hours %v, met %v; want 16.5 and met", f.Hours(now), f.MetTarget(now))
+	}
+}
+
+func TestSummarizeFasts(t *testing.T) {
+	now := time.Date(2025, 3, 20, 9, 0, 0, 0, time.UTC)
+	fast := func(daysAgo int, hours float64) *Fast {
+		end := time.Date(2025, 3, 20-daysAgo, 12, 0, 0, 0, time.UTC)
+		f := NewFast(16).WithStartedAt(end.Add(-time.Duration(hours * float64(time.Hour))))
+		f.EndedAt = &end
+		return f
+	}
+
+	fasts := []*Fast{
+		fast(1, 16), fast(2, 18), fast(3, 17), // current streak, through yesterday
+		fast(4, 12),                                        // missed the target
+		fast(6, 16), fast(7, 16), fast(8, 16), fast(9, 20), // longest streak
+		NewFast(16).WithStartedAt(now.Add(-2 * time.Hour)), // active, left out
+	}
+	got := SummarizeFasts(fasts, now)
+	want := FastStats{Completed: 8, MetTarget: 7, AverageHours: 16.38, LongestHours: 20, CurrentStreak: 3, LongestStreak: 4}
+	if got != want {
+		t.Errorf("SummarizeFasts = %+v, want %+v", got, want)
+	}
+
+	if got := SummarizeFasts(fasts[3:], now); got.CurrentStreak != 0 {
+		t.Errorf("current streak after a missed day = %d, want 0", got.CurrentStreak)
+	}
+	if got := SummarizeFasts(nil, now); got != (FastStats{}) {
+		t.Errorf("SummarizeFasts(nil) = %+v, want zero", got)
+	}
+}
@@ -0,0 +1,68 @@
+// ABOUTME: Location model for tagging metrics and workouts with where they happened.
+// ABOUTME: Named locations ("gym", "home") can carry coordinates; raw "lat,lon" tags are also allowed.
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Location is a named place that entries can be tagged with.
+type Location struct {
+	ID        uuid.UUID
+	Name      string
+	Latitude  *float64
+	Longitude *float64
+	Notes     *string
+	CreatedAt time.Time
+}
+
+// NewLocation creates a new Location with generated UUID and current timestamp.
+func NewLocation(name string) *Location {
+	return &Location{
+		ID:        uuid.New(),
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+}
+
+// WithCoordinates sets the latitude and longitude of the location.
+func (l *Location) WithCoordinates(lat, lon float64) *Location {
+	l.Latitude = &lat
+	l.Longitude = &lon
+	return l
+}
+
+// WithNotes sets notes on the location.
+func (l *Location) WithNotes(notes string) *Location {
+	l.Notes = &notes
+	return l
+}
+
+// Slug returns the normalized name used for lookups and file names.
+func (l *Location) Slug() string {
+	return Slugify(l.Name)
+}
+
+// ParseCoordinates parses a "lat,lon" location tag. ok is false when s is
+// not a coordinate pair (e.g. a location name); err is set when it looks
+// like one but is out of range.
+func ParseCoordinates(s string) (lat, lon float64, ok bool, err error) {
+	latStr, lonStr, found := strings.Cut(s, ",")
+	if !found {
+		return 0, 0, false, nil
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false, nil
+	}
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, 0, true, fmt.Errorf("coordinates out of range: %s", s)
+	}
+	return lat, lon, true, nil
+}
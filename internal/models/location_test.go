@@ -0,0 +1,43 @@
+// ABOUTME: Tests for the Location model.
+// ABOUTME: Validates construction and "lat,lon" tag parsing.
+package models
+
+import "testing"
+
+func TestNewLocation(t *testing.T) {
+	l := NewLocation("Home Gym").WithCoordinates(41.88, -87.63)
+
+	if l.Slug() != "home-gym" {
+		t.Errorf("Slug = %q, want home-gym", l.Slug())
+	}
+	if l.Latitude == nil || *l.Latitude != 41.88 || l.Longitude == nil || *l.Longitude != -87.63 {
+		t.Error("expected coordinates to be set")
+	}
+}
+
+func TestParseCoordinates(t *testing.T) {
+	tests := []struct {
+		in      string
+		ok      bool
+		wantErr bool
+		lat     float64
+		lon     float64
+	}{
+		{in: "41.88,-87.63", ok: true, lat: 41.88, lon: -87.63},
+		{in: "41.88, -87.63", ok: true, lat: 41.88, lon: -87.63},
+		{in: "gym"},
+		{in: "paris, france"},
+		{in: "91,0", ok: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		lat, lon, ok, err := ParseCoordinates(tt.in)
+		if ok != tt.ok || (err != nil) != tt.wantErr {
+			t.Errorf("ParseCoordinates(%q) ok=%v err=%v", tt.in, ok, err)
+			continue
+		}
+		if ok && !tt.wantErr && (lat != tt.lat || lon != tt.lon) {
+			t.Errorf("ParseCoordinates(%q) = %v,%v", tt.in, lat, lon)
+		}
+	}
+}
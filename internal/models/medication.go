@@ -0,0 +1,175 @@
+// ABOUTME: Medication and MedicationIntake models for supplement/medication logging.
+// ABOUTME: Includes schedule parsing and adherence calculation over a time window.
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// Medication is a medication or supplement the user takes on a schedule.
+type Medication struct {
+	ID        uuid.UUID
+	Name      string
+	Dose      string // Free-form, e.g. "1000 IU" or "10 mg"
+	Schedule  string // daily, twice daily, 3x daily, weekly, as needed
+	Notes     *string
+	CreatedAt time.Time
+}
+
+// NewMedication creates a new Medication with generated UUID.
+func NewMedication(name, dose, schedule string) *Medication {
+	return &Medication{
+		ID:        uuid.New(),
+		Name:      name,
+		Dose:      dose,
+		Schedule:  schedule,
+		CreatedAt: time.Now(),
+	}
+}
+
+// WithNotes sets notes on the medication.
+func (m *Medication) WithNotes(notes string) *Medication {
+	m.Notes = &notes
+	return m
+}
+
+// Slug returns a lowercase, dash-separated form of the name
+// ("Vitamin D" -> "vitamin-d") used for command-line lookups.
+func (m *Medication) Slug() string {
+	return Slugify(m.Name)
+}
+
+// Slugify lowercases s and collapses runs of non-alphanumerics into dashes.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// MedicationIntake records a single dose being taken.
+type MedicationIntake struct {
+	ID           uuid.UUID
+	MedicationID uuid.UUID
+	TakenAt      time.Time
+	Dose         *string // Overrides the medication's default dose when set
+	Notes        *string
+	CreatedAt    time.Time
+}
+
+// NewMedicationIntake creates a new intake for a medication, taken now.
+func NewMedicationIntake(medicationID uuid.UUID) *MedicationIntake {
+	now := time.Now()
+	return &MedicationIntake{
+		ID:           uuid.New(),
+		MedicationID: medicationID,
+		TakenAt:      now,
+		CreatedAt:    now,
+	}
+}
+
+// WithTakenAt sets a custom time the dose was taken.
+func (i *MedicationIntake) WithTakenAt(t time.Time) *MedicationIntake {
+	i.TakenAt = t
+	return i
+}
+
+// WithDose overrides the dose for this intake.
+func (i *MedicationIntake) WithDose(dose string) *MedicationIntake {
+	i.Dose = &dose
+	return i
+}
+
+// WithNotes sets notes on the intake.
+func (i *MedicationIntake) WithNotes(notes string) *MedicationIntake {
+	i.Notes = &notes
+	return i
+}
+
+// scheduleDoses maps accepted schedule spellings to expected doses per day.
+// A zero value means the medication is taken as needed.
+var scheduleDoses = map[string]float64{
+	"daily":             1,
+	"once daily":        1,
+	"1x daily":          1,
+	"twice daily":       2,
+	"2x daily":          2,
+	"bid":               2,
+	"3x daily":          3,
+	"three times daily": 3,
+	"tid":               3,
+	"4x daily":          4,
+	"qid":               4,
+	"weekly":            1.0 / 7,
+	"as needed":         0,
+	"prn":               0,
+}
+
+// DosesPerDay returns how many doses per day a schedule expects.
+// As-needed schedules return 0.
+func DosesPerDay(schedule string) (float64, error) {
+	doses, ok := scheduleDoses[strings.ToLower(strings.TrimSpace(schedule))]
+	if !ok {
+		return 0, fmt.Errorf("unknown schedule %q (use daily, twice daily, 3x daily, 4x daily, weekly, or as needed)", schedule)
+	}
+	return doses, nil
+}
+
+// Adherence summarizes how many doses were taken against the schedule.
+type Adherence struct {
+	Taken    int
+	Expected float64
+	// Percent is nil for as-needed medications, which have no target.
+	Percent *float64
+}
+
+// CalculateAdherence compares intakes in [since, until) against the
+// medication's schedule. Intakes outside the window are ignored. Doses are
+// only expected from when the medication was added, or from its first
+// intake in the window if that's earlier (it was logged after the fact).
+func CalculateAdherence(m *Medication, intakes []*MedicationIntake, since, until time.Time) Adherence {
+	var a Adherence
+	start := since
+	if m.CreatedAt.After(start) {
+		start = m.CreatedAt
+	}
+	for _, in := range intakes {
+		if in.MedicationID == m.ID && !in.TakenAt.Before(since) && in.TakenAt.Before(until) {
+			a.Taken++
+			if in.TakenAt.Before(start) {
+				start = in.TakenAt
+			}
+		}
+	}
+
+	perDay, err := DosesPerDay(m.Schedule)
+	if err != nil || perDay == 0 {
+		return a
+	}
+
+	days := until.Sub(start).Hours() / 24
+	a.Expected = perDay * days
+	if a.Expected > 0 {
+		pct := float64(a.Taken) / a.Expected * 100
+		if pct > 100 {
+			pct = 100
+		}
+		a.Percent = &pct
+	}
+	return a
+}
@@ -0,0 +1,107 @@
+// ABOUTME: Tests for Medication and MedicationIntake models.
+// ABOUTME: Covers slugs, schedule parsing, and adherence calculation.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMedicationSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Vitamin D", "vitamin-d"},
+		{"  Omega-3 (fish oil) ", "omega-3-fish-oil"},
+		{"ibuprofen", "ibuprofen"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMedication(tt.name, "1", "daily")
+			if got := m.Slug(); got != tt.want {
+				t.Errorf("Slug() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDosesPerDay(t *testing.T) {
+	tests := []struct {
+		schedule string
+		want     float64
+		wantErr  bool
+	}{
+		{"daily", 1, false},
+		{"Twice Daily", 2, false},
+		{"tid", 3, false},
+		{"weekly", 1.0 / 7, false},
+		{"as needed", 0, false},
+		{"whenever", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			got, err := DosesPerDay(tt.schedule)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DosesPerDay(%q) error = %v, wantErr %v", tt.schedule, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DosesPerDay(%q) = %v, want %v", tt.schedule, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateAdherence(t *testing.T) {
+	until := time.Date(2024, 12, 15, 0, 0, 0, 0, time.UTC)
+	since := until.AddDate(0, 0, -7)
+
+	m := NewMedication("Magnesium", "200 mg", "twice daily")
+	m.CreatedAt = since.AddDate(0, -1, 0)
+	var intakes []*MedicationIntake
+	for i := 0; i < 7; i++ {
+		intakes = append(intakes, NewMedicationIntake(m.ID).WithTakenAt(since.Add(time.Duration(i)*24*time.Hour+8*time.Hour)))
+	}
+	// Outside the window and for another medication: ignored
+	intakes = append(intakes, NewMedicationIntake(m.ID).WithTakenAt(since.Add(-time.Hour)))
+	intakes = append(intakes, NewMedicationIntake(NewMedication("Other", "1", "daily").ID).WithTakenAt(since.Add(time.Hour)))
+
+	a := CalculateAdherence(m, intakes, since, until)
+	if a.Taken != 7 {
+		t.Errorf("Taken = %d, want 7", a.Taken)
+	}
+	if a.Expected != 14 {
+		t.Errorf("Expected = %v, want 14", a.Expected)
+	}
+	if a.Percent == nil || *a.Percent != 50 {
+		t.Errorf("Percent = %v, want 50", a.Percent)
+	}
+
+	prn := NewMedication("Ibuprofen", "400 mg", "as needed")
+	a = CalculateAdherence(prn, []*MedicationIntake{NewMedicationIntake(prn.ID).WithTakenAt(since.Add(time.Hour))}, since, until)
+	if a.Taken != 1 || a.Percent != nil {
+		t.Errorf("as-needed adherence = %+v, want 1 taken and no percent", a)
+	}
+
+	// Added two days before the end of the window, and taken as scheduled
+	// since: expected doses start when it was added
+	added := NewMedication("Vitamin D", "1000 IU", "daily")
+	added.CreatedAt = until.AddDate(0, 0, -2)
+	intakes = []*MedicationIntake{
+		NewMedicationIntake(added.ID).WithTakenAt(added.CreatedAt.Add(time.Hour)),
+		NewMedicationIntake(added.ID).WithTakenAt(added.CreatedAt.Add(25 * time.Hour)),
+	}
+	a = CalculateAdherence(added, intakes, since, until)
+	if a.Expected != 2 || a.Percent == nil || *a.Percent != 100 {
+		t.Errorf("adherence for a medication added in the window = %+v, want 2 expected and 100%%", a)
+	}
+
+	// An intake logged from before it was added moves the start back
+	intakes = append(intakes, NewMedicationIntake(added.ID).WithTakenAt(until.AddDate(0, 0, -3)))
+	a = CalculateAdherence(added, intakes, since, until)
+	if a.Taken != 3 || a.Expected != 3 {
+		t.Errorf("adherence with an earlier intake = %+v, want 3 taken of 3", a)
+	}
+}
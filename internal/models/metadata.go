@@ -0,0 +1,31 @@
+// ABOUTME: Free-form key/value metadata carried by metrics and workouts.
+// ABOUTME: Parses key=value pairs so importers and the CLI can attach details without schema changes.
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+func setMetadata(md map[string]string, key, value string) map[string]string {
+	if md == nil {
+		md = map[string]string{}
+	}
+	md[key] = value
+	return md
+}
+
+// ParseMetadata turns "key=value" pairs into a metadata map. Values may
+// contain '='; keys may not be empty. It returns nil for no pairs.
+func ParseMetadata(pairs []string) (map[string]string, error) {
+	var md map[string]string
+	for _, p := range pairs {
+		key, value, ok := strings.Cut(p, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid metadata %q (use key=value)", p)
+		}
+		md = setMetadata(md, key, value)
+	}
+	return md, nil
+}
@@ -0,0 +1,42 @@
+// ABOUTME: Tests for metric and workout metadata.
+// ABOUTME: Covers key=value parsing and the WithMetadata builders.
+package models
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	md, err := ParseMetadata([]string{"device=Withings Body+", "url=https://x.test/?a=b", " id =7"})
+	if err != nil {
+		t.Fatalf("ParseMetadata failed: %v", err)
+	}
+	want := map[string]string{"device": "Withings Body+", "url": "https://x.test/?a=b", "id": "7"}
+	if !maps.Equal(md, want) {
+		t.Errorf("ParseMetadata = %v, want %v", md, want)
+	}
+
+	if md, err := ParseMetadata(nil); err != nil || md != nil {
+		t.Errorf("ParseMetadata(nil) = %v, %v; want nil", md, err)
+	}
+	for _, bad := range []string{"device", "=value"} {
+		if _, err := ParseMetadata([]string{bad}); err == nil {
+			t.Errorf("ParseMetadata(%q) should fail", bad)
+		}
+	}
+}
+
+func TestWithMetadata(t *testing.T) {
+	m := NewMetric(MetricWeight, 82).WithMetadata("device", "scale").WithMetadata("id", "1")
+	if len(m.Metadata) != 2 || m.Metadata["device"] != "scale" {
+		t.Errorf("metric metadata = %v", m.Metadata)
+	}
+	w := NewWorkout("ride").WithMetadata("gpx", "0412.gpx")
+	if w.Metadata["gpx"] != "0412.gpx" {
+		t.Errorf("workout metadata = %v", w.Metadata)
+	}
+	if NewMetric(MetricMood, 7).Metadata != nil {
+		t.Error("a new metric should have no metadata")
+	}
+}
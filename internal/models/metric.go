@@ -1,5 +1,5 @@
 // ABOUTME: Metric model and MetricType enum for health data.
-// ABOUTME: Defines 22 metric types across biometrics, activity, nutrition, mental health.
+// ABOUTME: Defines 30 metric types across biometrics, activity, nutrition, mental health, environment.
 package models
 
 import (
@@ -20,11 +20,17 @@ const (
 	MetricHeartRate   MetricType = "heart_rate"
 	MetricHRV         MetricType = "hrv"
 	MetricTemperature MetricType = "temperature"
+	MetricTempDev     MetricType = "temp_deviation"
+	MetricReadiness   MetricType = "readiness"
 
 	// Activity.
 	MetricSteps          MetricType = "steps"
 	MetricSleepHours     MetricType = "sleep_hours"
+	MetricDeepSleep      MetricType = "deep_sleep"
+	MetricREMSleep       MetricType = "rem_sleep"
+	MetricLightSleep     MetricType = "light_sleep"
 	MetricActiveCalories MetricType = "active_calories"
+	MetricVO2Max         MetricType = "vo2max"
 
 	// Nutrition.
 	MetricWater    MetricType = "water"
@@ -40,6 +46,11 @@ const (
 	MetricAnxiety    MetricType = "anxiety"
 	MetricFocus      MetricType = "focus"
 	MetricMeditation MetricType = "meditation"
+
+	// Environment.
+	MetricAQI         MetricType = "aqi"
+	MetricPollen      MetricType = "pollen"
+	MetricAmbientTemp MetricType = "ambient_temp"
 )
 
 // MetricUnits maps metric types to their display units.
@@ -51,9 +62,15 @@ var MetricUnits = map[MetricType]string{
 	MetricHeartRate:      "bpm",
 	MetricHRV:            "ms",
 	MetricTemperature:    "°C",
+	MetricTempDev:        "Δ°C",
+	MetricReadiness:      "score",
 	MetricSteps:          "steps",
 	MetricSleepHours:     "hours",
+	MetricDeepSleep:      "hours",
+	MetricREMSleep:       "hours",
+	MetricLightSleep:     "hours",
 	MetricActiveCalories: "kcal",
+	MetricVO2Max:         "ml/kg/min",
 	MetricWater:          "ml",
 	MetricCalories:       "kcal",
 	MetricProtein:        "g",
@@ -65,15 +82,50 @@ var MetricUnits = map[MetricType]string{
 	MetricAnxiety:        "scale",
 	MetricFocus:          "scale",
 	MetricMeditation:     "min",
+	MetricAQI:            "AQI",
+	MetricPollen:         "grains/m³",
+	MetricAmbientTemp:    "°C",
 }
 
 // AllMetricTypes returns all valid metric types.
 var AllMetricTypes = []MetricType{
 	MetricWeight, MetricBodyFat, MetricBPSys, MetricBPDia,
-	MetricHeartRate, MetricHRV, MetricTemperature,
-	MetricSteps, MetricSleepHours, MetricActiveCalories,
+	MetricHeartRate, MetricHRV, MetricTemperature, MetricTempDev, MetricReadiness,
+	MetricSteps, MetricSleepHours, MetricDeepSleep, MetricREMSleep, MetricLightSleep, MetricActiveCalories, MetricVO2Max,
 	MetricWater, MetricCalories, MetricProtein, MetricCarbs, MetricFat,
 	MetricMood, MetricEnergy, MetricStress, MetricAnxiety, MetricFocus, MetricMeditation,
+	MetricAQI, MetricPollen, MetricAmbientTemp,
+}
+
+// CumulativeMetricTypes are logged as several entries a day that add up to
+// a daily total (e.g. each glass of water).
+var CumulativeMetricTypes = []MetricType{
+	MetricWater, MetricCalories, MetricProtein, MetricCarbs, MetricFat,
+}
+
+// IsCumulative reports whether entries of this type sum to a daily total.
+func IsCumulative(mt MetricType) bool {
+	for _, c := range CumulativeMetricTypes {
+		if c == mt {
+			return true
+		}
+	}
+	return false
+}
+
+// metricTypeAliases are shorthand names accepted when logging a metric.
+var metricTypeAliases = map[string]MetricType{
+	"sleep": MetricSleepHours,
+}
+
+// CanonicalMetricType returns the metric type s names, resolving
+// shorthand such as "sleep" for sleep_hours. Anything else is returned
+// as is, for IsValidMetricType to check.
+func CanonicalMetricType(s string) MetricType {
+	if mt, ok := metricTypeAliases[s]; ok {
+		return mt
+	}
+	return MetricType(s)
 }
 
 // IsValidMetricType checks if a string is a valid metric type.
@@ -94,6 +146,10 @@ type Metric struct {
 	Unit       string
 	RecordedAt time.Time
 	Notes      *string
+	Location   *string           // Location name or "lat,lon"
+	ReadingID  *uuid.UUID        // Shared by metrics taken as one reading (bp_sys + bp_dia)
+	Source     string            // Where the value came from, e.g. SourceManual; empty if unknown
+	Metadata   map[string]string // Free-form details such as a device name or external ID
 	CreatedAt  time.Time
 }
 
@@ -121,3 +177,21 @@ func (m *Metric) WithNotes(notes string) *Metric {
 	m.Notes = &notes
 	return m
 }
+
+// WithLocation tags the metric with a location name or "lat,lon".
+func (m *Metric) WithLocation(location string) *Metric {
+	m.Location = &location
+	return m
+}
+
+// WithSource records where the metric came from.
+func (m *Metric) WithSource(source string) *Metric {
+	m.Source = source
+	return m
+}
+
+// WithMetadata sets one metadata key on the metric.
+func (m *Metric) WithMetadata(key, value string) *Metric {
+	m.Metadata = setMetadata(m.Metadata, key, value)
+	return m
+}
@@ -55,6 +55,7 @@ func TestAllMetricTypesHaveUnits(t *testing.T) {
 		MetricSteps, MetricSleepHours, MetricActiveCalories,
 		MetricWater, MetricCalories, MetricProtein, MetricCarbs, MetricFat,
 		MetricMood, MetricEnergy, MetricStress, MetricAnxiety, MetricFocus, MetricMeditation,
+		MetricAQI, MetricPollen, MetricAmbientTemp,
 	}
 
 	for _, mt := range types {
@@ -91,6 +92,8 @@ func TestIsValidMetricType(t *testing.T) {
 		{"valid anxiety", "anxiety", true},
 		{"valid focus", "focus", true},
 		{"valid meditation", "meditation", true},
+		{"valid aqi", "aqi", true},
+		{"valid ambient_temp", "ambient_temp", true},
 		{"invalid empty", "", false},
 		{"invalid random", "random", false},
 		{"invalid typo", "wieght", false},
@@ -216,7 +219,7 @@ func TestNewMetricSetsCreatedAt(t *testing.T) {
 }
 
 func TestAllMetricTypesSlice(t *testing.T) {
-	expectedCount := 21 // Total number of metric types
+	expectedCount := 30 // Total number of metric types
 
 	if len(AllMetricTypes) != expectedCount {
 		t.Errorf("AllMetricTypes has %d types, want %d", len(AllMetricTypes), expectedCount)
@@ -246,3 +249,16 @@ func TestMetricChaining(t *testing.T) {
 		t.Error("Notes should be 'chained call'")
 	}
 }
+
+func TestIsCumulative(t *testing.T) {
+	for _, mt := range []MetricType{MetricWater, MetricCalories, MetricProtein} {
+		if !IsCumulative(mt) {
+			t.Errorf("expected %s to be cumulative", mt)
+		}
+	}
+	for _, mt := range []MetricType{MetricWeight, MetricMood, MetricSteps} {
+		if IsCumulative(mt) {
+			t.Errorf("expected %s not to be cumulative", mt)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// ABOUTME: Profile model with the personal details shown on an emergency card.
+// ABOUTME: Normalizes blood types and lays out the card as plain labelled lines.
+package models
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Profile is the emergency information about the user: who they are and
+// what a first responder needs to know.
+type Profile struct {
+	Name             string
+	BirthDate        string // YYYY-MM-DD
+	Sex              string // "female" or "male"
+	HeightCM         float64
+	BloodType        string
+	Allergies        []string
+	Conditions       []string
+	EmergencyContact *Contact
+}
+
+// Contact is a person to call in an emergency.
+type Contact struct {
+	Name     string
+	Phone    string
+	Relation string
+}
+
+// String formats the contact, e.g. "Jane Doe (spouse) +1 555 0100".
+func (c Contact) String() string {
+	s := c.Name
+	if c.Relation != "" {
+		s += " (" + c.Relation + ")"
+	}
+	return s + " " + c.Phone
+}
+
+var bloodTypes = []string{"A+", "A-", "B+", "B-", "AB+", "AB-", "O+", "O-"}
+
+// NormalizeBloodType validates a blood type like "ab+" or "O neg" and
+// returns its canonical form ("AB+", "O-").
+func NormalizeBloodType(s string) (string, error) {
+	t := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+	if strings.HasSuffix(t, "POS") {
+		t = strings.TrimSuffix(t, "POS") + "+"
+	} else if strings.HasSuffix(t, "NEG") {
+		t = strings.TrimSuffix(t, "NEG") + "-"
+	}
+	for _, bt := range bloodTypes {
+		if t == bt {
+			return bt, nil
+		}
+	}
+	return "", fmt.Errorf("invalid blood type: %q (use one of %s)", s, strings.Join(bloodTypes, ", "))
+}
+
+// NormalizeSex accepts "female", "male", or their first letter, in any case.
+func NormalizeSex(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "f", "female":
+		return "female", nil
+	case "m", "male":
+		return "male", nil
+	}
+	return "", fmt.Errorf("invalid sex: %q (use female or male)", s)
+}
+
+var feetInchesRe = regexp.MustCompile(`^(\d+)\s*(?:'|ft)\s*(?:(\d+(?:\.\d+)?)\s*(?:"|in)?)?$`)
+
+// ParseHeight reads a height as centimeters ("180", "180cm"), meters
+// ("1.80m", or a bare number under 3), or feet and inches (5'11", 5ft 11in),
+// and returns it in centimeters.
+func ParseHeight(s string) (float64, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	cm, err := func() (float64, error) {
+		if m := feetInchesRe.FindStringSubmatch(t); m != nil {
+			feet, _ := strconv.ParseFloat(m[1], 64)
+			inches, _ := strconv.ParseFloat(m[2], 64)
+			return (feet*12 + inches) * 2.54, nil
+		}
+		if v, ok := strings.CutSuffix(t, "cm"); ok {
+			return strconv.ParseFloat(strings.TrimSpace(v), 64)
+		}
+		if v, ok := strings.CutSuffix(t, "m"); ok {
+			m, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			return m * 100, err
+		}
+		v, err := strconv.ParseFloat(t, 64)
+		if v < 3 {
+			v *= 100
+		}
+		return v, err
+	}()
+	if err != nil || cm < 50 || cm > 272 {
+		return 0, fmt.Errorf("invalid height %q (use e.g. 180cm, 1.80m, or 5'11\")", s)
+	}
+	return math.Round(cm*10) / 10, nil
+}
+
+// ParseBirthDate validates a YYYY-MM-DD date of birth.
+func ParseBirthDate(s string) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid birth date %q (use YYYY-MM-DD)", s)
+	}
+	return t, nil
+}
+
+// Age returns the age in whole years at now, or 0 when the birth date is
+// unset or invalid.
+func (p *Profile) Age(now time.Time) int {
+	born, err := ParseBirthDate(p.BirthDate)
+	if err != nil {
+		return 0
+	}
+	age := now.Year() - born.Year()
+	if now.Month() < born.Month() || (now.Month() == born.Month() && now.Day() < born.Day()) {
+		age--
+	}
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// CardField is one labelled line on the emergency card.
+type CardField struct {
+	Label string
+	Value string
+}
+
+// CardFields lays out the emergency card from the profile and the
+// medications being taken. Unset fields are left off, except allergies,
+// where "none recorded" is itself useful to a responder.
+func (p *Profile) CardFields(meds []*Medication) []CardField {
+	var fields []CardField
+	add := func(label, value string) {
+		if value != "" {
+			fields = append(fields, CardField{label, value})
+		}
+	}
+
+	add("Name", p.Name)
+	add("Born", p.BirthDate)
+	add("Sex", p.Sex)
+	if p.HeightCM > 0 {
+		add("Height", fmt.Sprintf("%g cm", p.HeightCM))
+	}
+	add("Blood type", p.BloodType)
+	allergies := strings.Join(p.Allergies, ", ")
+	if allergies == "" {
+		allergies = "none recorded"
+	}
+	add("Allergies", allergies)
+	add("Conditions", strings.Join(p.Conditions, ", "))
+
+	names := make([]string, 0, len(meds))
+	for _, m := range meds {
+		name := m.Name
+		if m.Dose != "" {
+			name += " " + m.Dose
+		}
+		names = append(names, name)
+	}
+	add("Medications", strings.Join(names, ", "))
+	if p.EmergencyContact != nil {
+		add("Contact", p.EmergencyContact.String())
+	}
+	return fields
+}
+
+// CardText renders the fields as "Label: value" lines, compact enough to
+// fit in a QR code.
+func CardText(fields []CardField) string {
+	var b strings.Builder
+	b.WriteString("EMERGENCY INFO\n")
+	for _, f := range fields {
+		fmt.Fprintf(&b, "%s: %s\n", f.Label, f.Value)
+	}
+	return b.String()
+}
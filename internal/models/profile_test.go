@@ -0,0 +1,92 @@
+// ABOUTME: Tests for the Profile model.
+// ABOUTME: Covers blood type normalization, age, and the emergency card layout.
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNormalizeBloodType(t *testing.T) {
+	tests := map[string]string{"O+": "O+", "ab-": "AB-", "o neg": "O-", "A pos": "A+"}
+	for in, want := range tests {
+		if got, err := NormalizeBloodType(in); err != nil || got != want {
+			t.Errorf("NormalizeBloodType(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "C+", "A", "O++"} {
+		if _, err := NormalizeBloodType(in); err == nil {
+			t.Errorf("NormalizeBloodType(%q) expected error", in)
+		}
+	}
+}
+
+func TestProfileCardFields(t *testing.T) {
+	p := &Profile{
+		Name:             "Harper Reed",
+		BloodType:        "O+",
+		Conditions:       []string{"asthma", "type 1 diabetes"},
+		EmergencyContact: &Contact{Name: "Jane Doe", Phone: "+1 555 0100"},
+	}
+	meds := []*Medication{NewMedication("Insulin", "", "daily"), NewMedication("Vitamin D", "1000 IU", "daily")}
+
+	text := CardText(p.CardFields(meds))
+	want := `EMERGENCY INFO
+Name: Harper Reed
+Blood type: O+
+Allergies: none recorded
+Conditions: asthma, type 1 diabetes
+Medications: Insulin, Vitamin D 1000 IU
+Contact: Jane Doe +1 555 0100
+`
+	if text != want {
+		t.Errorf("CardText =\n%s\nwant\n%s", text, want)
+	}
+
+	empty := CardText((&Profile{}).CardFields(nil))
+	if strings.Contains(empty, "Medications") || !strings.Contains(empty, "Allergies: none recorded") {
+		t.Errorf("Unexpected card for empty profile:\n%s", empty)
+	}
+}
+
+func TestProfileAge(t *testing.T) {
+	p := &Profile{BirthDate: "1980-03-15"}
+	if age := p.Age(time.Date(2025, 3, 14, 12, 0, 0, 0, time.UTC)); age != 44 {
+		t.Errorf("Age the day before the birthday = %d, want 44", age)
+	}
+	if age := p.Age(time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)); age != 45 {
+		t.Errorf("Age on the birthday = %d, want 45", age)
+	}
+	if age := (&Profile{}).Age(time.Now()); age != 0 {
+		t.Errorf("Age without a birth date = %d, want 0", age)
+	}
+
+	for in, want := range map[string]string{"F": "female", " male ": "male", "m": "male"} {
+		if got, err := NormalizeSex(in); err != nil || got != want {
+			t.Errorf("NormalizeSex(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := NormalizeSex("x"); err == nil {
+		t.Error("Expected error for unknown sex")
+	}
+	if _, err := ParseBirthDate("15/03/1980"); err == nil {
+		t.Error("Expected error for a non-ISO birth date")
+	}
+}
+
+func TestParseHeight(t *testing.T) {
+	for in, want := range map[string]float64{
+		"180": 180, "180cm": 180, "180 cm": 180, "1.80m": 180, "1.8": 180,
+		`5'11"`: 180.3, "5ft 11in": 180.3, "6'": 182.9,
+	} {
+		if got, err := ParseHeight(in); err != nil || got != want {
+			t.Errorf("ParseHeight(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "tall", "18m", "400cm", "-170"} {
+		if got, err := ParseHeight(bad); err == nil {
+			t.Errorf("ParseHeight(%q) = %v; want error", bad, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// ABOUTME: Linked readings that span several metrics, such as blood pressure.
+// ABOUTME: Pairs bp_sys and bp_dia so they can be shown as a single "120/80" record.
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NewBloodPressure creates systolic and diastolic metrics linked by a
+// shared reading ID and timestamp.
+func NewBloodPressure(systolic, diastolic float64, recordedAt time.Time) (sys, dia *Metric) {
+	readingID := uuid.New()
+	sys = NewMetric(MetricBPSys, systolic).WithRecordedAt(recordedAt)
+	dia = NewMetric(MetricBPDia, diastolic).WithRecordedAt(recordedAt)
+	sys.ReadingID = &readingID
+	dia.ReadingID = &readingID
+	return sys, dia
+}
+
+// IsBloodPressure reports whether the metric is one half of a blood
+// pressure reading.
+func (m *Metric) IsBloodPressure() bool {
+	return m.MetricType == MetricBPSys || m.MetricType == MetricBPDia
+}
+
+// SameReading reports whether a and b are the two halves of one blood
+// pressure reading. Linked metrics must share a reading ID; rows logged
+// before readings were linked pair up when recorded at the same instant.
+func SameReading(a, b *Metric) bool {
+	if !a.IsBloodPressure() || !b.IsBloodPressure() || a.MetricType == b.MetricType {
+		return false
+	}
+	if a.ReadingID != nil || b.ReadingID != nil {
+		return a.ReadingID != nil && b.ReadingID != nil && *a.ReadingID == *b.ReadingID
+	}
+	return a.RecordedAt.Equal(b.RecordedAt)
+}
+
+// MetricEntry is one row of a metric listing: a single metric, or both
+// halves of a blood pressure reading.
+type MetricEntry struct {
+	Metric    *Metric // the metric, or the systolic half of a reading
+	Diastolic *Metric // the diastolic half, when Metric is a paired bp_sys
+}
+
+// IsBloodPressure reports whether the entry is a paired blood pressure reading.
+func (e MetricEntry) IsBloodPressure() bool {
+	return e.Diastolic != nil
+}
+
+// Reading renders a paired blood pressure entry as "120/80".
+func (e MetricEntry) Reading() string {
+	if e.Diastolic == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.0f/%.0f", e.Metric.Value, e.Diastolic.Value)
+}
+
+// GroupReadings collapses paired blood pressure metrics into single entries,
+// keeping the order of ms. A half whose partner is not in ms stays on its own.
+func GroupReadings(ms []*Metric) []MetricEntry {
+	paired := make(map[uuid.UUID]bool)
+	entries := make([]MetricEntry, 0, len(ms))
+
+	for i, m := range ms {
+		if paired[m.ID] {
+			continue
+		}
+		entry := MetricEntry{Metric: m}
+		if m.IsBloodPressure() {
+			for _, other := range ms[i+1:] {
+				if paired[other.ID] || !SameReading(m, other) {
+					continue
+				}
+				paired[other.ID] = true
+				if m.MetricType == MetricBPSys {
+					entry.Diastolic = other
+				} else {
+					entry = MetricEntry{Metric: other, Diastolic: m}
+				}
+				break
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
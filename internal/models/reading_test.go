@@ -0,0 +1,70 @@
+// ABOUTME: Tests for linked blood pressure readings.
+// ABOUTME: Covers pairing by reading ID and the legacy same-timestamp fallback.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewBloodPressure(t *testing.T) {
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	sys, dia := NewBloodPressure(120, 80, at)
+
+	if sys.MetricType != MetricBPSys || dia.MetricType != MetricBPDia {
+		t.Fatalf("types = %s, %s", sys.MetricType, dia.MetricType)
+	}
+	if sys.ReadingID == nil || dia.ReadingID == nil || *sys.ReadingID != *dia.ReadingID {
+		t.Fatal("expected both halves to share a reading ID")
+	}
+	if !sys.RecordedAt.Equal(at) || !dia.RecordedAt.Equal(at) {
+		t.Error("expected both halves at the same time")
+	}
+	if !SameReading(sys, dia) {
+		t.Error("expected linked halves to be the same reading")
+	}
+}
+
+func TestSameReadingLegacyRows(t *testing.T) {
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	sys := NewMetric(MetricBPSys, 118).WithRecordedAt(at)
+	dia := NewMetric(MetricBPDia, 76).WithRecordedAt(at)
+	if !SameReading(sys, dia) {
+		t.Error("expected unlinked halves at the same instant to pair")
+	}
+
+	later := NewMetric(MetricBPDia, 76).WithRecordedAt(at.Add(time.Minute))
+	if SameReading(sys, later) {
+		t.Error("expected halves at different times not to pair")
+	}
+
+	linkedSys, _ := NewBloodPressure(130, 85, at)
+	if SameReading(linkedSys, dia) {
+		t.Error("expected a linked half not to pair with an unlinked one")
+	}
+	if SameReading(sys, NewMetric(MetricWeight, 80).WithRecordedAt(at)) {
+		t.Error("expected non-BP metrics never to pair")
+	}
+}
+
+func TestGroupReadings(t *testing.T) {
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	sys, dia := NewBloodPressure(120, 80, at)
+	weight := NewMetric(MetricWeight, 82).WithRecordedAt(at)
+	orphan := NewMetric(MetricBPSys, 125).WithRecordedAt(at.Add(-time.Hour))
+
+	// Diastolic first: the entry should still lead with the systolic half
+	entries := GroupReadings([]*Metric{dia, weight, sys, orphan})
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if !entries[0].IsBloodPressure() || entries[0].Metric != sys || entries[0].Reading() != "120/80" {
+		t.Errorf("entry 0 = %+v, want paired 120/80", entries[0])
+	}
+	if entries[1].Metric != weight || entries[1].IsBloodPressure() {
+		t.Errorf("entry 1 = %+v, want weight", entries[1])
+	}
+	if entries[2].Metric != orphan || entries[2].IsBloodPressure() {
+		t.Errorf("entry 2 = %+v, want unpaired bp_sys", entries[2])
+	}
+}
@@ -0,0 +1,134 @@
+// ABOUTME: Reminder model for daily nudges like "log weight".
+// ABOUTME: Works out when a reminder is due, honoring snoozes, quiet hours, and delivery channels.
+package models
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Reminder delivery channels.
+const (
+	ChannelTerminal = "terminal" // printed by 'health remind due'
+	ChannelDesktop  = "desktop"  // osascript or notify-send
+	ChannelNtfy     = "ntfy"     // push to an ntfy topic
+	ChannelEmail    = "email"    // sent over SMTP
+)
+
+// ReminderChannels lists every delivery channel.
+var ReminderChannels = []string{ChannelTerminal, ChannelDesktop, ChannelNtfy, ChannelEmail}
+
+// IsValidChannel reports whether c is a known delivery channel.
+func IsValidChannel(c string) bool {
+	return slices.Contains(ReminderChannels, c)
+}
+
+// Reminder is a nudge that comes due once a day at a set time.
+type Reminder struct {
+	Message  string
+	Daily    string   // time of day, HH:MM
+	Notify   bool     // also send a desktop notification
+	Channels []string // where to deliver it; empty means the terminal
+}
+
+// DeliveryChannels returns where the reminder goes: its channels, or the
+// terminal when none are set, plus the desktop when Notify is on.
+func (r Reminder) DeliveryChannels() []string {
+	channels := append([]string(nil), r.Channels...)
+	if len(channels) == 0 {
+		channels = []string{ChannelTerminal}
+	}
+	if r.Notify && !slices.Contains(channels, ChannelDesktop) {
+		channels = append(channels, ChannelDesktop)
+	}
+	return channels
+}
+
+// Key identifies the reminder when tracking whether it has fired.
+func (r Reminder) Key() string {
+	return Slugify(r.Message)
+}
+
+// ParseClock validates an HH:MM time of day.
+func ParseClock(s string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// LastDue returns the most recent time at or before now that the reminder
+// came due.
+func (r Reminder) LastDue(now time.Time) (time.Time, error) {
+	hour, minute, err := ParseClock(r.Daily)
+	if err != nil {
+		return time.Time{}, err
+	}
+	due := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if due.After(now) {
+		due = due.AddDate(0, 0, -1)
+	}
+	return due, nil
+}
+
+// IsDue reports whether the reminder should fire at now. Normally that is
+// when it has come due since it last fired; a reminder that has never fired
+// is due at its most recent occurrence. While snoozedUntil is in the future
+// the reminder stays quiet, and once it passes the reminder fires again even
+// if it already fired that day.
+func (r Reminder) IsDue(now time.Time, lastFired, snoozedUntil *time.Time) (bool, error) {
+	due, err := r.LastDue(now)
+	if err != nil {
+		return false, err
+	}
+	if snoozedUntil != nil {
+		if now.Before(*snoozedUntil) {
+			return false, nil
+		}
+		if snoozedUntil.After(due) {
+			due = *snoozedUntil
+		}
+	}
+	return lastFired == nil || lastFired.Before(due), nil
+}
+
+// QuietHours is a daily window, such as 22:00 to 07:00, in which reminders
+// are held back. Reminders that come due during it fire when it ends.
+type QuietHours struct {
+	Start string // HH:MM
+	End   string // HH:MM, may be earlier than Start to span midnight
+}
+
+// Validate checks both ends are HH:MM times.
+func (q QuietHours) Validate() error {
+	if _, _, err := ParseClock(q.Start); err != nil {
+		return err
+	}
+	_, _, err := ParseClock(q.End)
+	return err
+}
+
+// Contains reports whether t falls in the quiet window. A window whose
+// start and end are equal is empty.
+func (q QuietHours) Contains(t time.Time) (bool, error) {
+	sh, sm, err := ParseClock(q.Start)
+	if err != nil {
+		return false, err
+	}
+	eh, em, err := ParseClock(q.End)
+	if err != nil {
+		return false, err
+	}
+	start, end, now := sh*60+sm, eh*60+em, t.Hour()*60+t.Minute()
+	if start <= end {
+		return now >= start && now < end, nil
+	}
+	return now >= start || now < end, nil
+}
+
+// String formats the window as "22:00-07:00".
+func (q QuietHours) String() string {
+	return q.Start + "-" + q.End
+}
@@ -0,0 +1,126 @@
+// ABOUTME: Tests for the Reminder model.
+// ABOUTME: Validates due-time calculation across day boundaries, snoozes, and quiet hours.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReminderLastDue(t *testing.T) {
+	r := Reminder{Message: "Log weight", Daily: "08:00"}
+
+	morning := time.Date(2024, 12, 14, 9, 30, 0, 0, time.UTC)
+	due, err := r.LastDue(morning)
+	if err != nil {
+		t.Fatalf("LastDue failed: %v", err)
+	}
+	if !due.Equal(time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("LastDue(09:30) = %v, want today 08:00", due)
+	}
+
+	early := time.Date(2024, 12, 14, 6, 0, 0, 0, time.UTC)
+	due, _ = r.LastDue(early)
+	if !due.Equal(time.Date(2024, 12, 13, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("LastDue(06:00) = %v, want yesterday 08:00", due)
+	}
+
+	if r.Key() != "log-weight" {
+		t.Errorf("Key = %q, want log-weight", r.Key())
+	}
+	if _, err := (Reminder{Daily: "8am"}).LastDue(morning); err == nil {
+		t.Error("expected error for invalid time")
+	}
+}
+
+func TestReminderIsDue(t *testing.T) {
+	r := Reminder{Message: "Log weight", Daily: "08:00"}
+	now := time.Date(2024, 12, 14, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		lastFired *time.Time
+		want      bool
+	}{
+		{"never fired", nil, true},
+		{"fired yesterday", timePtr(now.AddDate(0, 0, -1)), true},
+		{"fired after today's time", timePtr(time.Date(2024, 12, 14, 8, 5, 0, 0, time.UTC)), false},
+	}
+
+	for _, tt := range tests {
+		got, err := r.IsDue(now, tt.lastFired, nil)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: IsDue = %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestReminderIsDueSnoozed(t *testing.T) {
+	r := Reminder{Message: "Log weight", Daily: "08:00"}
+	firedToday := timePtr(time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC))
+	until := timePtr(time.Date(2024, 12, 14, 10, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		now       time.Time
+		lastFired *time.Time
+		want      bool
+	}{
+		{"still snoozed", time.Date(2024, 12, 14, 9, 30, 0, 0, time.UTC), firedToday, false},
+		{"snooze over", time.Date(2024, 12, 14, 10, 0, 0, 0, time.UTC), firedToday, true},
+		{"fired after snooze", time.Date(2024, 12, 14, 11, 0, 0, 0, time.UTC), timePtr(time.Date(2024, 12, 14, 10, 1, 0, 0, time.UTC)), false},
+		{"occurrence held by snooze", time.Date(2024, 12, 14, 7, 0, 0, 0, time.UTC), timePtr(time.Date(2024, 12, 13, 8, 0, 0, 0, time.UTC)), false},
+	}
+	for _, tt := range tests {
+		got, err := r.IsDue(tt.now, tt.lastFired, until)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: IsDue = %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2024, 12, 14, h, m, 0, 0, time.UTC) }
+	overnight := QuietHours{Start: "22:00", End: "07:00"}
+	afternoon := QuietHours{Start: "13:00", End: "14:30"}
+
+	tests := []struct {
+		q    QuietHours
+		t    time.Time
+		want bool
+	}{
+		{overnight, at(23, 0), true},
+		{overnight, at(3, 0), true},
+		{overnight, at(7, 0), false},
+		{overnight, at(12, 0), false},
+		{afternoon, at(13, 0), true},
+		{afternoon, at(14, 30), false},
+		{QuietHours{Start: "09:00", End: "09:00"}, at(9, 0), false},
+	}
+	for _, tt := range tests {
+		got, err := tt.q.Contains(tt.t)
+		if err != nil || got != tt.want {
+			t.Errorf("%s contains %s = %v, %v; want %v", tt.q, tt.t.Format("15:04"), got, err, tt.want)
+		}
+	}
+	if err := (QuietHours{Start: "10pm", End: "07:00"}).Validate(); err == nil {
+		t.Error("expected error for invalid start")
+	}
+}
+
+func TestReminderDeliveryChannels(t *testing.T) {
+	if got := (Reminder{}).DeliveryChannels(); len(got) != 1 || got[0] != ChannelTerminal {
+		t.Errorf("default channels = %v, want terminal", got)
+	}
+	got := Reminder{Notify: true, Channels: []string{ChannelNtfy}}.DeliveryChannels()
+	if len(got) != 2 || got[0] != ChannelNtfy || got[1] != ChannelDesktop {
+		t.Errorf("channels = %v, want ntfy and desktop", got)
+	}
+	if IsValidChannel("sms") {
+		t.Error("sms should not be a channel")
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,66 @@
+// ABOUTME: SleepSession model for tracking bed/wake times and sleep quality.
+// ABOUTME: Sessions derive a sleep_hours metric so existing metric views keep working.
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SleepSession represents a single sleep period from bed time to wake time.
+type SleepSession struct {
+	ID         uuid.UUID
+	BedTime    time.Time
+	WakeTime   time.Time
+	Awakenings *int
+	Quality    *int       // 1-10 scale
+	MetricID   *uuid.UUID // Derived sleep_hours metric, if one was recorded
+	Notes      *string
+	CreatedAt  time.Time
+}
+
+// NewSleepSession creates a new SleepSession with generated UUID.
+func NewSleepSession(bedTime, wakeTime time.Time) *SleepSession {
+	return &SleepSession{
+		ID:        uuid.New(),
+		BedTime:   bedTime,
+		WakeTime:  wakeTime,
+		CreatedAt: time.Now(),
+	}
+}
+
+// WithAwakenings sets the number of times the sleeper woke during the night.
+func (s *SleepSession) WithAwakenings(n int) *SleepSession {
+	s.Awakenings = &n
+	return s
+}
+
+// WithQuality sets the subjective sleep quality (1-10).
+func (s *SleepSession) WithQuality(q int) *SleepSession {
+	s.Quality = &q
+	return s
+}
+
+// WithNotes sets notes on the sleep session.
+func (s *SleepSession) WithNotes(notes string) *SleepSession {
+	s.Notes = &notes
+	return s
+}
+
+// Duration returns the time between going to bed and waking up.
+func (s *SleepSession) Duration() time.Duration {
+	return s.WakeTime.Sub(s.BedTime)
+}
+
+// Hours returns the session duration in hours, rounded to two decimals.
+func (s *SleepSession) Hours() float64 {
+	return math.Round(s.Duration().Hours()*100) / 100
+}
+
+// HoursMetric builds the sleep_hours metric derived from this session,
+// recorded at wake time.
+func (s *SleepSession) HoursMetric() *Metric {
+	return NewMetric(MetricSleepHours, s.Hours()).WithRecordedAt(s.WakeTime)
+}
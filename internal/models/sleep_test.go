@@ -0,0 +1,54 @@
+// ABOUTME: Tests for the SleepSession model.
+// ABOUTME: Validates duration math and the derived sleep_hours metric.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewSleepSession(t *testing.T) {
+	bed := time.Date(2024, 12, 13, 23, 30, 0, 0, time.UTC)
+	wake := time.Date(2024, 12, 14, 7, 10, 0, 0, time.UTC)
+
+	s := NewSleepSession(bed, wake).WithAwakenings(2).WithQuality(7).WithNotes("restless")
+
+	if s.ID.String() == "" {
+		t.Error("expected UUID to be set")
+	}
+	if s.Duration() != 7*time.Hour+40*time.Minute {
+		t.Errorf("Duration = %v, want 7h40m", s.Duration())
+	}
+	if s.Hours() != 7.67 {
+		t.Errorf("Hours = %v, want 7.67", s.Hours())
+	}
+	if s.Awakenings == nil || *s.Awakenings != 2 {
+		t.Error("expected awakenings to be 2")
+	}
+	if s.Quality == nil || *s.Quality != 7 {
+		t.Error("expected quality to be 7")
+	}
+	if s.Notes == nil || *s.Notes != "restless" {
+		t.Error("expected notes to be set")
+	}
+}
+
+func TestSleepSessionHoursMetric(t *testing.T) {
+	bed := time.Date(2024, 12, 13, 22, 0, 0, 0, time.UTC)
+	wake := time.Date(2024, 12, 14, 6, 30, 0, 0, time.UTC)
+
+	m := NewSleepSession(bed, wake).HoursMetric()
+
+	if m.MetricType != MetricSleepHours {
+		t.Errorf("MetricType = %s, want sleep_hours", m.MetricType)
+	}
+	if m.Value != 8.5 {
+		t.Errorf("Value = %v, want 8.5", m.Value)
+	}
+	if m.Unit != "hours" {
+		t.Errorf("Unit = %s, want hours", m.Unit)
+	}
+	if !m.RecordedAt.Equal(wake) {
+		t.Errorf("RecordedAt = %v, want wake time %v", m.RecordedAt, wake)
+	}
+}
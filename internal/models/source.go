@@ -0,0 +1,33 @@
+// ABOUTME: Source attribution recording where a metric or workout came from.
+// ABOUTME: Lists the known sources: manual entry, importers, the MCP server, sync, and estimates.
+package models
+
+// Sources of metrics and workouts. Entries stored before sources were
+// recorded have none.
+const (
+	SourceManual      = "manual"
+	SourceAppleHealth = "apple_health"
+	SourceFitbit      = "fitbit"
+	SourceOura        = "oura"
+	SourceWithings    = "withings"
+	SourceEnvironment = "environment"
+	SourceMCP         = "mcp"
+	SourceSync        = "sync"
+	SourceEstimate    = "estimate" // computed from other data, e.g. VO2max from runs
+)
+
+// AllSources lists the known sources.
+var AllSources = []string{
+	SourceManual, SourceAppleHealth, SourceFitbit, SourceOura, SourceWithings,
+	SourceEnvironment, SourceMCP, SourceSync, SourceEstimate,
+}
+
+// IsValidSource checks if a string is a known source.
+func IsValidSource(s string) bool {
+	for _, src := range AllSources {
+		if src == s {
+			return true
+		}
+	}
+	return false
+}
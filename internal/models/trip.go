@@ -0,0 +1,92 @@
+// ABOUTME: Trip model for travel mode.
+// ABOUTME: A trip marks a period away from home with a destination tag and optional timezone.
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Trip is a period of travel. Entries logged while a trip is active are
+// tagged with its Destination, and clock times are read in its Timezone.
+// Day-by-day analyses such as streaks should treat days covered by a trip
+// as excused rather than missed.
+type Trip struct {
+	ID          uuid.UUID
+	Destination string
+	Timezone    *string // IANA name, e.g. "Europe/Paris"
+	StartedAt   time.Time
+	EndedAt     *time.Time
+	Notes       *string
+	CreatedAt   time.Time
+}
+
+// NewTrip creates a new open-ended Trip starting now.
+func NewTrip(destination string) *Trip {
+	now := time.Now()
+	return &Trip{
+		ID:          uuid.New(),
+		Destination: destination,
+		StartedAt:   now,
+		CreatedAt:   now,
+	}
+}
+
+// WithTimezone sets the trip's IANA timezone after checking that it exists.
+func (t *Trip) WithTimezone(tz string) (*Trip, error) {
+	if _, err := time.LoadLocation(tz); err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", tz)
+	}
+	t.Timezone = &tz
+	return t, nil
+}
+
+// WithStartedAt sets when the trip began.
+func (t *Trip) WithStartedAt(at time.Time) *Trip {
+	t.StartedAt = at
+	return t
+}
+
+// WithNotes sets notes on the trip.
+func (t *Trip) WithNotes(notes string) *Trip {
+	t.Notes = &notes
+	return t
+}
+
+// IsActive reports whether the trip has not been ended.
+func (t *Trip) IsActive() bool {
+	return t.EndedAt == nil
+}
+
+// Covers reports whether at falls within the trip.
+func (t *Trip) Covers(at time.Time) bool {
+	if at.Before(t.StartedAt) {
+		return false
+	}
+	return t.EndedAt == nil || at.Before(*t.EndedAt)
+}
+
+// CoversDay reports whether any part of the calendar day containing day
+// (in day's location) falls within the trip.
+func (t *Trip) CoversDay(day time.Time) bool {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	if !t.StartedAt.Before(end) {
+		return false
+	}
+	return t.EndedAt == nil || t.EndedAt.After(start)
+}
+
+// Location returns the trip's timezone, or time.Local when none is set.
+func (t *Trip) Location() *time.Location {
+	if t.Timezone == nil {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(*t.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
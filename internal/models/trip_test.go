@@ -0,0 +1,57 @@
+// ABOUTME: Tests for the Trip model.
+// ABOUTME: Validates coverage checks and timezone handling.
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTripCovers(t *testing.T) {
+	start := time.Date(2024, 12, 10, 15, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 12, 14, 9, 0, 0, 0, time.UTC)
+	trip := NewTrip("paris").WithStartedAt(start)
+
+	if !trip.IsActive() || !trip.Covers(start.AddDate(0, 1, 0)) {
+		t.Error("open trip should cover everything after its start")
+	}
+	if trip.Covers(start.Add(-time.Minute)) {
+		t.Error("trip should not cover time before its start")
+	}
+
+	trip.EndedAt = &end
+	if trip.Covers(end) {
+		t.Error("trip end should be exclusive")
+	}
+	if !trip.Covers(end.Add(-time.Minute)) {
+		t.Error("trip should cover time before its end")
+	}
+
+	if !trip.CoversDay(time.Date(2024, 12, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Error("trip should cover the day it started")
+	}
+	if !trip.CoversDay(time.Date(2024, 12, 14, 23, 0, 0, 0, time.UTC)) {
+		t.Error("trip should cover the day it ended")
+	}
+	if trip.CoversDay(time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC)) {
+		t.Error("trip should not cover the day after it ended")
+	}
+}
+
+func TestTripTimezone(t *testing.T) {
+	trip := NewTrip("tokyo")
+	if trip.Location() != time.Local {
+		t.Error("trip without timezone should use local time")
+	}
+
+	if _, err := trip.WithTimezone("Asia/Tokyo"); err != nil {
+		t.Fatalf("WithTimezone failed: %v", err)
+	}
+	if trip.Location().String() != "Asia/Tokyo" {
+		t.Errorf("Location = %s, want Asia/Tokyo", trip.Location())
+	}
+
+	if _, err := NewTrip("nowhere").WithTimezone("Mars/Olympus"); err == nil {
+		t.Error("expected error for unknown timezone")
+	}
+}
@@ -0,0 +1,170 @@
+// ABOUTME: Unit parsing for metric values typed with a unit, like "180lb" or "16oz".
+// ABOUTME: Converts values to the stored unit for each metric type in MetricUnits.
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func scale(f float64) func(float64) float64 {
+	return func(v float64) float64 { return v * f }
+}
+
+var (
+	same         = scale(1)
+	fahrenheitC  = func(v float64) float64 { return (v - 32) * 5 / 9 }
+	poundKg      = scale(0.45359237)
+	fluidOunceMl = scale(29.5735295625)
+	cupMl        = scale(236.5882365)
+	ounceG       = scale(28.349523125)
+)
+
+// unitConversions lists, per stored unit, the suffixes accepted on input
+// (lowercase, without spaces) and how to convert them. Stored units not
+// listed here accept only themselves.
+var unitConversions = map[string]map[string]func(float64) float64{
+	"kg": {
+		"kg": same, "kgs": same,
+		"lb": poundKg, "lbs": poundKg, "pound": poundKg, "pounds": poundKg,
+		"st": scale(6.35029318),
+	},
+	"ml": {
+		"ml": same, "l": scale(1000),
+		"oz": fluidOunceMl, "floz": fluidOunceMl,
+		"cup": cupMl, "cups": cupMl,
+	},
+	"°C": {
+		"c": same, "°c": same,
+		"f": fahrenheitC, "°f": fahrenheitC,
+	},
+	// Differences from a baseline scale without the 32° offset
+	"Δ°C": {
+		"c": same, "°c": same, "δ°c": same,
+		"f": scale(5.0 / 9), "°f": scale(5.0 / 9),
+	},
+	"hours": {
+		"h": same, "hr": same, "hrs": same, "hour": same, "hours": same,
+		"m": scale(1.0 / 60), "min": scale(1.0 / 60), "mins": scale(1.0 / 60), "minutes": scale(1.0 / 60),
+	},
+	"min": {
+		"m": same, "min": same, "mins": same, "minutes": same,
+		"h": scale(60), "hr": scale(60), "hrs": scale(60), "hour": scale(60), "hours": scale(60),
+	},
+	"g": {
+		"g": same, "grams": same,
+		"oz": ounceG,
+	},
+	"kcal": {
+		"kcal": same, "cal": same, "cals": same, "calories": same,
+		"kj": scale(1 / 4.184),
+	},
+}
+
+// ConvertValue converts a value given in unit to the stored unit for mt.
+// An empty unit means the value is already in the stored unit.
+func ConvertValue(mt MetricType, value float64, unit string) (float64, error) {
+	key := strings.ToLower(strings.ReplaceAll(unit, " ", ""))
+	if key == "" {
+		return value, nil
+	}
+
+	stored := MetricUnits[mt]
+	conversions := unitConversions[stored]
+	if conversions == nil {
+		conversions = map[string]func(float64) float64{strings.ToLower(stored): same}
+	}
+	convert, ok := conversions[key]
+	if !ok {
+		accepted := make([]string, 0, len(conversions))
+		for k := range conversions {
+			accepted = append(accepted, k)
+		}
+		sort.Strings(accepted)
+		return 0, fmt.Errorf("unknown unit %q for %s (use %s)", unit, mt, strings.Join(accepted, ", "))
+	}
+	return convert(value), nil
+}
+
+// ParseValue parses a number with an optional unit suffix ("180lb",
+// "16 oz", "+250ml") and converts it to the stored unit for mt. A leading
+// + is allowed for increments. Several parts, each with its unit, add up:
+// "7h30m" of sleep is 7.5 hours.
+func ParseValue(mt MetricType, s string) (float64, error) {
+	parts, err := splitQuantity(s)
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, p := range parts {
+		if len(parts) > 1 && p.unit == "" {
+			return 0, fmt.Errorf("invalid value: %s (give each part a unit, e.g. 7h30m)", strings.TrimSpace(s))
+		}
+		v, err := ConvertValue(mt, p.value, p.unit)
+		if err != nil {
+			return 0, err
+		}
+		total += v
+	}
+	return total, nil
+}
+
+// quantityPart is one number and the unit after it.
+type quantityPart struct {
+	value float64
+	unit  string
+}
+
+// splitQuantity splits "7h 30m" into its numbers and units. Only the
+// first number may carry a sign.
+func splitQuantity(s string) ([]quantityPart, error) {
+	s = strings.TrimSpace(s)
+	var parts []quantityPart
+	for rest := s; rest != ""; {
+		digits := "+-.0123456789"
+		if len(parts) > 0 {
+			digits = ".0123456789"
+		}
+		end := 0
+		for end < len(rest) && strings.ContainsRune(digits, rune(rest[end])) {
+			end++
+		}
+		value, err := strconv.ParseFloat(rest[:end], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value: %s", s)
+		}
+		rest = rest[end:]
+		unitEnd := strings.IndexAny(rest, ".0123456789")
+		if unitEnd < 0 {
+			unitEnd = len(rest)
+		}
+		parts = append(parts, quantityPart{value, strings.TrimSpace(rest[:unitEnd])})
+		rest = strings.TrimSpace(rest[unitEnd:])
+	}
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("invalid value: %s", s)
+	}
+	return parts, nil
+}
+
+// Retype moves a metric logged under the wrong type to mt, switching its
+// unit to mt's stored unit. A value in a unit mt accepts is converted
+// (0.5 hours of sleep_hours becomes 30 min of meditation); any other value
+// is kept as is, since it was typed in mt's unit under the wrong name.
+// Blood pressure halves are linked into readings and can't be retyped.
+func (m *Metric) Retype(mt MetricType) error {
+	if !IsValidMetricType(string(mt)) {
+		return fmt.Errorf("unknown metric type: %s", mt)
+	}
+	if m.IsBloodPressure() || mt == MetricBPSys || mt == MetricBPDia {
+		return fmt.Errorf("blood pressure readings can't be retyped; delete and add the reading again")
+	}
+	if value, err := ConvertValue(mt, m.Value, m.Unit); err == nil {
+		m.Value = value
+	}
+	m.MetricType = mt
+	m.Unit = MetricUnits[mt]
+	return nil
+}
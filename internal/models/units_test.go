@@ -0,0 +1,103 @@
+// ABOUTME: Tests for unit parsing and conversion of metric values.
+// ABOUTME: Covers suffix forms, per-type units, and unknown units.
+package models
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		mt   MetricType
+		in   string
+		want float64
+	}{
+		{MetricWeight, "82.5", 82.5},
+		{MetricWeight, "180lb", 81.647},
+		{MetricWeight, "180 LBS", 81.647},
+		{MetricWeight, "12st", 76.204},
+		{MetricWater, "+16oz", 473.176},
+		{MetricWater, "1.5l", 1500},
+		{MetricWater, "2 cups", 473.176},
+		{MetricTemperature, "98.6f", 37},
+		{MetricTemperature, "37°C", 37},
+		{MetricTempDev, "0.9f", 0.5},
+		{MetricTempDev, "-0.3", -0.3},
+		{MetricSleepHours, "450min", 7.5},
+		{MetricSleepHours, "7h30m", 7.5},
+		{MetricSleepHours, "7h 15 min", 7.25},
+		{MetricMeditation, "1h5m", 65},
+		{MetricMeditation, "1h", 60},
+		{MetricProtein, "4oz", 113.398},
+		{MetricCalories, "+2000kj", 478.011},
+		{MetricHeartRate, "62bpm", 62},
+		{MetricBodyFat, "18%", 18},
+	}
+	for _, tt := range tests {
+		got, err := ParseValue(tt.mt, tt.in)
+		if err != nil {
+			t.Errorf("ParseValue(%s, %q) error: %v", tt.mt, tt.in, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 0.001 {
+			t.Errorf("ParseValue(%s, %q) = %.3f, want %.3f", tt.mt, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseValueErrors(t *testing.T) {
+	tests := []struct {
+		mt MetricType
+		in string
+	}{
+		{MetricWeight, "lots"},
+		{MetricWeight, "180oz"},
+		{MetricMood, "7kg"},
+		{MetricWater, ""},
+		{MetricSleepHours, "7h30"},
+		{MetricSleepHours, "7h-30m"},
+		{MetricWeight, "180lb 4oz"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseValue(tt.mt, tt.in); err == nil {
+			t.Errorf("ParseValue(%s, %q) expected error", tt.mt, tt.in)
+		}
+	}
+}
+
+func TestRetype(t *testing.T) {
+	tests := []struct {
+		from      MetricType
+		value     float64
+		to        MetricType
+		want      float64
+		wantUnits string
+	}{
+		{MetricSteps, 2100, MetricCalories, 2100, "kcal"},
+		{MetricSleepHours, 0.5, MetricMeditation, 30, "min"},
+		{MetricCalories, 450, MetricActiveCalories, 450, "kcal"},
+		{MetricTemperature, 21, MetricAmbientTemp, 21, "°C"},
+	}
+	for _, tt := range tests {
+		m := NewMetric(tt.from, tt.value)
+		if err := m.Retype(tt.to); err != nil {
+			t.Fatalf("Retype(%s → %s) error: %v", tt.from, tt.to, err)
+		}
+		if m.MetricType != tt.to || m.Unit != tt.wantUnits || math.Abs(m.Value-tt.want) > 0.001 {
+			t.Errorf("Retype(%s → %s) = %s %v %s, want %v %s", tt.from, tt.to, m.MetricType, m.Value, m.Unit, tt.want, tt.wantUnits)
+		}
+	}
+
+	sys, _ := NewBloodPressure(120, 80, time.Now())
+	if err := sys.Retype(MetricHeartRate); err == nil {
+		t.Error("expected an error retyping blood pressure")
+	}
+	if err := NewMetric(MetricHeartRate, 62).Retype(MetricBPSys); err == nil {
+		t.Error("expected an error retyping to blood pressure")
+	}
+	if err := NewMetric(MetricSteps, 1).Retype("nope"); err == nil {
+		t.Error("expected an error for an unknown type")
+	}
+}
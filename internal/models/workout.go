@@ -1,8 +1,9 @@
 // ABOUTME: Workout and WorkoutMetric models for exercise tracking.
-// ABOUTME: Workouts contain sub-metrics like distance, pace, sets, reps.
+// ABOUTME: Workouts contain sub-metrics like distance, pace, sets, reps, and coach comments.
 package models
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,9 +15,16 @@ type Workout struct {
 	WorkoutType     string
 	StartedAt       time.Time
 	DurationMinutes *int
+	RPE             *int // Rating of perceived exertion, 1-10
+	Zone            *int // Intensity zone, 1-5
 	Notes           *string
+	Location        *string           // Location name or "lat,lon"
+	Source          string            // Where the workout came from, e.g. SourceManual; empty if unknown
+	Metadata        map[string]string // Free-form details such as a device name or GPS file
 	CreatedAt       time.Time
-	Metrics         []WorkoutMetric // Populated when fetching full workout
+	Metrics         []WorkoutMetric  // Populated when fetching full workout
+	Sets            []WorkoutSet     // Populated when fetching full workout
+	Comments        []WorkoutComment // Populated when fetching full workout
 }
 
 // NewWorkout creates a new Workout with generated UUID and current timestamp.
@@ -36,12 +44,61 @@ func (w *Workout) WithDuration(minutes int) *Workout {
 	return w
 }
 
+// WithRPE sets the rating of perceived exertion.
+func (w *Workout) WithRPE(rpe int) *Workout {
+	w.RPE = &rpe
+	return w
+}
+
+// WithZone sets the intensity zone.
+func (w *Workout) WithZone(zone int) *Workout {
+	w.Zone = &zone
+	return w
+}
+
+// Intensity scales: RPE runs from 1 (very light) to 10 (max effort), and
+// zones from 1 (recovery) to 5 (maximal), as in heart rate training zones.
+const (
+	MaxRPE  = 10
+	MaxZone = 5
+)
+
+// ValidateIntensity checks the workout's RPE and zone, when set, are on
+// their scales.
+func (w *Workout) ValidateIntensity() error {
+	if w.RPE != nil && (*w.RPE < 1 || *w.RPE > MaxRPE) {
+		return fmt.Errorf("rpe must be between 1 and %d, got %d", MaxRPE, *w.RPE)
+	}
+	if w.Zone != nil && (*w.Zone < 1 || *w.Zone > MaxZone) {
+		return fmt.Errorf("zone must be between 1 and %d, got %d", MaxZone, *w.Zone)
+	}
+	return nil
+}
+
 // WithNotes sets notes on the workout.
 func (w *Workout) WithNotes(notes string) *Workout {
 	w.Notes = &notes
 	return w
 }
 
+// WithLocation tags the workout with a location name or "lat,lon".
+func (w *Workout) WithLocation(location string) *Workout {
+	w.Location = &location
+	return w
+}
+
+// WithSource records where the workout came from.
+func (w *Workout) WithSource(source string) *Workout {
+	w.Source = source
+	return w
+}
+
+// WithMetadata sets one metadata key on the workout.
+func (w *Workout) WithMetadata(key, value string) *Workout {
+	w.Metadata = setMetadata(w.Metadata, key, value)
+	return w
+}
+
 // WithStartedAt sets a custom start timestamp.
 func (w *Workout) WithStartedAt(t time.Time) *Workout {
 	w.StartedAt = t
@@ -73,3 +130,56 @@ func NewWorkoutMetric(workoutID uuid.UUID, name string, value float64, unit stri
 		CreatedAt:  time.Now(),
 	}
 }
+
+// WorkoutSet represents one set of a strength exercise within a workout.
+type WorkoutSet struct {
+	ID         uuid.UUID
+	WorkoutID  uuid.UUID
+	Exercise   string
+	SetNumber  int
+	Reps       int
+	Weight     *float64
+	WeightUnit *string
+	CreatedAt  time.Time
+}
+
+// NewWorkoutSet creates a new WorkoutSet without a load.
+func NewWorkoutSet(workoutID uuid.UUID, exercise string, setNumber, reps int) *WorkoutSet {
+	return &WorkoutSet{
+		ID:        uuid.New(),
+		WorkoutID: workoutID,
+		Exercise:  exercise,
+		SetNumber: setNumber,
+		Reps:      reps,
+		CreatedAt: time.Now(),
+	}
+}
+
+// WithWeight sets the load lifted for the set. An empty unit is left unset.
+func (s *WorkoutSet) WithWeight(weight float64, unit string) *WorkoutSet {
+	s.Weight = &weight
+	if unit != "" {
+		s.WeightUnit = &unit
+	}
+	return s
+}
+
+// WorkoutComment is feedback left on a workout, typically by a coach.
+type WorkoutComment struct {
+	ID        uuid.UUID
+	WorkoutID uuid.UUID
+	Author    string
+	Body      string
+	CreatedAt time.Time
+}
+
+// NewWorkoutComment creates a new WorkoutComment dated now.
+func NewWorkoutComment(workoutID uuid.UUID, author, body string) *WorkoutComment {
+	return &WorkoutComment{
+		ID:        uuid.New(),
+		WorkoutID: workoutID,
+		Author:    author,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+}
@@ -164,3 +164,57 @@ func TestWorkoutMetricsSliceInitialization(t *testing.T) {
 		t.Errorf("Metrics should be empty initially, got %d", len(w.Metrics))
 	}
 }
+
+func TestNewWorkoutSet(t *testing.T) {
+	w := NewWorkout("lift")
+	ws := NewWorkoutSet(w.ID, "bench", 2, 5)
+
+	if ws.WorkoutID != w.ID {
+		t.Error("expected WorkoutID to match")
+	}
+	if ws.Exercise != "bench" || ws.SetNumber != 2 || ws.Reps != 5 {
+		t.Errorf("unexpected set: %+v", ws)
+	}
+	if ws.Weight != nil || ws.WeightUnit != nil {
+		t.Error("expected no weight by default")
+	}
+}
+
+func TestWorkoutSetWithWeight(t *testing.T) {
+	w := NewWorkout("lift")
+	ws := NewWorkoutSet(w.ID, "squat", 1, 5).WithWeight(100, "kg")
+
+	if ws.Weight == nil || *ws.Weight != 100 {
+		t.Error("expected Weight to be 100")
+	}
+	if ws.WeightUnit == nil || *ws.WeightUnit != "kg" {
+		t.Error("expected WeightUnit to be kg")
+	}
+
+	bodyweight := NewWorkoutSet(w.ID, "dip", 1, 10).WithWeight(20, "")
+	if bodyweight.WeightUnit != nil {
+		t.Error("WeightUnit should be nil when empty string provided")
+	}
+}
+
+func TestWorkoutValidateIntensity(t *testing.T) {
+	w := NewWorkout("run").WithRPE(7).WithZone(3)
+	if *w.RPE != 7 || *w.Zone != 3 {
+		t.Errorf("RPE/Zone = %d/%d, want 7/3", *w.RPE, *w.Zone)
+	}
+	if err := w.ValidateIntensity(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := NewWorkout("run").ValidateIntensity(); err != nil {
+		t.Errorf("unset intensity should be valid: %v", err)
+	}
+	for _, bad := range []*Workout{
+		NewWorkout("run").WithRPE(0),
+		NewWorkout("run").WithRPE(11),
+		NewWorkout("run").WithZone(6),
+	} {
+		if err := bad.ValidateIntensity(); err == nil {
+			t.Errorf("expected an error for RPE %v zone %v", bad.RPE, bad.Zone)
+		}
+	}
+}
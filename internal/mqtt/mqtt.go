@@ -0,0 +1,261 @@
+// ABOUTME: Minimal MQTT 3.1.1 publisher: connect, publish with QoS 1, disconnect.
+// ABOUTME: Enough for pushing retained sensor states; it never subscribes.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Packet types, as the high nibble of the first header byte.
+const (
+	typeConnect    = 1
+	typeConnack    = 2
+	typePublish    = 3
+	typePuback     = 4
+	typeDisconnect = 14
+)
+
+// Timeout bounds each network round trip.
+const Timeout = 30 * time.Second
+
+// connackErrors are the CONNACK return codes of MQTT 3.1.1 section 3.2.2.3.
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Options are the connection settings.
+type Options struct {
+	ClientID string
+	Username string
+	Password string
+}
+
+// Client is a connection to a broker.
+type Client struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID uint16
+}
+
+// Dial connects to broker, given as host:port (1883 if omitted), or as a
+// mqtt://, tcp://, or mqtts:// URL (TLS, 8883 if omitted).
+func Dial(ctx context.Context, broker string, opts Options) (*Client, error) {
+	addr, useTLS, err := parseBroker(broker)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: Timeout}
+	var conn net.Conn
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		td := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = td.DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: %w", err)
+	}
+
+	c := &Client{conn: conn, r: bufio.NewReader(conn), nextID: 1}
+	if err := c.connect(opts); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	slog.DebugContext(ctx, "mqtt connected", "broker", addr, "tls", useTLS)
+	return c, nil
+}
+
+// parseBroker returns the host:port to dial and whether to use TLS.
+func parseBroker(broker string) (string, bool, error) {
+	useTLS := false
+	if strings.Contains(broker, "://") {
+		u, err := url.Parse(broker)
+		if err != nil {
+			return "", false, fmt.Errorf("mqtt: invalid broker URL: %w", err)
+		}
+		switch u.Scheme {
+		case "mqtt", "tcp":
+		case "mqtts", "ssl", "tls":
+			useTLS = true
+		default:
+			return "", false, fmt.Errorf("mqtt: unsupported scheme %q (use mqtt:// or mqtts://)", u.Scheme)
+		}
+		broker = u.Host
+	}
+	if broker == "" {
+		return "", false, errors.New("mqtt: broker address is empty")
+	}
+	if _, _, err := net.SplitHostPort(broker); err != nil {
+		port := "1883"
+		if useTLS {
+			port = "8883"
+		}
+		broker = net.JoinHostPort(broker, port)
+	}
+	return broker, useTLS, nil
+}
+
+func (c *Client) connect(opts Options) error {
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+	flags := byte(0x02)    // clean session
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, 60) // keep alive, seconds
+	body = appendString(body, opts.ClientID)
+	if opts.Username != "" {
+		body = appendString(body, opts.Username)
+		if opts.Password != "" {
+			body = appendString(body, opts.Password)
+		}
+	}
+	if err := c.write(typeConnect<<4, body); err != nil {
+		return err
+	}
+
+	typ, resp, err := c.read()
+	if err != nil {
+		return err
+	}
+	if typ != typeConnack || len(resp) != 2 {
+		return fmt.Errorf("mqtt: expected CONNACK, got packet type %d", typ)
+	}
+	if resp[1] != 0 {
+		msg := connackErrors[resp[1]]
+		if msg == "" {
+			msg = fmt.Sprintf("return code %d", resp[1])
+		}
+		return fmt.Errorf("mqtt: connection refused: %s", msg)
+	}
+	return nil
+}
+
+// Publish sends payload to topic with QoS 1 and waits for the broker to
+// acknowledge it. Retained messages are kept by the broker for clients
+// that subscribe later.
+func (c *Client) Publish(topic string, payload []byte, retain bool) error {
+	id := c.nextID
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+
+	header := byte(typePublish<<4 | 0x02) // QoS 1
+	if retain {
+		header |= 0x01
+	}
+	body := appendString(nil, topic)
+	body = binary.BigEndian.AppendUint16(body, id)
+	body = append(body, payload...)
+	if err := c.write(header, body); err != nil {
+		return err
+	}
+
+	typ, resp, err := c.read()
+	if err != nil {
+		return err
+	}
+	if typ != typePuback || len(resp) != 2 || binary.BigEndian.Uint16(resp) != id {
+		return fmt.Errorf("mqtt: expected PUBACK for %s", topic)
+	}
+	return nil
+}
+
+// Close disconnects cleanly and closes the connection.
+func (c *Client) Close() error {
+	werr := c.write(typeDisconnect<<4, nil)
+	cerr := c.conn.Close()
+	return errors.Join(werr, cerr)
+}
+
+func (c *Client) write(header byte, body []byte) error {
+	packet := []byte{header}
+	packet = appendLength(packet, len(body))
+	packet = append(packet, body...)
+	_ = c.conn.SetWriteDeadline(time.Now().Add(Timeout))
+	if _, err := c.conn.Write(packet); err != nil {
+		return fmt.Errorf("mqtt: %w", err)
+	}
+	return nil
+}
+
+// read returns the type and body of the next packet.
+func (c *Client) read() (byte, []byte, error) {
+	_ = c.conn.SetReadDeadline(time.Now().Add(Timeout))
+	typ, body, err := readPacket(c.r)
+	if err != nil {
+		return 0, nil, fmt.Errorf("mqtt: %w", err)
+	}
+	return typ, body, nil
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, mult := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * mult
+		if b&0x80 == 0 {
+			break
+		}
+		mult *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// appendLength encodes the variable-length remaining length field.
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// appendString encodes a length-prefixed UTF-8 string.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
@@ -0,0 +1,127 @@
+// ABOUTME: Tests for the MQTT publisher against a fake broker on a local listener.
+// ABOUTME: Checks the CONNECT fields, QoS 1 publishes, retain flags, and refused logins.
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+)
+
+type published struct {
+	topic   string
+	payload string
+	retain  bool
+}
+
+// fakeBroker accepts one connection, answers CONNACK with code, and
+// acknowledges publishes until DISCONNECT.
+func fakeBroker(t *testing.T, code byte) (addr string, connect chan []byte, got chan []published) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	connect = make(chan []byte, 1)
+	got = make(chan []published, 1)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var msgs []published
+		defer func() { got <- msgs }()
+		for {
+			header, err := r.Peek(1)
+			if err != nil {
+				return
+			}
+			flags := header[0] & 0x0f
+			typ, body, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			switch typ {
+			case typeConnect:
+				connect <- body
+				conn.Write([]byte{typeConnack << 4, 2, 0, code})
+			case typePublish:
+				n := int(binary.BigEndian.Uint16(body))
+				topic := string(body[2 : 2+n])
+				id := body[2+n : 4+n]
+				msgs = append(msgs, published{topic, string(body[4+n:]), flags&0x01 != 0})
+				conn.Write([]byte{typePuback << 4, 2, id[0], id[1]})
+			case typeDisconnect:
+				return
+			}
+		}
+	}()
+	return ln.Addr().String(), connect, got
+}
+
+func TestPublish(t *testing.T) {
+	addr, connect, got := fakeBroker(t, 0)
+
+	c, err := Dial(t.Context(), "mqtt://"+addr, Options{ClientID: "health-test", Username: "ha", Password: "pw"})
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	body := <-connect
+	if !strings.HasPrefix(string(body), "\x00\x04MQTT\x04") || body[7]&0xc2 != 0xc2 {
+		t.Errorf("CONNECT header = %q", body[:10])
+	}
+	if !strings.Contains(string(body), "health-test") || !strings.HasSuffix(string(body), "\x00\x02ha\x00\x02pw") {
+		t.Errorf("CONNECT payload = %q", body[10:])
+	}
+
+	long := strings.Repeat("x", 300) // needs a two-byte remaining length
+	if err := c.Publish("health/weight/state", []byte(long), true); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := c.Publish("health/steps/state", []byte("8000"), false); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	msgs := <-got
+	want := []published{{"health/weight/state", long, true}, {"health/steps/state", "8000", false}}
+	if len(msgs) != 2 || msgs[0] != want[0] || msgs[1] != want[1] {
+		t.Errorf("broker received %+v", msgs)
+	}
+}
+
+func TestDialRefused(t *testing.T) {
+	addr, _, _ := fakeBroker(t, 4)
+	_, err := Dial(t.Context(), addr, Options{ClientID: "health-test"})
+	if err == nil || !strings.Contains(err.Error(), "bad user name or password") {
+		t.Errorf("expected a refused login, got %v", err)
+	}
+}
+
+func TestParseBroker(t *testing.T) {
+	for in, want := range map[string]string{
+		"localhost":             "localhost:1883",
+		"10.0.0.5:1884":         "10.0.0.5:1884",
+		"mqtt://ha.local":       "ha.local:1883",
+		"mqtts://broker.hivemq": "broker.hivemq:8883",
+	} {
+		addr, _, err := parseBroker(in)
+		if err != nil || addr != want {
+			t.Errorf("parseBroker(%q) = %q, %v; want %q", in, addr, err, want)
+		}
+	}
+	if _, useTLS, _ := parseBroker("mqtts://h"); !useTLS {
+		t.Error("mqtts:// should use TLS")
+	}
+	if _, _, err := parseBroker("http://h"); err == nil {
+		t.Error("expected http:// to be refused")
+	}
+}
@@ -0,0 +1,108 @@
+// ABOUTME: Reminder delivery over the network: ntfy push notifications and SMTP email.
+// ABOUTME: Desktop notifications stay in the CLI since they shell out to the platform notifier.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ntfy publishes to one ntfy topic, such as https://ntfy.sh/my-health.
+type Ntfy struct {
+	URL        string
+	Token      string // optional access token for protected topics
+	HTTPClient *http.Client
+}
+
+// NewNtfy creates an Ntfy publisher for the topic at url.
+func NewNtfy(url, token string) *Ntfy {
+	return &Ntfy{
+		URL:        url,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Send publishes message with the given title.
+func (n *Ntfy) Send(ctx context.Context, title, message string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	req.Header.Set("Tags", "alarm_clock")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	start := time.Now()
+	resp, err := n.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ntfy: %w", err)
+	}
+	defer resp.Body.Close()
+	slog.Debug("ntfy publish", "url", n.URL, "status", resp.StatusCode, "duration", time.Since(start))
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("ntfy: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Email sends plain-text mail through an SMTP server. Authentication is
+// used when Username is set; net/smtp only sends it over TLS or to
+// localhost.
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Send mails body with the given subject to every recipient.
+func (e *Email) Send(subject, body string) error {
+	if e.Host == "" || e.From == "" || len(e.To) == 0 {
+		return fmt.Errorf("email: smtp host, from, and to are required")
+	}
+	port := e.Port
+	if port == 0 {
+		port = 587
+	}
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		auth = smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	}
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(port))
+	if err := smtp.SendMail(addr, auth, e.From, e.To, message(e.From, e.To, subject, body, time.Now())); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	slog.Debug("email sent", "server", addr, "to", len(e.To))
+	return nil
+}
+
+// message builds an RFC 5322 message with CRLF line endings.
+func message(from string, to []string, subject, body string, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	b.WriteString("Subject: " + strings.ReplaceAll(subject, "\n", " ") + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,63 @@
+// ABOUTME: Tests for ntfy publishing and email message construction.
+// ABOUTME: Uses an httptest server in place of ntfy.sh.
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNtfySend(t *testing.T) {
+	var title, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title = r.Header.Get("Title")
+		auth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	if err := NewNtfy(server.URL+"/health", "tk_secret").Send(t.Context(), "health", "log weight"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if title != "health" || body != "log weight" || auth != "Bearer tk_secret" {
+		t.Errorf("got title %q, body %q, auth %q", title, body, auth)
+	}
+}
+
+func TestNtfySendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "topic is reserved", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := NewNtfy(server.URL, "").Send(t.Context(), "health", "log weight")
+	if err == nil || !strings.Contains(err.Error(), "topic is reserved") {
+		t.Errorf("Send error = %v, want the server's message", err)
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	date := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	msg := string(message("health@example.com", []string{"me@example.com", "you@example.com"}, "log\nweight", "line one\nline two", date))
+
+	for _, want := range []string{
+		"From: health@example.com\r\n",
+		"To: me@example.com, you@example.com\r\n",
+		"Subject: log weight\r\n",
+		"Date: Sat, 14 Dec 2024 08:00:00 +0000\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+
+	if err := (&Email{}).Send("s", "b"); err == nil {
+		t.Error("expected error for unconfigured email")
+	}
+}
@@ -0,0 +1,237 @@
+// ABOUTME: Client for the Oura API v2: daily readiness, temperature deviation, and sleep.
+// ABOUTME: Converts responses into metrics (HRV, sleep stages) and sleep sessions.
+package oura
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// DefaultBaseURL is the Oura API.
+const DefaultBaseURL = "https://api.ouraring.com"
+
+// Client reads one user's data with a personal access token or OAuth
+// access token.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a Client for the Oura API.
+func NewClient(token string) *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Day is what Oura reports for one day. Every field is optional; a day
+// without a main sleep has no Sleep.
+type Day struct {
+	Date          string
+	Readiness     *float64
+	TempDeviation *float64
+	Sleep         *Sleep
+}
+
+// Sleep is the main sleep of a day, which Oura files under the day the
+// sleeper woke up.
+type Sleep struct {
+	BedTime  time.Time
+	WakeTime time.Time
+	HRV      *float64 // average over the night, ms
+	Deep     float64  // hours
+	REM      float64  // hours
+	Light    float64  // hours
+}
+
+// Fetch pulls the days from through to (inclusive), in date order.
+// Times are converted to from's location.
+func (c *Client) Fetch(ctx context.Context, from, to time.Time) ([]*Day, error) {
+	loc := from.Location()
+	first, last := from.Format("2006-01-02"), to.Format("2006-01-02")
+	days := map[string]*Day{}
+	day := func(date string) *Day {
+		if days[date] == nil {
+			days[date] = &Day{Date: date}
+		}
+		return days[date]
+	}
+
+	var readiness []struct {
+		Day                  string   `json:"day"`
+		Score                *float64 `json:"score"`
+		TemperatureDeviation *float64 `json:"temperature_deviation"`
+	}
+	if err := c.list(ctx, "/v2/usercollection/daily_readiness", first, last, &readiness); err != nil {
+		return nil, err
+	}
+	for _, r := range readiness {
+		d := day(r.Day)
+		d.Readiness, d.TempDeviation = r.Score, r.TemperatureDeviation
+	}
+
+	var sleep []struct {
+		Day                string   `json:"day"`
+		Type               string   `json:"type"`
+		BedtimeStart       string   `json:"bedtime_start"`
+		BedtimeEnd         string   `json:"bedtime_end"`
+		AverageHRV         *float64 `json:"average_hrv"`
+		DeepSleepDuration  float64  `json:"deep_sleep_duration"`
+		REMSleepDuration   float64  `json:"rem_sleep_duration"`
+		LightSleepDuration float64  `json:"light_sleep_duration"`
+	}
+	if err := c.list(ctx, "/v2/usercollection/sleep", first, last, &sleep); err != nil {
+		return nil, err
+	}
+	for _, s := range sleep {
+		if s.Type != "long_sleep" { // naps and rests
+			continue
+		}
+		bed, err1 := time.Parse(time.RFC3339, s.BedtimeStart)
+		wake, err2 := time.Parse(time.RFC3339, s.BedtimeEnd)
+		if err1 != nil || err2 != nil || !wake.After(bed) {
+			continue
+		}
+		d := day(s.Day)
+		if d.Sleep != nil && d.Sleep.WakeTime.After(wake) {
+			continue // keep the last of split nights
+		}
+		d.Sleep = &Sleep{
+			BedTime:  bed.In(loc),
+			WakeTime: wake.In(loc),
+			HRV:      s.AverageHRV,
+			Deep:     s.DeepSleepDuration / 3600,
+			REM:      s.REMSleepDuration / 3600,
+			Light:    s.LightSleepDuration / 3600,
+		}
+	}
+
+	out := make([]*Day, 0, len(days))
+	for date, d := range days {
+		if date >= first && date <= last {
+			out = append(out, d)
+		}
+	}
+	slices.SortFunc(out, func(a, b *Day) int { return strings.Compare(a.Date, b.Date) })
+	return out, nil
+}
+
+// Metrics converts a day into metrics noted with Source. Readiness and
+// temperature deviation are recorded at wake time when the day has a main
+// sleep and at 23:59 otherwise; HRV and sleep stages at wake time. The
+// sleep session itself is left to the caller.
+func (d *Day) Metrics(loc *time.Location) []*models.Metric {
+	date, err := time.ParseInLocation("2006-01-02", d.Date, loc)
+	if err != nil {
+		return nil
+	}
+	at := date.Add(23*time.Hour + 59*time.Minute)
+	if d.Sleep != nil {
+		at = d.Sleep.WakeTime
+	}
+
+	var metrics []*models.Metric
+	add := func(mt models.MetricType, v float64) {
+		metrics = append(metrics, models.NewMetric(mt, v).WithRecordedAt(at).WithSource(models.SourceOura))
+	}
+	if d.Readiness != nil {
+		add(models.MetricReadiness, *d.Readiness)
+	}
+	if d.TempDeviation != nil {
+		add(models.MetricTempDev, *d.TempDeviation)
+	}
+	if s := d.Sleep; s != nil {
+		if s.HRV != nil {
+			add(models.MetricHRV, *s.HRV)
+		}
+		if s.Deep+s.REM+s.Light > 0 {
+			add(models.MetricDeepSleep, s.Deep)
+			add(models.MetricREMSleep, s.REM)
+			add(models.MetricLightSleep, s.Light)
+		}
+	}
+	return metrics
+}
+
+// list reads every page of a collection endpoint into out, which must
+// point to a slice.
+func (c *Client) list(ctx context.Context, path, first, last string, out any) error {
+	// end_date is exclusive on some endpoints; ask for a day more and
+	// let Fetch drop what falls outside the range
+	end, _ := time.Parse("2006-01-02", last)
+	q := url.Values{
+		"start_date": {first},
+		"end_date":   {end.AddDate(0, 0, 1).Format("2006-01-02")},
+	}
+
+	var all []json.RawMessage
+	for {
+		var page struct {
+			Data      []json.RawMessage `json:"data"`
+			NextToken string            `json:"next_token"`
+		}
+		if err := c.get(ctx, path+"?"+q.Encode(), &page); err != nil {
+			return err
+		}
+		all = append(all, page.Data...)
+		if page.NextToken == "" {
+			break
+		}
+		q.Set("next_token", page.NextToken)
+	}
+
+	raw, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("oura: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("oura: %w", err)
+	}
+	defer resp.Body.Close()
+	slog.DebugContext(ctx, "oura get", "path", path, "status", resp.StatusCode, "duration", time.Since(start))
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("oura: token rejected (expired or revoked?)")
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("oura: access denied (is the Oura membership active?)")
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("oura: rate limited, try again in %s seconds", cmp.Or(resp.Header.Get("Retry-After"), "a few"))
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("oura: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("oura: decode %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// ABOUTME: Tests for the Oura client against canned API v2 responses.
+// ABOUTME: Checks pagination, the day mapping to metrics, and error reporting.
+package oura
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// fakeOura serves readiness in two pages and one night of sleep plus a nap.
+func fakeOura(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		if q.Get("start_date") != "2025-03-01" || q.Get("end_date") != "2025-03-03" {
+			t.Errorf("range = %s", r.URL.RawQuery)
+		}
+		switch {
+		case r.URL.Path == "/v2/usercollection/daily_readiness" && q.Get("next_token") == "":
+			w.Write([]byte(`{"data":[{"day":"2025-03-01","score":81,"temperature_deviation":-0.12}],"next_token":"p2"}`))
+		case r.URL.Path == "/v2/usercollection/daily_readiness":
+			w.Write([]byte(`{"data":[{"day":"2025-03-02","score":74,"temperature_deviation":0.3},
+				{"day":"2025-03-03","score":90}],"next_token":null}`))
+		case r.URL.Path == "/v2/usercollection/sleep":
+			w.Write([]byte(`{"data":[
+				{"day":"2025-03-02","type":"long_sleep","bedtime_start":"2025-03-01T23:05:00-06:00","bedtime_end":"2025-03-02T06:50:00-06:00",
+				 "average_hrv":48,"deep_sleep_duration":5400,"rem_sleep_duration":6300,"light_sleep_duration":12600},
+				{"day":"2025-03-02","type":"late_nap","bedtime_start":"2025-03-02T14:00:00-06:00","bedtime_end":"2025-03-02T14:40:00-06:00"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	srv := fakeOura(t)
+	c := NewClient("tok")
+	c.BaseURL = srv.URL
+
+	loc := time.FixedZone("CST", -6*3600)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, loc)
+	days, err := c.Fetch(t.Context(), from, from.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if len(days) != 2 || days[0].Date != "2025-03-01" || days[1].Date != "2025-03-02" {
+		t.Fatalf("days = %+v, want 2025-03-01 and 02 in order", days)
+	}
+	if days[0].Sleep != nil || *days[0].Readiness != 81 {
+		t.Errorf("day 1 = %+v", days[0])
+	}
+
+	s := days[1].Sleep
+	if s == nil || !s.WakeTime.Equal(time.Date(2025, 3, 2, 6, 50, 0, 0, loc)) || s.WakeTime.Location() != loc {
+		t.Fatalf("sleep = %+v, want the main sleep ending 06:50 CST", s)
+	}
+
+	got := map[models.MetricType]*models.Metric{}
+	for _, m := range days[1].Metrics(loc) {
+		got[m.MetricType] = m
+	}
+	want := map[models.MetricType]float64{
+		models.MetricReadiness:  74,
+		models.MetricTempDev:    0.3,
+		models.MetricHRV:        48,
+		models.MetricDeepSleep:  1.5,
+		models.MetricREMSleep:   1.75,
+		models.MetricLightSleep: 3.5,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d metrics, want %d", len(got), len(want))
+	}
+	for mt, v := range want {
+		m := got[mt]
+		if m == nil || m.Value != v || !m.RecordedAt.Equal(s.WakeTime) || m.Source != models.SourceOura || m.Notes != nil {
+			t.Errorf("%s = %+v, want %v at wake time", mt, m, v)
+		}
+	}
+
+	// Without a main sleep, daily values fall at the end of the day
+	m := days[0].Metrics(loc)
+	if len(m) != 2 || !m[0].RecordedAt.Equal(time.Date(2025, 3, 1, 23, 59, 0, 0, loc)) {
+		t.Errorf("day 1 metrics = %+v", m)
+	}
+}
+
+func TestFetchErrors(t *testing.T) {
+	srv := fakeOura(t)
+	c := NewClient("wrong")
+	c.BaseURL = srv.URL
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := c.Fetch(t.Context(), from, from.AddDate(0, 0, 1)); err == nil || !strings.Contains(err.Error(), "token rejected") {
+		t.Errorf("expected a rejected token, got %v", err)
+	}
+}
@@ -0,0 +1,329 @@
+// ABOUTME: Minimal Parquet writer for flat tables: one row group, PLAIN encoding, no compression.
+// ABOUTME: Enough for DuckDB, pandas, and Spark to read exports without pulling in a Thrift stack.
+package parquet
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// magic opens and closes every Parquet file.
+const magic = "PAR1"
+
+// Type is a column's logical type.
+type Type int
+
+const (
+	String    Type = iota // UTF-8 text; Go string
+	Double                // float64
+	Int64                 // int64
+	Bool                  // bool
+	Timestamp             // time.Time, stored as UTC milliseconds
+)
+
+func (t Type) String() string {
+	switch t {
+	case String:
+		return "string"
+	case Double:
+		return "double"
+	case Int64:
+		return "int64"
+	case Bool:
+		return "bool"
+	case Timestamp:
+		return "timestamp"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Field is one column of a table. Optional columns accept nil values.
+type Field struct {
+	Name     string
+	Type     Type
+	Optional bool
+}
+
+// Table is a flat table to write. Each row holds one value per field, in
+// field order. Metadata lands in the file footer as key/value pairs.
+type Table struct {
+	Fields   []Field
+	Rows     [][]any
+	Metadata map[string]string
+}
+
+// Physical types, repetitions, converted types, and encodings from the
+// Parquet format spec.
+const (
+	physBoolean   = 0
+	physInt64     = 2
+	physDouble    = 5
+	physByteArray = 6
+
+	repRequired = 0
+	repOptional = 1
+
+	convUTF8            = 0
+	convTimestampMillis = 9
+
+	encPlain = 0
+	encRLE   = 3
+
+	pageData = 0
+)
+
+// Write writes t to w as a Parquet file.
+func Write(w io.Writer, t *Table) error {
+	for i, row := range t.Rows {
+		if len(row) != len(t.Fields) {
+			return fmt.Errorf("row %d has %d values, want %d", i, len(row), len(t.Fields))
+		}
+	}
+
+	out := []byte(magic)
+	chunks := make([]columnChunk, len(t.Fields))
+	for i, f := range t.Fields {
+		page, err := encodeColumn(f, t.Rows, i)
+		if err != nil {
+			return err
+		}
+		header := pageHeader(len(page), len(t.Rows))
+		chunks[i] = columnChunk{
+			field:  f,
+			offset: int64(len(out)),
+			size:   int64(len(header) + len(page)),
+			values: int64(len(t.Rows)),
+		}
+		out = append(out, header...)
+		out = append(out, page...)
+	}
+
+	meta := fileMetadata(t, chunks)
+	out = append(out, meta...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(meta)))
+	out = append(out, magic...)
+
+	if _, err := w.Write(out); err != nil {
+		return fmt.Errorf("write parquet: %w", err)
+	}
+	return nil
+}
+
+// columnChunk records where a column's single data page landed.
+type columnChunk struct {
+	field  Field
+	offset int64
+	size   int64
+	values int64
+}
+
+// encodeColumn builds column col's data page body: definition levels for
+// optional columns, then the non-null values.
+func encodeColumn(f Field, rows [][]any, col int) ([]byte, error) {
+	var (
+		page    []byte
+		defined = make([]bool, len(rows))
+		bits    []bool
+	)
+	for i, row := range rows {
+		v := row[col]
+		if v == nil {
+			if !f.Optional {
+				return nil, fmt.Errorf("column %s: nil in required column (row %d)", f.Name, i)
+			}
+			continue
+		}
+		defined[i] = true
+
+		switch f.Type {
+		case String:
+			s, ok := v.(string)
+			if !ok {
+				return nil, typeError(f, v)
+			}
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(s)))
+			page = append(page, s...)
+		case Double:
+			x, ok := v.(float64)
+			if !ok {
+				return nil, typeError(f, v)
+			}
+			page = binary.LittleEndian.AppendUint64(page, math.Float64bits(x))
+		case Int64:
+			x, ok := v.(int64)
+			if !ok {
+				return nil, typeError(f, v)
+			}
+			page = binary.LittleEndian.AppendUint64(page, uint64(x))
+		case Timestamp:
+			x, ok := v.(time.Time)
+			if !ok {
+				return nil, typeError(f, v)
+			}
+			page = binary.LittleEndian.AppendUint64(page, uint64(x.UnixMilli()))
+		case Bool:
+			x, ok := v.(bool)
+			if !ok {
+				return nil, typeError(f, v)
+			}
+			bits = append(bits, x)
+		default:
+			return nil, fmt.Errorf("column %s: unsupported type %v", f.Name, f.Type)
+		}
+	}
+	if f.Type == Bool {
+		page = packBits(bits)
+	}
+
+	if !f.Optional {
+		return page, nil
+	}
+	levels := rleBits(defined)
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	body = append(body, levels...)
+	return append(body, page...), nil
+}
+
+func typeError(f Field, v any) error {
+	return fmt.Errorf("column %s: want %v, got %T", f.Name, f.Type, v)
+}
+
+// packBits is PLAIN boolean encoding: one bit per value, LSB first.
+func packBits(bits []bool) []byte {
+	out := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			out[i/8] |= 1 << (i % 8)
+		}
+	}
+	return out
+}
+
+// rleBits encodes 0/1 levels as runs in the RLE/bit-packing hybrid with
+// a bit width of 1. Levels come in long runs (most values are present),
+// so plain runs are compact enough.
+func rleBits(levels []bool) []byte {
+	var out []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		if levels[i] {
+			out = append(out, 1)
+		} else {
+			out = append(out, 0)
+		}
+		i = j
+	}
+	return out
+}
+
+// pageHeader encodes a PageHeader for an uncompressed v1 data page.
+func pageHeader(size, values int) []byte {
+	var c compact
+	c.i32(1, pageData)
+	c.i32(2, int32(size))
+	c.i32(3, int32(size))
+	c.begin(5) // DataPageHeader
+	c.i32(1, int32(values))
+	c.i32(2, encPlain)
+	c.i32(3, encRLE)
+	c.i32(4, encRLE)
+	c.end()
+	c.stop()
+	return c.buf
+}
+
+// fileMetadata encodes the FileMetaData footer.
+func fileMetadata(t *Table, chunks []columnChunk) []byte {
+	var c compact
+	c.i32(1, 1) // version
+
+	c.list(2, ctStruct, len(t.Fields)+1)
+	c.push() // root
+	c.str(4, "schema")
+	c.i32(5, int32(len(t.Fields)))
+	c.end()
+	for _, f := range t.Fields {
+		phys, conv := physicalType(f.Type)
+		c.push()
+		c.i32(1, phys)
+		if f.Optional {
+			c.i32(3, repOptional)
+		} else {
+			c.i32(3, repRequired)
+		}
+		c.str(4, f.Name)
+		if conv >= 0 {
+			c.i32(6, conv)
+		}
+		c.end()
+	}
+
+	c.i64(3, int64(len(t.Rows)))
+
+	c.list(4, ctStruct, 1)
+	c.push() // RowGroup
+	c.list(1, ctStruct, len(chunks))
+	var total int64
+	for _, ch := range chunks {
+		phys, _ := physicalType(ch.field.Type)
+		c.push() // ColumnChunk
+		c.i64(2, ch.offset)
+		c.begin(3) // ColumnMetaData
+		c.i32(1, phys)
+		c.list(2, ctI32, 2)
+		c.zigzag(encPlain)
+		c.zigzag(encRLE)
+		c.list(3, ctBinary, 1)
+		c.binary(ch.field.Name)
+		c.i32(4, 0) // uncompressed
+		c.i64(5, ch.values)
+		c.i64(6, ch.size)
+		c.i64(7, ch.size)
+		c.i64(9, ch.offset)
+		c.end()
+		c.end()
+		total += ch.size
+	}
+	c.i64(2, total)
+	c.i64(3, int64(len(t.Rows)))
+	c.end()
+
+	if len(t.Metadata) > 0 {
+		keys := sortedKeys(t.Metadata)
+		c.list(5, ctStruct, len(keys))
+		for _, k := range keys {
+			c.push()
+			c.str(1, k)
+			c.str(2, t.Metadata[k])
+			c.end()
+		}
+	}
+	c.str(6, "health")
+	c.stop()
+	return c.buf
+}
+
+// physicalType maps a Type to its physical and converted types; -1 means
+// no converted type.
+func physicalType(t Type) (int32, int32) {
+	switch t {
+	case String:
+		return physByteArray, convUTF8
+	case Double:
+		return physDouble, -1
+	case Int64:
+		return physInt64, -1
+	case Bool:
+		return physBoolean, -1
+	case Timestamp:
+		return physInt64, convTimestampMillis
+	}
+	return physByteArray, -1
+}
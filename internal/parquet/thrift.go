@@ -0,0 +1,107 @@
+// ABOUTME: Thrift compact protocol encoder for Parquet page headers and file metadata.
+// ABOUTME: Covers only the field types the writer uses: i32, i64, binary, list, struct.
+package parquet
+
+import (
+	"encoding/binary"
+	"sort"
+)
+
+// Compact protocol field and element types.
+const (
+	ctI32    = 5
+	ctI64    = 6
+	ctBinary = 8
+	ctList   = 9
+	ctStruct = 12
+)
+
+// compact builds a Thrift compact-protocol message. Field IDs are written
+// as deltas from the previous field in the same struct, so nested structs
+// save and restore the last ID.
+type compact struct {
+	buf   []byte
+	last  int16
+	stack []int16
+}
+
+func (c *compact) uvarint(v uint64) {
+	c.buf = binary.AppendUvarint(c.buf, v)
+}
+
+func (c *compact) zigzag(v int64) {
+	c.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (c *compact) binary(s string) {
+	c.uvarint(uint64(len(s)))
+	c.buf = append(c.buf, s...)
+}
+
+func (c *compact) field(id int16, typ byte) {
+	if d := id - c.last; d > 0 && d <= 15 {
+		c.buf = append(c.buf, byte(d)<<4|typ)
+	} else {
+		c.buf = append(c.buf, typ)
+		c.zigzag(int64(id))
+	}
+	c.last = id
+}
+
+func (c *compact) i32(id int16, v int32) {
+	c.field(id, ctI32)
+	c.zigzag(int64(v))
+}
+
+func (c *compact) i64(id int16, v int64) {
+	c.field(id, ctI64)
+	c.zigzag(v)
+}
+
+func (c *compact) str(id int16, s string) {
+	c.field(id, ctBinary)
+	c.binary(s)
+}
+
+// list writes a list field header; the caller writes n elements after it.
+func (c *compact) list(id int16, elem byte, n int) {
+	c.field(id, ctList)
+	if n < 15 {
+		c.buf = append(c.buf, byte(n)<<4|elem)
+	} else {
+		c.buf = append(c.buf, 0xf0|elem)
+		c.uvarint(uint64(n))
+	}
+}
+
+// begin opens a struct-valued field; end closes it.
+func (c *compact) begin(id int16) {
+	c.field(id, ctStruct)
+	c.push()
+}
+
+// push opens a struct without a field header, as for list elements.
+func (c *compact) push() {
+	c.stack = append(c.stack, c.last)
+	c.last = 0
+}
+
+func (c *compact) end() {
+	c.stop()
+	c.last = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+// stop ends the top-level struct.
+func (c *compact) stop() {
+	c.buf = append(c.buf, 0)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,86 @@
+// ABOUTME: Minimal writer for the Prometheus text exposition format (gauges only).
+// ABOUTME: Avoids the client library for the handful of series 'health prom' serves.
+package prom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the Content-Type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Label is one name="value" pair on a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is one series of a gauge, identified by its labels.
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Gauge is a metric family of gauge samples sharing a name and help text.
+type Gauge struct {
+	Name    string
+	Help    string
+	Samples []Sample
+}
+
+// Write renders gauges in the text exposition format. Gauges without
+// samples are left out, since a family with no series tells a scraper
+// nothing.
+func Write(w io.Writer, gauges []Gauge) error {
+	bw := bufio.NewWriter(w)
+	for _, g := range gauges {
+		if len(g.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n", g.Name, escapeHelp(g.Help))
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", g.Name)
+		for _, s := range g.Samples {
+			bw.WriteString(g.Name)
+			if len(s.Labels) > 0 {
+				bw.WriteByte('{')
+				for i, l := range s.Labels {
+					if i > 0 {
+						bw.WriteByte(',')
+					}
+					fmt.Fprintf(bw, "%s=\"%s\"", l.Name, escapeLabel(l.Value))
+				}
+				bw.WriteByte('}')
+			}
+			bw.WriteByte(' ')
+			bw.WriteString(formatValue(s.Value))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// formatValue writes a float the way Prometheus parses it.
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
@@ -0,0 +1,47 @@
+// ABOUTME: Tests for the Prometheus text exposition writer.
+// ABOUTME: Checks family headers, label escaping, and special float values.
+package prom
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b, []Gauge{
+		{Name: "health_metric_latest", Help: "Latest value.\nPer type.", Samples: []Sample{
+			{Labels: []Label{{"type", "weight"}, {"unit", "kg"}}, Value: 82.5},
+			{Labels: []Label{{"type", `say "hi"\`}}, Value: math.Inf(1)},
+		}},
+		{Name: "health_empty", Help: "Never shown."},
+		{Name: "health_up", Help: "Up.", Samples: []Sample{{Value: 1}}},
+	})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := `# HELP health_metric_latest Latest value.\nPer type.
+# TYPE health_metric_latest gauge
+health_metric_latest{type="weight",unit="kg"} 82.5
+health_metric_latest{type="say \"hi\"\\"} +Inf
+# HELP health_up Up.
+# TYPE health_up gauge
+health_up 1
+`
+	if b.String() != want {
+		t.Errorf("Write output:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for v, want := range map[float64]string{1e21: "1e+21", 0.1: "0.1", -3: "-3", math.Inf(-1): "-Inf"} {
+		if got := formatValue(v); got != want {
+			t.Errorf("formatValue(%v) = %q, want %q", v, got, want)
+		}
+	}
+	if got := formatValue(math.NaN()); got != "NaN" {
+		t.Errorf("formatValue(NaN) = %q", got)
+	}
+}
@@ -0,0 +1,535 @@
+// ABOUTME: Minimal QR code encoder for byte-mode payloads (versions 1-10).
+// ABOUTME: Builds the module matrix and renders it as terminal blocks or SVG.
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Level is the error correction level.
+type Level int
+
+const (
+	// Low recovers about 7% of the symbol and fits the most data.
+	Low Level = iota
+	// Medium recovers about 15% of the symbol.
+	Medium
+)
+
+// MaxVersion is the largest symbol Encode produces (57x57 modules).
+const MaxVersion = 10
+
+// ErrTooLong is returned when the data does not fit in MaxVersion.
+var ErrTooLong = errors.New("qr: data too long")
+
+// blockSpec describes how a version's codewords split into blocks: EC
+// codewords per block, then (block count, data codewords) per group.
+type blockSpec struct {
+	ec     int
+	groups [][2]int
+}
+
+// blockTable is indexed by level then version (ISO/IEC 18004 table 9).
+var blockTable = [2][MaxVersion + 1]blockSpec{
+	Low: {
+		{},
+		{7, [][2]int{{1, 19}}},
+		{10, [][2]int{{1, 34}}},
+		{15, [][2]int{{1, 55}}},
+		{20, [][2]int{{1, 80}}},
+		{26, [][2]int{{1, 108}}},
+		{18, [][2]int{{2, 68}}},
+		{20, [][2]int{{2, 78}}},
+		{24, [][2]int{{2, 97}}},
+		{30, [][2]int{{2, 116}}},
+		{18, [][2]int{{2, 68}, {2, 69}}},
+	},
+	Medium: {
+		{},
+		{10, [][2]int{{1, 16}}},
+		{16, [][2]int{{1, 28}}},
+		{26, [][2]int{{1, 44}}},
+		{18, [][2]int{{2, 32}}},
+		{24, [][2]int{{2, 43}}},
+		{16, [][2]int{{4, 27}}},
+		{18, [][2]int{{4, 31}}},
+		{22, [][2]int{{2, 38}, {2, 39}}},
+		{22, [][2]int{{3, 36}, {2, 37}}},
+		{26, [][2]int{{4, 43}, {1, 44}}},
+	},
+}
+
+// dataCodewords returns how many data codewords a version holds.
+func (b blockSpec) dataCodewords() int {
+	n := 0
+	for _, g := range b.groups {
+		n += g[0] * g[1]
+	}
+	return n
+}
+
+// Code is an encoded QR symbol. Module (x, y) is column x, row y.
+type Code struct {
+	Version int
+	Size    int
+	modules [][]bool
+	fixed   [][]bool
+}
+
+// Dark reports whether the module at column x, row y is dark. Coordinates
+// outside the symbol (the quiet zone) are light.
+func (c *Code) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+		return false
+	}
+	return c.modules[y][x]
+}
+
+// Encode encodes data in byte mode using the smallest version that fits.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level != Low && level != Medium {
+		return nil, fmt.Errorf("qr: unknown level %d", level)
+	}
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= blockTable[level][v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	spec := blockTable[level][version]
+	codewords := interleave(spec, dataBits(data, version, spec.dataCodewords()))
+
+	c := newCode(version)
+	c.drawFunctionPatterns()
+	c.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(level, mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // XOR again to undo
+	}
+	c.applyMask(best)
+	c.drawFormat(level, best)
+	return c, nil
+}
+
+// countBits is the width of the byte-mode character count field.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// dataBits builds the mode, count, payload, terminator, and padding as
+// exactly capacity codewords.
+func dataBits(data []byte, version, capacity int) []byte {
+	var bits []bool
+	appendBits := func(v uint, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, v>>uint(i)&1 == 1)
+		}
+	}
+	appendBits(0x4, 4)
+	appendBits(uint(len(data)), countBits(version))
+	for _, b := range data {
+		appendBits(uint(b), 8)
+	}
+	appendBits(0, min(4, capacity*8-len(bits)))
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+
+	out := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 0x80 >> uint(j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// interleave splits data into blocks, appends error correction to each,
+// and interleaves the result column by column.
+func interleave(spec blockSpec, data []byte) []byte {
+	var blocks, ecBlocks [][]byte
+	divisor := rsDivisor(spec.ec)
+	for _, g := range spec.groups {
+		for i := 0; i < g[0]; i++ {
+			block := data[:g[1]]
+			data = data[g[1]:]
+			blocks = append(blocks, block)
+			ecBlocks = append(ecBlocks, rsRemainder(block, divisor))
+		}
+	}
+
+	var out []byte
+	longest := spec.groups[len(spec.groups)-1][1]
+	for i := 0; i < longest; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ec; i++ {
+		for _, b := range ecBlocks {
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+func newCode(version int) *Code {
+	size := version*4 + 17
+	c := &Code{Version: version, Size: size}
+	c.modules = make([][]bool, size)
+	c.fixed = make([][]bool, size)
+	for y := range c.modules {
+		c.modules[y] = make([]bool, size)
+		c.fixed[y] = make([]bool, size)
+	}
+	return c
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y][x] = dark
+	c.fixed[y][x] = true
+}
+
+// alignmentPositions returns the row/column centers of alignment patterns.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*4 + n*2 + 1) / (n*2 - 2) * 2
+	pos := make([]int, n)
+	pos[0] = 6
+	for i, p := n-1, version*4+10; i > 0; i, p = i-1, p-step {
+		pos[i] = p
+	}
+	return pos
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	for _, f := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := f[0]+dx, f[1]+dy
+				if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				c.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+
+	pos := alignmentPositions(c.Version)
+	last := len(pos) - 1
+	for i := range pos {
+		for j := range pos {
+			// Skip the three that would overlap finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(pos[i]+dx, pos[j]+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas; real bits are drawn after masking
+	c.drawFormat(Medium, 0)
+
+	if c.Version >= 7 {
+		bits := versionBits(c.Version)
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			c.set(a, b, dark)
+			c.set(b, a, dark)
+		}
+	}
+}
+
+// formatBits returns the 15-bit BCH-coded format information.
+func formatBits(level Level, mask int) int {
+	levelBits := map[Level]int{Low: 1, Medium: 0}[level]
+	data := levelBits<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem&0x3FF) ^ 0x5412
+}
+
+// versionBits returns the 18-bit BCH-coded version information.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem&0xFFF
+}
+
+func (c *Code) drawFormat(level Level, mask int) {
+	bits := formatBits(level, mask)
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	// Around the top-left finder
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	// Split between the top-right and bottom-left finders
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true)
+}
+
+// drawCodewords places the codeword bits in the two-column zigzag, from
+// the bottom-right corner, skipping function modules and the timing column.
+func (c *Code) drawCodewords(data []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.fixed[y][x] || i >= len(data)*8 {
+					continue
+				}
+				c.modules[y][x] = data[i>>3]>>uint(7-i&7)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// maskFuncs are the eight data mask conditions; x is the column, y the row.
+var maskFuncs = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+func (c *Code) applyMask(mask int) {
+	f := maskFuncs[mask]
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.fixed[y][x] && f(x, y) {
+				c.modules[y][x] = !c.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four standard rules; lower is easier
+// to scan.
+func (c *Code) penalty() int {
+	n := c.Size
+	total := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return c.modules[x][y]
+		}
+		return c.modules[y][x]
+	}
+	finderA := []bool{true, false, true, true, true, false, true, false, false, false, false}
+	finderB := []bool{false, false, false, false, true, false, true, true, true, false, true}
+
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			// Runs of five or more of the same color
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					total += 3 + run - 5
+				}
+				run = 1
+			}
+
+			// Patterns that look like a finder
+			for x := 0; x+11 <= n; x++ {
+				for _, pat := range [][]bool{finderA, finderB} {
+					match := true
+					for k, want := range pat {
+						if at(x+k, y, transpose) != want {
+							match = false
+							break
+						}
+					}
+					if match {
+						total += 40
+					}
+				}
+			}
+		}
+	}
+
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				v := c.modules[y][x]
+				if c.modules[y][x+1] == v && c.modules[y+1][x] == v && c.modules[y+1][x+1] == v {
+					total += 3
+				}
+			}
+		}
+	}
+	total += abs(dark*100/(n*n)-50) / 5 * 10
+	return total
+}
+
+// gfMul multiplies in GF(256) with the QR polynomial x^8+x^4+x^3+x^2+1.
+func gfMul(a, b byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		hi := z & 0x80
+		z <<= 1
+		if hi != 0 {
+			z ^= 0x1D
+		}
+		if b>>uint(i)&1 == 1 {
+			z ^= a
+		}
+	}
+	return z
+}
+
+// rsDivisor returns the Reed-Solomon generator polynomial of the given
+// degree, highest coefficient first with the leading 1 dropped.
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder returns the error correction codewords for data.
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= gfMul(d, factor)
+		}
+	}
+	return result
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// quiet is the light border scanners need around the symbol, in modules.
+const quiet = 4
+
+// String renders the symbol with Unicode half blocks, two rows per line,
+// dark modules drawn as ink. It reads correctly when printed or shown on a
+// light background.
+func (c *Code) String() string {
+	var b strings.Builder
+	for y := -quiet; y < c.Size+quiet; y += 2 {
+		for x := -quiet; x < c.Size+quiet; x++ {
+			top, bottom := c.Dark(x, y), c.Dark(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// SVGPath returns an SVG path drawing each dark module as a unit square,
+// offset by the quiet zone. Width and height are Size+8 units.
+func (c *Code) SVGPath() string {
+	var b strings.Builder
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if c.modules[y][x] {
+				fmt.Fprintf(&b, "M%d,%dh1v1h-1z", x+quiet, y+quiet)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,292 @@
+// ABOUTME: Tests for the QR encoder against published vectors.
+// ABOUTME: Also decodes generated symbols back to check placement end to end.
+package qr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// "HELLO WORLD" as version 1-Q, from the standard worked example
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236}
+	want := []byte{168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16}
+	got := rsRemainder(data, rsDivisor(len(want)))
+	if !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Medium, 0, 0b101010000010010},
+		{Low, 0, 0b111011111000100},
+		{Low, 7, 0b110100101110110},
+		{Medium, 5, 0b100000011001110},
+	}
+	for _, tt := range tests {
+		if got := formatBits(tt.level, tt.mask); got != tt.want {
+			t.Errorf("formatBits(%d, %d) = %015b, want %015b", tt.level, tt.mask, got, tt.want)
+		}
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("versionBits(7) = %018b", got)
+	}
+}
+
+func TestBlockTableMatchesSymbolSize(t *testing.T) {
+	for v := 1; v <= MaxVersion; v++ {
+		// Modules left after function patterns, per ISO/IEC 18004
+		raw := (16*v+128)*v + 64
+		if v >= 2 {
+			n := v/7 + 2
+			raw -= (25*n-10)*n - 55
+			if v >= 7 {
+				raw -= 36
+			}
+		}
+		for _, level := range []Level{Low, Medium} {
+			spec := blockTable[level][v]
+			total := 0
+			for _, g := range spec.groups {
+				total += g[0] * (g[1] + spec.ec)
+			}
+			if total != raw/8 {
+				t.Errorf("version %d level %d: %d codewords, want %d", v, level, total, raw/8)
+			}
+		}
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	if got := alignmentPositions(2); len(got) != 2 || got[1] != 18 {
+		t.Errorf("version 2 = %v", got)
+	}
+	if got := alignmentPositions(7); len(got) != 3 || got[1] != 22 || got[2] != 38 {
+		t.Errorf("version 7 = %v", got)
+	}
+	if got := alignmentPositions(10); len(got) != 3 || got[1] != 28 || got[2] != 50 {
+		t.Errorf("version 10 = %v", got)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		payload string
+		level   Level
+		version int
+	}{
+		{"hi", Medium, 1},
+		{"https://example.com/emergency", Low, 2},
+		{strings.Repeat("blood type O+ ", 10), Medium, 8},
+		{strings.Repeat("y", 110), Medium, 7},
+		{strings.Repeat("x", 271), Low, 10},
+	}
+	for _, tt := range tests {
+		c, err := Encode([]byte(tt.payload), tt.level)
+		if err != nil {
+			t.Fatalf("Encode(%d bytes): %v", len(tt.payload), err)
+		}
+		if c.Version != tt.version {
+			t.Errorf("Encode(%d bytes) version = %d, want %d", len(tt.payload), c.Version, tt.version)
+		}
+		if got := decode(t, c); got != tt.payload {
+			t.Errorf("decoded %q, want %q", got, tt.payload)
+		}
+	}
+
+	if _, err := Encode([]byte(strings.Repeat("x", 272)), Low); err != ErrTooLong {
+		t.Errorf("oversized payload err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestString(t *testing.T) {
+	c, err := Encode([]byte("hi"), Medium)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(c.String(), "\n"), "\n")
+	// 21 modules plus 4 of quiet zone each side, two rows per line
+	if len(lines) != 15 {
+		t.Errorf("got %d lines, want 15", len(lines))
+	}
+	if n := len([]rune(lines[0])); n != 29 {
+		t.Errorf("line width = %d, want 29", n)
+	}
+	// Top row of the top-left finder is solid ink
+	if !strings.HasPrefix(string([]rune(lines[2])[4:]), "█▀▀▀▀▀█") {
+		t.Errorf("finder row = %q", lines[2])
+	}
+}
+
+// decode reads a symbol back the way a scanner would: format bits from
+// both copies, unmask, walk the zigzag, de-interleave, check every block's
+// syndromes, and parse the byte-mode segment.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+	n := c.Size
+
+	var first, second int
+	firstCoords := [][2]int{
+		{0, 8}, {1, 8}, {2, 8}, {3, 8}, {4, 8}, {5, 8}, {7, 8}, {8, 8},
+		{8, 7}, {8, 5}, {8, 4}, {8, 3}, {8, 2}, {8, 1}, {8, 0},
+	}
+	for _, p := range firstCoords {
+		first <<= 1
+		if c.Dark(p[0], p[1]) {
+			first |= 1
+		}
+	}
+	for i := 0; i < 7; i++ {
+		second <<= 1
+		if c.Dark(8, n-1-i) {
+			second |= 1
+		}
+	}
+	for i := 0; i < 8; i++ {
+		second <<= 1
+		if c.Dark(n-8+i, 8) {
+			second |= 1
+		}
+	}
+	if first != second {
+		t.Fatalf("format copies differ: %015b vs %015b", first, second)
+	}
+	if !c.Dark(8, n-8) {
+		t.Fatal("dark module missing")
+	}
+	info := first ^ 0x5412
+	level := map[int]Level{1: Low, 0: Medium}[info>>13]
+	mask := info >> 10 & 7
+	if formatBits(level, mask) != first {
+		t.Fatalf("format bits %015b fail BCH check", first)
+	}
+
+	// Function modules, from scratch
+	fn := make([][]bool, n)
+	for y := range fn {
+		fn[y] = make([]bool, n)
+	}
+	mark := func(x0, y0, w, h int) {
+		for y := y0; y < y0+h; y++ {
+			for x := x0; x < x0+w; x++ {
+				fn[y][x] = true
+			}
+		}
+	}
+	mark(0, 0, 9, 9)
+	mark(n-8, 0, 8, 9)
+	mark(0, n-8, 9, 8)
+	mark(6, 0, 1, n)
+	mark(0, 6, n, 1)
+	pos := alignmentPositions(c.Version)
+	last := len(pos) - 1
+	for i, ax := range pos {
+		for j, ay := range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			mark(ax-2, ay-2, 5, 5)
+		}
+	}
+	if c.Version >= 7 {
+		mark(n-11, 0, 3, 6)
+		mark(0, n-11, 6, 3)
+	}
+
+	// Zigzag: column pairs right to left, alternating up and down
+	var bits []bool
+	up := true
+	for col := n - 1; col > 0; col -= 2 {
+		if col == 6 {
+			col--
+		}
+		for k := 0; k < n; k++ {
+			y := k
+			if up {
+				y = n - 1 - k
+			}
+			for _, x := range []int{col, col - 1} {
+				if fn[y][x] {
+					continue
+				}
+				bits = append(bits, c.Dark(x, y) != maskFuncs[mask](x, y))
+			}
+		}
+		up = !up
+	}
+	var stream []byte
+	for i := 0; i+8 <= len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << uint(7-j)
+			}
+		}
+		stream = append(stream, b)
+	}
+
+	spec := blockTable[level][c.Version]
+	var blocks [][]byte
+	for _, g := range spec.groups {
+		for i := 0; i < g[0]; i++ {
+			blocks = append(blocks, make([]byte, 0, g[1]+spec.ec))
+		}
+	}
+	idx := 0
+	longest := spec.groups[len(spec.groups)-1][1]
+	for i := 0; i < longest; i++ {
+		for b := range blocks {
+			if i < cap(blocks[b])-spec.ec {
+				blocks[b] = append(blocks[b], stream[idx])
+				idx++
+			}
+		}
+	}
+	for i := 0; i < spec.ec; i++ {
+		for b := range blocks {
+			blocks[b] = append(blocks[b], stream[idx])
+			idx++
+		}
+	}
+
+	var data []byte
+	for bi, block := range blocks {
+		// A valid codeword has every syndrome zero: block(α^i) = 0
+		alpha := byte(1)
+		for i := 0; i < spec.ec; i++ {
+			var s byte
+			for _, cw := range block {
+				s = gfMul(s, alpha) ^ cw
+			}
+			if s != 0 {
+				t.Fatalf("block %d syndrome %d = %d", bi, i, s)
+			}
+			alpha = gfMul(alpha, 2)
+		}
+		data = append(data, block[:len(block)-spec.ec]...)
+	}
+
+	readBits := func(off, n int) int {
+		v := 0
+		for i := off; i < off+n; i++ {
+			v = v<<1 | int(data[i/8]>>uint(7-i%8)&1)
+		}
+		return v
+	}
+	if mode := readBits(0, 4); mode != 4 {
+		t.Fatalf("mode = %d, want byte mode", mode)
+	}
+	cb := countBits(c.Version)
+	length := readBits(4, cb)
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = byte(readBits(4+cb+8*i, 8))
+	}
+	return string(out)
+}
@@ -0,0 +1,116 @@
+// ABOUTME: Public reference ranges (BP categories, resting heart rate, sleep, body fat, BMI) with citations.
+// ABOUTME: Classifies a value into a labelled range, honoring sex and age limits from the profile.
+package reference
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed ranges.yaml
+var rangesYAML []byte
+
+// Source is where a set of ranges comes from.
+type Source struct {
+	ID       string `json:"id"`
+	Citation string `json:"citation"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Range labels values of one metric from Min (inclusive) to Max
+// (exclusive). Sex and the age bounds limit who it applies to.
+type Range struct {
+	Metric string   `yaml:"metric"`
+	Min    *float64 `yaml:"min"`
+	Max    *float64 `yaml:"max"`
+	Label  string   `yaml:"label"`
+	Sex    string   `yaml:"sex"`
+	MinAge int      `yaml:"min_age"`
+	MaxAge int      `yaml:"max_age"` // exclusive
+	Source string   `yaml:"source"`
+}
+
+// Person is what the ranges need to know about the user. Empty Sex or a
+// zero Age mean unknown, and ranges that depend on them are skipped.
+type Person struct {
+	Sex string
+	Age int
+}
+
+// Annotation says which range a value falls in and who says so.
+type Annotation struct {
+	Label  string `json:"label"`
+	Source Source `json:"source"`
+}
+
+type table struct {
+	Sources map[string]Source `yaml:"sources"`
+	Ranges  []Range           `yaml:"ranges"`
+}
+
+var ranges = mustLoad(rangesYAML)
+
+func mustLoad(data []byte) table {
+	var t table
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		panic(fmt.Sprintf("reference ranges: %v", err))
+	}
+	for id, s := range t.Sources {
+		s.ID = id
+		t.Sources[id] = s
+	}
+	for _, r := range t.Ranges {
+		if _, ok := t.Sources[r.Source]; !ok {
+			panic(fmt.Sprintf("reference ranges: %s %q cites unknown source %q", r.Metric, r.Label, r.Source))
+		}
+	}
+	return t
+}
+
+// Classify finds the range value falls in for metric, or nil if there is
+// no reference for it (or none that applies to p).
+func Classify(metric string, value float64, p Person) *Annotation {
+	for _, r := range ranges.Ranges {
+		if r.Metric != metric || !r.appliesTo(p) {
+			continue
+		}
+		if (r.Min != nil && value < *r.Min) || (r.Max != nil && value >= *r.Max) {
+			continue
+		}
+		return &Annotation{Label: r.Label, Source: ranges.Sources[r.Source]}
+	}
+	return nil
+}
+
+// bpCategories orders blood pressure labels from least to most severe.
+var bpCategories = []string{"normal", "elevated", "stage 1 hypertension", "stage 2 hypertension", "hypertensive crisis"}
+
+// ClassifyBP places a blood pressure reading in the higher of the
+// categories its systolic and diastolic values fall in.
+func ClassifyBP(systolic, diastolic float64, p Person) *Annotation {
+	sys := Classify("bp_sys", systolic, p)
+	dia := Classify("bp_dia", diastolic, p)
+	if sys == nil || dia == nil {
+		return nil
+	}
+	if slices.Index(bpCategories, dia.Label) > slices.Index(bpCategories, sys.Label) {
+		return dia
+	}
+	return sys
+}
+
+func (r Range) appliesTo(p Person) bool {
+	if r.Sex != "" && r.Sex != p.Sex {
+		return false
+	}
+	if r.MinAge == 0 && r.MaxAge == 0 {
+		return true
+	}
+	if p.Age == 0 || p.Age < r.MinAge {
+		return false
+	}
+	return r.MaxAge == 0 || p.Age < r.MaxAge
+}
@@ -0,0 +1,62 @@
+// ABOUTME: Tests for reference range classification.
+// ABOUTME: Checks range boundaries, BP combination, and sex- and age-specific ranges.
+package reference
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	adult := Person{Sex: "female", Age: 40}
+	tests := []struct {
+		metric string
+		value  float64
+		p      Person
+		want   string
+	}{
+		{"bp_sys", 119, Person{}, "normal"},
+		{"bp_sys", 120, Person{}, "elevated"},
+		{"bp_sys", 140, Person{}, "stage 2 hypertension"},
+		{"heart_rate", 52, Person{}, "athlete range"},
+		{"heart_rate", 100, Person{}, "typical"},
+		{"heart_rate", 101, Person{}, "above typical"},
+		{"sleep_hours", 6.5, adult, "below recommended"},
+		{"sleep_hours", 9, adult, "recommended"},
+		{"sleep_hours", 8.5, Person{Age: 70}, "above recommended"},
+		{"sleep_hours", 6.5, Person{}, ""}, // age unknown
+		{"body_fat", 19, adult, "athlete range"},
+		{"body_fat", 19, Person{Sex: "male"}, "average"},
+		{"body_fat", 19, Person{}, ""}, // sex unknown
+		{"bmi", 24.9, Person{}, "normal weight"},
+		{"mood", 7, adult, ""},
+	}
+	for _, tt := range tests {
+		got := Classify(tt.metric, tt.value, tt.p)
+		label := ""
+		if got != nil {
+			label = got.Label
+			if got.Source.Citation == "" || got.Source.ID == "" {
+				t.Errorf("%s %v: annotation without a citation: %+v", tt.metric, tt.value, got)
+			}
+		}
+		if label != tt.want {
+			t.Errorf("Classify(%s, %v, %+v) = %q, want %q", tt.metric, tt.value, tt.p, label, tt.want)
+		}
+	}
+}
+
+func TestClassifyBP(t *testing.T) {
+	tests := []struct {
+		sys, dia float64
+		want     string
+	}{
+		{115, 75, "normal"},
+		{125, 75, "elevated"},
+		{125, 85, "stage 1 hypertension"},
+		{118, 92, "stage 2 hypertension"},
+		{190, 100, "hypertensive crisis"},
+	}
+	for _, tt := range tests {
+		if got := ClassifyBP(tt.sys, tt.dia, Person{}); got == nil || got.Label != tt.want {
+			t.Errorf("ClassifyBP(%v/%v) = %+v, want %q", tt.sys, tt.dia, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// ABOUTME: Minimal decoder for Garmin FIT activity files.
+// ABOUTME: Reads positions and altitude from record messages and totals from the session.
+package route
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// FIT global message numbers and the fields read from them.
+const (
+	fitMsgSession = 18
+	fitMsgRecord  = 20
+
+	fitRecordLat       = 0
+	fitRecordLon       = 1
+	fitRecordAltitude  = 2
+	fitRecordEnhAlt    = 78
+	fitSessionDistance = 9
+	fitSessionAscent   = 22
+	fitSessionDescent  = 23
+	fitTimestamp       = 253
+)
+
+// fitEpoch is the zero of FIT timestamps.
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+type fitField struct {
+	num, size byte
+}
+
+type fitDefinition struct {
+	global   uint16
+	order    binary.ByteOrder
+	fields   []fitField
+	devBytes int
+}
+
+// ParseFIT reads the GPS points of a FIT activity file, plus the
+// distance, ascent, and descent its session message reports.
+func ParseFIT(data []byte) (*Route, error) {
+	if len(data) < 12 || string(data[8:12]) != ".FIT" {
+		return nil, fmt.Errorf("parse FIT: not a FIT file")
+	}
+	headerSize := int(data[0])
+	end := headerSize + int(binary.LittleEndian.Uint32(data[4:8]))
+	if headerSize < 12 || end > len(data) {
+		return nil, fmt.Errorf("parse FIT: truncated file")
+	}
+
+	r := &Route{}
+	defs := map[byte]*fitDefinition{}
+	var lastTimestamp uint32
+	buf := bytes.NewReader(data[headerSize:end])
+	for buf.Len() > 0 {
+		h, _ := buf.ReadByte()
+		local := h & 0x0f
+		compressed := h&0x80 != 0
+		if compressed {
+			local = (h >> 5) & 0x03
+		} else if h&0x40 != 0 {
+			def, err := readFITDefinition(buf, h&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			defs[local] = def
+			continue
+		}
+
+		def := defs[local]
+		if def == nil {
+			return nil, fmt.Errorf("parse FIT: data for undefined local message %d", local)
+		}
+		values := map[byte]uint64{}
+		for _, f := range def.fields {
+			b := make([]byte, f.size)
+			if n, _ := buf.Read(b); n < len(b) {
+				return nil, fmt.Errorf("parse FIT: truncated record")
+			}
+			if v, ok := fitUint(b, def.order); ok {
+				values[f.num] = v
+			}
+		}
+		if def.devBytes > buf.Len() {
+			return nil, fmt.Errorf("parse FIT: truncated record")
+		}
+		buf.Seek(int64(def.devBytes), io.SeekCurrent)
+
+		if ts, ok := values[fitTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		} else if compressed {
+			offset := uint32(h & 0x1f)
+			lastTimestamp += (offset - lastTimestamp&0x1f) & 0x1f
+			values[fitTimestamp] = uint64(lastTimestamp)
+		}
+
+		switch def.global {
+		case fitMsgRecord:
+			r.addFITRecord(values)
+		case fitMsgSession:
+			r.addFITSession(values)
+		}
+	}
+	return r, nil
+}
+
+func readFITDefinition(buf *bytes.Reader, hasDevFields bool) (*fitDefinition, error) {
+	head := make([]byte, 5)
+	if n, _ := buf.Read(head); n < 5 {
+		return nil, fmt.Errorf("parse FIT: truncated definition")
+	}
+	def := &fitDefinition{order: binary.LittleEndian}
+	if head[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(head[2:4])
+	fields := make([]byte, 3*int(head[4]))
+	if n, _ := buf.Read(fields); n < len(fields) {
+		return nil, fmt.Errorf("parse FIT: truncated definition")
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fitField{num: fields[i], size: fields[i+1]})
+	}
+	if hasDevFields {
+		n, err := buf.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("parse FIT: truncated definition")
+		}
+		dev := make([]byte, 3*int(n))
+		if n, _ := buf.Read(dev); n < len(dev) {
+			return nil, fmt.Errorf("parse FIT: truncated definition")
+		}
+		for i := 0; i < len(dev); i += 3 {
+			def.devBytes += int(dev[i+1])
+		}
+	}
+	return def, nil
+}
+
+// fitUint decodes an unsigned field of 1, 2, or 4 bytes. It reports
+// false for other sizes (arrays, strings) and for the "invalid" value of
+// all ones.
+func fitUint(b []byte, order binary.ByteOrder) (uint64, bool) {
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), b[0] != 0xff
+	case 2:
+		v := order.Uint16(b)
+		return uint64(v), v != 0xffff
+	case 4:
+		v := order.Uint32(b)
+		return uint64(v), v != 0xffffffff
+	}
+	return 0, false
+}
+
+func (r *Route) addFITRecord(values map[byte]uint64) {
+	lat, okLat := values[fitRecordLat]
+	lon, okLon := values[fitRecordLon]
+	// Positions are signed; 0x7fffffff marks a record without a fix
+	if !okLat || !okLon || lat == math.MaxInt32 || lon == math.MaxInt32 {
+		return
+	}
+	p := Point{
+		Lat: semicircles(lat),
+		Lon: semicircles(lon),
+	}
+	if ts, ok := values[fitTimestamp]; ok {
+		p.Time = fitEpoch.Add(time.Duration(ts) * time.Second)
+	}
+	alt, ok := values[fitRecordEnhAlt]
+	if !ok {
+		alt, ok = values[fitRecordAltitude]
+	}
+	if ok {
+		ele := float64(alt)/5 - 500
+		p.Ele = &ele
+	}
+	r.Points = append(r.Points, p)
+}
+
+func (r *Route) addFITSession(values map[byte]uint64) {
+	if v, ok := values[fitSessionDistance]; ok {
+		d := float64(v) / 100
+		r.Distance = &d
+	}
+	if v, ok := values[fitSessionAscent]; ok {
+		a := float64(v)
+		r.Ascent = &a
+	}
+	if v, ok := values[fitSessionDescent]; ok {
+		d := float64(v)
+		r.Descent = &d
+	}
+}
+
+// semicircles converts a FIT position to degrees.
+func semicircles(v uint64) float64 {
+	return float64(int32(uint32(v))) * 180 / (1 << 31)
+}
@@ -0,0 +1,63 @@
+// ABOUTME: GPX 1.0/1.1 reader taking track and route points with elevation and time.
+// ABOUTME: Track segments are joined in order; waypoints are ignored.
+package route
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+type gpxPoint struct {
+	Lat  float64  `xml:"lat,attr"`
+	Lon  float64  `xml:"lon,attr"`
+	Ele  *float64 `xml:"ele"`
+	Time string   `xml:"time"`
+}
+
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+	Routes []struct {
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+}
+
+// ParseGPX reads the track points of a GPX file, or its route points if
+// it has no tracks.
+func ParseGPX(data []byte) (*Route, error) {
+	var f gpxFile
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse GPX: %w", err)
+	}
+
+	var pts []gpxPoint
+	for _, t := range f.Tracks {
+		for _, seg := range t.Segments {
+			pts = append(pts, seg.Points...)
+		}
+	}
+	if len(pts) == 0 {
+		for _, r := range f.Routes {
+			pts = append(pts, r.Points...)
+		}
+	}
+
+	r := &Route{Points: make([]Point, 0, len(pts))}
+	for _, p := range pts {
+		pt := Point{Lat: p.Lat, Lon: p.Lon, Ele: p.Ele}
+		if p.Time != "" {
+			t, err := time.Parse(time.RFC3339, p.Time)
+			if err != nil {
+				return nil, fmt.Errorf("parse GPX: point time %q: %w", p.Time, err)
+			}
+			pt.Time = t
+		}
+		r.Points = append(r.Points, pt)
+	}
+	return r, nil
+}
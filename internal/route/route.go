@@ -0,0 +1,165 @@
+// ABOUTME: GPS routes recorded during workouts, read from GPX or FIT files.
+// ABOUTME: Summarizes distance, elevation, and bounds, and links to the route on a map.
+package route
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// MetadataKey is the workout metadata key holding the attached route's
+// path, relative to the data directory.
+const MetadataKey = "route"
+
+// Workout metric names written from a route's summary.
+const (
+	DistanceMetric      = "distance"
+	ElevationGainMetric = "elevation_gain"
+)
+
+// Point is one position fix.
+type Point struct {
+	Lat, Lon float64
+	Ele      *float64 // meters, when recorded
+	Time     time.Time
+}
+
+// Route is the track of a workout. Devices that measure distance and
+// climb themselves (FIT files) report them, and Summary prefers those
+// over values worked out from the points.
+type Route struct {
+	Points   []Point
+	Distance *float64 // meters, as recorded by the device
+	Ascent   *float64 // meters
+	Descent  *float64 // meters
+}
+
+// Parse reads a route from a file's contents, choosing the format by the
+// file name's extension.
+func Parse(name string, data []byte) (*Route, error) {
+	var r *Route
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(name)); ext {
+	case ".gpx":
+		r, err = ParseGPX(data)
+	case ".fit":
+		r, err = ParseFIT(data)
+	default:
+		return nil, fmt.Errorf("unsupported route file %q (use .gpx or .fit)", filepath.Base(name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Points) == 0 {
+		return nil, fmt.Errorf("%s has no GPS points", filepath.Base(name))
+	}
+	return r, nil
+}
+
+// Summary describes a route.
+type Summary struct {
+	DistanceKm float64
+	AscentM    float64
+	DescentM   float64
+	Start, End time.Time // zero if the points carry no times
+	Points     int
+
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// elevationThreshold is the climb or drop, in meters, that counts toward
+// ascent and descent. Smaller wiggles are GPS noise.
+const elevationThreshold = 3
+
+// Summary works out a route's distance, climb, time span, and bounds.
+func (r *Route) Summary() *Summary {
+	s := &Summary{Points: len(r.Points)}
+	if len(r.Points) == 0 {
+		return s
+	}
+	first := r.Points[0]
+	s.MinLat, s.MaxLat, s.MinLon, s.MaxLon = first.Lat, first.Lat, first.Lon, first.Lon
+
+	var meters float64
+	var ref *float64
+	for i, p := range r.Points {
+		s.MinLat, s.MaxLat = min(s.MinLat, p.Lat), max(s.MaxLat, p.Lat)
+		s.MinLon, s.MaxLon = min(s.MinLon, p.Lon), max(s.MaxLon, p.Lon)
+		if !p.Time.IsZero() {
+			if s.Start.IsZero() || p.Time.Before(s.Start) {
+				s.Start = p.Time
+			}
+			if p.Time.After(s.End) {
+				s.End = p.Time
+			}
+		}
+		if i > 0 {
+			meters += haversine(r.Points[i-1], p)
+		}
+		if p.Ele == nil {
+			continue
+		}
+		switch {
+		case ref == nil:
+			ref = p.Ele
+		case *p.Ele-*ref >= elevationThreshold:
+			s.AscentM += *p.Ele - *ref
+			ref = p.Ele
+		case *ref-*p.Ele >= elevationThreshold:
+			s.DescentM += *ref - *p.Ele
+			ref = p.Ele
+		}
+	}
+
+	if r.Distance != nil {
+		meters = *r.Distance
+	}
+	if r.Ascent != nil {
+		s.AscentM = *r.Ascent
+	}
+	if r.Descent != nil {
+		s.DescentM = *r.Descent
+	}
+	s.DistanceKm = math.Round(meters) / 1000
+	s.AscentM = math.Round(s.AscentM)
+	s.DescentM = math.Round(s.DescentM)
+	return s
+}
+
+// WorkoutMetrics returns the summary as distance and elevation_gain
+// metrics for a workout. Elevation is left out when the route has none.
+func (s *Summary) WorkoutMetrics(workoutID uuid.UUID) []*models.WorkoutMetric {
+	metrics := []*models.WorkoutMetric{models.NewWorkoutMetric(workoutID, DistanceMetric, s.DistanceKm, "km")}
+	if s.AscentM > 0 || s.DescentM > 0 {
+		metrics = append(metrics, models.NewWorkoutMetric(workoutID, ElevationGainMetric, s.AscentM, "m"))
+	}
+	return metrics
+}
+
+// MapURL links to an OpenStreetMap view centered on the route.
+func (s *Summary) MapURL() string {
+	lat := (s.MinLat + s.MaxLat) / 2
+	lon := (s.MinLon + s.MaxLon) / 2
+	zoom := 15
+	if span := max(s.MaxLat-s.MinLat, s.MaxLon-s.MinLon); span > 0 {
+		zoom = min(max(int(math.Log2(360/span)), 2), 17)
+	}
+	return fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.5f&mlon=%.5f#map=%d/%.5f/%.5f", lat, lon, zoom, lat, lon)
+}
+
+const earthRadius = 6371008.8 // meters
+
+// haversine returns the great-circle distance between two points in meters.
+func haversine(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,139 @@
+// ABOUTME: Tests for route parsing and summaries.
+// ABOUTME: Uses a small GPX track and a FIT file assembled in the test.
+package route
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleGPX = `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1">
+  <wpt lat="0" lon="0"><name>ignored</name></wpt>
+  <trk><name>Morning Ride</name>
+    <trkseg>
+      <trkpt lat="41.8800" lon="-87.6300"><ele>180.0</ele><time>2025-04-12T13:00:00Z</time></trkpt>
+      <trkpt lat="41.8890" lon="-87.6300"><ele>181.5</ele><time>2025-04-12T13:03:00Z</time></trkpt>
+      <trkpt lat="41.8980" lon="-87.6300"><ele>190.0</ele><time>2025-04-12T13:06:00Z</time></trkpt>
+    </trkseg>
+    <trkseg>
+      <trkpt lat="41.9070" lon="-87.6300"><ele>184.0</ele><time>2025-04-12T13:09:00Z</time></trkpt>
+    </trkseg>
+  </trk>
+</gpx>`
+
+func TestParseGPX(t *testing.T) {
+	r, err := Parse("ride.GPX", []byte(sampleGPX))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(r.Points) != 4 || *r.Points[3].Ele != 184 {
+		t.Fatalf("points = %+v, want both segments joined", r.Points)
+	}
+
+	s := r.Summary()
+	// 0.027° of latitude is about 3.0 km
+	if math.Abs(s.DistanceKm-3.002) > 0.01 {
+		t.Errorf("DistanceKm = %v, want about 3.0", s.DistanceKm)
+	}
+	// The 1.5 m wiggle is noise; 180 → 190 is the climb, 190 → 184 the drop
+	if s.AscentM != 10 || s.DescentM != 6 {
+		t.Errorf("ascent/descent = %v/%v, want 10/6", s.AscentM, s.DescentM)
+	}
+	if !s.Start.Equal(time.Date(2025, 4, 12, 13, 0, 0, 0, time.UTC)) || s.End.Sub(s.Start) != 9*time.Minute {
+		t.Errorf("span = %v to %v", s.Start, s.End)
+	}
+	if url := s.MapURL(); !strings.HasPrefix(url, "https://www.openstreetmap.org/?mlat=41.89350&mlon=-87.63000#map=") {
+		t.Errorf("MapURL = %s", url)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if _, err := Parse("ride.tcx", nil); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("expected an unsupported format error, got %v", err)
+	}
+	if _, err := Parse("empty.gpx", []byte(`<gpx><trk><trkseg></trkseg></trk></gpx>`)); err == nil || !strings.Contains(err.Error(), "no GPS points") {
+		t.Errorf("expected a no points error, got %v", err)
+	}
+	if _, err := Parse("ride.fit", []byte("not a fit file")); err == nil {
+		t.Error("expected a FIT parse error")
+	}
+}
+
+// fitFile assembles a FIT activity: three records (one compressed, one
+// without a fix) and a session with device totals.
+func fitFile(t *testing.T) []byte {
+	t.Helper()
+	var body bytes.Buffer
+	le := binary.LittleEndian
+	put := func(vs ...any) {
+		for _, v := range vs {
+			if err := binary.Write(&body, le, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	deg := func(d float64) int32 { return int32(d * (1 << 31) / 180) }
+	ts := uint32(time.Date(2025, 4, 12, 13, 0, 0, 0, time.UTC).Sub(fitEpoch) / time.Second)
+
+	// Local 0: record with timestamp, lat, lon, enhanced_altitude
+	body.Write([]byte{0x40, 0, 0})
+	put(uint16(fitMsgRecord))
+	body.Write([]byte{4, fitTimestamp, 4, 0x86, fitRecordLat, 4, 0x85, fitRecordLon, 4, 0x85, fitRecordEnhAlt, 4, 0x86})
+	body.WriteByte(0x00)
+	put(ts, deg(41.88), deg(-87.63), uint32((200+500)*5))
+	body.WriteByte(0x00)
+	put(ts+5, int32(math.MaxInt32), int32(math.MaxInt32), uint32(0xffffffff))
+
+	// Local 1: record without a timestamp, sent with a compressed header,
+	// with a developer field to skip
+	body.Write([]byte{0x61, 0, 0})
+	put(uint16(fitMsgRecord))
+	body.Write([]byte{3, fitRecordLat, 4, 0x85, fitRecordLon, 4, 0x85, fitRecordAltitude, 2, 0x84})
+	body.Write([]byte{1, 0, 2, 0})
+	body.WriteByte(0x80 | 1<<5 | byte((ts+10)&0x1f))
+	put(deg(41.89), deg(-87.63), uint16((215+500)*5), uint16(7))
+
+	// Local 2: session
+	body.Write([]byte{0x42, 0, 0})
+	put(uint16(fitMsgSession))
+	body.Write([]byte{3, fitSessionDistance, 4, 0x86, fitSessionAscent, 2, 0x84, fitSessionDescent, 2, 0x84})
+	body.WriteByte(0x02)
+	put(uint32(112345), uint16(42), uint16(40))
+
+	var f bytes.Buffer
+	f.Write([]byte{12, 0x10})
+	binary.Write(&f, le, uint16(2100))
+	binary.Write(&f, le, uint32(body.Len()))
+	f.WriteString(".FIT")
+	f.Write(body.Bytes())
+	f.Write([]byte{0, 0}) // CRC, not checked
+	return f.Bytes()
+}
+
+func TestParseFIT(t *testing.T) {
+	r, err := Parse("ride.fit", fitFile(t))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(r.Points) != 2 {
+		t.Fatalf("points = %+v, want the two fixes", r.Points)
+	}
+	p := r.Points[1]
+	if math.Abs(p.Lat-41.89) > 1e-6 || math.Abs(p.Lon+87.63) > 1e-6 || *p.Ele != 215 {
+		t.Errorf("second point = %+v", p)
+	}
+	want := time.Date(2025, 4, 12, 13, 0, 10, 0, time.UTC)
+	if !p.Time.Equal(want) {
+		t.Errorf("compressed timestamp = %v, want %v", p.Time, want)
+	}
+
+	s := r.Summary()
+	if s.DistanceKm != 1.123 || s.AscentM != 42 || s.DescentM != 40 {
+		t.Errorf("summary = %+v, want the session's totals", s)
+	}
+}
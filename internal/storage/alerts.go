@@ -0,0 +1,64 @@
+// ABOUTME: Threshold alert checks against stored metrics.
+// ABOUTME: Compares new entries and latest values (daily totals for cumulative types).
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// CheckAlerts returns the alerts crossed by a newly recorded metric. For
+// cumulative types the day's running total is compared, not the entry.
+func CheckAlerts(ctx context.Context, r Repository, alerts []models.Alert, m *models.Metric) ([]models.AlertHit, error) {
+	var hits []models.AlertHit
+	for _, a := range alerts {
+		if a.MetricType != m.MetricType {
+			continue
+		}
+		value := m.Value
+		if models.IsCumulative(m.MetricType) {
+			total, err := DailyTotal(ctx, r, m.MetricType, m.RecordedAt)
+			if err != nil {
+				return nil, fmt.Errorf("total %s: %w", m.MetricType, err)
+			}
+			value = total.Sum
+		}
+		if a.Crossed(value) {
+			hits = append(hits, models.AlertHit{Alert: a, Value: value, Unit: m.Unit, At: m.RecordedAt})
+		}
+	}
+	return hits, nil
+}
+
+// ActiveAlerts returns the alerts crossed by the latest value of each
+// metric type. Cumulative types use today's total and are only checked
+// once something has been logged today.
+func ActiveAlerts(ctx context.Context, r Repository, alerts []models.Alert, now time.Time) ([]models.AlertHit, error) {
+	var hits []models.AlertHit
+	for _, a := range alerts {
+		mt := a.MetricType
+		if models.IsCumulative(mt) {
+			total, err := DailyTotal(ctx, r, mt, now)
+			if err != nil {
+				return nil, fmt.Errorf("total %s: %w", mt, err)
+			}
+			if total.Count > 0 && a.Crossed(total.Sum) {
+				hits = append(hits, models.AlertHit{Alert: a, Value: total.Sum, Unit: models.MetricUnits[mt], At: now})
+			}
+			continue
+		}
+
+		latest, err := r.ListMetrics(ctx, &mt, 1)
+		if err != nil {
+			return nil, fmt.Errorf("latest %s: %w", mt, err)
+		}
+		if len(latest) > 0 && a.Crossed(latest[0].Value) {
+			m := latest[0]
+			hits = append(hits, models.AlertHit{Alert: a, Value: m.Value, Unit: m.Unit, At: m.RecordedAt})
+		}
+	}
+	return hits, nil
+}
@@ -0,0 +1,109 @@
+// ABOUTME: Anonymized exports for sharing with researchers or attaching to bug reports.
+// ABOUTME: Strips free text and places, truncates times to the day, and re-keys every ID.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// ExportJSONAnonymized exports all data as JSON, with derived metrics,
+// passed through Anonymize.
+func ExportJSONAnonymized(ctx context.Context, r Repository, derive DeriveFunc) ([]byte, error) {
+	data, err := GetAllDataFromRepo(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if derive != nil {
+		data.Derived = derive(data.Metrics)
+	}
+	Anonymize(data)
+	return json.MarshalIndent(data, "", "  ")
+}
+
+// Anonymize rewrites data in place so it can be shared. Values, types,
+// units, sources, and medication names and doses are kept. Notes,
+// metadata, location tags, and workout comments are removed, as are
+// locations, trips, appointments, and events, which name people and
+// places. Every timestamp becomes midnight UTC of its local day, and every
+// ID is replaced by a random one, consistently, so links between records
+// (a workout's metrics, the halves of a blood pressure reading) survive.
+func Anonymize(data *ExportData) {
+	ids := make(map[uuid.UUID]uuid.UUID)
+	rekey := func(id uuid.UUID) uuid.UUID {
+		if id == uuid.Nil {
+			return id
+		}
+		if n, ok := ids[id]; ok {
+			return n
+		}
+		n := uuid.New()
+		ids[id] = n
+		return n
+	}
+	rekeyPtr := func(id *uuid.UUID) *uuid.UUID {
+		if id == nil {
+			return nil
+		}
+		n := rekey(*id)
+		return &n
+	}
+
+	data.ExportedAt = dayOf(data.ExportedAt)
+	for _, metrics := range [][]*models.Metric{data.Metrics, data.Derived} {
+		for _, m := range metrics {
+			m.ID, m.ReadingID = rekey(m.ID), rekeyPtr(m.ReadingID)
+			m.RecordedAt, m.CreatedAt = dayOf(m.RecordedAt), dayOf(m.CreatedAt)
+			m.Notes, m.Location, m.Metadata = nil, nil, nil
+		}
+	}
+	for _, w := range data.Workouts {
+		w.ID = rekey(w.ID)
+		w.StartedAt, w.CreatedAt = dayOf(w.StartedAt), dayOf(w.CreatedAt)
+		w.Notes, w.Location, w.Metadata, w.Comments = nil, nil, nil, nil
+		for i := range w.Metrics {
+			wm := &w.Metrics[i]
+			wm.ID, wm.WorkoutID, wm.CreatedAt = rekey(wm.ID), w.ID, dayOf(wm.CreatedAt)
+		}
+		for i := range w.Sets {
+			ws := &w.Sets[i]
+			ws.ID, ws.WorkoutID, ws.CreatedAt = rekey(ws.ID), w.ID, dayOf(ws.CreatedAt)
+		}
+	}
+	for _, ss := range data.SleepSessions {
+		ss.ID, ss.MetricID = rekey(ss.ID), rekeyPtr(ss.MetricID)
+		ss.BedTime, ss.WakeTime, ss.CreatedAt = dayOf(ss.BedTime), dayOf(ss.WakeTime), dayOf(ss.CreatedAt)
+		ss.Notes = nil
+	}
+	for _, m := range data.Medications {
+		m.ID, m.CreatedAt, m.Notes = rekey(m.ID), dayOf(m.CreatedAt), nil
+	}
+	for _, in := range data.MedicationIntakes {
+		in.ID, in.MedicationID = rekey(in.ID), rekey(in.MedicationID)
+		in.TakenAt, in.CreatedAt = dayOf(in.TakenAt), dayOf(in.CreatedAt)
+		in.Notes = nil
+	}
+	for _, f := range data.Fasts {
+		f.ID = rekey(f.ID)
+		f.StartedAt, f.CreatedAt = dayOf(f.StartedAt), dayOf(f.CreatedAt)
+		if f.EndedAt != nil {
+			end := dayOf(*f.EndedAt)
+			f.EndedAt = &end
+		}
+		f.Notes = nil
+	}
+	data.Locations, data.Trips, data.Appointments, data.Events = nil, nil, nil, nil
+}
+
+// dayOf returns midnight UTC of t's local calendar day.
+func dayOf(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	y, m, d := t.In(time.Local).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,222 @@
+// ABOUTME: Appointment CRUD operations for SQLite storage.
+// ABOUTME: Handles scheduling, listing, visit summaries, and deletion.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateAppointment stores a new appointment in the database.
+func (d *DB) CreateAppointment(ctx context.Context, a *models.Appointment) error {
+	query := `
+		INSERT INTO appointments (id, provider, scheduled_at, duration_minutes, location, reason, summary, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	// Stored in UTC so filters compare correctly as strings
+	_, err := d.db.ExecContext(ctx, query,
+		a.ID.String(),
+		a.Provider,
+		a.ScheduledAt.UTC().Format(time.RFC3339),
+		a.DurationMinutes,
+		a.Location,
+		a.Reason,
+		a.Summary,
+		a.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create appointment: %w", err)
+	}
+	return nil
+}
+
+// GetAppointment retrieves an appointment by ID or ID prefix.
+func (d *DB) GetAppointment(ctx context.Context, idOrPrefix string) (*models.Appointment, error) {
+	id, err := d.resolveAppointmentID(ctx, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, provider, scheduled_at, duration_minutes, location, reason, summary, created_at
+		FROM appointments
+		WHERE id = ?
+	`
+	a, err := scanAppointment(d.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("not found")
+	}
+	return a, err
+}
+
+// ListAppointments retrieves appointments matching the filter, soonest first.
+func (d *DB) ListAppointments(ctx context.Context, filter AppointmentFilter) ([]*models.Appointment, error) {
+	query := `
+		SELECT id, provider, scheduled_at, duration_minutes, location, reason, summary, created_at
+		FROM appointments
+		WHERE 1=1
+	`
+	var args []interface{}
+	if filter.Since != nil {
+		query += " AND scheduled_at >= ?"
+		args = append(args, filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		query += " AND scheduled_at < ?"
+		args = append(args, filter.Until.UTC().Format(time.RFC3339))
+	}
+	query += " ORDER BY scheduled_at ASC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list appointments: %w", err)
+	}
+	defer rows.Close()
+
+	var appts []*models.Appointment
+	for rows.Next() {
+		a, err := scanAppointment(rows)
+		if err != nil {
+			return nil, err
+		}
+		appts = append(appts, a)
+	}
+	return appts, rows.Err()
+}
+
+// SetAppointmentSummary links a visit summary to an appointment, replacing
+// any earlier one.
+func (d *DB) SetAppointmentSummary(ctx context.Context, idOrPrefix string, summary string) error {
+	id, err := d.resolveAppointmentID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set appointment summary: %w", err)
+	}
+
+	if _, err := d.db.ExecContext(ctx, "UPDATE appointments SET summary = ? WHERE id = ?", summary, id); err != nil {
+		return fmt.Errorf("set appointment summary: %w", err)
+	}
+	return nil
+}
+
+// DeleteAppointment removes an appointment by ID or prefix.
+func (d *DB) DeleteAppointment(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveAppointmentID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete appointment: %w", err)
+	}
+
+	result, err := d.db.ExecContext(ctx, "DELETE FROM appointments WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete appointment: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete appointment: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("not found: %s", idOrPrefix)
+	}
+
+	return nil
+}
+
+// resolveAppointmentID finds the full ID from a prefix.
+func (d *DB) resolveAppointmentID(ctx context.Context, idOrPrefix string) (string, error) {
+	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
+		return idOrPrefix, nil
+	}
+
+	query := `SELECT id FROM appointments WHERE id LIKE ? || '%'`
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
+	if err != nil {
+		return "", fmt.Errorf("resolve appointment ID: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan appointment ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+	}
+
+	return matches[0], nil
+}
+
+// scanAppointment scans a row from either QueryRow or Query into an Appointment.
+func scanAppointment(row interface{ Scan(dest ...any) error }) (*models.Appointment, error) {
+	var a models.Appointment
+	var idStr, scheduledAt, createdAt string
+	var duration sql.NullInt64
+	var location, reason, summary sql.NullString
+
+	err := row.Scan(&idStr, &a.Provider, &scheduledAt, &duration, &location, &reason, &summary, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan appointment: %w", err)
+	}
+
+	a.ID, _ = uuid.Parse(idStr)
+	a.ScheduledAt, _ = time.Parse(time.RFC3339, scheduledAt)
+	a.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if duration.Valid {
+		minutes := int(duration.Int64)
+		a.DurationMinutes = &minutes
+	}
+	if location.Valid {
+		a.Location = &location.String
+	}
+	if reason.Valid {
+		a.Reason = &reason.String
+	}
+	if summary.Valid {
+		a.Summary = &summary.String
+	}
+
+	return &a, nil
+}
+
+// UpcomingAppointments returns appointments that haven't finished by now,
+// soonest first. A limit of 0 returns all of them.
+func UpcomingAppointments(ctx context.Context, r Repository, now time.Time, limit int) ([]*models.Appointment, error) {
+	// Start a day back so an appointment in progress is still included
+	since := now.AddDate(0, 0, -1)
+	appts, err := r.ListAppointments(ctx, AppointmentFilter{Since: &since})
+	if err != nil {
+		return nil, err
+	}
+
+	var upcoming []*models.Appointment
+	for _, a := range appts {
+		if !a.IsUpcoming(now) {
+			continue
+		}
+		upcoming = append(upcoming, a)
+		if limit > 0 && len(upcoming) == limit {
+			break
+		}
+	}
+	return upcoming, nil
+}
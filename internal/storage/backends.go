@@ -0,0 +1,91 @@
+// ABOUTME: Registry of storage backends selectable by name from config.
+// ABOUTME: Each backend registers an opener in init(); OpenBackend looks it up.
+package storage
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Opener opens a backend's Repository rooted at dataDir.
+//
+// Backends beyond the ones registered below live in their own packages,
+// which implement Repository and call RegisterBackend from init(); a blank
+// import of the package in cmd/health makes its name a valid "backend"
+// value. internal/storage/postgres is the reference example.
+type Opener func(dataDir string) (Repository, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Opener{}
+)
+
+func init() {
+	RegisterBackend("sqlite", func(dataDir string) (Repository, error) {
+		return Open(filepath.Join(dataDir, "health.db"))
+	})
+	RegisterBackend("markdown", func(dataDir string) (Repository, error) {
+		return NewMarkdownStore(dataDir)
+	})
+	RegisterBackend("jsonl", func(dataDir string) (Repository, error) {
+		return OpenJSONL(filepath.Join(dataDir, "health.jsonl"))
+	})
+	// Nothing under dataDir is read or written
+	RegisterBackend("memory", func(dataDir string) (Repository, error) {
+		return NewMemoryStore(), nil
+	})
+}
+
+// RegisterBackend makes a backend available under name, the value used for
+// "backend" in config.json and for migrate --to. It is meant to be called
+// from init() and panics if name is empty or already registered.
+func RegisterBackend(name string, open Opener) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if name == "" || open == nil {
+		panic("storage: RegisterBackend needs a name and an opener")
+	}
+	if _, dup := backends[name]; dup {
+		panic(fmt.Sprintf("storage: backend %q registered twice", name))
+	}
+	backends[name] = open
+}
+
+// Backends returns the registered backend names, sorted.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBackend reports whether name is a registered backend.
+func IsBackend(name string) bool {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+	_, ok := backends[name]
+	return ok
+}
+
+// OpenBackend opens the named backend with its data rooted at dataDir.
+func OpenBackend(name, dataDir string) (Repository, error) {
+	backendsMu.RLock()
+	open, ok := backends[name]
+	backendsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown backend: %q (available: %s)", name, strings.Join(Backends(), ", "))
+	}
+	start := time.Now()
+	r, err := open(dataDir)
+	slog.Debug("storage opened", "backend", name, "data_dir", dataDir, "duration", time.Since(start), "err", err)
+	return r, err
+}
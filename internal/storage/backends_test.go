@@ -0,0 +1,49 @@
+// ABOUTME: Tests for the storage backend registry.
+// ABOUTME: Covers the built-in backends, unknown names, and duplicate registration.
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuiltinBackendsRegistered(t *testing.T) {
+	names := Backends()
+	for _, want := range []string{"jsonl", "markdown", "memory", "sqlite"} {
+		if !slices.Contains(names, want) || !IsBackend(want) {
+			t.Errorf("Backends() = %v, missing %q", names, want)
+		}
+	}
+	if !slices.IsSorted(names) {
+		t.Errorf("Backends() = %v, want sorted", names)
+	}
+
+	dir := t.TempDir()
+	r, err := OpenBackend("sqlite", dir)
+	if err != nil {
+		t.Fatalf("OpenBackend(sqlite) failed: %v", err)
+	}
+	r.Close()
+	if _, err := os.Stat(filepath.Join(dir, "health.db")); err != nil {
+		t.Errorf("Expected health.db in data dir: %v", err)
+	}
+}
+
+func TestOpenBackendUnknown(t *testing.T) {
+	_, err := OpenBackend("nope", t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), `unknown backend: "nope"`) || !strings.Contains(err.Error(), "sqlite") {
+		t.Errorf("Expected unknown backend error listing the available ones, got %v", err)
+	}
+}
+
+func TestRegisterBackendDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering sqlite twice to panic")
+		}
+	}()
+	RegisterBackend("sqlite", func(string) (Repository, error) { return nil, nil })
+}
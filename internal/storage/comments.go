@@ -0,0 +1,64 @@
+// ABOUTME: WorkoutComment operations for SQLite storage.
+// ABOUTME: Stores feedback (e.g. from a coach) attached to a workout.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// AddWorkoutComment stores a new workout comment in the database.
+func (d *DB) AddWorkoutComment(ctx context.Context, c *models.WorkoutComment) error {
+	query := `
+		INSERT INTO workout_comments (id, workout_id, author, body, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := d.db.ExecContext(ctx, query,
+		c.ID.String(),
+		c.WorkoutID.String(),
+		c.Author,
+		c.Body,
+		c.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("add workout comment: %w", err)
+	}
+	return nil
+}
+
+// ListWorkoutComments retrieves all comments on a workout, oldest first.
+func (d *DB) ListWorkoutComments(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutComment, error) {
+	query := `
+		SELECT id, workout_id, author, body, created_at
+		FROM workout_comments
+		WHERE workout_id = ?
+		ORDER BY created_at ASC, rowid ASC
+	`
+	rows, err := d.db.QueryContext(ctx, query, workoutID.String())
+	if err != nil {
+		return nil, fmt.Errorf("list workout comments: %w", err)
+	}
+	defer rows.Close()
+
+	var comments []*models.WorkoutComment
+	for rows.Next() {
+		var c models.WorkoutComment
+		var idStr, workoutIDStr, createdAt string
+
+		if err := rows.Scan(&idStr, &workoutIDStr, &c.Author, &c.Body, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan workout comment: %w", err)
+		}
+
+		c.ID, _ = uuid.Parse(idStr)
+		c.WorkoutID, _ = uuid.Parse(workoutIDStr)
+		c.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+
+		comments = append(comments, &c)
+	}
+
+	return comments, rows.Err()
+}
@@ -5,6 +5,7 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 
@@ -13,8 +14,9 @@ import (
 
 // DB wraps the SQLite database connection.
 type DB struct {
-	db     *sql.DB
-	dbPath string
+	db      *timedDB
+	dbPath  string
+	upgrade *SchemaUpgrade // what Open migrated, if anything
 }
 
 // Open opens or creates a SQLite database at the given path.
@@ -25,7 +27,7 @@ func Open(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("create data directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := sql.Open("sqlite", dsn(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
@@ -36,13 +38,7 @@ func Open(dbPath string) (*DB, error) {
 		return nil, fmt.Errorf("set database permissions: %w", err)
 	}
 
-	d := &DB{db: db, dbPath: dbPath}
-
-	// Configure pragmas for better performance
-	if err := d.configurePragmas(); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("configure pragmas: %w", err)
-	}
+	d := &DB{db: &timedDB{DB: db}, dbPath: dbPath}
 
 	// Initialize schema
 	if err := d.initSchema(); err != nil {
@@ -81,18 +77,23 @@ func (d *DB) Close() error {
 	return nil
 }
 
-// configurePragmas sets up SQLite for optimal performance.
-func (d *DB) configurePragmas() error {
-	pragmas := []string{
-		"PRAGMA journal_mode = WAL",
-		"PRAGMA foreign_keys = ON",
-		"PRAGMA busy_timeout = 5000",
-		"PRAGMA synchronous = NORMAL",
-	}
-	for _, pragma := range pragmas {
-		if _, err := d.db.Exec(pragma); err != nil {
-			return fmt.Errorf("execute %s: %w", pragma, err)
-		}
-	}
-	return nil
+// connPragmas are set on every pooled connection as it opens, not just
+// the first: busy_timeout and foreign_keys only apply to the connection
+// that ran them. WAL lets the CLI read while the MCP server writes, and
+// busy_timeout makes a second writer, in this process or another, wait
+// for the lock instead of failing with "database is locked".
+var connPragmas = []string{
+	"busy_timeout(5000)",
+	"journal_mode(WAL)",
+	"foreign_keys(ON)",
+	"synchronous(NORMAL)",
+}
+
+// dsn returns the connection string for dbPath. Transactions begin
+// IMMEDIATE, taking the write lock up front; a deferred transaction that
+// reads first and then writes can't wait for the lock and fails at once
+// if another connection wrote in between.
+func dsn(dbPath string) string {
+	q := url.Values{"_pragma": connPragmas, "_txlock": {"immediate"}}
+	return dbPath + "?" + q.Encode()
 }
@@ -0,0 +1,44 @@
+// ABOUTME: Duplicate-entry detection for newly logged metrics.
+// ABOUTME: Finds an existing entry of the same type within the configured window.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// FindDuplicate returns an existing entry of m's type recorded within the
+// window windows gives that type, or nil if there is none or the check is
+// off. The nearest entry in time is returned.
+func FindDuplicate(ctx context.Context, r Repository, windows models.DuplicateWindows, m *models.Metric) (*models.Metric, error) {
+	window := windows.Window(m.MetricType)
+	if window <= 0 {
+		return nil, nil
+	}
+	since := m.RecordedAt.Add(-window)
+	until := m.RecordedAt.Add(window + 1)
+	found, err := r.QueryMetrics(ctx, MetricFilter{Type: &m.MetricType, Since: &since, Until: &until})
+	if err != nil {
+		return nil, fmt.Errorf("check for duplicate %s: %w", m.MetricType, err)
+	}
+	var nearest *models.Metric
+	for _, e := range found {
+		if e.ID == m.ID {
+			continue
+		}
+		if nearest == nil || absDuration(e.RecordedAt.Sub(m.RecordedAt)) < absDuration(nearest.RecordedAt.Sub(m.RecordedAt)) {
+			nearest = e
+		}
+	}
+	return nearest, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,170 @@
+// ABOUTME: Event CRUD operations for SQLite storage.
+// ABOUTME: Events are races and goal dates, listed soonest first.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateEvent stores a new event in the database.
+func (d *DB) CreateEvent(ctx context.Context, e *models.Event) error {
+	query := `
+		INSERT INTO events (id, name, date, workout_type, weekly_minutes, weekly_km, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := d.db.ExecContext(ctx, query,
+		e.ID.String(),
+		e.Name,
+		e.Date.UTC().Format(time.RFC3339),
+		e.WorkoutType,
+		e.WeeklyMinutes,
+		e.WeeklyKm,
+		e.Notes,
+		e.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create event: %w", err)
+	}
+	return nil
+}
+
+// GetEvent retrieves an event by ID or ID prefix.
+func (d *DB) GetEvent(ctx context.Context, idOrPrefix string) (*models.Event, error) {
+	id, err := d.resolveEventID(ctx, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, name, date, workout_type, weekly_minutes, weekly_km, notes, created_at
+		FROM events
+		WHERE id = ?
+	`
+	e, err := scanEvent(d.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("not found")
+	}
+	return e, err
+}
+
+// ListEvents retrieves every event, soonest first.
+func (d *DB) ListEvents(ctx context.Context) ([]*models.Event, error) {
+	query := `
+		SELECT id, name, date, workout_type, weekly_minutes, weekly_km, notes, created_at
+		FROM events
+		ORDER BY date ASC, name ASC
+	`
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.Event
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// DeleteEvent removes an event by ID or prefix.
+func (d *DB) DeleteEvent(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveEventID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+
+	result, err := d.db.ExecContext(ctx, "DELETE FROM events WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("not found: %s", idOrPrefix)
+	}
+
+	return nil
+}
+
+// resolveEventID finds the full ID from a prefix.
+func (d *DB) resolveEventID(ctx context.Context, idOrPrefix string) (string, error) {
+	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
+		return idOrPrefix, nil
+	}
+
+	query := `SELECT id FROM events WHERE id LIKE ? || '%'`
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
+	if err != nil {
+		return "", fmt.Errorf("resolve event ID: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan event ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+	}
+
+	return matches[0], nil
+}
+
+// scanEvent scans a row from either QueryRow or Query into an Event.
+func scanEvent(row interface{ Scan(dest ...any) error }) (*models.Event, error) {
+	var e models.Event
+	var idStr, date, createdAt string
+	var workoutType, notes sql.NullString
+	var weeklyMinutes sql.NullInt64
+	var weeklyKm sql.NullFloat64
+
+	err := row.Scan(&idStr, &e.Name, &date, &workoutType, &weeklyMinutes, &weeklyKm, &notes, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan event: %w", err)
+	}
+
+	e.ID, _ = uuid.Parse(idStr)
+	e.Date, _ = time.Parse(time.RFC3339, date)
+	e.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if workoutType.Valid {
+		e.WorkoutType = &workoutType.String
+	}
+	if weeklyMinutes.Valid {
+		minutes := int(weeklyMinutes.Int64)
+		e.WeeklyMinutes = &minutes
+	}
+	if weeklyKm.Valid {
+		e.WeeklyKm = &weeklyKm.Float64
+	}
+	if notes.Valid {
+		e.Notes = &notes.String
+	}
+
+	return &e, nil
+}
@@ -3,9 +3,12 @@
 package storage
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -13,104 +16,360 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ExportFormatVersion is the major.minor version of the JSON and YAML
+// export format. Minor versions only add fields, which older readers
+// ignore; a new major version means old readers would misread the file.
+const ExportFormatVersion = "1.5"
+
 // ExportData represents the full export format for health data.
 type ExportData struct {
-	Version    string            `json:"version" yaml:"version"`
-	ExportedAt time.Time         `json:"exported_at" yaml:"exported_at"`
-	Tool       string            `json:"tool" yaml:"tool"`
-	Metrics    []*models.Metric  `json:"metrics" yaml:"metrics"`
-	Workouts   []*models.Workout `json:"workouts" yaml:"workouts"`
+	Version string `json:"version" yaml:"version"`
+	// MinReaderVersion is the oldest format version that can import this
+	// file. Empty means the first minor version of Version's major, so a
+	// writer only sets it when a new field must not be dropped on import.
+	MinReaderVersion string `json:"min_reader_version,omitempty" yaml:"min_reader_version,omitempty"`
+
+	ExportedAt    time.Time              `json:"exported_at" yaml:"exported_at"`
+	Tool          string                 `json:"tool" yaml:"tool"`
+	Metrics       []*models.Metric       `json:"metrics" yaml:"metrics"`
+	Workouts      []*models.Workout      `json:"workouts" yaml:"workouts"`
+	SleepSessions []*models.SleepSession `json:"sleep_sessions,omitempty" yaml:"sleep_sessions,omitempty"`
+
+	Medications       []*models.Medication       `json:"medications,omitempty" yaml:"medications,omitempty"`
+	MedicationIntakes []*models.MedicationIntake `json:"medication_intakes,omitempty" yaml:"medication_intakes,omitempty"`
+	Locations         []*models.Location         `json:"locations,omitempty" yaml:"locations,omitempty"`
+	Trips             []*models.Trip             `json:"trips,omitempty" yaml:"trips,omitempty"`
+	Fasts             []*models.Fast             `json:"fasts,omitempty" yaml:"fasts,omitempty"`
+	Appointments      []*models.Appointment      `json:"appointments,omitempty" yaml:"appointments,omitempty"`
+	Events            []*models.Event            `json:"events,omitempty" yaml:"events,omitempty"`
+
+	// Derived holds computed metrics (e.g. BMI) for readers of the export.
+	// They are recomputed from Metrics, so imports ignore them.
+	Derived []*models.Metric `json:"derived,omitempty" yaml:"derived,omitempty"`
+}
+
+// DeriveFunc computes metrics that are never stored, such as BMI, from the
+// stored metrics. Exports that take one include its results.
+type DeriveFunc func(metrics []*models.Metric) []*models.Metric
+
+// ExportFilter narrows an export to one metric type and a time range.
+// Zero values mean "no constraint"; Until is exclusive.
+type ExportFilter struct {
+	Type  *models.MetricType
+	Since *time.Time
+	Until *time.Time
+}
+
+// includes reports whether t falls within the filter's range.
+func (f ExportFilter) includes(t time.Time) bool {
+	return (f.Since == nil || !t.Before(*f.Since)) && (f.Until == nil || t.Before(*f.Until))
 }
 
 // GetAllData retrieves all data for export.
-func (d *DB) GetAllData() (*ExportData, error) {
-	return GetAllDataFromRepo(d)
+func (d *DB) GetAllData(ctx context.Context) (*ExportData, error) {
+	return GetAllDataFromRepo(ctx, d)
 }
 
 // GetAllDataFromRepo retrieves all data for export from any Repository.
-func GetAllDataFromRepo(r Repository) (*ExportData, error) {
-	metrics, err := r.ListMetrics(nil, 0)
+func GetAllDataFromRepo(ctx context.Context, r Repository) (*ExportData, error) {
+	metrics, err := r.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		return nil, fmt.Errorf("list metrics: %w", err)
 	}
 
-	workouts, err := r.ListWorkouts(nil, 0)
+	workouts, err := r.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		return nil, fmt.Errorf("list workouts: %w", err)
 	}
 
-	// Populate workout metrics
+	// Populate workout metrics, sets, and comments
 	for _, w := range workouts {
-		wMetrics, err := r.ListWorkoutMetrics(w.ID)
+		wMetrics, err := r.ListWorkoutMetrics(ctx, w.ID)
 		if err != nil {
 			return nil, fmt.Errorf("list workout metrics: %w", err)
 		}
 		for _, wm := range wMetrics {
 			w.Metrics = append(w.Metrics, *wm)
 		}
+		wSets, err := r.ListWorkoutSets(ctx, w.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list workout sets: %w", err)
+		}
+		for _, ws := range wSets {
+			w.Sets = append(w.Sets, *ws)
+		}
+		wComments, err := r.ListWorkoutComments(ctx, w.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list workout comments: %w", err)
+		}
+		for _, c := range wComments {
+			w.Comments = append(w.Comments, *c)
+		}
+	}
+
+	sleepSessions, err := r.ListSleepSessions(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list sleep sessions: %w", err)
+	}
+
+	medications, err := r.ListMedications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list medications: %w", err)
+	}
+
+	intakes, err := r.ListMedicationIntakes(ctx, IntakeFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list medication intakes: %w", err)
+	}
+
+	locations, err := r.ListLocations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list locations: %w", err)
+	}
+
+	trips, err := r.ListTrips(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list trips: %w", err)
+	}
+
+	fasts, err := r.ListFasts(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list fasts: %w", err)
+	}
+
+	appointments, err := r.ListAppointments(ctx, AppointmentFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list appointments: %w", err)
+	}
+
+	events, err := r.ListEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
 	}
 
 	return &ExportData{
-		Version:    "1.0",
-		ExportedAt: time.Now(),
-		Tool:       "health",
-		Metrics:    metrics,
-		Workouts:   workouts,
+		Version:           ExportFormatVersion,
+		ExportedAt:        time.Now(),
+		Tool:              "health",
+		Metrics:           metrics,
+		Workouts:          workouts,
+		SleepSessions:     sleepSessions,
+		Medications:       medications,
+		MedicationIntakes: intakes,
+		Locations:         locations,
+		Trips:             trips,
+		Fasts:             fasts,
+		Appointments:      appointments,
+		Events:            events,
 	}, nil
 }
 
 // ImportData imports data from an export file.
-func (d *DB) ImportData(data *ExportData) error {
-	return ImportDataToRepo(d, data)
+func (d *DB) ImportData(ctx context.Context, data *ExportData) error {
+	return ImportDataToRepo(ctx, d, data)
 }
 
 // ImportDataToRepo imports data from an export file into any Repository.
-func ImportDataToRepo(r Repository, data *ExportData) error {
-	// Import metrics
-	for _, m := range data.Metrics {
-		if err := r.CreateMetric(m); err != nil {
-			return fmt.Errorf("import metric: %w", err)
-		}
+func ImportDataToRepo(ctx context.Context, r Repository, data *ExportData) error {
+	if err := r.CreateMetrics(ctx, data.Metrics); err != nil {
+		return fmt.Errorf("import metrics: %w", err)
+	}
+
+	// Import workouts with their metrics, sets, and comments. Children are
+	// detached before CreateWorkouts so file-based backends don't write them twice.
+	type children struct {
+		metrics  []models.WorkoutMetric
+		sets     []models.WorkoutSet
+		comments []models.WorkoutComment
+	}
+	detached := make([]children, len(data.Workouts))
+	for i, w := range data.Workouts {
+		detached[i] = children{w.Metrics, w.Sets, w.Comments}
+		w.Metrics, w.Sets, w.Comments = nil, nil, nil
+	}
+	err := r.CreateWorkouts(ctx, data.Workouts)
+	for i, w := range data.Workouts {
+		w.Metrics, w.Sets, w.Comments = detached[i].metrics, detached[i].sets, detached[i].comments
+	}
+	if err != nil {
+		return fmt.Errorf("import workouts: %w", err)
 	}
 
-	// Import workouts and their metrics
 	for _, w := range data.Workouts {
-		if err := r.CreateWorkout(w); err != nil {
-			return fmt.Errorf("import workout: %w", err)
-		}
 		for _, wm := range w.Metrics {
 			wm.WorkoutID = w.ID
-			if err := r.AddWorkoutMetric(&wm); err != nil {
+			if err := r.AddWorkoutMetric(ctx, &wm); err != nil {
 				return fmt.Errorf("import workout metric: %w", err)
 			}
 		}
+		for _, ws := range w.Sets {
+			ws.WorkoutID = w.ID
+			if err := r.AddWorkoutSet(ctx, &ws); err != nil {
+				return fmt.Errorf("import workout set: %w", err)
+			}
+		}
+		for _, c := range w.Comments {
+			c.WorkoutID = w.ID
+			if err := r.AddWorkoutComment(ctx, &c); err != nil {
+				return fmt.Errorf("import workout comment: %w", err)
+			}
+		}
+	}
+
+	// Import sleep sessions. Their derived sleep_hours metrics are part of
+	// data.Metrics, so they are not recreated here.
+	for _, ss := range data.SleepSessions {
+		if err := r.CreateSleepSession(ctx, ss); err != nil {
+			return fmt.Errorf("import sleep session: %w", err)
+		}
+	}
+
+	if err := importMedications(ctx, r, data); err != nil {
+		return err
+	}
+	if err := importLocations(ctx, r, data); err != nil {
+		return err
+	}
+	if err := importFasts(ctx, r, data); err != nil {
+		return err
+	}
+	return importAppointments(ctx, r, data)
+}
+
+// importAppointments imports appointments with their visit summaries, and
+// events with their training plans.
+func importAppointments(ctx context.Context, r Repository, data *ExportData) error {
+	for _, a := range data.Appointments {
+		if err := r.CreateAppointment(ctx, a); err != nil {
+			return fmt.Errorf("import appointment: %w", err)
+		}
+	}
+	for _, e := range data.Events {
+		if err := r.CreateEvent(ctx, e); err != nil {
+			return fmt.Errorf("import event: %w", err)
+		}
+	}
+	return nil
+}
+
+// importLocations imports the location registry and trips. Entries carry
+// their location as a tag, so order relative to metrics and workouts
+// doesn't matter.
+func importLocations(ctx context.Context, r Repository, data *ExportData) error {
+	for _, l := range data.Locations {
+		if err := r.CreateLocation(ctx, l); err != nil {
+			return fmt.Errorf("import location: %w", err)
+		}
+	}
+	for _, t := range data.Trips {
+		if err := r.CreateTrip(ctx, t); err != nil {
+			return fmt.Errorf("import trip: %w", err)
+		}
+	}
+	return nil
+}
+
+// importFasts imports fasting windows, including one still running.
+func importFasts(ctx context.Context, r Repository, data *ExportData) error {
+	for _, f := range data.Fasts {
+		if err := r.CreateFast(ctx, f); err != nil {
+			return fmt.Errorf("import fast: %w", err)
+		}
 	}
+	return nil
+}
 
+// importMedications imports medications before their intakes so intakes
+// always reference an existing medication.
+func importMedications(ctx context.Context, r Repository, data *ExportData) error {
+	for _, m := range data.Medications {
+		if err := r.CreateMedication(ctx, m); err != nil {
+			return fmt.Errorf("import medication: %w", err)
+		}
+	}
+	for _, in := range data.MedicationIntakes {
+		if err := r.LogMedicationIntake(ctx, in); err != nil {
+			return fmt.Errorf("import medication intake: %w", err)
+		}
+	}
 	return nil
 }
 
 // ExportJSON exports all data as JSON.
-func (d *DB) ExportJSON() ([]byte, error) {
-	return ExportJSONFromRepo(d)
+func (d *DB) ExportJSON(ctx context.Context) ([]byte, error) {
+	return ExportJSONFromRepo(ctx, d)
 }
 
 // ExportJSONFromRepo exports all data as JSON from any Repository.
-func ExportJSONFromRepo(r Repository) ([]byte, error) {
-	data, err := GetAllDataFromRepo(r)
+func ExportJSONFromRepo(ctx context.Context, r Repository) ([]byte, error) {
+	return ExportJSONWithDerived(ctx, r, nil)
+}
+
+// ExportJSONWithDerived exports all data as JSON, adding derived metrics.
+func ExportJSONWithDerived(ctx context.Context, r Repository, derive DeriveFunc) ([]byte, error) {
+	data, err := GetAllDataFromRepo(ctx, r)
 	if err != nil {
 		return nil, err
 	}
+	if derive != nil {
+		data.Derived = derive(data.Metrics)
+	}
 	return json.MarshalIndent(data, "", "  ")
 }
 
+// ExportJSONFiltered exports the data f selects as JSON, adding derived
+// metrics. With a Type only that type's metrics are kept, as in the
+// Markdown export; otherwise dated entries of every kind are kept by the
+// range, and medications and locations come along whole for reference.
+func ExportJSONFiltered(ctx context.Context, r Repository, f ExportFilter, derive DeriveFunc) ([]byte, error) {
+	data, err := GetAllDataFromRepo(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	if derive != nil {
+		data.Derived = derive(data.Metrics)
+	}
+	return json.MarshalIndent(filterExportData(data, f), "", "  ")
+}
+
+// filterExportData returns data narrowed to what f selects.
+func filterExportData(data *ExportData, f ExportFilter) *ExportData {
+	metric := func(m *models.Metric) bool {
+		return (f.Type == nil || m.MetricType == *f.Type) && f.includes(m.RecordedAt)
+	}
+	// Copying keeps the header, including MinReaderVersion, as is
+	out := *data
+	out.Metrics = slices.DeleteFunc(data.Metrics, func(m *models.Metric) bool { return !metric(m) })
+	out.Derived = slices.DeleteFunc(data.Derived, func(m *models.Metric) bool { return !metric(m) })
+	if f.Type != nil {
+		out.Workouts = []*models.Workout{}
+		out.SleepSessions, out.MedicationIntakes, out.Trips, out.Fasts = nil, nil, nil, nil
+		out.Appointments, out.Events, out.Medications, out.Locations = nil, nil, nil, nil
+		return &out
+	}
+	out.Workouts = slices.DeleteFunc(data.Workouts, func(w *models.Workout) bool { return !f.includes(w.StartedAt) })
+	out.SleepSessions = slices.DeleteFunc(data.SleepSessions, func(ss *models.SleepSession) bool { return !f.includes(ss.WakeTime) })
+	out.MedicationIntakes = slices.DeleteFunc(data.MedicationIntakes, func(in *models.MedicationIntake) bool { return !f.includes(in.TakenAt) })
+	out.Trips = slices.DeleteFunc(data.Trips, func(t *models.Trip) bool { return !f.includes(t.StartedAt) })
+	out.Fasts = slices.DeleteFunc(data.Fasts, func(fa *models.Fast) bool { return !f.includes(fa.StartedAt) })
+	out.Appointments = slices.DeleteFunc(data.Appointments, func(a *models.Appointment) bool { return !f.includes(a.ScheduledAt) })
+	out.Events = slices.DeleteFunc(data.Events, func(e *models.Event) bool { return !f.includes(e.Date) })
+	return &out
+}
+
 // ExportYAML exports all data as YAML.
-func (d *DB) ExportYAML() ([]byte, error) {
-	return ExportYAMLFromRepo(d)
+func (d *DB) ExportYAML(ctx context.Context) ([]byte, error) {
+	return ExportYAMLFromRepo(ctx, d)
 }
 
 // ExportYAMLFromRepo exports all data as YAML from any Repository.
-func ExportYAMLFromRepo(r Repository) ([]byte, error) {
-	data, err := GetAllDataFromRepo(r)
+func ExportYAMLFromRepo(ctx context.Context, r Repository) ([]byte, error) {
+	return ExportYAMLWithDerived(ctx, r, nil)
+}
+
+// ExportYAMLWithDerived exports all data as YAML, adding derived metrics.
+func ExportYAMLWithDerived(ctx context.Context, r Repository, derive DeriveFunc) ([]byte, error) {
+	data, err := GetAllDataFromRepo(ctx, r)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +380,16 @@ func ExportYAMLFromRepo(r Repository) ([]byte, error) {
 		ExportedAt string                  `yaml:"exported_at"`
 		Tool       string                  `yaml:"tool"`
 		Metrics    map[string][]yamlMetric `yaml:"metrics"`
+		Derived    map[string][]yamlMetric `yaml:"derived,omitempty"`
+		BP         []yamlBloodPressure     `yaml:"blood_pressure,omitempty"`
 		Workouts   []yamlWorkout           `yaml:"workouts"`
+		Sleep      []yamlSleep             `yaml:"sleep,omitempty"`
+		Meds       []yamlMedication        `yaml:"medications,omitempty"`
+		Locations  []yamlLocation          `yaml:"locations,omitempty"`
+		Trips      []yamlTrip              `yaml:"trips,omitempty"`
+		Fasts      []yamlFast              `yaml:"fasts,omitempty"`
+		Appts      []yamlAppointment       `yaml:"appointments,omitempty"`
+		Events     []yamlEvent             `yaml:"events,omitempty"`
 	}{
 		Version:    data.Version,
 		ExportedAt: data.ExportedAt.Format(time.RFC3339),
@@ -130,34 +398,87 @@ func ExportYAMLFromRepo(r Repository) ([]byte, error) {
 		Workouts:   make([]yamlWorkout, 0, len(data.Workouts)),
 	}
 
-	// Group metrics by type
-	for _, m := range data.Metrics {
+	// Group metrics by type; paired blood pressure gets its own section
+	for _, e := range models.GroupReadings(data.Metrics) {
+		m := e.Metric
+		if e.IsBloodPressure() {
+			yb := yamlBloodPressure{
+				ID:         m.ID.String()[:8],
+				Reading:    e.Reading(),
+				Systolic:   m.Value,
+				Diastolic:  e.Diastolic.Value,
+				Unit:       m.Unit,
+				RecordedAt: m.RecordedAt.Format(time.RFC3339),
+				Source:     m.Source,
+				Metadata:   m.Metadata,
+			}
+			if m.Notes != nil {
+				yb.Notes = *m.Notes
+			}
+			if m.Location != nil {
+				yb.Location = *m.Location
+			}
+			yamlData.BP = append(yamlData.BP, yb)
+			continue
+		}
+
 		mt := string(m.MetricType)
 		ym := yamlMetric{
 			ID:         m.ID.String()[:8],
 			Value:      m.Value,
 			Unit:       m.Unit,
 			RecordedAt: m.RecordedAt.Format(time.RFC3339),
+			Source:     m.Source,
+			Metadata:   m.Metadata,
 		}
 		if m.Notes != nil {
 			ym.Notes = *m.Notes
 		}
+		if m.Location != nil {
+			ym.Location = *m.Location
+		}
 		yamlData.Metrics[mt] = append(yamlData.Metrics[mt], ym)
 	}
 
+	// Derived metrics are computed, so they have no notes, location, source,
+	// or metadata
+	if derive != nil {
+		yamlData.Derived = make(map[string][]yamlMetric)
+		for _, m := range derive(data.Metrics) {
+			mt := string(m.MetricType)
+			yamlData.Derived[mt] = append(yamlData.Derived[mt], yamlMetric{
+				ID:         m.ID.String()[:8],
+				Value:      m.Value,
+				Unit:       m.Unit,
+				RecordedAt: m.RecordedAt.Format(time.RFC3339),
+			})
+		}
+	}
+
 	// Convert workouts
 	for _, w := range data.Workouts {
 		yw := yamlWorkout{
 			ID:        w.ID.String()[:8],
 			Type:      w.WorkoutType,
 			StartedAt: w.StartedAt.Format(time.RFC3339),
+			Source:    w.Source,
+			Metadata:  w.Metadata,
 		}
 		if w.DurationMinutes != nil {
 			yw.DurationMinutes = *w.DurationMinutes
 		}
+		if w.RPE != nil {
+			yw.RPE = *w.RPE
+		}
+		if w.Zone != nil {
+			yw.Zone = *w.Zone
+		}
 		if w.Notes != nil {
 			yw.Notes = *w.Notes
 		}
+		if w.Location != nil {
+			yw.Location = *w.Location
+		}
 		for _, wm := range w.Metrics {
 			ywm := yamlWorkoutMetric{
 				Name:  wm.MetricName,
@@ -168,27 +489,200 @@ func ExportYAMLFromRepo(r Repository) ([]byte, error) {
 			}
 			yw.Metrics = append(yw.Metrics, ywm)
 		}
+		for _, ws := range w.Sets {
+			yws := yamlWorkoutSet{
+				Exercise: ws.Exercise,
+				Set:      ws.SetNumber,
+				Reps:     ws.Reps,
+			}
+			if ws.Weight != nil {
+				yws.Weight = *ws.Weight
+			}
+			if ws.WeightUnit != nil {
+				yws.WeightUnit = *ws.WeightUnit
+			}
+			yw.Sets = append(yw.Sets, yws)
+		}
+		for _, c := range w.Comments {
+			yw.Comments = append(yw.Comments, yamlWorkoutComment{
+				Author:    c.Author,
+				Body:      c.Body,
+				CreatedAt: c.CreatedAt.Format(time.RFC3339),
+			})
+		}
 		yamlData.Workouts = append(yamlData.Workouts, yw)
 	}
 
+	// Convert sleep sessions
+	for _, ss := range data.SleepSessions {
+		ys := yamlSleep{
+			ID:         ss.ID.String()[:8],
+			BedTime:    ss.BedTime.Format(time.RFC3339),
+			WakeTime:   ss.WakeTime.Format(time.RFC3339),
+			Hours:      ss.Hours(),
+			Awakenings: ss.Awakenings,
+			Quality:    ss.Quality,
+		}
+		if ss.Notes != nil {
+			ys.Notes = *ss.Notes
+		}
+		yamlData.Sleep = append(yamlData.Sleep, ys)
+	}
+
+	// Convert medications with their intakes nested underneath
+	for _, m := range data.Medications {
+		ym := yamlMedication{
+			ID:       m.ID.String()[:8],
+			Name:     m.Name,
+			Dose:     m.Dose,
+			Schedule: m.Schedule,
+		}
+		if m.Notes != nil {
+			ym.Notes = *m.Notes
+		}
+		for _, in := range data.MedicationIntakes {
+			if in.MedicationID != m.ID {
+				continue
+			}
+			yi := yamlIntake{TakenAt: in.TakenAt.Format(time.RFC3339)}
+			if in.Dose != nil {
+				yi.Dose = *in.Dose
+			}
+			if in.Notes != nil {
+				yi.Notes = *in.Notes
+			}
+			ym.Intakes = append(ym.Intakes, yi)
+		}
+		yamlData.Meds = append(yamlData.Meds, ym)
+	}
+
+	// Convert locations
+	for _, l := range data.Locations {
+		yl := yamlLocation{
+			Name:      l.Name,
+			Latitude:  l.Latitude,
+			Longitude: l.Longitude,
+		}
+		if l.Notes != nil {
+			yl.Notes = *l.Notes
+		}
+		yamlData.Locations = append(yamlData.Locations, yl)
+	}
+
+	// Convert trips
+	for _, t := range data.Trips {
+		yt := yamlTrip{
+			ID:          t.ID.String()[:8],
+			Destination: t.Destination,
+			StartedAt:   t.StartedAt.Format(time.RFC3339),
+		}
+		if t.Timezone != nil {
+			yt.Timezone = *t.Timezone
+		}
+		if t.EndedAt != nil {
+			yt.EndedAt = t.EndedAt.Format(time.RFC3339)
+		}
+		if t.Notes != nil {
+			yt.Notes = *t.Notes
+		}
+		yamlData.Trips = append(yamlData.Trips, yt)
+	}
+
+	// Convert fasts
+	for _, f := range data.Fasts {
+		yf := yamlFast{
+			ID:          f.ID.String()[:8],
+			StartedAt:   f.StartedAt.Format(time.RFC3339),
+			TargetHours: f.TargetHours,
+		}
+		if f.EndedAt != nil {
+			yf.EndedAt = f.EndedAt.Format(time.RFC3339)
+		}
+		if f.Notes != nil {
+			yf.Notes = *f.Notes
+		}
+		yamlData.Fasts = append(yamlData.Fasts, yf)
+	}
+
+	// Convert appointments
+	for _, a := range data.Appointments {
+		ya := yamlAppointment{
+			ID:              a.ID.String()[:8],
+			Provider:        a.Provider,
+			ScheduledAt:     a.ScheduledAt.Format(time.RFC3339),
+			DurationMinutes: a.DurationMinutes,
+		}
+		if a.Location != nil {
+			ya.Location = *a.Location
+		}
+		if a.Reason != nil {
+			ya.Reason = *a.Reason
+		}
+		if a.Summary != nil {
+			ya.Summary = *a.Summary
+		}
+		yamlData.Appts = append(yamlData.Appts, ya)
+	}
+
+	// Convert events
+	for _, e := range data.Events {
+		ye := yamlEvent{
+			ID:            e.ID.String()[:8],
+			Name:          e.Name,
+			Date:          e.Date.Local().Format("2006-01-02"),
+			WeeklyMinutes: e.WeeklyMinutes,
+			WeeklyKm:      e.WeeklyKm,
+		}
+		if e.WorkoutType != nil {
+			ye.WorkoutType = *e.WorkoutType
+		}
+		if e.Notes != nil {
+			ye.Notes = *e.Notes
+		}
+		yamlData.Events = append(yamlData.Events, ye)
+	}
+
 	return yaml.Marshal(yamlData)
 }
 
 type yamlMetric struct {
-	ID         string  `yaml:"id"`
-	Value      float64 `yaml:"value"`
-	Unit       string  `yaml:"unit"`
-	RecordedAt string  `yaml:"recorded_at"`
-	Notes      string  `yaml:"notes,omitempty"`
+	ID         string            `yaml:"id"`
+	Value      float64           `yaml:"value"`
+	Unit       string            `yaml:"unit"`
+	RecordedAt string            `yaml:"recorded_at"`
+	Notes      string            `yaml:"notes,omitempty"`
+	Location   string            `yaml:"location,omitempty"`
+	Source     string            `yaml:"source,omitempty"`
+	Metadata   map[string]string `yaml:"metadata,omitempty"`
+}
+
+type yamlBloodPressure struct {
+	ID         string            `yaml:"id"`
+	Reading    string            `yaml:"reading"`
+	Systolic   float64           `yaml:"systolic"`
+	Diastolic  float64           `yaml:"diastolic"`
+	Unit       string            `yaml:"unit"`
+	RecordedAt string            `yaml:"recorded_at"`
+	Notes      string            `yaml:"notes,omitempty"`
+	Location   string            `yaml:"location,omitempty"`
+	Source     string            `yaml:"source,omitempty"`
+	Metadata   map[string]string `yaml:"metadata,omitempty"`
 }
 
 type yamlWorkout struct {
-	ID              string              `yaml:"id"`
-	Type            string              `yaml:"type"`
-	StartedAt       string              `yaml:"started_at"`
-	DurationMinutes int                 `yaml:"duration_minutes,omitempty"`
-	Notes           string              `yaml:"notes,omitempty"`
-	Metrics         []yamlWorkoutMetric `yaml:"metrics,omitempty"`
+	ID              string               `yaml:"id"`
+	Type            string               `yaml:"type"`
+	StartedAt       string               `yaml:"started_at"`
+	DurationMinutes int                  `yaml:"duration_minutes,omitempty"`
+	RPE             int                  `yaml:"rpe,omitempty"`
+	Zone            int                  `yaml:"zone,omitempty"`
+	Notes           string               `yaml:"notes,omitempty"`
+	Location        string               `yaml:"location,omitempty"`
+	Source          string               `yaml:"source,omitempty"`
+	Metadata        map[string]string    `yaml:"metadata,omitempty"`
+	Metrics         []yamlWorkoutMetric  `yaml:"metrics,omitempty"`
+	Sets            []yamlWorkoutSet     `yaml:"sets,omitempty"`
+	Comments        []yamlWorkoutComment `yaml:"comments,omitempty"`
 }
 
 type yamlWorkoutMetric struct {
@@ -197,28 +691,137 @@ type yamlWorkoutMetric struct {
 	Unit  string  `yaml:"unit,omitempty"`
 }
 
+type yamlWorkoutSet struct {
+	Exercise   string  `yaml:"exercise"`
+	Set        int     `yaml:"set"`
+	Reps       int     `yaml:"reps"`
+	Weight     float64 `yaml:"weight,omitempty"`
+	WeightUnit string  `yaml:"weight_unit,omitempty"`
+}
+
+type yamlWorkoutComment struct {
+	Author    string `yaml:"author"`
+	Body      string `yaml:"body"`
+	CreatedAt string `yaml:"created_at"`
+}
+
+type yamlSleep struct {
+	ID         string  `yaml:"id"`
+	BedTime    string  `yaml:"bed_time"`
+	WakeTime   string  `yaml:"wake_time"`
+	Hours      float64 `yaml:"hours"`
+	Awakenings *int    `yaml:"awakenings,omitempty"`
+	Quality    *int    `yaml:"quality,omitempty"`
+	Notes      string  `yaml:"notes,omitempty"`
+}
+
+type yamlMedication struct {
+	ID       string       `yaml:"id"`
+	Name     string       `yaml:"name"`
+	Dose     string       `yaml:"dose"`
+	Schedule string       `yaml:"schedule"`
+	Notes    string       `yaml:"notes,omitempty"`
+	Intakes  []yamlIntake `yaml:"intakes,omitempty"`
+}
+
+type yamlLocation struct {
+	Name      string   `yaml:"name"`
+	Latitude  *float64 `yaml:"latitude,omitempty"`
+	Longitude *float64 `yaml:"longitude,omitempty"`
+	Notes     string   `yaml:"notes,omitempty"`
+}
+
+type yamlTrip struct {
+	ID          string `yaml:"id"`
+	Destination string `yaml:"destination"`
+	Timezone    string `yaml:"timezone,omitempty"`
+	StartedAt   string `yaml:"started_at"`
+	EndedAt     string `yaml:"ended_at,omitempty"`
+	Notes       string `yaml:"notes,omitempty"`
+}
+
+type yamlFast struct {
+	ID          string  `yaml:"id"`
+	StartedAt   string  `yaml:"started_at"`
+	EndedAt     string  `yaml:"ended_at,omitempty"`
+	TargetHours float64 `yaml:"target_hours"`
+	Notes       string  `yaml:"notes,omitempty"`
+}
+
+type yamlAppointment struct {
+	ID              string `yaml:"id"`
+	Provider        string `yaml:"provider"`
+	ScheduledAt     string `yaml:"scheduled_at"`
+	DurationMinutes *int   `yaml:"duration_minutes,omitempty"`
+	Location        string `yaml:"location,omitempty"`
+	Reason          string `yaml:"reason,omitempty"`
+	Summary         string `yaml:"summary,omitempty"`
+}
+
+type yamlEvent struct {
+	ID            string   `yaml:"id"`
+	Name          string   `yaml:"name"`
+	Date          string   `yaml:"date"`
+	WorkoutType   string   `yaml:"workout_type,omitempty"`
+	WeeklyMinutes *int     `yaml:"weekly_minutes,omitempty"`
+	WeeklyKm      *float64 `yaml:"weekly_km,omitempty"`
+	Notes         string   `yaml:"notes,omitempty"`
+}
+
+type yamlIntake struct {
+	TakenAt string `yaml:"taken_at"`
+	Dose    string `yaml:"dose,omitempty"`
+	Notes   string `yaml:"notes,omitempty"`
+}
+
 // ExportMarkdown exports data as Markdown.
-func (d *DB) ExportMarkdown(metricType *models.MetricType, since *time.Time) (string, error) {
-	return ExportMarkdownFromRepo(d, metricType, since)
+func (d *DB) ExportMarkdown(ctx context.Context, metricType *models.MetricType, since *time.Time) (string, error) {
+	return ExportMarkdownFromRepo(ctx, d, metricType, since)
 }
 
 // ExportMarkdownFromRepo exports data as Markdown from any Repository.
+func ExportMarkdownFromRepo(ctx context.Context, r Repository, metricType *models.MetricType, since *time.Time) (string, error) {
+	return ExportMarkdownWithDerived(ctx, r, metricType, since, nil)
+}
+
+// ExportMarkdownWithDerived exports data as Markdown, adding derived metrics
+// as their own sections. metricType may name a derived metric.
+func ExportMarkdownWithDerived(ctx context.Context, r Repository, metricType *models.MetricType, since *time.Time, derive DeriveFunc) (string, error) {
+	return ExportMarkdownFiltered(ctx, r, ExportFilter{Type: metricType, Since: since}, derive)
+}
+
+// ExportMarkdownFiltered exports the data f selects as Markdown, adding
+// derived metrics as their own sections.
 //
 //nolint:gocognit,nestif,gocyclo // This function has clear, linear logic despite complexity metrics.
-func ExportMarkdownFromRepo(r Repository, metricType *models.MetricType, since *time.Time) (string, error) {
+func ExportMarkdownFiltered(ctx context.Context, r Repository, f ExportFilter, derive DeriveFunc) (string, error) {
+	metricType, since := f.Type, f.Since
 	var metrics []*models.Metric
 	var err error
 
-	metrics, err = r.ListMetrics(metricType, 0)
+	metrics, err = r.ListMetrics(ctx, metricType, 0)
 	if err != nil {
 		return "", err
 	}
 
-	// Filter by since date if provided
-	if since != nil {
+	// Derived values need every input's history, not just the filtered type
+	if derive != nil {
+		all, err := r.ListMetrics(ctx, nil, 0)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range derive(all) {
+			if metricType == nil || m.MetricType == *metricType {
+				metrics = append(metrics, m)
+			}
+		}
+	}
+
+	// Filter by date range if provided
+	if since != nil || f.Until != nil {
 		var filtered []*models.Metric
 		for _, m := range metrics {
-			if m.RecordedAt.After(*since) || m.RecordedAt.Equal(*since) {
+			if f.includes(m.RecordedAt) {
 				filtered = append(filtered, m)
 			}
 		}
@@ -245,10 +848,14 @@ func ExportMarkdownFromRepo(r Repository, metricType *models.MetricType, since *
 				m.Value, m.Unit, notes))
 		}
 	} else {
-		// Group by metric type
-		grouped := make(map[models.MetricType][]*models.Metric)
-		for _, m := range metrics {
-			grouped[m.MetricType] = append(grouped[m.MetricType], m)
+		// Group by metric type, showing paired blood pressure as "bp"
+		grouped := make(map[models.MetricType][]models.MetricEntry)
+		for _, e := range models.GroupReadings(metrics) {
+			mt := e.Metric.MetricType
+			if e.IsBloodPressure() {
+				mt = "bp"
+			}
+			grouped[mt] = append(grouped[mt], e)
 		}
 
 		// Sort types for consistent output
@@ -264,26 +871,31 @@ func ExportMarkdownFromRepo(r Repository, metricType *models.MetricType, since *
 			sb.WriteString(fmt.Sprintf("## %s\n\n", t))
 			sb.WriteString("| Date | Value | Notes |\n")
 			sb.WriteString("|------|-------|-------|\n")
-			for _, m := range grouped[t] {
+			for _, e := range grouped[t] {
+				m := e.Metric
 				notes := ""
 				if m.Notes != nil {
 					notes = *m.Notes
 				}
-				sb.WriteString(fmt.Sprintf("| %s | %.2f %s | %s |\n",
+				value := fmt.Sprintf("%.2f", m.Value)
+				if e.IsBloodPressure() {
+					value = e.Reading()
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s %s | %s |\n",
 					m.RecordedAt.Format("2006-01-02 15:04"),
-					m.Value, m.Unit, notes))
+					value, m.Unit, notes))
 			}
 			sb.WriteString("\n")
 		}
 
 		// Add workouts section
-		workouts, err := r.ListWorkouts(nil, 0)
+		workouts, err := r.ListWorkouts(ctx, nil, 0)
 		if err == nil && len(workouts) > 0 {
-			// Filter by since if provided
-			if since != nil {
+			// Filter by date range if provided
+			if since != nil || f.Until != nil {
 				var filtered []*models.Workout
 				for _, w := range workouts {
-					if w.StartedAt.After(*since) || w.StartedAt.Equal(*since) {
+					if f.includes(w.StartedAt) {
 						filtered = append(filtered, w)
 					}
 				}
@@ -309,21 +921,150 @@ func ExportMarkdownFromRepo(r Repository, metricType *models.MetricType, since *
 				}
 			}
 		}
+
+		// Add sleep section
+		sessions, err := r.ListSleepSessions(ctx, 0)
+		if err == nil && len(sessions) > 0 {
+			if since != nil || f.Until != nil {
+				var filtered []*models.SleepSession
+				for _, ss := range sessions {
+					if f.includes(ss.WakeTime) {
+						filtered = append(filtered, ss)
+					}
+				}
+				sessions = filtered
+			}
+
+			if len(sessions) > 0 {
+				sb.WriteString("\n## Sleep\n\n")
+				sb.WriteString("| Bed | Wake | Hours | Awakenings | Quality | Notes |\n")
+				sb.WriteString("|-----|------|-------|------------|---------|-------|\n")
+				for _, ss := range sessions {
+					awakenings, quality, notes := "", "", ""
+					if ss.Awakenings != nil {
+						awakenings = fmt.Sprintf("%d", *ss.Awakenings)
+					}
+					if ss.Quality != nil {
+						quality = fmt.Sprintf("%d", *ss.Quality)
+					}
+					if ss.Notes != nil {
+						notes = *ss.Notes
+					}
+					sb.WriteString(fmt.Sprintf("| %s | %s | %.2f | %s | %s | %s |\n",
+						ss.BedTime.Format("2006-01-02 15:04"),
+						ss.WakeTime.Format("2006-01-02 15:04"),
+						ss.Hours(), awakenings, quality, notes))
+				}
+			}
+		}
+
+		// Add medications section
+		meds, err := r.ListMedications(ctx)
+		if err == nil && len(meds) > 0 {
+			names := make(map[string]*models.Medication, len(meds))
+			for _, m := range meds {
+				names[m.ID.String()] = m
+			}
+			intakes, err := r.ListMedicationIntakes(ctx, IntakeFilter{Since: since, Until: f.Until})
+			if err == nil && len(intakes) > 0 {
+				sb.WriteString("\n## Medications\n\n")
+				sb.WriteString("| Date | Medication | Dose | Notes |\n")
+				sb.WriteString("|------|------------|------|-------|\n")
+				for _, in := range intakes {
+					name, dose, notes := "", "", ""
+					if m, ok := names[in.MedicationID.String()]; ok {
+						name, dose = m.Name, m.Dose
+					}
+					if in.Dose != nil {
+						dose = *in.Dose
+					}
+					if in.Notes != nil {
+						notes = *in.Notes
+					}
+					sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+						in.TakenAt.Format("2006-01-02 15:04"), name, dose, notes))
+				}
+			}
+		}
+
+		// Add appointments section with visit summaries
+		appts, err := r.ListAppointments(ctx, AppointmentFilter{Since: since, Until: f.Until})
+		if err == nil && len(appts) > 0 {
+			sb.WriteString("\n## Appointments\n\n")
+			sb.WriteString("| Date | Provider | Reason | Summary |\n")
+			sb.WriteString("|------|----------|--------|---------|\n")
+			for _, a := range appts {
+				reason, summary := "", ""
+				if a.Reason != nil {
+					reason = *a.Reason
+				}
+				if a.Summary != nil {
+					summary = strings.ReplaceAll(*a.Summary, "\n", " ")
+				}
+				sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+					a.ScheduledAt.Local().Format("2006-01-02 15:04"), a.Provider, reason, summary))
+			}
+		}
 	}
 
 	return sb.String(), nil
 }
 
 // ImportJSON imports data from JSON bytes.
-func (d *DB) ImportJSON(data []byte) error {
-	return ImportJSONToRepo(d, data)
+func (d *DB) ImportJSON(ctx context.Context, data []byte) error {
+	return ImportJSONToRepo(ctx, d, data)
 }
 
 // ImportJSONToRepo imports data from JSON bytes into any Repository.
-func ImportJSONToRepo(r Repository, data []byte) error {
+func ImportJSONToRepo(ctx context.Context, r Repository, data []byte) error {
 	var exportData ExportData
 	if err := json.Unmarshal(data, &exportData); err != nil {
 		return fmt.Errorf("unmarshal JSON: %w", err)
 	}
-	return ImportDataToRepo(r, &exportData)
+	if err := CheckExportVersion(&exportData); err != nil {
+		return err
+	}
+	return ImportDataToRepo(ctx, r, &exportData)
+}
+
+// CheckExportVersion returns an error if data needs a newer reader than
+// this build. Files without a version predate versioning and always
+// import; files from a newer minor version import with their unknown
+// fields ignored unless they set MinReaderVersion.
+func CheckExportVersion(data *ExportData) error {
+	required := data.MinReaderVersion
+	if required == "" && data.Version != "" {
+		major, _, err := parseFormatVersion(data.Version)
+		if err != nil {
+			return fmt.Errorf("export version %q: %w", data.Version, err)
+		}
+		required = strconv.Itoa(major) + ".0"
+	}
+	if required == "" {
+		return nil
+	}
+	needMajor, needMinor, err := parseFormatVersion(required)
+	if err != nil {
+		return fmt.Errorf("export min_reader_version %q: %w", required, err)
+	}
+	major, minor, _ := parseFormatVersion(ExportFormatVersion)
+	if needMajor > major || (needMajor == major && needMinor > minor) {
+		return fmt.Errorf("export format %s needs a reader for format %s, but this version of health reads format %s; upgrade health to import it",
+			data.Version, required, ExportFormatVersion)
+	}
+	return nil
+}
+
+func parseFormatVersion(v string) (major, minor int, err error) {
+	maj, mnr, ok := strings.Cut(v, ".")
+	if !ok {
+		return 0, 0, fmt.Errorf("want major.minor")
+	}
+	if major, err = strconv.Atoi(maj); err != nil {
+		return 0, 0, fmt.Errorf("want major.minor")
+	}
+	if minor, err = strconv.Atoi(mnr); err != nil {
+		return 0, 0, fmt.Errorf("want major.minor")
+	}
+	return major, minor, nil
 }
@@ -0,0 +1,151 @@
+// ABOUTME: Parquet export of metrics and workouts as two flat tables for DuckDB and pandas.
+// ABOUTME: Columns are only ever appended, so exports from different versions read together.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/parquet"
+)
+
+// ParquetSchemaVersion is stored in each file's footer as
+// health.schema_version. Bump it when adding a column. Columns are never
+// renamed, retyped, or removed; new ones go at the end and are nullable,
+// so older and newer exports combine with DuckDB's union_by_name.
+const ParquetSchemaVersion = 4
+
+var metricsParquetFields = []parquet.Field{
+	{Name: "id", Type: parquet.String},
+	{Name: "metric_type", Type: parquet.String},
+	{Name: "value", Type: parquet.Double},
+	{Name: "unit", Type: parquet.String},
+	{Name: "recorded_at", Type: parquet.Timestamp},
+	{Name: "notes", Type: parquet.String, Optional: true},
+	{Name: "location", Type: parquet.String, Optional: true},
+	{Name: "reading_id", Type: parquet.String, Optional: true},
+	{Name: "created_at", Type: parquet.Timestamp},
+	{Name: "derived", Type: parquet.Bool},
+	{Name: "source", Type: parquet.String, Optional: true},
+	{Name: "metadata", Type: parquet.String, Optional: true},
+}
+
+var workoutsParquetFields = []parquet.Field{
+	{Name: "id", Type: parquet.String},
+	{Name: "workout_type", Type: parquet.String},
+	{Name: "started_at", Type: parquet.Timestamp},
+	{Name: "duration_minutes", Type: parquet.Int64, Optional: true},
+	{Name: "notes", Type: parquet.String, Optional: true},
+	{Name: "location", Type: parquet.String, Optional: true},
+	{Name: "created_at", Type: parquet.Timestamp},
+	{Name: "source", Type: parquet.String, Optional: true},
+	{Name: "metadata", Type: parquet.String, Optional: true},
+	{Name: "rpe", Type: parquet.Int64, Optional: true},
+	{Name: "zone", Type: parquet.Int64, Optional: true},
+}
+
+// ExportParquet writes the metrics table (stored and derived metrics, one
+// row each) to metricsOut and the workouts table to workoutsOut, oldest
+// first.
+func ExportParquet(ctx context.Context, r Repository, derive DeriveFunc, metricsOut, workoutsOut io.Writer) error {
+	metrics, err := r.ListMetrics(ctx, nil, 0)
+	if err != nil {
+		return fmt.Errorf("list metrics: %w", err)
+	}
+	workouts, err := r.ListWorkouts(ctx, nil, 0)
+	if err != nil {
+		return fmt.Errorf("list workouts: %w", err)
+	}
+	exportedAt := time.Now()
+
+	var derived []*models.Metric
+	if derive != nil {
+		derived = derive(metrics)
+	}
+	rows := make([][]any, 0, len(metrics)+len(derived))
+	for _, m := range metrics {
+		rows = append(rows, metricRow(m, false))
+	}
+	for _, m := range derived {
+		rows = append(rows, metricRow(m, true))
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i][4].(time.Time).Before(rows[j][4].(time.Time))
+	})
+	if err := parquet.Write(metricsOut, &parquet.Table{
+		Fields:   metricsParquetFields,
+		Rows:     rows,
+		Metadata: parquetMetadata("metrics", exportedAt),
+	}); err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+
+	sort.SliceStable(workouts, func(i, j int) bool {
+		return workouts[i].StartedAt.Before(workouts[j].StartedAt)
+	})
+	rows = make([][]any, 0, len(workouts))
+	for _, w := range workouts {
+		rows = append(rows, []any{
+			w.ID.String(), w.WorkoutType, w.StartedAt, optionalInt(w.DurationMinutes),
+			optionalString(w.Notes), optionalString(w.Location), w.CreatedAt, optionalSource(w.Source),
+			encodeMetadata(w.Metadata), optionalInt(w.RPE), optionalInt(w.Zone),
+		})
+	}
+	if err := parquet.Write(workoutsOut, &parquet.Table{
+		Fields:   workoutsParquetFields,
+		Rows:     rows,
+		Metadata: parquetMetadata("workouts", exportedAt),
+	}); err != nil {
+		return fmt.Errorf("workouts: %w", err)
+	}
+	return nil
+}
+
+func metricRow(m *models.Metric, derived bool) []any {
+	var readingID any
+	if m.ReadingID != nil {
+		readingID = m.ReadingID.String()
+	}
+	return []any{
+		m.ID.String(), string(m.MetricType), m.Value, m.Unit, m.RecordedAt,
+		optionalString(m.Notes), optionalString(m.Location), readingID, m.CreatedAt, derived,
+		optionalSource(m.Source), encodeMetadata(m.Metadata),
+	}
+}
+
+// optionalString turns a nil *string into a null column value.
+func optionalString(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+// optionalInt turns a nil int into a null column value.
+func optionalInt(n *int) any {
+	if n == nil {
+		return nil
+	}
+	return int64(*n)
+}
+
+// optionalSource turns an unknown (empty) source into a null column value.
+func optionalSource(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func parquetMetadata(table string, exportedAt time.Time) map[string]string {
+	return map[string]string{
+		"health.table":          table,
+		"health.schema_version": strconv.Itoa(ParquetSchemaVersion),
+		"health.exported_at":    exportedAt.UTC().Format(time.RFC3339),
+	}
+}
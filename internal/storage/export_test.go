@@ -3,6 +3,7 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
 	"strings"
 	"testing"
@@ -14,23 +15,24 @@ import (
 )
 
 func TestExportJSON(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add test data
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("test note")
-	db.CreateMetric(m)
+	db.CreateMetric(ctx, m)
 
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	db.AddWorkoutMetric(wm)
+	db.AddWorkoutMetric(ctx, wm)
 
 	// Export
-	data, err := db.ExportJSON()
+	data, err := db.ExportJSON(ctx)
 	if err != nil {
 		t.Fatalf("ExportJSON failed: %v", err)
 	}
@@ -41,8 +43,8 @@ func TestExportJSON(t *testing.T) {
 		t.Fatalf("Failed to parse JSON: %v", err)
 	}
 
-	if export.Version != "1.0" {
-		t.Errorf("Expected version 1.0, got %s", export.Version)
+	if export.Version != "1.5" {
+		t.Errorf("Expected version 1.1, got %s", export.Version)
 	}
 	if export.Tool != "health" {
 		t.Errorf("Expected tool health, got %s", export.Tool)
@@ -56,15 +58,16 @@ func TestExportJSON(t *testing.T) {
 }
 
 func TestExportYAML(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add test data
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	db.CreateMetric(m)
+	db.CreateMetric(ctx, m)
 
 	// Export
-	data, err := db.ExportYAML()
+	data, err := db.ExportYAML(ctx)
 	if err != nil {
 		t.Fatalf("ExportYAML failed: %v", err)
 	}
@@ -75,8 +78,8 @@ func TestExportYAML(t *testing.T) {
 		t.Fatalf("Failed to parse YAML: %v", err)
 	}
 
-	if yamlData["version"] != "1.0" {
-		t.Errorf("Expected version 1.0, got %v", yamlData["version"])
+	if yamlData["version"] != "1.5" {
+		t.Errorf("Expected version 1.1, got %v", yamlData["version"])
 	}
 	if yamlData["tool"] != "health" {
 		t.Errorf("Expected tool health, got %v", yamlData["tool"])
@@ -92,16 +95,17 @@ func TestExportYAML(t *testing.T) {
 }
 
 func TestExportMarkdown(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add test data
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("morning")
-	db.CreateMetric(m)
+	db.CreateMetric(ctx, m)
 
 	// Export all
-	md, err := db.ExportMarkdown(nil, nil)
+	md, err := db.ExportMarkdown(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -118,7 +122,7 @@ func TestExportMarkdown(t *testing.T) {
 
 	// Export filtered by type
 	weightType := models.MetricWeight
-	md, err = db.ExportMarkdown(&weightType, nil)
+	md, err = db.ExportMarkdown(ctx, &weightType, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown with type failed: %v", err)
 	}
@@ -129,21 +133,22 @@ func TestExportMarkdown(t *testing.T) {
 }
 
 func TestExportMarkdownWithSince(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add old and new metrics
 	oldMetric := models.NewMetric(models.MetricWeight, 80.0)
 	oldMetric.RecordedAt = time.Now().Add(-30 * 24 * time.Hour) // 30 days ago
-	db.CreateMetric(oldMetric)
+	db.CreateMetric(ctx, oldMetric)
 
 	newMetric := models.NewMetric(models.MetricWeight, 82.5)
 	newMetric.RecordedAt = time.Now()
-	db.CreateMetric(newMetric)
+	db.CreateMetric(ctx, newMetric)
 
 	// Export with since filter
 	since := time.Now().Add(-7 * 24 * time.Hour) // 7 days ago
-	md, err := db.ExportMarkdown(nil, &since)
+	md, err := db.ExportMarkdown(ctx, nil, &since)
 	if err != nil {
 		t.Fatalf("ExportMarkdown with since failed: %v", err)
 	}
@@ -158,6 +163,7 @@ func TestExportMarkdownWithSince(t *testing.T) {
 }
 
 func TestImportJSON(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -193,12 +199,12 @@ func TestImportJSON(t *testing.T) {
 		]
 	}`
 
-	if err := db.ImportJSON([]byte(jsonData)); err != nil {
+	if err := db.ImportJSON(ctx, []byte(jsonData)); err != nil {
 		t.Fatalf("ImportJSON failed: %v", err)
 	}
 
 	// Verify imported data
-	metrics, err := db.ListMetrics(nil, 0)
+	metrics, err := db.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -209,7 +215,7 @@ func TestImportJSON(t *testing.T) {
 		t.Errorf("Expected value 82.5, got %v", metrics[0].Value)
 	}
 
-	workouts, err := db.ListWorkouts(nil, 0)
+	workouts, err := db.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -219,6 +225,7 @@ func TestImportJSON(t *testing.T) {
 }
 
 func TestExportMarkdownWithWorkouts(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -226,10 +233,10 @@ func TestExportMarkdownWithWorkouts(t *testing.T) {
 	w := models.NewWorkout("run")
 	w.WithDuration(45)
 	w.WithNotes("Morning jog")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Export
-	md, err := db.ExportMarkdown(nil, nil)
+	md, err := db.ExportMarkdown(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -246,26 +253,27 @@ func TestExportMarkdownWithWorkouts(t *testing.T) {
 }
 
 func TestExportYAMLWithAllOptionalFields(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add metric with notes
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("morning weight")
-	db.CreateMetric(m)
+	db.CreateMetric(ctx, m)
 
 	// Add workout with all optional fields
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
 	w.WithNotes("Easy run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Add workout metric with unit
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	db.AddWorkoutMetric(wm)
+	db.AddWorkoutMetric(ctx, wm)
 
 	// Export
-	data, err := db.ExportYAML()
+	data, err := db.ExportYAML(ctx)
 	if err != nil {
 		t.Fatalf("ExportYAML failed: %v", err)
 	}
@@ -299,19 +307,20 @@ func TestExportYAMLWithAllOptionalFields(t *testing.T) {
 }
 
 func TestExportYAMLWithWorkoutMetricNoUnit(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add workout
 	w := models.NewWorkout("lift")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Add workout metric without unit
 	wm := models.NewWorkoutMetric(w.ID, "sets", 4, "")
-	db.AddWorkoutMetric(wm)
+	db.AddWorkoutMetric(ctx, wm)
 
 	// Export
-	data, err := db.ExportYAML()
+	data, err := db.ExportYAML(ctx)
 	if err != nil {
 		t.Fatalf("ExportYAML failed: %v", err)
 	}
@@ -332,15 +341,16 @@ func TestExportYAMLWithWorkoutMetricNoUnit(t *testing.T) {
 }
 
 func TestExportYAMLWithNullableWorkoutFields(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add workout without duration or notes
 	w := models.NewWorkout("swim")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Export
-	data, err := db.ExportYAML()
+	data, err := db.ExportYAML(ctx)
 	if err != nil {
 		t.Fatalf("ExportYAML failed: %v", err)
 	}
@@ -359,17 +369,18 @@ func TestExportYAMLWithNullableWorkoutFields(t *testing.T) {
 }
 
 func TestExportMarkdownWithSinceAndType(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add metrics
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	db.CreateMetric(m)
+	db.CreateMetric(ctx, m)
 
 	// Export with type filter and since
 	weightType := models.MetricWeight
 	since := time.Now().Add(-24 * time.Hour)
-	md, err := db.ExportMarkdown(&weightType, &since)
+	md, err := db.ExportMarkdown(ctx, &weightType, &since)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -380,22 +391,23 @@ func TestExportMarkdownWithSinceAndType(t *testing.T) {
 }
 
 func TestExportMarkdownWorkoutsWithSince(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add old workout
 	oldWorkout := models.NewWorkout("run")
 	oldWorkout.StartedAt = time.Now().Add(-30 * 24 * time.Hour)
-	db.CreateWorkout(oldWorkout)
+	db.CreateWorkout(ctx, oldWorkout)
 
 	// Add new workout
 	newWorkout := models.NewWorkout("lift")
 	newWorkout.WithDuration(45)
-	db.CreateWorkout(newWorkout)
+	db.CreateWorkout(ctx, newWorkout)
 
 	// Export with since filter
 	since := time.Now().Add(-7 * 24 * time.Hour)
-	md, err := db.ExportMarkdown(nil, &since)
+	md, err := db.ExportMarkdown(ctx, nil, &since)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -407,15 +419,16 @@ func TestExportMarkdownWorkoutsWithSince(t *testing.T) {
 }
 
 func TestExportMarkdownWorkoutWithoutDuration(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add workout without duration
 	w := models.NewWorkout("yoga")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Export
-	md, err := db.ExportMarkdown(nil, nil)
+	md, err := db.ExportMarkdown(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -426,16 +439,17 @@ func TestExportMarkdownWorkoutWithoutDuration(t *testing.T) {
 }
 
 func TestExportMarkdownWorkoutWithoutNotes(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add workout without notes
 	w := models.NewWorkout("cycling")
 	w.WithDuration(60)
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Export
-	md, err := db.ExportMarkdown(nil, nil)
+	md, err := db.ExportMarkdown(ctx, nil, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -446,16 +460,17 @@ func TestExportMarkdownWorkoutWithoutNotes(t *testing.T) {
 }
 
 func TestExportMarkdownWithTypeFilterOnlyNoNotes(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add metric without notes
 	m := models.NewMetric(models.MetricMood, 7)
-	db.CreateMetric(m)
+	db.CreateMetric(ctx, m)
 
 	// Export with type filter
 	moodType := models.MetricMood
-	md, err := db.ExportMarkdown(&moodType, nil)
+	md, err := db.ExportMarkdown(ctx, &moodType, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -470,23 +485,24 @@ func TestImportJSONInvalid(t *testing.T) {
 	defer db.Close()
 
 	// Invalid JSON
-	err := db.ImportJSON([]byte("not valid json"))
+	err := db.ImportJSON(t.Context(), []byte("not valid json"))
 	if err == nil {
 		t.Error("Expected error for invalid JSON")
 	}
 }
 
 func TestExportYAMLMultipleMetricTypes(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Add multiple metric types
-	db.CreateMetric(models.NewMetric(models.MetricWeight, 82.5))
-	db.CreateMetric(models.NewMetric(models.MetricMood, 7))
-	db.CreateMetric(models.NewMetric(models.MetricSteps, 10000))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 10000))
 
 	// Export
-	data, err := db.ExportYAML()
+	data, err := db.ExportYAML(ctx)
 	if err != nil {
 		t.Fatalf("ExportYAML failed: %v", err)
 	}
@@ -504,19 +520,20 @@ func TestExportYAMLMultipleMetricTypes(t *testing.T) {
 }
 
 func TestGetAllDataWithWorkoutMetrics(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Create workout with metrics
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm1 := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
 	wm2 := models.NewWorkoutMetric(w.ID, "pace", 5.5, "min/km")
-	db.AddWorkoutMetric(wm1)
-	db.AddWorkoutMetric(wm2)
+	db.AddWorkoutMetric(ctx, wm1)
+	db.AddWorkoutMetric(ctx, wm2)
 
-	data, err := db.GetAllData()
+	data, err := db.GetAllData(ctx)
 	if err != nil {
 		t.Fatalf("GetAllData failed: %v", err)
 	}
@@ -530,6 +547,7 @@ func TestGetAllDataWithWorkoutMetrics(t *testing.T) {
 }
 
 func TestImportDataWithWorkoutMetrics(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -563,12 +581,12 @@ func TestImportDataWithWorkoutMetrics(t *testing.T) {
 		},
 	}
 
-	if err := db.ImportData(data); err != nil {
+	if err := db.ImportData(ctx, data); err != nil {
 		t.Fatalf("ImportData failed: %v", err)
 	}
 
 	// Verify workout was imported with metrics
-	w, err := db.GetWorkoutWithMetrics(workoutID.String())
+	w, err := db.GetWorkoutWithMetrics(ctx, workoutID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
 	}
@@ -579,19 +597,20 @@ func TestImportDataWithWorkoutMetrics(t *testing.T) {
 }
 
 func TestExportJSONWithWorkoutMetrics(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Create workout with metrics
 	w := models.NewWorkout("lift")
 	w.WithDuration(45)
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "sets", 4, "")
-	db.AddWorkoutMetric(wm)
+	db.AddWorkoutMetric(ctx, wm)
 
 	// Export
-	data, err := db.ExportJSON()
+	data, err := db.ExportJSON(ctx)
 	if err != nil {
 		t.Fatalf("ExportJSON failed: %v", err)
 	}
@@ -615,7 +634,7 @@ func TestExportMarkdownEmptyDB(t *testing.T) {
 	defer db.Close()
 
 	// Export with no data
-	md, err := db.ExportMarkdown(nil, nil)
+	md, err := db.ExportMarkdown(t.Context(), nil, nil)
 	if err != nil {
 		t.Fatalf("ExportMarkdown failed: %v", err)
 	}
@@ -630,7 +649,7 @@ func TestExportYAMLEmpty(t *testing.T) {
 	defer db.Close()
 
 	// Export with no data
-	data, err := db.ExportYAML()
+	data, err := db.ExportYAML(t.Context())
 	if err != nil {
 		t.Fatalf("ExportYAML failed: %v", err)
 	}
@@ -641,8 +660,8 @@ func TestExportYAMLEmpty(t *testing.T) {
 		t.Fatalf("Failed to parse YAML: %v", err)
 	}
 
-	if yamlData["version"] != "1.0" {
-		t.Errorf("Expected version 1.0, got %v", yamlData["version"])
+	if yamlData["version"] != "1.5" {
+		t.Errorf("Expected version 1.1, got %v", yamlData["version"])
 	}
 }
 
@@ -651,7 +670,7 @@ func TestExportJSONEmpty(t *testing.T) {
 	defer db.Close()
 
 	// Export with no data
-	data, err := db.ExportJSON()
+	data, err := db.ExportJSON(t.Context())
 	if err != nil {
 		t.Fatalf("ExportJSON failed: %v", err)
 	}
@@ -662,8 +681,8 @@ func TestExportJSONEmpty(t *testing.T) {
 		t.Fatalf("Failed to parse JSON: %v", err)
 	}
 
-	if export.Version != "1.0" {
-		t.Errorf("Expected version 1.0, got %s", export.Version)
+	if export.Version != "1.5" {
+		t.Errorf("Expected version 1.1, got %s", export.Version)
 	}
 	if len(export.Metrics) != 0 {
 		t.Errorf("Expected 0 metrics, got %d", len(export.Metrics))
@@ -674,6 +693,7 @@ func TestExportJSONEmpty(t *testing.T) {
 }
 
 func TestImportDataMultipleItems(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -715,17 +735,610 @@ func TestImportDataMultipleItems(t *testing.T) {
 		},
 	}
 
-	if err := db.ImportData(data); err != nil {
+	if err := db.ImportData(ctx, data); err != nil {
 		t.Fatalf("ImportData failed: %v", err)
 	}
 
-	metrics, _ := db.ListMetrics(nil, 0)
+	metrics, _ := db.ListMetrics(ctx, nil, 0)
 	if len(metrics) != 2 {
 		t.Errorf("Expected 2 metrics, got %d", len(metrics))
 	}
 
-	workouts, _ := db.ListWorkouts(nil, 0)
+	workouts, _ := db.ListWorkouts(ctx, nil, 0)
 	if len(workouts) != 2 {
 		t.Errorf("Expected 2 workouts, got %d", len(workouts))
 	}
 }
+
+func TestExportImportWorkoutSetsRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	w := models.NewWorkout("lift")
+	src.CreateWorkout(ctx, w)
+	src.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "bench", 1, 5).WithWeight(100, "kg"))
+	src.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "bench", 2, 5).WithWeight(100, "kg"))
+	src.AddWorkoutComment(ctx, models.NewWorkoutComment(w.ID, "Coach Sam", "Add a third set next week"))
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+
+	dst := setupTestDB(t)
+	defer dst.Close()
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+
+	sets, err := dst.ListWorkoutSets(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutSets failed: %v", err)
+	}
+	if len(sets) != 2 {
+		t.Fatalf("Expected 2 imported sets, got %d", len(sets))
+	}
+	if sets[1].Weight == nil || *sets[1].Weight != 100 {
+		t.Error("Expected set weight to survive export/import")
+	}
+	if comments, _ := dst.ListWorkoutComments(ctx, w.ID); len(comments) != 1 || comments[0].Author != "Coach Sam" {
+		t.Errorf("Expected comment to survive export/import, got %+v", comments)
+	}
+
+	yamlOut, err := ExportYAMLFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportYAMLFromRepo failed: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "exercise: bench") {
+		t.Error("Expected YAML export to include sets")
+	}
+	if !strings.Contains(string(yamlOut), "author: Coach Sam") {
+		t.Error("Expected YAML export to include comments")
+	}
+}
+
+func TestExportImportSleepSessionsRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	bed := time.Date(2024, 12, 13, 23, 0, 0, 0, time.UTC)
+	s := models.NewSleepSession(bed, bed.Add(7*time.Hour)).WithAwakenings(3)
+	if _, err := RecordSleepSession(ctx, src, s, models.SourceManual); err != nil {
+		t.Fatalf("RecordSleepSession failed: %v", err)
+	}
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+
+	dst := setupTestDB(t)
+	defer dst.Close()
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+
+	sessions, err := dst.ListSleepSessions(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListSleepSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Awakenings == nil || *sessions[0].Awakenings != 3 {
+		t.Fatalf("expected imported session with 3 awakenings, got %+v", sessions)
+	}
+	sleepType := models.MetricSleepHours
+	metrics, _ := dst.ListMetrics(ctx, &sleepType, 0)
+	if len(metrics) != 1 {
+		t.Errorf("expected derived metric imported once, got %d", len(metrics))
+	}
+
+	yamlOut, err := ExportYAMLFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportYAMLFromRepo failed: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "awakenings: 3") {
+		t.Error("expected YAML export to include sleep sessions")
+	}
+
+	md, err := ExportMarkdownFromRepo(ctx, src, nil, nil)
+	if err != nil {
+		t.Fatalf("ExportMarkdownFromRepo failed: %v", err)
+	}
+	if !strings.Contains(md, "## Sleep") {
+		t.Error("expected markdown export to include a Sleep section")
+	}
+}
+
+func TestExportImportMedicationsRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	m := models.NewMedication("Vitamin D", "1000 IU", "daily")
+	src.CreateMedication(ctx, m)
+	src.LogMedicationIntake(ctx, models.NewMedicationIntake(m.ID).WithTakenAt(time.Now().Add(-time.Hour)))
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+
+	dst := setupTestMarkdownStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+
+	meds, _ := dst.ListMedications(ctx)
+	intakes, _ := dst.ListMedicationIntakes(ctx, IntakeFilter{})
+	if len(meds) != 1 || len(intakes) != 1 {
+		t.Fatalf("expected 1 medication and 1 intake after import, got %d and %d", len(meds), len(intakes))
+	}
+
+	yamlOut, err := ExportYAMLFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportYAMLFromRepo failed: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "schedule: daily") || !strings.Contains(string(yamlOut), "intakes:") {
+		t.Error("expected YAML export to include medications with intakes")
+	}
+
+	md, err := ExportMarkdownFromRepo(ctx, src, nil, nil)
+	if err != nil {
+		t.Fatalf("ExportMarkdownFromRepo failed: %v", err)
+	}
+	if !strings.Contains(md, "## Medications") || !strings.Contains(md, "Vitamin D") {
+		t.Error("expected markdown export to include medication intakes")
+	}
+}
+
+func TestExportImportLocationsRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	src.CreateLocation(ctx, models.NewLocation("Hotel").WithCoordinates(48.85, 2.35))
+	src.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 81.5).WithLocation("Hotel"))
+	src.CreateWorkout(ctx, models.NewWorkout("run").WithLocation("48.85,2.35"))
+	trip, _ := models.NewTrip("Hotel").WithTimezone("Europe/Paris")
+	src.CreateTrip(ctx, trip)
+	src.CreateFast(ctx, models.NewFast(18))
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+
+	dst := setupTestMarkdownStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+
+	locs, _ := dst.ListLocations(ctx)
+	if len(locs) != 1 || locs[0].Latitude == nil {
+		t.Fatalf("expected 1 location with coordinates after import, got %d", len(locs))
+	}
+	metrics, _ := dst.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 1 || metrics[0].Location == nil || *metrics[0].Location != "Hotel" {
+		t.Error("expected metric location tag to survive import")
+	}
+	workouts, _ := dst.ListWorkouts(ctx, nil, 0)
+	if len(workouts) != 1 || workouts[0].Location == nil || *workouts[0].Location != "48.85,2.35" {
+		t.Error("expected workout coordinates tag to survive import")
+	}
+	trips, _ := dst.ListTrips(ctx, 0)
+	if len(trips) != 1 || trips[0].Timezone == nil || *trips[0].Timezone != "Europe/Paris" || !trips[0].IsActive() {
+		t.Error("expected active trip with timezone to survive import")
+	}
+	fasts, _ := dst.ListFasts(ctx, 0)
+	if len(fasts) != 1 || fasts[0].TargetHours != 18 || !fasts[0].IsActive() {
+		t.Error("expected running fast to survive import")
+	}
+
+	yamlOut, err := ExportYAMLFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportYAMLFromRepo failed: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "location: Hotel") || !strings.Contains(string(yamlOut), "locations:") {
+		t.Error("expected YAML export to include location tags and registry")
+	}
+	if !strings.Contains(string(yamlOut), "destination: Hotel") {
+		t.Error("expected YAML export to include trips")
+	}
+}
+
+func TestExportBloodPressureAsOneReading(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	sys, dia := models.NewBloodPressure(120, 80, at)
+	RecordBloodPressure(ctx, db, sys, dia)
+	// Legacy rows without a reading ID pair by timestamp
+	db.CreateMetric(ctx, models.NewMetric(models.MetricBPSys, 130).WithRecordedAt(at.AddDate(0, 0, -1)))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricBPDia, 85).WithRecordedAt(at.AddDate(0, 0, -1)))
+
+	yamlOut, err := ExportYAMLFromRepo(ctx, db)
+	if err != nil {
+		t.Fatalf("ExportYAMLFromRepo failed: %v", err)
+	}
+	out := string(yamlOut)
+	if !strings.Contains(out, "reading: 120/80") || !strings.Contains(out, "reading: 130/85") {
+		t.Errorf("expected YAML blood_pressure readings, got:\n%s", out)
+	}
+	if strings.Contains(out, "bp_sys:") || strings.Contains(out, "bp_dia:") {
+		t.Error("expected paired halves to be left out of the per-type metrics")
+	}
+
+	md, err := ExportMarkdownFromRepo(ctx, db, nil, nil)
+	if err != nil {
+		t.Fatalf("ExportMarkdownFromRepo failed: %v", err)
+	}
+	if !strings.Contains(md, "## bp\n") || !strings.Contains(md, "| 120/80 mmHg |") {
+		t.Errorf("expected markdown bp section with 120/80, got:\n%s", md)
+	}
+
+	// JSON keeps both halves with their link so imports stay lossless
+	exported, _ := ExportJSONFromRepo(ctx, db)
+	dst := setupTestMarkdownStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+	imported, _ := dst.GetMetric(ctx, dia.ID.String())
+	if imported == nil || imported.ReadingID == nil || *imported.ReadingID != *sys.ReadingID {
+		t.Error("expected reading ID to survive JSON round-trip")
+	}
+}
+
+func TestExportWithDerived(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 80).WithRecordedAt(at))
+
+	// Stand-in for a real formula: halve each weight.
+	derive := func(ms []*models.Metric) []*models.Metric {
+		var out []*models.Metric
+		for _, m := range ms {
+			if m.MetricType == models.MetricWeight {
+				d := models.NewMetric("half_weight", m.Value/2).WithRecordedAt(m.RecordedAt)
+				d.Unit = "kg"
+				out = append(out, d)
+			}
+		}
+		return out
+	}
+
+	exported, err := ExportJSONWithDerived(ctx, db, derive)
+	if err != nil {
+		t.Fatalf("ExportJSONWithDerived failed: %v", err)
+	}
+	if !strings.Contains(string(exported), `"derived"`) || !strings.Contains(string(exported), `"half_weight"`) {
+		t.Errorf("expected derived section in JSON, got:\n%s", exported)
+	}
+
+	yamlOut, err := ExportYAMLWithDerived(ctx, db, derive)
+	if err != nil {
+		t.Fatalf("ExportYAMLWithDerived failed: %v", err)
+	}
+	if !strings.Contains(string(yamlOut), "derived:\n    half_weight:") {
+		t.Errorf("expected derived section in YAML, got:\n%s", yamlOut)
+	}
+
+	halfWeight := models.MetricType("half_weight")
+	md, err := ExportMarkdownWithDerived(ctx, db, &halfWeight, nil, derive)
+	if err != nil {
+		t.Fatalf("ExportMarkdownWithDerived failed: %v", err)
+	}
+	if !strings.Contains(md, "## half_weight") || !strings.Contains(md, "| 40.00 kg |") {
+		t.Errorf("expected derived markdown section, got:\n%s", md)
+	}
+
+	// Derived values are not stored again on import
+	dst := setupTestMarkdownStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+	all, _ := dst.ListMetrics(ctx, nil, 0)
+	if len(all) != 1 {
+		t.Errorf("expected only the stored metric imported, got %d", len(all))
+	}
+}
+
+func TestExportEvents(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	e := models.NewEvent("Chicago Marathon", time.Date(2026, 10, 11, 0, 0, 0, 0, time.Local)).
+		WithWorkoutType("run").WithWeeklyKm(50)
+	src.CreateEvent(ctx, e)
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+	dst, _ := setupTestJSONLStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+	events, _ := dst.ListEvents(ctx)
+	if len(events) != 1 || events[0].WeeklyKm == nil || *events[0].WeeklyKm != 50 {
+		t.Fatalf("expected event with its plan to survive import, got %+v", events)
+	}
+
+	yamlOut, _ := ExportYAMLFromRepo(ctx, src)
+	if !strings.Contains(string(yamlOut), "name: Chicago Marathon") || !strings.Contains(string(yamlOut), "date: \"2026-10-11\"") {
+		t.Errorf("expected YAML events, got:\n%s", yamlOut)
+	}
+}
+
+func TestExportAppointments(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	at := time.Date(2025, 7, 2, 14, 0, 0, 0, time.UTC)
+	a := models.NewAppointment("Dr. Lee", at).WithLocation("Main St Clinic, Suite 4").WithReason("annual physical")
+	a.WithSummary("BP fine; recheck cholesterol")
+	src.CreateAppointment(ctx, a)
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+	dst := setupTestMarkdownStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+	appts, _ := dst.ListAppointments(ctx, AppointmentFilter{})
+	if len(appts) != 1 || appts[0].Summary == nil || *appts[0].Summary != "BP fine; recheck cholesterol" {
+		t.Fatalf("expected appointment with summary to survive import, got %+v", appts)
+	}
+
+	yamlOut, _ := ExportYAMLFromRepo(ctx, src)
+	if !strings.Contains(string(yamlOut), "provider: Dr. Lee") {
+		t.Errorf("expected YAML appointments, got:\n%s", yamlOut)
+	}
+	md, _ := ExportMarkdownFromRepo(ctx, src, nil, nil)
+	if !strings.Contains(md, "## Appointments") || !strings.Contains(md, "annual physical") {
+		t.Errorf("expected markdown appointments section, got:\n%s", md)
+	}
+
+	stamp := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ics := AppointmentsICS([]*models.Appointment{a}, stamp)
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:" + a.ID.String() + "@health\r\n",
+		"DTSTAMP:20250601T000000Z\r\n",
+		"DTSTART:20250702T140000Z\r\n",
+		"DTEND:20250702T143000Z\r\n",
+		"SUMMARY:Dr. Lee\r\n",
+		`LOCATION:Main St Clinic\, Suite 4` + "\r\n",
+		`DESCRIPTION:annual physical\n\nVisit summary: BP fine\; recheck cholesterol` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("expected ICS to contain %q, got:\n%s", want, ics)
+		}
+	}
+}
+
+func TestWorkoutEventICS(t *testing.T) {
+	w := models.NewWorkout("run").WithDuration(45).WithNotes("Easy pace, felt good").WithLocation("Lakefront")
+	w.StartedAt = time.Date(2025, 6, 1, 7, 0, 0, 0, time.UTC)
+	w.Metrics = []models.WorkoutMetric{*models.NewWorkoutMetric(w.ID, "distance", 8.5, "km")}
+
+	ics := WorkoutEventICS(w, time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC))
+	for _, want := range []string{
+		"PRODID:-//health//workouts//EN\r\n",
+		"UID:" + w.ID.String() + "@health\r\n",
+		"DTSTART:20250601T070000Z\r\n",
+		"DTEND:20250601T074500Z\r\n",
+		"SUMMARY:Workout: run\r\n",
+		"LOCATION:Lakefront\r\n",
+		`DESCRIPTION:distance: 8.5 km\nEasy pace\, felt good` + "\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("expected ICS to contain %q, got:\n%s", want, ics)
+		}
+	}
+	if strings.Count(ics, "BEGIN:VEVENT") != 1 {
+		t.Errorf("expected exactly one event, got:\n%s", ics)
+	}
+
+	// No duration: a point in time
+	if ics := WorkoutEventICS(models.NewWorkout("yoga"), time.Now()); strings.Contains(ics, "DTEND") {
+		t.Errorf("expected no DTEND without a duration, got:\n%s", ics)
+	}
+}
+
+func TestFoldICSLine(t *testing.T) {
+	long := "DESCRIPTION:" + strings.Repeat("é", 60)
+	folded := foldICSLine(long)
+	for _, line := range strings.Split(folded, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %d", len(line))
+		}
+	}
+	if strings.ReplaceAll(folded, "\r\n ", "") != long {
+		t.Error("unfolding should give back the original line")
+	}
+}
+
+func TestExportParquet(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	m := models.NewMetric(models.MetricWeight, 82.5).WithNotes("after run")
+	db.CreateMetric(ctx, m)
+	w := models.NewWorkout("swim").WithDuration(30)
+	db.CreateWorkout(ctx, w)
+
+	derived := models.NewMetric("bmi", 24.1)
+	derive := func([]*models.Metric) []*models.Metric { return []*models.Metric{derived} }
+
+	var metrics, workouts bytes.Buffer
+	if err := ExportParquet(ctx, db, derive, &metrics, &workouts); err != nil {
+		t.Fatalf("ExportParquet failed: %v", err)
+	}
+	for name, buf := range map[string]*bytes.Buffer{"metrics": &metrics, "workouts": &workouts} {
+		data := buf.Bytes()
+		if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+			t.Fatalf("%s: not a parquet file", name)
+		}
+		if !bytes.Contains(data, []byte("health.schema_version")) || !bytes.Contains(data, []byte(name)) {
+			t.Errorf("%s: footer missing table metadata", name)
+		}
+	}
+	for _, want := range []string{m.ID.String(), derived.ID.String(), "after run", "derived"} {
+		if !bytes.Contains(metrics.Bytes(), []byte(want)) {
+			t.Errorf("metrics table missing %q", want)
+		}
+	}
+	if !bytes.Contains(workouts.Bytes(), []byte(w.ID.String())) || !bytes.Contains(workouts.Bytes(), []byte("duration_minutes")) {
+		t.Error("workouts table missing the workout or its columns")
+	}
+}
+
+func TestImportChecksFormatVersion(t *testing.T) {
+	ctx := t.Context()
+
+	tests := []struct {
+		header string
+		ok     bool
+	}{
+		{`{}`, true}, // predates versioning
+		{`{"version": "1.0"}`, true},
+		{`{"version": "1.9", "future_field": [1, 2]}`, true},
+		{`{"version": "1.9", "min_reader_version": "1.0"}`, true},
+		{`{"version": "1.9", "min_reader_version": "1.8"}`, false},
+		{`{"version": "2.0"}`, false},
+		{`{"version": "two"}`, false},
+	}
+	for _, tt := range tests {
+		db := setupTestDB(t)
+		err := ImportJSONToRepo(ctx, db, []byte(tt.header))
+		db.Close()
+		if (err == nil) != tt.ok {
+			t.Errorf("import %s: err = %v, want ok = %v", tt.header, err, tt.ok)
+		}
+	}
+}
+
+func TestExportImportSourceAndMetadataRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	src.CreateMetric(ctx, models.NewMetric(models.MetricHRV, 48).WithSource(models.SourceOura).WithMetadata("oura_day", "2025-03-02"))
+	src.CreateWorkout(ctx, models.NewWorkout("run").WithSource(models.SourceManual).WithMetadata("gpx", "runs/1.gpx"))
+
+	exported, err := ExportJSONFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportJSONFromRepo failed: %v", err)
+	}
+	dst := setupTestMarkdownStore(t)
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+
+	metrics, _ := dst.ListMetrics(ctx, nil, 0)
+	if len(metrics) != 1 || metrics[0].Source != models.SourceOura || metrics[0].Metadata["oura_day"] != "2025-03-02" {
+		t.Errorf("expected the oura source and metadata to survive import, got %+v", metrics)
+	}
+	workouts, _ := dst.ListWorkouts(ctx, nil, 0)
+	if len(workouts) != 1 || workouts[0].Source != models.SourceManual || workouts[0].Metadata["gpx"] != "runs/1.gpx" {
+		t.Errorf("expected the manual source and metadata to survive import, got %+v", workouts)
+	}
+
+	data, err := ExportYAMLFromRepo(ctx, src)
+	if err != nil {
+		t.Fatalf("ExportYAMLFromRepo failed: %v", err)
+	}
+	if !strings.Contains(string(data), "source: oura") || !strings.Contains(string(data), "gpx: runs/1.gpx") {
+		t.Errorf("expected the source and metadata in the YAML export, got:\n%s", data)
+	}
+}
+
+func TestExportJSONAnonymized(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+	defer src.Close()
+
+	at := time.Date(2025, 3, 14, 7, 42, 13, 0, time.Local)
+	weight := models.NewMetric(models.MetricWeight, 82.5).WithRecordedAt(at).
+		WithNotes("after the party").WithLocation("Mom's house").WithMetadata("device", "Scale SN 12345")
+	src.CreateMetric(ctx, weight)
+	sys, dia := models.NewBloodPressure(120, 80, at)
+	RecordBloodPressure(ctx, src, sys, dia)
+	w := models.NewWorkout("run").WithStartedAt(at).WithNotes("with Alex")
+	src.CreateWorkout(ctx, w)
+	src.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+	src.AddWorkoutComment(ctx, models.NewWorkoutComment(w.ID, "Coach Sam", "Nice pacing"))
+	src.CreateLocation(ctx, models.NewLocation("home"))
+	src.CreateAppointment(ctx, models.NewAppointment("Dr. Lee", at))
+
+	exported, err := ExportJSONAnonymized(ctx, src, nil)
+	if err != nil {
+		t.Fatalf("ExportJSONAnonymized failed: %v", err)
+	}
+	for _, secret := range []string{"party", "Mom", "SN 12345", "Alex", "Coach Sam", "home", "Dr. Lee", weight.ID.String(), w.ID.String(), "07:42"} {
+		if strings.Contains(string(exported), secret) {
+			t.Errorf("anonymized export contains %q", secret)
+		}
+	}
+
+	var data ExportData
+	if err := json.Unmarshal(exported, &data); err != nil {
+		t.Fatalf("anonymized export isn't JSON: %v", err)
+	}
+	day := time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)
+	for _, m := range data.Metrics {
+		if !m.RecordedAt.Equal(day) {
+			t.Errorf("%s recorded at %v, want %v", m.MetricType, m.RecordedAt, day)
+		}
+	}
+	if len(data.Workouts) != 1 || len(data.Workouts[0].Metrics) != 1 || data.Workouts[0].Metrics[0].WorkoutID != data.Workouts[0].ID {
+		t.Errorf("expected the workout to keep its metric under its new ID, got %+v", data.Workouts)
+	}
+
+	// The copy imports, with the blood pressure halves still paired
+	dst := setupTestDB(t)
+	defer dst.Close()
+	if err := ImportJSONToRepo(ctx, dst, exported); err != nil {
+		t.Fatalf("ImportJSONToRepo failed: %v", err)
+	}
+	bpSys := models.MetricBPSys
+	got, _ := dst.ListMetrics(ctx, &bpSys, 1)
+	if len(got) != 1 {
+		t.Fatalf("expected the bp_sys half imported, got %d", len(got))
+	}
+	if partner, err := ReadingPartner(ctx, dst, got[0]); err != nil || partner == nil || partner.Value != 80 {
+		t.Errorf("ReadingPartner after import = %+v, %v; want the diastolic half", partner, err)
+	}
+}
+
+func TestFilterExportDataKeepsHeader(t *testing.T) {
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := &ExportData{
+		Version:          "1.3",
+		MinReaderVersion: "1.2",
+		Tool:             "health",
+		Metrics: []*models.Metric{
+			models.NewMetric(models.MetricWeight, 82).WithRecordedAt(since.AddDate(0, 0, -1)),
+			models.NewMetric(models.MetricWeight, 81).WithRecordedAt(since.AddDate(0, 0, 1)),
+		},
+		Medications: []*models.Medication{models.NewMedication("Magnesium", "200 mg", "daily")},
+	}
+
+	out := filterExportData(data, ExportFilter{Since: &since})
+	if out.Version != "1.3" || out.MinReaderVersion != "1.2" || out.Tool != "health" {
+		t.Errorf("header = %q %q %q, want it kept", out.Version, out.MinReaderVersion, out.Tool)
+	}
+	if len(out.Metrics) != 1 || out.Metrics[0].Value != 81 || len(out.Medications) != 1 {
+		t.Errorf("filtered export = %+v", out)
+	}
+}
@@ -0,0 +1,199 @@
+// ABOUTME: Fast CRUD operations for SQLite storage and the active-fast helper.
+// ABOUTME: Fasts are open-ended until ended, like trips.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateFast stores a new fast in the database.
+func (d *DB) CreateFast(ctx context.Context, f *models.Fast) error {
+	query := `
+		INSERT INTO fasts (id, started_at, ended_at, target_hours, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	var endedAt *string
+	if f.EndedAt != nil {
+		s := f.EndedAt.UTC().Format(time.RFC3339)
+		endedAt = &s
+	}
+	_, err := d.db.ExecContext(ctx, query,
+		f.ID.String(),
+		f.StartedAt.UTC().Format(time.RFC3339),
+		endedAt,
+		f.TargetHours,
+		f.Notes,
+		f.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create fast: %w", err)
+	}
+	return nil
+}
+
+// GetFast retrieves a fast by ID or ID prefix.
+func (d *DB) GetFast(ctx context.Context, idOrPrefix string) (*models.Fast, error) {
+	id, err := d.resolveFastID(ctx, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, started_at, ended_at, target_hours, notes, created_at
+		FROM fasts
+		WHERE id = ?
+	`
+	f, err := scanFast(d.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("not found")
+	}
+	return f, err
+}
+
+// ListFasts retrieves fasts sorted by StartedAt descending.
+func (d *DB) ListFasts(ctx context.Context, limit int) ([]*models.Fast, error) {
+	query := `
+		SELECT id, started_at, ended_at, target_hours, notes, created_at
+		FROM fasts
+		ORDER BY started_at DESC
+	`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list fasts: %w", err)
+	}
+	defer rows.Close()
+
+	var fasts []*models.Fast
+	for rows.Next() {
+		f, err := scanFast(rows)
+		if err != nil {
+			return nil, err
+		}
+		fasts = append(fasts, f)
+	}
+	return fasts, rows.Err()
+}
+
+// EndFast records when a fast ended.
+func (d *DB) EndFast(ctx context.Context, idOrPrefix string, endedAt time.Time) error {
+	id, err := d.resolveFastID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("end fast: %w", err)
+	}
+
+	if _, err := d.db.ExecContext(ctx, "UPDATE fasts SET ended_at = ? WHERE id = ?", endedAt.UTC().Format(time.RFC3339), id); err != nil {
+		return fmt.Errorf("end fast: %w", err)
+	}
+	return nil
+}
+
+// DeleteFast removes a fast by ID or prefix.
+func (d *DB) DeleteFast(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveFastID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete fast: %w", err)
+	}
+
+	result, err := d.db.ExecContext(ctx, "DELETE FROM fasts WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete fast: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete fast: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("not found: %s", idOrPrefix)
+	}
+
+	return nil
+}
+
+// resolveFastID finds the full ID from a prefix.
+func (d *DB) resolveFastID(ctx context.Context, idOrPrefix string) (string, error) {
+	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
+		return idOrPrefix, nil
+	}
+
+	query := `SELECT id FROM fasts WHERE id LIKE ? || '%'`
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
+	if err != nil {
+		return "", fmt.Errorf("resolve fast ID: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan fast ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+	}
+
+	return matches[0], nil
+}
+
+// scanFast scans a row from either QueryRow or Query into a Fast.
+func scanFast(row interface{ Scan(dest ...any) error }) (*models.Fast, error) {
+	var f models.Fast
+	var idStr, startedAt, createdAt string
+	var endedAt, notes sql.NullString
+
+	err := row.Scan(&idStr, &startedAt, &endedAt, &f.TargetHours, &notes, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan fast: %w", err)
+	}
+
+	f.ID, _ = uuid.Parse(idStr)
+	f.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	f.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if endedAt.Valid {
+		if e, err := time.Parse(time.RFC3339, endedAt.String); err == nil {
+			f.EndedAt = &e
+		}
+	}
+	if notes.Valid {
+		f.Notes = &notes.String
+	}
+
+	return &f, nil
+}
+
+// ActiveFast returns the fast that has been started but not ended, or nil.
+func ActiveFast(ctx context.Context, r Repository) (*models.Fast, error) {
+	fasts, err := r.ListFasts(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fasts {
+		if f.IsActive() {
+			return f, nil
+		}
+	}
+	return nil, nil
+}
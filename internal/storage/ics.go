@@ -0,0 +1,167 @@
+// ABOUTME: iCalendar (.ics) export of appointments, and single-event calendars for CalDAV.
+// ABOUTME: Produces an RFC 5545 calendar that calendar apps can import or subscribe to.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// icsTime formats a time as an iCalendar UTC date-time.
+const icsTime = "20060102T150405Z"
+
+// ExportICSFromRepo exports every appointment as an iCalendar file.
+func ExportICSFromRepo(ctx context.Context, r Repository) ([]byte, error) {
+	appts, err := r.ListAppointments(ctx, AppointmentFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return []byte(AppointmentsICS(appts, time.Now())), nil
+}
+
+// AppointmentsICS renders appointments as an iCalendar document. stamp is
+// written as each event's DTSTAMP.
+func AppointmentsICS(appts []*models.Appointment, stamp time.Time) string {
+	var c icsCalendar
+	c.begin("appointments")
+	for _, a := range appts {
+		c.appointment(a, stamp)
+	}
+	return c.end()
+}
+
+// AppointmentEventICS renders one appointment as a calendar holding a single
+// event, the form CalDAV servers store.
+func AppointmentEventICS(a *models.Appointment, stamp time.Time) string {
+	var c icsCalendar
+	c.begin("appointments")
+	c.appointment(a, stamp)
+	return c.end()
+}
+
+// WorkoutEventICS renders one workout as a calendar holding a single event.
+// Workout metrics, if loaded, are listed in the description.
+func WorkoutEventICS(w *models.Workout, stamp time.Time) string {
+	var c icsCalendar
+	c.begin("workouts")
+	c.workout(w, stamp)
+	return c.end()
+}
+
+// icsCalendar accumulates folded, CRLF-terminated content lines.
+type icsCalendar struct {
+	sb strings.Builder
+}
+
+func (c *icsCalendar) line(s string) {
+	c.sb.WriteString(foldICSLine(s))
+	c.sb.WriteString("\r\n")
+}
+
+func (c *icsCalendar) begin(product string) {
+	c.line("BEGIN:VCALENDAR")
+	c.line("VERSION:2.0")
+	c.line("PRODID:-//health//" + product + "//EN")
+	c.line("CALSCALE:GREGORIAN")
+}
+
+func (c *icsCalendar) end() string {
+	c.line("END:VCALENDAR")
+	return c.sb.String()
+}
+
+func (c *icsCalendar) appointment(a *models.Appointment, stamp time.Time) {
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + a.ID.String() + "@health")
+	c.line("DTSTAMP:" + stamp.UTC().Format(icsTime))
+	c.line("DTSTART:" + a.ScheduledAt.UTC().Format(icsTime))
+	c.line("DTEND:" + a.EndsAt().UTC().Format(icsTime))
+	c.line("SUMMARY:" + escapeICSText(a.Provider))
+	if a.Location != nil {
+		c.line("LOCATION:" + escapeICSText(*a.Location))
+	}
+
+	var desc []string
+	if a.Reason != nil {
+		desc = append(desc, *a.Reason)
+	}
+	if a.Summary != nil {
+		desc = append(desc, "Visit summary: "+*a.Summary)
+	}
+	if len(desc) > 0 {
+		c.line("DESCRIPTION:" + escapeICSText(strings.Join(desc, "\n\n")))
+	}
+	c.line("END:VEVENT")
+}
+
+func (c *icsCalendar) workout(w *models.Workout, stamp time.Time) {
+	c.line("BEGIN:VEVENT")
+	c.line("UID:" + w.ID.String() + "@health")
+	c.line("DTSTAMP:" + stamp.UTC().Format(icsTime))
+	c.line("DTSTART:" + w.StartedAt.UTC().Format(icsTime))
+	// Without a duration the event is a point in time (RFC 5545 3.6.1)
+	if w.DurationMinutes != nil {
+		end := w.StartedAt.Add(time.Duration(*w.DurationMinutes) * time.Minute)
+		c.line("DTEND:" + end.UTC().Format(icsTime))
+	}
+	c.line("SUMMARY:" + escapeICSText("Workout: "+w.WorkoutType))
+	if w.Location != nil {
+		c.line("LOCATION:" + escapeICSText(*w.Location))
+	}
+	c.line("CATEGORIES:Workout")
+
+	var desc []string
+	for _, wm := range w.Metrics {
+		entry := fmt.Sprintf("%s: %s", wm.MetricName, strconv.FormatFloat(wm.Value, 'f', -1, 64))
+		if wm.Unit != nil {
+			entry += " " + *wm.Unit
+		}
+		desc = append(desc, entry)
+	}
+	if w.Notes != nil {
+		desc = append(desc, *w.Notes)
+	}
+	if len(desc) > 0 {
+		c.line("DESCRIPTION:" + escapeICSText(strings.Join(desc, "\n")))
+	}
+	c.line("END:VEVENT")
+}
+
+// escapeICSText escapes a TEXT value per RFC 5545 section 3.3.11.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// foldICSLine splits content lines longer than 75 octets, continuing each
+// part on a new line that starts with a space. It never splits a UTF-8
+// character.
+func foldICSLine(s string) string {
+	const limit = 75
+	if len(s) <= limit {
+		return s
+	}
+
+	var sb strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > limit {
+			sb.WriteString("\r\n ")
+			width = 1
+		}
+		sb.WriteRune(r)
+		width += n
+	}
+	return sb.String()
+}
@@ -0,0 +1,74 @@
+// ABOUTME: Maps metrics and workouts to InfluxDB points for 'health push influx'.
+// ABOUTME: One health_metric point per metric and one health_workout point per workout.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/harperreed/health/internal/influx"
+	"github.com/harperreed/health/internal/models"
+)
+
+// MetricInfluxPoint maps a metric to a health_metric point tagged with its
+// type, unit, and location.
+func MetricInfluxPoint(m *models.Metric) influx.Point {
+	tags := map[string]string{"type": string(m.MetricType), "unit": m.Unit}
+	if m.Location != nil {
+		tags["location"] = *m.Location
+	}
+	return influx.Point{
+		Measurement: "health_metric",
+		Tags:        tags,
+		Fields:      map[string]float64{"value": m.Value},
+		Time:        m.RecordedAt,
+	}
+}
+
+// WorkoutInfluxPoint maps a workout to a health_workout point at its start,
+// tagged with its type and location. Its duration and each workout metric
+// (by name; the last wins if a name repeats) are fields.
+func WorkoutInfluxPoint(w *models.Workout) influx.Point {
+	tags := map[string]string{"workout_type": w.WorkoutType}
+	if w.Location != nil {
+		tags["location"] = *w.Location
+	}
+	fields := make(map[string]float64)
+	if w.DurationMinutes != nil {
+		fields["duration_minutes"] = float64(*w.DurationMinutes)
+	}
+	for _, wm := range w.Metrics {
+		fields[wm.MetricName] = wm.Value
+	}
+	return influx.Point{Measurement: "health_workout", Tags: tags, Fields: fields, Time: w.StartedAt}
+}
+
+// InfluxPoints maps every metric and workout recorded since since (or all
+// of them when nil) to points.
+func InfluxPoints(ctx context.Context, r Repository, since *time.Time) ([]influx.Point, error) {
+	metrics, err := r.QueryMetrics(ctx, MetricFilter{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("list metrics: %w", err)
+	}
+	workouts, err := r.QueryWorkouts(ctx, WorkoutFilter{Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("list workouts: %w", err)
+	}
+
+	points := make([]influx.Point, 0, len(metrics)+len(workouts))
+	for _, m := range metrics {
+		points = append(points, MetricInfluxPoint(m))
+	}
+	for _, w := range workouts {
+		wms, err := r.ListWorkoutMetrics(ctx, w.ID)
+		if err != nil {
+			return nil, fmt.Errorf("list metrics of workout %s: %w", w.ID, err)
+		}
+		for _, wm := range wms {
+			w.Metrics = append(w.Metrics, *wm)
+		}
+		points = append(points, WorkoutInfluxPoint(w))
+	}
+	return points, nil
+}
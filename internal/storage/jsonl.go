@@ -0,0 +1,1435 @@
+// ABOUTME: JSONLStore keeps all health data in one append-only JSON Lines file.
+// ABOUTME: The file is replayed into in-memory indexes on open; every change appends one line.
+// ABOUTME: The same engine runs on any RecordLog, such as a database table, or on none (MemoryStore).
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// Record kinds in a JSONL store. Workout children get their own lines so
+// adding a set doesn't rewrite the whole workout.
+const (
+	kindMetric         = "metric"
+	kindWorkout        = "workout"
+	kindWorkoutMetric  = "workout_metric"
+	kindWorkoutSet     = "workout_set"
+	kindWorkoutComment = "workout_comment"
+	kindSleep          = "sleep"
+	kindMedication     = "medication"
+	kindIntake         = "intake"
+	kindLocation       = "location"
+	kindTrip           = "trip"
+	kindFast           = "fast"
+	kindAppointment    = "appointment"
+	kindEvent          = "event"
+	kindReminder       = "reminder"
+	kindReminderSnooze = "reminder_snooze"
+)
+
+// jsonlRecord is one line of the file. A put replaces the record with the
+// same kind and ID; a delete removes it, along with anything it owns.
+type jsonlRecord struct {
+	Op   string          `json:"op"`
+	Kind string          `json:"kind"`
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// JSONLStore provides single-file storage for health data. All records are
+// held in memory; the file is the log of changes that rebuilds them.
+type JSONLStore struct {
+	path string    // the JSONL file; empty when the log is kept elsewhere
+	log  RecordLog // nil in memory: records are applied and never written
+
+	mu           sync.Mutex // with the log's lock, serializes writers; see lock
+	lines        int        // records in the log
+	metrics      map[uuid.UUID]*models.Metric
+	workouts     map[uuid.UUID]*models.Workout
+	sleep        map[uuid.UUID]*models.SleepSession
+	medications  map[uuid.UUID]*models.Medication
+	intakes      map[uuid.UUID]*models.MedicationIntake
+	locations    map[uuid.UUID]*models.Location
+	trips        map[uuid.UUID]*models.Trip
+	fasts        map[uuid.UUID]*models.Fast
+	appointments map[uuid.UUID]*models.Appointment
+	events       map[uuid.UUID]*models.Event
+	reminders    map[string]time.Time
+	snoozes      map[string]time.Time
+}
+
+// Compile-time check that JSONLStore implements Repository.
+var _ Repository = (*JSONLStore)(nil)
+
+// OpenJSONL opens or creates a JSONL store at path. When the file holds
+// many more lines than live records (from deletes and updates), it is
+// rewritten with one line per record first.
+func OpenJSONL(path string) (*JSONLStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("create data directory: %w", err)
+	}
+
+	s := newJSONLStore(&fileLog{path: path})
+	s.path = path
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OpenRecordLog opens a store whose records are kept in log rather than a
+// file, replaying them into memory and compacting the log as OpenJSONL
+// does. Backends in other packages use it with a log of their own.
+func OpenRecordLog(log RecordLog) (*JSONLStore, error) {
+	s := newJSONLStore(log)
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// newJSONLStore returns a store on log with empty indexes.
+func newJSONLStore(log RecordLog) *JSONLStore {
+	s := &JSONLStore{log: log}
+	s.reset()
+	return s
+}
+
+// open replays the log, then compacts it if it holds many more records
+// than live entries.
+func (s *JSONLStore) open() error {
+	unlock, err := s.log.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = s.refresh()
+	if err == nil && s.lines > 2*s.liveRecords()+100 {
+		err = s.compact()
+	}
+	if err != nil {
+		s.log.Close()
+		return err
+	}
+	return nil
+}
+
+// reset empties the indexes, before replaying the log from the start.
+func (s *JSONLStore) reset() {
+	s.lines = 0
+	s.metrics = make(map[uuid.UUID]*models.Metric)
+	s.workouts = make(map[uuid.UUID]*models.Workout)
+	s.sleep = make(map[uuid.UUID]*models.SleepSession)
+	s.medications = make(map[uuid.UUID]*models.Medication)
+	s.intakes = make(map[uuid.UUID]*models.MedicationIntake)
+	s.locations = make(map[uuid.UUID]*models.Location)
+	s.trips = make(map[uuid.UUID]*models.Trip)
+	s.fasts = make(map[uuid.UUID]*models.Fast)
+	s.appointments = make(map[uuid.UUID]*models.Appointment)
+	s.events = make(map[uuid.UUID]*models.Event)
+	s.reminders = make(map[string]time.Time)
+	s.snoozes = make(map[string]time.Time)
+}
+
+// Close flushes and closes the log.
+func (s *JSONLStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	return s.log.Close()
+}
+
+// liveRecords counts the lines a compacted file would have.
+func (s *JSONLStore) liveRecords() int {
+	return len(s.metrics) + len(s.workouts) + len(s.sleep) + len(s.medications) +
+		len(s.intakes) + len(s.locations) + len(s.trips) + len(s.fasts) + len(s.appointments) + len(s.events) + len(s.reminders) + len(s.snoozes)
+}
+
+// compact rewrites the log with one put per live record, workouts
+// carrying their children. The caller holds the log's lock, so no other
+// process is appending; the others replay the rewritten log before their
+// next write (see refresh).
+func (s *JSONLStore) compact() error {
+	var records [][]byte
+	put := func(kind, id string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		rec, err := json.Marshal(jsonlRecord{Op: "put", Kind: kind, ID: id, Data: data})
+		if err != nil {
+			return err
+		}
+		records = append(records, rec)
+		return nil
+	}
+
+	var err error
+	for _, l := range s.listLocations() {
+		err = errors.Join(err, put(kindLocation, l.ID.String(), l))
+	}
+	for _, m := range s.listMedications() {
+		err = errors.Join(err, put(kindMedication, m.ID.String(), m))
+	}
+	for _, in := range s.listIntakes(IntakeFilter{}) {
+		err = errors.Join(err, put(kindIntake, in.ID.String(), in))
+	}
+	for _, m := range s.queryMetrics(MetricFilter{}) {
+		err = errors.Join(err, put(kindMetric, m.ID.String(), m))
+	}
+	for _, w := range s.queryWorkouts(WorkoutFilter{}) {
+		err = errors.Join(err, put(kindWorkout, w.ID.String(), s.workouts[w.ID]))
+	}
+	for _, ss := range s.listSleep(0) {
+		err = errors.Join(err, put(kindSleep, ss.ID.String(), ss))
+	}
+	for _, t := range s.listTrips(0) {
+		err = errors.Join(err, put(kindTrip, t.ID.String(), t))
+	}
+	for _, f := range s.listFasts(0) {
+		err = errors.Join(err, put(kindFast, f.ID.String(), f))
+	}
+	for _, a := range s.listAppointments(AppointmentFilter{}) {
+		err = errors.Join(err, put(kindAppointment, a.ID.String(), a))
+	}
+	for _, e := range s.listEvents() {
+		err = errors.Join(err, put(kindEvent, e.ID.String(), e))
+	}
+	for _, state := range []struct {
+		kind  string
+		times map[string]time.Time
+	}{{kindReminder, s.reminders}, {kindReminderSnooze, s.snoozes}} {
+		keys := make([]string, 0, len(state.times))
+		for k := range state.times {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			err = errors.Join(err, put(state.kind, k, state.times[k]))
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("compact jsonl store: %w", err)
+	}
+
+	if err := s.log.Rewrite(records); err != nil {
+		return fmt.Errorf("compact jsonl store: %w", err)
+	}
+	s.lines = len(records)
+	return nil
+}
+
+// write appends a record to the log and applies it in memory.
+func (s *JSONLStore) write(op, kind, id string, v any) error {
+	rec := jsonlRecord{Op: op, Kind: kind, ID: id}
+	if v != nil {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("marshal %s: %w", kind, err)
+		}
+		rec.Data = data
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal %s: %w", kind, err)
+	}
+	if s.log == nil {
+		return s.apply(&rec)
+	}
+	if err := s.log.Append(line); err != nil {
+		return fmt.Errorf("write %s: %w", kind, err)
+	}
+	s.lines++
+	return s.apply(&rec)
+}
+
+// putRecord decodes data into a new T and stores it under id.
+func putRecord[T any](items map[uuid.UUID]*T, id uuid.UUID, data json.RawMessage) error {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	items[id] = &v
+	return nil
+}
+
+// apply replays one record against the in-memory indexes.
+func (s *JSONLStore) apply(rec *jsonlRecord) error {
+	if rec.Kind == kindReminder || rec.Kind == kindReminderSnooze {
+		times := s.reminders
+		if rec.Kind == kindReminderSnooze {
+			times = s.snoozes
+		}
+		if rec.Op == "delete" {
+			delete(times, rec.ID)
+			return nil
+		}
+		var at time.Time
+		if err := json.Unmarshal(rec.Data, &at); err != nil {
+			return err
+		}
+		times[rec.ID] = at
+		return nil
+	}
+
+	id, err := uuid.Parse(rec.ID)
+	if err != nil {
+		return fmt.Errorf("parse %s ID %q: %w", rec.Kind, rec.ID, err)
+	}
+
+	if rec.Op == "delete" {
+		switch rec.Kind {
+		case kindMetric:
+			delete(s.metrics, id)
+		case kindWorkout:
+			delete(s.workouts, id)
+		case kindWorkoutMetric:
+			for _, w := range s.workouts {
+				w.Metrics = slices.DeleteFunc(w.Metrics, func(wm models.WorkoutMetric) bool { return wm.ID == id })
+			}
+		case kindSleep:
+			delete(s.sleep, id)
+		case kindMedication:
+			delete(s.medications, id)
+			for inID, in := range s.intakes {
+				if in.MedicationID == id {
+					delete(s.intakes, inID)
+				}
+			}
+		case kindLocation:
+			delete(s.locations, id)
+		case kindTrip:
+			delete(s.trips, id)
+		case kindFast:
+			delete(s.fasts, id)
+		case kindAppointment:
+			delete(s.appointments, id)
+		case kindEvent:
+			delete(s.events, id)
+		default:
+			return fmt.Errorf("cannot delete %s records", rec.Kind)
+		}
+		return nil
+	}
+	if rec.Op != "put" {
+		return fmt.Errorf("unknown op %q", rec.Op)
+	}
+
+	switch rec.Kind {
+	case kindMetric:
+		return putRecord(s.metrics, id, rec.Data)
+	case kindWorkout:
+		return putRecord(s.workouts, id, rec.Data)
+	case kindWorkoutMetric:
+		var wm models.WorkoutMetric
+		if err := json.Unmarshal(rec.Data, &wm); err != nil {
+			return err
+		}
+		if w := s.workouts[wm.WorkoutID]; w != nil {
+			// A put for a metric already there is an update
+			if i := slices.IndexFunc(w.Metrics, func(m models.WorkoutMetric) bool { return m.ID == wm.ID }); i >= 0 {
+				w.Metrics[i] = wm
+			} else {
+				w.Metrics = append(w.Metrics, wm)
+			}
+		}
+	case kindWorkoutSet:
+		var ws models.WorkoutSet
+		if err := json.Unmarshal(rec.Data, &ws); err != nil {
+			return err
+		}
+		if w := s.workouts[ws.WorkoutID]; w != nil {
+			w.Sets = append(w.Sets, ws)
+		}
+	case kindWorkoutComment:
+		var c models.WorkoutComment
+		if err := json.Unmarshal(rec.Data, &c); err != nil {
+			return err
+		}
+		if w := s.workouts[c.WorkoutID]; w != nil {
+			w.Comments = append(w.Comments, c)
+		}
+	case kindSleep:
+		return putRecord(s.sleep, id, rec.Data)
+	case kindMedication:
+		return putRecord(s.medications, id, rec.Data)
+	case kindIntake:
+		return putRecord(s.intakes, id, rec.Data)
+	case kindLocation:
+		return putRecord(s.locations, id, rec.Data)
+	case kindTrip:
+		return putRecord(s.trips, id, rec.Data)
+	case kindFast:
+		return putRecord(s.fasts, id, rec.Data)
+	case kindAppointment:
+		return putRecord(s.appointments, id, rec.Data)
+	case kindEvent:
+		return putRecord(s.events, id, rec.Data)
+	default:
+		return fmt.Errorf("unknown record kind %q", rec.Kind)
+	}
+	return nil
+}
+
+// findByPrefix looks up a record by full ID or unique ID prefix.
+func findByPrefix[T any](items map[uuid.UUID]*T, idOrPrefix string) (*T, error) {
+	if len(idOrPrefix) == 36 {
+		if id, err := uuid.Parse(idOrPrefix); err == nil {
+			if v, ok := items[id]; ok {
+				return v, nil
+			}
+			return nil, fmt.Errorf("not found: %s", idOrPrefix)
+		}
+	}
+
+	var match *T
+	for id, v := range items {
+		if strings.HasPrefix(id.String(), idOrPrefix) {
+			if match != nil {
+				return nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+			}
+			match = v
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	return match, nil
+}
+
+// clone returns a shallow copy so callers can't change the stored record.
+func clone[T any](v *T) *T {
+	c := *v
+	return &c
+}
+
+// cloneWorkout copies a workout, with copies of its children when
+// withChildren is set and without them otherwise.
+func cloneWorkout(w *models.Workout, withChildren bool) *models.Workout {
+	c := *w
+	c.Metrics, c.Sets, c.Comments = nil, nil, nil
+	if withChildren {
+		c.Metrics = slices.Clone(w.Metrics)
+		c.Sets = slices.Clone(w.Sets)
+		c.Comments = slices.Clone(w.Comments)
+	}
+	return &c
+}
+
+// newestFirst orders records by time descending, breaking ties by ID so
+// paging through equal timestamps is stable.
+func newestFirst[T any](items []*T, at func(*T) time.Time, id func(*T) uuid.UUID) {
+	sort.Slice(items, func(i, j int) bool {
+		a, b := at(items[i]), at(items[j])
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return id(items[i]).String() < id(items[j]).String()
+	})
+}
+
+// --- Metrics ---
+
+// CreateMetric stores a new metric.
+func (s *JSONLStore) CreateMetric(ctx context.Context, m *models.Metric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindMetric, m.ID.String(), m)
+}
+
+// CreateMetrics stores many metrics under a single lock.
+func (s *JSONLStore) CreateMetrics(ctx context.Context, metrics []*models.Metric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, m := range metrics {
+		if err := s.write("put", kindMetric, m.ID.String(), m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMetric retrieves a metric by ID or ID prefix.
+func (s *JSONLStore) GetMetric(ctx context.Context, idOrPrefix string) (*models.Metric, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, err := findByPrefix(s.metrics, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(m), nil
+}
+
+// ListMetrics retrieves metrics with optional filtering by type.
+// Results are sorted by RecordedAt descending (most recent first).
+func (s *JSONLStore) ListMetrics(ctx context.Context, metricType *models.MetricType, limit int) ([]*models.Metric, error) {
+	return s.QueryMetrics(ctx, MetricFilter{Type: metricType, Limit: limit})
+}
+
+// QueryMetrics retrieves metrics matching the filter.
+// Results are sorted by RecordedAt descending (most recent first).
+func (s *JSONLStore) QueryMetrics(ctx context.Context, filter MetricFilter) ([]*models.Metric, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queryMetrics(filter), nil
+}
+
+func (s *JSONLStore) queryMetrics(filter MetricFilter) []*models.Metric {
+	var metrics []*models.Metric
+	for _, m := range s.metrics {
+		if !matchesMetric(m, filter) {
+			continue
+		}
+		metrics = append(metrics, clone(m))
+	}
+	newestFirst(metrics,
+		func(m *models.Metric) time.Time { return m.RecordedAt },
+		func(m *models.Metric) uuid.UUID { return m.ID })
+	return paginate(metrics, filter.Offset, filter.Limit)
+}
+
+// SumMetrics adds up the values of all metrics matching the filter.
+// Limit and Offset are ignored.
+func (s *JSONLStore) SumMetrics(ctx context.Context, filter MetricFilter) (*MetricTotal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agg := s.aggregateMetrics(filter)
+	return agg.total(), nil
+}
+
+// AvgMetrics averages the values of all metrics matching the filter and
+// finds their range. Limit and Offset are ignored.
+func (s *JSONLStore) AvgMetrics(ctx context.Context, filter MetricFilter) (*MetricAverage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agg := s.aggregateMetrics(filter)
+	return agg.average(), nil
+}
+
+// CountMetrics counts the metrics matching filter, ignoring its Limit and
+// Offset.
+func (s *JSONLStore) CountMetrics(ctx context.Context, filter MetricFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aggregateMetrics(filter).count, nil
+}
+
+// aggregateMetrics folds the metrics matching filter without copying or
+// sorting them.
+func (s *JSONLStore) aggregateMetrics(filter MetricFilter) *metricAggregate {
+	var agg metricAggregate
+	for _, m := range s.metrics {
+		if matchesMetric(m, filter) {
+			agg.add(m)
+		}
+	}
+	return &agg
+}
+
+// DeleteMetric removes a metric by ID or prefix.
+func (s *JSONLStore) DeleteMetric(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m, err := findByPrefix(s.metrics, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete metric: %w", err)
+	}
+	return s.write("delete", kindMetric, m.ID.String(), nil)
+}
+
+// SetMetricMetadata sets one metadata key on a metric, or removes it when
+// value is empty.
+func (s *JSONLStore) SetMetricMetadata(ctx context.Context, idOrPrefix, key, value string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m, err := findByPrefix(s.metrics, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set metric metadata: %w", err)
+	}
+	updated := clone(m)
+	updated.Metadata = withMetadataKey(m.Metadata, key, value)
+	return s.write("put", kindMetric, updated.ID.String(), updated)
+}
+
+// UpdateMetric replaces the stored metric that has m's ID with m.
+func (s *JSONLStore) UpdateMetric(ctx context.Context, m *models.Metric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.metrics[m.ID]; !ok {
+		return fmt.Errorf("update metric: not found: %s", m.ID)
+	}
+	return s.write("put", kindMetric, m.ID.String(), clone(m))
+}
+
+// GetLatestMetric returns the most recent metric of a specific type.
+func (s *JSONLStore) GetLatestMetric(ctx context.Context, metricType models.MetricType) (*models.Metric, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	metrics := s.queryMetrics(MetricFilter{Type: &metricType, Limit: 1})
+	if len(metrics) == 0 {
+		return nil, fmt.Errorf("no metrics of type %s found", metricType)
+	}
+	return metrics[0], nil
+}
+
+// --- Workouts ---
+
+// CreateWorkout stores a new workout along with any children it carries.
+func (s *JSONLStore) CreateWorkout(ctx context.Context, w *models.Workout) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindWorkout, w.ID.String(), w)
+}
+
+// CreateWorkouts stores many workouts, with their children, under a single lock.
+func (s *JSONLStore) CreateWorkouts(ctx context.Context, workouts []*models.Workout) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, w := range workouts {
+		if err := s.write("put", kindWorkout, w.ID.String(), w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetWorkout retrieves a workout by ID or ID prefix (without metrics).
+func (s *JSONLStore) GetWorkout(ctx context.Context, idOrPrefix string) (*models.Workout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := findByPrefix(s.workouts, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return cloneWorkout(w, false), nil
+}
+
+// GetWorkoutWithMetrics retrieves a workout with all its associated metrics, sets, and comments.
+func (s *JSONLStore) GetWorkoutWithMetrics(ctx context.Context, idOrPrefix string) (*models.Workout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, err := findByPrefix(s.workouts, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return cloneWorkout(w, true), nil
+}
+
+// ListWorkouts retrieves workouts with optional filtering by type.
+// Results are sorted by StartedAt descending (most recent first).
+func (s *JSONLStore) ListWorkouts(ctx context.Context, workoutType *string, limit int) ([]*models.Workout, error) {
+	return s.QueryWorkouts(ctx, WorkoutFilter{Type: workoutType, Limit: limit})
+}
+
+// QueryWorkouts retrieves workouts matching the filter.
+// Results are sorted by StartedAt descending (most recent first).
+func (s *JSONLStore) QueryWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queryWorkouts(filter), nil
+}
+
+func (s *JSONLStore) queryWorkouts(filter WorkoutFilter) []*models.Workout {
+	var workouts []*models.Workout
+	for _, w := range s.workouts {
+		if filter.Type != nil && !strings.EqualFold(w.WorkoutType, *filter.Type) {
+			continue
+		}
+		if !matchesLocation(w.Location, filter.Location) || !matchesSource(w.Source, filter.Source) || !inRange(w.StartedAt, filter.Since, filter.Until) {
+			continue
+		}
+		workouts = append(workouts, cloneWorkout(w, false))
+	}
+	newestFirst(workouts,
+		func(w *models.Workout) time.Time { return w.StartedAt },
+		func(w *models.Workout) uuid.UUID { return w.ID })
+	return paginate(workouts, filter.Offset, filter.Limit)
+}
+
+// CountWorkouts counts the workouts matching filter, ignoring its Limit
+// and Offset.
+func (s *JSONLStore) CountWorkouts(ctx context.Context, filter WorkoutFilter) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	filter.Limit, filter.Offset = 0, 0
+	return len(s.queryWorkouts(filter)), nil
+}
+
+// DeleteWorkout removes a workout by ID or prefix along with its children.
+func (s *JSONLStore) DeleteWorkout(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	w, err := findByPrefix(s.workouts, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete workout: %w", err)
+	}
+	return s.write("delete", kindWorkout, w.ID.String(), nil)
+}
+
+// SetWorkoutMetadata sets one metadata key on a workout, or removes it
+// when value is empty.
+func (s *JSONLStore) SetWorkoutMetadata(ctx context.Context, idOrPrefix, key, value string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	w, err := findByPrefix(s.workouts, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set workout metadata: %w", err)
+	}
+	updated := cloneWorkout(w, true)
+	updated.Metadata = withMetadataKey(w.Metadata, key, value)
+	return s.write("put", kindWorkout, updated.ID.String(), updated)
+}
+
+// UpdateWorkout replaces the stored workout that has w's ID with w's own
+// fields; its metrics, sets, and comments are left as they are.
+func (s *JSONLStore) UpdateWorkout(ctx context.Context, w *models.Workout) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	old, ok := s.workouts[w.ID]
+	if !ok {
+		return fmt.Errorf("update workout: not found: %s", w.ID)
+	}
+	updated := cloneWorkout(w, false)
+	updated.Metrics = slices.Clone(old.Metrics)
+	updated.Sets = slices.Clone(old.Sets)
+	updated.Comments = slices.Clone(old.Comments)
+	return s.write("put", kindWorkout, updated.ID.String(), updated)
+}
+
+// AddWorkoutMetric adds a metric to an existing workout.
+func (s *JSONLStore) AddWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.workouts[wm.WorkoutID]; !ok {
+		return fmt.Errorf("add workout metric: workout not found: %s", wm.WorkoutID)
+	}
+	return s.write("put", kindWorkoutMetric, wm.ID.String(), wm)
+}
+
+// findWorkoutMetric finds a workout metric by ID or unique ID prefix.
+func (s *JSONLStore) findWorkoutMetric(idOrPrefix string) (*models.WorkoutMetric, error) {
+	all := make(map[uuid.UUID]*models.WorkoutMetric)
+	for _, w := range s.workouts {
+		for i := range w.Metrics {
+			all[w.Metrics[i].ID] = &w.Metrics[i]
+		}
+	}
+	return findByPrefix(all, idOrPrefix)
+}
+
+// GetWorkoutMetric retrieves a workout metric by ID or ID prefix.
+func (s *JSONLStore) GetWorkoutMetric(ctx context.Context, idOrPrefix string) (*models.WorkoutMetric, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wm, err := s.findWorkoutMetric(idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(wm), nil
+}
+
+// ListWorkoutMetrics retrieves all workout metrics for a specific workout.
+func (s *JSONLStore) ListWorkoutMetrics(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutMetric, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.workouts[workoutID]
+	if !ok {
+		return nil, fmt.Errorf("list workout metrics: not found: %s", workoutID)
+	}
+
+	metrics := make([]*models.WorkoutMetric, 0, len(w.Metrics))
+	for i := range w.Metrics {
+		metrics = append(metrics, clone(&w.Metrics[i]))
+	}
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].CreatedAt.Before(metrics[j].CreatedAt)
+	})
+	return metrics, nil
+}
+
+// UpdateWorkoutMetric replaces the stored metric that has wm's ID, on the
+// workout wm.WorkoutID, with wm.
+func (s *JSONLStore) UpdateWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	w, ok := s.workouts[wm.WorkoutID]
+	if !ok || !slices.ContainsFunc(w.Metrics, func(m models.WorkoutMetric) bool { return m.ID == wm.ID }) {
+		return fmt.Errorf("update workout metric: not found: %s on workout %s", wm.ID, wm.WorkoutID)
+	}
+	return s.write("put", kindWorkoutMetric, wm.ID.String(), clone(wm))
+}
+
+// DeleteWorkoutMetric removes a workout metric by ID or prefix.
+func (s *JSONLStore) DeleteWorkoutMetric(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	wm, err := s.findWorkoutMetric(idOrPrefix)
+	if err != nil {
+		return err
+	}
+	return s.write("delete", kindWorkoutMetric, wm.ID.String(), nil)
+}
+
+// AddWorkoutSet adds a set to an existing workout.
+func (s *JSONLStore) AddWorkoutSet(ctx context.Context, ws *models.WorkoutSet) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.workouts[ws.WorkoutID]; !ok {
+		return fmt.Errorf("add workout set: workout not found: %s", ws.WorkoutID)
+	}
+	return s.write("put", kindWorkoutSet, ws.ID.String(), ws)
+}
+
+// ListWorkoutSets retrieves all sets for a specific workout in the order they were logged.
+func (s *JSONLStore) ListWorkoutSets(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutSet, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.workouts[workoutID]
+	if !ok {
+		return nil, fmt.Errorf("list workout sets: not found: %s", workoutID)
+	}
+
+	sets := make([]*models.WorkoutSet, 0, len(w.Sets))
+	for i := range w.Sets {
+		sets = append(sets, clone(&w.Sets[i]))
+	}
+	sort.SliceStable(sets, func(i, j int) bool {
+		return sets[i].CreatedAt.Before(sets[j].CreatedAt)
+	})
+	return sets, nil
+}
+
+// AddWorkoutComment adds a comment to an existing workout.
+func (s *JSONLStore) AddWorkoutComment(ctx context.Context, c *models.WorkoutComment) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.workouts[c.WorkoutID]; !ok {
+		return fmt.Errorf("add workout comment: workout not found: %s", c.WorkoutID)
+	}
+	return s.write("put", kindWorkoutComment, c.ID.String(), c)
+}
+
+// ListWorkoutComments retrieves all comments on a workout, oldest first.
+func (s *JSONLStore) ListWorkoutComments(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutComment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.workouts[workoutID]
+	if !ok {
+		return nil, fmt.Errorf("list workout comments: not found: %s", workoutID)
+	}
+
+	comments := make([]*models.WorkoutComment, 0, len(w.Comments))
+	for i := range w.Comments {
+		comments = append(comments, clone(&w.Comments[i]))
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	return comments, nil
+}
+
+// --- Sleep ---
+
+// CreateSleepSession stores a new sleep session.
+func (s *JSONLStore) CreateSleepSession(ctx context.Context, ss *models.SleepSession) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindSleep, ss.ID.String(), ss)
+}
+
+// GetSleepSession retrieves a sleep session by ID or ID prefix.
+func (s *JSONLStore) GetSleepSession(ctx context.Context, idOrPrefix string) (*models.SleepSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ss, err := findByPrefix(s.sleep, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(ss), nil
+}
+
+// ListSleepSessions retrieves sleep sessions sorted by WakeTime descending.
+func (s *JSONLStore) ListSleepSessions(ctx context.Context, limit int) ([]*models.SleepSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listSleep(limit), nil
+}
+
+func (s *JSONLStore) listSleep(limit int) []*models.SleepSession {
+	sessions := make([]*models.SleepSession, 0, len(s.sleep))
+	for _, ss := range s.sleep {
+		sessions = append(sessions, clone(ss))
+	}
+	newestFirst(sessions,
+		func(ss *models.SleepSession) time.Time { return ss.WakeTime },
+		func(ss *models.SleepSession) uuid.UUID { return ss.ID })
+	return paginate(sessions, 0, limit)
+}
+
+// DeleteSleepSession removes a sleep session by ID or prefix.
+func (s *JSONLStore) DeleteSleepSession(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	ss, err := findByPrefix(s.sleep, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete sleep session: %w", err)
+	}
+	return s.write("delete", kindSleep, ss.ID.String(), nil)
+}
+
+// --- Medications ---
+
+// CreateMedication stores a new medication. Names must be unique, ignoring
+// case and punctuation.
+func (s *JSONLStore) CreateMedication(ctx context.Context, m *models.Medication) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, e := range s.medications {
+		if e.Slug() == m.Slug() {
+			return fmt.Errorf("medication %q already exists", e.Name)
+		}
+	}
+	return s.write("put", kindMedication, m.ID.String(), m)
+}
+
+// GetMedication retrieves a medication by name, slug, ID, or ID prefix.
+func (s *JSONLStore) GetMedication(ctx context.Context, nameOrID string) (*models.Medication, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return matchMedication(s.listMedications(), nameOrID)
+}
+
+// ListMedications retrieves all medications sorted by name.
+func (s *JSONLStore) ListMedications(ctx context.Context) ([]*models.Medication, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listMedications(), nil
+}
+
+func (s *JSONLStore) listMedications() []*models.Medication {
+	meds := make([]*models.Medication, 0, len(s.medications))
+	for _, m := range s.medications {
+		meds = append(meds, clone(m))
+	}
+	sort.Slice(meds, func(i, j int) bool {
+		return strings.ToLower(meds[i].Name) < strings.ToLower(meds[j].Name)
+	})
+	return meds
+}
+
+// DeleteMedication removes a medication and all of its intakes.
+func (s *JSONLStore) DeleteMedication(ctx context.Context, nameOrID string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	m, err := matchMedication(s.listMedications(), nameOrID)
+	if err != nil {
+		return fmt.Errorf("delete medication: %w", err)
+	}
+	return s.write("delete", kindMedication, m.ID.String(), nil)
+}
+
+// LogMedicationIntake stores a new intake of an existing medication.
+func (s *JSONLStore) LogMedicationIntake(ctx context.Context, in *models.MedicationIntake) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, ok := s.medications[in.MedicationID]; !ok {
+		return fmt.Errorf("log medication intake: not found: %s", in.MedicationID)
+	}
+	return s.write("put", kindIntake, in.ID.String(), in)
+}
+
+// ListMedicationIntakes retrieves intakes matching the filter.
+// Results are sorted by TakenAt descending (most recent first).
+func (s *JSONLStore) ListMedicationIntakes(ctx context.Context, filter IntakeFilter) ([]*models.MedicationIntake, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listIntakes(filter), nil
+}
+
+func (s *JSONLStore) listIntakes(filter IntakeFilter) []*models.MedicationIntake {
+	var intakes []*models.MedicationIntake
+	for _, in := range s.intakes {
+		if filter.MedicationID != nil && in.MedicationID != *filter.MedicationID {
+			continue
+		}
+		if inRange(in.TakenAt, filter.Since, filter.Until) {
+			intakes = append(intakes, clone(in))
+		}
+	}
+	newestFirst(intakes,
+		func(in *models.MedicationIntake) time.Time { return in.TakenAt },
+		func(in *models.MedicationIntake) uuid.UUID { return in.ID })
+	return paginate(intakes, 0, filter.Limit)
+}
+
+// --- Locations ---
+
+// CreateLocation stores a new location. Names must be unique, ignoring
+// case and punctuation.
+func (s *JSONLStore) CreateLocation(ctx context.Context, l *models.Location) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, e := range s.locations {
+		if e.Slug() == l.Slug() {
+			return fmt.Errorf("location %q already exists", e.Name)
+		}
+	}
+	return s.write("put", kindLocation, l.ID.String(), l)
+}
+
+// GetLocation retrieves a location by name, slug, ID, or ID prefix.
+func (s *JSONLStore) GetLocation(ctx context.Context, nameOrID string) (*models.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return matchLocation(s.listLocations(), nameOrID)
+}
+
+// ListLocations retrieves all locations sorted by name.
+func (s *JSONLStore) ListLocations(ctx context.Context) ([]*models.Location, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listLocations(), nil
+}
+
+func (s *JSONLStore) listLocations() []*models.Location {
+	locs := make([]*models.Location, 0, len(s.locations))
+	for _, l := range s.locations {
+		locs = append(locs, clone(l))
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		return strings.ToLower(locs[i].Name) < strings.ToLower(locs[j].Name)
+	})
+	return locs
+}
+
+// DeleteLocation removes a location. Entries already tagged with its name
+// keep their tag.
+func (s *JSONLStore) DeleteLocation(ctx context.Context, nameOrID string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	l, err := matchLocation(s.listLocations(), nameOrID)
+	if err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+	return s.write("delete", kindLocation, l.ID.String(), nil)
+}
+
+// --- Trips ---
+
+// CreateTrip stores a new trip.
+func (s *JSONLStore) CreateTrip(ctx context.Context, t *models.Trip) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindTrip, t.ID.String(), t)
+}
+
+// GetTrip retrieves a trip by ID or ID prefix.
+func (s *JSONLStore) GetTrip(ctx context.Context, idOrPrefix string) (*models.Trip, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := findByPrefix(s.trips, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(t), nil
+}
+
+// ListTrips retrieves trips sorted by StartedAt descending.
+func (s *JSONLStore) ListTrips(ctx context.Context, limit int) ([]*models.Trip, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listTrips(limit), nil
+}
+
+func (s *JSONLStore) listTrips(limit int) []*models.Trip {
+	trips := make([]*models.Trip, 0, len(s.trips))
+	for _, t := range s.trips {
+		trips = append(trips, clone(t))
+	}
+	newestFirst(trips,
+		func(t *models.Trip) time.Time { return t.StartedAt },
+		func(t *models.Trip) uuid.UUID { return t.ID })
+	return paginate(trips, 0, limit)
+}
+
+// EndTrip records when a trip ended.
+func (s *JSONLStore) EndTrip(ctx context.Context, idOrPrefix string, endedAt time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	t, err := findByPrefix(s.trips, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("end trip: %w", err)
+	}
+	ended := clone(t)
+	ended.EndedAt = &endedAt
+	return s.write("put", kindTrip, ended.ID.String(), ended)
+}
+
+// DeleteTrip removes a trip by ID or prefix.
+func (s *JSONLStore) DeleteTrip(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	t, err := findByPrefix(s.trips, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete trip: %w", err)
+	}
+	return s.write("delete", kindTrip, t.ID.String(), nil)
+}
+
+// --- Fasts ---
+
+// CreateFast stores a new fast.
+func (s *JSONLStore) CreateFast(ctx context.Context, f *models.Fast) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindFast, f.ID.String(), f)
+}
+
+// GetFast retrieves a fast by ID or ID prefix.
+func (s *JSONLStore) GetFast(ctx context.Context, idOrPrefix string) (*models.Fast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := findByPrefix(s.fasts, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(f), nil
+}
+
+// ListFasts retrieves fasts sorted by StartedAt descending.
+func (s *JSONLStore) ListFasts(ctx context.Context, limit int) ([]*models.Fast, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listFasts(limit), nil
+}
+
+func (s *JSONLStore) listFasts(limit int) []*models.Fast {
+	fasts := make([]*models.Fast, 0, len(s.fasts))
+	for _, f := range s.fasts {
+		fasts = append(fasts, clone(f))
+	}
+	newestFirst(fasts,
+		func(f *models.Fast) time.Time { return f.StartedAt },
+		func(f *models.Fast) uuid.UUID { return f.ID })
+	return paginate(fasts, 0, limit)
+}
+
+// EndFast records when a fast ended.
+func (s *JSONLStore) EndFast(ctx context.Context, idOrPrefix string, endedAt time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := findByPrefix(s.fasts, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("end fast: %w", err)
+	}
+	ended := clone(f)
+	ended.EndedAt = &endedAt
+	return s.write("put", kindFast, ended.ID.String(), ended)
+}
+
+// DeleteFast removes a fast by ID or prefix.
+func (s *JSONLStore) DeleteFast(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := findByPrefix(s.fasts, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete fast: %w", err)
+	}
+	return s.write("delete", kindFast, f.ID.String(), nil)
+}
+
+// --- Appointments ---
+
+// CreateAppointment stores a new appointment.
+func (s *JSONLStore) CreateAppointment(ctx context.Context, a *models.Appointment) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindAppointment, a.ID.String(), a)
+}
+
+// GetAppointment retrieves an appointment by ID or ID prefix.
+func (s *JSONLStore) GetAppointment(ctx context.Context, idOrPrefix string) (*models.Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, err := findByPrefix(s.appointments, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(a), nil
+}
+
+// ListAppointments retrieves appointments matching the filter, soonest first.
+func (s *JSONLStore) ListAppointments(ctx context.Context, filter AppointmentFilter) ([]*models.Appointment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listAppointments(filter), nil
+}
+
+func (s *JSONLStore) listAppointments(filter AppointmentFilter) []*models.Appointment {
+	var appts []*models.Appointment
+	for _, a := range s.appointments {
+		if inRange(a.ScheduledAt, filter.Since, filter.Until) {
+			appts = append(appts, clone(a))
+		}
+	}
+	sort.Slice(appts, func(i, j int) bool {
+		if !appts[i].ScheduledAt.Equal(appts[j].ScheduledAt) {
+			return appts[i].ScheduledAt.Before(appts[j].ScheduledAt)
+		}
+		return appts[i].ID.String() < appts[j].ID.String()
+	})
+	return paginate(appts, 0, filter.Limit)
+}
+
+// SetAppointmentSummary links a visit summary to an appointment, replacing
+// any earlier one.
+func (s *JSONLStore) SetAppointmentSummary(ctx context.Context, idOrPrefix string, summary string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	a, err := findByPrefix(s.appointments, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set appointment summary: %w", err)
+	}
+	updated := clone(a)
+	updated.Summary = &summary
+	return s.write("put", kindAppointment, updated.ID.String(), updated)
+}
+
+// DeleteAppointment removes an appointment by ID or prefix.
+func (s *JSONLStore) DeleteAppointment(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	a, err := findByPrefix(s.appointments, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete appointment: %w", err)
+	}
+	return s.write("delete", kindAppointment, a.ID.String(), nil)
+}
+
+// --- Events ---
+
+// CreateEvent stores a new event.
+func (s *JSONLStore) CreateEvent(ctx context.Context, e *models.Event) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindEvent, e.ID.String(), e)
+}
+
+// GetEvent retrieves an event by ID or ID prefix.
+func (s *JSONLStore) GetEvent(ctx context.Context, idOrPrefix string) (*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, err := findByPrefix(s.events, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return clone(e), nil
+}
+
+// ListEvents retrieves every event, soonest first.
+func (s *JSONLStore) ListEvents(ctx context.Context) ([]*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.listEvents(), nil
+}
+
+func (s *JSONLStore) listEvents() []*models.Event {
+	events := make([]*models.Event, 0, len(s.events))
+	for _, e := range s.events {
+		events = append(events, clone(e))
+	}
+	sortEvents(events)
+	return events
+}
+
+// DeleteEvent removes an event by ID or prefix.
+func (s *JSONLStore) DeleteEvent(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	e, err := findByPrefix(s.events, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+	return s.write("delete", kindEvent, e.ID.String(), nil)
+}
+
+// --- Reminder state ---
+
+// GetReminderLastFired returns when the reminder with the given key last
+// fired, or nil if it never has.
+func (s *JSONLStore) GetReminderLastFired(ctx context.Context, key string) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.reminders[key]
+	if !ok {
+		return nil, nil
+	}
+	return &at, nil
+}
+
+// SetReminderLastFired records that the reminder with the given key fired at.
+func (s *JSONLStore) SetReminderLastFired(ctx context.Context, key string, at time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.write("put", kindReminder, key, at)
+}
+
+// GetReminderSnooze returns when the snooze on the reminder with the given
+// key ends, or nil if it isn't snoozed.
+func (s *JSONLStore) GetReminderSnooze(ctx context.Context, key string) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.snoozes[key]
+	if !ok {
+		return nil, nil
+	}
+	return &until, nil
+}
+
+// SetReminderSnooze snoozes the reminder with the given key until the given
+// time. A nil until clears the snooze.
+func (s *JSONLStore) SetReminderSnooze(ctx context.Context, key string, until *time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if until == nil {
+		if _, ok := s.snoozes[key]; !ok {
+			return nil
+		}
+		return s.write("delete", kindReminderSnooze, key, nil)
+	}
+	return s.write("put", kindReminderSnooze, key, *until)
+}
+
+// --- Export/Import ---
+
+// GetAllData retrieves all data for export.
+func (s *JSONLStore) GetAllData(ctx context.Context) (*ExportData, error) {
+	return GetAllDataFromRepo(ctx, s)
+}
+
+// ImportData imports data from an export file.
+func (s *JSONLStore) ImportData(ctx context.Context, data *ExportData) error {
+	return ImportDataToRepo(ctx, s, data)
+}
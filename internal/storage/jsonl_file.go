@@ -0,0 +1,169 @@
+// ABOUTME: fileLog keeps the JSONL store's records in a JSON Lines file, one record per line.
+// ABOUTME: Writers take turns on .lock beside it; a compaction swaps in a new file that readers follow.
+
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// fileLog is the RecordLog of a JSONL file at path.
+type fileLog struct {
+	path   string
+	file   *os.File // append handle, opened by the first Read
+	offset int64    // bytes of file read so far
+	closed bool
+}
+
+// errJSONLClosed is returned for writes after Close.
+var errJSONLClosed = errors.New("jsonl store is closed")
+
+func (l *fileLog) String() string {
+	return l.path
+}
+
+// Lock takes the advisory lock on the .lock file beside the store,
+// returning the func that releases it.
+func (l *fileLog) Lock() (func(), error) {
+	f, err := os.OpenFile(l.path+".lock", os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("lock jsonl store: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("lock jsonl store: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// openFile opens the append handle on the file now at path, closing any
+// earlier one.
+func (l *fileLog) openFile() error {
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open jsonl store: %w", err)
+	}
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file = f
+	return nil
+}
+
+// Read returns the lines appended since the last Read. If another process
+// compacted the store, the file at path is a new one, so it is read from
+// the start and appended to from then on. A final line cut short, which
+// only an interrupted write leaves behind, is cut off so the next append
+// starts a fresh line.
+func (l *fileLog) Read() ([][]byte, bool, error) {
+	if l.closed {
+		return nil, false, errJSONLClosed
+	}
+	reset := false
+	if l.file == nil {
+		if err := l.openFile(); err != nil {
+			return nil, false, err
+		}
+	} else {
+		info, err := os.Stat(l.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, false, fmt.Errorf("refresh jsonl store: %w", err)
+		}
+		current, ferr := l.file.Stat()
+		if ferr != nil {
+			return nil, false, fmt.Errorf("refresh jsonl store: %w", ferr)
+		}
+		if err != nil || !os.SameFile(info, current) {
+			reset, l.offset = true, 0
+			if err := l.openFile(); err != nil {
+				return nil, false, err
+			}
+		} else if info.Size() == l.offset {
+			return nil, false, nil
+		}
+	}
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, false, fmt.Errorf("open jsonl store: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Seek(l.offset, io.SeekStart); err != nil {
+		return nil, false, fmt.Errorf("read jsonl store: %w", err)
+	}
+
+	var records [][]byte
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				if err := os.Truncate(l.path, l.offset); err != nil {
+					return nil, false, fmt.Errorf("drop torn line in jsonl store: %w", err)
+				}
+			}
+			return records, reset, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("read jsonl store: %w", err)
+		}
+		l.offset += int64(len(line))
+		if len(bytes.TrimSpace(line)) > 0 {
+			records = append(records, line)
+		}
+	}
+}
+
+// Append writes record as a new line.
+func (l *fileLog) Append(record []byte) error {
+	if l.file == nil {
+		return errJSONLClosed
+	}
+	line := append(record, '\n')
+	if _, err := l.file.Write(line); err != nil {
+		return err
+	}
+	l.offset += int64(len(line))
+	return nil
+}
+
+// Rewrite writes records to a new file, swaps it in atomically, and moves
+// the append handle to it.
+func (l *fileLog) Rewrite(records [][]byte) error {
+	var buf bytes.Buffer
+	for _, rec := range records {
+		buf.Write(rec)
+		buf.WriteByte('\n')
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+	l.offset = int64(buf.Len())
+	return l.openFile()
+}
+
+// Close syncs and closes the file.
+func (l *fileLog) Close() error {
+	l.closed = true
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Sync()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
@@ -0,0 +1,60 @@
+// ABOUTME: Cross-process locking for the JSONL engine, so a compaction can't strand another process's appends.
+// ABOUTME: Writers hold the log's lock and first replay what other processes appended or compacted.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// lock takes the store's write lock and brings the in-memory records up
+// to date with the log, returning the func that releases it. Every change
+// holds it from its lookups to its append, so two processes sharing the
+// log, such as the MCP server and the CLI, see each other's writes and
+// never append to a file the other has compacted away. Like the markdown
+// store's lock it isn't reentrant.
+func (s *JSONLStore) lock() (func(), error) {
+	s.mu.Lock()
+	if s.log == nil {
+		return s.mu.Unlock, nil
+	}
+	release, err := s.log.Lock()
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	unlock := func() {
+		release()
+		s.mu.Unlock()
+	}
+	if err := s.refresh(); err != nil {
+		unlock()
+		return nil, err
+	}
+	return unlock, nil
+}
+
+// refresh replays records other processes added since this store last
+// read the log. If one of them compacted it, the store starts over from
+// the rewritten log. The caller holds the log's lock.
+func (s *JSONLStore) refresh() error {
+	records, reset, err := s.log.Read()
+	if err != nil {
+		return err
+	}
+	if reset {
+		s.reset()
+	}
+	for _, line := range records {
+		var rec jsonlRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return fmt.Errorf("%s line %d: %w", s.log, s.lines+1, err)
+		}
+		if err := s.apply(&rec); err != nil {
+			return fmt.Errorf("%s line %d: %w", s.log, s.lines+1, err)
+		}
+		s.lines++
+	}
+	return nil
+}
@@ -0,0 +1,466 @@
+// ABOUTME: Tests for JSONLStore implementation of Repository interface.
+// ABOUTME: Verifies CRUD, replay after reopen, cascades, compaction, torn-line recovery, and sharing a file or other log.
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// setupTestJSONLStore opens a JSONLStore in a temp directory.
+func setupTestJSONLStore(t *testing.T) (*JSONLStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "health.jsonl")
+	store, err := OpenJSONL(path)
+	if err != nil {
+		t.Fatalf("Failed to open JSONLStore: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, path
+}
+
+// reopenJSONL closes store and opens the same file again.
+func reopenJSONL(t *testing.T, store *JSONLStore, path string) *JSONLStore {
+	t.Helper()
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	reopened, err := OpenJSONL(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	t.Cleanup(func() { _ = reopened.Close() })
+	return reopened
+}
+
+func TestJSONLStoreMetrics(t *testing.T) {
+	ctx := t.Context()
+	store, path := setupTestJSONLStore(t)
+
+	base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	var ids []string
+	for i, v := range []float64{82, 81.5, 81} {
+		m := models.NewMetric(models.MetricWeight, v).WithNotes("scale")
+		m.RecordedAt = base.AddDate(0, 0, i)
+		if err := store.CreateMetric(ctx, m); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+		ids = append(ids, m.ID.String())
+	}
+	store.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7))
+
+	store = reopenJSONL(t, store, path)
+
+	got, err := store.GetMetric(ctx, ids[0][:8])
+	if err != nil {
+		t.Fatalf("GetMetric by prefix failed: %v", err)
+	}
+	if got.Value != 82 || got.Notes == nil || *got.Notes != "scale" {
+		t.Errorf("Metric did not survive reopen: %+v", got)
+	}
+
+	mt := models.MetricWeight
+	list, _ := store.ListMetrics(ctx, &mt, 2)
+	if len(list) != 2 || list[0].Value != 81 {
+		t.Errorf("ListMetrics = %d metrics, want newest two", len(list))
+	}
+	total, _ := store.SumMetrics(ctx, MetricFilter{Type: &mt})
+	if total.Count != 3 || total.Sum != 244.5 {
+		t.Errorf("SumMetrics = %+v, want 3 / 244.5", total)
+	}
+	if n, _ := store.CountMetrics(ctx, MetricFilter{Type: &mt, Limit: 1}); n != 3 {
+		t.Errorf("CountMetrics = %d, want 3", n)
+	}
+
+	if err := store.DeleteMetric(ctx, ids[2]); err != nil {
+		t.Fatalf("DeleteMetric failed: %v", err)
+	}
+	store = reopenJSONL(t, store, path)
+	latest, err := store.GetLatestMetric(ctx, models.MetricWeight)
+	if err != nil || latest.Value != 81.5 {
+		t.Errorf("GetLatestMetric after delete = %v, %v; want 81.5", latest, err)
+	}
+	if _, err := store.GetMetric(ctx, ids[2]); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found for deleted metric, got %v", err)
+	}
+}
+
+func TestJSONLStoreReturnsCopies(t *testing.T) {
+	ctx := t.Context()
+	store, _ := setupTestJSONLStore(t)
+
+	m := models.NewMetric(models.MetricWeight, 82)
+	store.CreateMetric(ctx, m)
+
+	got, _ := store.GetMetric(ctx, m.ID.String())
+	got.Value = 0
+	again, _ := store.GetMetric(ctx, m.ID.String())
+	if again.Value != 82 {
+		t.Errorf("Changing a returned metric changed the store")
+	}
+}
+
+func TestJSONLStoreWorkoutChildren(t *testing.T) {
+	ctx := t.Context()
+	store, path := setupTestJSONLStore(t)
+
+	w := models.NewWorkout("lift")
+	if err := store.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+	wm := models.NewWorkoutMetric(w.ID, "volume", 5000, "kg")
+	store.AddWorkoutMetric(ctx, wm)
+	store.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "squat", 1, 5))
+	store.AddWorkoutComment(ctx, models.NewWorkoutComment(w.ID, "coach", "Depth looked good"))
+
+	if err := store.AddWorkoutSet(ctx, models.NewWorkoutSet(models.NewWorkout("x").ID, "squat", 1, 5)); err == nil {
+		t.Error("Expected error adding a set to a missing workout")
+	}
+
+	store = reopenJSONL(t, store, path)
+
+	plain, _ := store.GetWorkout(ctx, w.ID.String())
+	if plain.Metrics != nil || plain.Sets != nil || plain.Comments != nil {
+		t.Error("GetWorkout should not include children")
+	}
+	full, err := store.GetWorkoutWithMetrics(ctx, w.ID.String()[:8])
+	if err != nil {
+		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+	}
+	if len(full.Metrics) != 1 || len(full.Sets) != 1 || len(full.Comments) != 1 {
+		t.Errorf("Children after reopen: %d metrics, %d sets, %d comments",
+			len(full.Metrics), len(full.Sets), len(full.Comments))
+	}
+
+	if err := store.DeleteWorkoutMetric(ctx, wm.ID.String()[:8]); err != nil {
+		t.Fatalf("DeleteWorkoutMetric failed: %v", err)
+	}
+	metrics, _ := store.ListWorkoutMetrics(ctx, w.ID)
+	if len(metrics) != 0 {
+		t.Errorf("Expected 0 workout metrics after delete, got %d", len(metrics))
+	}
+
+	if err := store.DeleteWorkout(ctx, w.ID.String()); err != nil {
+		t.Fatalf("DeleteWorkout failed: %v", err)
+	}
+	store = reopenJSONL(t, store, path)
+	if _, err := store.GetWorkout(ctx, w.ID.String()); err == nil {
+		t.Error("Expected deleted workout to stay deleted after reopen")
+	}
+}
+
+func TestJSONLStoreMedicationsAndState(t *testing.T) {
+	ctx := t.Context()
+	store, path := setupTestJSONLStore(t)
+
+	med := models.NewMedication("Vitamin D", "1000 IU", "daily")
+	store.CreateMedication(ctx, med)
+	if err := store.CreateMedication(ctx, models.NewMedication("vitamin d", "", "")); err == nil {
+		t.Error("Expected duplicate medication name to be rejected")
+	}
+	store.LogMedicationIntake(ctx, models.NewMedicationIntake(med.ID))
+
+	trip := models.NewTrip("paris")
+	store.CreateTrip(ctx, trip)
+	ended := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	store.EndTrip(ctx, trip.ID.String()[:8], ended)
+	fast := models.NewFast(16)
+	store.CreateFast(ctx, fast)
+	store.EndFast(ctx, fast.ID.String()[:8], ended)
+
+	appt := models.NewAppointment("Dr. Lee", time.Date(2025, 7, 2, 14, 0, 0, 0, time.UTC))
+	store.CreateAppointment(ctx, appt)
+	store.SetAppointmentSummary(ctx, appt.ID.String(), "Labs look good")
+
+	fired := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	store.SetReminderLastFired(ctx, "log weight", fired)
+	snoozed := fired.Add(2 * time.Hour)
+	store.SetReminderSnooze(ctx, "log weight", &snoozed)
+	store.SetReminderSnooze(ctx, "drink water", &snoozed)
+	store.SetReminderSnooze(ctx, "drink water", nil)
+
+	store = reopenJSONL(t, store, path)
+
+	got, err := store.GetMedication(ctx, "vitamin-d")
+	if err != nil || got.ID != med.ID {
+		t.Fatalf("GetMedication by slug = %v, %v", got, err)
+	}
+	gotTrip, _ := store.GetTrip(ctx, trip.ID.String())
+	if gotTrip.EndedAt == nil || !gotTrip.EndedAt.Equal(ended) {
+		t.Errorf("EndTrip not persisted: %v", gotTrip.EndedAt)
+	}
+	gotFast, _ := store.GetFast(ctx, fast.ID.String())
+	if gotFast == nil || gotFast.EndedAt == nil || !gotFast.EndedAt.Equal(ended) || gotFast.TargetHours != 16 {
+		t.Errorf("EndFast not persisted: %+v", gotFast)
+	}
+	gotAppt, _ := store.GetAppointment(ctx, appt.ID.String())
+	if gotAppt.Summary == nil || *gotAppt.Summary != "Labs look good" {
+		t.Errorf("Appointment summary not persisted: %v", gotAppt.Summary)
+	}
+	at, _ := store.GetReminderLastFired(ctx, "log weight")
+	if at == nil || !at.Equal(fired) {
+		t.Errorf("Reminder state = %v, want %v", at, fired)
+	}
+	if until, _ := store.GetReminderSnooze(ctx, "log weight"); until == nil || !until.Equal(snoozed) {
+		t.Errorf("Reminder snooze = %v, want %v", until, snoozed)
+	}
+	if until, _ := store.GetReminderSnooze(ctx, "drink water"); until != nil {
+		t.Errorf("Cleared snooze came back as %v", until)
+	}
+
+	if err := store.DeleteMedication(ctx, "Vitamin D"); err != nil {
+		t.Fatalf("DeleteMedication failed: %v", err)
+	}
+	store = reopenJSONL(t, store, path)
+	intakes, _ := store.ListMedicationIntakes(ctx, IntakeFilter{})
+	if len(intakes) != 0 {
+		t.Errorf("Expected intakes to be deleted with their medication, got %d", len(intakes))
+	}
+}
+
+func TestJSONLStoreCompactsOnOpen(t *testing.T) {
+	ctx := t.Context()
+	store, path := setupTestJSONLStore(t)
+
+	keep := models.NewMetric(models.MetricWeight, 80)
+	store.CreateMetric(ctx, keep)
+	w := models.NewWorkout("run")
+	store.CreateWorkout(ctx, w)
+	store.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+	for i := 0; i < 100; i++ {
+		m := models.NewMetric(models.MetricSteps, float64(i))
+		store.CreateMetric(ctx, m)
+		store.DeleteMetric(ctx, m.ID.String())
+	}
+
+	store = reopenJSONL(t, store, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Compacted file has %d lines, want 2", lines)
+	}
+	if _, err := store.GetMetric(ctx, keep.ID.String()); err != nil {
+		t.Errorf("Live metric lost in compaction: %v", err)
+	}
+	full, _ := store.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if len(full.Metrics) != 1 {
+		t.Errorf("Workout metric lost in compaction")
+	}
+}
+
+func TestJSONLStoreRecoversTornLine(t *testing.T) {
+	ctx := t.Context()
+	store, path := setupTestJSONLStore(t)
+
+	m := models.NewMetric(models.MetricWeight, 80)
+	store.CreateMetric(ctx, m)
+	store.Close()
+
+	// Simulate a write cut off mid-line
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString(`{"op":"put","kind":"metric","id":`)
+	f.Close()
+
+	store, err := OpenJSONL(path)
+	if err != nil {
+		t.Fatalf("Open with torn line failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	next := models.NewMetric(models.MetricWeight, 79)
+	store.CreateMetric(ctx, next)
+	store = reopenJSONL(t, store, path)
+
+	list, err := store.ListMetrics(ctx, nil, 0)
+	if err != nil || len(list) != 2 {
+		t.Errorf("ListMetrics after recovery = %d, %v; want 2", len(list), err)
+	}
+}
+
+func TestJSONLStoreSharedBetweenProcesses(t *testing.T) {
+	ctx := t.Context()
+	first, path := setupTestJSONLStore(t)
+	// A second store on the same file stands in for another process, such
+	// as the MCP server while the CLI runs
+	second, err := OpenJSONL(path)
+	if err != nil {
+		t.Fatalf("OpenJSONL failed: %v", err)
+	}
+	t.Cleanup(func() { _ = second.Close() })
+
+	fromSecond := models.NewMetric(models.MetricWeight, 80)
+	second.CreateMetric(ctx, fromSecond)
+	// Compaction swaps in a new file under the first store's append handle
+	if _, err := Maintain(ctx, second, MaintenanceOptions{}); err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	fromFirst := models.NewMetric(models.MetricWeight, 79)
+	if err := first.CreateMetric(ctx, fromFirst); err != nil {
+		t.Fatalf("CreateMetric after another store compacted failed: %v", err)
+	}
+	if _, err := first.GetMetric(ctx, fromSecond.ID.String()); err != nil {
+		t.Errorf("Expected the first store to catch up on the second's metric: %v", err)
+	}
+
+	const writers, each = 4, 10
+	errs := make(chan error, writers)
+	for i := range writers {
+		store := first
+		if i%2 == 1 {
+			store = second
+		}
+		go func() {
+			for range each {
+				if err := store.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 1000)); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for range writers {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent writes failed: %v", err)
+		}
+	}
+
+	reopened := reopenJSONL(t, first, path)
+	if list, _ := reopened.ListMetrics(ctx, nil, 0); len(list) != 2+writers*each {
+		t.Errorf("Reopened store has %d metrics, want %d", len(list), 2+writers*each)
+	}
+}
+
+// sharedRecords is a record log held in memory, standing in for a table
+// that several processes use; each store reads it through a memoryLog.
+type sharedRecords struct {
+	mu         sync.Mutex
+	records    [][]byte
+	generation int
+}
+
+// memoryLog is one store's RecordLog view of sharedRecords.
+type memoryLog struct {
+	shared     *sharedRecords
+	read       int
+	generation int
+}
+
+func (l *memoryLog) String() string { return "memory log" }
+func (l *memoryLog) Close() error   { return nil }
+
+func (l *memoryLog) Lock() (func(), error) {
+	l.shared.mu.Lock()
+	return l.shared.mu.Unlock, nil
+}
+
+func (l *memoryLog) Read() ([][]byte, bool, error) {
+	reset := l.generation != l.shared.generation
+	if reset {
+		l.read, l.generation = 0, l.shared.generation
+	}
+	records := l.shared.records[l.read:]
+	l.read = len(l.shared.records)
+	return records, reset, nil
+}
+
+func (l *memoryLog) Append(record []byte) error {
+	l.shared.records = append(l.shared.records, record)
+	l.read = len(l.shared.records)
+	return nil
+}
+
+func (l *memoryLog) Rewrite(records [][]byte) error {
+	l.shared.records = slices.Clone(records)
+	l.shared.generation++
+	l.read, l.generation = len(records), l.shared.generation
+	return nil
+}
+
+func TestOpenRecordLog(t *testing.T) {
+	ctx := t.Context()
+	shared := &sharedRecords{}
+	open := func() *JSONLStore {
+		s, err := OpenRecordLog(&memoryLog{shared: shared})
+		if err != nil {
+			t.Fatalf("OpenRecordLog failed: %v", err)
+		}
+		return s
+	}
+	first, second := open(), open()
+
+	fromSecond := models.NewMetric(models.MetricWeight, 80)
+	second.CreateMetric(ctx, fromSecond)
+	for range 60 {
+		m := models.NewMetric(models.MetricSteps, 1)
+		second.CreateMetric(ctx, m)
+		second.DeleteMetric(ctx, m.ID.String())
+	}
+	// Opening a bloated log compacts it under the other two stores
+	open()
+	if len(shared.records) != 1 {
+		t.Fatalf("Compacted log has %d records, want 1", len(shared.records))
+	}
+
+	if err := first.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 79)); err != nil {
+		t.Fatalf("CreateMetric after compaction failed: %v", err)
+	}
+	if _, err := first.GetMetric(ctx, fromSecond.ID.String()); err != nil {
+		t.Errorf("Expected the first store to catch up on the second's metric: %v", err)
+	}
+	second.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7))
+	if list, _ := open().ListMetrics(ctx, nil, 0); len(list) != 3 {
+		t.Errorf("New store has %d metrics, want 3", len(list))
+	}
+}
+
+func TestJSONLStoreRejectsCorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "health.jsonl")
+	os.WriteFile(path, []byte("not json\n"), 0600)
+
+	if _, err := OpenJSONL(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected error naming line 1, got %v", err)
+	}
+}
+
+func TestMigrateDataSQLiteToJSONL(t *testing.T) {
+	ctx := t.Context()
+	src := setupTestDB(t)
+
+	m := models.NewMetric(models.MetricWeight, 82.5).WithNotes("test note")
+	src.CreateMetric(ctx, m)
+	w := models.NewWorkout("run")
+	src.CreateWorkout(ctx, w)
+	src.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+
+	dst, path := setupTestJSONLStore(t)
+	summary, err := MigrateData(ctx, src, dst)
+	if err != nil {
+		t.Fatalf("MigrateData failed: %v", err)
+	}
+	if summary.Metrics != 1 || summary.Workouts != 1 || summary.WorkoutMetrics != 1 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+
+	dst = reopenJSONL(t, dst, path)
+	full, err := dst.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil || len(full.Metrics) != 1 {
+		t.Errorf("Workout metrics not migrated: %v", err)
+	}
+	st, err := Status(ctx, dst, time.Now())
+	if err != nil || st.Backend != "jsonl" || st.SizeBytes == 0 || st.Metrics != 1 {
+		t.Errorf("Status = %+v, %v", st, err)
+	}
+}
@@ -0,0 +1,124 @@
+// ABOUTME: Location registry CRUD operations for SQLite storage.
+// ABOUTME: Implements Repository interface methods for named locations.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateLocation stores a new named location. Names must be unique, ignoring
+// case and punctuation, so they can be used as tags.
+func (d *DB) CreateLocation(ctx context.Context, l *models.Location) error {
+	existing, err := d.ListLocations(ctx)
+	if err != nil {
+		return fmt.Errorf("create location: %w", err)
+	}
+	for _, e := range existing {
+		if e.Slug() == l.Slug() {
+			return fmt.Errorf("location %q already exists", e.Name)
+		}
+	}
+
+	query := `
+		INSERT INTO locations (id, name, latitude, longitude, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err = d.db.ExecContext(ctx, query,
+		l.ID.String(),
+		l.Name,
+		l.Latitude,
+		l.Longitude,
+		l.Notes,
+		l.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create location: %w", err)
+	}
+	return nil
+}
+
+// GetLocation retrieves a location by name, slug, ID, or ID prefix.
+func (d *DB) GetLocation(ctx context.Context, nameOrID string) (*models.Location, error) {
+	locs, err := d.ListLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matchLocation(locs, nameOrID)
+}
+
+// ListLocations retrieves all locations sorted by name.
+func (d *DB) ListLocations(ctx context.Context) ([]*models.Location, error) {
+	query := `
+		SELECT id, name, latitude, longitude, notes, created_at
+		FROM locations
+		ORDER BY name COLLATE NOCASE ASC
+	`
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list locations: %w", err)
+	}
+	defer rows.Close()
+
+	var locs []*models.Location
+	for rows.Next() {
+		var l models.Location
+		var idStr, createdAt string
+		var lat, lon sql.NullFloat64
+		var notes sql.NullString
+
+		if err := rows.Scan(&idStr, &l.Name, &lat, &lon, &notes, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan location: %w", err)
+		}
+
+		l.ID, _ = uuid.Parse(idStr)
+		l.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if lat.Valid && lon.Valid {
+			l.WithCoordinates(lat.Float64, lon.Float64)
+		}
+		if notes.Valid {
+			l.Notes = &notes.String
+		}
+		locs = append(locs, &l)
+	}
+	return locs, rows.Err()
+}
+
+// DeleteLocation removes a location from the registry. Entries already
+// tagged with its name keep their tag.
+func (d *DB) DeleteLocation(ctx context.Context, nameOrID string) error {
+	l, err := d.GetLocation(ctx, nameOrID)
+	if err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+
+	if _, err := d.db.ExecContext(ctx, "DELETE FROM locations WHERE id = ?", l.ID.String()); err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+	return nil
+}
+
+// ResolveLocationTag turns a user-supplied location into the tag stored on
+// an entry: the registered name for a known location (matched by name, slug,
+// or ID), or normalized "lat,lon" for raw coordinates.
+func ResolveLocationTag(ctx context.Context, r Repository, location string) (string, error) {
+	lat, lon, ok, err := models.ParseCoordinates(location)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return strconv.FormatFloat(lat, 'f', -1, 64) + "," + strconv.FormatFloat(lon, 'f', -1, 64), nil
+	}
+
+	l, err := r.GetLocation(ctx, location)
+	if err != nil {
+		return "", fmt.Errorf("unknown location %q (register it with: health location add %s)", location, location)
+	}
+	return l.Name, nil
+}
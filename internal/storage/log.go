@@ -0,0 +1,85 @@
+// ABOUTME: Debug logging of storage operations through log/slog.
+// ABOUTME: SQLite statements are timed by a thin wrapper around *sql.DB, which also serializes writes.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timedDB is a *sql.DB that logs each statement and how long it took.
+// Statements run through ExecContext and transactions from BeginTx hold
+// writeMu, so writers in this process take turns instead of contending
+// for SQLite's lock; busy_timeout covers writers in other processes.
+type timedDB struct {
+	*sql.DB
+	writeMu sync.Mutex
+}
+
+func (t *timedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	start := time.Now()
+	res, err := t.DB.ExecContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return res, err
+}
+
+// BeginTx starts a write transaction, holding writeMu until it commits
+// or rolls back.
+func (t *timedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*writeTx, error) {
+	t.writeMu.Lock()
+	tx, err := t.DB.BeginTx(ctx, opts)
+	if err != nil {
+		t.writeMu.Unlock()
+		return nil, err
+	}
+	return &writeTx{Tx: tx, unlock: sync.OnceFunc(t.writeMu.Unlock)}, nil
+}
+
+// writeTx is a transaction that releases the writer lock when it ends.
+type writeTx struct {
+	*sql.Tx
+	unlock func()
+}
+
+func (tx *writeTx) Commit() error {
+	defer tx.unlock()
+	return tx.Tx.Commit()
+}
+
+func (tx *writeTx) Rollback() error {
+	defer tx.unlock()
+	return tx.Tx.Rollback()
+}
+
+func (t *timedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := t.DB.QueryContext(ctx, query, args...)
+	logQuery(ctx, query, start, err)
+	return rows, err
+}
+
+func (t *timedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	start := time.Now()
+	row := t.DB.QueryRowContext(ctx, query, args...)
+	logQuery(ctx, query, start, row.Err())
+	return row
+}
+
+// logQuery logs a statement on one line. Query times cover execution up to
+// the first row, not reading the rest.
+func logQuery(ctx context.Context, query string, start time.Time, err error) {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []any{"sql", strings.Join(strings.Fields(query), " "), "duration", time.Since(start)}
+	if err != nil {
+		attrs = append(attrs, "err", err)
+	}
+	slog.DebugContext(ctx, "sqlite query", attrs...)
+}
@@ -0,0 +1,251 @@
+// ABOUTME: Store maintenance: orphan cleanup and VACUUM for SQLite, compaction for JSONL,
+// ABOUTME: and a frontmatter check for markdown that can move broken files to quarantine/.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// QuarantineDir is where broken markdown files are moved, under the data
+// directory. Nothing reads from it.
+const QuarantineDir = "quarantine"
+
+// MaintenanceOptions controls what Maintain changes.
+type MaintenanceOptions struct {
+	DryRun     bool // report only
+	Quarantine bool // move unparsable markdown files to QuarantineDir
+}
+
+// Orphans counts rows pointing at a parent that no longer exists.
+type Orphans struct {
+	Table string
+	Rows  int
+}
+
+// BrokenFile is a markdown file whose frontmatter doesn't parse.
+type BrokenFile struct {
+	Path string // relative to the data directory
+	Err  error
+}
+
+// MaintenanceReport says what Maintain found and, unless it was a dry
+// run, fixed.
+type MaintenanceReport struct {
+	Backend     string
+	Orphans     []Orphans // sqlite
+	SizeBefore  int64     // sqlite and jsonl, around VACUUM or compaction
+	SizeAfter   int64
+	Broken      []BrokenFile // markdown
+	Quarantined bool
+}
+
+// orphanChecks lists child tables and the parent each row must point at.
+// foreign_keys is a per-connection setting in SQLite, so rows written
+// through a connection without it (another tool, an older build) can
+// outlive their parent.
+var orphanChecks = []struct {
+	table, column, parent string
+}{
+	{"workout_metrics", "workout_id", "workouts"},
+	{"workout_sets", "workout_id", "workouts"},
+	{"workout_comments", "workout_id", "workouts"},
+	{"medication_intakes", "medication_id", "medications"},
+}
+
+// Maintain cleans up the store behind r. For SQLite it deletes orphaned
+// child rows, clears sleep sessions' links to deleted metrics, and
+// VACUUMs. For JSONL it compacts the file. For markdown it reports files
+// with unparsable frontmatter and, with opts.Quarantine, moves them out of
+// the way.
+func Maintain(ctx context.Context, r Repository, opts MaintenanceOptions) (*MaintenanceReport, error) {
+	switch store := r.(type) {
+	case *DB:
+		return store.maintain(ctx, opts)
+	case *MarkdownStore:
+		return store.maintain(ctx, opts)
+	case *JSONLStore:
+		return store.maintain(opts)
+	case *MemoryStore:
+		return &MaintenanceReport{Backend: "memory"}, nil
+	}
+	return nil, fmt.Errorf("maintenance is not supported for this backend")
+}
+
+func (d *DB) maintain(ctx context.Context, opts MaintenanceOptions) (*MaintenanceReport, error) {
+	rep := &MaintenanceReport{Backend: "sqlite"}
+
+	for _, c := range orphanChecks {
+		where := fmt.Sprintf("%s NOT IN (SELECT id FROM %s)", c.column, c.parent)
+		var n int
+		if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+c.table+" WHERE "+where).Scan(&n); err != nil {
+			return nil, fmt.Errorf("count orphaned %s: %w", c.table, err)
+		}
+		if n > 0 && !opts.DryRun {
+			if _, err := d.db.ExecContext(ctx, "DELETE FROM "+c.table+" WHERE "+where); err != nil {
+				return nil, fmt.Errorf("delete orphaned %s: %w", c.table, err)
+			}
+		}
+		rep.Orphans = append(rep.Orphans, Orphans{Table: c.table, Rows: n})
+	}
+
+	const dangling = "metric_id IS NOT NULL AND metric_id NOT IN (SELECT id FROM metrics)"
+	var n int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sleep_sessions WHERE "+dangling).Scan(&n); err != nil {
+		return nil, fmt.Errorf("count dangling sleep metrics: %w", err)
+	}
+	if n > 0 && !opts.DryRun {
+		if _, err := d.db.ExecContext(ctx, "UPDATE sleep_sessions SET metric_id = NULL WHERE "+dangling); err != nil {
+			return nil, fmt.Errorf("clear dangling sleep metrics: %w", err)
+		}
+	}
+	rep.Orphans = append(rep.Orphans, Orphans{Table: "sleep_sessions.metric_id", Rows: n})
+
+	rep.SizeBefore = d.sizeOnDisk()
+	rep.SizeAfter = rep.SizeBefore
+	if opts.DryRun {
+		return rep, nil
+	}
+	if _, err := d.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return nil, fmt.Errorf("vacuum: %w", err)
+	}
+	// Fold the WAL back in so the size reflects the vacuumed file
+	if _, err := d.db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return nil, fmt.Errorf("checkpoint: %w", err)
+	}
+	rep.SizeAfter = d.sizeOnDisk()
+	return rep, nil
+}
+
+// sizeOnDisk is the database plus its WAL and shared-memory files.
+func (d *DB) sizeOnDisk() int64 {
+	var size int64
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if info, err := os.Stat(d.dbPath + suffix); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+func (s *JSONLStore) maintain(opts MaintenanceOptions) (*MaintenanceReport, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	rep := &MaintenanceReport{Backend: "jsonl"}
+	if info, err := os.Stat(s.path); err == nil {
+		rep.SizeBefore = info.Size()
+	}
+	rep.SizeAfter = rep.SizeBefore
+	if opts.DryRun {
+		return rep, nil
+	}
+
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		rep.SizeAfter = info.Size()
+	}
+	return rep, nil
+}
+
+// entryReader is a directory of markdown entry files and how to parse
+// one, returning its ID.
+type entryReader struct {
+	dir  string
+	read func(path string) (uuid.UUID, error)
+}
+
+// entryReaders lists every directory of entry files with its parser.
+func (s *MarkdownStore) entryReaders() []entryReader {
+	return []entryReader{
+		{s.metricsDir(), readID(readMetricsFile, func(ms []*models.Metric) uuid.UUID { return ms[0].ID })},
+		{s.workoutsDir(), readID(readWorkoutFile, func(w *models.Workout) uuid.UUID { return w.ID })},
+		{s.sleepDir(), readID(readSleepFile, func(ss *models.SleepSession) uuid.UUID { return ss.ID })},
+		{s.medicationsDir(), readID(readMedicationFile, func(m *models.Medication) uuid.UUID { return m.ID })},
+		{s.intakesDir(), readID(readIntakeFile, func(i *models.MedicationIntake) uuid.UUID { return i.ID })},
+		{s.locationsDir(), readID(readLocationFile, func(l *models.Location) uuid.UUID { return l.ID })},
+		{s.tripsDir(), readID(readTripFile, func(t *models.Trip) uuid.UUID { return t.ID })},
+		{s.fastsDir(), readID(readFastFile, func(f *models.Fast) uuid.UUID { return f.ID })},
+		{s.appointmentsDir(), readID(readAppointmentFile, func(a *models.Appointment) uuid.UUID { return a.ID })},
+		{s.eventsDir(), readID(readEventFile, func(e *models.Event) uuid.UUID { return e.ID })},
+	}
+}
+
+// readID adapts a file reader to return just the entry's ID.
+func readID[T any](read func(string) (T, error), id func(T) uuid.UUID) func(string) (uuid.UUID, error) {
+	return func(path string) (uuid.UUID, error) {
+		v, err := read(path)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return id(v), nil
+	}
+}
+
+// walkEntryFiles calls fn for each .md file under dir.
+func walkEntryFiles(ctx context.Context, dir string, fn func(path string) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+func (s *MarkdownStore) maintain(ctx context.Context, opts MaintenanceOptions) (*MaintenanceReport, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	rep := &MaintenanceReport{Backend: "markdown"}
+	for _, r := range s.entryReaders() {
+		err := walkEntryFiles(ctx, r.dir, func(path string) error {
+			if _, rerr := r.read(path); rerr != nil {
+				rel, _ := filepath.Rel(s.dataDir, path)
+				rep.Broken = append(rep.Broken, BrokenFile{Path: rel, Err: rerr})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("check markdown files: %w", err)
+		}
+	}
+
+	if !opts.Quarantine || opts.DryRun || len(rep.Broken) == 0 {
+		return rep, nil
+	}
+	for _, b := range rep.Broken {
+		dst := filepath.Join(s.dataDir, QuarantineDir, b.Path)
+		if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+			return nil, fmt.Errorf("quarantine %s: %w", b.Path, err)
+		}
+		if err := os.Rename(filepath.Join(s.dataDir, b.Path), dst); err != nil {
+			return nil, fmt.Errorf("quarantine %s: %w", b.Path, err)
+		}
+	}
+	rep.Quarantined = true
+	return rep, nil
+}
@@ -0,0 +1,127 @@
+// ABOUTME: Tests for store maintenance on each backend.
+// ABOUTME: Plants orphans and broken files behind the store's back, then checks they are found and fixed.
+package storage
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func TestMaintainSQLiteRemovesOrphans(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	w := models.NewWorkout("run")
+	db.CreateWorkout(ctx, w)
+	db.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+
+	// A connection without foreign_keys, as older versions left behind
+	raw, err := sql.Open("sqlite", db.dbPath)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	_, err = raw.Exec(`INSERT INTO workout_metrics (id, workout_id, metric_name, value) VALUES ('orphan', 'gone', 'distance', 3);
+		INSERT INTO sleep_sessions (id, bed_time, wake_time, metric_id) VALUES ('s1', '2025-01-01 23:00:00', '2025-01-02 07:00:00', 'gone')`)
+	raw.Close()
+	if err != nil {
+		t.Fatalf("plant orphans: %v", err)
+	}
+
+	orphans := func(rep *MaintenanceReport) map[string]int {
+		m := map[string]int{}
+		for _, o := range rep.Orphans {
+			m[o.Table] = o.Rows
+		}
+		return m
+	}
+
+	rep, err := Maintain(ctx, db, MaintenanceOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if got := orphans(rep); got["workout_metrics"] != 1 || got["sleep_sessions.metric_id"] != 1 || got["workout_sets"] != 0 {
+		t.Errorf("dry run orphans = %v", got)
+	}
+
+	if _, err := Maintain(ctx, db, MaintenanceOptions{}); err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	rep, _ = Maintain(ctx, db, MaintenanceOptions{DryRun: true})
+	for table, n := range orphans(rep) {
+		if n != 0 {
+			t.Errorf("%d orphaned %s left after maintenance", n, table)
+		}
+	}
+	if wms, _ := db.ListWorkoutMetrics(ctx, w.ID); len(wms) != 1 {
+		t.Errorf("Expected the real workout metric to survive, got %d", len(wms))
+	}
+}
+
+func TestMaintainJSONLCompacts(t *testing.T) {
+	ctx := t.Context()
+	store, path := setupTestJSONLStore(t)
+
+	for i := 0; i < 20; i++ {
+		m := models.NewMetric(models.MetricWeight, float64(80+i))
+		store.CreateMetric(ctx, m)
+		store.DeleteMetric(ctx, m.ID.String())
+	}
+	rep, err := Maintain(ctx, store, MaintenanceOptions{})
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if rep.SizeAfter >= rep.SizeBefore {
+		t.Errorf("Size %d → %d; expected compaction to shrink the file", rep.SizeBefore, rep.SizeAfter)
+	}
+
+	// Writes after compaction land in the new file
+	kept := models.NewMetric(models.MetricWeight, 81)
+	if err := store.CreateMetric(ctx, kept); err != nil {
+		t.Fatalf("CreateMetric after maintenance failed: %v", err)
+	}
+	store.Close()
+	reopened, err := OpenJSONL(path)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.GetMetric(ctx, kept.ID.String()); err != nil {
+		t.Errorf("metric written after maintenance lost: %v", err)
+	}
+}
+
+func TestMaintainMarkdownQuarantines(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82))
+
+	broken := filepath.Join(store.dataDir, "metrics", "2025", "01", "2025-01-05-weight-deadbeef.md")
+	os.MkdirAll(filepath.Dir(broken), 0750)
+	os.WriteFile(broken, []byte("---\nid: [unclosed\n---\n"), 0600)
+	if _, err := store.ListMetrics(ctx, nil, 0); err == nil {
+		t.Fatal("Expected the broken file to break listing")
+	}
+
+	rep, err := Maintain(ctx, store, MaintenanceOptions{})
+	if err != nil {
+		t.Fatalf("Maintain failed: %v", err)
+	}
+	if len(rep.Broken) != 1 || rep.Broken[0].Path != filepath.Join("metrics", "2025", "01", "2025-01-05-weight-deadbeef.md") || rep.Quarantined {
+		t.Fatalf("report = %+v; want the broken file listed and left in place", rep)
+	}
+
+	if _, err := Maintain(ctx, store, MaintenanceOptions{Quarantine: true}); err != nil {
+		t.Fatalf("Maintain with quarantine failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dataDir, QuarantineDir, rep.Broken[0].Path)); err != nil {
+		t.Errorf("Expected file in quarantine: %v", err)
+	}
+	if metrics, err := store.ListMetrics(ctx, nil, 0); err != nil || len(metrics) != 1 {
+		t.Errorf("ListMetrics after quarantine = %d, %v; want 1, nil", len(metrics), err)
+	}
+}
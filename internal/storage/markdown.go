@@ -4,11 +4,15 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +24,14 @@ import (
 // MarkdownStore provides file-based storage for health data using markdown files.
 type MarkdownStore struct {
 	dataDir string
+	mu      sync.Mutex     // with .lock, serializes writers; see lock
+	indexMu sync.RWMutex   // guards index, which readers use without lock
+	index   *markdownIndex // loaded on first lookup
+	layout  MarkdownLayout // where new files go; nil for the date layout
+
+	// Daily notes that new entries are appended to; see SetDailyNotes
+	dailyNotes       string
+	dailyNoteHeading string
 }
 
 // Compile-time check that MarkdownStore implements Repository.
@@ -33,9 +45,9 @@ func NewMarkdownStore(dataDir string) (*MarkdownStore, error) {
 	return &MarkdownStore{dataDir: dataDir}, nil
 }
 
-// Close releases resources. For MarkdownStore this is a no-op.
+// Close saves the ID index if it changed.
 func (s *MarkdownStore) Close() error {
-	return nil
+	return s.saveIndex()
 }
 
 // metricsDir returns the path to the metrics directory.
@@ -48,45 +60,57 @@ func (s *MarkdownStore) workoutsDir() string {
 	return filepath.Join(s.dataDir, "workouts")
 }
 
-// metricFilePath returns the path for a metric file based on date and type.
-// Format: metrics/YYYY/MM/YYYY-MM-DD-<type>-<id_prefix>.md.
+// metricFilePath returns the path for a metric file under metrics/, as
+// placed by the layout (metrics/YYYY/MM/YYYY-MM-DD-<type>-<id_prefix>.md by
+// default). Metrics given the same path share the file. Dates in paths are local (time.Local, which the CLI sets to the
+// configured timezone); timestamps inside the files are UTC.
 func (s *MarkdownStore) metricFilePath(recordedAt time.Time, metricType models.MetricType, id uuid.UUID) string {
-	year := recordedAt.Format("2006")
-	month := recordedAt.Format("01")
-	date := recordedAt.Format("2006-01-02")
-	return filepath.Join(s.metricsDir(), year, month,
-		fmt.Sprintf("%s-%s-%s.md", date, string(metricType), id.String()[:8]))
+	return filepath.Join(s.metricsDir(), s.layoutOrDefault().Path(recordedAt, string(metricType), id))
 }
 
-// workoutFilePath returns the path for a workout file based on date and type.
-// Format: workouts/YYYY/MM/YYYY-MM-DD-<type>-<id_prefix>.md.
+// workoutFilePath returns the path for a workout file under workouts/, as
+// placed by the layout (workouts/YYYY/MM/YYYY-MM-DD-<type>-<id_prefix>.md by
+// default). Workouts, which carry their own sets and comments, always get
+// a file each: with a layout that shares files, the ID prefix is added to
+// the name.
 func (s *MarkdownStore) workoutFilePath(startedAt time.Time, workoutType string, id uuid.UUID) string {
-	year := startedAt.Format("2006")
-	month := startedAt.Format("01")
-	date := startedAt.Format("2006-01-02")
-	slug := mdstore.Slugify(workoutType)
-	return filepath.Join(s.workoutsDir(), year, month,
-		fmt.Sprintf("%s-%s-%s.md", date, slug, id.String()[:8]))
+	layout := s.layoutOrDefault()
+	path := layout.Path(startedAt, mdstore.Slugify(workoutType), id)
+	if sharesFiles(layout) {
+		path = strings.TrimSuffix(path, ".md") + "-" + id.String()[:8] + ".md"
+	}
+	return filepath.Join(s.workoutsDir(), path)
 }
 
 // metricFrontmatter holds the YAML frontmatter of a metric file.
 type metricFrontmatter struct {
-	ID         string  `yaml:"id"`
-	MetricType string  `yaml:"metric_type"`
-	Value      float64 `yaml:"value"`
-	Unit       string  `yaml:"unit"`
-	RecordedAt string  `yaml:"recorded_at"`
-	CreatedAt  string  `yaml:"created_at"`
+	ID         string            `yaml:"id"`
+	MetricType string            `yaml:"metric_type"`
+	Value      float64           `yaml:"value"`
+	Unit       string            `yaml:"unit"`
+	RecordedAt string            `yaml:"recorded_at"`
+	Location   string            `yaml:"location,omitempty"`
+	ReadingID  string            `yaml:"reading_id,omitempty"`
+	Source     string            `yaml:"source,omitempty"`
+	Metadata   map[string]string `yaml:"metadata,omitempty"`
+	CreatedAt  string            `yaml:"created_at"`
 }
 
 // workoutFrontmatter holds the YAML frontmatter of a workout file.
 type workoutFrontmatter struct {
-	ID              string                     `yaml:"id"`
-	WorkoutType     string                     `yaml:"workout_type"`
-	StartedAt       string                     `yaml:"started_at"`
-	DurationMinutes *int                       `yaml:"duration_minutes,omitempty"`
-	CreatedAt       string                     `yaml:"created_at"`
-	Metrics         []workoutMetricFrontmatter `yaml:"metrics,omitempty"`
+	ID              string                      `yaml:"id"`
+	WorkoutType     string                      `yaml:"workout_type"`
+	StartedAt       string                      `yaml:"started_at"`
+	DurationMinutes *int                        `yaml:"duration_minutes,omitempty"`
+	RPE             *int                        `yaml:"rpe,omitempty"`
+	Zone            *int                        `yaml:"zone,omitempty"`
+	Location        string                      `yaml:"location,omitempty"`
+	Source          string                      `yaml:"source,omitempty"`
+	Metadata        map[string]string           `yaml:"metadata,omitempty"`
+	CreatedAt       string                      `yaml:"created_at"`
+	Metrics         []workoutMetricFrontmatter  `yaml:"metrics,omitempty"`
+	Sets            []workoutSetFrontmatter     `yaml:"sets,omitempty"`
+	Comments        []workoutCommentFrontmatter `yaml:"comments,omitempty"`
 }
 
 // workoutMetricFrontmatter holds workout metric data in frontmatter.
@@ -98,6 +122,25 @@ type workoutMetricFrontmatter struct {
 	CreatedAt  string  `yaml:"created_at"`
 }
 
+// workoutSetFrontmatter holds strength-training set data in frontmatter.
+type workoutSetFrontmatter struct {
+	ID         string   `yaml:"id"`
+	Exercise   string   `yaml:"exercise"`
+	SetNumber  int      `yaml:"set"`
+	Reps       int      `yaml:"reps"`
+	Weight     *float64 `yaml:"weight,omitempty"`
+	WeightUnit string   `yaml:"weight_unit,omitempty"`
+	CreatedAt  string   `yaml:"created_at"`
+}
+
+// workoutCommentFrontmatter holds a comment on the workout in frontmatter.
+type workoutCommentFrontmatter struct {
+	ID        string `yaml:"id"`
+	Author    string `yaml:"author"`
+	Body      string `yaml:"body"`
+	CreatedAt string `yaml:"created_at"`
+}
+
 // metricFromFrontmatter converts frontmatter to a models.Metric.
 func metricFromFrontmatter(fm *metricFrontmatter, notes string) (*models.Metric, error) {
 	id, err := uuid.Parse(fm.ID)
@@ -119,24 +162,45 @@ func metricFromFrontmatter(fm *metricFrontmatter, notes string) (*models.Metric,
 		Value:      fm.Value,
 		Unit:       fm.Unit,
 		RecordedAt: recordedAt,
+		Source:     fm.Source,
+		Metadata:   fm.Metadata,
 		CreatedAt:  createdAt,
 	}
 	if notes != "" {
 		m.Notes = &notes
 	}
+	if fm.Location != "" {
+		m.Location = &fm.Location
+	}
+	if fm.ReadingID != "" {
+		readingID, err := uuid.Parse(fm.ReadingID)
+		if err != nil {
+			return nil, fmt.Errorf("parse reading_id %q: %w", fm.ReadingID, err)
+		}
+		m.ReadingID = &readingID
+	}
 	return m, nil
 }
 
 // metricToFrontmatter converts a models.Metric to frontmatter.
 func metricToFrontmatter(m *models.Metric) metricFrontmatter {
-	return metricFrontmatter{
+	fm := metricFrontmatter{
 		ID:         m.ID.String(),
 		MetricType: string(m.MetricType),
 		Value:      m.Value,
 		Unit:       m.Unit,
 		RecordedAt: mdstore.FormatTime(m.RecordedAt.UTC()),
+		Source:     m.Source,
+		Metadata:   m.Metadata,
 		CreatedAt:  mdstore.FormatTime(m.CreatedAt.UTC()),
 	}
+	if m.Location != nil {
+		fm.Location = *m.Location
+	}
+	if m.ReadingID != nil {
+		fm.ReadingID = m.ReadingID.String()
+	}
+	return fm
 }
 
 // workoutFromFrontmatter converts frontmatter to a models.Workout.
@@ -159,23 +223,38 @@ func workoutFromFrontmatter(fm *workoutFrontmatter, notes string) (*models.Worko
 		WorkoutType:     fm.WorkoutType,
 		StartedAt:       startedAt,
 		DurationMinutes: fm.DurationMinutes,
+		RPE:             fm.RPE,
+		Zone:            fm.Zone,
+		Source:          fm.Source,
+		Metadata:        fm.Metadata,
 		CreatedAt:       createdAt,
 	}
 	if notes != "" {
 		w.Notes = &notes
 	}
+	if fm.Location != "" {
+		w.Location = &fm.Location
+	}
 	return w, nil
 }
 
 // workoutToFrontmatter converts a models.Workout to frontmatter.
 func workoutToFrontmatter(w *models.Workout) workoutFrontmatter {
-	return workoutFrontmatter{
+	fm := workoutFrontmatter{
 		ID:              w.ID.String(),
 		WorkoutType:     w.WorkoutType,
 		StartedAt:       mdstore.FormatTime(w.StartedAt.UTC()),
 		DurationMinutes: w.DurationMinutes,
+		RPE:             w.RPE,
+		Zone:            w.Zone,
+		Source:          w.Source,
+		Metadata:        w.Metadata,
 		CreatedAt:       mdstore.FormatTime(w.CreatedAt.UTC()),
 	}
+	if w.Location != nil {
+		fm.Location = *w.Location
+	}
+	return fm
 }
 
 // workoutMetricFromFrontmatter converts frontmatter to a models.WorkoutMetric.
@@ -217,31 +296,69 @@ func workoutMetricToFrontmatter(wm *models.WorkoutMetric) workoutMetricFrontmatt
 	}
 }
 
-// readMetricFile reads a metric from a markdown file.
-func readMetricFile(path string) (*models.Metric, error) {
-	data, err := os.ReadFile(path)
+// workoutSetFromFrontmatter converts frontmatter to a models.WorkoutSet.
+func workoutSetFromFrontmatter(wsf *workoutSetFrontmatter, workoutID uuid.UUID) (*models.WorkoutSet, error) {
+	id, err := uuid.Parse(wsf.ID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("parse workout set ID %q: %w", wsf.ID, err)
 	}
-
-	yamlStr, body := mdstore.ParseFrontmatter(string(data))
-	if yamlStr == "" {
-		return nil, fmt.Errorf("no frontmatter in %s", path)
+	createdAt, err := mdstore.ParseTime(wsf.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", wsf.CreatedAt, err)
 	}
 
-	var fm metricFrontmatter
-	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
-		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	ws := &models.WorkoutSet{
+		ID:        id,
+		WorkoutID: workoutID,
+		Exercise:  wsf.Exercise,
+		SetNumber: wsf.SetNumber,
+		Reps:      wsf.Reps,
+		Weight:    wsf.Weight,
+		CreatedAt: createdAt,
+	}
+	if wsf.WeightUnit != "" {
+		ws.WeightUnit = &wsf.WeightUnit
 	}
+	return ws, nil
+}
 
-	notes := strings.TrimSpace(body)
-	return metricFromFrontmatter(&fm, notes)
+// workoutSetToFrontmatter converts a models.WorkoutSet to frontmatter.
+func workoutSetToFrontmatter(ws *models.WorkoutSet) workoutSetFrontmatter {
+	unit := ""
+	if ws.WeightUnit != nil {
+		unit = *ws.WeightUnit
+	}
+	return workoutSetFrontmatter{
+		ID:         ws.ID.String(),
+		Exercise:   ws.Exercise,
+		SetNumber:  ws.SetNumber,
+		Reps:       ws.Reps,
+		Weight:     ws.Weight,
+		WeightUnit: unit,
+		CreatedAt:  mdstore.FormatTime(ws.CreatedAt.UTC()),
+	}
 }
 
-// writeMetricFile writes a metric to a markdown file.
+// writeMetricFile writes a metric to its markdown file, alongside any
+// metrics already sharing it.
 func (s *MarkdownStore) writeMetricFile(m *models.Metric) error {
-	fm := metricToFrontmatter(m)
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	path := s.metricFilePath(m.RecordedAt, m.MetricType, m.ID)
+	if err := putMetricsAt(path, m); err != nil {
+		return err
+	}
+	s.indexPut(kindMetric, m.ID.String(), path)
+	return nil
+}
+
+// writeMetricFileAt renders a metric to path.
+func writeMetricFileAt(path string, m *models.Metric) error {
+	fm := metricToFrontmatter(m)
 
 	body := ""
 	if m.Notes != nil && *m.Notes != "" {
@@ -256,6 +373,36 @@ func (s *MarkdownStore) writeMetricFile(m *models.Metric) error {
 	return mdstore.AtomicWrite(path, []byte(content))
 }
 
+// workoutCommentFromFrontmatter converts frontmatter to a models.WorkoutComment.
+func workoutCommentFromFrontmatter(wcf *workoutCommentFrontmatter, workoutID uuid.UUID) (*models.WorkoutComment, error) {
+	id, err := uuid.Parse(wcf.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse workout comment ID %q: %w", wcf.ID, err)
+	}
+	createdAt, err := mdstore.ParseTime(wcf.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", wcf.CreatedAt, err)
+	}
+
+	return &models.WorkoutComment{
+		ID:        id,
+		WorkoutID: workoutID,
+		Author:    wcf.Author,
+		Body:      wcf.Body,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// workoutCommentToFrontmatter converts a models.WorkoutComment to frontmatter.
+func workoutCommentToFrontmatter(c *models.WorkoutComment) workoutCommentFrontmatter {
+	return workoutCommentFrontmatter{
+		ID:        c.ID.String(),
+		Author:    c.Author,
+		Body:      c.Body,
+		CreatedAt: mdstore.FormatTime(c.CreatedAt.UTC()),
+	}
+}
+
 // readWorkoutFile reads a workout from a markdown file.
 func readWorkoutFile(path string) (*models.Workout, error) {
 	data, err := os.ReadFile(path)
@@ -288,19 +435,51 @@ func readWorkoutFile(path string) (*models.Workout, error) {
 		w.Metrics = append(w.Metrics, *wm)
 	}
 
+	// Parse embedded sets from frontmatter
+	for _, wsf := range fm.Sets {
+		ws, err := workoutSetFromFrontmatter(&wsf, w.ID)
+		if err != nil {
+			continue
+		}
+		w.Sets = append(w.Sets, *ws)
+	}
+
+	// Parse embedded comments from frontmatter
+	for _, wcf := range fm.Comments {
+		c, err := workoutCommentFromFrontmatter(&wcf, w.ID)
+		if err != nil {
+			continue
+		}
+		w.Comments = append(w.Comments, *c)
+	}
+
 	return w, nil
 }
 
-// writeWorkoutFile writes a workout (with its metrics) to a markdown file.
+// writeWorkoutFile writes a workout (with its metrics, sets, and comments) to a markdown file.
 func (s *MarkdownStore) writeWorkoutFile(w *models.Workout) error {
+	path := s.workoutFilePath(w.StartedAt, w.WorkoutType, w.ID)
+	if err := writeWorkoutFileAt(path, w); err != nil {
+		return err
+	}
+	s.indexPut(kindWorkout, w.ID.String(), path)
+	return nil
+}
+
+// writeWorkoutFileAt renders a workout with its embedded metrics, sets, and comments to path.
+func writeWorkoutFileAt(path string, w *models.Workout) error {
 	fm := workoutToFrontmatter(w)
 
-	// Include workout metrics in frontmatter
+	// Include workout metrics, sets, and comments in frontmatter
 	for _, wm := range w.Metrics {
 		fm.Metrics = append(fm.Metrics, workoutMetricToFrontmatter(&wm))
 	}
-
-	path := s.workoutFilePath(w.StartedAt, w.WorkoutType, w.ID)
+	for _, ws := range w.Sets {
+		fm.Sets = append(fm.Sets, workoutSetToFrontmatter(&ws))
+	}
+	for _, c := range w.Comments {
+		fm.Comments = append(fm.Comments, workoutCommentToFrontmatter(&c))
+	}
 
 	body := ""
 	if w.Notes != nil && *w.Notes != "" {
@@ -315,7 +494,8 @@ func (s *MarkdownStore) writeWorkoutFile(w *models.Workout) error {
 	return mdstore.AtomicWrite(path, []byte(content))
 }
 
-// walkMetricFiles walks all metric markdown files and calls fn for each.
+// walkMetricFiles walks all metric markdown files and calls fn for each
+// metric in them.
 func (s *MarkdownStore) walkMetricFiles(fn func(path string, m *models.Metric) error) error {
 	metricsDir := s.metricsDir()
 	if _, err := os.Stat(metricsDir); os.IsNotExist(err) {
@@ -330,12 +510,17 @@ func (s *MarkdownStore) walkMetricFiles(fn func(path string, m *models.Metric) e
 			return nil
 		}
 
-		m, err := readMetricFile(path)
+		metrics, err := readMetricsFile(path)
 		if err != nil {
 			return fmt.Errorf("read metric file %s: %w", path, err)
 		}
 
-		return fn(path, m)
+		for _, m := range metrics {
+			if err := fn(path, m); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -363,109 +548,108 @@ func (s *MarkdownStore) walkWorkoutFiles(fn func(path string, w *models.Workout)
 	})
 }
 
-// findMetricFile finds the file path for a metric by ID or prefix.
+// findMetricFile finds the file path for a metric by ID or prefix, and
+// the metric in it.
 func (s *MarkdownStore) findMetricFile(idOrPrefix string) (string, *models.Metric, error) {
-	isFullUUID := len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4
-
-	var foundPath string
-	var foundMetric *models.Metric
-	matchCount := 0
-
-	err := s.walkMetricFiles(func(path string, m *models.Metric) error {
-		idStr := m.ID.String()
-		if isFullUUID {
-			if idStr == idOrPrefix {
-				foundPath = path
-				foundMetric = m
-				matchCount = 1
-				return filepath.SkipAll
-			}
-		} else {
-			if strings.HasPrefix(idStr, idOrPrefix) {
-				foundPath = path
-				foundMetric = m
-				matchCount++
+	for attempt := 0; ; attempt++ {
+		path, err := s.resolveIndexed(kindMetric, idOrPrefix)
+		if err != nil {
+			return "", nil, err
+		}
+		metrics, err := readMetricsFile(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("read metric file %s: %w", path, err)
+		}
+		for _, m := range metrics {
+			if strings.HasPrefix(m.ID.String(), idOrPrefix) {
+				return path, m, nil
 			}
 		}
-		return nil
-	})
-	if err != nil {
-		return "", nil, err
-	}
 
-	if matchCount == 0 {
-		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
-	}
-	if matchCount > 1 {
-		return "", nil, fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+		// The entry left a shared file that's still there, so the index
+		// is stale; rebuild it once
+		if attempt > 0 {
+			return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+		}
+		if err := s.reloadIndex(); err != nil {
+			return "", nil, err
+		}
 	}
-
-	return foundPath, foundMetric, nil
 }
 
 // findWorkoutFile finds the file path for a workout by ID or prefix.
 func (s *MarkdownStore) findWorkoutFile(idOrPrefix string) (string, *models.Workout, error) {
-	isFullUUID := len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4
-
-	var foundPath string
-	var foundWorkout *models.Workout
-	matchCount := 0
-
-	err := s.walkWorkoutFiles(func(path string, w *models.Workout) error {
-		idStr := w.ID.String()
-		if isFullUUID {
-			if idStr == idOrPrefix {
-				foundPath = path
-				foundWorkout = w
-				matchCount = 1
-				return filepath.SkipAll
-			}
-		} else {
-			if strings.HasPrefix(idStr, idOrPrefix) {
-				foundPath = path
-				foundWorkout = w
-				matchCount++
-			}
-		}
-		return nil
-	})
+	path, err := s.resolveIndexed(kindWorkout, idOrPrefix)
 	if err != nil {
 		return "", nil, err
 	}
+	w, err := readWorkoutFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("read workout file %s: %w", path, err)
+	}
+	return path, w, nil
+}
 
-	if matchCount == 0 {
-		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+// paginate returns the window of items starting at offset, capped at limit
+// entries when limit is positive.
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return nil
+		}
+		items = items[offset:]
 	}
-	if matchCount > 1 {
-		return "", nil, fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
 	}
-
-	return foundPath, foundWorkout, nil
+	return items
 }
 
 // --- Repository interface methods ---
 
-// CreateMetric stores a new metric as a markdown file.
-func (s *MarkdownStore) CreateMetric(m *models.Metric) error {
-	return s.writeMetricFile(m)
+// CreateMetric stores a new metric in a markdown file.
+func (s *MarkdownStore) CreateMetric(ctx context.Context, m *models.Metric) error {
+	if err := s.writeMetricFile(m); err != nil {
+		return err
+	}
+	return s.noteMetric(m)
 }
 
 // GetMetric retrieves a metric by ID or ID prefix.
-func (s *MarkdownStore) GetMetric(idOrPrefix string) (*models.Metric, error) {
+func (s *MarkdownStore) GetMetric(ctx context.Context, idOrPrefix string) (*models.Metric, error) {
 	_, m, err := s.findMetricFile(idOrPrefix)
 	return m, err
 }
 
 // ListMetrics retrieves metrics with optional filtering by type.
 // Results are sorted by RecordedAt descending (most recent first).
-func (s *MarkdownStore) ListMetrics(metricType *models.MetricType, limit int) ([]*models.Metric, error) {
-	var metrics []*models.Metric
+func (s *MarkdownStore) ListMetrics(ctx context.Context, metricType *models.MetricType, limit int) ([]*models.Metric, error) {
+	return s.QueryMetrics(ctx, MetricFilter{Type: metricType, Limit: limit})
+}
 
-	err := s.walkMetricFiles(func(path string, m *models.Metric) error {
-		if metricType != nil && m.MetricType != *metricType {
-			return nil
+// QueryMetrics retrieves metrics matching the filter.
+// Results are sorted by RecordedAt descending (most recent first).
+func (s *MarkdownStore) QueryMetrics(ctx context.Context, filter MetricFilter) ([]*models.Metric, error) {
+	start := time.Now()
+	metrics, ok, err := s.scanMetrics(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("list metrics: %w", err)
+	}
+	if ok {
+		slog.DebugContext(ctx, "markdown metric query", "matched", len(metrics), "duration", time.Since(start))
+		return paginate(metrics, filter.Offset, filter.Limit), nil
+	}
+	slog.DebugContext(ctx, "markdown metric query: unexpected layout, reading every file", "data_dir", s.dataDir)
+
+	// Files outside the YYYY/MM layout: read everything
+	metrics = nil
+	err = s.walkMetricFiles(func(path string, m *models.Metric) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if matchesMetric(m, filter) {
+			metrics = append(metrics, m)
 		}
-		metrics = append(metrics, m)
 		return nil
 	})
 	if err != nil {
@@ -477,30 +661,108 @@ func (s *MarkdownStore) ListMetrics(metricType *models.MetricType, limit int) ([
 		return metrics[i].RecordedAt.After(metrics[j].RecordedAt)
 	})
 
-	if limit > 0 && len(metrics) > limit {
-		metrics = metrics[:limit]
+	slog.DebugContext(ctx, "markdown metric query", "matched", len(metrics), "duration", time.Since(start))
+	return paginate(metrics, filter.Offset, filter.Limit), nil
+}
+
+// SumMetrics adds up the values of all metrics matching the filter.
+// Limit and Offset are ignored.
+func (s *MarkdownStore) SumMetrics(ctx context.Context, filter MetricFilter) (*MetricTotal, error) {
+	agg, err := s.aggregateMetrics(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("sum metrics: %w", err)
 	}
+	return agg.total(), nil
+}
 
-	return metrics, nil
+// AvgMetrics averages the values of all metrics matching the filter and
+// finds their range. Limit and Offset are ignored.
+func (s *MarkdownStore) AvgMetrics(ctx context.Context, filter MetricFilter) (*MetricAverage, error) {
+	agg, err := s.aggregateMetrics(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("average metrics: %w", err)
+	}
+	return agg.average(), nil
 }
 
-// DeleteMetric removes a metric file by ID or prefix.
-func (s *MarkdownStore) DeleteMetric(idOrPrefix string) error {
-	path, _, err := s.findMetricFile(idOrPrefix)
+// CountMetrics counts the metrics matching filter, ignoring its Limit and
+// Offset.
+func (s *MarkdownStore) CountMetrics(ctx context.Context, filter MetricFilter) (int, error) {
+	agg, err := s.aggregateMetrics(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count metrics: %w", err)
+	}
+	return agg.count, nil
+}
+
+// DeleteMetric removes a metric by ID or prefix, and its file once no
+// other metric shares it.
+func (s *MarkdownStore) DeleteMetric(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, m, err := s.findMetricFile(idOrPrefix)
 	if err != nil {
 		return fmt.Errorf("delete metric: %w", err)
 	}
 
-	if err := os.Remove(path); err != nil {
-		return fmt.Errorf("delete metric file: %w", err)
+	if err := removeMetricAt(path, m.ID); err != nil {
+		return fmt.Errorf("delete metric: %w", err)
+	}
+	s.indexDelete(kindMetric, m.ID.String())
+	return nil
+}
+
+// SetMetricMetadata sets one metadata key on a metric, or removes it when
+// value is empty.
+func (s *MarkdownStore) SetMetricMetadata(ctx context.Context, idOrPrefix, key, value string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, m, err := s.findMetricFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set metric metadata: %w", err)
+	}
+	m.Metadata = withMetadataKey(m.Metadata, key, value)
+	return putMetricsAt(path, m)
+}
+
+// UpdateMetric replaces the stored metric that has m's ID with m, moving
+// it to another file when the type or recorded time places it elsewhere.
+func (s *MarkdownStore) UpdateMetric(ctx context.Context, m *models.Metric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	oldPath, _, err := s.findMetricFile(m.ID.String())
+	if err != nil {
+		return fmt.Errorf("update metric: %w", err)
+	}
+	path := s.metricFilePath(m.RecordedAt, m.MetricType, m.ID)
+	if err := putMetricsAt(path, m); err != nil {
+		return err
+	}
+	if path != oldPath {
+		if err := removeMetricAt(oldPath, m.ID); err != nil {
+			return fmt.Errorf("update metric: remove from old file: %w", err)
+		}
+		s.indexPut(kindMetric, m.ID.String(), path)
 	}
 	return nil
 }
 
 // GetLatestMetric returns the most recent metric of a specific type.
-func (s *MarkdownStore) GetLatestMetric(metricType models.MetricType) (*models.Metric, error) {
+func (s *MarkdownStore) GetLatestMetric(ctx context.Context, metricType models.MetricType) (*models.Metric, error) {
 	mt := metricType
-	metrics, err := s.ListMetrics(&mt, 1)
+	metrics, err := s.ListMetrics(ctx, &mt, 1)
 	if err != nil {
 		return nil, err
 	}
@@ -511,38 +773,60 @@ func (s *MarkdownStore) GetLatestMetric(metricType models.MetricType) (*models.M
 }
 
 // CreateWorkout stores a new workout as a markdown file.
-func (s *MarkdownStore) CreateWorkout(w *models.Workout) error {
-	return s.writeWorkoutFile(w)
+func (s *MarkdownStore) CreateWorkout(ctx context.Context, w *models.Workout) error {
+	if err := s.writeWorkoutFile(w); err != nil {
+		return err
+	}
+	return s.noteWorkout(w)
 }
 
 // GetWorkout retrieves a workout by ID or ID prefix (without metrics).
-func (s *MarkdownStore) GetWorkout(idOrPrefix string) (*models.Workout, error) {
+func (s *MarkdownStore) GetWorkout(ctx context.Context, idOrPrefix string) (*models.Workout, error) {
 	_, w, err := s.findWorkoutFile(idOrPrefix)
 	if err != nil {
 		return nil, err
 	}
-	// Clear metrics for plain GetWorkout
+	// Clear children for plain GetWorkout
 	w.Metrics = nil
+	w.Sets = nil
+	w.Comments = nil
 	return w, nil
 }
 
-// GetWorkoutWithMetrics retrieves a workout with all its associated metrics.
-func (s *MarkdownStore) GetWorkoutWithMetrics(idOrPrefix string) (*models.Workout, error) {
+// GetWorkoutWithMetrics retrieves a workout with all its associated metrics, sets, and comments.
+func (s *MarkdownStore) GetWorkoutWithMetrics(ctx context.Context, idOrPrefix string) (*models.Workout, error) {
 	_, w, err := s.findWorkoutFile(idOrPrefix)
 	return w, err
 }
 
 // ListWorkouts retrieves workouts with optional filtering by type.
 // Results are sorted by StartedAt descending (most recent first).
-func (s *MarkdownStore) ListWorkouts(workoutType *string, limit int) ([]*models.Workout, error) {
+func (s *MarkdownStore) ListWorkouts(ctx context.Context, workoutType *string, limit int) ([]*models.Workout, error) {
+	return s.QueryWorkouts(ctx, WorkoutFilter{Type: workoutType, Limit: limit})
+}
+
+// QueryWorkouts retrieves workouts matching the filter.
+// Results are sorted by StartedAt descending (most recent first).
+func (s *MarkdownStore) QueryWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, error) {
 	var workouts []*models.Workout
 
 	err := s.walkWorkoutFiles(func(path string, w *models.Workout) error {
-		if workoutType != nil && !strings.EqualFold(w.WorkoutType, *workoutType) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if filter.Type != nil && !strings.EqualFold(w.WorkoutType, *filter.Type) {
 			return nil
 		}
-		// Clear metrics for list view
+		if !matchesLocation(w.Location, filter.Location) || !matchesSource(w.Source, filter.Source) {
+			return nil
+		}
+		if !inRange(w.StartedAt, filter.Since, filter.Until) {
+			return nil
+		}
+		// Clear children for list view
 		w.Metrics = nil
+		w.Sets = nil
+		w.Comments = nil
 		workouts = append(workouts, w)
 		return nil
 	})
@@ -555,16 +839,29 @@ func (s *MarkdownStore) ListWorkouts(workoutType *string, limit int) ([]*models.
 		return workouts[i].StartedAt.After(workouts[j].StartedAt)
 	})
 
-	if limit > 0 && len(workouts) > limit {
-		workouts = workouts[:limit]
-	}
+	return paginate(workouts, filter.Offset, filter.Limit), nil
+}
 
-	return workouts, nil
+// CountWorkouts counts the workouts matching filter, ignoring its Limit
+// and Offset.
+func (s *MarkdownStore) CountWorkouts(ctx context.Context, filter WorkoutFilter) (int, error) {
+	filter.Limit, filter.Offset = 0, 0
+	workouts, err := s.QueryWorkouts(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("count workouts: %w", err)
+	}
+	return len(workouts), nil
 }
 
 // DeleteWorkout removes a workout file by ID or prefix (cascade deletes metrics).
-func (s *MarkdownStore) DeleteWorkout(idOrPrefix string) error {
-	path, _, err := s.findWorkoutFile(idOrPrefix)
+func (s *MarkdownStore) DeleteWorkout(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, w, err := s.findWorkoutFile(idOrPrefix)
 	if err != nil {
 		return fmt.Errorf("delete workout: %w", err)
 	}
@@ -572,40 +869,97 @@ func (s *MarkdownStore) DeleteWorkout(idOrPrefix string) error {
 	if err := os.Remove(path); err != nil {
 		return fmt.Errorf("delete workout file: %w", err)
 	}
+	s.indexDelete(kindWorkout, w.ID.String())
+	return nil
+}
+
+// SetWorkoutMetadata sets one metadata key on a workout, or removes it
+// when value is empty.
+func (s *MarkdownStore) SetWorkoutMetadata(ctx context.Context, idOrPrefix, key, value string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, w, err := s.findWorkoutFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set workout metadata: %w", err)
+	}
+	w.Metadata = withMetadataKey(w.Metadata, key, value)
+	return writeWorkoutFileAt(path, w)
+}
+
+// UpdateWorkout replaces the stored workout that has w's ID with w's own
+// fields, keeping the metrics, sets, and comments in its file, and moves
+// the file if its date or type changed where it belongs.
+func (s *MarkdownStore) UpdateWorkout(ctx context.Context, w *models.Workout) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	oldPath, old, err := s.findWorkoutFile(w.ID.String())
+	if err != nil {
+		return fmt.Errorf("update workout: %w", err)
+	}
+	updated := *w
+	updated.Metrics, updated.Sets, updated.Comments = old.Metrics, old.Sets, old.Comments
+	path := s.workoutFilePath(w.StartedAt, w.WorkoutType, w.ID)
+	if err := writeWorkoutFileAt(path, &updated); err != nil {
+		return err
+	}
+	if path != oldPath {
+		if err := os.Remove(oldPath); err != nil {
+			return fmt.Errorf("update workout: remove old file: %w", err)
+		}
+		s.indexPut(kindWorkout, w.ID.String(), path)
+	}
 	return nil
 }
 
 // AddWorkoutMetric adds a metric to an existing workout by re-writing the workout file.
-func (s *MarkdownStore) AddWorkoutMetric(wm *models.WorkoutMetric) error {
+func (s *MarkdownStore) AddWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	path, w, err := s.findWorkoutFile(wm.WorkoutID.String())
 	if err != nil {
 		return fmt.Errorf("add workout metric: workout not found: %w", err)
 	}
 
-	// Add the new metric to the workout
+	// Add the new metric to the workout and rewrite the file
 	w.Metrics = append(w.Metrics, *wm)
+	return writeWorkoutFileAt(path, w)
+}
 
-	// Rewrite the file
-	fm := workoutToFrontmatter(w)
-	for _, existing := range w.Metrics {
-		fm.Metrics = append(fm.Metrics, workoutMetricToFrontmatter(&existing))
-	}
-
-	body := ""
-	if w.Notes != nil && *w.Notes != "" {
-		body = "\n" + *w.Notes + "\n"
+// UpdateWorkoutMetric replaces the metric that has wm's ID in the file of
+// the workout wm.WorkoutID with wm.
+func (s *MarkdownStore) UpdateWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	content, err := mdstore.RenderFrontmatter(&fm, body)
+	path, w, err := s.findWorkoutFile(wm.WorkoutID.String())
 	if err != nil {
-		return fmt.Errorf("render workout file: %w", err)
+		return fmt.Errorf("update workout metric: workout not found: %w", err)
 	}
-
-	return mdstore.AtomicWrite(path, []byte(content))
+	i := slices.IndexFunc(w.Metrics, func(m models.WorkoutMetric) bool { return m.ID == wm.ID })
+	if i < 0 {
+		return fmt.Errorf("update workout metric: not found: %s on workout %s", wm.ID, wm.WorkoutID)
+	}
+	w.Metrics[i] = *wm
+	return writeWorkoutFileAt(path, w)
 }
 
 // GetWorkoutMetric retrieves a workout metric by ID or ID prefix.
-func (s *MarkdownStore) GetWorkoutMetric(idOrPrefix string) (*models.WorkoutMetric, error) {
+func (s *MarkdownStore) GetWorkoutMetric(ctx context.Context, idOrPrefix string) (*models.WorkoutMetric, error) {
 	isFullUUID := len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4
 
 	var found *models.WorkoutMetric
@@ -638,14 +992,14 @@ func (s *MarkdownStore) GetWorkoutMetric(idOrPrefix string) (*models.WorkoutMetr
 		return nil, fmt.Errorf("not found: %s", idOrPrefix)
 	}
 	if matchCount > 1 {
-		return nil, fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+		return nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
 	}
 
 	return found, nil
 }
 
 // ListWorkoutMetrics retrieves all workout metrics for a specific workout.
-func (s *MarkdownStore) ListWorkoutMetrics(workoutID uuid.UUID) ([]*models.WorkoutMetric, error) {
+func (s *MarkdownStore) ListWorkoutMetrics(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutMetric, error) {
 	_, w, err := s.findWorkoutFile(workoutID.String())
 	if err != nil {
 		return nil, fmt.Errorf("list workout metrics: %w", err)
@@ -665,7 +1019,13 @@ func (s *MarkdownStore) ListWorkoutMetrics(workoutID uuid.UUID) ([]*models.Worko
 }
 
 // DeleteWorkoutMetric removes a workout metric by re-writing the workout file.
-func (s *MarkdownStore) DeleteWorkoutMetric(idOrPrefix string) error {
+func (s *MarkdownStore) DeleteWorkoutMetric(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	isFullUUID := len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4
 
 	var targetPath string
@@ -673,7 +1033,7 @@ func (s *MarkdownStore) DeleteWorkoutMetric(idOrPrefix string) error {
 	var targetIndex = -1
 	matchCount := 0
 
-	err := s.walkWorkoutFiles(func(path string, w *models.Workout) error {
+	err = s.walkWorkoutFiles(func(path string, w *models.Workout) error {
 		for i := range w.Metrics {
 			wm := &w.Metrics[i]
 			idStr := wm.ID.String()
@@ -704,34 +1064,91 @@ func (s *MarkdownStore) DeleteWorkoutMetric(idOrPrefix string) error {
 		return fmt.Errorf("not found: %s", idOrPrefix)
 	}
 	if matchCount > 1 {
-		return fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+		return fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
 	}
 
 	// Remove the metric from the slice
 	targetWorkout.Metrics = append(targetWorkout.Metrics[:targetIndex], targetWorkout.Metrics[targetIndex+1:]...)
 
-	// Rewrite the file
-	fm := workoutToFrontmatter(targetWorkout)
-	for _, wm := range targetWorkout.Metrics {
-		fm.Metrics = append(fm.Metrics, workoutMetricToFrontmatter(&wm))
+	return writeWorkoutFileAt(targetPath, targetWorkout)
+}
+
+// AddWorkoutSet adds a set to an existing workout by re-writing the workout file.
+func (s *MarkdownStore) AddWorkoutSet(ctx context.Context, ws *models.WorkoutSet) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	body := ""
-	if targetWorkout.Notes != nil && *targetWorkout.Notes != "" {
-		body = "\n" + *targetWorkout.Notes + "\n"
+	path, w, err := s.findWorkoutFile(ws.WorkoutID.String())
+	if err != nil {
+		return fmt.Errorf("add workout set: workout not found: %w", err)
 	}
 
-	content, err := mdstore.RenderFrontmatter(&fm, body)
+	w.Sets = append(w.Sets, *ws)
+	return writeWorkoutFileAt(path, w)
+}
+
+// ListWorkoutSets retrieves all sets for a specific workout in the order they were logged.
+func (s *MarkdownStore) ListWorkoutSets(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutSet, error) {
+	_, w, err := s.findWorkoutFile(workoutID.String())
 	if err != nil {
-		return fmt.Errorf("render workout file: %w", err)
+		return nil, fmt.Errorf("list workout sets: %w", err)
+	}
+
+	sets := make([]*models.WorkoutSet, 0, len(w.Sets))
+	for i := range w.Sets {
+		sets = append(sets, &w.Sets[i])
 	}
 
-	return mdstore.AtomicWrite(targetPath, []byte(content))
+	// File order is insertion order; keep it stable within equal timestamps
+	sort.SliceStable(sets, func(i, j int) bool {
+		return sets[i].CreatedAt.Before(sets[j].CreatedAt)
+	})
+
+	return sets, nil
+}
+
+// AddWorkoutComment adds a comment to an existing workout by re-writing the workout file.
+func (s *MarkdownStore) AddWorkoutComment(ctx context.Context, c *models.WorkoutComment) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, w, err := s.findWorkoutFile(c.WorkoutID.String())
+	if err != nil {
+		return fmt.Errorf("add workout comment: workout not found: %w", err)
+	}
+
+	w.Comments = append(w.Comments, *c)
+	return writeWorkoutFileAt(path, w)
+}
+
+// ListWorkoutComments retrieves all comments on a workout, oldest first.
+func (s *MarkdownStore) ListWorkoutComments(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutComment, error) {
+	_, w, err := s.findWorkoutFile(workoutID.String())
+	if err != nil {
+		return nil, fmt.Errorf("list workout comments: %w", err)
+	}
+
+	comments := make([]*models.WorkoutComment, 0, len(w.Comments))
+	for i := range w.Comments {
+		comments = append(comments, &w.Comments[i])
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+
+	return comments, nil
 }
 
 // GetAllData retrieves all data for export.
-func (s *MarkdownStore) GetAllData() (*ExportData, error) {
-	metrics, err := s.ListMetrics(nil, 0)
+func (s *MarkdownStore) GetAllData(ctx context.Context) (*ExportData, error) {
+	metrics, err := s.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		return nil, fmt.Errorf("list metrics: %w", err)
 	}
@@ -746,36 +1163,100 @@ func (s *MarkdownStore) GetAllData() (*ExportData, error) {
 		return nil, fmt.Errorf("list workouts: %w", err)
 	}
 
+	sleepSessions, err := s.ListSleepSessions(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	medications, err := s.ListMedications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	intakes, err := s.ListMedicationIntakes(ctx, IntakeFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.ListLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	trips, err := s.ListTrips(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fasts, err := s.ListFasts(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	appointments, err := s.ListAppointments(ctx, AppointmentFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.ListEvents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ExportData{
-		Version:    "1.0",
-		ExportedAt: time.Now(),
-		Tool:       "health",
-		Metrics:    metrics,
-		Workouts:   workouts,
+		Version:           ExportFormatVersion,
+		ExportedAt:        time.Now(),
+		Tool:              "health",
+		Metrics:           metrics,
+		Workouts:          workouts,
+		SleepSessions:     sleepSessions,
+		Medications:       medications,
+		MedicationIntakes: intakes,
+		Locations:         locations,
+		Trips:             trips,
+		Fasts:             fasts,
+		Appointments:      appointments,
+		Events:            events,
 	}, nil
 }
 
 // ImportData imports data from an export format.
-func (s *MarkdownStore) ImportData(data *ExportData) error {
-	// Import metrics
-	for _, m := range data.Metrics {
-		if err := s.CreateMetric(m); err != nil {
-			return fmt.Errorf("import metric: %w", err)
-		}
+func (s *MarkdownStore) ImportData(ctx context.Context, data *ExportData) error {
+	if err := s.CreateMetrics(ctx, data.Metrics); err != nil {
+		return fmt.Errorf("import metrics: %w", err)
 	}
 
-	// Import workouts and their metrics
+	// Import workouts; metrics, sets, and comments are embedded in the workout file
 	for _, w := range data.Workouts {
-		if err := s.CreateWorkout(w); err != nil {
-			return fmt.Errorf("import workout: %w", err)
+		for i := range w.Metrics {
+			w.Metrics[i].WorkoutID = w.ID
 		}
-		for _, wm := range w.Metrics {
-			wm.WorkoutID = w.ID
-			if err := s.AddWorkoutMetric(&wm); err != nil {
-				return fmt.Errorf("import workout metric: %w", err)
-			}
+		for i := range w.Sets {
+			w.Sets[i].WorkoutID = w.ID
+		}
+		for i := range w.Comments {
+			w.Comments[i].WorkoutID = w.ID
 		}
 	}
+	if err := s.CreateWorkouts(ctx, data.Workouts); err != nil {
+		return fmt.Errorf("import workouts: %w", err)
+	}
 
-	return nil
+	// Import sleep sessions; derived sleep_hours metrics came in with data.Metrics
+	for _, ss := range data.SleepSessions {
+		if err := s.CreateSleepSession(ctx, ss); err != nil {
+			return fmt.Errorf("import sleep session: %w", err)
+		}
+	}
+
+	if err := importMedications(ctx, s, data); err != nil {
+		return err
+	}
+	if err := importLocations(ctx, s, data); err != nil {
+		return err
+	}
+	if err := importFasts(ctx, s, data); err != nil {
+		return err
+	}
+	return importAppointments(ctx, s, data)
 }
@@ -0,0 +1,235 @@
+// ABOUTME: Appointment storage for the markdown backend.
+// ABOUTME: Stores one file per appointment in appointments/; the visit summary is the body.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// appointmentFrontmatter holds the YAML frontmatter of an appointment file.
+type appointmentFrontmatter struct {
+	ID              string `yaml:"id"`
+	Provider        string `yaml:"provider"`
+	ScheduledAt     string `yaml:"scheduled_at"`
+	DurationMinutes *int   `yaml:"duration_minutes,omitempty"`
+	Location        string `yaml:"location,omitempty"`
+	Reason          string `yaml:"reason,omitempty"`
+	CreatedAt       string `yaml:"created_at"`
+}
+
+// appointmentsDir returns the path to the appointments directory.
+func (s *MarkdownStore) appointmentsDir() string {
+	return filepath.Join(s.dataDir, "appointments")
+}
+
+// appointmentFilePath returns the path for an appointment file.
+// Format: appointments/YYYY-MM-DD-<provider>-<id_prefix>.md, dated by the visit.
+func (s *MarkdownStore) appointmentFilePath(a *models.Appointment) string {
+	return filepath.Join(s.appointmentsDir(), fmt.Sprintf("%s-%s-%s.md",
+		a.ScheduledAt.Local().Format("2006-01-02"), mdstore.Slugify(a.Provider), a.ID.String()[:8]))
+}
+
+// readAppointmentFile reads an appointment from a markdown file.
+func readAppointmentFile(path string) (*models.Appointment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm appointmentFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse appointment ID %q: %w", fm.ID, err)
+	}
+	scheduledAt, err := mdstore.ParseTime(fm.ScheduledAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse scheduled_at %q: %w", fm.ScheduledAt, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	a := &models.Appointment{
+		ID:              id,
+		Provider:        fm.Provider,
+		ScheduledAt:     scheduledAt,
+		DurationMinutes: fm.DurationMinutes,
+		CreatedAt:       createdAt,
+	}
+	if fm.Location != "" {
+		a.Location = &fm.Location
+	}
+	if fm.Reason != "" {
+		a.Reason = &fm.Reason
+	}
+	if summary := strings.TrimSpace(body); summary != "" {
+		a.Summary = &summary
+	}
+	return a, nil
+}
+
+// writeAppointmentFile writes an appointment to a markdown file.
+func (s *MarkdownStore) writeAppointmentFile(a *models.Appointment) error {
+	fm := appointmentFrontmatter{
+		ID:              a.ID.String(),
+		Provider:        a.Provider,
+		ScheduledAt:     mdstore.FormatTime(a.ScheduledAt.UTC()),
+		DurationMinutes: a.DurationMinutes,
+		CreatedAt:       mdstore.FormatTime(a.CreatedAt.UTC()),
+	}
+	if a.Location != nil {
+		fm.Location = *a.Location
+	}
+	if a.Reason != nil {
+		fm.Reason = *a.Reason
+	}
+
+	body := ""
+	if a.Summary != nil && *a.Summary != "" {
+		body = "\n" + *a.Summary + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render appointment file: %w", err)
+	}
+
+	return mdstore.AtomicWrite(s.appointmentFilePath(a), []byte(content))
+}
+
+// appointmentFiles returns every appointment keyed by its file path.
+func (s *MarkdownStore) appointmentFiles() (map[string]*models.Appointment, error) {
+	entries, err := os.ReadDir(s.appointmentsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read appointments directory: %w", err)
+	}
+
+	appts := make(map[string]*models.Appointment)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		path := filepath.Join(s.appointmentsDir(), e.Name())
+		a, err := readAppointmentFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read appointment file %s: %w", path, err)
+		}
+		appts[path] = a
+	}
+	return appts, nil
+}
+
+// findAppointmentFile finds the file path for an appointment by ID or prefix.
+func (s *MarkdownStore) findAppointmentFile(idOrPrefix string) (string, *models.Appointment, error) {
+	appts, err := s.appointmentFiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var foundPath string
+	var found *models.Appointment
+	for path, a := range appts {
+		if !strings.HasPrefix(a.ID.String(), idOrPrefix) {
+			continue
+		}
+		if found != nil {
+			return "", nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+		}
+		foundPath, found = path, a
+	}
+	if found == nil {
+		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	return foundPath, found, nil
+}
+
+// CreateAppointment stores a new appointment as a markdown file.
+func (s *MarkdownStore) CreateAppointment(ctx context.Context, a *models.Appointment) error {
+	return s.writeAppointmentFile(a)
+}
+
+// GetAppointment retrieves an appointment by ID or ID prefix.
+func (s *MarkdownStore) GetAppointment(ctx context.Context, idOrPrefix string) (*models.Appointment, error) {
+	_, a, err := s.findAppointmentFile(idOrPrefix)
+	return a, err
+}
+
+// ListAppointments retrieves appointments matching the filter, soonest first.
+func (s *MarkdownStore) ListAppointments(ctx context.Context, filter AppointmentFilter) ([]*models.Appointment, error) {
+	files, err := s.appointmentFiles()
+	if err != nil {
+		return nil, fmt.Errorf("list appointments: %w", err)
+	}
+
+	appts := make([]*models.Appointment, 0, len(files))
+	for _, a := range files {
+		if inRange(a.ScheduledAt, filter.Since, filter.Until) {
+			appts = append(appts, a)
+		}
+	}
+	sort.Slice(appts, func(i, j int) bool {
+		return appts[i].ScheduledAt.Before(appts[j].ScheduledAt)
+	})
+
+	return paginate(appts, 0, filter.Limit), nil
+}
+
+// SetAppointmentSummary links a visit summary to an appointment, replacing
+// any earlier one.
+func (s *MarkdownStore) SetAppointmentSummary(ctx context.Context, idOrPrefix string, summary string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, a, err := s.findAppointmentFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set appointment summary: %w", err)
+	}
+	a.Summary = &summary
+	return s.writeAppointmentFile(a)
+}
+
+// DeleteAppointment removes an appointment file by ID or prefix.
+func (s *MarkdownStore) DeleteAppointment(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, _, err := s.findAppointmentFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete appointment: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete appointment file: %w", err)
+	}
+	return nil
+}
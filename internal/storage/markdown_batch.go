@@ -0,0 +1,116 @@
+// ABOUTME: Bulk metric and workout creation for the markdown backend.
+// ABOUTME: Writes files on a small worker pool, then updates the index once.
+
+package storage
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// maxParallelWrites caps concurrent file writes; past a handful the disk,
+// not the CPU, is the bottleneck.
+const maxParallelWrites = 8
+
+// writeParallel calls write(i) for i in [0, n) on a worker pool. It returns
+// the first error, along with which writes succeeded so the caller can
+// index exactly the files that exist.
+func writeParallel(n int, write func(i int) error) ([]bool, error) {
+	done := make([]bool, n)
+	jobs := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	workers := min(n, runtime.NumCPU(), maxParallelWrites)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				err := write(i)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				done[i] = err == nil
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return done, firstErr
+}
+
+// CreateMetrics stores many metrics, writing their files in parallel.
+// Metrics written before an error stay stored.
+func (s *MarkdownStore) CreateMetrics(ctx context.Context, metrics []*models.Metric) error {
+	if err := s.writeMetricFiles(metrics); err != nil {
+		return err
+	}
+	// A blood pressure reading is one entry to the user, not an import
+	if len(metrics) == 2 && metrics[0].IsBloodPressure() && models.SameReading(metrics[0], metrics[1]) {
+		return s.noteReading(metrics[0], metrics[1])
+	}
+	return nil
+}
+
+// writeMetricFiles writes metrics to their files, each file once with all
+// of its new metrics, and indexes the ones written.
+func (s *MarkdownStore) writeMetricFiles(metrics []*models.Metric) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var paths []string
+	byPath := make(map[string][]*models.Metric)
+	for _, m := range metrics {
+		path := s.metricFilePath(m.RecordedAt, m.MetricType, m.ID)
+		if _, ok := byPath[path]; !ok {
+			paths = append(paths, path)
+		}
+		byPath[path] = append(byPath[path], m)
+	}
+	done, err := writeParallel(len(paths), func(i int) error {
+		return putMetricsAt(paths[i], byPath[paths[i]]...)
+	})
+	for i, ok := range done {
+		if !ok {
+			continue
+		}
+		for _, m := range byPath[paths[i]] {
+			s.indexPut(kindMetric, m.ID.String(), paths[i])
+		}
+	}
+	return err
+}
+
+// CreateWorkouts stores many workouts, with any children they carry,
+// writing their files in parallel. Workouts written before an error stay
+// stored.
+func (s *MarkdownStore) CreateWorkouts(ctx context.Context, workouts []*models.Workout) error {
+	paths := make([]string, len(workouts))
+	for i, w := range workouts {
+		paths[i] = s.workoutFilePath(w.StartedAt, w.WorkoutType, w.ID)
+	}
+	done, err := writeParallel(len(workouts), func(i int) error {
+		return writeWorkoutFileAt(paths[i], workouts[i])
+	})
+	for i, ok := range done {
+		if ok {
+			s.indexPut(kindWorkout, workouts[i].ID.String(), paths[i])
+		}
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+// ABOUTME: Optional Obsidian-style daily notes for the markdown backend.
+// ABOUTME: New metrics and workouts are appended as list items under a heading in YYYY-MM-DD.md.
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+)
+
+// DefaultDailyNoteHeading is the section entries are appended under.
+const DefaultDailyNoteHeading = "## Health"
+
+// SetDailyNotes turns on appending entries to daily notes named
+// YYYY-MM-DD.md in dir, under heading (DefaultDailyNoteHeading when
+// empty). The per-entry files stay the source of truth; notes are only
+// written to, never read. Metrics, blood pressure readings, and workouts
+// added one at a time are noted; bulk imports aren't.
+func (s *MarkdownStore) SetDailyNotes(dir, heading string) {
+	if heading == "" {
+		heading = DefaultDailyNoteHeading
+	}
+	s.dailyNotes, s.dailyNoteHeading = dir, heading
+}
+
+// noteMetric appends a metric to its day's note.
+func (s *MarkdownStore) noteMetric(m *models.Metric) error {
+	line := fmt.Sprintf("%s %s", m.MetricType, formatNoteValue(m.Value, m.Unit))
+	return s.appendDailyNote(m.RecordedAt, line, m.Notes)
+}
+
+// noteReading appends both halves of a blood pressure reading as one item.
+func (s *MarkdownStore) noteReading(sys, dia *models.Metric) error {
+	if sys.MetricType == models.MetricBPDia {
+		sys, dia = dia, sys
+	}
+	line := fmt.Sprintf("bp %s/%s", formatNoteValue(sys.Value, ""), formatNoteValue(dia.Value, sys.Unit))
+	return s.appendDailyNote(sys.RecordedAt, line, sys.Notes)
+}
+
+// noteWorkout appends a workout to its day's note.
+func (s *MarkdownStore) noteWorkout(w *models.Workout) error {
+	line := w.WorkoutType
+	if w.DurationMinutes != nil {
+		line += fmt.Sprintf(" %d min", *w.DurationMinutes)
+	}
+	return s.appendDailyNote(w.StartedAt, line, w.Notes)
+}
+
+// appendDailyNote adds "- HH:MM text — notes" to the end of the heading's
+// section in the note for at's day, adding the heading when it's missing.
+func (s *MarkdownStore) appendDailyNote(at time.Time, text string, notes *string) error {
+	if s.dailyNotes == "" {
+		return nil
+	}
+	at = at.Local()
+	item := fmt.Sprintf("- %s %s", at.Format("15:04"), text)
+	if notes != nil && *notes != "" {
+		item += " — " + strings.ReplaceAll(*notes, "\n", " ")
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := mdstore.EnsureDir(s.dailyNotes); err != nil {
+		return fmt.Errorf("daily note: %w", err)
+	}
+	path := filepath.Join(s.dailyNotes, at.Format("2006-01-02")+".md")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("daily note: %w", err)
+	}
+	content := insertUnderHeading(string(data), s.dailyNoteHeading, item)
+	if err := mdstore.AtomicWrite(path, []byte(content)); err != nil {
+		return fmt.Errorf("daily note: %w", err)
+	}
+	return nil
+}
+
+// insertUnderHeading adds line at the end of heading's section, before
+// any blank lines that close it, or appends the heading and line when the
+// document has no such section.
+func insertUnderHeading(content, heading, line string) string {
+	lines := strings.Split(content, "\n")
+	start := -1
+	for i, l := range lines {
+		if strings.TrimSpace(l) == heading {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		content = strings.TrimRight(content, "\n")
+		if content != "" {
+			content += "\n\n"
+		}
+		return content + heading + "\n" + line + "\n"
+	}
+
+	level := headingLevel(heading)
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if l := headingLevel(lines[i]); l > 0 && l <= level {
+			end = i
+			break
+		}
+	}
+	for end > start+1 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+
+	out := make([]string, 0, len(lines)+1)
+	out = append(out, lines[:end]...)
+	out = append(out, line)
+	out = append(out, lines[end:]...)
+	return strings.Join(out, "\n")
+}
+
+// headingLevel returns the number of #s of a markdown heading line, or 0.
+func headingLevel(line string) int {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	if n == 0 || n == len(line) || line[n] != ' ' {
+		return 0
+	}
+	return n
+}
+
+// formatNoteValue renders a value without trailing zeros, with its unit;
+// 1-10 scales read as "7/10".
+func formatNoteValue(v float64, unit string) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	switch unit {
+	case "":
+	case "scale":
+		s += "/10"
+	default:
+		s += " " + unit
+	}
+	return s
+}
@@ -0,0 +1,219 @@
+// ABOUTME: Event storage for the markdown backend.
+// ABOUTME: Stores one file per event in events/, named by event date.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// eventFrontmatter holds the YAML frontmatter of an event file.
+type eventFrontmatter struct {
+	ID            string   `yaml:"id"`
+	Name          string   `yaml:"name"`
+	Date          string   `yaml:"date"`
+	WorkoutType   string   `yaml:"workout_type,omitempty"`
+	WeeklyMinutes *int     `yaml:"weekly_minutes,omitempty"`
+	WeeklyKm      *float64 `yaml:"weekly_km,omitempty"`
+	CreatedAt     string   `yaml:"created_at"`
+}
+
+// eventsDir returns the path to the events directory.
+func (s *MarkdownStore) eventsDir() string {
+	return filepath.Join(s.dataDir, "events")
+}
+
+// eventFilePath returns the path for an event file.
+// Format: events/YYYY-MM-DD-<id_prefix>.md, dated by the event.
+func (s *MarkdownStore) eventFilePath(e *models.Event) string {
+	return filepath.Join(s.eventsDir(), fmt.Sprintf("%s-%s.md",
+		e.Date.Local().Format("2006-01-02"), e.ID.String()[:8]))
+}
+
+// readEventFile reads an event from a markdown file.
+func readEventFile(path string) (*models.Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm eventFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse event ID %q: %w", fm.ID, err)
+	}
+	date, err := mdstore.ParseTime(fm.Date)
+	if err != nil {
+		return nil, fmt.Errorf("parse date %q: %w", fm.Date, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	e := &models.Event{
+		ID:            id,
+		Name:          fm.Name,
+		Date:          date,
+		WeeklyMinutes: fm.WeeklyMinutes,
+		WeeklyKm:      fm.WeeklyKm,
+		CreatedAt:     createdAt,
+	}
+	if fm.WorkoutType != "" {
+		e.WorkoutType = &fm.WorkoutType
+	}
+	if notes := strings.TrimSpace(body); notes != "" {
+		e.Notes = &notes
+	}
+	return e, nil
+}
+
+// writeEventFile writes an event to a markdown file.
+func (s *MarkdownStore) writeEventFile(e *models.Event) error {
+	fm := eventFrontmatter{
+		ID:            e.ID.String(),
+		Name:          e.Name,
+		Date:          mdstore.FormatTime(e.Date.UTC()),
+		WeeklyMinutes: e.WeeklyMinutes,
+		WeeklyKm:      e.WeeklyKm,
+		CreatedAt:     mdstore.FormatTime(e.CreatedAt.UTC()),
+	}
+	if e.WorkoutType != nil {
+		fm.WorkoutType = *e.WorkoutType
+	}
+
+	body := ""
+	if e.Notes != nil && *e.Notes != "" {
+		body = "\n" + *e.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render event file: %w", err)
+	}
+
+	return mdstore.AtomicWrite(s.eventFilePath(e), []byte(content))
+}
+
+// eventFiles returns every event keyed by its file path.
+func (s *MarkdownStore) eventFiles() (map[string]*models.Event, error) {
+	entries, err := os.ReadDir(s.eventsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read events directory: %w", err)
+	}
+
+	events := make(map[string]*models.Event)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".md") {
+			continue
+		}
+		path := filepath.Join(s.eventsDir(), entry.Name())
+		e, err := readEventFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read event file %s: %w", path, err)
+		}
+		events[path] = e
+	}
+	return events, nil
+}
+
+// findEventFile finds the file path for an event by ID or prefix.
+func (s *MarkdownStore) findEventFile(idOrPrefix string) (string, *models.Event, error) {
+	events, err := s.eventFiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var foundPath string
+	var found *models.Event
+	for path, e := range events {
+		if !strings.HasPrefix(e.ID.String(), idOrPrefix) {
+			continue
+		}
+		if found != nil {
+			return "", nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+		}
+		foundPath, found = path, e
+	}
+	if found == nil {
+		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	return foundPath, found, nil
+}
+
+// CreateEvent stores a new event as a markdown file.
+func (s *MarkdownStore) CreateEvent(ctx context.Context, e *models.Event) error {
+	return s.writeEventFile(e)
+}
+
+// GetEvent retrieves an event by ID or ID prefix.
+func (s *MarkdownStore) GetEvent(ctx context.Context, idOrPrefix string) (*models.Event, error) {
+	_, e, err := s.findEventFile(idOrPrefix)
+	return e, err
+}
+
+// ListEvents retrieves every event, soonest first.
+func (s *MarkdownStore) ListEvents(ctx context.Context) ([]*models.Event, error) {
+	files, err := s.eventFiles()
+	if err != nil {
+		return nil, fmt.Errorf("list events: %w", err)
+	}
+
+	events := make([]*models.Event, 0, len(files))
+	for _, e := range files {
+		events = append(events, e)
+	}
+	sortEvents(events)
+	return events, nil
+}
+
+// DeleteEvent removes an event file by ID or prefix.
+func (s *MarkdownStore) DeleteEvent(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, _, err := s.findEventFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete event: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete event file: %w", err)
+	}
+	return nil
+}
+
+// sortEvents orders events soonest first, then by name, as SQLite does.
+func sortEvents(events []*models.Event) {
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Date.Equal(events[j].Date) {
+			return events[i].Date.Before(events[j].Date)
+		}
+		return events[i].Name < events[j].Name
+	})
+}
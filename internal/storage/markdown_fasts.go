@@ -0,0 +1,227 @@
+// ABOUTME: Fast storage for the markdown backend.
+// ABOUTME: Stores one file per fast in fasts/, named by start date.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// fastFrontmatter holds the YAML frontmatter of a fast file.
+type fastFrontmatter struct {
+	ID          string  `yaml:"id"`
+	StartedAt   string  `yaml:"started_at"`
+	EndedAt     string  `yaml:"ended_at,omitempty"`
+	TargetHours float64 `yaml:"target_hours"`
+	CreatedAt   string  `yaml:"created_at"`
+}
+
+// fastsDir returns the path to the fasts directory.
+func (s *MarkdownStore) fastsDir() string {
+	return filepath.Join(s.dataDir, "fasts")
+}
+
+// fastFilePath returns the path for a fast file.
+// Format: fasts/YYYY-MM-DD-<id_prefix>.md, dated by start.
+func (s *MarkdownStore) fastFilePath(f *models.Fast) string {
+	return filepath.Join(s.fastsDir(), fmt.Sprintf("%s-%s.md",
+		f.StartedAt.Local().Format("2006-01-02"), f.ID.String()[:8]))
+}
+
+// readFastFile reads a fast from a markdown file.
+func readFastFile(path string) (*models.Fast, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm fastFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse fast ID %q: %w", fm.ID, err)
+	}
+	startedAt, err := mdstore.ParseTime(fm.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse started_at %q: %w", fm.StartedAt, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	f := &models.Fast{
+		ID:          id,
+		StartedAt:   startedAt,
+		TargetHours: fm.TargetHours,
+		CreatedAt:   createdAt,
+	}
+	if fm.EndedAt != "" {
+		endedAt, err := mdstore.ParseTime(fm.EndedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse ended_at %q: %w", fm.EndedAt, err)
+		}
+		f.EndedAt = &endedAt
+	}
+	if notes := strings.TrimSpace(body); notes != "" {
+		f.Notes = &notes
+	}
+	return f, nil
+}
+
+// writeFastFile writes a fast to a markdown file.
+func (s *MarkdownStore) writeFastFile(f *models.Fast) error {
+	fm := fastFrontmatter{
+		ID:          f.ID.String(),
+		StartedAt:   mdstore.FormatTime(f.StartedAt.UTC()),
+		TargetHours: f.TargetHours,
+		CreatedAt:   mdstore.FormatTime(f.CreatedAt.UTC()),
+	}
+	if f.EndedAt != nil {
+		fm.EndedAt = mdstore.FormatTime(f.EndedAt.UTC())
+	}
+
+	body := ""
+	if f.Notes != nil && *f.Notes != "" {
+		body = "\n" + *f.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render fast file: %w", err)
+	}
+
+	return mdstore.AtomicWrite(s.fastFilePath(f), []byte(content))
+}
+
+// fastFiles returns every fast keyed by its file path.
+func (s *MarkdownStore) fastFiles() (map[string]*models.Fast, error) {
+	entries, err := os.ReadDir(s.fastsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read fasts directory: %w", err)
+	}
+
+	fasts := make(map[string]*models.Fast)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		path := filepath.Join(s.fastsDir(), e.Name())
+		f, err := readFastFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read fast file %s: %w", path, err)
+		}
+		fasts[path] = f
+	}
+	return fasts, nil
+}
+
+// findFastFile finds the file path for a fast by ID or prefix.
+func (s *MarkdownStore) findFastFile(idOrPrefix string) (string, *models.Fast, error) {
+	fasts, err := s.fastFiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var foundPath string
+	var found *models.Fast
+	for path, f := range fasts {
+		if !strings.HasPrefix(f.ID.String(), idOrPrefix) {
+			continue
+		}
+		if found != nil {
+			return "", nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+		}
+		foundPath, found = path, f
+	}
+	if found == nil {
+		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	return foundPath, found, nil
+}
+
+// CreateFast stores a new fast as a markdown file.
+func (s *MarkdownStore) CreateFast(ctx context.Context, f *models.Fast) error {
+	return s.writeFastFile(f)
+}
+
+// GetFast retrieves a fast by ID or ID prefix.
+func (s *MarkdownStore) GetFast(ctx context.Context, idOrPrefix string) (*models.Fast, error) {
+	_, f, err := s.findFastFile(idOrPrefix)
+	return f, err
+}
+
+// ListFasts retrieves fasts sorted by StartedAt descending.
+func (s *MarkdownStore) ListFasts(ctx context.Context, limit int) ([]*models.Fast, error) {
+	files, err := s.fastFiles()
+	if err != nil {
+		return nil, fmt.Errorf("list fasts: %w", err)
+	}
+
+	fasts := make([]*models.Fast, 0, len(files))
+	for _, f := range files {
+		fasts = append(fasts, f)
+	}
+	sort.Slice(fasts, func(i, j int) bool {
+		return fasts[i].StartedAt.After(fasts[j].StartedAt)
+	})
+
+	return paginate(fasts, 0, limit), nil
+}
+
+// EndFast records when a fast ended.
+func (s *MarkdownStore) EndFast(ctx context.Context, idOrPrefix string, endedAt time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, f, err := s.findFastFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("end fast: %w", err)
+	}
+	f.EndedAt = &endedAt
+	return s.writeFastFile(f)
+}
+
+// DeleteFast removes a fast file by ID or prefix.
+func (s *MarkdownStore) DeleteFast(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, _, err := s.findFastFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete fast: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete fast file: %w", err)
+	}
+	return nil
+}
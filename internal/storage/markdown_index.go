@@ -0,0 +1,219 @@
+// ABOUTME: ID-to-path index for the markdown backend, kept in .index.json in the data dir.
+// ABOUTME: Lets metric and workout lookups open one file instead of parsing every file.
+
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+)
+
+// markdownIndexFile is the index inside the data directory. It is a cache:
+// deleting it only costs one full scan to rebuild.
+const markdownIndexFile = ".index.json"
+
+// markdownIndex maps full IDs to file paths relative to the data directory.
+type markdownIndex struct {
+	Metrics  map[string]string `json:"metrics"`
+	Workouts map[string]string `json:"workouts"`
+
+	dirty bool
+}
+
+// entries returns the ID-to-path map for the metrics or workouts kind.
+func (idx *markdownIndex) entries(kind string) map[string]string {
+	if kind == kindWorkout {
+		return idx.Workouts
+	}
+	return idx.Metrics
+}
+
+// indexPath returns the path of the index file.
+func (s *MarkdownStore) indexPath() string {
+	return filepath.Join(s.dataDir, markdownIndexFile)
+}
+
+// loadIndex returns the in-memory index, reading it from disk or
+// rebuilding it by scanning every file the first time. The caller holds
+// indexMu for writing.
+func (s *MarkdownStore) loadIndex() (*markdownIndex, error) {
+	if s.index != nil {
+		return s.index, nil
+	}
+
+	if data, err := os.ReadFile(s.indexPath()); err == nil {
+		var idx markdownIndex
+		if json.Unmarshal(data, &idx) == nil && idx.Metrics != nil && idx.Workouts != nil {
+			s.index = &idx
+			return s.index, nil
+		}
+	}
+	return s.rebuildIndex()
+}
+
+// rebuildIndex scans all metric and workout files and replaces the index.
+// The caller holds indexMu for writing.
+func (s *MarkdownStore) rebuildIndex() (*markdownIndex, error) {
+	idx := &markdownIndex{
+		Metrics:  make(map[string]string),
+		Workouts: make(map[string]string),
+		dirty:    true,
+	}
+	rel := func(path string) string {
+		r, err := filepath.Rel(s.dataDir, path)
+		if err != nil {
+			return path
+		}
+		return r
+	}
+
+	err := s.walkMetricFiles(func(path string, m *models.Metric) error {
+		idx.Metrics[m.ID.String()] = rel(path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("index metrics: %w", err)
+	}
+	err = s.walkWorkoutFiles(func(path string, w *models.Workout) error {
+		idx.Workouts[w.ID.String()] = rel(path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("index workouts: %w", err)
+	}
+
+	s.index = idx
+	return idx, nil
+}
+
+// indexPut records where a file lives. Index errors are not fatal; the
+// next lookup that misses rebuilds it.
+func (s *MarkdownStore) indexPut(kind, id, path string) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	idx, err := s.loadIndex()
+	if err != nil {
+		return
+	}
+	if r, err := filepath.Rel(s.dataDir, path); err == nil {
+		path = r
+	}
+	idx.entries(kind)[id] = path
+	idx.dirty = true
+}
+
+// indexDelete forgets a file.
+func (s *MarkdownStore) indexDelete(kind, id string) {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if s.index == nil {
+		return
+	}
+	delete(s.index.entries(kind), id)
+	s.index.dirty = true
+}
+
+// saveIndex writes the index to disk if it changed.
+func (s *MarkdownStore) saveIndex() error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	if s.index == nil || !s.index.dirty {
+		return nil
+	}
+	data, err := json.Marshal(s.index)
+	if err != nil {
+		return fmt.Errorf("marshal index: %w", err)
+	}
+	if err := mdstore.AtomicWrite(s.indexPath(), data); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+	s.index.dirty = false
+	return nil
+}
+
+// reloadIndex drops the in-memory index and rebuilds it from the files.
+func (s *MarkdownStore) reloadIndex() error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	s.index = nil
+	_, err := s.rebuildIndex()
+	return err
+}
+
+// indexMatches returns the paths of the files whose ID is idOrPrefix, or
+// starts with it unless full is set. With rebuild it rescans the files
+// first; otherwise it loads the index if this is the first lookup.
+func (s *MarkdownStore) indexMatches(kind, idOrPrefix string, full, rebuild bool) ([]string, error) {
+	match := func(idx *markdownIndex) []string {
+		var matches []string
+		for id, rel := range idx.entries(kind) {
+			if (full && id == idOrPrefix) || (!full && strings.HasPrefix(id, idOrPrefix)) {
+				matches = append(matches, filepath.Join(s.dataDir, rel))
+			}
+		}
+		return matches
+	}
+
+	if !rebuild {
+		s.indexMu.RLock()
+		if s.index != nil {
+			matches := match(s.index)
+			s.indexMu.RUnlock()
+			return matches, nil
+		}
+		s.indexMu.RUnlock()
+	}
+
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+	var idx *markdownIndex
+	var err error
+	if rebuild {
+		idx, err = s.rebuildIndex()
+	} else {
+		idx, err = s.loadIndex()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match(idx), nil
+}
+
+// resolveIndexed finds the file for a metric or workout by full ID or
+// unique prefix. If nothing matches, or a match points at a missing file,
+// the index may be stale (files added or removed outside the store), so
+// it is rebuilt once and the lookup retried.
+func (s *MarkdownStore) resolveIndexed(kind, idOrPrefix string) (string, error) {
+	isFullUUID := len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4
+
+	for attempt := 0; ; attempt++ {
+		matches, err := s.indexMatches(kind, idOrPrefix, isFullUUID, attempt > 0)
+		if err != nil {
+			return "", err
+		}
+
+		stale := len(matches) == 0
+		for _, path := range matches {
+			if _, err := os.Stat(path); err != nil {
+				stale = true
+			}
+		}
+		if stale && attempt == 0 {
+			continue
+		}
+
+		switch {
+		case len(matches) == 0:
+			return "", fmt.Errorf("not found: %s", idOrPrefix)
+		case len(matches) > 1:
+			return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+		}
+		return matches[0], nil
+	}
+}
@@ -0,0 +1,244 @@
+// ABOUTME: Layout strategies deciding where the markdown backend writes metric and workout files.
+// ABOUTME: Built-in layouts by name, or a path pattern with {yyyy}, {date}, {type}, {id} placeholders.
+// ABOUTME: Patterns without {id} gather metrics into shared files, such as one per day.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+)
+
+// MarkdownLayout decides where new metric and workout files go. Reading
+// doesn't depend on it: every .md file under metrics/ and workouts/ is
+// picked up wherever it sits, so changing layouts leaves older files
+// readable where they are until 'health reindex --relayout' moves them.
+type MarkdownLayout interface {
+	// Path returns the file's path relative to metrics/ or workouts/ for
+	// an entry of kind (the metric type or slugged workout type) recorded
+	// at at, local time. Metrics given the same path share one file.
+	Path(at time.Time, kind string, id uuid.UUID) string
+}
+
+// Built-in layout patterns, selected by name in config.
+var markdownLayouts = map[string]string{
+	// metrics/2025/01/2025-01-05-weight-1a2b3c4d.md
+	"date": "{yyyy}/{mm}/{date}-{type}-{id}.md",
+	// metrics/weight/2025/2025-01-05-1a2b3c4d.md
+	"type": "{type}/{yyyy}/{date}-{id}.md",
+	// metrics/2025-01-05.md, holding every metric of the day
+	"flat": "{date}.md",
+}
+
+// DefaultMarkdownLayout is the layout used when none is configured.
+const DefaultMarkdownLayout = "date"
+
+// layoutPlaceholder matches a {name} in a layout pattern.
+var layoutPlaceholder = regexp.MustCompile(`\{[^}]*\}`)
+
+// patternLayout fills a pattern's placeholders: {yyyy}, {mm}, {dd},
+// {date} (YYYY-MM-DD), {type}, {id} (the first 8 characters of the ID),
+// and {uuid} (the whole ID).
+type patternLayout string
+
+func (p patternLayout) Path(at time.Time, kind string, id uuid.UUID) string {
+	at = at.Local()
+	path := layoutPlaceholder.ReplaceAllStringFunc(string(p), func(ph string) string {
+		switch ph {
+		case "{yyyy}":
+			return at.Format("2006")
+		case "{mm}":
+			return at.Format("01")
+		case "{dd}":
+			return at.Format("02")
+		case "{date}":
+			return at.Format("2006-01-02")
+		case "{type}":
+			return kind
+		case "{id}":
+			return id.String()[:8]
+		case "{uuid}":
+			return id.String()
+		}
+		return ph
+	})
+	return filepath.FromSlash(path)
+}
+
+// ParseMarkdownLayout returns the built-in layout called name, or treats
+// it as a pattern such as "{type}/{date}-{id}.md". Patterns must end in
+// .md and stay inside their folder. Without {id} or {uuid}, metrics that
+// land on the same path share the file, so "{yyyy}/{date}.md" gives one
+// file per day.
+func ParseMarkdownLayout(name string) (MarkdownLayout, error) {
+	if name == "" {
+		name = DefaultMarkdownLayout
+	}
+	if pattern, ok := markdownLayouts[name]; ok {
+		return patternLayout(pattern), nil
+	}
+
+	if !strings.Contains(name, "{") {
+		return nil, fmt.Errorf("unknown markdown layout %q (want date, type, flat, or a pattern)", name)
+	}
+	for _, ph := range layoutPlaceholder.FindAllString(name, -1) {
+		switch ph {
+		case "{yyyy}", "{mm}", "{dd}", "{date}", "{type}", "{id}", "{uuid}":
+		default:
+			return nil, fmt.Errorf("markdown layout %q: unknown placeholder %s", name, ph)
+		}
+	}
+	if !strings.HasSuffix(name, ".md") {
+		return nil, fmt.Errorf("markdown layout %q: must end in .md", name)
+	}
+	if strings.HasPrefix(name, "/") || strings.Contains(name, "\\") {
+		return nil, fmt.Errorf("markdown layout %q: must be a relative path using /", name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			return nil, fmt.Errorf("markdown layout %q: empty, . or .. path element", name)
+		}
+	}
+	return patternLayout(name), nil
+}
+
+// SetLayout changes where new metric and workout files are written; nil
+// restores the default date layout.
+func (s *MarkdownStore) SetLayout(l MarkdownLayout) {
+	s.layout = l
+}
+
+// datedLayout reports whether files are laid out metrics/YYYY/MM/ with
+// the type in each name, which lets queries skip months and types.
+func (s *MarkdownStore) datedLayout() bool {
+	return s.layout == nil || s.layout == patternLayout(markdownLayouts[DefaultMarkdownLayout])
+}
+
+// layoutOrDefault returns the configured layout or the date layout.
+func (s *MarkdownStore) layoutOrDefault() MarkdownLayout {
+	if s.layout == nil {
+		return patternLayout(markdownLayouts[DefaultMarkdownLayout])
+	}
+	return s.layout
+}
+
+// Relayout moves metrics and workouts that aren't where the layout would
+// put them, returning the paths of the files they came from relative to
+// the data directory, and removes folders left empty. A metric alone in
+// its file moves with the file; one leaving or joining a shared file is
+// rewritten into its new file. With dryRun nothing moves.
+func (s *MarkdownStore) Relayout(ctx context.Context, dryRun bool) ([]string, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	type move struct {
+		from, to, kind, id string
+		metric             *models.Metric // set for metrics
+	}
+	var moves []move
+	perFile := make(map[string]int) // metrics in each file
+	err = s.walkMetricFiles(func(path string, m *models.Metric) error {
+		perFile[path]++
+		if to := s.metricFilePath(m.RecordedAt, m.MetricType, m.ID); to != path {
+			moves = append(moves, move{path, to, kindMetric, m.ID.String(), m})
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("relayout: %w", err)
+	}
+	err = s.walkWorkoutFiles(func(path string, w *models.Workout) error {
+		if to := s.workoutFilePath(w.StartedAt, w.WorkoutType, w.ID); to != path {
+			moves = append(moves, move{path, to, kindWorkout, w.ID.String(), nil})
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("relayout: %w", err)
+	}
+
+	moved := make([]string, 0, len(moves))
+	reported := make(map[string]bool)
+	for _, mv := range moves {
+		if !reported[mv.from] {
+			reported[mv.from] = true
+			rel, _ := filepath.Rel(s.dataDir, mv.from)
+			moved = append(moved, rel)
+		}
+		if dryRun {
+			continue
+		}
+		_, err := os.Stat(mv.to)
+		if mv.metric != nil && (perFile[mv.from] > 1 || err == nil) {
+			if err := putMetricsAt(mv.to, mv.metric); err != nil {
+				return moved, fmt.Errorf("relayout: %w", err)
+			}
+			if err := removeMetricAt(mv.from, mv.metric.ID); err != nil {
+				return moved, fmt.Errorf("relayout: %w", err)
+			}
+			s.indexPut(mv.kind, mv.id, mv.to)
+			continue
+		}
+		if err == nil {
+			return moved, fmt.Errorf("relayout: %s already exists", mv.to)
+		}
+		if err := mdstore.EnsureDir(filepath.Dir(mv.to)); err != nil {
+			return moved, fmt.Errorf("relayout: %w", err)
+		}
+		if err := os.Rename(mv.from, mv.to); err != nil {
+			return moved, fmt.Errorf("relayout: %w", err)
+		}
+		s.indexPut(mv.kind, mv.id, mv.to)
+	}
+	if dryRun || len(moves) == 0 {
+		return moved, nil
+	}
+	for _, dir := range []string{s.metricsDir(), s.workoutsDir()} {
+		if err := removeEmptyDirs(dir); err != nil {
+			return moved, fmt.Errorf("relayout: %w", err)
+		}
+	}
+	return moved, nil
+}
+
+// removeEmptyDirs deletes the empty folders below root, deepest first.
+func removeEmptyDirs(root string) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := os.ReadDir(dirs[i]); err == nil && len(entries) == 0 {
+			if err := os.Remove(dirs[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// ABOUTME: Location registry storage for the markdown backend.
+// ABOUTME: Each named location is a file in locations/ with coordinates in frontmatter.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// locationFrontmatter holds the YAML frontmatter of a location file.
+type locationFrontmatter struct {
+	ID        string   `yaml:"id"`
+	Name      string   `yaml:"name"`
+	Latitude  *float64 `yaml:"latitude,omitempty"`
+	Longitude *float64 `yaml:"longitude,omitempty"`
+	CreatedAt string   `yaml:"created_at"`
+}
+
+// locationsDir returns the path to the locations directory.
+func (s *MarkdownStore) locationsDir() string {
+	return filepath.Join(s.dataDir, "locations")
+}
+
+// locationFilePath returns the path for a location file.
+// Format: locations/<slug>-<id_prefix>.md.
+func (s *MarkdownStore) locationFilePath(l *models.Location) string {
+	return filepath.Join(s.locationsDir(),
+		fmt.Sprintf("%s-%s.md", mdstore.Slugify(l.Name), l.ID.String()[:8]))
+}
+
+// readLocationFile reads a location from a markdown file.
+func readLocationFile(path string) (*models.Location, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm locationFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse location ID %q: %w", fm.ID, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	l := &models.Location{
+		ID:        id,
+		Name:      fm.Name,
+		Latitude:  fm.Latitude,
+		Longitude: fm.Longitude,
+		CreatedAt: createdAt,
+	}
+	if notes := strings.TrimSpace(body); notes != "" {
+		l.Notes = &notes
+	}
+	return l, nil
+}
+
+// locationFiles returns every location keyed by its file path.
+func (s *MarkdownStore) locationFiles() (map[string]*models.Location, error) {
+	locs := make(map[string]*models.Location)
+	err := walkMarkdownFiles(s.locationsDir(), func(path string) error {
+		l, err := readLocationFile(path)
+		if err != nil {
+			return fmt.Errorf("read location file %s: %w", path, err)
+		}
+		locs[path] = l
+		return nil
+	})
+	return locs, err
+}
+
+// CreateLocation stores a new location as a markdown file.
+// Names must be unique, ignoring case and punctuation.
+func (s *MarkdownStore) CreateLocation(ctx context.Context, l *models.Location) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := s.ListLocations(ctx)
+	if err != nil {
+		return fmt.Errorf("create location: %w", err)
+	}
+	for _, e := range existing {
+		if e.Slug() == l.Slug() {
+			return fmt.Errorf("location %q already exists", e.Name)
+		}
+	}
+
+	fm := locationFrontmatter{
+		ID:        l.ID.String(),
+		Name:      l.Name,
+		Latitude:  l.Latitude,
+		Longitude: l.Longitude,
+		CreatedAt: mdstore.FormatTime(l.CreatedAt.UTC()),
+	}
+	body := ""
+	if l.Notes != nil && *l.Notes != "" {
+		body = "\n" + *l.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render location file: %w", err)
+	}
+	return mdstore.AtomicWrite(s.locationFilePath(l), []byte(content))
+}
+
+// GetLocation retrieves a location by name, slug, ID, or ID prefix.
+func (s *MarkdownStore) GetLocation(ctx context.Context, nameOrID string) (*models.Location, error) {
+	locs, err := s.ListLocations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matchLocation(locs, nameOrID)
+}
+
+// ListLocations retrieves all locations sorted by name.
+func (s *MarkdownStore) ListLocations(ctx context.Context) ([]*models.Location, error) {
+	files, err := s.locationFiles()
+	if err != nil {
+		return nil, fmt.Errorf("list locations: %w", err)
+	}
+
+	locs := make([]*models.Location, 0, len(files))
+	for _, l := range files {
+		locs = append(locs, l)
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		return strings.ToLower(locs[i].Name) < strings.ToLower(locs[j].Name)
+	})
+	return locs, nil
+}
+
+// DeleteLocation removes a location file. Entries already tagged with its
+// name keep their tag.
+func (s *MarkdownStore) DeleteLocation(ctx context.Context, nameOrID string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files, err := s.locationFiles()
+	if err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+	locs := make([]*models.Location, 0, len(files))
+	for _, l := range files {
+		locs = append(locs, l)
+	}
+	target, err := matchLocation(locs, nameOrID)
+	if err != nil {
+		return fmt.Errorf("delete location: %w", err)
+	}
+
+	for path, l := range files {
+		if l.ID == target.ID {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("delete location file: %w", err)
+			}
+		}
+	}
+	return nil
+}
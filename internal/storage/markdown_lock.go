@@ -0,0 +1,40 @@
+// ABOUTME: Write lock for the markdown backend, so read-modify-write updates don't lose data.
+// ABOUTME: A mutex orders writers in this process; an advisory lock on .lock in the data directory covers other processes.
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// lockFileName is the file in the data directory that writers in
+// different processes, such as the MCP server and the CLI, take turns
+// locking.
+const lockFileName = ".lock"
+
+// lock takes the store's write lock, returning the func that releases it.
+// Anything that reads a file and writes it back, or deletes one, holds it
+// from the read to the write; otherwise two writers adding a set to the
+// same workout could each write back a copy missing the other's. The lock
+// isn't reentrant, so callers must not call another locking method while
+// holding it.
+func (s *MarkdownStore) lock() (func(), error) {
+	s.mu.Lock()
+	f, err := os.OpenFile(filepath.Join(s.dataDir, lockFileName), os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("lock store: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		s.mu.Unlock()
+		return nil, fmt.Errorf("lock store: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+		s.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,12 @@
+// ABOUTME: No-op file locking where flock(2) isn't available.
+// ABOUTME: Writers in one process still take turns through the store's mutex.
+
+//go:build !unix
+
+package storage
+
+import "os"
+
+func lockFile(f *os.File) error { return nil }
+
+func unlockFile(f *os.File) {}
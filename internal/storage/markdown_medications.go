@@ -0,0 +1,349 @@
+// ABOUTME: Medication and intake storage for the markdown backend.
+// ABOUTME: Definitions live in medications/, intakes in intakes/YYYY/MM by date taken.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// medicationFrontmatter holds the YAML frontmatter of a medication file.
+type medicationFrontmatter struct {
+	ID        string `yaml:"id"`
+	Name      string `yaml:"name"`
+	Dose      string `yaml:"dose"`
+	Schedule  string `yaml:"schedule"`
+	CreatedAt string `yaml:"created_at"`
+}
+
+// intakeFrontmatter holds the YAML frontmatter of an intake file.
+// Medication is the name at the time of logging, kept for readability.
+type intakeFrontmatter struct {
+	ID           string `yaml:"id"`
+	MedicationID string `yaml:"medication_id"`
+	Medication   string `yaml:"medication,omitempty"`
+	TakenAt      string `yaml:"taken_at"`
+	Dose         string `yaml:"dose,omitempty"`
+	CreatedAt    string `yaml:"created_at"`
+}
+
+// medicationsDir returns the path to the medication definitions directory.
+func (s *MarkdownStore) medicationsDir() string {
+	return filepath.Join(s.dataDir, "medications")
+}
+
+// intakesDir returns the path to the medication intakes directory.
+func (s *MarkdownStore) intakesDir() string {
+	return filepath.Join(s.dataDir, "intakes")
+}
+
+// medicationFilePath returns the path for a medication file.
+// Format: medications/<slug>-<id_prefix>.md.
+func (s *MarkdownStore) medicationFilePath(m *models.Medication) string {
+	return filepath.Join(s.medicationsDir(),
+		fmt.Sprintf("%s-%s.md", mdstore.Slugify(m.Name), m.ID.String()[:8]))
+}
+
+// intakeFilePath returns the path for an intake file.
+// Format: intakes/YYYY/MM/YYYY-MM-DD-<slug>-<id_prefix>.md.
+func (s *MarkdownStore) intakeFilePath(in *models.MedicationIntake, medName string) string {
+	t := in.TakenAt.Local()
+	return filepath.Join(s.intakesDir(), t.Format("2006"), t.Format("01"),
+		fmt.Sprintf("%s-%s-%s.md", t.Format("2006-01-02"), mdstore.Slugify(medName), in.ID.String()[:8]))
+}
+
+// readMedicationFile reads a medication from a markdown file.
+func readMedicationFile(path string) (*models.Medication, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm medicationFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse medication ID %q: %w", fm.ID, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	m := &models.Medication{
+		ID:        id,
+		Name:      fm.Name,
+		Dose:      fm.Dose,
+		Schedule:  fm.Schedule,
+		CreatedAt: createdAt,
+	}
+	if notes := strings.TrimSpace(body); notes != "" {
+		m.Notes = &notes
+	}
+	return m, nil
+}
+
+// readIntakeFile reads a medication intake from a markdown file.
+func readIntakeFile(path string) (*models.MedicationIntake, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm intakeFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse intake ID %q: %w", fm.ID, err)
+	}
+	medID, err := uuid.Parse(fm.MedicationID)
+	if err != nil {
+		return nil, fmt.Errorf("parse medication_id %q: %w", fm.MedicationID, err)
+	}
+	takenAt, err := mdstore.ParseTime(fm.TakenAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse taken_at %q: %w", fm.TakenAt, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	in := &models.MedicationIntake{
+		ID:           id,
+		MedicationID: medID,
+		TakenAt:      takenAt,
+		CreatedAt:    createdAt,
+	}
+	if fm.Dose != "" {
+		in.Dose = &fm.Dose
+	}
+	if notes := strings.TrimSpace(body); notes != "" {
+		in.Notes = &notes
+	}
+	return in, nil
+}
+
+// walkMarkdownFiles calls fn for every .md file under dir, if dir exists.
+func walkMarkdownFiles(dir string, fn func(path string) error) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+		return fn(path)
+	})
+}
+
+// medicationFiles returns every medication keyed by its file path.
+func (s *MarkdownStore) medicationFiles() (map[string]*models.Medication, error) {
+	meds := make(map[string]*models.Medication)
+	err := walkMarkdownFiles(s.medicationsDir(), func(path string) error {
+		m, err := readMedicationFile(path)
+		if err != nil {
+			return fmt.Errorf("read medication file %s: %w", path, err)
+		}
+		meds[path] = m
+		return nil
+	})
+	return meds, err
+}
+
+// CreateMedication stores a new medication as a markdown file.
+// Names must be unique, ignoring case and punctuation.
+func (s *MarkdownStore) CreateMedication(ctx context.Context, m *models.Medication) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	existing, err := s.ListMedications(ctx)
+	if err != nil {
+		return fmt.Errorf("create medication: %w", err)
+	}
+	for _, e := range existing {
+		if e.Slug() == m.Slug() {
+			return fmt.Errorf("medication %q already exists", e.Name)
+		}
+	}
+
+	fm := medicationFrontmatter{
+		ID:        m.ID.String(),
+		Name:      m.Name,
+		Dose:      m.Dose,
+		Schedule:  m.Schedule,
+		CreatedAt: mdstore.FormatTime(m.CreatedAt.UTC()),
+	}
+	body := ""
+	if m.Notes != nil && *m.Notes != "" {
+		body = "\n" + *m.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render medication file: %w", err)
+	}
+	return mdstore.AtomicWrite(s.medicationFilePath(m), []byte(content))
+}
+
+// GetMedication retrieves a medication by name, slug, ID, or ID prefix.
+func (s *MarkdownStore) GetMedication(ctx context.Context, nameOrID string) (*models.Medication, error) {
+	meds, err := s.ListMedications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matchMedication(meds, nameOrID)
+}
+
+// ListMedications retrieves all medications sorted by name.
+func (s *MarkdownStore) ListMedications(ctx context.Context) ([]*models.Medication, error) {
+	files, err := s.medicationFiles()
+	if err != nil {
+		return nil, fmt.Errorf("list medications: %w", err)
+	}
+
+	meds := make([]*models.Medication, 0, len(files))
+	for _, m := range files {
+		meds = append(meds, m)
+	}
+	sort.Slice(meds, func(i, j int) bool {
+		return strings.ToLower(meds[i].Name) < strings.ToLower(meds[j].Name)
+	})
+	return meds, nil
+}
+
+// DeleteMedication removes a medication file and all of its intake files.
+func (s *MarkdownStore) DeleteMedication(ctx context.Context, nameOrID string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files, err := s.medicationFiles()
+	if err != nil {
+		return fmt.Errorf("delete medication: %w", err)
+	}
+	meds := make([]*models.Medication, 0, len(files))
+	for _, m := range files {
+		meds = append(meds, m)
+	}
+	target, err := matchMedication(meds, nameOrID)
+	if err != nil {
+		return fmt.Errorf("delete medication: %w", err)
+	}
+
+	err = walkMarkdownFiles(s.intakesDir(), func(path string) error {
+		in, err := readIntakeFile(path)
+		if err != nil {
+			return fmt.Errorf("read intake file %s: %w", path, err)
+		}
+		if in.MedicationID == target.ID {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete medication intakes: %w", err)
+	}
+
+	for path, m := range files {
+		if m.ID == target.ID {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("delete medication file: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// LogMedicationIntake stores a new intake as a markdown file.
+func (s *MarkdownStore) LogMedicationIntake(ctx context.Context, in *models.MedicationIntake) error {
+	m, err := s.GetMedication(ctx, in.MedicationID.String())
+	if err != nil {
+		return fmt.Errorf("log medication intake: %w", err)
+	}
+
+	fm := intakeFrontmatter{
+		ID:           in.ID.String(),
+		MedicationID: in.MedicationID.String(),
+		Medication:   m.Name,
+		TakenAt:      mdstore.FormatTime(in.TakenAt.UTC()),
+		CreatedAt:    mdstore.FormatTime(in.CreatedAt.UTC()),
+	}
+	if in.Dose != nil {
+		fm.Dose = *in.Dose
+	}
+	body := ""
+	if in.Notes != nil && *in.Notes != "" {
+		body = "\n" + *in.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render intake file: %w", err)
+	}
+	return mdstore.AtomicWrite(s.intakeFilePath(in, m.Name), []byte(content))
+}
+
+// ListMedicationIntakes retrieves intakes matching the filter.
+// Results are sorted by TakenAt descending (most recent first).
+func (s *MarkdownStore) ListMedicationIntakes(ctx context.Context, filter IntakeFilter) ([]*models.MedicationIntake, error) {
+	var intakes []*models.MedicationIntake
+
+	err := walkMarkdownFiles(s.intakesDir(), func(path string) error {
+		in, err := readIntakeFile(path)
+		if err != nil {
+			return fmt.Errorf("read intake file %s: %w", path, err)
+		}
+		if filter.MedicationID != nil && in.MedicationID != *filter.MedicationID {
+			return nil
+		}
+		if !inRange(in.TakenAt, filter.Since, filter.Until) {
+			return nil
+		}
+		intakes = append(intakes, in)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list medication intakes: %w", err)
+	}
+
+	sort.Slice(intakes, func(i, j int) bool {
+		return intakes[i].TakenAt.After(intakes[j].TakenAt)
+	})
+
+	return paginate(intakes, 0, filter.Limit), nil
+}
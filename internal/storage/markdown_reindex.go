@@ -0,0 +1,195 @@
+// ABOUTME: Reconciles hand-edited markdown files: fills in missing IDs, re-keys copies, reports errors.
+// ABOUTME: Also fingerprints the data directory so a watcher can tell when files changed.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"gopkg.in/yaml.v3"
+)
+
+// ReindexReport says what Reindex found and, unless it was a dry run,
+// fixed. Paths are relative to the data directory.
+type ReindexReport struct {
+	Checked  int
+	Assigned []string     // files given the id or created_at they lacked
+	Rekeyed  []string     // files that repeated another file's ID, usually copies
+	Broken   []BrokenFile // files that still don't parse
+}
+
+// Reindex checks every entry file after hand edits. A file missing an id
+// or created_at gets one (created_at from the file's modification time), a
+// file repeating an ID seen earlier in its folder gets a new one, and
+// anything that still doesn't parse is reported. The ID index is then
+// rebuilt. With dryRun nothing is written.
+func (s *MarkdownStore) Reindex(ctx context.Context, dryRun bool) (*ReindexReport, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	rep := &ReindexReport{}
+	for _, r := range s.entryReaders() {
+		seen := map[uuid.UUID]bool{}
+		err := walkEntryFiles(ctx, r.dir, func(path string) error {
+			rep.Checked++
+			rel, _ := filepath.Rel(s.dataDir, path)
+
+			id, rerr := r.read(path)
+			if rerr != nil {
+				filled, err := fillMissingFields(path, dryRun)
+				if err != nil {
+					return err
+				}
+				if filled && dryRun {
+					rep.Assigned = append(rep.Assigned, rel)
+					return nil
+				}
+				if filled {
+					id, rerr = r.read(path)
+				}
+				if rerr != nil {
+					rep.Broken = append(rep.Broken, BrokenFile{Path: rel, Err: rerr})
+					return nil
+				}
+				rep.Assigned = append(rep.Assigned, rel)
+			}
+
+			if seen[id] {
+				rep.Rekeyed = append(rep.Rekeyed, rel)
+				if dryRun {
+					return nil
+				}
+				if id, rerr = rekeyFile(path, id); rerr != nil {
+					return rerr
+				}
+			}
+			seen[id] = true
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("reindex: %w", err)
+		}
+	}
+
+	if dryRun {
+		return rep, nil
+	}
+	// Broken metric or workout files stop the index from building; lookups
+	// rebuild it once they're fixed
+	if err := s.reloadIndex(); err != nil {
+		if err := os.Remove(s.indexPath()); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("reindex: %w", err)
+		}
+		return rep, nil
+	}
+	if err := s.saveIndex(); err != nil {
+		return nil, fmt.Errorf("reindex: %w", err)
+	}
+	return rep, nil
+}
+
+// emptyFieldLine matches a top-level frontmatter key with no value.
+var emptyFieldLine = regexp.MustCompile(`(?m)^(id|created_at):[ \t]*(""|'')?[ \t]*\n`)
+
+// fillMissingFields adds an id and created_at to a file's frontmatter when
+// they're absent or empty, reporting whether it did (or, with dryRun,
+// would). Files without parsable frontmatter are left alone.
+func fillMissingFields(path string, dryRun bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	content := string(data)
+	yamlStr, _ := mdstore.ParseFrontmatter(content)
+	if yamlStr == "" {
+		return false, nil
+	}
+	var fm map[string]any
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return false, nil
+	}
+
+	missing := func(key string) bool {
+		v, ok := fm[key]
+		return !ok || v == nil || v == ""
+	}
+	add := ""
+	if missing("id") {
+		add += "id: " + uuid.New().String() + "\n"
+	}
+	if missing("created_at") {
+		info, err := os.Stat(path)
+		if err != nil {
+			return false, err
+		}
+		add += "created_at: " + mdstore.FormatTime(info.ModTime().UTC()) + "\n"
+	}
+	if add == "" || dryRun {
+		return add != "", nil
+	}
+
+	head := emptyFieldLine.ReplaceAllString(yamlStr, "")
+	content = "---\n" + add + head + content[4+len(yamlStr):]
+	if err := mdstore.AtomicWrite(path, []byte(content)); err != nil {
+		return false, fmt.Errorf("write %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// rekeyFile gives the file a new ID in place of old, returning it.
+func rekeyFile(path string, old uuid.UUID) (uuid.UUID, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	content := string(data)
+	yamlStr, _ := mdstore.ParseFrontmatter(content)
+	line := regexp.MustCompile(`(?m)^id:[ \t]*["']?` + old.String() + `["']?[ \t]*$`)
+	if !line.MatchString(yamlStr) {
+		return uuid.Nil, fmt.Errorf("rekey %s: no id line for %s", path, old)
+	}
+
+	id := uuid.New()
+	head := line.ReplaceAllLiteralString(yamlStr, "id: "+id.String())
+	content = "---\n" + head + content[4+len(yamlStr):]
+	if err := mdstore.AtomicWrite(path, []byte(content)); err != nil {
+		return uuid.Nil, fmt.Errorf("write %s: %w", path, err)
+	}
+	return id, nil
+}
+
+// Fingerprint summarizes the names, sizes, and modification times of the
+// entry files, so a watcher can poll for hand edits cheaply.
+func (s *MarkdownStore) Fingerprint(ctx context.Context) (uint64, error) {
+	h := fnv.New64a()
+	for _, r := range s.entryReaders() {
+		err := walkEntryFiles(ctx, r.dir, func(path string) error {
+			info, err := os.Stat(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			fmt.Fprintf(h, "%s|%d|%d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+		// Files renamed or deleted mid-walk are picked up next time
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, fmt.Errorf("fingerprint: %w", err)
+		}
+	}
+	return h.Sum64(), nil
+}
@@ -0,0 +1,126 @@
+// ABOUTME: Reminder due-state for the markdown backend.
+// ABOUTME: Keeps last-fired times in reminders.yaml and snoozes in reminder-snoozes.yaml.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/harper/suite/mdstore"
+	"gopkg.in/yaml.v3"
+)
+
+// reminderStatePath returns the path to the reminder state file.
+func (s *MarkdownStore) reminderStatePath() string {
+	return filepath.Join(s.dataDir, "reminders.yaml")
+}
+
+// reminderSnoozePath returns the path to the reminder snooze file.
+func (s *MarkdownStore) reminderSnoozePath() string {
+	return filepath.Join(s.dataDir, "reminder-snoozes.yaml")
+}
+
+// readReminderState loads times keyed by reminder key from a state file.
+func readReminderState(path string) (map[string]string, error) {
+	state := map[string]string{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read reminder state: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse reminder state: %w", err)
+	}
+	if state == nil {
+		state = map[string]string{}
+	}
+	return state, nil
+}
+
+// GetReminderLastFired returns when the reminder with the given key last
+// fired, or nil if it never has.
+func (s *MarkdownStore) GetReminderLastFired(ctx context.Context, key string) (*time.Time, error) {
+	state, err := readReminderState(s.reminderStatePath())
+	if err != nil {
+		return nil, err
+	}
+	value, ok := state[key]
+	if !ok {
+		return nil, nil
+	}
+	t, err := mdstore.ParseTime(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse reminder state for %s: %w", key, err)
+	}
+	return &t, nil
+}
+
+// SetReminderLastFired records that the reminder with the given key fired at.
+func (s *MarkdownStore) SetReminderLastFired(ctx context.Context, key string, at time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := readReminderState(s.reminderStatePath())
+	if err != nil {
+		return err
+	}
+	state[key] = mdstore.FormatTime(at)
+	return writeReminderState(s.reminderStatePath(), state)
+}
+
+// writeReminderState replaces a state file.
+func writeReminderState(path string, state map[string]string) error {
+	data, err := yaml.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("marshal reminder state: %w", err)
+	}
+	return mdstore.AtomicWrite(path, data)
+}
+
+// GetReminderSnooze returns when the snooze on the reminder with the given
+// key ends, or nil if it isn't snoozed.
+func (s *MarkdownStore) GetReminderSnooze(ctx context.Context, key string) (*time.Time, error) {
+	snoozes, err := readReminderState(s.reminderSnoozePath())
+	if err != nil {
+		return nil, err
+	}
+	value, ok := snoozes[key]
+	if !ok {
+		return nil, nil
+	}
+	t, err := mdstore.ParseTime(value)
+	if err != nil {
+		return nil, fmt.Errorf("parse reminder snooze for %s: %w", key, err)
+	}
+	return &t, nil
+}
+
+// SetReminderSnooze snoozes the reminder with the given key until the given
+// time. A nil until clears the snooze.
+func (s *MarkdownStore) SetReminderSnooze(ctx context.Context, key string, until *time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	snoozes, err := readReminderState(s.reminderSnoozePath())
+	if err != nil {
+		return err
+	}
+	if until == nil {
+		delete(snoozes, key)
+	} else {
+		snoozes[key] = mdstore.FormatTime(*until)
+	}
+	return writeReminderState(s.reminderSnoozePath(), snoozes)
+}
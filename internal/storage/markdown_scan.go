@@ -0,0 +1,206 @@
+// ABOUTME: Newest-first scanning of markdown metric files for filtered queries and aggregates.
+// ABOUTME: Prunes by the type and date in file names so "latest weight" reads a handful of files.
+
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// dayMargin covers the gap between the local date in a file's path and
+// its UTC timestamp; no timezone is more than 14 hours from UTC.
+const dayMargin = 24 * time.Hour
+
+// metricMonth is one metrics/YYYY/MM directory.
+type metricMonth struct {
+	dir   string
+	start time.Time // first of the month, UTC
+}
+
+// metricMonths lists the month directories newest first. It reports false
+// when the tree holds anything outside the YYYY/MM layout, in which case
+// callers must fall back to a full walk.
+func (s *MarkdownStore) metricMonths() ([]metricMonth, bool, error) {
+	years, err := os.ReadDir(s.metricsDir())
+	if os.IsNotExist(err) {
+		return nil, true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var months []metricMonth
+	for _, y := range years {
+		year, err := strconv.Atoi(y.Name())
+		if !y.IsDir() || err != nil || len(y.Name()) != 4 {
+			return nil, false, nil
+		}
+		entries, err := os.ReadDir(filepath.Join(s.metricsDir(), y.Name()))
+		if err != nil {
+			return nil, false, err
+		}
+		for _, m := range entries {
+			month, err := strconv.Atoi(m.Name())
+			if !m.IsDir() || err != nil || len(m.Name()) != 2 || month < 1 || month > 12 {
+				return nil, false, nil
+			}
+			months = append(months, metricMonth{
+				dir:   filepath.Join(s.metricsDir(), y.Name(), m.Name()),
+				start: time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC),
+			})
+		}
+	}
+
+	sort.Slice(months, func(i, j int) bool { return months[i].start.After(months[j].start) })
+	return months, true, nil
+}
+
+// metricTypeFromName extracts the type from YYYY-MM-DD-<type>-<id>.md, or
+// "" if the name doesn't follow that pattern.
+func metricTypeFromName(name string) string {
+	name = strings.TrimSuffix(name, ".md")
+	if len(name) < 12 || name[10] != '-' {
+		return ""
+	}
+	rest := name[11:]
+	i := strings.LastIndex(rest, "-")
+	if i <= 0 {
+		return ""
+	}
+	return rest[:i]
+}
+
+// scanMetrics collects metrics matching filter, reading month directories
+// newest first. Months outside the Since/Until window and files of other
+// types are skipped without being opened, and with a Limit the scan stops
+// once no older month can contribute to the requested page. The result is
+// sorted by RecordedAt descending but not yet paginated. It reports false
+// if the tree layout is unexpected or another layout is configured. It
+// stops early if ctx is cancelled.
+func (s *MarkdownStore) scanMetrics(ctx context.Context, filter MetricFilter) ([]*models.Metric, bool, error) {
+	need := 0
+	if filter.Limit > 0 {
+		need = filter.Offset + filter.Limit
+	}
+	byNewest := func(metrics []*models.Metric) {
+		sort.Slice(metrics, func(i, j int) bool {
+			return metrics[i].RecordedAt.After(metrics[j].RecordedAt)
+		})
+	}
+
+	var metrics []*models.Metric
+	ok, err := s.scanMonths(ctx, filter, func(m *models.Metric) {
+		metrics = append(metrics, m)
+	}, func(month metricMonth) bool {
+		// Every file in older months was recorded before this month began
+		// (give or take a timezone), so once the page is full of entries at
+		// least that new, nothing older can change it.
+		if need > 0 && len(metrics) >= need {
+			byNewest(metrics)
+			return !metrics[need-1].RecordedAt.Before(month.start.Add(dayMargin))
+		}
+		return false
+	})
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	byNewest(metrics)
+	return metrics, true, nil
+}
+
+// scanMonths calls visit for each metric matching filter, a month
+// directory at a time, newest first, pruning by the dates and types in
+// the tree as scanMetrics describes. After each month, done (if not nil)
+// can end the scan. It reports false, possibly after some visits, if the
+// tree layout is unexpected or another layout is configured.
+func (s *MarkdownStore) scanMonths(ctx context.Context, filter MetricFilter, visit func(*models.Metric), done func(metricMonth) bool) (bool, error) {
+	if !s.datedLayout() {
+		return false, nil
+	}
+	months, ok, err := s.metricMonths()
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	for _, month := range months {
+		if filter.Until != nil && month.start.After(filter.Until.Add(dayMargin)) {
+			continue
+		}
+		if filter.Since != nil && month.start.AddDate(0, 1, 0).Add(dayMargin).Before(*filter.Since) {
+			break
+		}
+
+		entries, err := os.ReadDir(month.dir)
+		if err != nil {
+			return false, err
+		}
+		for _, e := range entries {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			if e.IsDir() {
+				return false, nil
+			}
+			name := e.Name()
+			if !strings.HasSuffix(name, ".md") {
+				continue
+			}
+			if filter.Type != nil {
+				if t := metricTypeFromName(name); t != "" && t != string(*filter.Type) {
+					continue
+				}
+			}
+
+			metrics, err := readMetricsFile(filepath.Join(month.dir, name))
+			if err != nil {
+				return false, err
+			}
+			for _, m := range metrics {
+				if matchesMetric(m, filter) {
+					visit(m)
+				}
+			}
+		}
+		if done != nil && done(month) {
+			break
+		}
+	}
+	return true, nil
+}
+
+// aggregateMetrics folds the metrics matching filter as it reads them,
+// so totals over years of entries don't hold them all in memory.
+func (s *MarkdownStore) aggregateMetrics(ctx context.Context, filter MetricFilter) (*metricAggregate, error) {
+	var agg metricAggregate
+	ok, err := s.scanMonths(ctx, filter, agg.add, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return &agg, nil
+	}
+
+	// Files outside the YYYY/MM layout: start over, reading everything
+	agg = metricAggregate{}
+	err = s.walkMetricFiles(func(path string, m *models.Metric) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if matchesMetric(m, filter) {
+			agg.add(m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &agg, nil
+}
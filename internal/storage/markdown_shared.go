@@ -0,0 +1,136 @@
+// ABOUTME: Metric files holding several entries, as layouts without {id} produce (one file per day for "flat").
+// ABOUTME: Reads either file form and adds, replaces, or removes single entries in place.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// sharedMetricsFrontmatter holds the frontmatter of a file that several
+// metrics share.
+type sharedMetricsFrontmatter struct {
+	Metrics []sharedMetricFrontmatter `yaml:"metrics"`
+}
+
+// sharedMetricFrontmatter is one metric in a shared file. Its notes sit
+// beside it, since the file's body can't be split between entries.
+type sharedMetricFrontmatter struct {
+	metricFrontmatter `yaml:",inline"`
+	Notes             string `yaml:"notes,omitempty"`
+}
+
+// sharesFiles reports whether l can put several entries in one file, as
+// patterns naming neither {id} nor {uuid} do.
+func sharesFiles(l MarkdownLayout) bool {
+	p, ok := l.(patternLayout)
+	return ok && !strings.Contains(string(p), "{id}") && !strings.Contains(string(p), "{uuid}")
+}
+
+// readMetricsFile reads the metrics in a markdown file: the one in a
+// metric file, or the list in a shared file.
+func readMetricsFile(path string) ([]*models.Metric, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var shared sharedMetricsFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &shared); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+	if len(shared.Metrics) == 0 {
+		var fm metricFrontmatter
+		if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+			return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+		}
+		m, err := metricFromFrontmatter(&fm, strings.TrimSpace(body))
+		if err != nil {
+			return nil, err
+		}
+		return []*models.Metric{m}, nil
+	}
+
+	metrics := make([]*models.Metric, 0, len(shared.Metrics))
+	for i := range shared.Metrics {
+		entry := &shared.Metrics[i]
+		m, err := metricFromFrontmatter(&entry.metricFrontmatter, strings.TrimSpace(entry.Notes))
+		if err != nil {
+			return nil, fmt.Errorf("metric %d in %s: %w", i+1, path, err)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// writeMetricsFileAt renders metrics to path: a single metric as a metric
+// file, several as a shared file listing them oldest first, and none by
+// removing the file.
+func writeMetricsFileAt(path string, metrics []*models.Metric) error {
+	switch len(metrics) {
+	case 0:
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("remove metric file: %w", err)
+		}
+		return nil
+	case 1:
+		return writeMetricFileAt(path, metrics[0])
+	}
+
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].RecordedAt.Before(metrics[j].RecordedAt)
+	})
+	fm := sharedMetricsFrontmatter{Metrics: make([]sharedMetricFrontmatter, 0, len(metrics))}
+	for _, m := range metrics {
+		entry := sharedMetricFrontmatter{metricFrontmatter: metricToFrontmatter(m)}
+		if m.Notes != nil {
+			entry.Notes = *m.Notes
+		}
+		fm.Metrics = append(fm.Metrics, entry)
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, "")
+	if err != nil {
+		return fmt.Errorf("render metric file: %w", err)
+	}
+	return mdstore.AtomicWrite(path, []byte(content))
+}
+
+// putMetricsAt adds metrics to the file at path, creating it if needed and
+// replacing any entries with the same IDs. The caller holds the store lock.
+func putMetricsAt(path string, metrics ...*models.Metric) error {
+	existing, err := readMetricsFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("read metric file %s: %w", path, err)
+	}
+	existing = slices.DeleteFunc(existing, func(old *models.Metric) bool {
+		return slices.ContainsFunc(metrics, func(m *models.Metric) bool { return m.ID == old.ID })
+	})
+	return writeMetricsFileAt(path, append(existing, metrics...))
+}
+
+// removeMetricAt drops the metric with id from the file at path, removing
+// the file once nothing else is in it. The caller holds the store lock.
+func removeMetricAt(path string, id uuid.UUID) error {
+	existing, err := readMetricsFile(path)
+	if err != nil {
+		return fmt.Errorf("read metric file %s: %w", path, err)
+	}
+	return writeMetricsFileAt(path, slices.DeleteFunc(existing, func(m *models.Metric) bool { return m.ID == id }))
+}
@@ -0,0 +1,246 @@
+// ABOUTME: Sleep session storage for the markdown backend.
+// ABOUTME: Stores one file per session under sleep/YYYY/MM, keyed by wake date.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// sleepFrontmatter holds the YAML frontmatter of a sleep session file.
+type sleepFrontmatter struct {
+	ID         string `yaml:"id"`
+	BedTime    string `yaml:"bed_time"`
+	WakeTime   string `yaml:"wake_time"`
+	Awakenings *int   `yaml:"awakenings,omitempty"`
+	Quality    *int   `yaml:"quality,omitempty"`
+	MetricID   string `yaml:"metric_id,omitempty"`
+	CreatedAt  string `yaml:"created_at"`
+}
+
+// sleepDir returns the path to the sleep sessions directory.
+func (s *MarkdownStore) sleepDir() string {
+	return filepath.Join(s.dataDir, "sleep")
+}
+
+// sleepFilePath returns the path for a sleep session file.
+// Format: sleep/YYYY/MM/YYYY-MM-DD-sleep-<id_prefix>.md, dated by wake time.
+func (s *MarkdownStore) sleepFilePath(ss *models.SleepSession) string {
+	wake := ss.WakeTime.Local()
+	return filepath.Join(s.sleepDir(), wake.Format("2006"), wake.Format("01"),
+		fmt.Sprintf("%s-sleep-%s.md", wake.Format("2006-01-02"), ss.ID.String()[:8]))
+}
+
+// sleepFromFrontmatter converts frontmatter to a models.SleepSession.
+func sleepFromFrontmatter(fm *sleepFrontmatter, notes string) (*models.SleepSession, error) {
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse sleep ID %q: %w", fm.ID, err)
+	}
+	bedTime, err := mdstore.ParseTime(fm.BedTime)
+	if err != nil {
+		return nil, fmt.Errorf("parse bed_time %q: %w", fm.BedTime, err)
+	}
+	wakeTime, err := mdstore.ParseTime(fm.WakeTime)
+	if err != nil {
+		return nil, fmt.Errorf("parse wake_time %q: %w", fm.WakeTime, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	ss := &models.SleepSession{
+		ID:         id,
+		BedTime:    bedTime,
+		WakeTime:   wakeTime,
+		Awakenings: fm.Awakenings,
+		Quality:    fm.Quality,
+		CreatedAt:  createdAt,
+	}
+	if fm.MetricID != "" {
+		metricID, err := uuid.Parse(fm.MetricID)
+		if err != nil {
+			return nil, fmt.Errorf("parse metric_id %q: %w", fm.MetricID, err)
+		}
+		ss.MetricID = &metricID
+	}
+	if notes != "" {
+		ss.Notes = &notes
+	}
+	return ss, nil
+}
+
+// sleepToFrontmatter converts a models.SleepSession to frontmatter.
+func sleepToFrontmatter(ss *models.SleepSession) sleepFrontmatter {
+	fm := sleepFrontmatter{
+		ID:         ss.ID.String(),
+		BedTime:    mdstore.FormatTime(ss.BedTime.UTC()),
+		WakeTime:   mdstore.FormatTime(ss.WakeTime.UTC()),
+		Awakenings: ss.Awakenings,
+		Quality:    ss.Quality,
+		CreatedAt:  mdstore.FormatTime(ss.CreatedAt.UTC()),
+	}
+	if ss.MetricID != nil {
+		fm.MetricID = ss.MetricID.String()
+	}
+	return fm
+}
+
+// readSleepFile reads a sleep session from a markdown file.
+func readSleepFile(path string) (*models.SleepSession, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm sleepFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	return sleepFromFrontmatter(&fm, strings.TrimSpace(body))
+}
+
+// writeSleepFile writes a sleep session to a markdown file.
+func (s *MarkdownStore) writeSleepFile(ss *models.SleepSession) error {
+	fm := sleepToFrontmatter(ss)
+
+	body := ""
+	if ss.Notes != nil && *ss.Notes != "" {
+		body = "\n" + *ss.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render sleep file: %w", err)
+	}
+
+	return mdstore.AtomicWrite(s.sleepFilePath(ss), []byte(content))
+}
+
+// walkSleepFiles walks all sleep session files and calls fn for each.
+func (s *MarkdownStore) walkSleepFiles(fn func(path string, ss *models.SleepSession) error) error {
+	dir := s.sleepDir()
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".md") {
+			return nil
+		}
+
+		ss, err := readSleepFile(path)
+		if err != nil {
+			return fmt.Errorf("read sleep file %s: %w", path, err)
+		}
+
+		return fn(path, ss)
+	})
+}
+
+// findSleepFile finds the file path for a sleep session by ID or prefix.
+func (s *MarkdownStore) findSleepFile(idOrPrefix string) (string, *models.SleepSession, error) {
+	isFullUUID := len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4
+
+	var foundPath string
+	var found *models.SleepSession
+	matchCount := 0
+
+	err := s.walkSleepFiles(func(path string, ss *models.SleepSession) error {
+		idStr := ss.ID.String()
+		if isFullUUID {
+			if idStr == idOrPrefix {
+				foundPath = path
+				found = ss
+				matchCount = 1
+				return filepath.SkipAll
+			}
+		} else if strings.HasPrefix(idStr, idOrPrefix) {
+			foundPath = path
+			found = ss
+			matchCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	if matchCount == 0 {
+		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	if matchCount > 1 {
+		return "", nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+	}
+
+	return foundPath, found, nil
+}
+
+// CreateSleepSession stores a new sleep session as a markdown file.
+func (s *MarkdownStore) CreateSleepSession(ctx context.Context, ss *models.SleepSession) error {
+	return s.writeSleepFile(ss)
+}
+
+// GetSleepSession retrieves a sleep session by ID or ID prefix.
+func (s *MarkdownStore) GetSleepSession(ctx context.Context, idOrPrefix string) (*models.SleepSession, error) {
+	_, ss, err := s.findSleepFile(idOrPrefix)
+	return ss, err
+}
+
+// ListSleepSessions retrieves sleep sessions sorted by WakeTime descending.
+func (s *MarkdownStore) ListSleepSessions(ctx context.Context, limit int) ([]*models.SleepSession, error) {
+	var sessions []*models.SleepSession
+
+	err := s.walkSleepFiles(func(path string, ss *models.SleepSession) error {
+		sessions = append(sessions, ss)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list sleep sessions: %w", err)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].WakeTime.After(sessions[j].WakeTime)
+	})
+
+	return paginate(sessions, 0, limit), nil
+}
+
+// DeleteSleepSession removes a sleep session file by ID or prefix.
+func (s *MarkdownStore) DeleteSleepSession(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, _, err := s.findSleepFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete sleep session: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete sleep file: %w", err)
+	}
+	return nil
+}
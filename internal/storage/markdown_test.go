@@ -3,8 +3,10 @@
 package storage
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,18 +33,19 @@ func setupTestMarkdownStore(t *testing.T) *MarkdownStore {
 }
 
 func TestMarkdownStoreCreateAndGetMetric(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("morning weight")
 
-	err := store.CreateMetric(m)
+	err := store.CreateMetric(ctx, m)
 	if err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Retrieve by full ID
-	got, err := store.GetMetric(m.ID.String())
+	got, err := store.GetMetric(ctx, m.ID.String())
 	if err != nil {
 		t.Fatalf("GetMetric failed: %v", err)
 	}
@@ -62,16 +65,17 @@ func TestMarkdownStoreCreateAndGetMetric(t *testing.T) {
 }
 
 func TestMarkdownStoreGetMetricByPrefix(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := store.CreateMetric(m); err != nil {
+	if err := store.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Retrieve by 8-char prefix
 	prefix := m.ID.String()[:8]
-	got, err := store.GetMetric(prefix)
+	got, err := store.GetMetric(ctx, prefix)
 	if err != nil {
 		t.Fatalf("GetMetric by prefix failed: %v", err)
 	}
@@ -82,6 +86,7 @@ func TestMarkdownStoreGetMetricByPrefix(t *testing.T) {
 }
 
 func TestMarkdownStoreListMetrics(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	// Create metrics with different timestamps
@@ -93,13 +98,13 @@ func TestMarkdownStoreListMetrics(t *testing.T) {
 	m3.RecordedAt = time.Now()
 
 	for _, m := range []*models.Metric{m1, m2, m3} {
-		if err := store.CreateMetric(m); err != nil {
+		if err := store.CreateMetric(ctx, m); err != nil {
 			t.Fatalf("CreateMetric failed: %v", err)
 		}
 	}
 
 	// List all metrics (should be ordered by RecordedAt DESC)
-	all, err := store.ListMetrics(nil, 0)
+	all, err := store.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -114,7 +119,7 @@ func TestMarkdownStoreListMetrics(t *testing.T) {
 
 	// Filter by type
 	weightType := models.MetricWeight
-	weights, err := store.ListMetrics(&weightType, 0)
+	weights, err := store.ListMetrics(ctx, &weightType, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics with type failed: %v", err)
 	}
@@ -123,7 +128,7 @@ func TestMarkdownStoreListMetrics(t *testing.T) {
 	}
 
 	// Test limit
-	limited, err := store.ListMetrics(nil, 2)
+	limited, err := store.ListMetrics(ctx, nil, 2)
 	if err != nil {
 		t.Fatalf("ListMetrics with limit failed: %v", err)
 	}
@@ -133,26 +138,28 @@ func TestMarkdownStoreListMetrics(t *testing.T) {
 }
 
 func TestMarkdownStoreDeleteMetric(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := store.CreateMetric(m); err != nil {
+	if err := store.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Delete by prefix
-	if err := store.DeleteMetric(m.ID.String()[:8]); err != nil {
+	if err := store.DeleteMetric(ctx, m.ID.String()[:8]); err != nil {
 		t.Fatalf("DeleteMetric failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := store.GetMetric(m.ID.String())
+	_, err := store.GetMetric(ctx, m.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted metric")
 	}
 }
 
 func TestMarkdownStoreGetLatestMetric(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	m1 := models.NewMetric(models.MetricWeight, 82.0)
@@ -160,14 +167,14 @@ func TestMarkdownStoreGetLatestMetric(t *testing.T) {
 	m2 := models.NewMetric(models.MetricWeight, 83.0)
 	m2.RecordedAt = time.Now()
 
-	if err := store.CreateMetric(m1); err != nil {
+	if err := store.CreateMetric(ctx, m1); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
-	if err := store.CreateMetric(m2); err != nil {
+	if err := store.CreateMetric(ctx, m2); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
-	latest, err := store.GetLatestMetric(models.MetricWeight)
+	latest, err := store.GetLatestMetric(ctx, models.MetricWeight)
 	if err != nil {
 		t.Fatalf("GetLatestMetric failed: %v", err)
 	}
@@ -178,17 +185,18 @@ func TestMarkdownStoreGetLatestMetric(t *testing.T) {
 }
 
 func TestMarkdownStoreCreateAndGetWorkout(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
 	w.WithDuration(45)
 	w.WithNotes("morning run")
 
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := store.GetWorkout(w.ID.String())
+	got, err := store.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -208,10 +216,11 @@ func TestMarkdownStoreCreateAndGetWorkout(t *testing.T) {
 }
 
 func TestMarkdownStoreWorkoutWithMetrics(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
@@ -219,15 +228,15 @@ func TestMarkdownStoreWorkoutWithMetrics(t *testing.T) {
 	wm1 := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
 	wm2 := models.NewWorkoutMetric(w.ID, "avg_hr", 150, "bpm")
 
-	if err := store.AddWorkoutMetric(wm1); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm1); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
-	if err := store.AddWorkoutMetric(wm2); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm2); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Get workout with metrics
-	got, err := store.GetWorkoutWithMetrics(w.ID.String())
+	got, err := store.GetWorkoutWithMetrics(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
 	}
@@ -238,31 +247,33 @@ func TestMarkdownStoreWorkoutWithMetrics(t *testing.T) {
 }
 
 func TestMarkdownStoreDeleteWorkout(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
-	if err := store.AddWorkoutMetric(wm); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Delete workout (and its metrics since they're embedded in the file)
-	if err := store.DeleteWorkout(w.ID.String()[:8]); err != nil {
+	if err := store.DeleteWorkout(ctx, w.ID.String()[:8]); err != nil {
 		t.Fatalf("DeleteWorkout failed: %v", err)
 	}
 
 	// Verify workout deleted
-	_, err := store.GetWorkout(w.ID.String())
+	_, err := store.GetWorkout(ctx, w.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted workout")
 	}
 }
 
 func TestMarkdownStoreListWorkouts(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w1 := models.NewWorkout("run")
@@ -273,13 +284,13 @@ func TestMarkdownStoreListWorkouts(t *testing.T) {
 	w3.StartedAt = time.Now()
 
 	for _, w := range []*models.Workout{w1, w2, w3} {
-		if err := store.CreateWorkout(w); err != nil {
+		if err := store.CreateWorkout(ctx, w); err != nil {
 			t.Fatalf("CreateWorkout failed: %v", err)
 		}
 	}
 
 	// List all
-	all, err := store.ListWorkouts(nil, 0)
+	all, err := store.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -289,7 +300,7 @@ func TestMarkdownStoreListWorkouts(t *testing.T) {
 
 	// Filter by type
 	runType := "run"
-	runs, err := store.ListWorkouts(&runType, 0)
+	runs, err := store.ListWorkouts(ctx, &runType, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts with type failed: %v", err)
 	}
@@ -298,7 +309,7 @@ func TestMarkdownStoreListWorkouts(t *testing.T) {
 	}
 
 	// Test limit
-	limited, err := store.ListWorkouts(nil, 2)
+	limited, err := store.ListWorkouts(ctx, nil, 2)
 	if err != nil {
 		t.Fatalf("ListWorkouts with limit failed: %v", err)
 	}
@@ -308,24 +319,25 @@ func TestMarkdownStoreListWorkouts(t *testing.T) {
 }
 
 func TestMarkdownStoreListWorkoutMetrics(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm1 := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
 	wm2 := models.NewWorkoutMetric(w.ID, "pace", 5.5, "min/km")
 
-	if err := store.AddWorkoutMetric(wm1); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm1); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
-	if err := store.AddWorkoutMetric(wm2); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm2); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	metrics, err := store.ListWorkoutMetrics(w.ID)
+	metrics, err := store.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		t.Fatalf("ListWorkoutMetrics failed: %v", err)
 	}
@@ -336,24 +348,25 @@ func TestMarkdownStoreListWorkoutMetrics(t *testing.T) {
 }
 
 func TestMarkdownStoreDeleteWorkoutMetric(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
-	if err := store.AddWorkoutMetric(wm); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	if err := store.DeleteWorkoutMetric(wm.ID.String()[:8]); err != nil {
+	if err := store.DeleteWorkoutMetric(ctx, wm.ID.String()[:8]); err != nil {
 		t.Fatalf("DeleteWorkoutMetric failed: %v", err)
 	}
 
 	// Verify deleted by checking workout metrics list is empty
-	metrics, err := store.ListWorkoutMetrics(w.ID)
+	metrics, err := store.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		t.Fatalf("ListWorkoutMetrics failed: %v", err)
 	}
@@ -363,18 +376,19 @@ func TestMarkdownStoreDeleteWorkoutMetric(t *testing.T) {
 }
 
 func TestMarkdownStoreGetWorkoutMetric(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	store.CreateWorkout(w)
+	store.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	if err := store.AddWorkoutMetric(wm); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Retrieve by full UUID
-	got, err := store.GetWorkoutMetric(wm.ID.String())
+	got, err := store.GetWorkoutMetric(ctx, wm.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric by full UUID failed: %v", err)
 	}
@@ -391,18 +405,19 @@ func TestMarkdownStoreGetWorkoutMetric(t *testing.T) {
 }
 
 func TestMarkdownStoreGetWorkoutMetricByPrefix(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	store.CreateWorkout(w)
+	store.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	if err := store.AddWorkoutMetric(wm); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Retrieve by prefix
-	got, err := store.GetWorkoutMetric(wm.ID.String()[:8])
+	got, err := store.GetWorkoutMetric(ctx, wm.ID.String()[:8])
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric by prefix failed: %v", err)
 	}
@@ -415,7 +430,7 @@ func TestMarkdownStoreGetWorkoutMetricByPrefix(t *testing.T) {
 func TestMarkdownStoreGetMetricNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	_, err := store.GetMetric("nonexistent")
+	_, err := store.GetMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent metric")
 	}
@@ -424,7 +439,7 @@ func TestMarkdownStoreGetMetricNotFound(t *testing.T) {
 func TestMarkdownStoreGetWorkoutNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	_, err := store.GetWorkout("nonexistent")
+	_, err := store.GetWorkout(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout")
 	}
@@ -433,7 +448,7 @@ func TestMarkdownStoreGetWorkoutNotFound(t *testing.T) {
 func TestMarkdownStoreDeleteMetricNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	err := store.DeleteMetric("nonexistent")
+	err := store.DeleteMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent metric")
 	}
@@ -442,7 +457,7 @@ func TestMarkdownStoreDeleteMetricNotFound(t *testing.T) {
 func TestMarkdownStoreDeleteWorkoutNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	err := store.DeleteWorkout("nonexistent")
+	err := store.DeleteWorkout(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout")
 	}
@@ -451,7 +466,7 @@ func TestMarkdownStoreDeleteWorkoutNotFound(t *testing.T) {
 func TestMarkdownStoreDeleteWorkoutMetricNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	err := store.DeleteWorkoutMetric("nonexistent")
+	err := store.DeleteWorkoutMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout metric")
 	}
@@ -460,7 +475,7 @@ func TestMarkdownStoreDeleteWorkoutMetricNotFound(t *testing.T) {
 func TestMarkdownStoreGetLatestMetricNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	_, err := store.GetLatestMetric(models.MetricWeight)
+	_, err := store.GetLatestMetric(t.Context(), models.MetricWeight)
 	if err == nil {
 		t.Error("Expected error when no metrics exist")
 	}
@@ -469,7 +484,7 @@ func TestMarkdownStoreGetLatestMetricNotFound(t *testing.T) {
 func TestMarkdownStoreGetWorkoutMetricNotFound(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	_, err := store.GetWorkoutMetric("nonexistent")
+	_, err := store.GetWorkoutMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout metric")
 	}
@@ -478,7 +493,7 @@ func TestMarkdownStoreGetWorkoutMetricNotFound(t *testing.T) {
 func TestMarkdownStoreListMetricsEmpty(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	metrics, err := store.ListMetrics(nil, 0)
+	metrics, err := store.ListMetrics(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -491,7 +506,7 @@ func TestMarkdownStoreListMetricsEmpty(t *testing.T) {
 func TestMarkdownStoreListWorkoutsEmpty(t *testing.T) {
 	store := setupTestMarkdownStore(t)
 
-	workouts, err := store.ListWorkouts(nil, 0)
+	workouts, err := store.ListWorkouts(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -512,16 +527,17 @@ func TestMarkdownStoreClose(t *testing.T) {
 }
 
 func TestMarkdownStoreWorkoutNullableDuration(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	// Workout without duration
 	w := models.NewWorkout("run")
 
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := store.GetWorkout(w.ID.String())
+	got, err := store.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -532,16 +548,17 @@ func TestMarkdownStoreWorkoutNullableDuration(t *testing.T) {
 }
 
 func TestMarkdownStoreWorkoutNullableNotes(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	// Workout without notes
 	w := models.NewWorkout("run")
 
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := store.GetWorkout(w.ID.String())
+	got, err := store.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -552,19 +569,20 @@ func TestMarkdownStoreWorkoutNullableNotes(t *testing.T) {
 }
 
 func TestMarkdownStoreWorkoutMetricNullableUnit(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("lift")
-	store.CreateWorkout(w)
+	store.CreateWorkout(ctx, w)
 
 	// Workout metric without unit
 	wm := models.NewWorkoutMetric(w.ID, "sets", 4, "")
 
-	if err := store.AddWorkoutMetric(wm); err != nil {
+	if err := store.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	got, err := store.GetWorkoutMetric(wm.ID.String())
+	got, err := store.GetWorkoutMetric(ctx, wm.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric failed: %v", err)
 	}
@@ -575,23 +593,24 @@ func TestMarkdownStoreWorkoutMetricNullableUnit(t *testing.T) {
 }
 
 func TestMarkdownStoreGetAllData(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	// Create metrics
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
-	store.CreateMetric(m1)
-	store.CreateMetric(m2)
+	store.CreateMetric(ctx, m1)
+	store.CreateMetric(ctx, m2)
 
 	// Create workout with metrics
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	store.CreateWorkout(w)
+	store.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	store.AddWorkoutMetric(wm)
+	store.AddWorkoutMetric(ctx, wm)
 
-	data, err := store.GetAllData()
+	data, err := store.GetAllData(ctx)
 	if err != nil {
 		t.Fatalf("GetAllData failed: %v", err)
 	}
@@ -608,6 +627,7 @@ func TestMarkdownStoreGetAllData(t *testing.T) {
 }
 
 func TestMarkdownStoreImportData(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	// Create export data
@@ -644,12 +664,12 @@ func TestMarkdownStoreImportData(t *testing.T) {
 	}
 
 	// Import
-	if err := store.ImportData(data); err != nil {
+	if err := store.ImportData(ctx, data); err != nil {
 		t.Fatalf("ImportData failed: %v", err)
 	}
 
 	// Verify
-	metrics, err := store.ListMetrics(nil, 0)
+	metrics, err := store.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -657,7 +677,7 @@ func TestMarkdownStoreImportData(t *testing.T) {
 		t.Errorf("Expected 1 metric, got %d", len(metrics))
 	}
 
-	workouts, err := store.ListWorkouts(nil, 0)
+	workouts, err := store.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -667,6 +687,7 @@ func TestMarkdownStoreImportData(t *testing.T) {
 }
 
 func TestMarkdownStoreFileLayout(t *testing.T) {
+	ctx := t.Context()
 	tmpDir, err := os.MkdirTemp("", "health-md-layout-*")
 	if err != nil {
 		t.Fatalf("Failed to create temp dir: %v", err)
@@ -680,7 +701,7 @@ func TestMarkdownStoreFileLayout(t *testing.T) {
 
 	// Create a metric
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := store.CreateMetric(m); err != nil {
+	if err := store.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
@@ -706,7 +727,7 @@ func TestMarkdownStoreFileLayout(t *testing.T) {
 
 	// Create a workout
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
@@ -755,12 +776,13 @@ func TestMarkdownStoreMetricRoundTrip(t *testing.T) {
 
 	for _, tc := range metricTypes {
 		t.Run(string(tc.metricType), func(t *testing.T) {
+			ctx := t.Context()
 			m := models.NewMetric(tc.metricType, tc.value)
-			if err := store.CreateMetric(m); err != nil {
+			if err := store.CreateMetric(ctx, m); err != nil {
 				t.Fatalf("CreateMetric(%s) failed: %v", tc.metricType, err)
 			}
 
-			got, err := store.GetMetric(m.ID.String())
+			got, err := store.GetMetric(ctx, m.ID.String())
 			if err != nil {
 				t.Fatalf("GetMetric(%s) failed: %v", tc.metricType, err)
 			}
@@ -779,54 +801,57 @@ func TestMarkdownStoreMetricRoundTrip(t *testing.T) {
 }
 
 func TestMarkdownStoreDeleteMetricByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := store.CreateMetric(m); err != nil {
+	if err := store.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Delete by full UUID
-	if err := store.DeleteMetric(m.ID.String()); err != nil {
+	if err := store.DeleteMetric(ctx, m.ID.String()); err != nil {
 		t.Fatalf("DeleteMetric by full UUID failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := store.GetMetric(m.ID.String())
+	_, err := store.GetMetric(ctx, m.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted metric")
 	}
 }
 
 func TestMarkdownStoreDeleteWorkoutByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	// Delete by full UUID
-	if err := store.DeleteWorkout(w.ID.String()); err != nil {
+	if err := store.DeleteWorkout(ctx, w.ID.String()); err != nil {
 		t.Fatalf("DeleteWorkout by full UUID failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := store.GetWorkout(w.ID.String())
+	_, err := store.GetWorkout(ctx, w.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted workout")
 	}
 }
 
 func TestMarkdownStoreGetWorkoutByPrefix(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	w := models.NewWorkout("run")
-	if err := store.CreateWorkout(w); err != nil {
+	if err := store.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := store.GetWorkout(w.ID.String()[:8])
+	got, err := store.GetWorkout(ctx, w.ID.String()[:8])
 	if err != nil {
 		t.Fatalf("GetWorkout by prefix failed: %v", err)
 	}
@@ -837,6 +862,7 @@ func TestMarkdownStoreGetWorkoutByPrefix(t *testing.T) {
 }
 
 func TestMarkdownStoreMetricWithCustomTimestamp(t *testing.T) {
+	ctx := t.Context()
 	store := setupTestMarkdownStore(t)
 
 	m := models.NewMetric(models.MetricHRV, 48)
@@ -844,11 +870,11 @@ func TestMarkdownStoreMetricWithCustomTimestamp(t *testing.T) {
 	customTime := time.Now().Add(-1 * time.Hour)
 	m.WithRecordedAt(customTime)
 
-	if err := store.CreateMetric(m); err != nil {
+	if err := store.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
-	got, err := store.GetMetric(m.ID.String())
+	got, err := store.GetMetric(ctx, m.ID.String())
 	if err != nil {
 		t.Fatalf("GetMetric failed: %v", err)
 	}
@@ -869,3 +895,1006 @@ func TestMarkdownStoreImplementsRepository(t *testing.T) {
 	var r Repository = store
 	_ = r
 }
+
+func TestMarkdownStoreQueryMetricsPagination(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	base := time.Now()
+	var created []*models.Metric
+	for i := 0; i < 5; i++ {
+		m := models.NewMetric(models.MetricWeight, 80+float64(i))
+		m.RecordedAt = base.Add(-time.Duration(i) * time.Hour)
+		if err := store.CreateMetric(ctx, m); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+		created = append(created, m)
+	}
+
+	page, err := store.QueryMetrics(ctx, MetricFilter{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(page) != 2 {
+		t.Fatalf("Expected 2 metrics, got %d", len(page))
+	}
+	if page[0].ID != created[2].ID || page[1].ID != created[3].ID {
+		t.Error("Expected third and fourth most recent metrics")
+	}
+
+	empty, err := store.QueryMetrics(ctx, MetricFilter{Limit: 2, Offset: 10})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("Expected no metrics, got %d", len(empty))
+	}
+}
+
+func TestMarkdownStoreQueryDateRange(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		m := models.NewMetric(models.MetricSteps, float64(1000*i))
+		m.RecordedAt = day.AddDate(0, 0, -i)
+		if err := store.CreateMetric(ctx, m); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+		w := models.NewWorkout("run").WithStartedAt(day.AddDate(0, 0, -i))
+		if err := store.CreateWorkout(ctx, w); err != nil {
+			t.Fatalf("CreateWorkout failed: %v", err)
+		}
+	}
+
+	since := day.AddDate(0, 0, -2)
+	until := day
+	metrics, err := store.QueryMetrics(ctx, MetricFilter{Since: &since, Until: &until})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Errorf("Expected 2 metrics in range, got %d", len(metrics))
+	}
+
+	workouts, err := store.QueryWorkouts(ctx, WorkoutFilter{Since: &since})
+	if err != nil {
+		t.Fatalf("QueryWorkouts failed: %v", err)
+	}
+	if len(workouts) != 3 {
+		t.Errorf("Expected 3 workouts since cutoff, got %d", len(workouts))
+	}
+}
+
+func TestMarkdownStoreWorkoutSets(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	w := models.NewWorkout("lift")
+	if err := store.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		ws := models.NewWorkoutSet(w.ID, "squat", i, 5).WithWeight(140, "kg")
+		if err := store.AddWorkoutSet(ctx, ws); err != nil {
+			t.Fatalf("AddWorkoutSet failed: %v", err)
+		}
+	}
+	wm := models.NewWorkoutMetric(w.ID, "rpe", 8, "")
+	if err := store.AddWorkoutMetric(ctx, wm); err != nil {
+		t.Fatalf("AddWorkoutMetric failed: %v", err)
+	}
+
+	sets, err := store.ListWorkoutSets(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutSets failed: %v", err)
+	}
+	if len(sets) != 3 {
+		t.Fatalf("Expected 3 sets, got %d", len(sets))
+	}
+	if sets[0].Weight == nil || *sets[0].Weight != 140 {
+		t.Error("Expected weight to round-trip")
+	}
+	if sets[0].WeightUnit == nil || *sets[0].WeightUnit != "kg" {
+		t.Error("Expected weight unit to round-trip")
+	}
+
+	// Deleting a workout metric must keep the sets intact
+	if err := store.DeleteWorkoutMetric(ctx, wm.ID.String()); err != nil {
+		t.Fatalf("DeleteWorkoutMetric failed: %v", err)
+	}
+	full, err := store.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+	}
+	if len(full.Sets) != 3 || len(full.Metrics) != 0 {
+		t.Errorf("Expected 3 sets and 0 metrics, got %d and %d", len(full.Sets), len(full.Metrics))
+	}
+
+	plain, err := store.GetWorkout(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkout failed: %v", err)
+	}
+	if plain.Sets != nil {
+		t.Error("Expected GetWorkout to omit sets")
+	}
+}
+
+func TestMarkdownStoreImportDataNoDuplicateChildren(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	w := models.NewWorkout("lift")
+	w.Metrics = []models.WorkoutMetric{*models.NewWorkoutMetric(w.ID, "rpe", 7, "")}
+	w.Sets = []models.WorkoutSet{*models.NewWorkoutSet(w.ID, "bench", 1, 5)}
+
+	if err := ImportDataToRepo(ctx, store, &ExportData{Workouts: []*models.Workout{w}}); err != nil {
+		t.Fatalf("ImportDataToRepo failed: %v", err)
+	}
+
+	full, err := store.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+	}
+	if len(full.Metrics) != 1 || len(full.Sets) != 1 {
+		t.Errorf("Expected 1 metric and 1 set, got %d and %d", len(full.Metrics), len(full.Sets))
+	}
+}
+
+func TestMarkdownStoreSleepSessions(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	bed := time.Date(2024, 12, 13, 23, 30, 0, 0, time.UTC)
+	s := models.NewSleepSession(bed, bed.Add(7*time.Hour+40*time.Minute)).WithQuality(6).WithNotes("late coffee")
+
+	m, err := RecordSleepSession(ctx, store, s, models.SourceManual)
+	if err != nil {
+		t.Fatalf("RecordSleepSession failed: %v", err)
+	}
+
+	path := filepath.Join(store.dataDir, "sleep", "2024", "12", "2024-12-14-sleep-"+s.ID.String()[:8]+".md")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected sleep file at %s: %v", path, err)
+	}
+
+	got, err := store.GetSleepSession(ctx, s.ID.String()[:8])
+	if err != nil {
+		t.Fatalf("GetSleepSession failed: %v", err)
+	}
+	if got.Notes == nil || *got.Notes != "late coffee" {
+		t.Error("expected notes to round-trip")
+	}
+	if got.Quality == nil || *got.Quality != 6 {
+		t.Error("expected quality to round-trip")
+	}
+	if got.Awakenings != nil {
+		t.Error("expected awakenings to stay unset")
+	}
+	if got.MetricID == nil || *got.MetricID != m.ID {
+		t.Error("expected MetricID to round-trip")
+	}
+
+	data, err := store.GetAllData(ctx)
+	if err != nil {
+		t.Fatalf("GetAllData failed: %v", err)
+	}
+	if len(data.SleepSessions) != 1 || len(data.Metrics) != 1 {
+		t.Errorf("expected 1 session and 1 metric in export, got %d and %d", len(data.SleepSessions), len(data.Metrics))
+	}
+
+	if err := RemoveSleepSession(ctx, store, s.ID.String()); err != nil {
+		t.Fatalf("RemoveSleepSession failed: %v", err)
+	}
+	sessions, _ := store.ListSleepSessions(ctx, 0)
+	metrics, _ := store.ListMetrics(ctx, nil, 0)
+	if len(sessions) != 0 || len(metrics) != 0 {
+		t.Errorf("expected session and metric removed, got %d and %d", len(sessions), len(metrics))
+	}
+}
+
+func TestMarkdownStoreMedications(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	vitD := models.NewMedication("Vitamin D", "1000 IU", "daily")
+	if err := store.CreateMedication(ctx, vitD); err != nil {
+		t.Fatalf("CreateMedication failed: %v", err)
+	}
+	if err := store.CreateMedication(ctx, models.NewMedication("VITAMIN D", "1", "daily")); err == nil {
+		t.Error("expected duplicate name to be rejected")
+	}
+	fish := models.NewMedication("Fish Oil", "1 g", "daily")
+	store.CreateMedication(ctx, fish)
+
+	taken := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	in := models.NewMedicationIntake(vitD.ID).WithTakenAt(taken).WithNotes("with breakfast")
+	if err := store.LogMedicationIntake(ctx, in); err != nil {
+		t.Fatalf("LogMedicationIntake failed: %v", err)
+	}
+	store.LogMedicationIntake(ctx, models.NewMedicationIntake(fish.ID).WithTakenAt(taken))
+
+	if err := store.LogMedicationIntake(ctx, models.NewMedicationIntake(uuid.New())); err == nil {
+		t.Error("expected intake for unknown medication to fail")
+	}
+
+	path := filepath.Join(store.dataDir, "intakes", "2024", "12", "2024-12-14-vitamin-d-"+in.ID.String()[:8]+".md")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected intake file at %s: %v", path, err)
+	}
+
+	got, err := store.GetMedication(ctx, "vitamin-d")
+	if err != nil || got.ID != vitD.ID {
+		t.Fatalf("GetMedication by slug = %v, %v", got, err)
+	}
+
+	intakes, err := store.ListMedicationIntakes(ctx, IntakeFilter{MedicationID: &vitD.ID})
+	if err != nil {
+		t.Fatalf("ListMedicationIntakes failed: %v", err)
+	}
+	if len(intakes) != 1 || intakes[0].Notes == nil || *intakes[0].Notes != "with breakfast" {
+		t.Fatalf("expected one intake with notes, got %+v", intakes)
+	}
+
+	if err := store.DeleteMedication(ctx, vitD.ID.String()[:8]); err != nil {
+		t.Fatalf("DeleteMedication failed: %v", err)
+	}
+	meds, _ := store.ListMedications(ctx)
+	all, _ := store.ListMedicationIntakes(ctx, IntakeFilter{})
+	if len(meds) != 1 || len(all) != 1 {
+		t.Errorf("expected fish oil and its intake to remain, got %d meds and %d intakes", len(meds), len(all))
+	}
+}
+
+func TestMarkdownStoreLocations(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	gym := models.NewLocation("Gym").WithCoordinates(41.89, -87.62).WithNotes("downtown")
+	if err := store.CreateLocation(ctx, gym); err != nil {
+		t.Fatalf("CreateLocation failed: %v", err)
+	}
+	if err := store.CreateLocation(ctx, models.NewLocation("GYM")); err == nil {
+		t.Error("expected duplicate name to be rejected")
+	}
+
+	path := filepath.Join(store.dataDir, "locations", "gym-"+gym.ID.String()[:8]+".md")
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected location file at %s: %v", path, err)
+	}
+
+	got, err := store.GetLocation(ctx, "gym")
+	if err != nil || got.ID != gym.ID {
+		t.Fatalf("GetLocation(gym) = %v, %v", got, err)
+	}
+	if got.Longitude == nil || *got.Longitude != -87.62 || got.Notes == nil || *got.Notes != "downtown" {
+		t.Error("expected coordinates and notes to round-trip")
+	}
+
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82).WithLocation("Gym"))
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 81))
+	store.CreateWorkout(ctx, models.NewWorkout("lift").WithLocation("Gym"))
+	store.CreateWorkout(ctx, models.NewWorkout("run"))
+
+	loc := "gym"
+	metrics, _ := store.QueryMetrics(ctx, MetricFilter{Location: &loc})
+	if len(metrics) != 1 || *metrics[0].Location != "Gym" {
+		t.Errorf("expected 1 metric tagged Gym, got %d", len(metrics))
+	}
+	workouts, _ := store.QueryWorkouts(ctx, WorkoutFilter{Location: &loc})
+	if len(workouts) != 1 || workouts[0].WorkoutType != "lift" {
+		t.Errorf("expected the lift workout, got %d workouts", len(workouts))
+	}
+
+	if err := store.DeleteLocation(ctx, gym.ID.String()[:8]); err != nil {
+		t.Fatalf("DeleteLocation failed: %v", err)
+	}
+	if locs, _ := store.ListLocations(ctx); len(locs) != 0 {
+		t.Errorf("expected no locations after delete, got %d", len(locs))
+	}
+}
+
+func TestMarkdownStoreSumMetrics(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	day := time.Date(2024, 12, 14, 9, 0, 0, 0, time.UTC)
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWater, 250).WithRecordedAt(day))
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWater, 500).WithRecordedAt(day.Add(8*time.Hour)))
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWater, 300).WithRecordedAt(day.AddDate(0, 0, -1)))
+
+	daily, err := DailyTotal(ctx, store, models.MetricWater, day)
+	if err != nil {
+		t.Fatalf("DailyTotal failed: %v", err)
+	}
+	if daily.Count != 2 || daily.Sum != 750 {
+		t.Errorf("DailyTotal = %+v, want 2 entries totalling 750", daily)
+	}
+
+	mt := models.MetricWater
+	if n, err := store.CountMetrics(ctx, MetricFilter{Type: &mt, Limit: 1}); err != nil || n != 3 {
+		t.Errorf("CountMetrics = %d, %v; want 3", n, err)
+	}
+	store.CreateWorkout(ctx, models.NewWorkout("run").WithStartedAt(day))
+	if n, err := store.CountWorkouts(ctx, WorkoutFilter{}); err != nil || n != 1 {
+		t.Errorf("CountWorkouts = %d, %v; want 1", n, err)
+	}
+}
+
+func TestMarkdownStoreReminderState(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	last, err := store.GetReminderLastFired(ctx, "log-weight")
+	if err != nil || last != nil {
+		t.Fatalf("GetReminderLastFired before firing = %v, %v; want nil", last, err)
+	}
+
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	store.SetReminderLastFired(ctx, "drink-water", at.Add(-time.Hour))
+	if err := store.SetReminderLastFired(ctx, "log-weight", at); err != nil {
+		t.Fatalf("SetReminderLastFired failed: %v", err)
+	}
+
+	last, err = store.GetReminderLastFired(ctx, "log-weight")
+	if err != nil || last == nil || !last.Equal(at) {
+		t.Errorf("GetReminderLastFired = %v, %v; want %v", last, err, at)
+	}
+	other, _ := store.GetReminderLastFired(ctx, "drink-water")
+	if other == nil || !other.Equal(at.Add(-time.Hour)) {
+		t.Errorf("drink-water state = %v, want it kept alongside log-weight", other)
+	}
+
+	until := at.Add(2 * time.Hour)
+	if err := store.SetReminderSnooze(ctx, "log-weight", &until); err != nil {
+		t.Fatalf("SetReminderSnooze failed: %v", err)
+	}
+	if got, err := store.GetReminderSnooze(ctx, "log-weight"); err != nil || got == nil || !got.Equal(until) {
+		t.Errorf("GetReminderSnooze = %v, %v; want %v", got, err, until)
+	}
+	store.SetReminderSnooze(ctx, "log-weight", nil)
+	if got, _ := store.GetReminderSnooze(ctx, "log-weight"); got != nil {
+		t.Errorf("snooze = %v after clearing, want nil", got)
+	}
+	if last, _ := store.GetReminderLastFired(ctx, "log-weight"); last == nil || !last.Equal(at) {
+		t.Errorf("snoozing changed last fired to %v", last)
+	}
+}
+
+func TestMarkdownStoreTrips(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	start := time.Date(2024, 12, 10, 9, 0, 0, 0, time.UTC)
+	trip, _ := models.NewTrip("Tokyo").WithTimezone("Asia/Tokyo")
+	trip.WithStartedAt(start).WithNotes("conference")
+	if err := store.CreateTrip(ctx, trip); err != nil {
+		t.Fatalf("CreateTrip failed: %v", err)
+	}
+
+	active, err := ActiveTrip(ctx, store)
+	if err != nil || active == nil || active.ID != trip.ID {
+		t.Fatalf("ActiveTrip = %+v, %v; want Tokyo", active, err)
+	}
+	if active.Notes == nil || *active.Notes != "conference" {
+		t.Error("expected notes to round-trip")
+	}
+
+	end := start.AddDate(0, 0, 5)
+	if err := store.EndTrip(ctx, trip.ID.String()[:8], end); err != nil {
+		t.Fatalf("EndTrip failed: %v", err)
+	}
+	got, err := store.GetTrip(ctx, trip.ID.String())
+	if err != nil || got.EndedAt == nil || !got.EndedAt.Equal(end) {
+		t.Errorf("GetTrip after end = %+v, %v", got, err)
+	}
+	if active, _ := ActiveTrip(ctx, store); active != nil {
+		t.Error("expected no active trip after end")
+	}
+
+	if err := store.DeleteTrip(ctx, trip.ID.String()); err != nil {
+		t.Fatalf("DeleteTrip failed: %v", err)
+	}
+	if trips, _ := store.ListTrips(ctx, 0); len(trips) != 0 {
+		t.Errorf("expected no trips after delete, got %d", len(trips))
+	}
+}
+
+func TestMarkdownStoreFasts(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	start := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+	fast := models.NewFast(18).WithStartedAt(start).WithNotes("first 18")
+	if err := store.CreateFast(ctx, fast); err != nil {
+		t.Fatalf("CreateFast failed: %v", err)
+	}
+
+	active, err := ActiveFast(ctx, store)
+	if err != nil || active == nil || active.ID != fast.ID || active.TargetHours != 18 {
+		t.Fatalf("ActiveFast = %+v, %v; want the 18 hour fast", active, err)
+	}
+	if active.Notes == nil || *active.Notes != "first 18" {
+		t.Error("expected notes to round-trip")
+	}
+
+	end := start.Add(19 * time.Hour)
+	if err := store.EndFast(ctx, fast.ID.String()[:8], end); err != nil {
+		t.Fatalf("EndFast failed: %v", err)
+	}
+	got, err := store.GetFast(ctx, fast.ID.String())
+	if err != nil || got.EndedAt == nil || !got.EndedAt.Equal(end) {
+		t.Errorf("GetFast after end = %+v, %v", got, err)
+	}
+
+	if err := store.DeleteFast(ctx, fast.ID.String()); err != nil {
+		t.Fatalf("DeleteFast failed: %v", err)
+	}
+	if fasts, _ := store.ListFasts(ctx, 0); len(fasts) != 0 {
+		t.Errorf("expected no fasts after delete, got %d", len(fasts))
+	}
+}
+
+func TestMarkdownStoreAppointments(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	a := models.NewAppointment("Dr. Lee", now.AddDate(0, 0, 1)).WithDuration(30).WithReason("annual physical")
+	if err := store.CreateAppointment(ctx, a); err != nil {
+		t.Fatalf("CreateAppointment failed: %v", err)
+	}
+
+	upcoming, err := UpcomingAppointments(ctx, store, now, 0)
+	if err != nil || len(upcoming) != 1 || upcoming[0].ID != a.ID {
+		t.Fatalf("UpcomingAppointments = %+v, %v", upcoming, err)
+	}
+
+	summary := "Discussed sleep.\n\n- Try magnesium\n- Follow up in 3 months"
+	if err := store.SetAppointmentSummary(ctx, a.ID.String()[:8], summary); err != nil {
+		t.Fatalf("SetAppointmentSummary failed: %v", err)
+	}
+	got, err := store.GetAppointment(ctx, a.ID.String())
+	if err != nil || got.Summary == nil || *got.Summary != summary {
+		t.Errorf("GetAppointment summary = %v, %v; want multi-line summary kept", got.Summary, err)
+	}
+	if got.Reason == nil || *got.Reason != "annual physical" || *got.DurationMinutes != 30 {
+		t.Errorf("expected reason and duration to round-trip, got %+v", got)
+	}
+
+	if err := store.DeleteAppointment(ctx, a.ID.String()); err != nil {
+		t.Fatalf("DeleteAppointment failed: %v", err)
+	}
+	if appts, _ := store.ListAppointments(ctx, AppointmentFilter{}); len(appts) != 0 {
+		t.Errorf("expected no appointments after delete, got %d", len(appts))
+	}
+}
+
+func TestMarkdownStoreBloodPressureReadings(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	sys, dia := models.NewBloodPressure(118, 76, at)
+	if err := RecordBloodPressure(ctx, store, sys, dia); err != nil {
+		t.Fatalf("RecordBloodPressure failed: %v", err)
+	}
+
+	got, err := store.GetMetric(ctx, sys.ID.String())
+	if err != nil || got.ReadingID == nil || *got.ReadingID != *dia.ReadingID {
+		t.Fatalf("expected reading ID to round-trip, got %+v, %v", got, err)
+	}
+
+	metrics, _ := store.ListMetrics(ctx, nil, 0)
+	entries := models.GroupReadings(metrics)
+	if len(entries) != 1 || entries[0].Reading() != "118/76" {
+		t.Errorf("expected one 118/76 reading, got %+v", entries)
+	}
+}
+
+func TestMarkdownStoreWorkoutComments(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	w := models.NewWorkout("run")
+	if err := store.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+	c := models.NewWorkoutComment(w.ID, "Coach Sam", "Hold 5:10/km next time.\n\nGood cadence.")
+	if err := store.AddWorkoutComment(ctx, c); err != nil {
+		t.Fatalf("AddWorkoutComment failed: %v", err)
+	}
+
+	comments, err := store.ListWorkoutComments(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutComments failed: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Body != c.Body || comments[0].Author != "Coach Sam" {
+		t.Fatalf("Expected comment to round-trip, got %+v", comments)
+	}
+
+	plain, err := store.GetWorkout(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkout failed: %v", err)
+	}
+	if plain.Comments != nil {
+		t.Error("Expected GetWorkout to omit comments")
+	}
+}
+
+func TestMarkdownStoreStatusWarnsOnGrowth(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82))
+
+	st, err := Status(ctx, store, time.Now())
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if st.Backend != "markdown" || st.Files != 1 || len(st.Warnings) != 0 {
+		t.Fatalf("Expected one file and no warnings, got %+v", st)
+	}
+
+	// Pretend the store is near the limit and growing fast
+	st.Files, st.Recent = MarkdownFileWarn-100, 100
+	if w := st.warnings(); len(w) != 1 || !strings.Contains(w[0], "within 90 days") {
+		t.Errorf("Expected projected growth warning, got %v", w)
+	}
+	st.Files = MarkdownFileWarn + 1
+	if w := st.warnings(); len(w) != 1 || !strings.Contains(w[0], "migrate --to sqlite") {
+		t.Errorf("Expected file count warning, got %v", w)
+	}
+}
+
+func TestMarkdownStoreIndexedLookup(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	m := models.NewMetric(models.MetricWeight, 82)
+	w := models.NewWorkout("run")
+	store.CreateMetric(ctx, m)
+	store.CreateWorkout(ctx, w)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dataDir, markdownIndexFile)); err != nil {
+		t.Fatalf("Expected index file after Close: %v", err)
+	}
+
+	// An unreadable file elsewhere doesn't matter once lookups use the index
+	bad := filepath.Join(store.metricsDir(), "2020", "01", "2020-01-01-weight-deadbeef.md")
+	os.MkdirAll(filepath.Dir(bad), 0750)
+	os.WriteFile(bad, []byte("not frontmatter"), 0600)
+
+	reopened, err := NewMarkdownStore(store.dataDir)
+	if err != nil {
+		t.Fatalf("NewMarkdownStore failed: %v", err)
+	}
+	got, err := reopened.GetMetric(ctx, m.ID.String()[:8])
+	if err != nil || got.ID != m.ID {
+		t.Fatalf("Indexed GetMetric = %v, %v", got, err)
+	}
+	if _, err := reopened.GetWorkout(ctx, w.ID.String()); err != nil {
+		t.Fatalf("Indexed GetWorkout failed: %v", err)
+	}
+	os.Remove(bad)
+
+	// Files written by another store instance are found after a rebuild
+	other, _ := NewMarkdownStore(store.dataDir)
+	added := models.NewMetric(models.MetricMood, 7)
+	other.CreateMetric(ctx, added)
+	if _, err := reopened.GetMetric(ctx, added.ID.String()); err != nil {
+		t.Errorf("Expected stale index to be rebuilt for new file: %v", err)
+	}
+
+	// And files removed behind its back are reported as not found
+	path, _, _ := reopened.findMetricFile(m.ID.String())
+	os.Remove(path)
+	if _, err := reopened.GetMetric(ctx, m.ID.String()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found for removed file, got %v", err)
+	}
+}
+
+func TestMarkdownStoreQueryMetricsPrunes(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	old := models.NewMetric(models.MetricWeight, 84)
+	old.RecordedAt = time.Date(2025, 1, 10, 8, 0, 0, 0, time.UTC)
+	recent := models.NewMetric(models.MetricWeight, 82)
+	recent.RecordedAt = time.Date(2025, 6, 10, 8, 0, 0, 0, time.UTC)
+	for _, m := range []*models.Metric{old, recent} {
+		if err := store.CreateMetric(ctx, m); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+	}
+
+	// Unreadable files that a pruned query must never open: an older month
+	// for a limited query, another type for a typed one.
+	oldDir := filepath.Join(store.metricsDir(), "2024", "12")
+	os.MkdirAll(oldDir, 0750)
+	os.WriteFile(filepath.Join(oldDir, "2024-12-01-weight-deadbeef.md"), []byte("not frontmatter"), 0600)
+	juneDir := filepath.Join(store.metricsDir(), "2025", "06")
+	os.WriteFile(filepath.Join(juneDir, "2025-06-11-mood-deadbeef.md"), []byte("not frontmatter"), 0600)
+
+	latest, err := store.GetLatestMetric(ctx, models.MetricWeight)
+	if err != nil || latest.ID != recent.ID {
+		t.Fatalf("GetLatestMetric = %v, %v; want the June metric", latest, err)
+	}
+
+	mt := models.MetricWeight
+	since := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+	list, err := store.QueryMetrics(ctx, MetricFilter{Type: &mt, Since: &since})
+	if err != nil || len(list) != 2 || list[0].ID != recent.ID {
+		t.Fatalf("QueryMetrics since January = %d, %v; want both weights newest first", len(list), err)
+	}
+
+	// A full scan still reaches the broken files
+	if _, err := store.QueryMetrics(ctx, MetricFilter{}); err == nil {
+		t.Error("Expected unfiltered query to fail on the unreadable file")
+	}
+
+	if got := metricTypeFromName("2025-06-10-blood_pressure_sys-1a2b3c4d.md"); got != "blood_pressure_sys" {
+		t.Errorf("metricTypeFromName = %q", got)
+	}
+}
+
+func TestMarkdownDailyNotes(t *testing.T) {
+	store := setupTestMarkdownStore(t)
+	ctx := t.Context()
+	vault := filepath.Join(t.TempDir(), "Daily")
+	store.SetDailyNotes(vault, "")
+
+	at := time.Date(2025, 6, 10, 8, 15, 0, 0, time.Local)
+	path := filepath.Join(vault, "2025-06-10.md")
+	os.MkdirAll(vault, 0750)
+	os.WriteFile(path, []byte("# Tuesday\n\nWent for a walk.\n"), 0600)
+
+	store.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82.5).WithRecordedAt(at).WithNotes("after coffee"))
+	sys, dia := models.NewBloodPressure(120, 80, at.Add(time.Minute))
+	if err := RecordBloodPressure(ctx, store, sys, dia); err != nil {
+		t.Fatalf("RecordBloodPressure failed: %v", err)
+	}
+	w := models.NewWorkout("run").WithDuration(30)
+	w.StartedAt = at.Add(time.Hour)
+	store.CreateWorkout(ctx, w)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("daily note not written: %v", err)
+	}
+	want := "# Tuesday\n\nWent for a walk.\n\n## Health\n- 08:15 weight 82.5 kg — after coffee\n- 08:16 bp 120/80 mmHg\n- 09:15 run 30 min\n"
+	if string(data) != want {
+		t.Errorf("daily note =\n%s\nwant\n%s", data, want)
+	}
+
+	// Bulk imports stay out of the notes
+	store.CreateMetrics(ctx, []*models.Metric{
+		models.NewMetric(models.MetricSteps, 9000).WithRecordedAt(at.AddDate(0, 0, 1)),
+		models.NewMetric(models.MetricSteps, 8000).WithRecordedAt(at.AddDate(0, 0, 1)),
+	})
+	if _, err := os.Stat(filepath.Join(vault, "2025-06-11.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected no note for a bulk import, got %v", err)
+	}
+}
+
+func TestInsertUnderHeading(t *testing.T) {
+	tests := []struct{ content, want string }{
+		{"", "## Health\n- x\n"},
+		{"## Health\n- a\n", "## Health\n- a\n- x\n"},
+		{"## Health\n- a\n\n### Detail\n- b\n\n## Journal\ntext\n", "## Health\n- a\n\n### Detail\n- b\n- x\n\n## Journal\ntext\n"},
+		{"## Journal\n#tag\n", "## Journal\n#tag\n\n## Health\n- x\n"},
+	}
+	for _, tt := range tests {
+		if got := insertUnderHeading(tt.content, "## Health", "- x"); got != tt.want {
+			t.Errorf("insertUnderHeading(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestMarkdownStoreReindex(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+	orig := models.NewMetric(models.MetricWeight, 82)
+	store.CreateMetric(ctx, orig)
+
+	dir := filepath.Join(store.dataDir, "metrics", "2025", "01")
+	os.MkdirAll(dir, 0750)
+	handmade := filepath.Join(dir, "2025-01-05-weight.md")
+	os.WriteFile(handmade, []byte("---\nid:\nmetric_type: weight\nvalue: 81.5\nunit: kg\nrecorded_at: 2025-01-05T08:00:00Z\n---\nAfter the holidays\n"), 0600)
+	origPath, _, _ := store.findMetricFile(orig.ID.String())
+	copied := filepath.Join(filepath.Dir(origPath), "zz-copy.md")
+	data, _ := os.ReadFile(origPath)
+	os.WriteFile(copied, data, 0600)
+	os.WriteFile(filepath.Join(dir, "2025-01-06-weight.md"), []byte("---\nvalue: [unclosed\n---\n"), 0600)
+
+	before, _ := store.Fingerprint(ctx)
+	rep, err := store.Reindex(ctx, true)
+	if err != nil {
+		t.Fatalf("Reindex dry run failed: %v", err)
+	}
+	if rep.Checked != 4 || len(rep.Assigned) != 1 || len(rep.Rekeyed) != 1 || len(rep.Broken) != 1 {
+		t.Fatalf("dry run report = %+v; want 4 checked, 1 assigned, 1 rekeyed, 1 broken", rep)
+	}
+	if after, _ := store.Fingerprint(ctx); after != before {
+		t.Error("Expected a dry run to leave files alone")
+	}
+
+	if _, err := store.Reindex(ctx, false); err != nil {
+		t.Fatalf("Reindex failed: %v", err)
+	}
+	if after, _ := store.Fingerprint(ctx); after == before {
+		t.Error("Expected the fingerprint to change once files were fixed")
+	}
+	content, _ := os.ReadFile(handmade)
+	if !strings.HasPrefix(string(content), "---\nid: ") || !strings.Contains(string(content), "created_at: ") ||
+		!strings.HasSuffix(string(content), "---\nAfter the holidays\n") {
+		t.Errorf("handmade file = %q; want an id and created_at added, body kept", content)
+	}
+
+	// Fixing the broken file makes everything listable, copy included
+	os.Remove(filepath.Join(dir, "2025-01-06-weight.md"))
+	metrics, err := store.ListMetrics(ctx, nil, 0)
+	if err != nil || len(metrics) != 3 {
+		t.Fatalf("ListMetrics = %d, %v; want 3, nil", len(metrics), err)
+	}
+	ids := map[uuid.UUID]bool{}
+	for _, m := range metrics {
+		ids[m.ID] = true
+	}
+	if len(ids) != 3 || !ids[orig.ID] {
+		t.Errorf("IDs = %v; want three distinct, keeping the original", ids)
+	}
+	if rep, _ := store.Reindex(ctx, true); len(rep.Assigned)+len(rep.Rekeyed)+len(rep.Broken) != 0 {
+		t.Errorf("second reindex = %+v; want nothing left to do", rep)
+	}
+}
+
+func TestParseMarkdownLayout(t *testing.T) {
+	at := time.Date(2025, 1, 5, 8, 0, 0, 0, time.Local)
+	id := uuid.MustParse("1a2b3c4d-0000-4000-8000-000000000000")
+	for name, want := range map[string]string{
+		"":     "2025/01/2025-01-05-weight-1a2b3c4d.md",
+		"type": "weight/2025/2025-01-05-1a2b3c4d.md",
+		"flat": "2025-01-05.md",
+		"Health/{type}/{yyyy}-{mm}-{dd} {uuid}.md": "Health/weight/2025-01-05 1a2b3c4d-0000-4000-8000-000000000000.md",
+	} {
+		layout, err := ParseMarkdownLayout(name)
+		if err != nil {
+			t.Errorf("ParseMarkdownLayout(%q) failed: %v", name, err)
+			continue
+		}
+		if got := layout.Path(at, "weight", id); got != filepath.FromSlash(want) {
+			t.Errorf("%q path = %q, want %q", name, got, want)
+		}
+	}
+
+	for _, bad := range []string{"by-week", "{date}-{id}.txt", "{week}/{id}.md", "../{id}.md", "/abs/{id}.md", "a//{id}.md"} {
+		if _, err := ParseMarkdownLayout(bad); err == nil {
+			t.Errorf("ParseMarkdownLayout(%q) succeeded; want error", bad)
+		}
+	}
+}
+
+func TestMarkdownStoreLayout(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+	layout, _ := ParseMarkdownLayout("type")
+	store.SetLayout(layout)
+
+	at := time.Date(2025, 1, 5, 8, 0, 0, 0, time.Local)
+	m := models.NewMetric(models.MetricWeight, 82).WithRecordedAt(at)
+	store.CreateMetric(ctx, m)
+	store.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 9000).WithRecordedAt(at))
+	w := models.NewWorkout("Trail Run")
+	w.StartedAt = at
+	store.CreateWorkout(ctx, w)
+
+	want := filepath.Join(store.dataDir, "metrics", "weight", "2025", "2025-01-05-"+m.ID.String()[:8]+".md")
+	if _, err := os.Stat(want); err != nil {
+		t.Fatalf("Expected metric at %s: %v", want, err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dataDir, "workouts", "trail-run", "2025", "2025-01-05-"+w.ID.String()[:8]+".md")); err != nil {
+		t.Errorf("Expected workout under workouts/trail-run/2025: %v", err)
+	}
+	weight := models.MetricWeight
+	if metrics, err := store.QueryMetrics(ctx, MetricFilter{Type: &weight, Limit: 1}); err != nil || len(metrics) != 1 || metrics[0].ID != m.ID {
+		t.Errorf("QueryMetrics = %v, %v; want the weight", metrics, err)
+	}
+
+	// Back to the date layout: --relayout moves everything and tidies up
+	store.SetLayout(nil)
+	moved, err := store.Relayout(ctx, true)
+	if err != nil || len(moved) != 3 {
+		t.Fatalf("Relayout dry run = %v, %v; want 3 files", moved, err)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Error("Expected a dry run to leave files in place")
+	}
+	if _, err := store.Relayout(ctx, false); err != nil {
+		t.Fatalf("Relayout failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dataDir, "metrics", "weight")); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied type folder to be removed, got %v", err)
+	}
+	if got, err := store.GetMetric(ctx, m.ID.String()); err != nil || got.Value != 82 {
+		t.Errorf("GetMetric after relayout = %v, %v", got, err)
+	}
+	if moved, _ := store.Relayout(ctx, true); len(moved) != 0 {
+		t.Errorf("second relayout = %v; want nothing to move", moved)
+	}
+}
+
+func TestMarkdownStoreFlatLayout(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+	layout, _ := ParseMarkdownLayout("flat")
+	store.SetLayout(layout)
+
+	at := time.Date(2025, 1, 5, 8, 0, 0, 0, time.Local)
+	weight := models.NewMetric(models.MetricWeight, 82).WithRecordedAt(at).WithNotes("after run")
+	store.CreateMetric(ctx, weight)
+	sys := models.NewMetric(models.MetricBPSys, 120).WithRecordedAt(at.Add(time.Hour))
+	dia := models.NewMetric(models.MetricBPDia, 80).WithRecordedAt(at.Add(time.Hour))
+	if err := store.CreateMetrics(ctx, []*models.Metric{sys, dia}); err != nil {
+		t.Fatalf("CreateMetrics failed: %v", err)
+	}
+	store.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 9000).WithRecordedAt(at.AddDate(0, 0, 1)))
+	w := models.NewWorkout("run")
+	w.StartedAt = at
+	store.CreateWorkout(ctx, w)
+
+	// One file per day holds every metric; workouts keep their own
+	day := filepath.Join(store.dataDir, "metrics", "2025-01-05.md")
+	metrics, err := readMetricsFile(day)
+	if err != nil || len(metrics) != 3 {
+		t.Fatalf("readMetricsFile(%s) = %d metrics, %v; want 3", day, len(metrics), err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dataDir, "workouts", "2025-01-05-"+w.ID.String()[:8]+".md")); err != nil {
+		t.Errorf("Expected the workout in its own file: %v", err)
+	}
+
+	got, err := store.GetMetric(ctx, weight.ID.String()[:8])
+	if err != nil || got.Value != 82 || got.Notes == nil || *got.Notes != "after run" {
+		t.Fatalf("GetMetric = %+v, %v; want the weight with its notes", got, err)
+	}
+	if err := store.SetMetricMetadata(ctx, sys.ID.String(), "arm", "left"); err != nil {
+		t.Fatalf("SetMetricMetadata failed: %v", err)
+	}
+	if got, _ := store.GetMetric(ctx, sys.ID.String()); got.Metadata["arm"] != "left" {
+		t.Errorf("metadata = %v; want arm=left", got.Metadata)
+	}
+
+	// Moving a metric to another day takes it out of one file and into the other
+	weight.RecordedAt = at.AddDate(0, 0, 1)
+	if err := store.UpdateMetric(ctx, weight); err != nil {
+		t.Fatalf("UpdateMetric failed: %v", err)
+	}
+	if metrics, _ := readMetricsFile(day); len(metrics) != 2 {
+		t.Errorf("day file holds %d metrics after the move; want 2", len(metrics))
+	}
+	if err := store.DeleteMetric(ctx, dia.ID.String()); err != nil {
+		t.Fatalf("DeleteMetric failed: %v", err)
+	}
+	if err := store.DeleteMetric(ctx, sys.ID.String()); err != nil {
+		t.Fatalf("DeleteMetric failed: %v", err)
+	}
+	if _, err := os.Stat(day); !os.IsNotExist(err) {
+		t.Errorf("Expected the emptied day file to be removed, got %v", err)
+	}
+	if n, err := store.CountMetrics(ctx, MetricFilter{}); err != nil || n != 2 {
+		t.Errorf("CountMetrics = %d, %v; want 2", n, err)
+	}
+
+	// Back to the date layout: the shared file splits into one per metric
+	store.SetLayout(nil)
+	if _, err := store.Relayout(ctx, false); err != nil {
+		t.Fatalf("Relayout failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(store.dataDir, "metrics", "2025-01-06.md")); !os.IsNotExist(err) {
+		t.Errorf("Expected the shared file to be split up, got %v", err)
+	}
+	steps := models.MetricSteps
+	if metrics, err := store.QueryMetrics(ctx, MetricFilter{Type: &steps}); err != nil || len(metrics) != 1 {
+		t.Errorf("QueryMetrics(steps) = %v, %v; want one", metrics, err)
+	}
+	if got, err := store.GetMetric(ctx, weight.ID.String()); err != nil || got.Notes == nil || *got.Notes != "after run" {
+		t.Errorf("GetMetric after relayout = %+v, %v", got, err)
+	}
+}
+
+func TestMarkdownStoreConcurrentWorkoutUpdates(t *testing.T) {
+	ctx := t.Context()
+	first := setupTestMarkdownStore(t)
+	// A second store on the same directory stands in for another process;
+	// it has its own mutex, so only the file lock keeps the two apart
+	second, err := NewMarkdownStore(first.dataDir)
+	if err != nil {
+		t.Fatalf("NewMarkdownStore failed: %v", err)
+	}
+	w := models.NewWorkout("lift")
+	first.CreateWorkout(ctx, w)
+
+	const writers, each = 6, 10
+	errs := make(chan error, writers)
+	for i := range writers {
+		store := first
+		if i%2 == 1 {
+			store = second
+		}
+		go func() {
+			for j := range each {
+				var err error
+				if j%2 == 0 {
+					err = store.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "squat", j, 5))
+				} else {
+					err = store.AddWorkoutComment(ctx, models.NewWorkoutComment(w.ID, "coach", "felt strong"))
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for range writers {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent update failed: %v", err)
+		}
+	}
+
+	got, err := second.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+	}
+	if len(got.Sets)+len(got.Comments) != writers*each {
+		t.Errorf("workout has %d sets and %d comments; want %d in all", len(got.Sets), len(got.Comments), writers*each)
+	}
+}
+
+func TestMarkdownStoreConcurrentCreateAndGet(t *testing.T) {
+	ctx := t.Context()
+	store := setupTestMarkdownStore(t)
+
+	// Creates write the ID index while gets read it, both from the first
+	// lookup on, when the index is loaded
+	const workers, each = 8, 10
+	errs := make(chan error, workers)
+	for i := range workers {
+		go func() {
+			for j := range each {
+				m := models.NewMetric(models.MetricWeight, float64(70+i+j))
+				if err := store.CreateMetric(ctx, m); err != nil {
+					errs <- err
+					return
+				}
+				got, err := store.GetMetric(ctx, m.ID.String())
+				if err != nil {
+					errs <- err
+					return
+				}
+				if got.ID != m.ID {
+					errs <- fmt.Errorf("GetMetric(%s) returned %s", m.ID, got.ID)
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for range workers {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent create and get failed: %v", err)
+		}
+	}
+
+	metrics, err := store.ListMetrics(ctx, nil, 0)
+	if err != nil {
+		t.Fatalf("ListMetrics failed: %v", err)
+	}
+	if len(metrics) != workers*each {
+		t.Errorf("ListMetrics returned %d metrics; want %d", len(metrics), workers*each)
+	}
+}
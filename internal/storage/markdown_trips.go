@@ -0,0 +1,234 @@
+// ABOUTME: Trip storage for the markdown backend.
+// ABOUTME: Stores one file per trip in trips/, named by start date and destination.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harper/suite/mdstore"
+	"github.com/harperreed/health/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// tripFrontmatter holds the YAML frontmatter of a trip file.
+type tripFrontmatter struct {
+	ID          string `yaml:"id"`
+	Destination string `yaml:"destination"`
+	Timezone    string `yaml:"timezone,omitempty"`
+	StartedAt   string `yaml:"started_at"`
+	EndedAt     string `yaml:"ended_at,omitempty"`
+	CreatedAt   string `yaml:"created_at"`
+}
+
+// tripsDir returns the path to the trips directory.
+func (s *MarkdownStore) tripsDir() string {
+	return filepath.Join(s.dataDir, "trips")
+}
+
+// tripFilePath returns the path for a trip file.
+// Format: trips/YYYY-MM-DD-<destination>-<id_prefix>.md, dated by start.
+func (s *MarkdownStore) tripFilePath(t *models.Trip) string {
+	return filepath.Join(s.tripsDir(), fmt.Sprintf("%s-%s-%s.md",
+		t.StartedAt.Local().Format("2006-01-02"), mdstore.Slugify(t.Destination), t.ID.String()[:8]))
+}
+
+// readTripFile reads a trip from a markdown file.
+func readTripFile(path string) (*models.Trip, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	yamlStr, body := mdstore.ParseFrontmatter(string(data))
+	if yamlStr == "" {
+		return nil, fmt.Errorf("no frontmatter in %s", path)
+	}
+
+	var fm tripFrontmatter
+	if err := yaml.Unmarshal([]byte(yamlStr), &fm); err != nil {
+		return nil, fmt.Errorf("parse frontmatter in %s: %w", path, err)
+	}
+
+	id, err := uuid.Parse(fm.ID)
+	if err != nil {
+		return nil, fmt.Errorf("parse trip ID %q: %w", fm.ID, err)
+	}
+	startedAt, err := mdstore.ParseTime(fm.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse started_at %q: %w", fm.StartedAt, err)
+	}
+	createdAt, err := mdstore.ParseTime(fm.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("parse created_at %q: %w", fm.CreatedAt, err)
+	}
+
+	t := &models.Trip{
+		ID:          id,
+		Destination: fm.Destination,
+		StartedAt:   startedAt,
+		CreatedAt:   createdAt,
+	}
+	if fm.Timezone != "" {
+		t.Timezone = &fm.Timezone
+	}
+	if fm.EndedAt != "" {
+		endedAt, err := mdstore.ParseTime(fm.EndedAt)
+		if err != nil {
+			return nil, fmt.Errorf("parse ended_at %q: %w", fm.EndedAt, err)
+		}
+		t.EndedAt = &endedAt
+	}
+	if notes := strings.TrimSpace(body); notes != "" {
+		t.Notes = &notes
+	}
+	return t, nil
+}
+
+// writeTripFile writes a trip to a markdown file.
+func (s *MarkdownStore) writeTripFile(t *models.Trip) error {
+	fm := tripFrontmatter{
+		ID:          t.ID.String(),
+		Destination: t.Destination,
+		StartedAt:   mdstore.FormatTime(t.StartedAt.UTC()),
+		CreatedAt:   mdstore.FormatTime(t.CreatedAt.UTC()),
+	}
+	if t.Timezone != nil {
+		fm.Timezone = *t.Timezone
+	}
+	if t.EndedAt != nil {
+		fm.EndedAt = mdstore.FormatTime(t.EndedAt.UTC())
+	}
+
+	body := ""
+	if t.Notes != nil && *t.Notes != "" {
+		body = "\n" + *t.Notes + "\n"
+	}
+
+	content, err := mdstore.RenderFrontmatter(&fm, body)
+	if err != nil {
+		return fmt.Errorf("render trip file: %w", err)
+	}
+
+	return mdstore.AtomicWrite(s.tripFilePath(t), []byte(content))
+}
+
+// tripFiles returns every trip keyed by its file path.
+func (s *MarkdownStore) tripFiles() (map[string]*models.Trip, error) {
+	entries, err := os.ReadDir(s.tripsDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read trips directory: %w", err)
+	}
+
+	trips := make(map[string]*models.Trip)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		path := filepath.Join(s.tripsDir(), e.Name())
+		t, err := readTripFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read trip file %s: %w", path, err)
+		}
+		trips[path] = t
+	}
+	return trips, nil
+}
+
+// findTripFile finds the file path for a trip by ID or prefix.
+func (s *MarkdownStore) findTripFile(idOrPrefix string) (string, *models.Trip, error) {
+	trips, err := s.tripFiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	var foundPath string
+	var found *models.Trip
+	for path, t := range trips {
+		if !strings.HasPrefix(t.ID.String(), idOrPrefix) {
+			continue
+		}
+		if found != nil {
+			return "", nil, fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+		}
+		foundPath, found = path, t
+	}
+	if found == nil {
+		return "", nil, fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	return foundPath, found, nil
+}
+
+// CreateTrip stores a new trip as a markdown file.
+func (s *MarkdownStore) CreateTrip(ctx context.Context, t *models.Trip) error {
+	return s.writeTripFile(t)
+}
+
+// GetTrip retrieves a trip by ID or ID prefix.
+func (s *MarkdownStore) GetTrip(ctx context.Context, idOrPrefix string) (*models.Trip, error) {
+	_, t, err := s.findTripFile(idOrPrefix)
+	return t, err
+}
+
+// ListTrips retrieves trips sorted by StartedAt descending.
+func (s *MarkdownStore) ListTrips(ctx context.Context, limit int) ([]*models.Trip, error) {
+	files, err := s.tripFiles()
+	if err != nil {
+		return nil, fmt.Errorf("list trips: %w", err)
+	}
+
+	trips := make([]*models.Trip, 0, len(files))
+	for _, t := range files {
+		trips = append(trips, t)
+	}
+	sort.Slice(trips, func(i, j int) bool {
+		return trips[i].StartedAt.After(trips[j].StartedAt)
+	})
+
+	return paginate(trips, 0, limit), nil
+}
+
+// EndTrip records when a trip ended.
+func (s *MarkdownStore) EndTrip(ctx context.Context, idOrPrefix string, endedAt time.Time) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, t, err := s.findTripFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("end trip: %w", err)
+	}
+	t.EndedAt = &endedAt
+	return s.writeTripFile(t)
+}
+
+// DeleteTrip removes a trip file by ID or prefix.
+func (s *MarkdownStore) DeleteTrip(ctx context.Context, idOrPrefix string) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	path, _, err := s.findTripFile(idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete trip: %w", err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("delete trip file: %w", err)
+	}
+	return nil
+}
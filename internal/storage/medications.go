@@ -0,0 +1,184 @@
+// ABOUTME: Medication and intake CRUD operations for SQLite storage.
+// ABOUTME: Implements Repository interface methods for the medication log.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateMedication stores a new medication. Names must be unique, ignoring
+// case and punctuation, so they can be used for lookups.
+func (d *DB) CreateMedication(ctx context.Context, m *models.Medication) error {
+	existing, err := d.ListMedications(ctx)
+	if err != nil {
+		return fmt.Errorf("create medication: %w", err)
+	}
+	for _, e := range existing {
+		if e.Slug() == m.Slug() {
+			return fmt.Errorf("medication %q already exists", e.Name)
+		}
+	}
+
+	query := `
+		INSERT INTO medications (id, name, dose, schedule, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err = d.db.ExecContext(ctx, query,
+		m.ID.String(),
+		m.Name,
+		m.Dose,
+		m.Schedule,
+		m.Notes,
+		m.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create medication: %w", err)
+	}
+	return nil
+}
+
+// GetMedication retrieves a medication by name, slug, ID, or ID prefix.
+func (d *DB) GetMedication(ctx context.Context, nameOrID string) (*models.Medication, error) {
+	meds, err := d.ListMedications(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return matchMedication(meds, nameOrID)
+}
+
+// ListMedications retrieves all medications sorted by name.
+func (d *DB) ListMedications(ctx context.Context) ([]*models.Medication, error) {
+	query := `
+		SELECT id, name, dose, schedule, notes, created_at
+		FROM medications
+		ORDER BY name COLLATE NOCASE ASC
+	`
+	rows, err := d.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list medications: %w", err)
+	}
+	defer rows.Close()
+
+	var meds []*models.Medication
+	for rows.Next() {
+		var m models.Medication
+		var idStr, createdAt string
+		var notes sql.NullString
+
+		if err := rows.Scan(&idStr, &m.Name, &m.Dose, &m.Schedule, &notes, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan medication: %w", err)
+		}
+
+		m.ID, _ = uuid.Parse(idStr)
+		m.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if notes.Valid {
+			m.Notes = &notes.String
+		}
+		meds = append(meds, &m)
+	}
+	return meds, rows.Err()
+}
+
+// DeleteMedication removes a medication and all its intakes (cascade delete).
+func (d *DB) DeleteMedication(ctx context.Context, nameOrID string) error {
+	m, err := d.GetMedication(ctx, nameOrID)
+	if err != nil {
+		return fmt.Errorf("delete medication: %w", err)
+	}
+
+	if _, err := d.db.ExecContext(ctx, "DELETE FROM medications WHERE id = ?", m.ID.String()); err != nil {
+		return fmt.Errorf("delete medication: %w", err)
+	}
+	return nil
+}
+
+// LogMedicationIntake stores a new medication intake.
+func (d *DB) LogMedicationIntake(ctx context.Context, in *models.MedicationIntake) error {
+	query := `
+		INSERT INTO medication_intakes (id, medication_id, taken_at, dose, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	_, err := d.db.ExecContext(ctx, query,
+		in.ID.String(),
+		in.MedicationID.String(),
+		in.TakenAt.UTC().Format(time.RFC3339),
+		in.Dose,
+		in.Notes,
+		in.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("log medication intake: %w", err)
+	}
+	return nil
+}
+
+// ListMedicationIntakes retrieves intakes matching the filter.
+// Results are sorted by TakenAt descending (most recent first).
+func (d *DB) ListMedicationIntakes(ctx context.Context, filter IntakeFilter) ([]*models.MedicationIntake, error) {
+	query := `
+		SELECT id, medication_id, taken_at, dose, notes, created_at
+		FROM medication_intakes
+	`
+	var conds []string
+	var args []interface{}
+
+	if filter.MedicationID != nil {
+		conds = append(conds, "medication_id = ?")
+		args = append(args, filter.MedicationID.String())
+	}
+	if filter.Since != nil {
+		conds = append(conds, "datetime(taken_at) >= datetime(?)")
+		args = append(args, filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		conds = append(conds, "datetime(taken_at) < datetime(?)")
+		args = append(args, filter.Until.UTC().Format(time.RFC3339))
+	}
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query += " ORDER BY taken_at DESC"
+
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list medication intakes: %w", err)
+	}
+	defer rows.Close()
+
+	var intakes []*models.MedicationIntake
+	for rows.Next() {
+		var in models.MedicationIntake
+		var idStr, medID, takenAt, createdAt string
+		var dose, notes sql.NullString
+
+		if err := rows.Scan(&idStr, &medID, &takenAt, &dose, &notes, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan medication intake: %w", err)
+		}
+
+		in.ID, _ = uuid.Parse(idStr)
+		in.MedicationID, _ = uuid.Parse(medID)
+		in.TakenAt, _ = time.Parse(time.RFC3339, takenAt)
+		in.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if dose.Valid {
+			in.Dose = &dose.String
+		}
+		if notes.Valid {
+			in.Notes = &notes.String
+		}
+		intakes = append(intakes, &in)
+	}
+	return intakes, rows.Err()
+}
@@ -0,0 +1,19 @@
+// ABOUTME: MemoryStore keeps health data in memory only, for tests and throwaway sessions.
+// ABOUTME: It is the JSONL store's indexes and record handling without the file.
+
+package storage
+
+// MemoryStore is a Repository that persists nothing: everything is gone
+// once the process exits. It shares JSONLStore's in-memory indexes, so it
+// behaves like the JSONL backend in every query.
+type MemoryStore struct {
+	*JSONLStore
+}
+
+// Compile-time check that MemoryStore implements Repository.
+var _ Repository = (*MemoryStore)(nil)
+
+// NewMemoryStore returns an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{JSONLStore: newJSONLStore(nil)}
+}
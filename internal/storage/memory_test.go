@@ -0,0 +1,77 @@
+// ABOUTME: Tests for MemoryStore, the in-memory Repository.
+// ABOUTME: Covers the round trip, the backend registry, and that nothing reaches disk.
+
+package storage
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := t.Context()
+	s := NewMemoryStore()
+
+	m := models.NewMetric(models.MetricWeight, 82.5)
+	if err := s.CreateMetric(ctx, m); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	w := models.NewWorkout("run").WithDuration(30)
+	if err := s.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+	if err := s.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km")); err != nil {
+		t.Fatalf("AddWorkoutMetric failed: %v", err)
+	}
+
+	got, err := s.GetMetric(ctx, m.ID.String()[:8])
+	if err != nil || got.Value != 82.5 {
+		t.Errorf("GetMetric = %+v, %v", got, err)
+	}
+	full, err := s.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil || len(full.Metrics) != 1 {
+		t.Errorf("GetWorkoutWithMetrics = %+v, %v", full, err)
+	}
+	if err := s.DeleteMetric(ctx, m.ID.String()); err != nil {
+		t.Fatalf("DeleteMetric failed: %v", err)
+	}
+	if n, _ := s.CountMetrics(ctx, MetricFilter{}); n != 0 {
+		t.Errorf("expected no metrics after delete, got %d", n)
+	}
+
+	st, err := Status(ctx, s, time.Now())
+	if err != nil || st.Backend != "memory" || st.SizeBytes != 0 || st.Workouts != 1 {
+		t.Errorf("Status = %+v, %v", st, err)
+	}
+	if rep, err := Maintain(ctx, s, MaintenanceOptions{}); err != nil || rep.Backend != "memory" {
+		t.Errorf("Maintain = %+v, %v", rep, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+}
+
+func TestMemoryBackendWritesNothing(t *testing.T) {
+	ctx := t.Context()
+	dir := t.TempDir()
+	r, err := OpenBackend("memory", dir)
+	if err != nil {
+		t.Fatalf("OpenBackend(memory) failed: %v", err)
+	}
+	if err := r.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7)); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	r.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected an untouched data dir, got %v, %v", entries, err)
+	}
+	again, _ := OpenBackend("memory", dir)
+	if n, _ := again.CountMetrics(ctx, MetricFilter{}); n != 0 {
+		t.Errorf("expected a fresh memory store to be empty, got %d metrics", n)
+	}
+}
@@ -3,9 +3,12 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"strings"
 	"time"
 
@@ -13,70 +16,107 @@ import (
 	"github.com/harperreed/health/internal/models"
 )
 
-// CreateMetric stores a new metric in the database.
-func (d *DB) CreateMetric(m *models.Metric) error {
-	query := `
-		INSERT INTO metrics (id, metric_type, value, unit, recorded_at, notes, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`
-	_, err := d.db.Exec(query,
+// insertMetricSQL inserts one metric row; metricArgs supplies its values.
+const insertMetricSQL = `
+	INSERT INTO metrics (id, metric_type, value, unit, recorded_at, notes, location, reading_id, source, metadata, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+func metricArgs(m *models.Metric) []any {
+	var readingID *string
+	if m.ReadingID != nil {
+		id := m.ReadingID.String()
+		readingID = &id
+	}
+	return []any{
 		m.ID.String(),
 		string(m.MetricType),
 		m.Value,
 		m.Unit,
-		m.RecordedAt.Format(time.RFC3339),
+		m.RecordedAt.UTC().Format(time.RFC3339),
 		m.Notes,
-		m.CreatedAt.Format(time.RFC3339),
-	)
-	if err != nil {
+		m.Location,
+		readingID,
+		m.Source,
+		encodeMetadata(m.Metadata),
+		m.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// CreateMetric stores a new metric in the database.
+func (d *DB) CreateMetric(ctx context.Context, m *models.Metric) error {
+	if _, err := d.db.ExecContext(ctx, insertMetricSQL, metricArgs(m)...); err != nil {
 		return fmt.Errorf("create metric: %w", err)
 	}
 	return nil
 }
 
+// CreateMetrics stores many metrics in one transaction with a single
+// prepared statement. Either all are stored or none are.
+func (d *DB) CreateMetrics(ctx context.Context, metrics []*models.Metric) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, insertMetricSQL)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, m := range metrics {
+		if _, err := stmt.ExecContext(ctx, metricArgs(m)...); err != nil {
+			return fmt.Errorf("create metric %s: %w", m.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
 // GetMetric retrieves a metric by ID or ID prefix.
-func (d *DB) GetMetric(idOrPrefix string) (*models.Metric, error) {
-	id, err := d.resolveMetricID(idOrPrefix)
+func (d *DB) GetMetric(ctx context.Context, idOrPrefix string) (*models.Metric, error) {
+	id, err := d.resolveMetricID(ctx, idOrPrefix)
 	if err != nil {
 		return nil, err
 	}
 
 	query := `
-		SELECT id, metric_type, value, unit, recorded_at, notes, created_at
+		SELECT id, metric_type, value, unit, recorded_at, notes, location, reading_id, source, metadata, created_at
 		FROM metrics
 		WHERE id = ?
 	`
-	return d.scanMetric(d.db.QueryRow(query, id))
+	return d.scanMetric(d.db.QueryRowContext(ctx, query, id))
 }
 
 // ListMetrics retrieves metrics with optional filtering by type.
 // Results are sorted by RecordedAt descending (most recent first).
-func (d *DB) ListMetrics(metricType *models.MetricType, limit int) ([]*models.Metric, error) {
-	var query string
-	var args []interface{}
-
-	if metricType != nil {
-		query = `
-			SELECT id, metric_type, value, unit, recorded_at, notes, created_at
-			FROM metrics
-			WHERE metric_type = ?
-			ORDER BY recorded_at DESC
-		`
-		args = append(args, string(*metricType))
-	} else {
-		query = `
-			SELECT id, metric_type, value, unit, recorded_at, notes, created_at
-			FROM metrics
-			ORDER BY recorded_at DESC
-		`
-	}
+func (d *DB) ListMetrics(ctx context.Context, metricType *models.MetricType, limit int) ([]*models.Metric, error) {
+	return d.QueryMetrics(ctx, MetricFilter{Type: metricType, Limit: limit})
+}
 
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
+// QueryMetrics retrieves metrics matching the filter, with paging done in SQL.
+// Results are sorted by RecordedAt descending (most recent first).
+func (d *DB) QueryMetrics(ctx context.Context, filter MetricFilter) ([]*models.Metric, error) {
+	where, args := metricWhere(filter)
+	query := `
+		SELECT id, metric_type, value, unit, recorded_at, notes, location, reading_id, source, metadata, created_at
+		FROM metrics
+	` + where + " ORDER BY recorded_at DESC"
+
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := filter.Limit
+		if limit <= 0 {
+			limit = -1 // SQLite: no upper bound
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filter.Offset)
 	}
 
-	rows, err := d.db.Query(query, args...)
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list metrics: %w", err)
 	}
@@ -85,14 +125,80 @@ func (d *DB) ListMetrics(metricType *models.MetricType, limit int) ([]*models.Me
 	return d.scanMetrics(rows)
 }
 
+// SumMetrics adds up the values of all metrics matching the filter.
+// Limit and Offset are ignored.
+func (d *DB) SumMetrics(ctx context.Context, filter MetricFilter) (*MetricTotal, error) {
+	where, args := metricWhere(filter)
+	var total MetricTotal
+	query := "SELECT COUNT(*), COALESCE(SUM(value), 0) FROM metrics" + where
+	if err := d.db.QueryRowContext(ctx, query, args...).Scan(&total.Count, &total.Sum); err != nil {
+		return nil, fmt.Errorf("sum metrics: %w", err)
+	}
+	return &total, nil
+}
+
+// AvgMetrics averages the values of all metrics matching the filter and
+// finds their range. Limit and Offset are ignored.
+func (d *DB) AvgMetrics(ctx context.Context, filter MetricFilter) (*MetricAverage, error) {
+	where, args := metricWhere(filter)
+	var avg MetricAverage
+	query := "SELECT COUNT(*), COALESCE(AVG(value), 0), COALESCE(MIN(value), 0), COALESCE(MAX(value), 0) FROM metrics" + where
+	if err := d.db.QueryRowContext(ctx, query, args...).Scan(&avg.Count, &avg.Avg, &avg.Min, &avg.Max); err != nil {
+		return nil, fmt.Errorf("average metrics: %w", err)
+	}
+	return &avg, nil
+}
+
+// CountMetrics counts the metrics matching filter, ignoring its Limit and
+// Offset.
+func (d *DB) CountMetrics(ctx context.Context, filter MetricFilter) (int, error) {
+	where, args := metricWhere(filter)
+	var n int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM metrics"+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count metrics: %w", err)
+	}
+	return n, nil
+}
+
+// metricWhere builds the WHERE clause for a filter's type, location,
+// source, and time range. datetime() normalizes stored offsets so ranges compare in UTC.
+func metricWhere(filter MetricFilter) (string, []any) {
+	var conds []string
+	var args []any
+	if filter.Type != nil {
+		conds = append(conds, "metric_type = ?")
+		args = append(args, string(*filter.Type))
+	}
+	if filter.Location != nil {
+		conds = append(conds, "location = ? COLLATE NOCASE")
+		args = append(args, *filter.Location)
+	}
+	if filter.Source != nil {
+		conds = append(conds, "source = ?")
+		args = append(args, *filter.Source)
+	}
+	if filter.Since != nil {
+		conds = append(conds, "datetime(recorded_at) >= datetime(?)")
+		args = append(args, filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		conds = append(conds, "datetime(recorded_at) < datetime(?)")
+		args = append(args, filter.Until.UTC().Format(time.RFC3339))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
 // DeleteMetric removes a metric by ID or prefix.
-func (d *DB) DeleteMetric(idOrPrefix string) error {
-	id, err := d.resolveMetricID(idOrPrefix)
+func (d *DB) DeleteMetric(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveMetricID(ctx, idOrPrefix)
 	if err != nil {
 		return fmt.Errorf("delete metric: %w", err)
 	}
 
-	result, err := d.db.Exec("DELETE FROM metrics WHERE id = ?", id)
+	result, err := d.db.ExecContext(ctx, "DELETE FROM metrics WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("delete metric: %w", err)
 	}
@@ -108,16 +214,54 @@ func (d *DB) DeleteMetric(idOrPrefix string) error {
 	return nil
 }
 
+// SetMetricMetadata sets one metadata key on a metric, or removes it when
+// value is empty.
+func (d *DB) SetMetricMetadata(ctx context.Context, idOrPrefix, key, value string) error {
+	id, err := d.resolveMetricID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set metric metadata: %w", err)
+	}
+
+	var metadata sql.NullString
+	if err := d.db.QueryRowContext(ctx, "SELECT metadata FROM metrics WHERE id = ?", id).Scan(&metadata); err != nil {
+		return fmt.Errorf("set metric metadata: %w", err)
+	}
+	md := withMetadataKey(decodeMetadata(metadata), key, value)
+	if _, err := d.db.ExecContext(ctx, "UPDATE metrics SET metadata = ? WHERE id = ?", encodeMetadata(md), id); err != nil {
+		return fmt.Errorf("set metric metadata: %w", err)
+	}
+	return nil
+}
+
+// UpdateMetric replaces the stored metric that has m's ID with m.
+func (d *DB) UpdateMetric(ctx context.Context, m *models.Metric) error {
+	query := `
+		UPDATE metrics
+		SET metric_type = ?, value = ?, unit = ?, recorded_at = ?, notes = ?, location = ?,
+			reading_id = ?, source = ?, metadata = ?, created_at = ?
+		WHERE id = ?
+	`
+	args := append(metricArgs(m)[1:], m.ID.String())
+	res, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update metric: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("update metric: not found: %s", m.ID)
+	}
+	return nil
+}
+
 // GetLatestMetric returns the most recent metric of a specific type.
-func (d *DB) GetLatestMetric(metricType models.MetricType) (*models.Metric, error) {
+func (d *DB) GetLatestMetric(ctx context.Context, metricType models.MetricType) (*models.Metric, error) {
 	query := `
-		SELECT id, metric_type, value, unit, recorded_at, notes, created_at
+		SELECT id, metric_type, value, unit, recorded_at, notes, location, reading_id, source, metadata, created_at
 		FROM metrics
 		WHERE metric_type = ?
 		ORDER BY recorded_at DESC
 		LIMIT 1
 	`
-	m, err := d.scanMetric(d.db.QueryRow(query, string(metricType)))
+	m, err := d.scanMetric(d.db.QueryRowContext(ctx, query, string(metricType)))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("no metrics of type %s found", metricType)
@@ -128,7 +272,7 @@ func (d *DB) GetLatestMetric(metricType models.MetricType) (*models.Metric, erro
 }
 
 // resolveMetricID finds the full ID from a prefix.
-func (d *DB) resolveMetricID(idOrPrefix string) (string, error) {
+func (d *DB) resolveMetricID(ctx context.Context, idOrPrefix string) (string, error) {
 	// If it looks like a full UUID, use it directly
 	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
 		return idOrPrefix, nil
@@ -136,7 +280,7 @@ func (d *DB) resolveMetricID(idOrPrefix string) (string, error) {
 
 	// Search by prefix
 	query := `SELECT id FROM metrics WHERE id LIKE ? || '%'`
-	rows, err := d.db.Query(query, idOrPrefix)
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
 	if err != nil {
 		return "", fmt.Errorf("resolve metric ID: %w", err)
 	}
@@ -155,7 +299,7 @@ func (d *DB) resolveMetricID(idOrPrefix string) (string, error) {
 		return "", fmt.Errorf("not found: %s", idOrPrefix)
 	}
 	if len(matches) > 1 {
-		return "", fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
 	}
 
 	return matches[0], nil
@@ -165,9 +309,9 @@ func (d *DB) resolveMetricID(idOrPrefix string) (string, error) {
 func (d *DB) scanMetric(row *sql.Row) (*models.Metric, error) {
 	var m models.Metric
 	var idStr, metricType, recordedAt, createdAt string
-	var notes sql.NullString
+	var notes, location, readingID, metadata sql.NullString
 
-	err := row.Scan(&idStr, &metricType, &m.Value, &m.Unit, &recordedAt, &notes, &createdAt)
+	err := row.Scan(&idStr, &metricType, &m.Value, &m.Unit, &recordedAt, &notes, &location, &readingID, &m.Source, &metadata, &createdAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("not found")
@@ -182,6 +326,15 @@ func (d *DB) scanMetric(row *sql.Row) (*models.Metric, error) {
 	if notes.Valid {
 		m.Notes = &notes.String
 	}
+	if location.Valid {
+		m.Location = &location.String
+	}
+	if readingID.Valid {
+		if id, err := uuid.Parse(readingID.String); err == nil {
+			m.ReadingID = &id
+		}
+	}
+	m.Metadata = decodeMetadata(metadata)
 
 	return &m, nil
 }
@@ -193,9 +346,9 @@ func (d *DB) scanMetrics(rows *sql.Rows) ([]*models.Metric, error) {
 	for rows.Next() {
 		var m models.Metric
 		var idStr, metricType, recordedAt, createdAt string
-		var notes sql.NullString
+		var notes, location, readingID, metadata sql.NullString
 
-		err := rows.Scan(&idStr, &metricType, &m.Value, &m.Unit, &recordedAt, &notes, &createdAt)
+		err := rows.Scan(&idStr, &metricType, &m.Value, &m.Unit, &recordedAt, &notes, &location, &readingID, &m.Source, &metadata, &createdAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan metric: %w", err)
 		}
@@ -207,9 +360,67 @@ func (d *DB) scanMetrics(rows *sql.Rows) ([]*models.Metric, error) {
 		if notes.Valid {
 			m.Notes = &notes.String
 		}
+		if location.Valid {
+			m.Location = &location.String
+		}
+		if readingID.Valid {
+			if id, err := uuid.Parse(readingID.String); err == nil {
+				m.ReadingID = &id
+			}
+		}
+		m.Metadata = decodeMetadata(metadata)
 
 		metrics = append(metrics, &m)
 	}
 
 	return metrics, rows.Err()
 }
+
+// encodeMetadata stores a metadata map as a JSON object, or NULL when
+// there is none.
+func encodeMetadata(md map[string]string) any {
+	if len(md) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(md) // a map of strings always marshals
+	return string(data)
+}
+
+// decodeMetadata reads a metadata column back, ignoring a value that
+// isn't a JSON object of strings.
+func decodeMetadata(s sql.NullString) map[string]string {
+	var md map[string]string
+	if s.Valid {
+		_ = json.Unmarshal([]byte(s.String), &md)
+	}
+	if len(md) == 0 {
+		return nil
+	}
+	return md
+}
+
+// withMetadataKey returns a copy of md with key set to value, or removed
+// when value is empty.
+func withMetadataKey(md map[string]string, key, value string) map[string]string {
+	md = maps.Clone(md)
+	if value == "" {
+		delete(md, key)
+	} else {
+		if md == nil {
+			md = map[string]string{}
+		}
+		md[key] = value
+	}
+	if len(md) == 0 {
+		return nil
+	}
+	return md
+}
+
+// DailyTotal sums the metrics of one type recorded on the calendar day
+// containing day, in day's time zone.
+func DailyTotal(ctx context.Context, r Repository, metricType models.MetricType, day time.Time) (*MetricTotal, error) {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location())
+	end := start.AddDate(0, 0, 1)
+	return r.SumMetrics(ctx, MetricFilter{Type: &metricType, Since: &start, Until: &end})
+}
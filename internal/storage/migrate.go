@@ -1,72 +1,204 @@
 // ABOUTME: Data migration between health storage backends.
-// ABOUTME: Copies metrics, workouts, and workout metrics from source to destination.
+// ABOUTME: Copies metrics, workouts, sleep, medications, locations, trips, appointments, and events from source to destination.
 
 package storage
 
 import (
+	"context"
 	"fmt"
 	"os"
+
+	"github.com/harperreed/health/internal/models"
 )
 
 // MigrateSummary holds counts of migrated entities.
 type MigrateSummary struct {
-	Metrics        int
-	Workouts       int
-	WorkoutMetrics int
+	Metrics         int
+	Workouts        int
+	WorkoutMetrics  int
+	WorkoutSets     int
+	WorkoutComments int
+	SleepSessions   int
+	Medications     int
+	Intakes         int
+	Locations       int
+	Trips           int
+	Fasts           int
+	Appointments    int
+	Events          int
 }
 
 // MigrateData copies all data from src to dst storage.
 // It iterates through metrics and workouts in order,
 // creating each entity in the destination. The destination should be empty
 // before calling this function.
-func MigrateData(src, dst Repository) (*MigrateSummary, error) {
+func MigrateData(ctx context.Context, src, dst Repository) (*MigrateSummary, error) {
 	summary := &MigrateSummary{}
 
 	// Migrate all metrics
-	metrics, err := src.ListMetrics(nil, 0)
+	metrics, err := src.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		return nil, fmt.Errorf("list source metrics: %w", err)
 	}
 
-	for _, m := range metrics {
-		if err := dst.CreateMetric(m); err != nil {
-			return nil, fmt.Errorf("create metric %s: %w", m.ID, err)
-		}
-		summary.Metrics++
+	if err := dst.CreateMetrics(ctx, metrics); err != nil {
+		return nil, fmt.Errorf("create metrics: %w", err)
 	}
+	summary.Metrics = len(metrics)
 
 	// Migrate all workouts with their metrics
-	workouts, err := src.ListWorkouts(nil, 0)
+	workouts, err := src.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		return nil, fmt.Errorf("list source workouts: %w", err)
 	}
 
-	for _, w := range workouts {
-		// Get the full workout with metrics
-		fullWorkout, err := src.GetWorkoutWithMetrics(w.ID.String())
+	fullWorkouts := make([]*models.Workout, len(workouts))
+	for i, w := range workouts {
+		fullWorkouts[i], err = src.GetWorkoutWithMetrics(ctx, w.ID.String())
 		if err != nil {
 			return nil, fmt.Errorf("get workout %s with metrics: %w", w.ID, err)
 		}
+	}
 
-		// Save metrics and clear them from the workout before creating.
-		// CreateWorkout should only create the workout itself; we add
-		// metrics separately via AddWorkoutMetric to avoid duplicates.
-		workoutMetrics := fullWorkout.Metrics
-		fullWorkout.Metrics = nil
-
-		if err := dst.CreateWorkout(fullWorkout); err != nil {
-			return nil, fmt.Errorf("create workout %s: %w", w.ID, err)
-		}
-		summary.Workouts++
+	// Create the workouts without their children, which are added
+	// separately via AddWorkoutMetric/AddWorkoutSet/AddWorkoutComment so
+	// file-based backends don't write them twice.
+	bare := make([]*models.Workout, len(fullWorkouts))
+	for i, w := range fullWorkouts {
+		copied := *w
+		copied.Metrics, copied.Sets, copied.Comments = nil, nil, nil
+		bare[i] = &copied
+	}
+	if err := dst.CreateWorkouts(ctx, bare); err != nil {
+		return nil, fmt.Errorf("create workouts: %w", err)
+	}
+	summary.Workouts = len(bare)
 
+	for _, fullWorkout := range fullWorkouts {
 		// Migrate workout metrics
-		for _, wm := range workoutMetrics {
+		for _, wm := range fullWorkout.Metrics {
 			wm.WorkoutID = fullWorkout.ID
-			if err := dst.AddWorkoutMetric(&wm); err != nil {
+			if err := dst.AddWorkoutMetric(ctx, &wm); err != nil {
 				return nil, fmt.Errorf("add workout metric %s: %w", wm.ID, err)
 			}
 			summary.WorkoutMetrics++
 		}
+
+		// Migrate workout sets
+		for _, ws := range fullWorkout.Sets {
+			ws.WorkoutID = fullWorkout.ID
+			if err := dst.AddWorkoutSet(ctx, &ws); err != nil {
+				return nil, fmt.Errorf("add workout set %s: %w", ws.ID, err)
+			}
+			summary.WorkoutSets++
+		}
+
+		// Migrate workout comments
+		for _, c := range fullWorkout.Comments {
+			c.WorkoutID = fullWorkout.ID
+			if err := dst.AddWorkoutComment(ctx, &c); err != nil {
+				return nil, fmt.Errorf("add workout comment %s: %w", c.ID, err)
+			}
+			summary.WorkoutComments++
+		}
+	}
+
+	// Migrate sleep sessions; their derived metrics were copied with the metrics above
+	sessions, err := src.ListSleepSessions(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list source sleep sessions: %w", err)
+	}
+
+	for _, ss := range sessions {
+		if err := dst.CreateSleepSession(ctx, ss); err != nil {
+			return nil, fmt.Errorf("create sleep session %s: %w", ss.ID, err)
+		}
+		summary.SleepSessions++
+	}
+
+	// Migrate medications before their intakes
+	meds, err := src.ListMedications(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list source medications: %w", err)
+	}
+
+	for _, m := range meds {
+		if err := dst.CreateMedication(ctx, m); err != nil {
+			return nil, fmt.Errorf("create medication %s: %w", m.ID, err)
+		}
+		summary.Medications++
+	}
+
+	intakes, err := src.ListMedicationIntakes(ctx, IntakeFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list source medication intakes: %w", err)
+	}
+
+	for _, in := range intakes {
+		if err := dst.LogMedicationIntake(ctx, in); err != nil {
+			return nil, fmt.Errorf("log medication intake %s: %w", in.ID, err)
+		}
+		summary.Intakes++
+	}
+
+	locations, err := src.ListLocations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list source locations: %w", err)
+	}
+
+	for _, l := range locations {
+		if err := dst.CreateLocation(ctx, l); err != nil {
+			return nil, fmt.Errorf("create location %s: %w", l.ID, err)
+		}
+		summary.Locations++
+	}
+
+	trips, err := src.ListTrips(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list source trips: %w", err)
+	}
+
+	for _, t := range trips {
+		if err := dst.CreateTrip(ctx, t); err != nil {
+			return nil, fmt.Errorf("create trip %s: %w", t.ID, err)
+		}
+		summary.Trips++
+	}
+
+	fasts, err := src.ListFasts(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list source fasts: %w", err)
+	}
+
+	for _, f := range fasts {
+		if err := dst.CreateFast(ctx, f); err != nil {
+			return nil, fmt.Errorf("create fast %s: %w", f.ID, err)
+		}
+		summary.Fasts++
+	}
+
+	appointments, err := src.ListAppointments(ctx, AppointmentFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list source appointments: %w", err)
+	}
+
+	for _, a := range appointments {
+		if err := dst.CreateAppointment(ctx, a); err != nil {
+			return nil, fmt.Errorf("create appointment %s: %w", a.ID, err)
+		}
+		summary.Appointments++
+	}
+
+	events, err := src.ListEvents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list source events: %w", err)
+	}
+
+	for _, e := range events {
+		if err := dst.CreateEvent(ctx, e); err != nil {
+			return nil, fmt.Errorf("create event %s: %w", e.ID, err)
+		}
+		summary.Events++
 	}
 
 	return summary, nil
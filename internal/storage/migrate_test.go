@@ -12,6 +12,7 @@ import (
 )
 
 func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
+	ctx := t.Context()
 	// Set up source (SQLite)
 	srcDir, err := os.MkdirTemp("", "health-migrate-src-*")
 	if err != nil {
@@ -29,16 +30,26 @@ func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m1.WithNotes("morning weight")
 	m2 := models.NewMetric(models.MetricMood, 7)
-	srcDB.CreateMetric(m1)
-	srcDB.CreateMetric(m2)
+	srcDB.CreateMetric(ctx, m1)
+	srcDB.CreateMetric(ctx, m2)
 
 	w := models.NewWorkout("run")
 	w.WithDuration(45)
 	w.WithNotes("morning run")
-	srcDB.CreateWorkout(w)
+	srcDB.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
-	srcDB.AddWorkoutMetric(wm)
+	srcDB.AddWorkoutMetric(ctx, wm)
+
+	bed := time.Date(2024, 12, 13, 23, 0, 0, 0, time.UTC)
+	ss := models.NewSleepSession(bed, bed.Add(8*time.Hour)).WithQuality(7)
+	srcDB.CreateSleepSession(ctx, ss)
+
+	srcDB.CreateLocation(ctx, models.NewLocation("gym"))
+	srcDB.CreateTrip(ctx, models.NewTrip("gym"))
+	srcDB.CreateFast(ctx, models.NewFast(16))
+	srcDB.CreateAppointment(ctx, models.NewAppointment("Dr. Lee", bed.AddDate(0, 1, 0)))
+	srcDB.CreateEvent(ctx, models.NewEvent("Chicago Marathon", bed.AddDate(0, 6, 0)))
 
 	// Set up destination (Markdown)
 	dstDir, err := os.MkdirTemp("", "health-migrate-dst-*")
@@ -53,7 +64,7 @@ func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
 	}
 
 	// Run migration
-	summary, err := MigrateData(srcDB, dstStore)
+	summary, err := MigrateData(ctx, srcDB, dstStore)
 	if err != nil {
 		t.Fatalf("MigrateData failed: %v", err)
 	}
@@ -68,9 +79,27 @@ func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
 	if summary.WorkoutMetrics != 1 {
 		t.Errorf("Expected 1 migrated workout metric, got %d", summary.WorkoutMetrics)
 	}
+	if summary.SleepSessions != 1 {
+		t.Errorf("Expected 1 migrated sleep session, got %d", summary.SleepSessions)
+	}
+	if summary.Locations != 1 {
+		t.Errorf("Expected 1 migrated location, got %d", summary.Locations)
+	}
+	if summary.Trips != 1 {
+		t.Errorf("Expected 1 migrated trip, got %d", summary.Trips)
+	}
+	if summary.Fasts != 1 {
+		t.Errorf("Expected 1 migrated fast, got %d", summary.Fasts)
+	}
+	if summary.Appointments != 1 {
+		t.Errorf("Expected 1 migrated appointment, got %d", summary.Appointments)
+	}
+	if summary.Events != 1 {
+		t.Errorf("Expected 1 migrated event, got %d", summary.Events)
+	}
 
 	// Verify data in destination
-	metrics, err := dstStore.ListMetrics(nil, 0)
+	metrics, err := dstStore.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics from dst failed: %v", err)
 	}
@@ -78,7 +107,7 @@ func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
 		t.Errorf("Expected 2 metrics in dst, got %d", len(metrics))
 	}
 
-	workouts, err := dstStore.ListWorkouts(nil, 0)
+	workouts, err := dstStore.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts from dst failed: %v", err)
 	}
@@ -87,7 +116,7 @@ func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
 	}
 
 	// Verify workout has metrics
-	dstWorkout, err := dstStore.GetWorkoutWithMetrics(w.ID.String())
+	dstWorkout, err := dstStore.GetWorkoutWithMetrics(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutWithMetrics from dst failed: %v", err)
 	}
@@ -97,6 +126,7 @@ func TestMigrateDataSQLiteToMarkdown(t *testing.T) {
 }
 
 func TestMigrateDataMarkdownToSQLite(t *testing.T) {
+	ctx := t.Context()
 	// Set up source (Markdown)
 	srcDir, err := os.MkdirTemp("", "health-migrate-src-*")
 	if err != nil {
@@ -113,16 +143,16 @@ func TestMigrateDataMarkdownToSQLite(t *testing.T) {
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m1.WithNotes("morning weight")
 	m2 := models.NewMetric(models.MetricMood, 7)
-	srcStore.CreateMetric(m1)
-	srcStore.CreateMetric(m2)
+	srcStore.CreateMetric(ctx, m1)
+	srcStore.CreateMetric(ctx, m2)
 
 	w := models.NewWorkout("run")
 	w.WithDuration(45)
 	w.WithNotes("morning run")
-	srcStore.CreateWorkout(w)
+	srcStore.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
-	srcStore.AddWorkoutMetric(wm)
+	srcStore.AddWorkoutMetric(ctx, wm)
 
 	// Set up destination (SQLite)
 	dstDir, err := os.MkdirTemp("", "health-migrate-dst-*")
@@ -138,7 +168,7 @@ func TestMigrateDataMarkdownToSQLite(t *testing.T) {
 	defer dstDB.Close()
 
 	// Run migration
-	summary, err := MigrateData(srcStore, dstDB)
+	summary, err := MigrateData(ctx, srcStore, dstDB)
 	if err != nil {
 		t.Fatalf("MigrateData failed: %v", err)
 	}
@@ -155,7 +185,7 @@ func TestMigrateDataMarkdownToSQLite(t *testing.T) {
 	}
 
 	// Verify data in destination
-	metrics, err := dstDB.ListMetrics(nil, 0)
+	metrics, err := dstDB.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics from dst failed: %v", err)
 	}
@@ -191,7 +221,7 @@ func TestMigrateDataEmptySource(t *testing.T) {
 	}
 
 	// Run migration with no data
-	summary, err := MigrateData(srcDB, dstStore)
+	summary, err := MigrateData(t.Context(), srcDB, dstStore)
 	if err != nil {
 		t.Fatalf("MigrateData with empty source failed: %v", err)
 	}
@@ -208,6 +238,7 @@ func TestMigrateDataEmptySource(t *testing.T) {
 }
 
 func TestMigrateDataRoundTrip(t *testing.T) {
+	ctx := t.Context()
 	// Create data in SQLite, migrate to Markdown, migrate back to SQLite
 	// and verify all data is preserved.
 
@@ -227,15 +258,15 @@ func TestMigrateDataRoundTrip(t *testing.T) {
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m1.WithNotes("test note")
 	m1.RecordedAt = time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
-	srcDB.CreateMetric(m1)
+	srcDB.CreateMetric(ctx, m1)
 
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
 	w.WithNotes("test run")
-	srcDB.CreateWorkout(w)
+	srcDB.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	srcDB.AddWorkoutMetric(wm)
+	srcDB.AddWorkoutMetric(ctx, wm)
 
 	// Step 2: Migrate SQLite -> Markdown
 	mdDir, err := os.MkdirTemp("", "health-migrate-rt-md-*")
@@ -249,7 +280,7 @@ func TestMigrateDataRoundTrip(t *testing.T) {
 		t.Fatalf("Failed to create MarkdownStore: %v", err)
 	}
 
-	_, err = MigrateData(srcDB, mdStore)
+	_, err = MigrateData(ctx, srcDB, mdStore)
 	if err != nil {
 		t.Fatalf("MigrateData (sqlite->md) failed: %v", err)
 	}
@@ -267,13 +298,13 @@ func TestMigrateDataRoundTrip(t *testing.T) {
 	}
 	defer dstDB.Close()
 
-	_, err = MigrateData(mdStore, dstDB)
+	_, err = MigrateData(ctx, mdStore, dstDB)
 	if err != nil {
 		t.Fatalf("MigrateData (md->sqlite) failed: %v", err)
 	}
 
 	// Step 4: Verify data round-tripped correctly
-	gotMetric, err := dstDB.GetMetric(m1.ID.String())
+	gotMetric, err := dstDB.GetMetric(ctx, m1.ID.String())
 	if err != nil {
 		t.Fatalf("GetMetric after round-trip failed: %v", err)
 	}
@@ -284,7 +315,7 @@ func TestMigrateDataRoundTrip(t *testing.T) {
 		t.Error("Metric notes lost in round-trip")
 	}
 
-	gotWorkout, err := dstDB.GetWorkoutWithMetrics(w.ID.String())
+	gotWorkout, err := dstDB.GetWorkoutWithMetrics(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutWithMetrics after round-trip failed: %v", err)
 	}
@@ -342,6 +373,7 @@ func TestIsDirNonEmpty(t *testing.T) {
 }
 
 func TestMigrateDataPreservesMetricDetails(t *testing.T) {
+	ctx := t.Context()
 	// Verify that specific field values are preserved during migration
 	srcDir, err := os.MkdirTemp("", "health-migrate-detail-*")
 	if err != nil {
@@ -378,7 +410,7 @@ func TestMigrateDataPreservesMetricDetails(t *testing.T) {
 	}
 
 	for _, m := range metrics {
-		srcDB.CreateMetric(m)
+		srcDB.CreateMetric(ctx, m)
 	}
 
 	dstDir, err := os.MkdirTemp("", "health-migrate-detail-dst-*")
@@ -392,7 +424,7 @@ func TestMigrateDataPreservesMetricDetails(t *testing.T) {
 		t.Fatalf("Failed to create MarkdownStore: %v", err)
 	}
 
-	summary, err := MigrateData(srcDB, dstStore)
+	summary, err := MigrateData(ctx, srcDB, dstStore)
 	if err != nil {
 		t.Fatalf("MigrateData failed: %v", err)
 	}
@@ -403,7 +435,7 @@ func TestMigrateDataPreservesMetricDetails(t *testing.T) {
 
 	// Verify each metric was preserved
 	for _, original := range metrics {
-		got, err := dstStore.GetMetric(original.ID.String())
+		got, err := dstStore.GetMetric(ctx, original.ID.String())
 		if err != nil {
 			t.Fatalf("GetMetric(%s) from dst failed: %v", original.ID, err)
 		}
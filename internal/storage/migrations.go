@@ -0,0 +1,271 @@
+// ABOUTME: Versioned SQLite schema migrations embedded from migrations/NNNN_name.sql.
+// ABOUTME: Applied in order on Open, each in a transaction, after backing up a database with data.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migration is one step of the schema. Versions start at 1 and have no
+// gaps; a migration, once released, never changes.
+type migration struct {
+	version int
+	name    string
+	sql     string
+	// after runs in the same transaction, for changes SQL can't make
+	// conditionally
+	after func(ctx context.Context, tx execer) error
+}
+
+// migrationHooks are the Go steps that go with SQL migrations, by version.
+var migrationHooks = map[int]func(ctx context.Context, tx execer) error{
+	1: upgradeLegacy,
+}
+
+// schemaMigrations is every migration in version order.
+var schemaMigrations = loadMigrations()
+
+// execer is what migrations run statements through: a transaction.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// loadMigrations reads the embedded migration files. A misnamed or
+// misnumbered file is a build mistake, so it panics.
+func loadMigrations() []migration {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		panic(err)
+	}
+	var ms []migration
+	for _, e := range entries {
+		base := strings.TrimSuffix(e.Name(), ".sql")
+		num, name, ok := strings.Cut(base, "_")
+		version, err := strconv.Atoi(num)
+		if !ok || err != nil || len(num) != 4 {
+			panic(fmt.Sprintf("migration %s: want NNNN_name.sql", e.Name()))
+		}
+		data, err := migrationFiles.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		ms = append(ms, migration{version: version, name: name, sql: string(data), after: migrationHooks[version]})
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].version < ms[j].version })
+	for i, m := range ms {
+		if m.version != i+1 {
+			panic(fmt.Sprintf("migration %04d_%s: want version %d", m.version, m.name, i+1))
+		}
+	}
+	return ms
+}
+
+// SchemaUpgrade describes the migrations Open applied.
+type SchemaUpgrade struct {
+	From, To int
+	Backup   string // copy of the database from before, if it had data
+}
+
+// AppliedMigration is a row of schema_version.
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	AppliedAt time.Time
+}
+
+// SchemaStatus reports the database's schema version against this build's.
+type SchemaStatus struct {
+	Version int // applied to the database
+	Latest  int // known to this build
+	Applied []AppliedMigration
+	Pending []string // NNNN_name of migrations not yet applied
+	Backups []string // pre-upgrade copies next to the database
+}
+
+const createSchemaVersion = `CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	applied_at DATETIME NOT NULL
+)`
+
+// migrate applies the migrations in ms that the database hasn't had. A
+// database with data is copied to <name>.pre-vN-<time>.bak first. A
+// database newer than ms is refused rather than written with an older
+// idea of its schema.
+func (d *DB) migrate(ctx context.Context, ms []migration) error {
+	if _, err := d.db.ExecContext(ctx, createSchemaVersion); err != nil {
+		return fmt.Errorf("create schema_version: %w", err)
+	}
+	current, err := d.schemaVersion(ctx, d.db)
+	if err != nil {
+		return err
+	}
+	latest := len(ms)
+	if current > latest {
+		return fmt.Errorf("database schema is version %d but this build only knows up to %d; upgrade health", current, latest)
+	}
+	if current == latest {
+		return nil
+	}
+
+	up := &SchemaUpgrade{From: current, To: latest}
+	hasData, err := d.hasTables(ctx)
+	if err != nil {
+		return err
+	}
+	if hasData {
+		if up.Backup, err = d.backup(ctx, latest); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range ms[current:] {
+		if err := d.apply(ctx, m); err != nil {
+			return err
+		}
+	}
+	d.upgrade = up
+	if up.Backup != "" {
+		slog.Info("upgraded database schema", "from", up.From, "to", up.To, "backup", up.Backup)
+	}
+	return nil
+}
+
+// apply runs one migration in a transaction, unless another process got
+// there first.
+func (d *DB) apply(ctx context.Context, m migration) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	current, err := d.schemaVersion(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if current >= m.version {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, m.sql); err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+	}
+	if m.after != nil {
+		if err := m.after(ctx, tx); err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.version, m.name, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("migration %04d_%s: record version: %w", m.version, m.name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("migration %04d_%s: commit: %w", m.version, m.name, err)
+	}
+	return nil
+}
+
+// schemaVersion is the highest migration applied, or 0.
+func (d *DB) schemaVersion(ctx context.Context, q execer) (int, error) {
+	rows, err := q.QueryContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_version`)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	defer rows.Close()
+	var v int
+	if rows.Next() {
+		if err := rows.Scan(&v); err != nil {
+			return 0, fmt.Errorf("read schema version: %w", err)
+		}
+	}
+	return v, rows.Err()
+}
+
+// hasTables reports whether the database holds anything besides
+// schema_version, so a brand-new file isn't backed up.
+func (d *DB) hasTables(ctx context.Context) (bool, error) {
+	var n int
+	err := d.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name != 'schema_version'`).Scan(&n)
+	if err != nil {
+		return false, fmt.Errorf("inspect database: %w", err)
+	}
+	return n > 0, nil
+}
+
+// backup copies the database, WAL included, before upgrading it to
+// version to.
+func (d *DB) backup(ctx context.Context, to int) (string, error) {
+	stem := fmt.Sprintf("%s.pre-v%d-%s", d.dbPath, to, time.Now().UTC().Format("20060102T150405Z"))
+	path := stem + ".bak"
+	// A retry after a failed upgrade can land in the same second
+	for n := 2; ; n++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			break
+		}
+		path = fmt.Sprintf("%s-%d.bak", stem, n)
+	}
+	if _, err := d.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return "", fmt.Errorf("back up database before upgrade: %w", err)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		return "", fmt.Errorf("set backup permissions: %w", err)
+	}
+	return path, nil
+}
+
+// Upgraded returns the schema upgrade Open applied, or nil if the
+// database was already current.
+func (d *DB) Upgraded() *SchemaUpgrade {
+	return d.upgrade
+}
+
+// SchemaStatus reports which migrations the database has had and which
+// this build would apply.
+func (d *DB) SchemaStatus(ctx context.Context) (*SchemaStatus, error) {
+	st := &SchemaStatus{Latest: len(schemaMigrations)}
+	rows, err := d.db.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_version ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m AppliedMigration
+		var at string
+		if err := rows.Scan(&m.Version, &m.Name, &at); err != nil {
+			return nil, fmt.Errorf("read schema version: %w", err)
+		}
+		if m.AppliedAt, err = time.Parse(time.RFC3339, at); err != nil {
+			return nil, fmt.Errorf("parse schema version time: %w", err)
+		}
+		st.Applied = append(st.Applied, m)
+		st.Version = max(st.Version, m.Version)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read schema version: %w", err)
+	}
+
+	for _, m := range schemaMigrations[min(st.Version, len(schemaMigrations)):] {
+		st.Pending = append(st.Pending, fmt.Sprintf("%04d_%s", m.version, m.name))
+	}
+	backups, err := filepath.Glob(d.dbPath + ".pre-v*.bak")
+	if err != nil {
+		return nil, err
+	}
+	st.Backups = backups
+	return st, nil
+}
@@ -0,0 +1,166 @@
+-- Baseline: the schema as it stood when versioning began. Everything is
+-- IF NOT EXISTS so databases from before then pick up only the tables they
+-- lack; upgradeLegacy in schema.go adds the columns they lack.
+
+CREATE TABLE IF NOT EXISTS metrics (
+	id TEXT PRIMARY KEY,
+	metric_type TEXT NOT NULL,
+	value REAL NOT NULL,
+	unit TEXT NOT NULL,
+	recorded_at DATETIME NOT NULL,
+	notes TEXT,
+	location TEXT,
+	reading_id TEXT,
+	source TEXT NOT NULL DEFAULT '',
+	metadata TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workouts (
+	id TEXT PRIMARY KEY,
+	workout_type TEXT NOT NULL,
+	started_at DATETIME NOT NULL,
+	duration_minutes INTEGER,
+	notes TEXT,
+	location TEXT,
+	source TEXT NOT NULL DEFAULT '',
+	metadata TEXT,
+	rpe INTEGER,
+	zone INTEGER,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS workout_metrics (
+	id TEXT PRIMARY KEY,
+	workout_id TEXT NOT NULL,
+	metric_name TEXT NOT NULL,
+	value REAL NOT NULL,
+	unit TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workout_sets (
+	id TEXT PRIMARY KEY,
+	workout_id TEXT NOT NULL,
+	exercise TEXT NOT NULL,
+	set_number INTEGER NOT NULL,
+	reps INTEGER NOT NULL,
+	weight REAL,
+	weight_unit TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS workout_comments (
+	id TEXT PRIMARY KEY,
+	workout_id TEXT NOT NULL,
+	author TEXT NOT NULL,
+	body TEXT NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sleep_sessions (
+	id TEXT PRIMARY KEY,
+	bed_time DATETIME NOT NULL,
+	wake_time DATETIME NOT NULL,
+	awakenings INTEGER,
+	quality INTEGER,
+	metric_id TEXT,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS medications (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	dose TEXT NOT NULL,
+	schedule TEXT NOT NULL,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS medication_intakes (
+	id TEXT PRIMARY KEY,
+	medication_id TEXT NOT NULL,
+	taken_at DATETIME NOT NULL,
+	dose TEXT,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (medication_id) REFERENCES medications(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS locations (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	latitude REAL,
+	longitude REAL,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS trips (
+	id TEXT PRIMARY KEY,
+	destination TEXT NOT NULL,
+	timezone TEXT,
+	started_at DATETIME NOT NULL,
+	ended_at DATETIME,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS fasts (
+	id TEXT PRIMARY KEY,
+	started_at DATETIME NOT NULL,
+	ended_at DATETIME,
+	target_hours REAL NOT NULL,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS appointments (
+	id TEXT PRIMARY KEY,
+	provider TEXT NOT NULL,
+	scheduled_at DATETIME NOT NULL,
+	duration_minutes INTEGER,
+	location TEXT,
+	reason TEXT,
+	summary TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS events (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	date DATETIME NOT NULL,
+	workout_type TEXT,
+	weekly_minutes INTEGER,
+	weekly_km REAL,
+	notes TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reminder_state (
+	key TEXT PRIMARY KEY,
+	last_fired DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reminder_snoozes (
+	key TEXT PRIMARY KEY,
+	snoozed_until DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_type ON metrics(metric_type);
+CREATE INDEX IF NOT EXISTS idx_metrics_recorded ON metrics(recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_metrics_type_recorded ON metrics(metric_type, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workouts_started ON workouts(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workout_metrics_workout ON workout_metrics(workout_id);
+CREATE INDEX IF NOT EXISTS idx_workout_sets_workout ON workout_sets(workout_id);
+CREATE INDEX IF NOT EXISTS idx_workout_comments_workout ON workout_comments(workout_id);
+CREATE INDEX IF NOT EXISTS idx_sleep_sessions_wake ON sleep_sessions(wake_time DESC);
+CREATE INDEX IF NOT EXISTS idx_medication_intakes_med ON medication_intakes(medication_id, taken_at DESC);
+CREATE INDEX IF NOT EXISTS idx_trips_started ON trips(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_fasts_started ON fasts(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_appointments_scheduled ON appointments(scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_events_date ON events(date);
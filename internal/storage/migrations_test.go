@@ -0,0 +1,116 @@
+// ABOUTME: Tests for SQLite schema migrations: versioning, upgrades from before versioning, and backups.
+// ABOUTME: Builds old-style databases by hand and runs extra migrations against them.
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenRecordsSchemaVersion(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	if db.Upgraded() == nil || db.Upgraded().Backup != "" {
+		t.Errorf("Upgraded() = %+v; want a new database migrated without a backup", db.Upgraded())
+	}
+
+	st, err := db.SchemaStatus(ctx)
+	if err != nil {
+		t.Fatalf("SchemaStatus failed: %v", err)
+	}
+	if st.Version != len(schemaMigrations) || st.Latest != st.Version || len(st.Pending) != 0 ||
+		len(st.Applied) != st.Version || st.Applied[0].Name != "baseline" || st.Applied[0].AppliedAt.IsZero() {
+		t.Errorf("status = %+v; want every migration applied", st)
+	}
+}
+
+func TestOpenUpgradesLegacyDatabase(t *testing.T) {
+	ctx := t.Context()
+	dbPath := filepath.Join(t.TempDir(), "health.db")
+
+	// A database from the first release: no schema_version, no later
+	// columns, and times stored with a local offset
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	_, err = raw.Exec(`
+		CREATE TABLE metrics (id TEXT PRIMARY KEY, metric_type TEXT NOT NULL, value REAL NOT NULL,
+			unit TEXT NOT NULL, recorded_at DATETIME NOT NULL, notes TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		INSERT INTO metrics (id, metric_type, value, unit, recorded_at)
+			VALUES ('6f1c2a9e-3b1d-4c4e-9a2f-0d8e7b6a5c41', 'weight', 82.5, 'kg', '2025-03-02T00:00:00-06:00');`)
+	raw.Close()
+	if err != nil {
+		t.Fatalf("create legacy database failed: %v", err)
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	up := db.Upgraded()
+	if up == nil || up.From != 0 || up.To != len(schemaMigrations) || !strings.HasPrefix(up.Backup, dbPath+".pre-v") {
+		t.Fatalf("Upgraded() = %+v; want an upgrade from 0 with a backup", up)
+	}
+	m, err := db.GetMetric(ctx, "6f1c2a9e")
+	if err != nil || m.Value != 82.5 || m.RecordedAt.UTC().Hour() != 6 {
+		t.Errorf("legacy metric = %+v, %v; want it readable, in UTC", m, err)
+	}
+	db.Close()
+
+	// The backup is the database as it was
+	backup, err := sql.Open("sqlite", up.Backup)
+	if err != nil {
+		t.Fatalf("open backup failed: %v", err)
+	}
+	defer backup.Close()
+	var recordedAt string
+	if err := backup.QueryRow(`SELECT recorded_at FROM metrics`).Scan(&recordedAt); err != nil || recordedAt != "2025-03-02T00:00:00-06:00" {
+		t.Errorf("backup recorded_at = %q, %v; want the original", recordedAt, err)
+	}
+
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+	if db.Upgraded() != nil {
+		t.Errorf("Upgraded() on reopen = %+v; want nil", db.Upgraded())
+	}
+	if st, _ := db.SchemaStatus(ctx); len(st.Backups) != 1 {
+		t.Errorf("backups = %v; want the one from the upgrade", st.Backups)
+	}
+}
+
+func TestMigrateAppliesNewVersions(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+
+	bad := append(schemaMigrations[:len(schemaMigrations):len(schemaMigrations)],
+		migration{version: len(schemaMigrations) + 1, name: "broken", sql: "ALTER TABLE metrics ADD COLUMN tags TEXT; SELECT nope FROM nowhere;"})
+	if err := db.migrate(ctx, bad); err == nil {
+		t.Fatal("Expected a failing migration to return an error")
+	}
+	if v, _ := db.schemaVersion(ctx, db.db); v != len(schemaMigrations) {
+		t.Errorf("version after failed migration = %d; want it unchanged", v)
+	}
+
+	next := append(schemaMigrations[:len(schemaMigrations):len(schemaMigrations)],
+		migration{version: len(schemaMigrations) + 1, name: "tags", sql: "ALTER TABLE metrics ADD COLUMN tags TEXT;"})
+	if err := db.migrate(ctx, next); err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+	if _, err := db.db.ExecContext(ctx, "UPDATE metrics SET tags = 'x'"); err != nil {
+		t.Errorf("Expected the tags column: %v", err)
+	}
+	if up := db.Upgraded(); up == nil || up.To != len(next) {
+		t.Errorf("Upgraded() = %+v; want an upgrade to %d", up, len(next))
+	}
+
+	// This build doesn't know version 2+1, so it won't open the database
+	if _, err := Open(db.dbPath); err == nil || !strings.Contains(err.Error(), "upgrade health") {
+		t.Errorf("Open of a newer database = %v; want a refusal", err)
+	}
+}
@@ -0,0 +1,228 @@
+// ABOUTME: Reference Postgres backend for a home server: the JSONL store's records kept in a table.
+// ABOUTME: Uses database/sql with whichever Postgres driver the binary links; registers "backend": "postgres".
+
+// Package postgres keeps health data in a Postgres database, so several
+// machines can share one store. It runs the JSONL store's engine on a
+// table instead of a file: every change is a row in health_log, replayed
+// into memory on open, and writers in different processes take turns on
+// an advisory lock, catching up on each other's rows first.
+//
+// The package talks to Postgres through database/sql and imports no
+// driver. A build that uses it links one with a blank import, such as
+// github.com/jackc/pgx/v5/stdlib (driver "pgx") or github.com/lib/pq
+// (driver "postgres"). The integration tests need the same, plus
+// HEALTH_POSTGRES_DSN pointing at a scratch database; they skip otherwise.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/harperreed/health/internal/storage"
+)
+
+// DSNEnv names the environment variable holding the connection string,
+// such as postgres://health@nas.local/health. It isn't a config.json
+// setting because it usually carries a password.
+const DSNEnv = "HEALTH_POSTGRES_DSN"
+
+// drivers are the database/sql driver names tried, in order.
+var drivers = []string{"pgx", "postgres"}
+
+// lockKey identifies the advisory lock that writers take turns on.
+const lockKey int64 = 0x6865616c7468 // "health"
+
+// schema creates the log and the generation counter that a compaction
+// bumps, telling other processes to replay the log from the start.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS health_log (
+		seq    BIGSERIAL PRIMARY KEY,
+		record JSONB NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS health_log_state (
+		singleton  BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (singleton),
+		generation BIGINT NOT NULL
+	)`,
+	`INSERT INTO health_log_state (singleton, generation) VALUES (TRUE, 0) ON CONFLICT DO NOTHING`,
+}
+
+// insertRecord appends one record. The text cast keeps drivers from
+// encoding the JSON as a JSON string.
+const insertRecord = `INSERT INTO health_log (record) VALUES (CAST($1 AS TEXT)::JSONB) RETURNING seq`
+
+func init() {
+	// The database is named by DSNEnv; nothing under dataDir is used
+	storage.RegisterBackend("postgres", func(dataDir string) (storage.Repository, error) {
+		return Open(os.Getenv(DSNEnv))
+	})
+}
+
+// Store is a Repository kept in Postgres. It shares the JSONL store's
+// in-memory indexes, so it behaves like the JSONL backend in every query.
+type Store struct {
+	*storage.JSONLStore
+}
+
+// Compile-time check that Store implements Repository.
+var _ storage.Repository = (*Store)(nil)
+
+// Open connects to the database at dsn, creating the tables on first use,
+// and loads every record.
+func Open(dsn string) (*Store, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("postgres backend: set %s to the database's connection string", DSNEnv)
+	}
+	driver, err := driverName()
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open postgres: %w", err)
+	}
+
+	log := &tableLog{db: db}
+	if err := log.createTables(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s, err := storage.OpenRecordLog(log)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{JSONLStore: s}, nil
+}
+
+// driverName returns the first linked database/sql driver for Postgres.
+func driverName() (string, error) {
+	linked := sql.Drivers()
+	for _, name := range drivers {
+		if slices.Contains(linked, name) {
+			return name, nil
+		}
+	}
+	return "", errors.New("postgres backend: this build has no Postgres driver; link one with a blank import of github.com/jackc/pgx/v5/stdlib or github.com/lib/pq")
+}
+
+// tableLog is the storage.RecordLog in health_log. It remembers the last
+// row and the generation it read, so Read returns only newer rows, or all
+// of them once another process's compaction has bumped the generation.
+type tableLog struct {
+	db         *sql.DB
+	seq        int64
+	generation int64
+}
+
+func (l *tableLog) String() string {
+	return "postgres health_log"
+}
+
+// createTables sets up the schema, under the lock so two processes
+// starting at once don't both create it.
+func (l *tableLog) createTables() error {
+	unlock, err := l.Lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	for _, stmt := range schema {
+		if _, err := l.db.Exec(stmt); err != nil {
+			return fmt.Errorf("create postgres tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// Lock takes a transaction-scoped advisory lock, held on its own
+// connection until the returned func rolls the transaction back. A
+// process that dies holding it loses its connection, and with it the lock.
+func (l *tableLog) Lock() (func(), error) {
+	tx, err := l.db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("lock postgres store: %w", err)
+	}
+	if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", lockKey); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("lock postgres store: %w", err)
+	}
+	return func() { _ = tx.Rollback() }, nil
+}
+
+// Read returns the rows added since the last Read, or every row if the
+// log was compacted in between.
+func (l *tableLog) Read() ([][]byte, bool, error) {
+	var generation int64
+	if err := l.db.QueryRow(`SELECT generation FROM health_log_state`).Scan(&generation); err != nil {
+		return nil, false, fmt.Errorf("read postgres store: %w", err)
+	}
+	reset := generation != l.generation
+	if reset {
+		l.seq, l.generation = 0, generation
+	}
+
+	rows, err := l.db.Query(`SELECT seq, record::TEXT FROM health_log WHERE seq > $1 ORDER BY seq`, l.seq)
+	if err != nil {
+		return nil, false, fmt.Errorf("read postgres store: %w", err)
+	}
+	defer rows.Close()
+	var records [][]byte
+	for rows.Next() {
+		var record string
+		if err := rows.Scan(&l.seq, &record); err != nil {
+			return nil, false, fmt.Errorf("read postgres store: %w", err)
+		}
+		records = append(records, []byte(record))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("read postgres store: %w", err)
+	}
+	return records, reset, nil
+}
+
+// Append inserts record as the newest row. The caller holds the lock, so
+// no other process has added rows since the last Read.
+func (l *tableLog) Append(record []byte) error {
+	return l.db.QueryRow(insertRecord, string(record)).Scan(&l.seq)
+}
+
+// Rewrite replaces every row with records and bumps the generation, in
+// one transaction.
+func (l *tableLog) Rewrite(records [][]byte) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM health_log`); err != nil {
+		return err
+	}
+	insert, err := tx.Prepare(insertRecord)
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	var seq, generation int64
+	for _, record := range records {
+		if err := insert.QueryRow(string(record)).Scan(&seq); err != nil {
+			return err
+		}
+	}
+	if err := tx.QueryRow(`UPDATE health_log_state SET generation = generation + 1 RETURNING generation`).Scan(&generation); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	l.seq, l.generation = seq, generation
+	return nil
+}
+
+// Close closes the connection pool.
+func (l *tableLog) Close() error {
+	return l.db.Close()
+}
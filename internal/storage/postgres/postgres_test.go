@@ -0,0 +1,125 @@
+// ABOUTME: Tests for the Postgres backend: registration everywhere, and a shared store against a real database.
+// ABOUTME: The database tests skip unless HEALTH_POSTGRES_DSN is set and a driver is linked into the test binary.
+
+package postgres
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/storage"
+)
+
+// openTestStore opens the scratch database named by DSNEnv after dropping
+// any health tables in it, skipping the test when there's no database.
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("set %s to a scratch database to run the Postgres tests", DSNEnv)
+	}
+	driver, err := driverName()
+	if err != nil {
+		t.Skip(err)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		t.Fatalf("sql.Open failed: %v", err)
+	}
+	if _, err := db.Exec(`DROP TABLE IF EXISTS health_log, health_log_state`); err != nil {
+		t.Fatalf("dropping old tables failed: %v", err)
+	}
+	db.Close()
+
+	store, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, dsn
+}
+
+func TestRegistered(t *testing.T) {
+	if !storage.IsBackend("postgres") {
+		t.Fatal("Expected postgres to be a registered backend")
+	}
+	t.Setenv(DSNEnv, "")
+	if _, err := storage.OpenBackend("postgres", t.TempDir()); err == nil || !strings.Contains(err.Error(), DSNEnv) {
+		t.Errorf("OpenBackend without a DSN = %v; want an error naming %s", err, DSNEnv)
+	}
+}
+
+func TestStoreRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	store, dsn := openTestStore(t)
+
+	m := models.NewMetric(models.MetricWeight, 82.5).WithNotes("after run")
+	if err := store.CreateMetric(ctx, m); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	w := models.NewWorkout("lift")
+	store.CreateWorkout(ctx, w)
+	store.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "squat", 1, 5))
+	store.Close()
+
+	reopened, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	got, err := reopened.GetMetric(ctx, m.ID.String())
+	if err != nil || got.Value != 82.5 || got.Notes == nil || *got.Notes != "after run" {
+		t.Errorf("GetMetric after reopen = %+v, %v", got, err)
+	}
+	if sets, _ := reopened.ListWorkoutSets(ctx, w.ID); len(sets) != 1 {
+		t.Errorf("ListWorkoutSets = %d sets, want 1", len(sets))
+	}
+}
+
+func TestStoreSharedBetweenProcesses(t *testing.T) {
+	ctx := t.Context()
+	first, dsn := openTestStore(t)
+	// A second store stands in for the same data used from another machine
+	second, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer second.Close()
+
+	fromSecond := models.NewMetric(models.MetricWeight, 80)
+	second.CreateMetric(ctx, fromSecond)
+	// Reopening with a bloated log compacts it under the others
+	for range 60 {
+		m := models.NewMetric(models.MetricSteps, 1)
+		second.CreateMetric(ctx, m)
+		second.DeleteMetric(ctx, m.ID.String())
+	}
+	compacted, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	compacted.Close()
+
+	fromFirst := models.NewMetric(models.MetricWeight, 79)
+	if err := first.CreateMetric(ctx, fromFirst); err != nil {
+		t.Fatalf("CreateMetric after compaction failed: %v", err)
+	}
+	if _, err := first.GetMetric(ctx, fromSecond.ID.String()); err != nil {
+		t.Errorf("Expected the first store to catch up on the second's metric: %v", err)
+	}
+	if err := second.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7)); err != nil {
+		t.Fatalf("CreateMetric on the second store failed: %v", err)
+	}
+
+	reopened, err := Open(dsn)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	if list, _ := reopened.ListMetrics(ctx, nil, 0); len(list) != 3 {
+		t.Errorf("Reopened store has %d metrics, want 3", len(list))
+	}
+}
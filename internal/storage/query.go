@@ -0,0 +1,78 @@
+// ABOUTME: Read-only ad hoc SQL against the SQLite database (health query).
+// ABOUTME: Statements run on a connection with query_only set, so writes fail.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QueryResult holds the columns and rows of an ad hoc query. Values are
+// int64, float64, string, or nil, as SQLite returned them.
+type QueryResult struct {
+	Columns []string
+	Rows    [][]any
+}
+
+// Query runs a single SELECT (or WITH ... SELECT) statement and returns
+// every row. The connection is switched to query_only for the duration,
+// so SQLite itself refuses anything that would write.
+func (d *DB) Query(ctx context.Context, query string) (*QueryResult, error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	var first string
+	if fields := strings.Fields(query); len(fields) > 0 {
+		first = strings.ToUpper(fields[0])
+	}
+	switch first {
+	case "SELECT", "WITH", "EXPLAIN":
+	default:
+		return nil, fmt.Errorf("only SELECT statements are allowed")
+	}
+
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("open connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.ExecContext(ctx, "PRAGMA query_only = ON"); err != nil {
+		return nil, fmt.Errorf("set query_only: %w", err)
+	}
+	// The connection goes back to the pool, so leave it writable again
+	defer func() { _, _ = conn.ExecContext(context.WithoutCancel(ctx), "PRAGMA query_only = OFF") }()
+
+	start := time.Now()
+	rows, err := conn.QueryContext(ctx, query)
+	logQuery(ctx, query, start, err)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("read columns: %w", err)
+	}
+	res := &QueryResult{Columns: cols}
+	for rows.Next() {
+		vals := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		for i, v := range vals {
+			if b, ok := v.([]byte); ok {
+				vals[i] = string(b)
+			}
+		}
+		res.Rows = append(res.Rows, vals)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,120 @@
+// ABOUTME: Helpers for readings that span several metrics, such as blood pressure.
+// ABOUTME: Finds and deletes the linked halves of a reading in any Repository.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// RecordBloodPressure stores both halves of a blood pressure reading made
+// with models.NewBloodPressure in one batch. If a backend stores only one
+// half before failing, it is removed so a reading is never stored
+// half-written.
+func RecordBloodPressure(ctx context.Context, r Repository, sys, dia *models.Metric) error {
+	if err := r.CreateMetrics(ctx, []*models.Metric{sys, dia}); err != nil {
+		_ = r.DeleteMetric(ctx, sys.ID.String())
+		_ = r.DeleteMetric(ctx, dia.ID.String())
+		return fmt.Errorf("create blood pressure: %w", err)
+	}
+	return nil
+}
+
+// ReadingPartner returns the other half of m's blood pressure reading, or
+// nil when m is not blood pressure or has no partner.
+func ReadingPartner(ctx context.Context, r Repository, m *models.Metric) (*models.Metric, error) {
+	if !m.IsBloodPressure() {
+		return nil, nil
+	}
+
+	since := m.RecordedAt.Truncate(time.Second)
+	until := since.Add(time.Second)
+	candidates, err := r.QueryMetrics(ctx, MetricFilter{Since: &since, Until: &until})
+	if err != nil {
+		return nil, fmt.Errorf("find reading partner: %w", err)
+	}
+	for _, c := range candidates {
+		if c.ID != m.ID && models.SameReading(m, c) {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+// DeleteReading deletes a metric along with the other half of its blood
+// pressure reading, so the two can't drift apart. It returns the deleted
+// metrics.
+func DeleteReading(ctx context.Context, r Repository, idOrPrefix string) ([]*models.Metric, error) {
+	m, err := r.GetMetric(ctx, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+	partner, err := ReadingPartner(ctx, r, m)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.DeleteMetric(ctx, m.ID.String()); err != nil {
+		return nil, err
+	}
+	deleted := []*models.Metric{m}
+	if partner != nil {
+		if err := r.DeleteMetric(ctx, partner.ID.String()); err != nil {
+			return deleted, fmt.Errorf("delete %s: %w", partner.MetricType, err)
+		}
+		deleted = append(deleted, partner)
+	}
+	return deleted, nil
+}
+
+// ReadingsMatching returns the metrics filter selects along with the
+// other half of any blood pressure reading among them, so deleting the
+// result never leaves half a reading behind.
+func ReadingsMatching(ctx context.Context, r Repository, filter MetricFilter) ([]*models.Metric, error) {
+	matched, err := r.QueryMetrics(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(matched))
+	for _, m := range matched {
+		seen[m.ID.String()] = true
+	}
+	readings := matched
+	for _, m := range matched {
+		partner, err := ReadingPartner(ctx, r, m)
+		if err != nil {
+			return nil, err
+		}
+		if partner != nil && !seen[partner.ID.String()] {
+			seen[partner.ID.String()] = true
+			readings = append(readings, partner)
+		}
+	}
+	return readings, nil
+}
+
+// CountReadings counts the entries filter selects the way listings show
+// them, with both halves of a blood pressure reading counted once. Limit
+// and Offset are ignored.
+func CountReadings(ctx context.Context, r Repository, filter MetricFilter) (int, error) {
+	filter.Limit, filter.Offset = 0, 0
+	total, err := r.CountMetrics(ctx, filter)
+	if err != nil || filter.Type != nil {
+		// One type never holds both halves
+		return total, err
+	}
+	var bp []*models.Metric
+	for _, mt := range []models.MetricType{models.MetricBPSys, models.MetricBPDia} {
+		halves := filter
+		halves.Type = &mt
+		ms, err := r.QueryMetrics(ctx, halves)
+		if err != nil {
+			return 0, err
+		}
+		bp = append(bp, ms...)
+	}
+	return total - (len(bp) - len(models.GroupReadings(bp))), nil
+}
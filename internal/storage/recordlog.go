@@ -0,0 +1,33 @@
+// ABOUTME: RecordLog interface: where a log-backed store (the JSONL engine) keeps its records.
+// ABOUTME: The JSONL file is one; a backend package can supply another, such as a database table.
+
+package storage
+
+// RecordLog is the durable side of a store built on the JSONL engine: an
+// ordered log of JSON records that, replayed from the start, rebuilds
+// every entry. The store holds the log's lock around every read of new
+// records and every change, so a log shared by several processes stays
+// consistent; it never calls a RecordLog from two goroutines at once.
+type RecordLog interface {
+	// Lock takes the log's write lock, which orders writers in different
+	// processes, returning the func that releases it.
+	Lock() (func(), error)
+
+	// Read returns the records added since the last Read, oldest first.
+	// If the log was rewritten in between, by this process or another,
+	// reset is true and every record is returned, to be replayed afresh.
+	Read() (records [][]byte, reset bool, err error)
+
+	// Append adds a record at the end of the log.
+	Append(record []byte) error
+
+	// Rewrite replaces everything in the log with records, as compaction
+	// does.
+	Rewrite(records [][]byte) error
+
+	// Close releases the log.
+	Close() error
+
+	// String names the log in error messages, such as its file's path.
+	String() string
+}
@@ -0,0 +1,80 @@
+// ABOUTME: Reminder due-state for SQLite storage.
+// ABOUTME: Records when each reminder last fired and how long it is snoozed.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GetReminderLastFired returns when the reminder with the given key last
+// fired, or nil if it never has.
+func (d *DB) GetReminderLastFired(ctx context.Context, key string) (*time.Time, error) {
+	var lastFired string
+	err := d.db.QueryRowContext(ctx, `SELECT last_fired FROM reminder_state WHERE key = ?`, key).Scan(&lastFired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get reminder state: %w", err)
+	}
+
+	t, err := time.Parse(time.RFC3339, lastFired)
+	if err != nil {
+		return nil, fmt.Errorf("parse reminder state: %w", err)
+	}
+	return &t, nil
+}
+
+// SetReminderLastFired records that the reminder with the given key fired at.
+func (d *DB) SetReminderLastFired(ctx context.Context, key string, at time.Time) error {
+	query := `
+		INSERT INTO reminder_state (key, last_fired) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET last_fired = excluded.last_fired
+	`
+	if _, err := d.db.ExecContext(ctx, query, key, at.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("set reminder state: %w", err)
+	}
+	return nil
+}
+
+// GetReminderSnooze returns when the snooze on the reminder with the given
+// key ends, or nil if it isn't snoozed.
+func (d *DB) GetReminderSnooze(ctx context.Context, key string) (*time.Time, error) {
+	var until string
+	err := d.db.QueryRowContext(ctx, `SELECT snoozed_until FROM reminder_snoozes WHERE key = ?`, key).Scan(&until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get reminder snooze: %w", err)
+	}
+
+	t, err := time.Parse(time.RFC3339, until)
+	if err != nil {
+		return nil, fmt.Errorf("parse reminder snooze: %w", err)
+	}
+	return &t, nil
+}
+
+// SetReminderSnooze snoozes the reminder with the given key until the given
+// time. A nil until clears the snooze.
+func (d *DB) SetReminderSnooze(ctx context.Context, key string, until *time.Time) error {
+	if until == nil {
+		if _, err := d.db.ExecContext(ctx, `DELETE FROM reminder_snoozes WHERE key = ?`, key); err != nil {
+			return fmt.Errorf("clear reminder snooze: %w", err)
+		}
+		return nil
+	}
+	query := `
+		INSERT INTO reminder_snoozes (key, snoozed_until) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET snoozed_until = excluded.snoozed_until
+	`
+	if _, err := d.db.ExecContext(ctx, query, key, until.UTC().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("set reminder snooze: %w", err)
+	}
+	return nil
+}
@@ -1,38 +1,292 @@
 // ABOUTME: Repository interface for health data storage.
-// ABOUTME: Defines contract for metrics and workouts CRUD operations.
+// ABOUTME: Defines contract for metrics, workouts, sleep, medication, location, trip, appointment, event, and reminder state operations.
 package storage
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/harperreed/health/internal/models"
 )
 
+// MetricFilter narrows a metric query. Zero values mean "no constraint".
+// Results are always sorted by RecordedAt descending, so Offset and Limit
+// page through history from the most recent entry backwards.
+// Since is inclusive and Until is exclusive. Location matches the tag
+// exactly, ignoring case; Source matches exactly.
+type MetricFilter struct {
+	Type     *models.MetricType
+	Location *string
+	Source   *string
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+	Offset   int
+}
+
+// WorkoutFilter narrows a workout query. Zero values mean "no constraint".
+// Results are sorted by StartedAt descending. Since is inclusive and Until
+// is exclusive. Location matches the tag exactly, ignoring case; Source
+// matches exactly.
+type WorkoutFilter struct {
+	Type     *string
+	Location *string
+	Source   *string
+	Since    *time.Time
+	Until    *time.Time
+	Limit    int
+	Offset   int
+}
+
+// IntakeFilter narrows a medication intake query. Zero values mean
+// "no constraint". Results are sorted by TakenAt descending.
+type IntakeFilter struct {
+	MedicationID *uuid.UUID
+	Since        *time.Time
+	Until        *time.Time
+	Limit        int
+}
+
+// AppointmentFilter narrows an appointment query. Zero values mean
+// "no constraint". Results are sorted by ScheduledAt ascending, soonest
+// first. Since is inclusive and Until is exclusive.
+type AppointmentFilter struct {
+	Since *time.Time
+	Until *time.Time
+	Limit int
+}
+
+// matchesLocation reports whether an entry's location tag satisfies the
+// optional filter.
+func matchesLocation(tag, filter *string) bool {
+	if filter == nil {
+		return true
+	}
+	return tag != nil && strings.EqualFold(*tag, *filter)
+}
+
+// matchesSource reports whether an entry's source satisfies the filter.
+// A nil filter matches everything.
+func matchesSource(source string, filter *string) bool {
+	return filter == nil || source == *filter
+}
+
+// matchesMetric reports whether m satisfies the filter's type, location,
+// source, and time range.
+func matchesMetric(m *models.Metric, filter MetricFilter) bool {
+	if filter.Type != nil && m.MetricType != *filter.Type {
+		return false
+	}
+	return matchesLocation(m.Location, filter.Location) && matchesSource(m.Source, filter.Source) &&
+		inRange(m.RecordedAt, filter.Since, filter.Until)
+}
+
+// MetricTotal is the aggregate of the metrics matching a filter.
+type MetricTotal struct {
+	Count int
+	Sum   float64
+}
+
+// MetricAverage is the mean and range of the metrics matching a filter.
+// All three values are 0 when Count is.
+type MetricAverage struct {
+	Count    int
+	Avg      float64
+	Min, Max float64
+}
+
+// metricAggregate accumulates a total and average one metric at a time,
+// for backends that aggregate while reading.
+type metricAggregate struct {
+	count         int
+	sum, min, max float64
+}
+
+func (a *metricAggregate) add(m *models.Metric) {
+	if a.count == 0 || m.Value < a.min {
+		a.min = m.Value
+	}
+	if a.count == 0 || m.Value > a.max {
+		a.max = m.Value
+	}
+	a.count++
+	a.sum += m.Value
+}
+
+func (a *metricAggregate) total() *MetricTotal {
+	return &MetricTotal{Count: a.count, Sum: a.sum}
+}
+
+func (a *metricAggregate) average() *MetricAverage {
+	if a.count == 0 {
+		return &MetricAverage{}
+	}
+	return &MetricAverage{Count: a.count, Avg: a.sum / float64(a.count), Min: a.min, Max: a.max}
+}
+
+// inRange reports whether t falls within the optional [since, until) window.
+func inRange(t time.Time, since, until *time.Time) bool {
+	if since != nil && t.Before(*since) {
+		return false
+	}
+	if until != nil && !t.Before(*until) {
+		return false
+	}
+	return true
+}
+
+// matchMedication picks a medication by name (case-insensitive), slug
+// ("vitamin-d" for "Vitamin D"), full ID, or unique ID prefix.
+func matchMedication(meds []*models.Medication, nameOrID string) (*models.Medication, error) {
+	for _, m := range meds {
+		if strings.EqualFold(m.Name, nameOrID) || m.Slug() == models.Slugify(nameOrID) || m.ID.String() == nameOrID {
+			return m, nil
+		}
+	}
+
+	var match *models.Medication
+	for _, m := range meds {
+		if strings.HasPrefix(m.ID.String(), nameOrID) {
+			if match != nil {
+				return nil, fmt.Errorf("ambiguous prefix %s: %w", nameOrID, ErrAmbiguous)
+			}
+			match = m
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("not found: %s", nameOrID)
+	}
+	return match, nil
+}
+
+// matchLocation picks a location by name (case-insensitive), slug, full ID,
+// or unique ID prefix.
+func matchLocation(locs []*models.Location, nameOrID string) (*models.Location, error) {
+	for _, l := range locs {
+		if strings.EqualFold(l.Name, nameOrID) || l.Slug() == models.Slugify(nameOrID) || l.ID.String() == nameOrID {
+			return l, nil
+		}
+	}
+
+	var match *models.Location
+	for _, l := range locs {
+		if strings.HasPrefix(l.ID.String(), nameOrID) {
+			if match != nil {
+				return nil, fmt.Errorf("ambiguous prefix %s: %w", nameOrID, ErrAmbiguous)
+			}
+			match = l
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("not found: %s", nameOrID)
+	}
+	return match, nil
+}
+
 // Repository defines the storage interface for health data.
 // This interface allows swapping implementations (e.g., for testing).
 type Repository interface {
 	// Metric operations
-	CreateMetric(m *models.Metric) error
-	GetMetric(idOrPrefix string) (*models.Metric, error)
-	ListMetrics(metricType *models.MetricType, limit int) ([]*models.Metric, error)
-	DeleteMetric(idOrPrefix string) error
-	GetLatestMetric(metricType models.MetricType) (*models.Metric, error)
+	CreateMetric(ctx context.Context, m *models.Metric) error
+	CreateMetrics(ctx context.Context, metrics []*models.Metric) error
+	GetMetric(ctx context.Context, idOrPrefix string) (*models.Metric, error)
+	ListMetrics(ctx context.Context, metricType *models.MetricType, limit int) ([]*models.Metric, error)
+	QueryMetrics(ctx context.Context, filter MetricFilter) ([]*models.Metric, error)
+	DeleteMetric(ctx context.Context, idOrPrefix string) error
+	SetMetricMetadata(ctx context.Context, idOrPrefix, key, value string) error
+	UpdateMetric(ctx context.Context, m *models.Metric) error
+	GetLatestMetric(ctx context.Context, metricType models.MetricType) (*models.Metric, error)
+	SumMetrics(ctx context.Context, filter MetricFilter) (*MetricTotal, error)
+	AvgMetrics(ctx context.Context, filter MetricFilter) (*MetricAverage, error)
+	CountMetrics(ctx context.Context, filter MetricFilter) (int, error)
 
 	// Workout operations
-	CreateWorkout(w *models.Workout) error
-	GetWorkout(idOrPrefix string) (*models.Workout, error)
-	GetWorkoutWithMetrics(idOrPrefix string) (*models.Workout, error)
-	ListWorkouts(workoutType *string, limit int) ([]*models.Workout, error)
-	DeleteWorkout(idOrPrefix string) error
+	CreateWorkout(ctx context.Context, w *models.Workout) error
+	CreateWorkouts(ctx context.Context, workouts []*models.Workout) error
+	GetWorkout(ctx context.Context, idOrPrefix string) (*models.Workout, error)
+	GetWorkoutWithMetrics(ctx context.Context, idOrPrefix string) (*models.Workout, error)
+	ListWorkouts(ctx context.Context, workoutType *string, limit int) ([]*models.Workout, error)
+	QueryWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, error)
+	CountWorkouts(ctx context.Context, filter WorkoutFilter) (int, error)
+	DeleteWorkout(ctx context.Context, idOrPrefix string) error
+	SetWorkoutMetadata(ctx context.Context, idOrPrefix, key, value string) error
+	UpdateWorkout(ctx context.Context, w *models.Workout) error
 
 	// Workout metric operations
-	AddWorkoutMetric(wm *models.WorkoutMetric) error
-	GetWorkoutMetric(idOrPrefix string) (*models.WorkoutMetric, error)
-	ListWorkoutMetrics(workoutID uuid.UUID) ([]*models.WorkoutMetric, error)
-	DeleteWorkoutMetric(idOrPrefix string) error
+	AddWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error
+	GetWorkoutMetric(ctx context.Context, idOrPrefix string) (*models.WorkoutMetric, error)
+	ListWorkoutMetrics(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutMetric, error)
+	UpdateWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error
+	DeleteWorkoutMetric(ctx context.Context, idOrPrefix string) error
+
+	// Workout set operations
+	AddWorkoutSet(ctx context.Context, ws *models.WorkoutSet) error
+	ListWorkoutSets(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutSet, error)
+
+	// Workout comment operations
+	AddWorkoutComment(ctx context.Context, c *models.WorkoutComment) error
+	ListWorkoutComments(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutComment, error)
+
+	// Sleep session operations
+	CreateSleepSession(ctx context.Context, s *models.SleepSession) error
+	GetSleepSession(ctx context.Context, idOrPrefix string) (*models.SleepSession, error)
+	ListSleepSessions(ctx context.Context, limit int) ([]*models.SleepSession, error)
+	DeleteSleepSession(ctx context.Context, idOrPrefix string) error
+
+	// Medication operations
+	CreateMedication(ctx context.Context, m *models.Medication) error
+	GetMedication(ctx context.Context, nameOrID string) (*models.Medication, error)
+	ListMedications(ctx context.Context) ([]*models.Medication, error)
+	DeleteMedication(ctx context.Context, nameOrID string) error
+	LogMedicationIntake(ctx context.Context, in *models.MedicationIntake) error
+	ListMedicationIntakes(ctx context.Context, filter IntakeFilter) ([]*models.MedicationIntake, error)
+
+	// Location operations
+	CreateLocation(ctx context.Context, l *models.Location) error
+	GetLocation(ctx context.Context, nameOrID string) (*models.Location, error)
+	ListLocations(ctx context.Context) ([]*models.Location, error)
+	DeleteLocation(ctx context.Context, nameOrID string) error
+
+	// Trip operations
+	CreateTrip(ctx context.Context, t *models.Trip) error
+	GetTrip(ctx context.Context, idOrPrefix string) (*models.Trip, error)
+	ListTrips(ctx context.Context, limit int) ([]*models.Trip, error)
+	EndTrip(ctx context.Context, idOrPrefix string, endedAt time.Time) error
+	DeleteTrip(ctx context.Context, idOrPrefix string) error
+
+	// Fast operations
+	CreateFast(ctx context.Context, f *models.Fast) error
+	GetFast(ctx context.Context, idOrPrefix string) (*models.Fast, error)
+	ListFasts(ctx context.Context, limit int) ([]*models.Fast, error)
+	EndFast(ctx context.Context, idOrPrefix string, endedAt time.Time) error
+	DeleteFast(ctx context.Context, idOrPrefix string) error
+
+	// Appointment operations
+	CreateAppointment(ctx context.Context, a *models.Appointment) error
+	GetAppointment(ctx context.Context, idOrPrefix string) (*models.Appointment, error)
+	ListAppointments(ctx context.Context, filter AppointmentFilter) ([]*models.Appointment, error)
+	SetAppointmentSummary(ctx context.Context, idOrPrefix string, summary string) error
+	DeleteAppointment(ctx context.Context, idOrPrefix string) error
+
+	// Event operations
+	CreateEvent(ctx context.Context, e *models.Event) error
+	GetEvent(ctx context.Context, idOrPrefix string) (*models.Event, error)
+	ListEvents(ctx context.Context) ([]*models.Event, error)
+	DeleteEvent(ctx context.Context, idOrPrefix string) error
+
+	// Reminder state operations. State is bookkeeping for 'health remind'
+	// and is not exported or migrated.
+	GetReminderLastFired(ctx context.Context, key string) (*time.Time, error)
+	SetReminderLastFired(ctx context.Context, key string, at time.Time) error
+	GetReminderSnooze(ctx context.Context, key string) (*time.Time, error)
+	SetReminderSnooze(ctx context.Context, key string, until *time.Time) error
 
 	// Export/Import
-	GetAllData() (*ExportData, error)
-	ImportData(data *ExportData) error
+	GetAllData(ctx context.Context) (*ExportData, error)
+	ImportData(ctx context.Context, data *ExportData) error
 
 	// Lifecycle
 	Close() error
@@ -3,8 +3,13 @@
 package storage
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,19 +18,20 @@ import (
 )
 
 func TestCreateAndGetMetric(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
 	m.WithNotes("morning weight")
 
-	err := db.CreateMetric(m)
+	err := db.CreateMetric(ctx, m)
 	if err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Retrieve by full ID
-	got, err := db.GetMetric(m.ID.String())
+	got, err := db.GetMetric(ctx, m.ID.String())
 	if err != nil {
 		t.Fatalf("GetMetric failed: %v", err)
 	}
@@ -45,17 +51,18 @@ func TestCreateAndGetMetric(t *testing.T) {
 }
 
 func TestGetMetricByPrefix(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := db.CreateMetric(m); err != nil {
+	if err := db.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Retrieve by 8-char prefix
 	prefix := m.ID.String()[:8]
-	got, err := db.GetMetric(prefix)
+	got, err := db.GetMetric(ctx, prefix)
 	if err != nil {
 		t.Fatalf("GetMetric by prefix failed: %v", err)
 	}
@@ -66,6 +73,7 @@ func TestGetMetricByPrefix(t *testing.T) {
 }
 
 func TestListMetrics(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -78,13 +86,13 @@ func TestListMetrics(t *testing.T) {
 	m3.RecordedAt = time.Now()
 
 	for _, m := range []*models.Metric{m1, m2, m3} {
-		if err := db.CreateMetric(m); err != nil {
+		if err := db.CreateMetric(ctx, m); err != nil {
 			t.Fatalf("CreateMetric failed: %v", err)
 		}
 	}
 
 	// List all metrics (should be ordered by RecordedAt DESC)
-	all, err := db.ListMetrics(nil, 0)
+	all, err := db.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -99,7 +107,7 @@ func TestListMetrics(t *testing.T) {
 
 	// Filter by type
 	weightType := models.MetricWeight
-	weights, err := db.ListMetrics(&weightType, 0)
+	weights, err := db.ListMetrics(ctx, &weightType, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics with type failed: %v", err)
 	}
@@ -108,7 +116,7 @@ func TestListMetrics(t *testing.T) {
 	}
 
 	// Test limit
-	limited, err := db.ListMetrics(nil, 2)
+	limited, err := db.ListMetrics(ctx, nil, 2)
 	if err != nil {
 		t.Fatalf("ListMetrics with limit failed: %v", err)
 	}
@@ -118,27 +126,29 @@ func TestListMetrics(t *testing.T) {
 }
 
 func TestDeleteMetric(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := db.CreateMetric(m); err != nil {
+	if err := db.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Delete by prefix
-	if err := db.DeleteMetric(m.ID.String()[:8]); err != nil {
+	if err := db.DeleteMetric(ctx, m.ID.String()[:8]); err != nil {
 		t.Fatalf("DeleteMetric failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := db.GetMetric(m.ID.String())
+	_, err := db.GetMetric(ctx, m.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted metric")
 	}
 }
 
 func TestGetLatestMetric(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -147,14 +157,14 @@ func TestGetLatestMetric(t *testing.T) {
 	m2 := models.NewMetric(models.MetricWeight, 83.0)
 	m2.RecordedAt = time.Now()
 
-	if err := db.CreateMetric(m1); err != nil {
+	if err := db.CreateMetric(ctx, m1); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
-	if err := db.CreateMetric(m2); err != nil {
+	if err := db.CreateMetric(ctx, m2); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
-	latest, err := db.GetLatestMetric(models.MetricWeight)
+	latest, err := db.GetLatestMetric(ctx, models.MetricWeight)
 	if err != nil {
 		t.Fatalf("GetLatestMetric failed: %v", err)
 	}
@@ -165,6 +175,7 @@ func TestGetLatestMetric(t *testing.T) {
 }
 
 func TestCreateAndGetWorkout(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -172,11 +183,11 @@ func TestCreateAndGetWorkout(t *testing.T) {
 	w.WithDuration(45)
 	w.WithNotes("morning run")
 
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := db.GetWorkout(w.ID.String())
+	got, err := db.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -193,11 +204,12 @@ func TestCreateAndGetWorkout(t *testing.T) {
 }
 
 func TestWorkoutWithMetrics(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
@@ -205,15 +217,15 @@ func TestWorkoutWithMetrics(t *testing.T) {
 	wm1 := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
 	wm2 := models.NewWorkoutMetric(w.ID, "avg_hr", 150, "bpm")
 
-	if err := db.AddWorkoutMetric(wm1); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm1); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
-	if err := db.AddWorkoutMetric(wm2); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm2); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Get workout with metrics
-	got, err := db.GetWorkoutWithMetrics(w.ID.String())
+	got, err := db.GetWorkoutWithMetrics(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
 	}
@@ -224,32 +236,33 @@ func TestWorkoutWithMetrics(t *testing.T) {
 }
 
 func TestDeleteWorkoutCascade(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Delete workout (should cascade to metrics)
-	if err := db.DeleteWorkout(w.ID.String()[:8]); err != nil {
+	if err := db.DeleteWorkout(ctx, w.ID.String()[:8]); err != nil {
 		t.Fatalf("DeleteWorkout failed: %v", err)
 	}
 
 	// Verify workout deleted
-	_, err := db.GetWorkout(w.ID.String())
+	_, err := db.GetWorkout(ctx, w.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted workout")
 	}
 
 	// Verify workout metrics also deleted
-	metrics, err := db.ListWorkoutMetrics(w.ID)
+	metrics, err := db.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		t.Fatalf("ListWorkoutMetrics failed: %v", err)
 	}
@@ -259,6 +272,7 @@ func TestDeleteWorkoutCascade(t *testing.T) {
 }
 
 func TestListWorkouts(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -270,13 +284,13 @@ func TestListWorkouts(t *testing.T) {
 	w3.StartedAt = time.Now()
 
 	for _, w := range []*models.Workout{w1, w2, w3} {
-		if err := db.CreateWorkout(w); err != nil {
+		if err := db.CreateWorkout(ctx, w); err != nil {
 			t.Fatalf("CreateWorkout failed: %v", err)
 		}
 	}
 
 	// List all
-	all, err := db.ListWorkouts(nil, 0)
+	all, err := db.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -286,7 +300,7 @@ func TestListWorkouts(t *testing.T) {
 
 	// Filter by type
 	runType := "run"
-	runs, err := db.ListWorkouts(&runType, 0)
+	runs, err := db.ListWorkouts(ctx, &runType, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts with type failed: %v", err)
 	}
@@ -295,7 +309,7 @@ func TestListWorkouts(t *testing.T) {
 	}
 
 	// Test limit
-	limited, err := db.ListWorkouts(nil, 2)
+	limited, err := db.ListWorkouts(ctx, nil, 2)
 	if err != nil {
 		t.Fatalf("ListWorkouts with limit failed: %v", err)
 	}
@@ -305,6 +319,7 @@ func TestListWorkouts(t *testing.T) {
 }
 
 func TestAmbiguousPrefixError(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -312,10 +327,10 @@ func TestAmbiguousPrefixError(t *testing.T) {
 	m1 := models.NewMetric(models.MetricWeight, 82.0)
 	m2 := models.NewMetric(models.MetricWeight, 83.0)
 
-	if err := db.CreateMetric(m1); err != nil {
+	if err := db.CreateMetric(ctx, m1); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
-	if err := db.CreateMetric(m2); err != nil {
+	if err := db.CreateMetric(ctx, m2); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
@@ -323,7 +338,7 @@ func TestAmbiguousPrefixError(t *testing.T) {
 	// Note: UUIDs start with hex chars, so "a" could theoretically match many
 	// For this test we use a prefix that should be unique in practice
 	// but we test the error handling path
-	_, err := db.GetMetric("00000000")
+	_, err := db.GetMetric(ctx, "00000000")
 	if err == nil {
 		// This is fine - prefix might not match anything
 		return
@@ -332,25 +347,26 @@ func TestAmbiguousPrefixError(t *testing.T) {
 }
 
 func TestListWorkoutMetrics(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm1 := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
 	wm2 := models.NewWorkoutMetric(w.ID, "pace", 5.5, "min/km")
 
-	if err := db.AddWorkoutMetric(wm1); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm1); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
-	if err := db.AddWorkoutMetric(wm2); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm2); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	metrics, err := db.ListWorkoutMetrics(w.ID)
+	metrics, err := db.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		t.Fatalf("ListWorkoutMetrics failed: %v", err)
 	}
@@ -361,25 +377,26 @@ func TestListWorkoutMetrics(t *testing.T) {
 }
 
 func TestDeleteWorkoutMetric(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.2, "km")
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	if err := db.DeleteWorkoutMetric(wm.ID.String()[:8]); err != nil {
+	if err := db.DeleteWorkoutMetric(ctx, wm.ID.String()[:8]); err != nil {
 		t.Fatalf("DeleteWorkoutMetric failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := db.GetWorkoutMetric(wm.ID.String())
+	_, err := db.GetWorkoutMetric(ctx, wm.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted workout metric")
 	}
@@ -406,24 +423,25 @@ func setupTestDB(t *testing.T) *DB {
 }
 
 func TestGetAllData(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	// Create metrics
 	m1 := models.NewMetric(models.MetricWeight, 82.5)
 	m2 := models.NewMetric(models.MetricMood, 7)
-	db.CreateMetric(m1)
-	db.CreateMetric(m2)
+	db.CreateMetric(ctx, m1)
+	db.CreateMetric(ctx, m2)
 
 	// Create workout with metrics
 	w := models.NewWorkout("run")
 	w.WithDuration(30)
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	db.AddWorkoutMetric(wm)
+	db.AddWorkoutMetric(ctx, wm)
 
-	data, err := db.GetAllData()
+	data, err := db.GetAllData(ctx)
 	if err != nil {
 		t.Fatalf("GetAllData failed: %v", err)
 	}
@@ -440,6 +458,7 @@ func TestGetAllData(t *testing.T) {
 }
 
 func TestImportData(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -477,12 +496,12 @@ func TestImportData(t *testing.T) {
 	}
 
 	// Import
-	if err := db.ImportData(data); err != nil {
+	if err := db.ImportData(ctx, data); err != nil {
 		t.Fatalf("ImportData failed: %v", err)
 	}
 
 	// Verify
-	metrics, err := db.ListMetrics(nil, 0)
+	metrics, err := db.ListMetrics(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -490,7 +509,7 @@ func TestImportData(t *testing.T) {
 		t.Errorf("Expected 1 metric, got %d", len(metrics))
 	}
 
-	workouts, err := db.ListWorkouts(nil, 0)
+	workouts, err := db.ListWorkouts(ctx, nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -504,7 +523,7 @@ func TestGetMetricNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to get a non-existent metric
-	_, err := db.GetMetric("nonexistent")
+	_, err := db.GetMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent metric")
 	}
@@ -515,7 +534,7 @@ func TestGetWorkoutNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to get a non-existent workout
-	_, err := db.GetWorkout("nonexistent")
+	_, err := db.GetWorkout(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout")
 	}
@@ -526,7 +545,7 @@ func TestGetWorkoutWithMetricsNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to get a non-existent workout with metrics
-	_, err := db.GetWorkoutWithMetrics("nonexistent")
+	_, err := db.GetWorkoutWithMetrics(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout")
 	}
@@ -537,7 +556,7 @@ func TestDeleteMetricNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to delete a non-existent metric
-	err := db.DeleteMetric("nonexistent")
+	err := db.DeleteMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent metric")
 	}
@@ -548,7 +567,7 @@ func TestDeleteWorkoutNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to delete a non-existent workout
-	err := db.DeleteWorkout("nonexistent")
+	err := db.DeleteWorkout(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout")
 	}
@@ -559,7 +578,7 @@ func TestDeleteWorkoutMetricNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to delete a non-existent workout metric
-	err := db.DeleteWorkoutMetric("nonexistent")
+	err := db.DeleteWorkoutMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout metric")
 	}
@@ -570,7 +589,7 @@ func TestGetLatestMetricNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to get latest metric when none exist
-	_, err := db.GetLatestMetric(models.MetricWeight)
+	_, err := db.GetLatestMetric(t.Context(), models.MetricWeight)
 	if err == nil {
 		t.Error("Expected error when no metrics exist")
 	}
@@ -581,23 +600,24 @@ func TestGetWorkoutMetricNotFound(t *testing.T) {
 	defer db.Close()
 
 	// Try to get a non-existent workout metric
-	_, err := db.GetWorkoutMetric("nonexistent")
+	_, err := db.GetWorkoutMetric(t.Context(), "nonexistent")
 	if err == nil {
 		t.Error("Expected error for non-existent workout metric")
 	}
 }
 
 func TestGetWorkoutByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	// Retrieve by full UUID
-	got, err := db.GetWorkout(w.ID.String())
+	got, err := db.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout by full UUID failed: %v", err)
 	}
@@ -608,16 +628,17 @@ func TestGetWorkoutByFullUUID(t *testing.T) {
 }
 
 func TestGetMetricByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := db.CreateMetric(m); err != nil {
+	if err := db.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Retrieve by full UUID
-	got, err := db.GetMetric(m.ID.String())
+	got, err := db.GetMetric(ctx, m.ID.String())
 	if err != nil {
 		t.Fatalf("GetMetric by full UUID failed: %v", err)
 	}
@@ -628,21 +649,22 @@ func TestGetMetricByFullUUID(t *testing.T) {
 }
 
 func TestGetWorkoutMetricByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Retrieve by full UUID
-	got, err := db.GetWorkoutMetric(wm.ID.String())
+	got, err := db.GetWorkoutMetric(ctx, wm.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric by full UUID failed: %v", err)
 	}
@@ -667,6 +689,77 @@ func TestDBClose(t *testing.T) {
 	// depending on implementation. Our implementation returns nil if db is nil.
 }
 
+func TestConcurrentWriters(t *testing.T) {
+	ctx := t.Context()
+	dbPath := filepath.Join(t.TempDir(), "health.db")
+	// Two handles on one file, like the CLI and the MCP server
+	var handles []*DB
+	for range 2 {
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		handles = append(handles, db)
+	}
+
+	const writers, each = 8, 25
+	errs := make(chan error, writers)
+	for i := range writers {
+		db := handles[i%2]
+		go func() {
+			for j := range each {
+				var err error
+				if j%5 == 0 {
+					err = db.CreateMetrics(ctx, []*models.Metric{models.NewMetric(models.MetricSteps, float64(j))})
+				} else {
+					err = db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, float64(j)))
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+				// Reads interleave with the other handle's writes
+				if _, err := db.ListMetrics(ctx, nil, 5); err != nil {
+					errs <- err
+					return
+				}
+			}
+			errs <- nil
+		}()
+	}
+	for range writers {
+		if err := <-errs; err != nil {
+			t.Fatalf("concurrent write failed: %v", err)
+		}
+	}
+
+	if n, err := handles[0].CountMetrics(ctx, MetricFilter{}); err != nil || n != writers*each {
+		t.Errorf("CountMetrics = %d, %v; want %d", n, err, writers*each)
+	}
+
+	// Every pooled connection gets the pragmas, not just the first
+	var conns []*sql.Conn
+	for range 3 {
+		conn, err := handles[1].db.Conn(ctx)
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		defer conn.Close()
+		conns = append(conns, conn)
+	}
+	for i, conn := range conns {
+		var timeout int
+		var mode string
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil || timeout != 5000 {
+			t.Errorf("conn %d busy_timeout = %d, %v; want 5000", i, timeout, err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+			t.Errorf("conn %d journal_mode = %q, %v; want wal", i, mode, err)
+		}
+	}
+}
+
 func TestDBCloseNilDB(t *testing.T) {
 	// Test closing a nil DB
 	d := &DB{db: nil}
@@ -677,6 +770,7 @@ func TestDBCloseNilDB(t *testing.T) {
 }
 
 func TestWorkoutWithNullableDuration(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -684,11 +778,11 @@ func TestWorkoutWithNullableDuration(t *testing.T) {
 	w := models.NewWorkout("run")
 	// Don't set duration - should be nil
 
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := db.GetWorkout(w.ID.String())
+	got, err := db.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -699,6 +793,7 @@ func TestWorkoutWithNullableDuration(t *testing.T) {
 }
 
 func TestWorkoutWithNullableNotes(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -706,11 +801,11 @@ func TestWorkoutWithNullableNotes(t *testing.T) {
 	w := models.NewWorkout("run")
 	// Don't set notes - should be nil
 
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := db.GetWorkout(w.ID.String())
+	got, err := db.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -721,20 +816,21 @@ func TestWorkoutWithNullableNotes(t *testing.T) {
 }
 
 func TestWorkoutMetricWithNullableUnit(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("lift")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	// Workout metric without unit
 	wm := models.NewWorkoutMetric(w.ID, "sets", 4, "")
 
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	got, err := db.GetWorkoutMetric(wm.ID.String())
+	got, err := db.GetWorkoutMetric(ctx, wm.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric failed: %v", err)
 	}
@@ -745,6 +841,7 @@ func TestWorkoutMetricWithNullableUnit(t *testing.T) {
 }
 
 func TestListWorkoutsWithTypeFilter(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -753,13 +850,13 @@ func TestListWorkoutsWithTypeFilter(t *testing.T) {
 	w2 := models.NewWorkout("lift")
 	w3 := models.NewWorkout("run")
 
-	db.CreateWorkout(w1)
-	db.CreateWorkout(w2)
-	db.CreateWorkout(w3)
+	db.CreateWorkout(ctx, w1)
+	db.CreateWorkout(ctx, w2)
+	db.CreateWorkout(ctx, w3)
 
 	// Filter by type
 	runType := "run"
-	workouts, err := db.ListWorkouts(&runType, 0)
+	workouts, err := db.ListWorkouts(ctx, &runType, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -780,7 +877,7 @@ func TestListMetricsNoResults(t *testing.T) {
 	defer db.Close()
 
 	// List metrics when none exist
-	metrics, err := db.ListMetrics(nil, 0)
+	metrics, err := db.ListMetrics(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListMetrics failed: %v", err)
 	}
@@ -795,7 +892,7 @@ func TestListWorkoutsNoResults(t *testing.T) {
 	defer db.Close()
 
 	// List workouts when none exist
-	workouts, err := db.ListWorkouts(nil, 0)
+	workouts, err := db.ListWorkouts(t.Context(), nil, 0)
 	if err != nil {
 		t.Fatalf("ListWorkouts failed: %v", err)
 	}
@@ -810,7 +907,7 @@ func TestAmbiguousWorkoutPrefixError(t *testing.T) {
 	defer db.Close()
 
 	// Try to get with a very short nonexistent prefix
-	_, err := db.GetWorkout("00000000")
+	_, err := db.GetWorkout(t.Context(), "00000000")
 	if err == nil {
 		// This is fine - prefix might not match anything
 		return
@@ -823,7 +920,7 @@ func TestAmbiguousWorkoutMetricPrefixError(t *testing.T) {
 	defer db.Close()
 
 	// Try to get with a nonexistent prefix
-	_, err := db.GetWorkoutMetric("00000000")
+	_, err := db.GetWorkoutMetric(t.Context(), "00000000")
 	if err == nil {
 		return
 	}
@@ -831,16 +928,17 @@ func TestAmbiguousWorkoutMetricPrefixError(t *testing.T) {
 }
 
 func TestGetWorkoutByPrefix(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	// Get by 8-char prefix
-	got, err := db.GetWorkout(w.ID.String()[:8])
+	got, err := db.GetWorkout(ctx, w.ID.String()[:8])
 	if err != nil {
 		t.Fatalf("GetWorkout by prefix failed: %v", err)
 	}
@@ -851,19 +949,20 @@ func TestGetWorkoutByPrefix(t *testing.T) {
 }
 
 func TestGetWorkoutMetricByPrefix(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Get by 8-char prefix
-	got, err := db.GetWorkoutMetric(wm.ID.String()[:8])
+	got, err := db.GetWorkoutMetric(ctx, wm.ID.String()[:8])
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric by prefix failed: %v", err)
 	}
@@ -874,72 +973,76 @@ func TestGetWorkoutMetricByPrefix(t *testing.T) {
 }
 
 func TestDeleteMetricByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	m := models.NewMetric(models.MetricWeight, 82.5)
-	if err := db.CreateMetric(m); err != nil {
+	if err := db.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
 	// Delete by full UUID
-	if err := db.DeleteMetric(m.ID.String()); err != nil {
+	if err := db.DeleteMetric(ctx, m.ID.String()); err != nil {
 		t.Fatalf("DeleteMetric by full UUID failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := db.GetMetric(m.ID.String())
+	_, err := db.GetMetric(ctx, m.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted metric")
 	}
 }
 
 func TestDeleteWorkoutByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
 	// Delete by full UUID
-	if err := db.DeleteWorkout(w.ID.String()); err != nil {
+	if err := db.DeleteWorkout(ctx, w.ID.String()); err != nil {
 		t.Fatalf("DeleteWorkout by full UUID failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := db.GetWorkout(w.ID.String())
+	_, err := db.GetWorkout(ctx, w.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted workout")
 	}
 }
 
 func TestDeleteWorkoutMetricByFullUUID(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "distance", 5.0, "km")
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
 	// Delete by full UUID
-	if err := db.DeleteWorkoutMetric(wm.ID.String()); err != nil {
+	if err := db.DeleteWorkoutMetric(ctx, wm.ID.String()); err != nil {
 		t.Fatalf("DeleteWorkoutMetric by full UUID failed: %v", err)
 	}
 
 	// Verify deleted
-	_, err := db.GetWorkoutMetric(wm.ID.String())
+	_, err := db.GetWorkoutMetric(ctx, wm.ID.String())
 	if err == nil {
 		t.Error("Expected error getting deleted workout metric")
 	}
 }
 
 func TestListMetricsWithTypeAndLimit(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -947,12 +1050,12 @@ func TestListMetricsWithTypeAndLimit(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		m := models.NewMetric(models.MetricWeight, float64(80+i))
 		m.RecordedAt = time.Now().Add(-time.Duration(i) * time.Hour)
-		db.CreateMetric(m)
+		db.CreateMetric(ctx, m)
 	}
 
 	// Filter by type with limit
 	weightType := models.MetricWeight
-	metrics, err := db.ListMetrics(&weightType, 2)
+	metrics, err := db.ListMetrics(ctx, &weightType, 2)
 	if err != nil {
 		t.Fatalf("ListMetrics with type and limit failed: %v", err)
 	}
@@ -963,6 +1066,7 @@ func TestListMetricsWithTypeAndLimit(t *testing.T) {
 }
 
 func TestListWorkoutsWithTypeAndLimit(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -970,12 +1074,12 @@ func TestListWorkoutsWithTypeAndLimit(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		w := models.NewWorkout("run")
 		w.StartedAt = time.Now().Add(-time.Duration(i) * time.Hour)
-		db.CreateWorkout(w)
+		db.CreateWorkout(ctx, w)
 	}
 
 	// Filter by type with limit
 	runType := "run"
-	workouts, err := db.ListWorkouts(&runType, 2)
+	workouts, err := db.ListWorkouts(ctx, &runType, 2)
 	if err != nil {
 		t.Fatalf("ListWorkouts with type and limit failed: %v", err)
 	}
@@ -986,6 +1090,7 @@ func TestListWorkoutsWithTypeAndLimit(t *testing.T) {
 }
 
 func TestWorkoutWithBothNullableFields(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -994,11 +1099,11 @@ func TestWorkoutWithBothNullableFields(t *testing.T) {
 	w.WithDuration(45)
 	w.WithNotes("Morning run")
 
-	if err := db.CreateWorkout(w); err != nil {
+	if err := db.CreateWorkout(ctx, w); err != nil {
 		t.Fatalf("CreateWorkout failed: %v", err)
 	}
 
-	got, err := db.GetWorkout(w.ID.String())
+	got, err := db.GetWorkout(ctx, w.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkout failed: %v", err)
 	}
@@ -1012,6 +1117,7 @@ func TestWorkoutWithBothNullableFields(t *testing.T) {
 }
 
 func TestMetricWithAllFields(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
@@ -1020,11 +1126,11 @@ func TestMetricWithAllFields(t *testing.T) {
 	customTime := time.Now().Add(-1 * time.Hour)
 	m.WithRecordedAt(customTime)
 
-	if err := db.CreateMetric(m); err != nil {
+	if err := db.CreateMetric(ctx, m); err != nil {
 		t.Fatalf("CreateMetric failed: %v", err)
 	}
 
-	got, err := db.GetMetric(m.ID.String())
+	got, err := db.GetMetric(ctx, m.ID.String())
 	if err != nil {
 		t.Fatalf("GetMetric failed: %v", err)
 	}
@@ -1038,18 +1144,19 @@ func TestMetricWithAllFields(t *testing.T) {
 }
 
 func TestWorkoutMetricWithAllFields(t *testing.T) {
+	ctx := t.Context()
 	db := setupTestDB(t)
 	defer db.Close()
 
 	w := models.NewWorkout("run")
-	db.CreateWorkout(w)
+	db.CreateWorkout(ctx, w)
 
 	wm := models.NewWorkoutMetric(w.ID, "pace", 5.5, "min/km")
-	if err := db.AddWorkoutMetric(wm); err != nil {
+	if err := db.AddWorkoutMetric(ctx, wm); err != nil {
 		t.Fatalf("AddWorkoutMetric failed: %v", err)
 	}
 
-	got, err := db.GetWorkoutMetric(wm.ID.String())
+	got, err := db.GetWorkoutMetric(ctx, wm.ID.String())
 	if err != nil {
 		t.Fatalf("GetWorkoutMetric failed: %v", err)
 	}
@@ -1064,3 +1171,1382 @@ func TestWorkoutMetricWithAllFields(t *testing.T) {
 		t.Error("Expected Unit to be 'min/km'")
 	}
 }
+
+func TestQueryMetricsPagination(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Now()
+	var created []*models.Metric
+	for i := 0; i < 5; i++ {
+		m := models.NewMetric(models.MetricWeight, 80+float64(i))
+		m.RecordedAt = base.Add(-time.Duration(i) * time.Hour)
+		if err := db.CreateMetric(ctx, m); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+		created = append(created, m)
+	}
+
+	page, err := db.QueryMetrics(ctx, MetricFilter{Limit: 2, Offset: 2})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(page) != 2 {
+		t.Fatalf("Expected 2 metrics, got %d", len(page))
+	}
+	if page[0].ID != created[2].ID || page[1].ID != created[3].ID {
+		t.Error("Expected third and fourth most recent metrics")
+	}
+
+	// Offset without a limit returns the remainder
+	rest, err := db.QueryMetrics(ctx, MetricFilter{Offset: 3})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(rest) != 2 {
+		t.Errorf("Expected 2 remaining metrics, got %d", len(rest))
+	}
+
+	// Offset past the end is empty
+	empty, err := db.QueryMetrics(ctx, MetricFilter{Limit: 2, Offset: 10})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Errorf("Expected no metrics, got %d", len(empty))
+	}
+}
+
+func TestQueryMetricsDateRange(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	day := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		m := models.NewMetric(models.MetricSteps, float64(1000*i))
+		m.RecordedAt = day.AddDate(0, 0, -i)
+		if err := db.CreateMetric(ctx, m); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+	}
+
+	since := day.AddDate(0, 0, -2)
+	until := day
+	got, err := db.QueryMetrics(ctx, MetricFilter{Since: &since, Until: &until})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	// since is inclusive, until is exclusive: days -2 and -1
+	if len(got) != 2 {
+		t.Errorf("Expected 2 metrics in range, got %d", len(got))
+	}
+	if n, err := db.CountMetrics(ctx, MetricFilter{Since: &since, Until: &until, Limit: 1}); err != nil || n != 2 {
+		t.Errorf("CountMetrics = %d, %v; want 2 regardless of limit", n, err)
+	}
+}
+
+func TestQueryWorkoutsDateRange(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	day := time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC)
+	for i := 0; i < 4; i++ {
+		w := models.NewWorkout("run").WithStartedAt(day.AddDate(0, 0, -i))
+		if err := db.CreateWorkout(ctx, w); err != nil {
+			t.Fatalf("CreateWorkout failed: %v", err)
+		}
+	}
+	lift := models.NewWorkout("lift").WithStartedAt(day)
+	if err := db.CreateWorkout(ctx, lift); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+
+	since := day.AddDate(0, 0, -1)
+	runType := "run"
+	got, err := db.QueryWorkouts(ctx, WorkoutFilter{Type: &runType, Since: &since})
+	if err != nil {
+		t.Fatalf("QueryWorkouts failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("Expected 2 run workouts since yesterday, got %d", len(got))
+	}
+	if n, err := db.CountWorkouts(ctx, WorkoutFilter{Type: &runType}); err != nil || n != 4 {
+		t.Errorf("CountWorkouts = %d, %v; want 4 runs", n, err)
+	}
+}
+
+func TestWorkoutSets(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	w := models.NewWorkout("lift")
+	if err := db.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		ws := models.NewWorkoutSet(w.ID, "bench", i, 5).WithWeight(100, "kg")
+		if err := db.AddWorkoutSet(ctx, ws); err != nil {
+			t.Fatalf("AddWorkoutSet failed: %v", err)
+		}
+	}
+	if err := db.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "pullup", 1, 8)); err != nil {
+		t.Fatalf("AddWorkoutSet failed: %v", err)
+	}
+
+	sets, err := db.ListWorkoutSets(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutSets failed: %v", err)
+	}
+	if len(sets) != 4 {
+		t.Fatalf("Expected 4 sets, got %d", len(sets))
+	}
+	if sets[0].SetNumber != 1 || sets[2].SetNumber != 3 {
+		t.Error("Expected sets in logged order")
+	}
+	if sets[3].Weight != nil || sets[3].WeightUnit != nil {
+		t.Error("Expected bodyweight set to have no load")
+	}
+
+	full, err := db.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+	}
+	if len(full.Sets) != 4 {
+		t.Errorf("Expected 4 sets on full workout, got %d", len(full.Sets))
+	}
+
+	// Sets cascade with the workout
+	if err := db.DeleteWorkout(ctx, w.ID.String()); err != nil {
+		t.Fatalf("DeleteWorkout failed: %v", err)
+	}
+	sets, err = db.ListWorkoutSets(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutSets failed: %v", err)
+	}
+	if len(sets) != 0 {
+		t.Errorf("Expected sets to cascade delete, got %d", len(sets))
+	}
+}
+
+func TestSleepSessions(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	base := time.Date(2024, 12, 13, 23, 0, 0, 0, time.UTC)
+	older := models.NewSleepSession(base.AddDate(0, 0, -1), base.AddDate(0, 0, -1).Add(7*time.Hour))
+	newer := models.NewSleepSession(base, base.Add(8*time.Hour)).WithQuality(8).WithAwakenings(1).WithNotes("good")
+
+	if _, err := RecordSleepSession(ctx, db, older, models.SourceManual); err != nil {
+		t.Fatalf("RecordSleepSession failed: %v", err)
+	}
+	m, err := RecordSleepSession(ctx, db, newer, models.SourceManual)
+	if err != nil {
+		t.Fatalf("RecordSleepSession failed: %v", err)
+	}
+	if m.Value != 8 || m.MetricType != models.MetricSleepHours {
+		t.Errorf("derived metric = %s %.2f, want sleep_hours 8", m.MetricType, m.Value)
+	}
+
+	got, err := db.GetSleepSession(ctx, newer.ID.String()[:8])
+	if err != nil {
+		t.Fatalf("GetSleepSession failed: %v", err)
+	}
+	if got.Quality == nil || *got.Quality != 8 || got.Awakenings == nil || *got.Awakenings != 1 {
+		t.Error("expected quality and awakenings to round-trip")
+	}
+	if got.MetricID == nil || *got.MetricID != m.ID {
+		t.Error("expected MetricID to link to derived metric")
+	}
+	if !got.WakeTime.Equal(newer.WakeTime) {
+		t.Errorf("WakeTime = %v, want %v", got.WakeTime, newer.WakeTime)
+	}
+
+	sessions, err := db.ListSleepSessions(ctx, 0)
+	if err != nil {
+		t.Fatalf("ListSleepSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != newer.ID {
+		t.Fatalf("expected 2 sessions newest first, got %d", len(sessions))
+	}
+
+	if err := RemoveSleepSession(ctx, db, newer.ID.String()); err != nil {
+		t.Fatalf("RemoveSleepSession failed: %v", err)
+	}
+	if _, err := db.GetSleepSession(ctx, newer.ID.String()); err == nil {
+		t.Error("expected session to be deleted")
+	}
+	if _, err := db.GetMetric(ctx, m.ID.String()); err == nil {
+		t.Error("expected derived metric to be deleted")
+	}
+
+	bad := models.NewSleepSession(base, base)
+	if _, err := RecordSleepSession(ctx, db, bad, models.SourceManual); err == nil {
+		t.Error("expected error when wake is not after bed")
+	}
+}
+
+func TestMedications(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	vitD := models.NewMedication("Vitamin D", "1000 IU", "daily")
+	if err := db.CreateMedication(ctx, vitD); err != nil {
+		t.Fatalf("CreateMedication failed: %v", err)
+	}
+	if err := db.CreateMedication(ctx, models.NewMedication("vitamin-d", "2000 IU", "daily")); err == nil {
+		t.Error("expected duplicate name to be rejected")
+	}
+	mag := models.NewMedication("Magnesium", "200 mg", "twice daily").WithNotes("with dinner")
+	if err := db.CreateMedication(ctx, mag); err != nil {
+		t.Fatalf("CreateMedication failed: %v", err)
+	}
+
+	for _, lookup := range []string{"Vitamin D", "vitamin d", "vitamin-d", vitD.ID.String()[:8]} {
+		got, err := db.GetMedication(ctx, lookup)
+		if err != nil || got.ID != vitD.ID {
+			t.Errorf("GetMedication(%q) = %v, %v; want Vitamin D", lookup, got, err)
+		}
+	}
+
+	meds, err := db.ListMedications(ctx)
+	if err != nil {
+		t.Fatalf("ListMedications failed: %v", err)
+	}
+	if len(meds) != 2 || meds[0].Name != "Magnesium" {
+		t.Fatalf("expected 2 medications sorted by name, got %d", len(meds))
+	}
+	if meds[0].Notes == nil || *meds[0].Notes != "with dinner" {
+		t.Error("expected notes to round-trip")
+	}
+
+	base := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	db.LogMedicationIntake(ctx, models.NewMedicationIntake(vitD.ID).WithTakenAt(base.AddDate(0, 0, -1)))
+	db.LogMedicationIntake(ctx, models.NewMedicationIntake(vitD.ID).WithTakenAt(base).WithDose("2000 IU"))
+	db.LogMedicationIntake(ctx, models.NewMedicationIntake(mag.ID).WithTakenAt(base))
+
+	intakes, err := db.ListMedicationIntakes(ctx, IntakeFilter{MedicationID: &vitD.ID})
+	if err != nil {
+		t.Fatalf("ListMedicationIntakes failed: %v", err)
+	}
+	if len(intakes) != 2 || !intakes[0].TakenAt.Equal(base) {
+		t.Fatalf("expected 2 vitamin D intakes newest first, got %d", len(intakes))
+	}
+	if intakes[0].Dose == nil || *intakes[0].Dose != "2000 IU" {
+		t.Error("expected dose override to round-trip")
+	}
+
+	since := base.Add(-time.Hour)
+	recent, _ := db.ListMedicationIntakes(ctx, IntakeFilter{Since: &since})
+	if len(recent) != 2 {
+		t.Errorf("expected 2 intakes since %v, got %d", since, len(recent))
+	}
+
+	if err := db.DeleteMedication(ctx, "vitamin-d"); err != nil {
+		t.Fatalf("DeleteMedication failed: %v", err)
+	}
+	all, _ := db.ListMedicationIntakes(ctx, IntakeFilter{})
+	if len(all) != 1 {
+		t.Errorf("expected intakes to cascade delete, %d remain", len(all))
+	}
+}
+
+func TestLocations(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	gym := models.NewLocation("Gym").WithCoordinates(41.89, -87.62)
+	if err := db.CreateLocation(ctx, gym); err != nil {
+		t.Fatalf("CreateLocation failed: %v", err)
+	}
+	if err := db.CreateLocation(ctx, models.NewLocation("gym")); err == nil {
+		t.Error("expected duplicate name to be rejected")
+	}
+	if err := db.CreateLocation(ctx, models.NewLocation("Home")); err != nil {
+		t.Fatalf("CreateLocation failed: %v", err)
+	}
+
+	got, err := db.GetLocation(ctx, "gym")
+	if err != nil || got.ID != gym.ID {
+		t.Fatalf("GetLocation(gym) = %v, %v", got, err)
+	}
+	if got.Latitude == nil || *got.Latitude != 41.89 {
+		t.Error("expected coordinates to round-trip")
+	}
+
+	tag, err := ResolveLocationTag(ctx, db, "GYM")
+	if err != nil || tag != "Gym" {
+		t.Errorf("ResolveLocationTag(GYM) = %q, %v; want Gym", tag, err)
+	}
+	if tag, err := ResolveLocationTag(ctx, db, "48.85, 2.35"); err != nil || tag != "48.85,2.35" {
+		t.Errorf("ResolveLocationTag(coords) = %q, %v", tag, err)
+	}
+	if _, err := ResolveLocationTag(ctx, db, "moon base"); err == nil {
+		t.Error("expected unknown location to be rejected")
+	}
+
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82).WithLocation("Gym"))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 81))
+	db.CreateWorkout(ctx, models.NewWorkout("lift").WithLocation("Gym"))
+	db.CreateWorkout(ctx, models.NewWorkout("run"))
+
+	loc := "gym"
+	metrics, err := db.QueryMetrics(ctx, MetricFilter{Location: &loc})
+	if err != nil {
+		t.Fatalf("QueryMetrics failed: %v", err)
+	}
+	if len(metrics) != 1 || metrics[0].Location == nil || *metrics[0].Location != "Gym" {
+		t.Errorf("expected 1 metric tagged Gym, got %d", len(metrics))
+	}
+	workouts, err := db.QueryWorkouts(ctx, WorkoutFilter{Location: &loc})
+	if err != nil {
+		t.Fatalf("QueryWorkouts failed: %v", err)
+	}
+	if len(workouts) != 1 || workouts[0].WorkoutType != "lift" {
+		t.Errorf("expected the lift workout, got %d workouts", len(workouts))
+	}
+
+	if err := db.DeleteLocation(ctx, "gym"); err != nil {
+		t.Fatalf("DeleteLocation failed: %v", err)
+	}
+	locs, _ := db.ListLocations(ctx)
+	if len(locs) != 1 || locs[0].Name != "Home" {
+		t.Errorf("expected only Home to remain, got %d locations", len(locs))
+	}
+	// Existing tags survive the registry entry being removed
+	metrics, _ = db.QueryMetrics(ctx, MetricFilter{Location: &loc})
+	if len(metrics) != 1 {
+		t.Error("expected tagged metric to keep its location")
+	}
+}
+
+func TestOpenAddsLocationColumnsToOldDatabase(t *testing.T) {
+	ctx := t.Context()
+	dbPath := filepath.Join(t.TempDir(), "health.db")
+
+	// Databases created before location tagging lack the column.
+	raw, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("open raw db: %v", err)
+	}
+	_, err = raw.Exec(`CREATE TABLE metrics (
+		id TEXT PRIMARY KEY, metric_type TEXT NOT NULL, value REAL NOT NULL,
+		unit TEXT NOT NULL, recorded_at DATETIME NOT NULL, notes TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP)`)
+	if err != nil {
+		t.Fatalf("create old schema: %v", err)
+	}
+	_ = raw.Close()
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed on old database: %v", err)
+	}
+	defer db.Close()
+
+	m := models.NewMetric(models.MetricWeight, 80).WithLocation("home")
+	if err := db.CreateMetric(ctx, m); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	got, err := db.GetMetric(ctx, m.ID.String())
+	if err != nil || got.Location == nil || *got.Location != "home" {
+		t.Errorf("expected location to be stored after upgrade, got %v, %v", got, err)
+	}
+}
+
+func TestSumMetricsAndDailyTotal(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	day := time.Date(2024, 12, 14, 9, 0, 0, 0, time.UTC)
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWater, 250).WithRecordedAt(day))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWater, 500).WithRecordedAt(day.Add(8*time.Hour)))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWater, 300).WithRecordedAt(day.AddDate(0, 0, 1)))
+	db.CreateMetric(ctx, models.NewMetric(models.MetricCalories, 600).WithRecordedAt(day))
+
+	water := models.MetricWater
+	total, err := db.SumMetrics(ctx, MetricFilter{Type: &water})
+	if err != nil {
+		t.Fatalf("SumMetrics failed: %v", err)
+	}
+	if total.Count != 3 || total.Sum != 1050 {
+		t.Errorf("SumMetrics = %+v, want 3 entries totalling 1050", total)
+	}
+
+	daily, err := DailyTotal(ctx, db, models.MetricWater, day)
+	if err != nil {
+		t.Fatalf("DailyTotal failed: %v", err)
+	}
+	if daily.Count != 2 || daily.Sum != 750 {
+		t.Errorf("DailyTotal = %+v, want 2 entries totalling 750", daily)
+	}
+
+	empty, err := DailyTotal(ctx, db, models.MetricProtein, day)
+	if err != nil || empty.Count != 0 || empty.Sum != 0 {
+		t.Errorf("DailyTotal(protein) = %+v, %v; want zero", empty, err)
+	}
+}
+
+func TestAggregateMetrics(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	flat := setupTestMarkdownStore(t)
+	layout, err := ParseMarkdownLayout("flat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flat.SetLayout(layout)
+	for name, r := range map[string]Repository{
+		"sqlite":        setupTestDB(t),
+		"markdown":      setupTestMarkdownStore(t),
+		"markdown flat": flat,
+		"jsonl":         jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			day := time.Date(2024, 12, 14, 9, 0, 0, 0, time.UTC)
+			for i, v := range []float64{82, 84, 83, 90} {
+				r.CreateMetric(ctx, models.NewMetric(models.MetricWeight, v).WithRecordedAt(day.AddDate(0, -i, 0)))
+			}
+			r.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7).WithRecordedAt(day))
+
+			weight := models.MetricWeight
+			since := day.AddDate(0, -2, 0)
+			filter := MetricFilter{Type: &weight, Since: &since, Limit: 1}
+			avg, err := r.AvgMetrics(ctx, filter)
+			if err != nil || avg.Count != 3 || avg.Avg != 83 || avg.Min != 82 || avg.Max != 84 {
+				t.Errorf("AvgMetrics = %+v, %v; want 3 entries averaging 83 (82-84)", avg, err)
+			}
+			total, err := r.SumMetrics(ctx, filter)
+			if err != nil || total.Count != 3 || total.Sum != 249 {
+				t.Errorf("SumMetrics = %+v, %v; want 3 entries totalling 249", total, err)
+			}
+			if n, err := r.CountMetrics(ctx, MetricFilter{Type: &weight}); err != nil || n != 4 {
+				t.Errorf("CountMetrics = %d, %v; want 4", n, err)
+			}
+
+			water := models.MetricWater
+			if empty, err := r.AvgMetrics(ctx, MetricFilter{Type: &water}); err != nil || *empty != (MetricAverage{}) {
+				t.Errorf("AvgMetrics(water) = %+v, %v; want zero", empty, err)
+			}
+		})
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	above, below := 140.0, 2000.0
+	alerts := []models.Alert{
+		{MetricType: models.MetricBPSys, Above: &above},
+		{MetricType: models.MetricWater, Below: &below},
+	}
+
+	day := time.Date(2024, 12, 14, 9, 0, 0, 0, time.UTC)
+	high := models.NewMetric(models.MetricBPSys, 152).WithRecordedAt(day)
+	db.CreateMetric(ctx, high)
+	hits, err := CheckAlerts(ctx, db, alerts, high)
+	if err != nil || len(hits) != 1 || hits[0].Value != 152 {
+		t.Fatalf("CheckAlerts(bp_sys 152) = %+v, %v; want one hit", hits, err)
+	}
+
+	// Cumulative metrics compare the day's running total
+	db.CreateMetric(ctx, models.NewMetric(models.MetricWater, 1500).WithRecordedAt(day))
+	glass := models.NewMetric(models.MetricWater, 600).WithRecordedAt(day.Add(time.Hour))
+	db.CreateMetric(ctx, glass)
+	if hits, _ := CheckAlerts(ctx, db, alerts, glass); len(hits) != 0 {
+		t.Errorf("expected water total 2100 not to be below 2000, got %+v", hits)
+	}
+
+	active, err := ActiveAlerts(ctx, db, alerts, day.Add(2*time.Hour))
+	if err != nil || len(active) != 1 || active[0].Alert.MetricType != models.MetricBPSys {
+		t.Errorf("ActiveAlerts = %+v, %v; want only bp_sys", active, err)
+	}
+
+	// A normal reading clears the bp alert; no water yet today leaves water quiet
+	db.CreateMetric(ctx, models.NewMetric(models.MetricBPSys, 118).WithRecordedAt(day.AddDate(0, 0, 1)))
+	active, _ = ActiveAlerts(ctx, db, alerts, day.AddDate(0, 0, 1).Add(time.Hour))
+	if len(active) != 0 {
+		t.Errorf("expected no active alerts, got %+v", active)
+	}
+}
+
+func TestReminderState(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	last, err := db.GetReminderLastFired(ctx, "log-weight")
+	if err != nil || last != nil {
+		t.Fatalf("GetReminderLastFired before firing = %v, %v; want nil", last, err)
+	}
+
+	first := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	if err := db.SetReminderLastFired(ctx, "log-weight", first); err != nil {
+		t.Fatalf("SetReminderLastFired failed: %v", err)
+	}
+	second := first.AddDate(0, 0, 1)
+	if err := db.SetReminderLastFired(ctx, "log-weight", second); err != nil {
+		t.Fatalf("SetReminderLastFired (update) failed: %v", err)
+	}
+
+	last, err = db.GetReminderLastFired(ctx, "log-weight")
+	if err != nil || last == nil || !last.Equal(second) {
+		t.Errorf("GetReminderLastFired = %v, %v; want %v", last, err, second)
+	}
+}
+
+func TestReminderSnooze(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	if until, err := db.GetReminderSnooze(ctx, "log-weight"); err != nil || until != nil {
+		t.Fatalf("GetReminderSnooze before snoozing = %v, %v; want nil", until, err)
+	}
+
+	until := time.Date(2024, 12, 14, 10, 0, 0, 0, time.UTC)
+	if err := db.SetReminderSnooze(ctx, "log-weight", &until); err != nil {
+		t.Fatalf("SetReminderSnooze failed: %v", err)
+	}
+	got, err := db.GetReminderSnooze(ctx, "log-weight")
+	if err != nil || got == nil || !got.Equal(until) {
+		t.Errorf("GetReminderSnooze = %v, %v; want %v", got, err, until)
+	}
+
+	if err := db.SetReminderSnooze(ctx, "log-weight", nil); err != nil {
+		t.Fatalf("clearing snooze failed: %v", err)
+	}
+	if got, _ := db.GetReminderSnooze(ctx, "log-weight"); got != nil {
+		t.Errorf("snooze = %v after clearing, want nil", got)
+	}
+}
+
+func TestTrips(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	start := time.Date(2024, 12, 10, 9, 0, 0, 0, time.UTC)
+	past := models.NewTrip("Chicago").WithStartedAt(start.AddDate(0, -1, 0))
+	if err := db.CreateTrip(ctx, past); err != nil {
+		t.Fatalf("CreateTrip failed: %v", err)
+	}
+	if err := db.EndTrip(ctx, past.ID.String()[:8], start.AddDate(0, -1, 3)); err != nil {
+		t.Fatalf("EndTrip failed: %v", err)
+	}
+	paris, _ := models.NewTrip("Paris").WithTimezone("Europe/Paris")
+	paris.WithStartedAt(start).WithNotes("work trip")
+	if err := db.CreateTrip(ctx, paris); err != nil {
+		t.Fatalf("CreateTrip failed: %v", err)
+	}
+
+	trips, err := db.ListTrips(ctx, 0)
+	if err != nil || len(trips) != 2 || trips[0].ID != paris.ID {
+		t.Fatalf("ListTrips = %d trips, %v; want Paris first", len(trips), err)
+	}
+	got, err := db.GetTrip(ctx, past.ID.String())
+	if err != nil || got.EndedAt == nil || got.IsActive() {
+		t.Errorf("GetTrip(past) = %+v, %v; want an ended trip", got, err)
+	}
+
+	active, err := ActiveTrip(ctx, db)
+	if err != nil || active == nil || active.ID != paris.ID || *active.Timezone != "Europe/Paris" {
+		t.Fatalf("ActiveTrip = %+v, %v; want Paris", active, err)
+	}
+
+	tag, err := TagFromTrip(ctx, db, nil, start.Add(time.Hour))
+	if err != nil || tag == nil || *tag != "Paris" {
+		t.Errorf("TagFromTrip during trip = %v, %v; want Paris", tag, err)
+	}
+	explicit := "gym"
+	if tag, _ := TagFromTrip(ctx, db, &explicit, start.Add(time.Hour)); tag != &explicit {
+		t.Error("TagFromTrip should keep an explicit tag")
+	}
+	if tag, _ := TagFromTrip(ctx, db, nil, start.AddDate(0, 0, -5)); tag != nil {
+		t.Errorf("TagFromTrip between trips = %v, want nil", *tag)
+	}
+
+	if err := db.DeleteTrip(ctx, paris.ID.String()); err != nil {
+		t.Fatalf("DeleteTrip failed: %v", err)
+	}
+	if active, _ := ActiveTrip(ctx, db); active != nil {
+		t.Error("expected no active trip after delete")
+	}
+}
+
+func TestFasts(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	start := time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC)
+	past := models.NewFast(16).WithStartedAt(start)
+	if err := db.CreateFast(ctx, past); err != nil {
+		t.Fatalf("CreateFast failed: %v", err)
+	}
+	if err := db.EndFast(ctx, past.ID.String()[:8], start.Add(17*time.Hour)); err != nil {
+		t.Fatalf("EndFast failed: %v", err)
+	}
+	running := models.NewFast(18).WithStartedAt(start.AddDate(0, 0, 1)).WithNotes("black coffee ok")
+	if err := db.CreateFast(ctx, running); err != nil {
+		t.Fatalf("CreateFast failed: %v", err)
+	}
+
+	fasts, err := db.ListFasts(ctx, 0)
+	if err != nil || len(fasts) != 2 || fasts[0].ID != running.ID {
+		t.Fatalf("ListFasts = %d fasts, %v; want the running fast first", len(fasts), err)
+	}
+	got, err := db.GetFast(ctx, past.ID.String())
+	if err != nil || got.IsActive() || got.TargetHours != 16 || got.Hours(time.Now()) != 17 {
+		t.Errorf("GetFast(past) = %+v, %v; want a 17 hour fast", got, err)
+	}
+
+	active, err := ActiveFast(ctx, db)
+	if err != nil || active == nil || active.ID != running.ID || *active.Notes != "black coffee ok" {
+		t.Fatalf("ActiveFast = %+v, %v; want the running fast", active, err)
+	}
+
+	if err := db.DeleteFast(ctx, running.ID.String()); err != nil {
+		t.Fatalf("DeleteFast failed: %v", err)
+	}
+	if active, _ := ActiveFast(ctx, db); active != nil {
+		t.Error("expected no active fast after delete")
+	}
+}
+
+func TestAppointments(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	past := models.NewAppointment("Dr. Lee", now.AddDate(0, -1, 0)).WithReason("checkup")
+	soon := models.NewAppointment("Dr. Lee", now.Add(26*time.Hour)).WithDuration(45).WithLocation("Main St Clinic")
+	later := models.NewAppointment("Dentist", now.AddDate(0, 1, 0))
+	for _, a := range []*models.Appointment{later, past, soon} {
+		if err := db.CreateAppointment(ctx, a); err != nil {
+			t.Fatalf("CreateAppointment failed: %v", err)
+		}
+	}
+
+	all, err := db.ListAppointments(ctx, AppointmentFilter{})
+	if err != nil || len(all) != 3 || all[0].ID != past.ID || all[2].ID != later.ID {
+		t.Fatalf("ListAppointments = %d, %v; want three, soonest first", len(all), err)
+	}
+
+	upcoming, err := UpcomingAppointments(ctx, db, now, 1)
+	if err != nil || len(upcoming) != 1 || upcoming[0].ID != soon.ID {
+		t.Fatalf("UpcomingAppointments = %+v, %v; want the next one", upcoming, err)
+	}
+	if *upcoming[0].DurationMinutes != 45 || *upcoming[0].Location != "Main St Clinic" {
+		t.Errorf("expected duration and location to round-trip, got %+v", upcoming[0])
+	}
+
+	if err := db.SetAppointmentSummary(ctx, past.ID.String()[:8], "BP fine, recheck in 6 months"); err != nil {
+		t.Fatalf("SetAppointmentSummary failed: %v", err)
+	}
+	got, err := db.GetAppointment(ctx, past.ID.String())
+	if err != nil || got.Summary == nil || *got.Summary != "BP fine, recheck in 6 months" || *got.Reason != "checkup" {
+		t.Errorf("GetAppointment after summary = %+v, %v", got, err)
+	}
+
+	if err := db.DeleteAppointment(ctx, later.ID.String()); err != nil {
+		t.Fatalf("DeleteAppointment failed: %v", err)
+	}
+	if all, _ := db.ListAppointments(ctx, AppointmentFilter{}); len(all) != 2 {
+		t.Errorf("expected 2 appointments after delete, got %d", len(all))
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			marathon := models.NewEvent("Chicago Marathon", time.Date(2026, 10, 11, 0, 0, 0, 0, time.UTC)).
+				WithWorkoutType("run").WithWeeklyMinutes(300).WithWeeklyKm(55.5).WithNotes("sub 4h")
+			tenK := models.NewEvent("Turkey Trot 10k", time.Date(2026, 11, 26, 0, 0, 0, 0, time.UTC))
+			for _, e := range []*models.Event{tenK, marathon} {
+				if err := r.CreateEvent(ctx, e); err != nil {
+					t.Fatalf("CreateEvent failed: %v", err)
+				}
+			}
+
+			all, err := r.ListEvents(ctx)
+			if err != nil || len(all) != 2 || all[0].ID != marathon.ID {
+				t.Fatalf("ListEvents = %d, %v; want two, soonest first", len(all), err)
+			}
+
+			got, err := r.GetEvent(ctx, marathon.ID.String()[:8])
+			if err != nil {
+				t.Fatalf("GetEvent failed: %v", err)
+			}
+			if got.Name != "Chicago Marathon" || !got.Date.Equal(marathon.Date) || *got.WorkoutType != "run" ||
+				*got.WeeklyMinutes != 300 || *got.WeeklyKm != 55.5 || *got.Notes != "sub 4h" {
+				t.Errorf("GetEvent = %+v, want the plan to round-trip", got)
+			}
+			if got, _ := r.GetEvent(ctx, tenK.ID.String()); got.HasPlan() || got.WorkoutType != nil {
+				t.Errorf("expected no plan on the 10k, got %+v", got)
+			}
+
+			if err := r.DeleteEvent(ctx, tenK.ID.String()[:8]); err != nil {
+				t.Fatalf("DeleteEvent failed: %v", err)
+			}
+			if all, _ := r.ListEvents(ctx); len(all) != 1 {
+				t.Errorf("expected 1 event after delete, got %d", len(all))
+			}
+			if _, err := r.GetEvent(ctx, tenK.ID.String()); err == nil {
+				t.Error("expected deleted event not found")
+			}
+		})
+	}
+}
+
+func TestBloodPressureReadings(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	at := time.Date(2024, 12, 14, 8, 0, 0, 0, time.UTC)
+	sys, dia := models.NewBloodPressure(120, 80, at)
+	if err := RecordBloodPressure(ctx, db, sys, dia); err != nil {
+		t.Fatalf("RecordBloodPressure failed: %v", err)
+	}
+	db.CreateMetric(ctx, models.NewMetric(models.MetricHeartRate, 60).WithRecordedAt(at))
+
+	got, err := db.GetMetric(ctx, dia.ID.String())
+	if err != nil || got.ReadingID == nil || *got.ReadingID != *sys.ReadingID {
+		t.Fatalf("expected reading ID to round-trip, got %+v, %v", got, err)
+	}
+
+	partner, err := ReadingPartner(ctx, db, got)
+	if err != nil || partner == nil || partner.ID != sys.ID {
+		t.Errorf("ReadingPartner = %v, %v; want the systolic half", partner, err)
+	}
+
+	dType := models.MetricBPDia
+	matching, err := ReadingsMatching(ctx, db, MetricFilter{Type: &dType})
+	if err != nil || len(matching) != 2 {
+		t.Errorf("ReadingsMatching bp_dia = %d metrics, %v; want both halves", len(matching), err)
+	}
+
+	deleted, err := DeleteReading(ctx, db, sys.ID.String()[:8])
+	if err != nil || len(deleted) != 2 {
+		t.Fatalf("DeleteReading = %d metrics, %v; want both halves", len(deleted), err)
+	}
+	remaining, _ := db.ListMetrics(ctx, nil, 0)
+	if len(remaining) != 1 || remaining[0].MetricType != models.MetricHeartRate {
+		t.Errorf("expected only heart rate to remain, got %d metrics", len(remaining))
+	}
+}
+
+func TestWorkoutComments(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	w := models.NewWorkout("run")
+	if err := db.CreateWorkout(ctx, w); err != nil {
+		t.Fatalf("CreateWorkout failed: %v", err)
+	}
+
+	first := models.NewWorkoutComment(w.ID, "Coach Sam", "Great negative split")
+	first.CreatedAt = time.Now().Add(-time.Hour)
+	if err := db.AddWorkoutComment(ctx, first); err != nil {
+		t.Fatalf("AddWorkoutComment failed: %v", err)
+	}
+	if err := db.AddWorkoutComment(ctx, models.NewWorkoutComment(w.ID, "me", "Legs felt heavy")); err != nil {
+		t.Fatalf("AddWorkoutComment failed: %v", err)
+	}
+
+	full, err := db.GetWorkoutWithMetrics(ctx, w.ID.String())
+	if err != nil {
+		t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+	}
+	if len(full.Comments) != 2 || full.Comments[0].Author != "Coach Sam" {
+		t.Fatalf("Expected 2 comments oldest first, got %+v", full.Comments)
+	}
+
+	// Comments cascade with the workout
+	if err := db.DeleteWorkout(ctx, w.ID.String()); err != nil {
+		t.Fatalf("DeleteWorkout failed: %v", err)
+	}
+	comments, err := db.ListWorkoutComments(ctx, w.ID)
+	if err != nil {
+		t.Fatalf("ListWorkoutComments failed: %v", err)
+	}
+	if len(comments) != 0 {
+		t.Errorf("Expected comments deleted with workout, got %d", len(comments))
+	}
+}
+
+func TestStatus(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	old := models.NewMetric(models.MetricWeight, 82)
+	old.CreatedAt = now.AddDate(0, -3, 0)
+	db.CreateMetric(ctx, old)
+	db.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7).WithNotes("short"))
+	db.CreateWorkout(ctx, models.NewWorkout("run").WithNotes(strings.Repeat("x", NoteSizeWarn+1)))
+
+	st, err := Status(ctx, db, now)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if st.Backend != "sqlite" || st.SizeBytes == 0 {
+		t.Errorf("Expected sqlite size on disk, got %+v", st)
+	}
+	if st.Metrics != 2 || st.Workouts != 1 || st.Recent != 2 {
+		t.Errorf("Expected 2 metrics, 1 workout, 2 recent; got %d, %d, %d", st.Metrics, st.Workouts, st.Recent)
+	}
+	if len(st.LargestNotes) != 2 || st.LargestNotes[0].Kind != "workout" {
+		t.Errorf("Expected workout note first, got %+v", st.LargestNotes)
+	}
+	if len(st.Warnings) != 1 || !strings.Contains(st.Warnings[0], "KB note") {
+		t.Errorf("Expected one large-note warning, got %v", st.Warnings)
+	}
+}
+
+func TestCreateMetricsAndWorkoutsBatch(t *testing.T) {
+	jsonl, _ := setupTestJSONLStore(t)
+	backends := map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	}
+
+	for name, r := range backends {
+		t.Run(name, func(t *testing.T) {
+			ctx := t.Context()
+			base := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+			var metrics []*models.Metric
+			for i := 0; i < 50; i++ {
+				metrics = append(metrics, models.NewMetric(models.MetricSteps, float64(i)).WithRecordedAt(base.Add(time.Duration(i)*time.Hour)))
+			}
+			if err := r.CreateMetrics(ctx, metrics); err != nil {
+				t.Fatalf("CreateMetrics failed: %v", err)
+			}
+			var workouts []*models.Workout
+			for i := 0; i < 5; i++ {
+				workouts = append(workouts, models.NewWorkout("run").WithStartedAt(base.AddDate(0, 0, i)))
+			}
+			if err := r.CreateWorkouts(ctx, workouts); err != nil {
+				t.Fatalf("CreateWorkouts failed: %v", err)
+			}
+
+			gotMetrics, _ := r.ListMetrics(ctx, nil, 0)
+			gotWorkouts, _ := r.ListWorkouts(ctx, nil, 0)
+			if len(gotMetrics) != 50 || len(gotWorkouts) != 5 {
+				t.Errorf("Stored %d metrics and %d workouts, want 50 and 5", len(gotMetrics), len(gotWorkouts))
+			}
+			if got, err := r.GetMetric(ctx, metrics[7].ID.String()[:8]); err != nil || got.Value != 7 {
+				t.Errorf("GetMetric after batch = %v, %v", got, err)
+			}
+			if err := r.CreateMetrics(ctx, nil); err != nil {
+				t.Errorf("CreateMetrics(nil) = %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateMetricsIsAtomicInSQLite(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	dup := models.NewMetric(models.MetricWeight, 80)
+	db.CreateMetric(ctx, dup)
+
+	fresh := models.NewMetric(models.MetricWeight, 81)
+	if err := db.CreateMetrics(ctx, []*models.Metric{fresh, dup}); err == nil {
+		t.Fatal("Expected error inserting a duplicate ID")
+	}
+	if _, err := db.GetMetric(ctx, fresh.ID.String()); err == nil {
+		t.Error("Expected the whole batch to roll back")
+	}
+}
+
+func TestQueriesHonourCancelledContext(t *testing.T) {
+	backends := map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+	}
+	for name, r := range backends {
+		t.Run(name, func(t *testing.T) {
+			r.CreateMetric(t.Context(), models.NewMetric(models.MetricWeight, 80))
+			r.CreateWorkout(t.Context(), models.NewWorkout("run"))
+
+			ctx, cancel := context.WithCancel(t.Context())
+			cancel()
+			if _, err := r.QueryMetrics(ctx, MetricFilter{}); !errors.Is(err, context.Canceled) {
+				t.Errorf("QueryMetrics with cancelled context = %v, want context.Canceled", err)
+			}
+			if _, err := r.QueryWorkouts(ctx, WorkoutFilter{}); !errors.Is(err, context.Canceled) {
+				t.Errorf("QueryWorkouts with cancelled context = %v, want context.Canceled", err)
+			}
+		})
+	}
+}
+
+func TestMetricTimesStoredInUTC(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	defer db.Close()
+
+	chicago := time.FixedZone("CST", -6*3600)
+	late := models.NewMetric(models.MetricWeight, 82.5).WithRecordedAt(time.Date(2025, 3, 1, 23, 0, 0, 0, chicago))
+	if err := db.CreateMetric(ctx, late); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	var stored string
+	if err := db.db.QueryRow("SELECT recorded_at FROM metrics WHERE id = ?", late.ID.String()).Scan(&stored); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if stored != "2025-03-02T05:00:00Z" {
+		t.Errorf("recorded_at = %q, want UTC", stored)
+	}
+
+	// Rows from older versions kept the local offset, which sorts wrongly
+	// as text; upgrading a database from then rewrites them
+	next := models.NewMetric(models.MetricWeight, 82).WithRecordedAt(time.Date(2025, 3, 2, 6, 0, 0, 0, time.UTC))
+	if err := db.CreateMetric(ctx, next); err != nil {
+		t.Fatalf("CreateMetric failed: %v", err)
+	}
+	if _, err := db.db.Exec("UPDATE metrics SET recorded_at = ? WHERE id = ?", "2025-03-02T00:00:00-06:00", next.ID.String()); err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if err := normalizeTimes(ctx, db.db); err != nil {
+		t.Fatalf("normalizeTimes failed: %v", err)
+	}
+	metrics, err := db.ListMetrics(ctx, nil, 0)
+	if err != nil {
+		t.Fatalf("ListMetrics failed: %v", err)
+	}
+	if len(metrics) != 2 || metrics[0].ID != next.ID || !metrics[0].RecordedAt.Equal(next.RecordedAt) {
+		t.Errorf("metrics out of order after normalizing: %v, %v", metrics[0].RecordedAt, metrics[1].RecordedAt)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	ctx := t.Context()
+	db := setupTestDB(t)
+	for _, v := range []float64{80, 82} {
+		if err := db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, v)); err != nil {
+			t.Fatalf("CreateMetric failed: %v", err)
+		}
+	}
+
+	res, err := db.Query(ctx, "SELECT metric_type, avg(value) AS avg, count(*) FROM metrics GROUP BY 1;")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(res.Columns) != 3 || res.Columns[1] != "avg" {
+		t.Errorf("columns = %v", res.Columns)
+	}
+	if len(res.Rows) != 1 || res.Rows[0][0] != "weight" || res.Rows[0][1] != 81.0 || res.Rows[0][2] != int64(2) {
+		t.Errorf("rows = %v, want [[weight 81 2]]", res.Rows)
+	}
+
+	if _, err := db.Query(ctx, "DELETE FROM metrics"); err == nil {
+		t.Error("expected a DELETE to be refused")
+	}
+	if _, err := db.Query(ctx, "WITH x AS (SELECT 1) DELETE FROM metrics"); err == nil {
+		t.Error("expected a write inside WITH to be refused")
+	}
+	// A stacked write may error or be ignored, but must not run
+	_, _ = db.Query(ctx, "SELECT 1; DELETE FROM metrics")
+	metrics, err := db.ListMetrics(ctx, nil, 0)
+	if err != nil || len(metrics) != 2 {
+		t.Errorf("metrics after refused writes = %d (%v), want 2", len(metrics), err)
+	}
+
+	// The pooled connection must be writable again afterwards
+	if err := db.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 83)); err != nil {
+		t.Errorf("CreateMetric after Query failed: %v", err)
+	}
+}
+
+func TestSourceAttribution(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			r.CreateMetric(ctx, models.NewMetric(models.MetricWeight, 82).WithSource(models.SourceManual))
+			r.CreateMetric(ctx, models.NewMetric(models.MetricSteps, 8000).WithSource(models.SourceFitbit))
+			r.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7)) // from before sources
+			r.CreateWorkout(ctx, models.NewWorkout("run").WithSource(models.SourceMCP))
+			r.CreateWorkout(ctx, models.NewWorkout("lift"))
+
+			src := models.SourceFitbit
+			metrics, err := r.QueryMetrics(ctx, MetricFilter{Source: &src})
+			if err != nil || len(metrics) != 1 || metrics[0].MetricType != models.MetricSteps || metrics[0].Source != src {
+				t.Errorf("QueryMetrics(source=fitbit) = %v, %v", metrics, err)
+			}
+			if n, _ := r.CountMetrics(ctx, MetricFilter{Source: &src}); n != 1 {
+				t.Errorf("CountMetrics(source=fitbit) = %d, want 1", n)
+			}
+			src = models.SourceMCP
+			workouts, err := r.QueryWorkouts(ctx, WorkoutFilter{Source: &src})
+			if err != nil || len(workouts) != 1 || workouts[0].WorkoutType != "run" || workouts[0].Source != src {
+				t.Errorf("QueryWorkouts(source=mcp) = %v, %v", workouts, err)
+			}
+
+			mood := models.MetricMood
+			old, _ := r.ListMetrics(ctx, &mood, 0)
+			if len(old) != 1 || old[0].Source != "" {
+				t.Errorf("expected a metric without a source to read back without one, got %+v", old)
+			}
+		})
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := models.NewMetric(models.MetricWeight, 82).WithMetadata("device", "Body+").WithMetadata("withings_grpid", "501")
+			r.CreateMetric(ctx, m)
+			r.CreateMetric(ctx, models.NewMetric(models.MetricMood, 7))
+			w := models.NewWorkout("ride").WithMetadata("gpx", "rides/0412.gpx")
+			r.CreateWorkout(ctx, w)
+
+			got, err := r.GetMetric(ctx, m.ID.String())
+			if err != nil || !maps.Equal(got.Metadata, m.Metadata) {
+				t.Errorf("GetMetric metadata = %v, %v; want %v", got, err, m.Metadata)
+			}
+			mood := models.MetricMood
+			plain, _ := r.ListMetrics(ctx, &mood, 0)
+			if len(plain) != 1 || plain[0].Metadata != nil {
+				t.Errorf("expected a metric without metadata to read back without it, got %+v", plain)
+			}
+			gotW, err := r.GetWorkoutWithMetrics(ctx, w.ID.String())
+			if err != nil || gotW.Metadata["gpx"] != "rides/0412.gpx" {
+				t.Errorf("GetWorkoutWithMetrics metadata = %v, %v", gotW, err)
+			}
+
+			r.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 30, "km"))
+			if err := r.SetWorkoutMetadata(ctx, w.ID.String()[:8], "route", "attachments/r.gpx"); err != nil {
+				t.Fatalf("SetWorkoutMetadata failed: %v", err)
+			}
+			if err := r.SetWorkoutMetadata(ctx, w.ID.String(), "gpx", ""); err != nil {
+				t.Fatalf("SetWorkoutMetadata (remove) failed: %v", err)
+			}
+			gotW, _ = r.GetWorkoutWithMetrics(ctx, w.ID.String())
+			if !maps.Equal(gotW.Metadata, map[string]string{"route": "attachments/r.gpx"}) || len(gotW.Metrics) != 1 {
+				t.Errorf("after SetWorkoutMetadata: metadata %v, %d metrics", gotW.Metadata, len(gotW.Metrics))
+			}
+			if err := r.SetWorkoutMetadata(ctx, "ffffffff", "k", "v"); err == nil {
+				t.Error("expected an error for an unknown workout")
+			}
+
+			if err := r.SetMetricMetadata(ctx, m.ID.String()[:8], "attachment:lunch.jpg", "attachments/metrics/x/lunch.jpg"); err != nil {
+				t.Fatalf("SetMetricMetadata failed: %v", err)
+			}
+			if err := r.SetMetricMetadata(ctx, m.ID.String(), "device", ""); err != nil {
+				t.Fatalf("SetMetricMetadata (remove) failed: %v", err)
+			}
+			got, _ = r.GetMetric(ctx, m.ID.String())
+			want := map[string]string{"withings_grpid": "501", "attachment:lunch.jpg": "attachments/metrics/x/lunch.jpg"}
+			if !maps.Equal(got.Metadata, want) || got.Value != 82 {
+				t.Errorf("after SetMetricMetadata: %+v, want metadata %v", got, want)
+			}
+			if err := r.SetMetricMetadata(ctx, "ffffffff", "k", "v"); err == nil {
+				t.Error("expected an error for an unknown metric")
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			m := models.NewMetric(models.MetricWeight, 82)
+			m.ID = uuid.MustParse("aaaa0000-0000-4000-8000-000000000001")
+			r.CreateMetric(ctx, m)
+			w := models.NewWorkout("run")
+			w.ID = uuid.MustParse("aaaa1111-0000-4000-8000-000000000002")
+			r.CreateWorkout(ctx, w)
+			wm := models.NewWorkoutMetric(w.ID, "distance", 5, "km")
+			wm.ID = uuid.MustParse("bbbb0000-0000-4000-8000-000000000003")
+			r.AddWorkoutMetric(ctx, wm)
+			for _, id := range []string{"cccc0000-0000-4000-8000-000000000004", "cccc1111-0000-4000-8000-000000000005"} {
+				other := models.NewMetric(models.MetricMood, 7)
+				other.ID = uuid.MustParse(id)
+				r.CreateMetric(ctx, other)
+			}
+
+			for prefix, want := range map[string]string{
+				"aaaa0":       KindMetric,
+				"aaaa1":       KindWorkout,
+				"bbbb":        KindWorkoutMetric,
+				w.ID.String(): KindWorkout,
+			} {
+				e, err := Resolve(ctx, r, prefix)
+				if err != nil || e.Kind != want {
+					t.Errorf("Resolve(%s) = %+v, %v; want a %s", prefix, e, err, want)
+				}
+			}
+			if e, _ := Resolve(ctx, r, "aaaa0"); e.Metric == nil || e.Metric.ID != m.ID {
+				t.Errorf("expected the metric set, got %+v", e)
+			}
+			if e, _ := Resolve(ctx, r, "bbbb"); e.WorkoutMetric == nil || e.WorkoutMetric.WorkoutID != w.ID {
+				t.Errorf("expected the workout metric set, got %+v", e)
+			}
+
+			for _, prefix := range []string{"aaaa", "cccc"} {
+				if _, err := Resolve(ctx, r, prefix); !errors.Is(err, ErrAmbiguous) {
+					t.Errorf("Resolve(%s) = %v, want ErrAmbiguous", prefix, err)
+				}
+			}
+			if _, err := Resolve(ctx, r, "aaaa"); err == nil || !strings.Contains(err.Error(), "a metric and a workout") {
+				t.Errorf("expected the kinds named, got %v", err)
+			}
+			if _, err := Resolve(ctx, r, "dddd"); err == nil || errors.Is(err, ErrAmbiguous) {
+				t.Errorf("expected not found, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateMetric(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			at := time.Date(2025, 4, 12, 8, 0, 0, 0, time.UTC)
+			m := models.NewMetric(models.MetricSteps, 450).WithRecordedAt(at).WithMetadata("device", "watch")
+			r.CreateMetric(ctx, m)
+
+			if err := m.Retype(models.MetricCalories); err != nil {
+				t.Fatal(err)
+			}
+			m.WithRecordedAt(at.AddDate(0, -1, 0)).WithNotes("lunch")
+			if err := r.UpdateMetric(ctx, m); err != nil {
+				t.Fatalf("UpdateMetric failed: %v", err)
+			}
+
+			got, err := r.GetMetric(ctx, m.ID.String())
+			if err != nil || got.MetricType != models.MetricCalories || got.Unit != "kcal" || got.Value != 450 ||
+				!got.RecordedAt.Equal(m.RecordedAt) || got.Notes == nil || got.Metadata["device"] != "watch" {
+				t.Errorf("GetMetric after update = %+v, %v", got, err)
+			}
+			steps := models.MetricSteps
+			if old, _ := r.ListMetrics(ctx, &steps, 0); len(old) != 0 {
+				t.Errorf("expected no steps left, got %+v", old)
+			}
+			calories := models.MetricCalories
+			if moved, _ := r.ListMetrics(ctx, &calories, 0); len(moved) != 1 {
+				t.Errorf("expected the entry listed as calories, got %+v", moved)
+			}
+
+			if err := r.UpdateMetric(ctx, models.NewMetric(models.MetricMood, 5)); err == nil {
+				t.Error("expected an error updating an unknown metric")
+			}
+		})
+	}
+}
+
+func TestUpdateWorkout(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			at := time.Date(2025, 4, 12, 8, 0, 0, 0, time.UTC)
+			w := models.NewWorkout("run").WithStartedAt(at).WithDuration(30)
+			r.CreateWorkout(ctx, w)
+			r.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "distance", 5, "km"))
+			r.AddWorkoutSet(ctx, models.NewWorkoutSet(w.ID, "strides", 1, 4))
+			r.AddWorkoutComment(ctx, models.NewWorkoutComment(w.ID, "coach", "easy pace"))
+
+			// The caller's copy has no children; updating mustn't drop them
+			w.WorkoutType = "trail run"
+			w.WithStartedAt(at.AddDate(0, -1, 0)).WithDuration(45).WithRPE(7).WithNotes("muddy")
+			if err := r.UpdateWorkout(ctx, w); err != nil {
+				t.Fatalf("UpdateWorkout failed: %v", err)
+			}
+
+			got, err := r.GetWorkoutWithMetrics(ctx, w.ID.String())
+			if err != nil {
+				t.Fatalf("GetWorkoutWithMetrics failed: %v", err)
+			}
+			if got.WorkoutType != "trail run" || !got.StartedAt.Equal(w.StartedAt) || *got.DurationMinutes != 45 ||
+				got.RPE == nil || *got.RPE != 7 || got.Notes == nil || *got.Notes != "muddy" {
+				t.Errorf("GetWorkoutWithMetrics after update = %+v", got)
+			}
+			if len(got.Metrics) != 1 || len(got.Sets) != 1 || len(got.Comments) != 1 {
+				t.Errorf("expected children kept, got %d metrics, %d sets, %d comments",
+					len(got.Metrics), len(got.Sets), len(got.Comments))
+			}
+			if all, _ := r.ListWorkouts(ctx, nil, 0); len(all) != 1 {
+				t.Errorf("expected one workout after the update, got %d", len(all))
+			}
+
+			data, err := GetAllDataFromRepo(ctx, r)
+			if err != nil || len(data.Workouts) != 1 || data.Workouts[0].WorkoutType != "trail run" || len(data.Workouts[0].Metrics) != 1 {
+				t.Errorf("export after update = %+v, %v", data, err)
+			}
+
+			if err := r.UpdateWorkout(ctx, models.NewWorkout("swim")); err == nil {
+				t.Error("expected an error updating an unknown workout")
+			}
+		})
+	}
+}
+
+func TestUpdateWorkoutMetric(t *testing.T) {
+	ctx := t.Context()
+	jsonl, path := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := models.NewWorkout("ride")
+			r.CreateWorkout(ctx, w)
+			wm := models.NewWorkoutMetric(w.ID, "distance", 20, "km")
+			r.AddWorkoutMetric(ctx, wm)
+			r.AddWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "avg_hr", 140, "bpm"))
+
+			wm.Value = 21.4
+			if err := r.UpdateWorkoutMetric(ctx, wm); err != nil {
+				t.Fatalf("UpdateWorkoutMetric failed: %v", err)
+			}
+			if name == "jsonl" {
+				// The update is replayed from the log, not appended twice
+				r = reopenJSONL(t, jsonl, path)
+			}
+
+			metrics, err := r.ListWorkoutMetrics(ctx, w.ID)
+			if err != nil || len(metrics) != 2 {
+				t.Fatalf("ListWorkoutMetrics = %+v, %v", metrics, err)
+			}
+			got, err := r.GetWorkoutMetric(ctx, wm.ID.String())
+			if err != nil || got.Value != 21.4 || got.Unit == nil || *got.Unit != "km" {
+				t.Errorf("GetWorkoutMetric after update = %+v, %v", got, err)
+			}
+
+			data, err := GetAllDataFromRepo(ctx, r)
+			if err != nil || len(data.Workouts) != 1 || len(data.Workouts[0].Metrics) != 2 {
+				t.Fatalf("export after update = %+v, %v", data, err)
+			}
+			for _, m := range data.Workouts[0].Metrics {
+				if m.ID == wm.ID && m.Value != 21.4 {
+					t.Errorf("expected the export to carry the update, got %v", m.Value)
+				}
+			}
+
+			other := models.NewWorkout("swim")
+			r.CreateWorkout(ctx, other)
+			moved := *wm
+			moved.WorkoutID = other.ID
+			if err := r.UpdateWorkoutMetric(ctx, &moved); err == nil {
+				t.Error("expected an error updating a metric on the wrong workout")
+			}
+			if err := r.UpdateWorkoutMetric(ctx, models.NewWorkoutMetric(w.ID, "cadence", 85, "rpm")); err == nil {
+				t.Error("expected an error updating an unknown workout metric")
+			}
+		})
+	}
+}
+
+func TestWorkoutIntensityRoundTrip(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := models.NewWorkout("run").WithRPE(7).WithZone(3)
+			r.CreateWorkout(ctx, w)
+			r.CreateWorkout(ctx, models.NewWorkout("walk"))
+
+			got, err := r.GetWorkout(ctx, w.ID.String())
+			if err != nil || got.RPE == nil || *got.RPE != 7 || got.Zone == nil || *got.Zone != 3 {
+				t.Errorf("GetWorkout = %+v, %v; want RPE 7, zone 3", got, err)
+			}
+			walk := "walk"
+			plain, _ := r.QueryWorkouts(ctx, WorkoutFilter{Type: &walk})
+			if len(plain) != 1 || plain[0].RPE != nil || plain[0].Zone != nil {
+				t.Errorf("expected a workout without intensity to read back without it, got %+v", plain)
+			}
+		})
+	}
+}
+
+func TestFindDuplicate(t *testing.T) {
+	ctx := t.Context()
+	jsonl, _ := setupTestJSONLStore(t)
+	for name, r := range map[string]Repository{
+		"sqlite":   setupTestDB(t),
+		"markdown": setupTestMarkdownStore(t),
+		"jsonl":    jsonl,
+	} {
+		t.Run(name, func(t *testing.T) {
+			at := time.Date(2025, 4, 12, 8, 0, 0, 0, time.UTC)
+			far := models.NewMetric(models.MetricWeight, 81).WithRecordedAt(at.Add(-time.Hour))
+			near := models.NewMetric(models.MetricWeight, 82).WithRecordedAt(at.Add(-4 * time.Minute))
+			r.CreateMetric(ctx, far)
+			r.CreateMetric(ctx, near)
+			r.CreateMetric(ctx, models.NewMetric(models.MetricWater, 250).WithRecordedAt(at))
+			windows := models.DefaultDuplicateWindows()
+
+			got, err := FindDuplicate(ctx, r, windows, models.NewMetric(models.MetricWeight, 83).WithRecordedAt(at))
+			if err != nil || got == nil || got.ID != near.ID {
+				t.Errorf("FindDuplicate = %+v, %v; want the entry 4 minutes earlier", got, err)
+			}
+			if got, _ := FindDuplicate(ctx, r, windows, models.NewMetric(models.MetricWeight, 83).WithRecordedAt(at.Add(time.Hour))); got != nil {
+				t.Errorf("FindDuplicate an hour later = %+v; want none", got)
+			}
+			if got, _ := FindDuplicate(ctx, r, windows, models.NewMetric(models.MetricWater, 250).WithRecordedAt(at)); got != nil {
+				t.Errorf("FindDuplicate for a running total = %+v; want none", got)
+			}
+			windows.ByType[models.MetricWeight] = 2 * time.Hour
+			if got, _ := FindDuplicate(ctx, r, windows, models.NewMetric(models.MetricWeight, 83).WithRecordedAt(at.Add(time.Hour))); got == nil || got.ID != near.ID {
+				t.Errorf("FindDuplicate with a 2h window = %+v; want the nearer entry", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// ABOUTME: Resolves an ID or prefix to a record of any kind.
+// ABOUTME: Lets top-level commands act on metrics, workouts, and workout metrics alike.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// ErrAmbiguous is wrapped by lookups whose ID prefix matches more than one
+// record.
+var ErrAmbiguous = errors.New("matches multiple records")
+
+// Kinds of records Resolve finds.
+const (
+	KindMetric        = "metric"
+	KindWorkout       = "workout"
+	KindWorkoutMetric = "workout metric"
+)
+
+// Entity is the record an ID resolved to. The field matching Kind is set.
+type Entity struct {
+	Kind          string
+	Metric        *models.Metric
+	Workout       *models.Workout
+	WorkoutMetric *models.WorkoutMetric
+}
+
+// Resolve finds the metric, workout, or workout metric with the given ID
+// or unique ID prefix. A prefix matching records of more than one kind,
+// or several records of one kind, is an error wrapping ErrAmbiguous.
+func Resolve(ctx context.Context, r Repository, idOrPrefix string) (*Entity, error) {
+	var found []*Entity
+	var kinds []string
+	add := func(e *Entity, err error) error {
+		switch {
+		case err == nil:
+			found = append(found, e)
+			kinds = append(kinds, "a "+e.Kind)
+		case errors.Is(err, ErrAmbiguous):
+			return err
+		}
+		return nil
+	}
+
+	m, err := r.GetMetric(ctx, idOrPrefix)
+	if err := add(&Entity{Kind: KindMetric, Metric: m}, err); err != nil {
+		return nil, fmt.Errorf("%w (several metrics)", err)
+	}
+	w, err := r.GetWorkout(ctx, idOrPrefix)
+	if err := add(&Entity{Kind: KindWorkout, Workout: w}, err); err != nil {
+		return nil, fmt.Errorf("%w (several workouts)", err)
+	}
+	wm, err := r.GetWorkoutMetric(ctx, idOrPrefix)
+	if err := add(&Entity{Kind: KindWorkoutMetric, WorkoutMetric: wm}, err); err != nil {
+		return nil, fmt.Errorf("%w (several workout metrics)", err)
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("not found: %s", idOrPrefix)
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf("ambiguous prefix %s: %w (%s)", idOrPrefix, ErrAmbiguous, strings.Join(kinds, " and "))
+}
@@ -1,46 +1,95 @@
-// ABOUTME: SQLite schema definition and initialization.
-// ABOUTME: Defines tables for metrics, workouts, and workout_metrics.
+// ABOUTME: SQLite schema initialization through versioned migrations.
+// ABOUTME: Also brings databases from before versioning up to the baseline.
 package storage
 
-// initSchema creates or updates the database schema.
+import (
+	"context"
+	"fmt"
+)
+
+// initSchema brings the database up to the latest schema version.
 func (d *DB) initSchema() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS metrics (
-		id TEXT PRIMARY KEY,
-		metric_type TEXT NOT NULL,
-		value REAL NOT NULL,
-		unit TEXT NOT NULL,
-		recorded_at DATETIME NOT NULL,
-		notes TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS workouts (
-		id TEXT PRIMARY KEY,
-		workout_type TEXT NOT NULL,
-		started_at DATETIME NOT NULL,
-		duration_minutes INTEGER,
-		notes TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS workout_metrics (
-		id TEXT PRIMARY KEY,
-		workout_id TEXT NOT NULL,
-		metric_name TEXT NOT NULL,
-		value REAL NOT NULL,
-		unit TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (workout_id) REFERENCES workouts(id) ON DELETE CASCADE
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_metrics_type ON metrics(metric_type);
-	CREATE INDEX IF NOT EXISTS idx_metrics_recorded ON metrics(recorded_at DESC);
-	CREATE INDEX IF NOT EXISTS idx_metrics_type_recorded ON metrics(metric_type, recorded_at DESC);
-	CREATE INDEX IF NOT EXISTS idx_workouts_started ON workouts(started_at DESC);
-	CREATE INDEX IF NOT EXISTS idx_workout_metrics_workout ON workout_metrics(workout_id);
-	`
-
-	_, err := d.db.Exec(schema)
-	return err
+	return d.migrate(context.Background(), schemaMigrations)
+}
+
+// legacyColumns were added before schema versioning, by checking for each
+// on open; CREATE TABLE IF NOT EXISTS leaves databases from then without
+// them.
+var legacyColumns = []struct{ table, column, definition string }{
+	{"metrics", "location", "TEXT"},
+	{"metrics", "reading_id", "TEXT"},
+	{"workouts", "location", "TEXT"},
+	{"metrics", "source", "TEXT NOT NULL DEFAULT ''"},
+	{"workouts", "source", "TEXT NOT NULL DEFAULT ''"},
+	{"metrics", "metadata", "TEXT"},
+	{"workouts", "metadata", "TEXT"},
+	{"workouts", "rpe", "INTEGER"},
+	{"workouts", "zone", "INTEGER"},
+}
+
+// upgradeLegacy runs with the baseline migration, bringing a database
+// from before versioning up to it: missing columns are added and local
+// timestamps converted to UTC. On a new database it changes nothing.
+func upgradeLegacy(ctx context.Context, tx execer) error {
+	for _, c := range legacyColumns {
+		if err := addColumnIfMissing(ctx, tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return normalizeTimes(ctx, tx)
+}
+
+// utcColumns are the timestamps entries are ordered and ranged by.
+var utcColumns = []struct{ table, column string }{
+	{"metrics", "recorded_at"},
+	{"workouts", "started_at"},
+	{"sleep_sessions", "bed_time"},
+	{"sleep_sessions", "wake_time"},
+	{"medication_intakes", "taken_at"},
+	{"trips", "started_at"},
+	{"trips", "ended_at"},
+	{"fasts", "started_at"},
+	{"fasts", "ended_at"},
+}
+
+// normalizeTimes rewrites timestamps stored with a local offset, as older
+// versions did, in UTC so they sort and compare as plain strings.
+func normalizeTimes(ctx context.Context, tx execer) error {
+	for _, c := range utcColumns {
+		query := fmt.Sprintf(`UPDATE %[1]s SET %[2]s = strftime('%%Y-%%m-%%dT%%H:%%M:%%SZ', %[2]s)
+			WHERE %[2]s GLOB '*[+-][0-9][0-9]:[0-9][0-9]'`, c.table, c.column)
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("normalize %s.%s: %w", c.table, c.column, err)
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already there.
+func addColumnIfMissing(ctx context.Context, tx execer, table, column, definition string) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt any
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return fmt.Errorf("inspect %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("inspect %s: %w", table, err)
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("add %s.%s: %w", table, column, err)
+	}
+	return nil
 }
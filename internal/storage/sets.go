@@ -0,0 +1,77 @@
+// ABOUTME: WorkoutSet operations for SQLite storage.
+// ABOUTME: Stores strength-training sets (exercise, reps, load) per workout.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// AddWorkoutSet stores a new workout set in the database.
+func (d *DB) AddWorkoutSet(ctx context.Context, ws *models.WorkoutSet) error {
+	query := `
+		INSERT INTO workout_sets (id, workout_id, exercise, set_number, reps, weight, weight_unit, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := d.db.ExecContext(ctx, query,
+		ws.ID.String(),
+		ws.WorkoutID.String(),
+		ws.Exercise,
+		ws.SetNumber,
+		ws.Reps,
+		ws.Weight,
+		ws.WeightUnit,
+		ws.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("add workout set: %w", err)
+	}
+	return nil
+}
+
+// ListWorkoutSets retrieves all sets for a specific workout in the order they were logged.
+func (d *DB) ListWorkoutSets(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutSet, error) {
+	query := `
+		SELECT id, workout_id, exercise, set_number, reps, weight, weight_unit, created_at
+		FROM workout_sets
+		WHERE workout_id = ?
+		ORDER BY created_at ASC, rowid ASC
+	`
+	rows, err := d.db.QueryContext(ctx, query, workoutID.String())
+	if err != nil {
+		return nil, fmt.Errorf("list workout sets: %w", err)
+	}
+	defer rows.Close()
+
+	var sets []*models.WorkoutSet
+	for rows.Next() {
+		var ws models.WorkoutSet
+		var idStr, workoutIDStr, createdAt string
+		var weight sql.NullFloat64
+		var weightUnit sql.NullString
+
+		err := rows.Scan(&idStr, &workoutIDStr, &ws.Exercise, &ws.SetNumber, &ws.Reps, &weight, &weightUnit, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan workout set: %w", err)
+		}
+
+		ws.ID, _ = uuid.Parse(idStr)
+		ws.WorkoutID, _ = uuid.Parse(workoutIDStr)
+		ws.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if weight.Valid {
+			ws.Weight = &weight.Float64
+		}
+		if weightUnit.Valid {
+			ws.WeightUnit = &weightUnit.String
+		}
+
+		sets = append(sets, &ws)
+	}
+
+	return sets, rows.Err()
+}
@@ -0,0 +1,226 @@
+// ABOUTME: Sleep session CRUD operations for SQLite storage.
+// ABOUTME: Implements Repository interface methods for sleep sessions.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateSleepSession stores a new sleep session in the database.
+func (d *DB) CreateSleepSession(ctx context.Context, s *models.SleepSession) error {
+	query := `
+		INSERT INTO sleep_sessions (id, bed_time, wake_time, awakenings, quality, metric_id, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	var metricID *string
+	if s.MetricID != nil {
+		id := s.MetricID.String()
+		metricID = &id
+	}
+	_, err := d.db.ExecContext(ctx, query,
+		s.ID.String(),
+		s.BedTime.UTC().Format(time.RFC3339),
+		s.WakeTime.UTC().Format(time.RFC3339),
+		s.Awakenings,
+		s.Quality,
+		metricID,
+		s.Notes,
+		s.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create sleep session: %w", err)
+	}
+	return nil
+}
+
+// GetSleepSession retrieves a sleep session by ID or ID prefix.
+func (d *DB) GetSleepSession(ctx context.Context, idOrPrefix string) (*models.SleepSession, error) {
+	id, err := d.resolveSleepSessionID(ctx, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, bed_time, wake_time, awakenings, quality, metric_id, notes, created_at
+		FROM sleep_sessions
+		WHERE id = ?
+	`
+	s, err := scanSleepSession(d.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("not found")
+	}
+	return s, err
+}
+
+// ListSleepSessions retrieves sleep sessions sorted by WakeTime descending.
+func (d *DB) ListSleepSessions(ctx context.Context, limit int) ([]*models.SleepSession, error) {
+	query := `
+		SELECT id, bed_time, wake_time, awakenings, quality, metric_id, notes, created_at
+		FROM sleep_sessions
+		ORDER BY wake_time DESC
+	`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list sleep sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.SleepSession
+	for rows.Next() {
+		s, err := scanSleepSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// DeleteSleepSession removes a sleep session by ID or prefix.
+func (d *DB) DeleteSleepSession(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveSleepSessionID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete sleep session: %w", err)
+	}
+
+	result, err := d.db.ExecContext(ctx, "DELETE FROM sleep_sessions WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete sleep session: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete sleep session: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("not found: %s", idOrPrefix)
+	}
+
+	return nil
+}
+
+// resolveSleepSessionID finds the full ID from a prefix.
+func (d *DB) resolveSleepSessionID(ctx context.Context, idOrPrefix string) (string, error) {
+	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
+		return idOrPrefix, nil
+	}
+
+	query := `SELECT id FROM sleep_sessions WHERE id LIKE ? || '%'`
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
+	if err != nil {
+		return "", fmt.Errorf("resolve sleep session ID: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan sleep session ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+	}
+
+	return matches[0], nil
+}
+
+// scanSleepSession scans a row from either QueryRow or Query into a SleepSession.
+func scanSleepSession(row interface{ Scan(dest ...any) error }) (*models.SleepSession, error) {
+	var s models.SleepSession
+	var idStr, bedTime, wakeTime, createdAt string
+	var awakenings, quality sql.NullInt64
+	var metricID, notes sql.NullString
+
+	err := row.Scan(&idStr, &bedTime, &wakeTime, &awakenings, &quality, &metricID, &notes, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan sleep session: %w", err)
+	}
+
+	s.ID, _ = uuid.Parse(idStr)
+	s.BedTime, _ = time.Parse(time.RFC3339, bedTime)
+	s.WakeTime, _ = time.Parse(time.RFC3339, wakeTime)
+	s.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if awakenings.Valid {
+		n := int(awakenings.Int64)
+		s.Awakenings = &n
+	}
+	if quality.Valid {
+		q := int(quality.Int64)
+		s.Quality = &q
+	}
+	if metricID.Valid {
+		if id, err := uuid.Parse(metricID.String); err == nil {
+			s.MetricID = &id
+		}
+	}
+	if notes.Valid {
+		s.Notes = &notes.String
+	}
+
+	return &s, nil
+}
+
+// RecordSleepSession stores a sleep session in any Repository along with its
+// derived sleep_hours metric, linking the two through MetricID. The metric
+// is attributed to source.
+func RecordSleepSession(ctx context.Context, r Repository, s *models.SleepSession, source string) (*models.Metric, error) {
+	if !s.WakeTime.After(s.BedTime) {
+		return nil, fmt.Errorf("wake time must be after bed time")
+	}
+
+	m := s.HoursMetric().WithSource(source)
+	if err := r.CreateMetric(ctx, m); err != nil {
+		return nil, fmt.Errorf("create sleep_hours metric: %w", err)
+	}
+	s.MetricID = &m.ID
+
+	if err := r.CreateSleepSession(ctx, s); err != nil {
+		_ = r.DeleteMetric(ctx, m.ID.String())
+		return nil, err
+	}
+	return m, nil
+}
+
+// RemoveSleepSession deletes a sleep session and its derived sleep_hours
+// metric from any Repository. A derived metric that was already deleted
+// by hand is not an error.
+func RemoveSleepSession(ctx context.Context, r Repository, idOrPrefix string) error {
+	s, err := r.GetSleepSession(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete sleep session: %w", err)
+	}
+	if err := r.DeleteSleepSession(ctx, s.ID.String()); err != nil {
+		return err
+	}
+	if s.MetricID != nil {
+		if _, err := r.GetMetric(ctx, s.MetricID.String()); err == nil {
+			if err := r.DeleteMetric(ctx, s.MetricID.String()); err != nil {
+				return fmt.Errorf("delete sleep_hours metric: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// ABOUTME: Storage health report: size on disk, record counts, large notes, and growth.
+// ABOUTME: Warns when the markdown store grows past the point where full scans get slow.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// MarkdownFileWarn is the file count past which the markdown store,
+	// which reads every file to answer a query, gets noticeably slow.
+	MarkdownFileWarn = 5000
+
+	// NoteSizeWarn is the size past which a single note is flagged.
+	NoteSizeWarn = 16 * 1024
+
+	// growthWindow is how far back Status looks to measure growth.
+	growthWindow = 30 * 24 * time.Hour
+
+	// largestNotes is how many notes Status lists.
+	largestNotes = 5
+)
+
+// NoteSize is the size of one entry's free-text notes.
+type NoteSize struct {
+	Kind  string // metric, workout, sleep, appointment
+	ID    uuid.UUID
+	Bytes int
+}
+
+// StoreStatus describes how big the store is and how fast it is growing.
+type StoreStatus struct {
+	Backend      string // sqlite, markdown, or jsonl
+	Path         string
+	SizeBytes    int64
+	Files        int // markdown files; zero for sqlite
+	Metrics      int
+	Workouts     int
+	Recent       int // metrics and workouts created in the last 30 days
+	LargestNotes []NoteSize
+	Warnings     []string
+}
+
+// MonthlyGrowthBytes estimates how much the store grows per 30 days,
+// assuming recent entries are about the size of older ones.
+func (s *StoreStatus) MonthlyGrowthBytes() int64 {
+	total := s.Metrics + s.Workouts
+	if total == 0 {
+		return 0
+	}
+	return s.SizeBytes * int64(s.Recent) / int64(total)
+}
+
+// Status reports on the store behind r. Only the built-in backends report
+// size on disk.
+func Status(ctx context.Context, r Repository, now time.Time) (*StoreStatus, error) {
+	st := &StoreStatus{}
+	switch store := r.(type) {
+	case *DB:
+		st.Backend, st.Path = "sqlite", store.dbPath
+		st.SizeBytes = store.sizeOnDisk()
+	case *MarkdownStore:
+		st.Backend, st.Path = "markdown", store.dataDir
+		err := filepath.WalkDir(store.dataDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			st.SizeBytes += info.Size()
+			if strings.HasSuffix(path, ".md") {
+				st.Files++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walk markdown store: %w", err)
+		}
+	case *JSONLStore:
+		st.Backend, st.Path = "jsonl", store.path
+		if info, err := os.Stat(store.path); err == nil {
+			st.SizeBytes = info.Size()
+		}
+	case *MemoryStore:
+		st.Backend = "memory"
+	}
+
+	since := now.Add(-growthWindow)
+	var notes []NoteSize
+	addNote := func(kind string, id uuid.UUID, text *string) {
+		if text != nil && *text != "" {
+			notes = append(notes, NoteSize{Kind: kind, ID: id, Bytes: len(*text)})
+		}
+	}
+
+	metrics, err := r.ListMetrics(ctx, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list metrics: %w", err)
+	}
+	st.Metrics = len(metrics)
+	for _, m := range metrics {
+		if m.CreatedAt.After(since) {
+			st.Recent++
+		}
+		addNote("metric", m.ID, m.Notes)
+	}
+
+	workouts, err := r.ListWorkouts(ctx, nil, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list workouts: %w", err)
+	}
+	st.Workouts = len(workouts)
+	for _, w := range workouts {
+		if w.CreatedAt.After(since) {
+			st.Recent++
+		}
+		addNote("workout", w.ID, w.Notes)
+	}
+
+	sessions, err := r.ListSleepSessions(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("list sleep sessions: %w", err)
+	}
+	for _, ss := range sessions {
+		addNote("sleep", ss.ID, ss.Notes)
+	}
+
+	appts, err := r.ListAppointments(ctx, AppointmentFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("list appointments: %w", err)
+	}
+	for _, a := range appts {
+		addNote("appointment", a.ID, a.Summary)
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool { return notes[i].Bytes > notes[j].Bytes })
+	if len(notes) > largestNotes {
+		notes = notes[:largestNotes]
+	}
+	st.LargestNotes = notes
+
+	st.Warnings = st.warnings()
+	return st, nil
+}
+
+// warnings lists soft-quota problems: a markdown store that is (or will
+// soon be) too big to scan quickly, and oversized notes.
+func (s *StoreStatus) warnings() []string {
+	var warnings []string
+	if s.Backend == "markdown" {
+		// Roughly one file per metric or workout
+		projected := s.Files + 3*s.Recent
+		switch {
+		case s.Files > MarkdownFileWarn:
+			warnings = append(warnings, fmt.Sprintf(
+				"%d markdown files; every query reads them all, so consider 'health migrate --to sqlite'", s.Files))
+		case projected > MarkdownFileWarn:
+			warnings = append(warnings, fmt.Sprintf(
+				"at the current rate the markdown store passes %d files within 90 days; consider 'health migrate --to sqlite'",
+				MarkdownFileWarn))
+		}
+	}
+	for _, n := range s.LargestNotes {
+		if n.Bytes > NoteSizeWarn {
+			warnings = append(warnings, fmt.Sprintf(
+				"%s %s has a %d KB note; long documents are better kept as files", n.Kind, n.ID.String()[:8], n.Bytes/1024))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,235 @@
+// ABOUTME: Trip CRUD operations for SQLite storage and travel-mode helpers.
+// ABOUTME: Finds the trip covering a time so entries can be tagged with its destination.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/harperreed/health/internal/models"
+)
+
+// CreateTrip stores a new trip in the database.
+func (d *DB) CreateTrip(ctx context.Context, t *models.Trip) error {
+	query := `
+		INSERT INTO trips (id, destination, timezone, started_at, ended_at, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	var endedAt *string
+	if t.EndedAt != nil {
+		s := t.EndedAt.UTC().Format(time.RFC3339)
+		endedAt = &s
+	}
+	_, err := d.db.ExecContext(ctx, query,
+		t.ID.String(),
+		t.Destination,
+		t.Timezone,
+		t.StartedAt.UTC().Format(time.RFC3339),
+		endedAt,
+		t.Notes,
+		t.CreatedAt.UTC().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("create trip: %w", err)
+	}
+	return nil
+}
+
+// GetTrip retrieves a trip by ID or ID prefix.
+func (d *DB) GetTrip(ctx context.Context, idOrPrefix string) (*models.Trip, error) {
+	id, err := d.resolveTripID(ctx, idOrPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, destination, timezone, started_at, ended_at, notes, created_at
+		FROM trips
+		WHERE id = ?
+	`
+	t, err := scanTrip(d.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("not found")
+	}
+	return t, err
+}
+
+// ListTrips retrieves trips sorted by StartedAt descending.
+func (d *DB) ListTrips(ctx context.Context, limit int) ([]*models.Trip, error) {
+	query := `
+		SELECT id, destination, timezone, started_at, ended_at, notes, created_at
+		FROM trips
+		ORDER BY started_at DESC
+	`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []*models.Trip
+	for rows.Next() {
+		t, err := scanTrip(rows)
+		if err != nil {
+			return nil, err
+		}
+		trips = append(trips, t)
+	}
+	return trips, rows.Err()
+}
+
+// EndTrip records when a trip ended.
+func (d *DB) EndTrip(ctx context.Context, idOrPrefix string, endedAt time.Time) error {
+	id, err := d.resolveTripID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("end trip: %w", err)
+	}
+
+	if _, err := d.db.ExecContext(ctx, "UPDATE trips SET ended_at = ? WHERE id = ?", endedAt.UTC().Format(time.RFC3339), id); err != nil {
+		return fmt.Errorf("end trip: %w", err)
+	}
+	return nil
+}
+
+// DeleteTrip removes a trip by ID or prefix. Entries tagged during the trip
+// keep their tag.
+func (d *DB) DeleteTrip(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveTripID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("delete trip: %w", err)
+	}
+
+	result, err := d.db.ExecContext(ctx, "DELETE FROM trips WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("delete trip: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete trip: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("not found: %s", idOrPrefix)
+	}
+
+	return nil
+}
+
+// resolveTripID finds the full ID from a prefix.
+func (d *DB) resolveTripID(ctx context.Context, idOrPrefix string) (string, error) {
+	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
+		return idOrPrefix, nil
+	}
+
+	query := `SELECT id FROM trips WHERE id LIKE ? || '%'`
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
+	if err != nil {
+		return "", fmt.Errorf("resolve trip ID: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return "", fmt.Errorf("scan trip ID: %w", err)
+		}
+		matches = append(matches, id)
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("not found: %s", idOrPrefix)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
+	}
+
+	return matches[0], nil
+}
+
+// scanTrip scans a row from either QueryRow or Query into a Trip.
+func scanTrip(row interface{ Scan(dest ...any) error }) (*models.Trip, error) {
+	var t models.Trip
+	var idStr, startedAt, createdAt string
+	var timezone, endedAt, notes sql.NullString
+
+	err := row.Scan(&idStr, &t.Destination, &timezone, &startedAt, &endedAt, &notes, &createdAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan trip: %w", err)
+	}
+
+	t.ID, _ = uuid.Parse(idStr)
+	t.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
+	t.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	if timezone.Valid {
+		t.Timezone = &timezone.String
+	}
+	if endedAt.Valid {
+		if e, err := time.Parse(time.RFC3339, endedAt.String); err == nil {
+			t.EndedAt = &e
+		}
+	}
+	if notes.Valid {
+		t.Notes = &notes.String
+	}
+
+	return &t, nil
+}
+
+// ActiveTrip returns the trip that has been started but not ended, or nil.
+func ActiveTrip(ctx context.Context, r Repository) (*models.Trip, error) {
+	trips, err := r.ListTrips(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trips {
+		if t.IsActive() {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// TripAt returns the trip covering at, or nil when at is not during a trip.
+func TripAt(ctx context.Context, r Repository, at time.Time) (*models.Trip, error) {
+	trips, err := r.ListTrips(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range trips {
+		if t.Covers(at) {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// TagFromTrip tags an untagged entry recorded at the given time with the
+// destination of the trip covering it. It returns the tag to use, which is
+// the existing one when set.
+func TagFromTrip(ctx context.Context, r Repository, tag *string, at time.Time) (*string, error) {
+	if tag != nil {
+		return tag, nil
+	}
+	t, err := TripAt(ctx, r, at)
+	if err != nil {
+		return nil, fmt.Errorf("look up trip: %w", err)
+	}
+	if t == nil {
+		return nil, nil
+	}
+	return &t.Destination, nil
+}
@@ -3,6 +3,7 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -12,49 +13,86 @@ import (
 	"github.com/harperreed/health/internal/models"
 )
 
-// CreateWorkout stores a new workout in the database.
-func (d *DB) CreateWorkout(w *models.Workout) error {
-	query := `
-		INSERT INTO workouts (id, workout_type, started_at, duration_minutes, notes, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
-	_, err := d.db.Exec(query,
+// insertWorkoutSQL inserts one workout row; workoutArgs supplies its values.
+const insertWorkoutSQL = `
+	INSERT INTO workouts (id, workout_type, started_at, duration_minutes, notes, location, source, metadata, rpe, zone, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+func workoutArgs(w *models.Workout) []any {
+	return []any{
 		w.ID.String(),
 		w.WorkoutType,
-		w.StartedAt.Format(time.RFC3339),
+		w.StartedAt.UTC().Format(time.RFC3339),
 		w.DurationMinutes,
 		w.Notes,
-		w.CreatedAt.Format(time.RFC3339),
-	)
-	if err != nil {
+		w.Location,
+		w.Source,
+		encodeMetadata(w.Metadata),
+		w.RPE,
+		w.Zone,
+		w.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// CreateWorkout stores a new workout in the database.
+func (d *DB) CreateWorkout(ctx context.Context, w *models.Workout) error {
+	if _, err := d.db.ExecContext(ctx, insertWorkoutSQL, workoutArgs(w)...); err != nil {
 		return fmt.Errorf("create workout: %w", err)
 	}
 	return nil
 }
 
+// CreateWorkouts stores many workouts in one transaction with a single
+// prepared statement. Like CreateWorkout it stores only the workouts
+// themselves, not their children. Either all are stored or none are.
+func (d *DB) CreateWorkouts(ctx context.Context, workouts []*models.Workout) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, insertWorkoutSQL)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, w := range workouts {
+		if _, err := stmt.ExecContext(ctx, workoutArgs(w)...); err != nil {
+			return fmt.Errorf("create workout %s: %w", w.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
 // GetWorkout retrieves a workout by ID or ID prefix (without metrics).
-func (d *DB) GetWorkout(idOrPrefix string) (*models.Workout, error) {
-	id, err := d.resolveWorkoutID(idOrPrefix)
+func (d *DB) GetWorkout(ctx context.Context, idOrPrefix string) (*models.Workout, error) {
+	id, err := d.resolveWorkoutID(ctx, idOrPrefix)
 	if err != nil {
 		return nil, err
 	}
 
 	query := `
-		SELECT id, workout_type, started_at, duration_minutes, notes, created_at
+		SELECT id, workout_type, started_at, duration_minutes, notes, location, source, metadata, rpe, zone, created_at
 		FROM workouts
 		WHERE id = ?
 	`
-	return d.scanWorkout(d.db.QueryRow(query, id))
+	return d.scanWorkout(d.db.QueryRowContext(ctx, query, id))
 }
 
-// GetWorkoutWithMetrics retrieves a workout with all its associated metrics.
-func (d *DB) GetWorkoutWithMetrics(idOrPrefix string) (*models.Workout, error) {
-	w, err := d.GetWorkout(idOrPrefix)
+// GetWorkoutWithMetrics retrieves a workout with all its associated metrics, sets, and comments.
+func (d *DB) GetWorkoutWithMetrics(ctx context.Context, idOrPrefix string) (*models.Workout, error) {
+	w, err := d.GetWorkout(ctx, idOrPrefix)
 	if err != nil {
 		return nil, err
 	}
 
-	metrics, err := d.ListWorkoutMetrics(w.ID)
+	metrics, err := d.ListWorkoutMetrics(ctx, w.ID)
 	if err != nil {
 		return nil, fmt.Errorf("list workout metrics: %w", err)
 	}
@@ -63,37 +101,52 @@ func (d *DB) GetWorkoutWithMetrics(idOrPrefix string) (*models.Workout, error) {
 		w.Metrics = append(w.Metrics, *m)
 	}
 
+	sets, err := d.ListWorkoutSets(ctx, w.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list workout sets: %w", err)
+	}
+
+	for _, ws := range sets {
+		w.Sets = append(w.Sets, *ws)
+	}
+
+	comments, err := d.ListWorkoutComments(ctx, w.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list workout comments: %w", err)
+	}
+
+	for _, c := range comments {
+		w.Comments = append(w.Comments, *c)
+	}
+
 	return w, nil
 }
 
 // ListWorkouts retrieves workouts with optional filtering by type.
 // Results are sorted by StartedAt descending (most recent first).
-func (d *DB) ListWorkouts(workoutType *string, limit int) ([]*models.Workout, error) {
-	var query string
-	var args []interface{}
-
-	if workoutType != nil {
-		query = `
-			SELECT id, workout_type, started_at, duration_minutes, notes, created_at
-			FROM workouts
-			WHERE LOWER(workout_type) = LOWER(?)
-			ORDER BY started_at DESC
-		`
-		args = append(args, *workoutType)
-	} else {
-		query = `
-			SELECT id, workout_type, started_at, duration_minutes, notes, created_at
-			FROM workouts
-			ORDER BY started_at DESC
-		`
-	}
-
-	if limit > 0 {
-		query += " LIMIT ?"
-		args = append(args, limit)
-	}
-
-	rows, err := d.db.Query(query, args...)
+func (d *DB) ListWorkouts(ctx context.Context, workoutType *string, limit int) ([]*models.Workout, error) {
+	return d.QueryWorkouts(ctx, WorkoutFilter{Type: workoutType, Limit: limit})
+}
+
+// QueryWorkouts retrieves workouts matching the filter.
+// Results are sorted by StartedAt descending (most recent first).
+func (d *DB) QueryWorkouts(ctx context.Context, filter WorkoutFilter) ([]*models.Workout, error) {
+	where, args := workoutWhere(filter)
+	query := `
+		SELECT id, workout_type, started_at, duration_minutes, notes, location, source, metadata, rpe, zone, created_at
+		FROM workouts
+	` + where + " ORDER BY started_at DESC"
+
+	if filter.Limit > 0 || filter.Offset > 0 {
+		limit := filter.Limit
+		if limit <= 0 {
+			limit = -1 // SQLite: no upper bound
+		}
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, limit, filter.Offset)
+	}
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("list workouts: %w", err)
 	}
@@ -102,15 +155,57 @@ func (d *DB) ListWorkouts(workoutType *string, limit int) ([]*models.Workout, er
 	return d.scanWorkouts(rows)
 }
 
+// CountWorkouts counts the workouts matching filter, ignoring its Limit
+// and Offset.
+func (d *DB) CountWorkouts(ctx context.Context, filter WorkoutFilter) (int, error) {
+	where, args := workoutWhere(filter)
+	var n int
+	if err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM workouts"+where, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count workouts: %w", err)
+	}
+	return n, nil
+}
+
+// workoutWhere builds the WHERE clause for a filter's type, location,
+// source, and time range.
+func workoutWhere(filter WorkoutFilter) (string, []any) {
+	var conds []string
+	var args []any
+	if filter.Type != nil {
+		conds = append(conds, "LOWER(workout_type) = LOWER(?)")
+		args = append(args, *filter.Type)
+	}
+	if filter.Location != nil {
+		conds = append(conds, "location = ? COLLATE NOCASE")
+		args = append(args, *filter.Location)
+	}
+	if filter.Source != nil {
+		conds = append(conds, "source = ?")
+		args = append(args, *filter.Source)
+	}
+	if filter.Since != nil {
+		conds = append(conds, "datetime(started_at) >= datetime(?)")
+		args = append(args, filter.Since.UTC().Format(time.RFC3339))
+	}
+	if filter.Until != nil {
+		conds = append(conds, "datetime(started_at) < datetime(?)")
+		args = append(args, filter.Until.UTC().Format(time.RFC3339))
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
 // DeleteWorkout removes a workout and all its metrics (cascade delete).
-func (d *DB) DeleteWorkout(idOrPrefix string) error {
-	id, err := d.resolveWorkoutID(idOrPrefix)
+func (d *DB) DeleteWorkout(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveWorkoutID(ctx, idOrPrefix)
 	if err != nil {
 		return fmt.Errorf("delete workout: %w", err)
 	}
 
 	// CASCADE is enabled, so deleting the workout deletes its metrics
-	result, err := d.db.Exec("DELETE FROM workouts WHERE id = ?", id)
+	result, err := d.db.ExecContext(ctx, "DELETE FROM workouts WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("delete workout: %w", err)
 	}
@@ -126,19 +221,58 @@ func (d *DB) DeleteWorkout(idOrPrefix string) error {
 	return nil
 }
 
+// SetWorkoutMetadata sets one metadata key on a workout, or removes it
+// when value is empty.
+func (d *DB) SetWorkoutMetadata(ctx context.Context, idOrPrefix, key, value string) error {
+	id, err := d.resolveWorkoutID(ctx, idOrPrefix)
+	if err != nil {
+		return fmt.Errorf("set workout metadata: %w", err)
+	}
+
+	var metadata sql.NullString
+	if err := d.db.QueryRowContext(ctx, "SELECT metadata FROM workouts WHERE id = ?", id).Scan(&metadata); err != nil {
+		return fmt.Errorf("set workout metadata: %w", err)
+	}
+	md := withMetadataKey(decodeMetadata(metadata), key, value)
+	if _, err := d.db.ExecContext(ctx, "UPDATE workouts SET metadata = ? WHERE id = ?", encodeMetadata(md), id); err != nil {
+		return fmt.Errorf("set workout metadata: %w", err)
+	}
+	return nil
+}
+
+// UpdateWorkout replaces the stored workout that has w's ID with w's own
+// fields; its metrics, sets, and comments are left as they are.
+func (d *DB) UpdateWorkout(ctx context.Context, w *models.Workout) error {
+	query := `
+		UPDATE workouts
+		SET workout_type = ?, started_at = ?, duration_minutes = ?, notes = ?, location = ?,
+			source = ?, metadata = ?, rpe = ?, zone = ?, created_at = ?
+		WHERE id = ?
+	`
+	args := append(workoutArgs(w)[1:], w.ID.String())
+	res, err := d.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update workout: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("update workout: not found: %s", w.ID)
+	}
+	return nil
+}
+
 // AddWorkoutMetric stores a new workout metric in the database.
-func (d *DB) AddWorkoutMetric(wm *models.WorkoutMetric) error {
+func (d *DB) AddWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
 	query := `
 		INSERT INTO workout_metrics (id, workout_id, metric_name, value, unit, created_at)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	_, err := d.db.Exec(query,
+	_, err := d.db.ExecContext(ctx, query,
 		wm.ID.String(),
 		wm.WorkoutID.String(),
 		wm.MetricName,
 		wm.Value,
 		wm.Unit,
-		wm.CreatedAt.Format(time.RFC3339),
+		wm.CreatedAt.UTC().Format(time.RFC3339),
 	)
 	if err != nil {
 		return fmt.Errorf("add workout metric: %w", err)
@@ -146,9 +280,34 @@ func (d *DB) AddWorkoutMetric(wm *models.WorkoutMetric) error {
 	return nil
 }
 
+// UpdateWorkoutMetric replaces the stored metric that has wm's ID, on the
+// workout wm.WorkoutID, with wm.
+func (d *DB) UpdateWorkoutMetric(ctx context.Context, wm *models.WorkoutMetric) error {
+	query := `
+		UPDATE workout_metrics
+		SET metric_name = ?, value = ?, unit = ?, created_at = ?
+		WHERE id = ? AND workout_id = ?
+	`
+	res, err := d.db.ExecContext(ctx, query,
+		wm.MetricName,
+		wm.Value,
+		wm.Unit,
+		wm.CreatedAt.UTC().Format(time.RFC3339),
+		wm.ID.String(),
+		wm.WorkoutID.String(),
+	)
+	if err != nil {
+		return fmt.Errorf("update workout metric: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("update workout metric: not found: %s on workout %s", wm.ID, wm.WorkoutID)
+	}
+	return nil
+}
+
 // GetWorkoutMetric retrieves a workout metric by ID or ID prefix.
-func (d *DB) GetWorkoutMetric(idOrPrefix string) (*models.WorkoutMetric, error) {
-	id, err := d.resolveWorkoutMetricID(idOrPrefix)
+func (d *DB) GetWorkoutMetric(ctx context.Context, idOrPrefix string) (*models.WorkoutMetric, error) {
+	id, err := d.resolveWorkoutMetricID(ctx, idOrPrefix)
 	if err != nil {
 		return nil, err
 	}
@@ -158,18 +317,18 @@ func (d *DB) GetWorkoutMetric(idOrPrefix string) (*models.WorkoutMetric, error)
 		FROM workout_metrics
 		WHERE id = ?
 	`
-	return d.scanWorkoutMetric(d.db.QueryRow(query, id))
+	return d.scanWorkoutMetric(d.db.QueryRowContext(ctx, query, id))
 }
 
 // ListWorkoutMetrics retrieves all workout metrics for a specific workout.
-func (d *DB) ListWorkoutMetrics(workoutID uuid.UUID) ([]*models.WorkoutMetric, error) {
+func (d *DB) ListWorkoutMetrics(ctx context.Context, workoutID uuid.UUID) ([]*models.WorkoutMetric, error) {
 	query := `
 		SELECT id, workout_id, metric_name, value, unit, created_at
 		FROM workout_metrics
 		WHERE workout_id = ?
 		ORDER BY created_at ASC
 	`
-	rows, err := d.db.Query(query, workoutID.String())
+	rows, err := d.db.QueryContext(ctx, query, workoutID.String())
 	if err != nil {
 		return nil, fmt.Errorf("list workout metrics: %w", err)
 	}
@@ -179,13 +338,13 @@ func (d *DB) ListWorkoutMetrics(workoutID uuid.UUID) ([]*models.WorkoutMetric, e
 }
 
 // DeleteWorkoutMetric removes a workout metric by ID or prefix.
-func (d *DB) DeleteWorkoutMetric(idOrPrefix string) error {
-	id, err := d.resolveWorkoutMetricID(idOrPrefix)
+func (d *DB) DeleteWorkoutMetric(ctx context.Context, idOrPrefix string) error {
+	id, err := d.resolveWorkoutMetricID(ctx, idOrPrefix)
 	if err != nil {
 		return fmt.Errorf("delete workout metric: %w", err)
 	}
 
-	result, err := d.db.Exec("DELETE FROM workout_metrics WHERE id = ?", id)
+	result, err := d.db.ExecContext(ctx, "DELETE FROM workout_metrics WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("delete workout metric: %w", err)
 	}
@@ -202,13 +361,13 @@ func (d *DB) DeleteWorkoutMetric(idOrPrefix string) error {
 }
 
 // resolveWorkoutID finds the full ID from a prefix.
-func (d *DB) resolveWorkoutID(idOrPrefix string) (string, error) {
+func (d *DB) resolveWorkoutID(ctx context.Context, idOrPrefix string) (string, error) {
 	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
 		return idOrPrefix, nil
 	}
 
 	query := `SELECT id FROM workouts WHERE id LIKE ? || '%'`
-	rows, err := d.db.Query(query, idOrPrefix)
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
 	if err != nil {
 		return "", fmt.Errorf("resolve workout ID: %w", err)
 	}
@@ -227,20 +386,20 @@ func (d *DB) resolveWorkoutID(idOrPrefix string) (string, error) {
 		return "", fmt.Errorf("not found: %s", idOrPrefix)
 	}
 	if len(matches) > 1 {
-		return "", fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
 	}
 
 	return matches[0], nil
 }
 
 // resolveWorkoutMetricID finds the full ID from a prefix.
-func (d *DB) resolveWorkoutMetricID(idOrPrefix string) (string, error) {
+func (d *DB) resolveWorkoutMetricID(ctx context.Context, idOrPrefix string) (string, error) {
 	if len(idOrPrefix) == 36 && strings.Count(idOrPrefix, "-") == 4 {
 		return idOrPrefix, nil
 	}
 
 	query := `SELECT id FROM workout_metrics WHERE id LIKE ? || '%'`
-	rows, err := d.db.Query(query, idOrPrefix)
+	rows, err := d.db.QueryContext(ctx, query, idOrPrefix)
 	if err != nil {
 		return "", fmt.Errorf("resolve workout metric ID: %w", err)
 	}
@@ -259,7 +418,7 @@ func (d *DB) resolveWorkoutMetricID(idOrPrefix string) (string, error) {
 		return "", fmt.Errorf("not found: %s", idOrPrefix)
 	}
 	if len(matches) > 1 {
-		return "", fmt.Errorf("ambiguous prefix %s: matches multiple records", idOrPrefix)
+		return "", fmt.Errorf("ambiguous prefix %s: %w", idOrPrefix, ErrAmbiguous)
 	}
 
 	return matches[0], nil
@@ -269,10 +428,10 @@ func (d *DB) resolveWorkoutMetricID(idOrPrefix string) (string, error) {
 func (d *DB) scanWorkout(row *sql.Row) (*models.Workout, error) {
 	var w models.Workout
 	var idStr, startedAt, createdAt string
-	var durationMinutes sql.NullInt64
-	var notes sql.NullString
+	var durationMinutes, rpe, zone sql.NullInt64
+	var notes, location, metadata sql.NullString
 
-	err := row.Scan(&idStr, &w.WorkoutType, &startedAt, &durationMinutes, &notes, &createdAt)
+	err := row.Scan(&idStr, &w.WorkoutType, &startedAt, &durationMinutes, &notes, &location, &w.Source, &metadata, &rpe, &zone, &createdAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("not found")
@@ -290,6 +449,18 @@ func (d *DB) scanWorkout(row *sql.Row) (*models.Workout, error) {
 	if notes.Valid {
 		w.Notes = &notes.String
 	}
+	if location.Valid {
+		w.Location = &location.String
+	}
+	if rpe.Valid {
+		n := int(rpe.Int64)
+		w.RPE = &n
+	}
+	if zone.Valid {
+		n := int(zone.Int64)
+		w.Zone = &n
+	}
+	w.Metadata = decodeMetadata(metadata)
 
 	return &w, nil
 }
@@ -301,10 +472,10 @@ func (d *DB) scanWorkouts(rows *sql.Rows) ([]*models.Workout, error) {
 	for rows.Next() {
 		var w models.Workout
 		var idStr, startedAt, createdAt string
-		var durationMinutes sql.NullInt64
-		var notes sql.NullString
+		var durationMinutes, rpe, zone sql.NullInt64
+		var notes, location, metadata sql.NullString
 
-		err := rows.Scan(&idStr, &w.WorkoutType, &startedAt, &durationMinutes, &notes, &createdAt)
+		err := rows.Scan(&idStr, &w.WorkoutType, &startedAt, &durationMinutes, &notes, &location, &w.Source, &metadata, &rpe, &zone, &createdAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan workout: %w", err)
 		}
@@ -319,6 +490,18 @@ func (d *DB) scanWorkouts(rows *sql.Rows) ([]*models.Workout, error) {
 		if notes.Valid {
 			w.Notes = &notes.String
 		}
+		if location.Valid {
+			w.Location = &location.String
+		}
+		if rpe.Valid {
+			n := int(rpe.Int64)
+			w.RPE = &n
+		}
+		if zone.Valid {
+			n := int(zone.Int64)
+			w.Zone = &n
+		}
+		w.Metadata = decodeMetadata(metadata)
 
 		workouts = append(workouts, &w)
 	}
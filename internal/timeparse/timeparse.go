@@ -0,0 +1,222 @@
+// ABOUTME: Parses entry timestamps, from ISO formats to "2 hours ago", "this morning", or "mon 7am".
+// ABOUTME: Dates, clock times, and relative phrases are read in the reference time's location.
+package timeparse
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// layouts are the absolute formats, tried before any phrase.
+var layouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// periods are the clock times words like "morning" stand for.
+var periods = map[string][2]int{
+	"morning":   {8, 0},
+	"noon":      {12, 0},
+	"afternoon": {14, 0},
+	"evening":   {19, 0},
+	"tonight":   {21, 0},
+	"night":     {22, 0},
+	"midnight":  {0, 0},
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var (
+	agoRe   = regexp.MustCompile(`^(\d+|an?|one)\s*(m|mins?|minutes?|h|hrs?|hours?|d|days?|w|wks?|weeks?)\s+ago$`)
+	clockRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+)
+
+// Parse reads s as a point in time. Besides RFC3339 and YYYY-MM-DD[ HH:MM]
+// it accepts:
+//
+//	now, 2 hours ago, 30 min ago, a day ago
+//	today, yesterday, monday, last fri       (current time of day)
+//	7am, 7:30 pm, 19:30, noon                (most recent, so 11pm at 1am is last night)
+//	this morning, afternoon, evening, tonight, last night
+//	yesterday 7am, mon 7am, today at noon, 9pm yesterday
+//
+// Anything without an offset is read in now's location.
+func Parse(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	phrase := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if t, ok := parsePhrase(phrase, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q (use YYYY-MM-DD HH:MM, or e.g. \"2 hours ago\", \"yesterday 7am\", \"mon 7am\")", s)
+}
+
+func parsePhrase(s string, now time.Time) (time.Time, bool) {
+	if s == "now" {
+		return now, true
+	}
+	if m := agoRe.FindStringSubmatch(s); m != nil {
+		return ago(m[1], m[2], now), true
+	}
+
+	words := slices.DeleteFunc(strings.Fields(s), func(w string) bool { return w == "at" })
+	if len(words) == 2 && words[0] == "last" && words[1] == "night" {
+		words = []string{"yesterday", "night"}
+	}
+	if len(words) > 0 && words[0] == "this" {
+		words = words[1:]
+	}
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+
+	// The day may come first ("mon 7am") or last ("7am mon")
+	day, match := parseDay(words, now)
+	if match.n > 0 {
+		words = words[match.n:]
+	} else if n := len(words); n > 1 {
+		if d, m := parseDay(words[n-1:], now); m.n > 0 {
+			day, match = d, m
+			words = words[:n-1]
+		}
+	}
+
+	if len(words) == 0 {
+		if match.n == 0 {
+			return time.Time{}, false
+		}
+		return atClock(day, now.Hour(), now.Minute()), true
+	}
+
+	hour, minute, isPeriod, ok := parseClock(strings.Join(words, " "))
+	if !ok {
+		return time.Time{}, false
+	}
+	if match.n > 0 {
+		t := atClock(day, hour, minute)
+		// "mon 7am" on a Monday before 7am means last week
+		if match.weekday && t.After(now) {
+			t = t.AddDate(0, 0, -7)
+		}
+		return t, true
+	}
+	t := atClock(now, hour, minute)
+	// A bare clock time in the future means yesterday; "this morning"
+	// stays today
+	if !isPeriod && t.After(now) {
+		t = t.AddDate(0, 0, -1)
+	}
+	return t, true
+}
+
+// dayMatch reports how many words named the day and whether it was a weekday.
+type dayMatch struct {
+	n       int
+	weekday bool
+}
+
+// parseDay reads a day from the start of words: today, yesterday, a
+// weekday, or "last" and a weekday.
+func parseDay(words []string, now time.Time) (time.Time, dayMatch) {
+	switch words[0] {
+	case "today":
+		return now, dayMatch{n: 1}
+	case "yesterday":
+		return now.AddDate(0, 0, -1), dayMatch{n: 1}
+	}
+	last := words[0] == "last" && len(words) > 1
+	name := words[0]
+	if last {
+		name = words[1]
+	}
+	wd, ok := weekdays[name]
+	if !ok {
+		return time.Time{}, dayMatch{}
+	}
+	back := (int(now.Weekday()) - int(wd) + 7) % 7
+	if last && back == 0 {
+		back = 7
+	}
+	n := 1
+	if last {
+		n = 2
+	}
+	return now.AddDate(0, 0, -back), dayMatch{n: n, weekday: true}
+}
+
+// parseClock reads "7am", "7:30 pm", "19:30", or a period like "morning",
+// reporting whether it was a period.
+func parseClock(s string) (hour, minute int, period, ok bool) {
+	if hm, found := periods[s]; found {
+		return hm[0], hm[1], true, true
+	}
+	m := clockRe.FindStringSubmatch(s)
+	if m == nil {
+		return 0, 0, false, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "":
+		// A bare number needs a colon to be a clock time
+		if m[2] == "" || hour > 23 {
+			return 0, 0, false, false
+		}
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if minute > 59 {
+		return 0, 0, false, false
+	}
+	return hour, minute, false, true
+}
+
+func ago(count, unit string, now time.Time) time.Time {
+	n := 1
+	if c, err := strconv.Atoi(count); err == nil {
+		n = c
+	}
+	switch unit[0] {
+	case 'm':
+		return now.Add(-time.Duration(n) * time.Minute)
+	case 'h':
+		return now.Add(-time.Duration(n) * time.Hour)
+	case 'd':
+		return now.AddDate(0, 0, -n)
+	default:
+		return now.AddDate(0, 0, -7*n)
+	}
+}
+
+func atClock(day time.Time, hour, minute int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
@@ -0,0 +1,64 @@
+// ABOUTME: Tests for timestamp parsing: absolute formats and relative phrases.
+// ABOUTME: Resolves phrases against a fixed Wednesday morning in a non-UTC zone.
+package timeparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	zone := time.FixedZone("CST", -6*3600)
+	// Wednesday 2025-03-19 10:30
+	now := time.Date(2025, 3, 19, 10, 30, 0, 0, zone)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 3, day, hour, minute, 0, 0, zone)
+	}
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2025-03-01 07:15", at(1, 7, 15)},
+		{"2025-03-01T07:15", at(1, 7, 15)},
+		{"2025-03-01", at(1, 0, 0)},
+		{"2025-03-01T07:15:00Z", time.Date(2025, 3, 1, 7, 15, 0, 0, time.UTC)},
+		{"now", now},
+		{"2 hours ago", at(19, 8, 30)},
+		{"30 min ago", at(19, 10, 0)},
+		{"an hour ago", at(19, 9, 30)},
+		{"3d ago", at(16, 10, 30)},
+		{"1 week ago", at(12, 10, 30)},
+		{"yesterday", at(18, 10, 30)},
+		{"Yesterday 7am", at(18, 7, 0)},
+		{"yesterday at 9:45 pm", at(18, 21, 45)},
+		{"9pm yesterday", at(18, 21, 0)},
+		{"today at noon", at(19, 12, 0)},
+		{"this morning", at(19, 8, 0)},
+		{"this evening", at(19, 19, 0)},
+		{"last night", at(18, 22, 0)},
+		{"7am", at(19, 7, 0)},
+		{"19:30", at(18, 19, 30)}, // later today, so yesterday
+		{"mon 7am", at(17, 7, 0)},
+		{"wed 7am", at(19, 7, 0)},
+		{"wed 11am", at(12, 11, 0)}, // still to come today, so last week
+		{"last wed", at(12, 10, 30)},
+		{"friday", at(14, 10, 30)},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in, now)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "31-01-2025", "not a date", "7", "13pm", "25:00", "next tuesday", "2 fortnights ago"} {
+		if got, err := Parse(bad, now); err == nil {
+			t.Errorf("Parse(%q) = %v; want error", bad, got)
+		}
+	}
+}
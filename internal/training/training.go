@@ -0,0 +1,161 @@
+// ABOUTME: Training volume and load analytics over workouts.
+// ABOUTME: Totals weekly minutes and distance per type and computes the acute:chronic workload ratio.
+package training
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/route"
+)
+
+// SpikeRatio is the acute:chronic workload ratio above which load has
+// risen faster than the body is used to.
+const SpikeRatio = 1.5
+
+// Volume is what a set of workouts adds up to.
+type Volume struct {
+	Workouts   int
+	Minutes    float64
+	DistanceKm float64
+}
+
+func (v *Volume) add(w *models.Workout) {
+	v.Workouts++
+	if w.DurationMinutes != nil {
+		v.Minutes += float64(*w.DurationMinutes)
+	}
+	if km, ok := DistanceKm(w); ok {
+		v.DistanceKm += km
+	}
+}
+
+// Week is the volume of the workouts started in the week beginning Start,
+// in total and by workout type.
+type Week struct {
+	Start  time.Time
+	Total  Volume
+	ByType map[string]*Volume
+}
+
+// WeekStart returns local midnight on the Monday of t's week.
+func WeekStart(t time.Time) time.Time {
+	t = t.Local()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.Local)
+}
+
+// Weekly totals the workouts into weeks consecutive weeks starting at
+// first, which should be a WeekStart. Workouts outside them are ignored.
+func Weekly(workouts []*models.Workout, first time.Time, weeks int) []Week {
+	out := make([]Week, weeks)
+	for i := range out {
+		out[i] = Week{Start: first.AddDate(0, 0, 7*i), ByType: make(map[string]*Volume)}
+	}
+	for _, w := range workouts {
+		// Rounded because a week spanning a DST change isn't 168 hours
+		i := int(math.Round(WeekStart(w.StartedAt).Sub(first).Hours()/24)) / 7
+		if w.StartedAt.Before(first) || i >= weeks {
+			continue
+		}
+		out[i].Total.add(w)
+		v := out[i].ByType[w.WorkoutType]
+		if v == nil {
+			v = &Volume{}
+			out[i].ByType[w.WorkoutType] = v
+		}
+		v.add(w)
+	}
+	return out
+}
+
+// Recent returns the volume of now's week so far and the average weekly
+// volume over the four full weeks before it. The average's Workouts counts
+// all four weeks.
+func Recent(workouts []*models.Workout, now time.Time) (thisWeek, average Volume) {
+	weeks := Weekly(workouts, WeekStart(now).AddDate(0, 0, -28), 5)
+	for _, w := range weeks[:4] {
+		average.Workouts += w.Total.Workouts
+		average.Minutes += w.Total.Minutes
+		average.DistanceKm += w.Total.DistanceKm
+	}
+	average.Minutes /= 4
+	average.DistanceKm /= 4
+	return weeks[4].Total, average
+}
+
+// Load compares the last week's training minutes with the weekly average
+// over the last four weeks.
+type Load struct {
+	Acute   float64 // minutes in the 7 days before the end
+	Chronic float64 // average weekly minutes over the 28 days before the end
+	Prior   float64 // minutes in the 21 days before the acute week
+}
+
+// Ratio is the acute:chronic workload ratio, or 0 without load before the
+// acute week. Chronic load includes the acute week, so training that all
+// falls inside it would otherwise read as a ratio of 4.
+func (l Load) Ratio() float64 {
+	if l.Prior == 0 || l.Chronic == 0 {
+		return 0
+	}
+	return l.Acute / l.Chronic
+}
+
+// Spike reports whether the ratio is above SpikeRatio.
+func (l Load) Spike() bool {
+	return l.Ratio() > SpikeRatio
+}
+
+// Workload computes the load as of end from workout durations.
+func Workload(workouts []*models.Workout, end time.Time) Load {
+	acuteStart := end.AddDate(0, 0, -7)
+	chronicStart := end.AddDate(0, 0, -28)
+	var l Load
+	for _, w := range workouts {
+		if w.DurationMinutes == nil || !w.StartedAt.Before(end) || w.StartedAt.Before(chronicStart) {
+			continue
+		}
+		minutes := float64(*w.DurationMinutes)
+		l.Chronic += minutes
+		if !w.StartedAt.Before(acuteStart) {
+			l.Acute += minutes
+		} else {
+			l.Prior += minutes
+		}
+	}
+	l.Chronic /= 4
+	return l
+}
+
+// kmPerUnit converts distance units to kilometers.
+var kmPerUnit = map[string]float64{
+	"":      1,
+	"km":    1,
+	"m":     0.001,
+	"mi":    1.609344,
+	"miles": 1.609344,
+	"yd":    0.0009144,
+}
+
+// DistanceKm returns the workout's distance metric in kilometers, and
+// false when it has none or its unit isn't a known distance unit.
+func DistanceKm(w *models.Workout) (float64, bool) {
+	for _, m := range w.Metrics {
+		if m.MetricName != route.DistanceMetric {
+			continue
+		}
+		unit := ""
+		if m.Unit != nil {
+			unit = strings.ToLower(strings.TrimSpace(*m.Unit))
+		}
+		factor, ok := kmPerUnit[unit]
+		if !ok {
+			return 0, false
+		}
+		return m.Value * factor, true
+	}
+	return 0, false
+}
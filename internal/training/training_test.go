@@ -0,0 +1,139 @@
+// ABOUTME: Tests for training volume and load analytics.
+// ABOUTME: Covers weekly totals, distance units, and the acute:chronic ratio.
+package training
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+	"github.com/harperreed/health/internal/route"
+)
+
+func workout(typ string, at time.Time, minutes int, distance float64, unit string) *models.Workout {
+	w := models.NewWorkout(typ).WithDuration(minutes)
+	w.StartedAt = at
+	if distance > 0 {
+		w.Metrics = append(w.Metrics, *models.NewWorkoutMetric(w.ID, route.DistanceMetric, distance, unit))
+	}
+	return w
+}
+
+func TestWeekStart(t *testing.T) {
+	thu := time.Date(2026, 10, 15, 18, 30, 0, 0, time.Local)
+	want := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	if got := WeekStart(thu); !got.Equal(want) {
+		t.Errorf("WeekStart(Thursday) = %v, want %v", got, want)
+	}
+	sun := time.Date(2026, 10, 18, 9, 0, 0, 0, time.Local)
+	if got := WeekStart(sun); !got.Equal(want) {
+		t.Errorf("WeekStart(Sunday) = %v, want %v", got, want)
+	}
+}
+
+func TestDistanceKm(t *testing.T) {
+	tests := []struct {
+		unit string
+		want float64
+		ok   bool
+	}{
+		{"km", 5, true},
+		{"mi", 5 * 1.609344, true},
+		{"m", 0.005, true},
+		{"laps", 0, false},
+	}
+	for _, tt := range tests {
+		w := workout("run", time.Now(), 30, 5, tt.unit)
+		got, ok := DistanceKm(w)
+		if ok != tt.ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("DistanceKm(5 %s) = %g, %v; want %g, %v", tt.unit, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := DistanceKm(models.NewWorkout("lift")); ok {
+		t.Error("expected no distance on a workout without one")
+	}
+}
+
+func TestWeekly(t *testing.T) {
+	first := time.Date(2026, 9, 28, 0, 0, 0, 0, time.Local)
+	workouts := []*models.Workout{
+		workout("run", first.AddDate(0, 0, 1), 30, 5, "km"),
+		workout("run", first.AddDate(0, 0, 3), 45, 8, "km"),
+		workout("lift", first.AddDate(0, 0, 4), 60, 0, ""),
+		workout("run", first.AddDate(0, 0, 8), 40, 6, "km"),
+		workout("run", first.AddDate(0, 0, -2), 90, 15, "km"),
+		workout("run", first.AddDate(0, 0, 15), 20, 3, "km"),
+	}
+
+	weeks := Weekly(workouts, first, 2)
+	if len(weeks) != 2 {
+		t.Fatalf("expected 2 weeks, got %d", len(weeks))
+	}
+	w0 := weeks[0]
+	if w0.Total.Workouts != 3 || w0.Total.Minutes != 135 || w0.Total.DistanceKm != 13 {
+		t.Errorf("week 1 total = %+v", w0.Total)
+	}
+	if run := w0.ByType["run"]; run == nil || run.Workouts != 2 || run.Minutes != 75 {
+		t.Errorf("week 1 runs = %+v", run)
+	}
+	if lift := w0.ByType["lift"]; lift == nil || lift.DistanceKm != 0 {
+		t.Errorf("week 1 lifts = %+v", lift)
+	}
+	if w1 := weeks[1]; w1.Total.Workouts != 1 || w1.Total.Minutes != 40 || !w1.Start.Equal(first.AddDate(0, 0, 7)) {
+		t.Errorf("week 2 = %+v", w1)
+	}
+}
+
+func TestRecent(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	start := WeekStart(now)
+	workouts := []*models.Workout{
+		workout("run", start.Add(time.Hour), 40, 8, "km"),
+		workout("run", start.AddDate(0, 0, -3), 60, 10, "km"),
+		workout("run", start.AddDate(0, 0, -20), 100, 10, "km"),
+		workout("run", start.AddDate(0, 0, -40), 500, 50, "km"),
+	}
+
+	thisWeek, average := Recent(workouts, now)
+	if thisWeek.Workouts != 1 || thisWeek.Minutes != 40 || thisWeek.DistanceKm != 8 {
+		t.Errorf("this week = %+v", thisWeek)
+	}
+	if average.Workouts != 2 || average.Minutes != 40 || average.DistanceKm != 5 {
+		t.Errorf("average = %+v, want 40 min and 5 km a week", average)
+	}
+}
+
+func TestWorkload(t *testing.T) {
+	end := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var workouts []*models.Workout
+	// 60 minutes a week for three weeks, then 180 in the last week
+	for week := 1; week <= 3; week++ {
+		workouts = append(workouts, workout("run", end.AddDate(0, 0, -7*week-2), 60, 0, ""))
+	}
+	workouts = append(workouts,
+		workout("run", end.AddDate(0, 0, -2), 90, 0, ""),
+		workout("run", end.AddDate(0, 0, -1), 90, 0, ""),
+		workout("run", end.AddDate(0, 0, -40), 500, 0, ""),
+		workout("run", end.Add(time.Hour), 500, 0, ""),
+	)
+
+	l := Workload(workouts, end)
+	if l.Acute != 180 || l.Chronic != 90 {
+		t.Errorf("Workload = %+v, want acute 180, chronic 90", l)
+	}
+	if l.Ratio() != 2 || !l.Spike() {
+		t.Errorf("Ratio() = %g, Spike() = %v; want 2, true", l.Ratio(), l.Spike())
+	}
+
+	if r := Workload(nil, end).Ratio(); r != 0 {
+		t.Errorf("Ratio() without workouts = %g, want 0", r)
+	}
+
+	// Just started: everything is in the last week, so there's nothing to
+	// compare it with
+	recent := workouts[3:5]
+	if l := Workload(recent, end); l.Ratio() != 0 || l.Spike() {
+		t.Errorf("Workload with only a week of training = %+v, ratio %g; want no ratio or spike", l, l.Ratio())
+	}
+}
@@ -0,0 +1,114 @@
+// ABOUTME: VO2max estimates from runs (pace and heart rate) or the max:resting heart rate ratio.
+// ABOUTME: Uses the Daniels-Gilbert oxygen cost of running and Swain's %HRR ≈ %VO2R relation.
+package training
+
+import (
+	"math"
+	"slices"
+
+	"github.com/harperreed/health/internal/hrzones"
+	"github.com/harperreed/health/internal/models"
+)
+
+// Methods behind a VO2max estimate.
+const (
+	MethodRuns    = "runs"
+	MethodHRRatio = "hr_ratio"
+)
+
+// minReserve is the smallest share of heart rate reserve a run must reach
+// to be extrapolated to VO2max; easy jogs say too little about the top end.
+const minReserve = 0.5
+
+// RunVO2Max estimates VO2max (ml/kg/min) from one run: the oxygen cost of
+// its average pace, scaled up by the share of heart rate reserve it was run
+// at. It reports false when the run is too easy, too short, or slower than
+// a jog to say much.
+func RunVO2Max(distanceKm, minutes, avgHR, maxHR, restHR float64) (float64, bool) {
+	if distanceKm <= 0 || minutes < 10 || avgHR <= 0 || maxHR <= restHR || restHR <= 0 {
+		return 0, false
+	}
+	v := distanceKm * 1000 / minutes // m/min
+	if v < 100 {
+		return 0, false
+	}
+	reserve := min((avgHR-restHR)/(maxHR-restHR), 1)
+	if reserve < minReserve {
+		return 0, false
+	}
+	cost := -4.60 + 0.182258*v + 0.000104*v*v
+	// Swain: %HRR tracks %VO2 reserve, the range above resting VO2 (3.5)
+	return 3.5 + (cost-3.5)/reserve, true
+}
+
+// HRRatioVO2Max is the Uth-Sørensen estimate, 15.3 × max / resting heart rate.
+func HRRatioVO2Max(maxHR, restHR float64) (float64, bool) {
+	if maxHR <= 0 || restHR <= 0 || maxHR <= restHR {
+		return 0, false
+	}
+	return 15.3 * maxHR / restHR, true
+}
+
+// RunEstimate is the VO2max estimated from one workout.
+type RunEstimate struct {
+	Workout *models.Workout
+	VO2Max  float64
+}
+
+// VO2MaxEstimate is a VO2max in ml/kg/min and how it was reached.
+type VO2MaxEstimate struct {
+	VO2Max float64
+	Method string        // MethodRuns or MethodHRRatio
+	Runs   []RunEstimate // the runs behind a MethodRuns estimate
+}
+
+// EstimateVO2Max takes the median of the estimates from runs that have a
+// distance, duration, and avg_hr metric, falling back to the heart rate
+// ratio when none qualify. It reports false when neither is possible.
+func EstimateVO2Max(runs []*models.Workout, maxHR, restHR float64) (VO2MaxEstimate, bool) {
+	var est VO2MaxEstimate
+	for _, w := range runs {
+		km, ok := DistanceKm(w)
+		avg := workoutMetric(w, hrzones.AvgMetric)
+		if !ok || w.DurationMinutes == nil || avg == 0 {
+			continue
+		}
+		if v, ok := RunVO2Max(km, float64(*w.DurationMinutes), avg, maxHR, restHR); ok {
+			est.Runs = append(est.Runs, RunEstimate{Workout: w, VO2Max: v})
+		}
+	}
+
+	if len(est.Runs) > 0 {
+		values := make([]float64, len(est.Runs))
+		for i, r := range est.Runs {
+			values[i] = r.VO2Max
+		}
+		slices.Sort(values)
+		mid := len(values) / 2
+		est.VO2Max = values[mid]
+		if len(values)%2 == 0 {
+			est.VO2Max = (values[mid-1] + values[mid]) / 2
+		}
+		est.VO2Max = math.Round(est.VO2Max*10) / 10
+		est.Method = MethodRuns
+		return est, true
+	}
+
+	v, ok := HRRatioVO2Max(maxHR, restHR)
+	if !ok {
+		return est, false
+	}
+	est.VO2Max = math.Round(v*10) / 10
+	est.Method = MethodHRRatio
+	return est, true
+}
+
+// workoutMetric returns the value of the named workout metric, or 0.
+func workoutMetric(w *models.Workout, name string) float64 {
+	for _, m := range w.Metrics {
+		if m.MetricName == name {
+			return m.Value
+		}
+	}
+	return 0
+}
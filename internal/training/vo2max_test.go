@@ -0,0 +1,88 @@
+// ABOUTME: Tests for VO2max estimation.
+// ABOUTME: Covers single-run estimates, the heart rate ratio fallback, and the median across runs.
+package training
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/hrzones"
+	"github.com/harperreed/health/internal/models"
+)
+
+func run(km float64, minutes int, avgHR float64) *models.Workout {
+	w := workout("run", time.Now(), minutes, km, "km")
+	if avgHR > 0 {
+		w.Metrics = append(w.Metrics, *models.NewWorkoutMetric(w.ID, hrzones.AvgMetric, avgHR, "bpm"))
+	}
+	return w
+}
+
+func TestRunVO2Max(t *testing.T) {
+	// 10 km in 50 min is 200 m/min, costing about 36.0 ml/kg/min, run at
+	// 75% of heart rate reserve
+	got, ok := RunVO2Max(10, 50, 155, 190, 50)
+	if !ok || math.Abs(got-46.85) > 0.05 {
+		t.Errorf("RunVO2Max = %.2f, %v; want about 46.85", got, ok)
+	}
+
+	tests := []struct {
+		name                            string
+		km, minutes, avg, maxHR, restHR float64
+	}{
+		{"too easy", 10, 50, 100, 190, 50},
+		{"too short", 2, 8, 160, 190, 50},
+		{"walking pace", 5, 60, 150, 190, 50},
+		{"no resting HR", 10, 50, 155, 190, 0},
+	}
+	for _, tt := range tests {
+		if v, ok := RunVO2Max(tt.km, tt.minutes, tt.avg, tt.maxHR, tt.restHR); ok {
+			t.Errorf("%s: RunVO2Max = %.1f, want no estimate", tt.name, v)
+		}
+	}
+
+	// Running above max HR is capped at the full reserve
+	capped, _ := RunVO2Max(10, 50, 200, 190, 50)
+	if math.Abs(capped-36.0) > 0.1 {
+		t.Errorf("RunVO2Max above max HR = %.2f, want the cost of pace", capped)
+	}
+}
+
+func TestHRRatioVO2Max(t *testing.T) {
+	if got, ok := HRRatioVO2Max(190, 50); !ok || math.Abs(got-58.14) > 1e-9 {
+		t.Errorf("HRRatioVO2Max(190, 50) = %g, %v; want 58.14", got, ok)
+	}
+	if _, ok := HRRatioVO2Max(50, 60); ok {
+		t.Error("expected no estimate with max below resting")
+	}
+}
+
+func TestEstimateVO2Max(t *testing.T) {
+	runs := []*models.Workout{
+		run(10, 50, 155), // 46.9
+		run(5, 25, 170),  // 41.4
+		run(8, 44, 150),  // 43.4, the median
+		run(10, 50, 0),   // no avg_hr
+		run(0, 40, 150),  // no distance
+	}
+	est, ok := EstimateVO2Max(runs, 190, 50)
+	if !ok || est.Method != MethodRuns {
+		t.Fatalf("EstimateVO2Max = %+v, %v; want a runs estimate", est, ok)
+	}
+	if len(est.Runs) != 3 {
+		t.Errorf("used %d runs, want 3", len(est.Runs))
+	}
+	if want := math.Round(est.Runs[2].VO2Max*10) / 10; est.VO2Max != want {
+		t.Errorf("VO2Max = %.1f, want the median %.1f", est.VO2Max, want)
+	}
+
+	est, ok = EstimateVO2Max(runs[3:], 190, 50)
+	if !ok || est.Method != MethodHRRatio || est.VO2Max != 58.1 {
+		t.Errorf("fallback = %+v, %v; want 58.1 from the heart rate ratio", est, ok)
+	}
+
+	if _, ok := EstimateVO2Max(nil, 0, 50); ok {
+		t.Error("expected no estimate without a max heart rate")
+	}
+}
@@ -0,0 +1,136 @@
+// ABOUTME: Opt-in, local-only usage stats: which commands run and how often metrics are logged.
+// ABOUTME: Events are appended to usage.jsonl in the data dir and never sent anywhere.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileName is the usage log inside the data directory.
+const FileName = "usage.jsonl"
+
+// Event is one command run.
+type Event struct {
+	Command string    `json:"command"`
+	At      time.Time `json:"at"`
+}
+
+// Path returns the usage log path for a data directory.
+func Path(dataDir string) string {
+	return filepath.Join(dataDir, FileName)
+}
+
+// Record appends an event to the log at path.
+func Record(path, command string, at time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("create usage dir: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("open usage log: %w", err)
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(Event{Command: command, At: at.UTC()})
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write usage log: %w", err)
+	}
+	return nil
+}
+
+// Load reads every event from the log at path. A missing log has no events;
+// lines that fail to parse are skipped.
+func Load(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open usage log: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.Command != "" {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read usage log: %w", err)
+	}
+	return events, nil
+}
+
+// CommandCount is how many times a command ran.
+type CommandCount struct {
+	Command string
+	Count   int
+}
+
+// TopCommands returns the n most-run commands, most first. Ties sort by name.
+func TopCommands(events []Event, n int) []CommandCount {
+	counts := make(map[string]int)
+	for _, e := range events {
+		counts[e.Command]++
+	}
+	top := make([]CommandCount, 0, len(counts))
+	for cmd, c := range counts {
+		top = append(top, CommandCount{Command: cmd, Count: c})
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Command < top[j].Command
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// WeekCount is how many things happened in the week starting on Start.
+type WeekCount struct {
+	Start time.Time
+	Count int
+}
+
+// Weekly buckets times into the last weeks weeks (Monday to Sunday, local
+// time), oldest first. The final bucket is the week containing now.
+func Weekly(times []time.Time, now time.Time, weeks int) []WeekCount {
+	current := weekStart(now)
+	buckets := make([]WeekCount, weeks)
+	for i := range buckets {
+		buckets[i].Start = current.AddDate(0, 0, -7*(weeks-1-i))
+	}
+	for _, t := range times {
+		start := weekStart(t.In(now.Location()))
+		for i := range buckets {
+			if buckets[i].Start.Equal(start) {
+				buckets[i].Count++
+				break
+			}
+		}
+	}
+	return buckets
+}
+
+// weekStart returns midnight on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
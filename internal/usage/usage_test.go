@@ -0,0 +1,83 @@
+// ABOUTME: Tests for the local usage log and its summaries.
+// ABOUTME: Covers append/load round trips, command ranking, and weekly buckets.
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAndLoad(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "health"))
+
+	events, err := Load(path)
+	if err != nil || events != nil {
+		t.Fatalf("Load(missing) = %v, %v; want no events", events, err)
+	}
+
+	at := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+	for _, cmd := range []string{"add", "list", "add"} {
+		if err := Record(path, cmd, at); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	// A damaged line must not hide the rest of the log
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("{not json\n")
+	f.Close()
+
+	events, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3", len(events))
+	}
+	if events[1].Command != "list" || !events[1].At.Equal(at) {
+		t.Errorf("events[1] = %+v", events[1])
+	}
+}
+
+func TestTopCommands(t *testing.T) {
+	var events []Event
+	for _, cmd := range []string{"list", "add", "workout add", "add", "list", "add"} {
+		events = append(events, Event{Command: cmd})
+	}
+
+	top := TopCommands(events, 2)
+	if len(top) != 2 {
+		t.Fatalf("got %d commands, want 2", len(top))
+	}
+	if top[0] != (CommandCount{"add", 3}) || top[1] != (CommandCount{"list", 2}) {
+		t.Errorf("TopCommands = %+v", top)
+	}
+}
+
+func TestWeekly(t *testing.T) {
+	// Wednesday
+	now := time.Date(2025, 6, 11, 15, 0, 0, 0, time.UTC)
+	times := []time.Time{
+		time.Date(2025, 6, 9, 0, 0, 0, 0, time.UTC),   // this Monday
+		time.Date(2025, 6, 11, 8, 0, 0, 0, time.UTC),  // today
+		time.Date(2025, 6, 8, 23, 0, 0, 0, time.UTC),  // last Sunday
+		time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),  // too old
+		time.Date(2025, 6, 12, 12, 0, 0, 0, time.UTC), // tomorrow, same week
+	}
+
+	weeks := Weekly(times, now, 3)
+	if len(weeks) != 3 {
+		t.Fatalf("got %d weeks, want 3", len(weeks))
+	}
+	wantStarts := []string{"2025-05-26", "2025-06-02", "2025-06-09"}
+	wantCounts := []int{0, 1, 3}
+	for i, w := range weeks {
+		if got := w.Start.Format("2006-01-02"); got != wantStarts[i] {
+			t.Errorf("week %d starts %s, want %s", i, got, wantStarts[i])
+		}
+		if w.Count != wantCounts[i] {
+			t.Errorf("week %d count = %d, want %d", i, w.Count, wantCounts[i])
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// ABOUTME: Client for the Withings API: OAuth 2.0 token exchange/refresh and body measures.
+// ABOUTME: Converts weigh-ins into weight and body_fat metrics, following result pages.
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+// DefaultBaseURL is the Withings API.
+const DefaultBaseURL = "https://wbsapi.withings.net"
+
+// AuthorizeURL is where the user grants access.
+const AuthorizeURL = "https://account.withings.com/oauth2_user/authorize2"
+
+// Scope is the permission needed to read body measures.
+const Scope = "user.metrics"
+
+// MetadataGroupID is the metadata key holding a metric's Withings measure
+// group, which identifies the weigh-in.
+const MetadataGroupID = "withings_grpid"
+
+// Measure types in getmeas responses.
+const (
+	measWeight   = 1
+	measFatRatio = 6
+)
+
+// Token is an OAuth 2.0 grant. Withings rotates the refresh token on every
+// refresh, so the whole Token must be saved again afterwards.
+type Token struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	UserID       string    `json:"user_id,omitempty"`
+}
+
+// Expired reports whether the access token is expired at now, or will be
+// within a minute.
+func (t *Token) Expired(now time.Time) bool {
+	return !now.Add(time.Minute).Before(t.ExpiresAt)
+}
+
+// Client talks to the Withings API for one app.
+type Client struct {
+	BaseURL      string
+	ClientID     string
+	ClientSecret string
+	HTTPClient   *http.Client
+}
+
+// NewClient creates a Client for the app with the given credentials.
+func NewClient(clientID, clientSecret string) *Client {
+	return &Client{
+		BaseURL:      DefaultBaseURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// AuthCodeURL is the page where the user grants access; Withings then
+// redirects to redirectURI with a code for Exchange.
+func (c *Client) AuthCodeURL(redirectURI, state string) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.ClientID},
+		"scope":         {Scope},
+		"redirect_uri":  {redirectURI},
+		"state":         {state},
+	}
+	return AuthorizeURL + "?" + q.Encode()
+}
+
+// Exchange trades an authorization code for a Token.
+func (c *Client) Exchange(ctx context.Context, code, redirectURI string) (*Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+	})
+}
+
+// Refresh trades a Token's refresh token for a new Token.
+func (c *Client) Refresh(ctx context.Context, t *Token) (*Token, error) {
+	return c.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {t.RefreshToken},
+	})
+}
+
+func (c *Client) requestToken(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("action", "requesttoken")
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	var body struct {
+		UserID       json.Number `json:"userid"`
+		AccessToken  string      `json:"access_token"`
+		RefreshToken string      `json:"refresh_token"`
+		ExpiresIn    int         `json:"expires_in"`
+	}
+	now := time.Now()
+	if err := c.post(ctx, "/v2/oauth2", "", form, &body); err != nil {
+		return nil, err
+	}
+	return &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		ExpiresAt:    now.Add(time.Duration(body.ExpiresIn) * time.Second),
+		UserID:       body.UserID.String(),
+	}, nil
+}
+
+// Query selects measures. A zero Since and LastUpdate fetch the whole
+// history.
+type Query struct {
+	// Since limits results to weigh-ins at or after it.
+	Since time.Time
+	// LastUpdate limits results to weigh-ins added or changed after it,
+	// for incremental syncs.
+	LastUpdate time.Time
+}
+
+// Result is what one Measures call returns.
+type Result struct {
+	Metrics []*models.Metric
+	// UpdateTime is the server time of the query; pass it as the next
+	// Query's LastUpdate.
+	UpdateTime time.Time
+}
+
+// Measures fetches weigh-ins as weight metrics, plus body_fat when the
+// scale measured it, recorded in loc. Deleted measures and ones the user
+// marked as not theirs are left out.
+func (c *Client) Measures(ctx context.Context, token string, q Query, loc *time.Location) (*Result, error) {
+	form := url.Values{
+		"action":    {"getmeas"},
+		"meastypes": {fmt.Sprintf("%d,%d", measWeight, measFatRatio)},
+		"category":  {"1"}, // real measures, not goals
+	}
+	if !q.Since.IsZero() {
+		form.Set("startdate", strconv.FormatInt(q.Since.Unix(), 10))
+		form.Set("enddate", strconv.FormatInt(time.Now().Unix(), 10))
+	}
+	if !q.LastUpdate.IsZero() {
+		form.Set("lastupdate", strconv.FormatInt(q.LastUpdate.Unix(), 10))
+	}
+
+	result := &Result{}
+	for {
+		var body struct {
+			UpdateTime int64 `json:"updatetime"`
+			Groups     []struct {
+				GrpID    int64 `json:"grpid"`
+				Date     int64 `json:"date"`
+				Attrib   int   `json:"attrib"`
+				Measures []struct {
+					Value int64 `json:"value"`
+					Type  int   `json:"type"`
+					Unit  int   `json:"unit"`
+				} `json:"measures"`
+			} `json:"measuregrps"`
+			More   int `json:"more"`
+			Offset int `json:"offset"`
+		}
+		if err := c.post(ctx, "/measure", token, form, &body); err != nil {
+			return nil, err
+		}
+		if result.UpdateTime.IsZero() {
+			result.UpdateTime = time.Unix(body.UpdateTime, 0)
+		}
+		for _, g := range body.Groups {
+			// Attributions 1 and 4 are measures the user assigned
+			// elsewhere or marked as not theirs
+			if g.Attrib == 1 || g.Attrib == 4 {
+				continue
+			}
+			at := time.Unix(g.Date, 0).In(loc)
+			grpID := strconv.FormatInt(g.GrpID, 10)
+			for _, m := range g.Measures {
+				v := float64(m.Value) * math.Pow10(m.Unit)
+				switch m.Type {
+				case measWeight:
+					result.Metrics = append(result.Metrics, newMetric(models.MetricWeight, v, at, grpID))
+				case measFatRatio:
+					result.Metrics = append(result.Metrics, newMetric(models.MetricBodyFat, v, at, grpID))
+				}
+			}
+		}
+		if body.More == 0 {
+			return result, nil
+		}
+		form.Set("offset", strconv.Itoa(body.Offset))
+	}
+}
+
+// post calls an API action. Withings answers HTTP 200 with a status code
+// in the body; only status 0 carries a result.
+func (c *Client) post(ctx context.Context, path, token string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.BaseURL, "/")+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("withings: %w", err)
+	}
+	defer resp.Body.Close()
+	slog.DebugContext(ctx, "withings post", "path", path, "action", form.Get("action"), "status", resp.StatusCode, "duration", time.Since(start))
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("withings: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	var envelope struct {
+		Status int             `json:"status"`
+		Error  string          `json:"error"`
+		Body   json.RawMessage `json:"body"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("withings: decode %s: %w", path, err)
+	}
+	switch {
+	case envelope.Status == 401:
+		return fmt.Errorf("withings: token rejected (run 'health import withings auth' again?)")
+	case envelope.Status == 601:
+		return fmt.Errorf("withings: rate limited, try again in a minute")
+	case envelope.Status != 0:
+		return fmt.Errorf("withings: status %d: %s", envelope.Status, envelope.Error)
+	}
+	if err := json.Unmarshal(envelope.Body, out); err != nil {
+		return fmt.Errorf("withings: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func newMetric(mt models.MetricType, value float64, at time.Time, grpID string) *models.Metric {
+	// Values come as integers times a power of ten; round off float noise
+	value = math.Round(value*1000) / 1000
+	return models.NewMetric(mt, value).WithRecordedAt(at).WithSource(models.SourceWithings).WithMetadata(MetadataGroupID, grpID)
+}
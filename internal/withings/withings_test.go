@@ -0,0 +1,120 @@
+// ABOUTME: Tests for the Withings client against a fake API.
+// ABOUTME: Checks token exchange and refresh, measure paging and scaling, and status errors.
+package withings
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harperreed/health/internal/models"
+)
+
+func fakeWithings(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/v2/oauth2":
+			if r.Form.Get("client_id") != "app" || r.Form.Get("client_secret") != "shh" {
+				w.Write([]byte(`{"status":503,"error":"Invalid Params: invalid client_id"}`))
+				return
+			}
+			switch r.Form.Get("grant_type") {
+			case "authorization_code":
+				w.Write([]byte(`{"status":0,"body":{"userid":"42","access_token":"a1","refresh_token":"r1","expires_in":10800}}`))
+			case "refresh_token":
+				w.Write([]byte(`{"status":0,"body":{"userid":42,"access_token":"a2","refresh_token":"r2","expires_in":10800}}`))
+			}
+		case "/measure":
+			if r.Header.Get("Authorization") != "Bearer a1" {
+				w.Write([]byte(`{"status":401,"error":"XRequestID: Not provided invalid_token"}`))
+				return
+			}
+			if r.Form.Get("lastupdate") != "1740000000" {
+				t.Errorf("lastupdate = %q", r.Form.Get("lastupdate"))
+			}
+			if r.Form.Get("offset") == "" {
+				w.Write([]byte(`{"status":0,"body":{"updatetime":1741000000,"measuregrps":[
+					{"grpid":501,"date":1740900000,"attrib":0,"measures":[{"value":82450,"type":1,"unit":-3},{"value":1850,"type":6,"unit":-2}]},
+					{"date":1740800000,"attrib":4,"measures":[{"value":61000,"type":1,"unit":-3}]}],"more":1,"offset":2}}`))
+				return
+			}
+			w.Write([]byte(`{"status":0,"body":{"updatetime":1741000001,"measuregrps":[
+				{"grpid":499,"date":1740700000,"attrib":2,"measures":[{"value":830,"type":1,"unit":-1}]}],"more":0}}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestTokens(t *testing.T) {
+	c := NewClient("app", "shh")
+	c.BaseURL = fakeWithings(t).URL
+
+	if u := c.AuthCodeURL("http://localhost/cb", "xyz"); !strings.HasPrefix(u, AuthorizeURL+"?") ||
+		!strings.Contains(u, "scope=user.metrics") || !strings.Contains(u, "client_id=app") {
+		t.Errorf("AuthCodeURL = %s", u)
+	}
+
+	tok, err := c.Exchange(t.Context(), "code", "http://localhost/cb")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if tok.AccessToken != "a1" || tok.RefreshToken != "r1" || tok.UserID != "42" || tok.Expired(time.Now()) {
+		t.Errorf("Exchange = %+v", tok)
+	}
+	if !tok.Expired(time.Now().Add(3 * time.Hour)) {
+		t.Error("expected the token to expire after three hours")
+	}
+
+	tok, err = c.Refresh(t.Context(), tok)
+	if err != nil || tok.AccessToken != "a2" || tok.RefreshToken != "r2" || tok.UserID != "42" {
+		t.Errorf("Refresh = %+v, %v", tok, err)
+	}
+
+	c.ClientSecret = "wrong"
+	if _, err := c.Refresh(t.Context(), tok); err == nil || !strings.Contains(err.Error(), "invalid client_id") {
+		t.Errorf("expected the API error, got %v", err)
+	}
+}
+
+func TestMeasures(t *testing.T) {
+	c := NewClient("app", "shh")
+	c.BaseURL = fakeWithings(t).URL
+	loc := time.FixedZone("CET", 3600)
+
+	res, err := c.Measures(t.Context(), "a1", Query{LastUpdate: time.Unix(1740000000, 0)}, loc)
+	if err != nil {
+		t.Fatalf("Measures failed: %v", err)
+	}
+	if !res.UpdateTime.Equal(time.Unix(1741000000, 0)) {
+		t.Errorf("UpdateTime = %v", res.UpdateTime)
+	}
+	want := []struct {
+		mt    models.MetricType
+		value float64
+		at    int64
+		grpID string
+	}{
+		{models.MetricWeight, 82.45, 1740900000, "501"},
+		{models.MetricBodyFat, 18.5, 1740900000, "501"},
+		{models.MetricWeight, 83, 1740700000, "499"},
+	}
+	if len(res.Metrics) != len(want) {
+		t.Fatalf("got %d metrics, want %d (the unattributed weigh-in left out)", len(res.Metrics), len(want))
+	}
+	for i, w := range want {
+		m := res.Metrics[i]
+		if m.MetricType != w.mt || m.Value != w.value || m.RecordedAt.Unix() != w.at ||
+			m.RecordedAt.Location() != loc || m.Source != models.SourceWithings || m.Notes != nil || m.Metadata[MetadataGroupID] != w.grpID {
+			t.Errorf("metric %d = %s %v at %v, want %s %v", i, m.MetricType, m.Value, m.RecordedAt, w.mt, w.value)
+		}
+	}
+
+	if _, err := c.Measures(t.Context(), "expired", Query{}, loc); err == nil || !strings.Contains(err.Error(), "token rejected") {
+		t.Errorf("expected a rejected token, got %v", err)
+	}
+}
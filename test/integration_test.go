@@ -91,7 +91,7 @@ func TestFullWorkflow(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to export json: %v\n%s", err, output)
 	}
-	if !strings.Contains(output, "\"version\": \"1.0\"") {
+	if !strings.Contains(output, "\"version\": \"1.5\"") {
 		t.Errorf("Expected version in JSON export, got: %s", output)
 	}
 